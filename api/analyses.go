@@ -0,0 +1,228 @@
+package api
+
+import (
+    "encoding/json"
+    "fmt"
+    "net/http"
+    "sort"
+    "strings"
+    "sync"
+    "time"
+    "anondd/utils/events"
+    "anondd/utils/trace"
+)
+
+const (
+    // analysisFeedBuffer is how many events a slow stream client may fall
+    // behind before it is disconnected to catch up on reconnect
+    analysisFeedBuffer = 256
+
+    // analysisKeepAlive is how often an idle stream sends a comment so
+    // proxies don't close it
+    analysisKeepAlive = 15 * time.Second
+)
+
+// LiveAnalysis is an LLM analysis being written, with its text so far
+type LiveAnalysis struct {
+    ID        string    `json:"id"`
+    PromptKey string    `json:"prompt_key"`
+    Command   string    `json:"command,omitempty"`
+    StartedAt time.Time `json:"started_at"`
+    Text      string    `json:"text"`
+}
+
+// analysisEvent is one server-sent event of the analysis stream
+type analysisEvent struct {
+    name string
+    data interface{}
+}
+
+// analysisFeed follows streamed completions on the event bus, keeping the
+// text of those in progress for clients that join part way and fanning each
+// piece out to the connected stream clients
+type analysisFeed struct {
+    mu     sync.Mutex
+    active map[string]*LiveAnalysis
+    subs   map[chan analysisEvent]struct{}
+}
+
+func newAnalysisFeed(bus *events.Bus) *analysisFeed {
+    feed := &analysisFeed{
+        active: make(map[string]*LiveAnalysis),
+        subs:   make(map[chan analysisEvent]struct{}),
+    }
+    events.Subscribe(bus, feed.started)
+    events.Subscribe(bus, feed.delta)
+    events.Subscribe(bus, feed.finished)
+    return feed
+}
+
+func (f *analysisFeed) started(e events.LLMStreamStarted) {
+    f.mu.Lock()
+    defer f.mu.Unlock()
+    analysis := &LiveAnalysis{ID: e.ID, PromptKey: e.PromptKey, Command: e.Command, StartedAt: e.StartedAt}
+    f.active[e.ID] = analysis
+    f.broadcast(analysisEvent{name: "started", data: *analysis})
+}
+
+func (f *analysisFeed) delta(e events.LLMStreamDelta) {
+    f.mu.Lock()
+    defer f.mu.Unlock()
+    analysis, ok := f.active[e.ID]
+    if !ok {
+        return
+    }
+    analysis.Text += e.Text
+    f.broadcast(analysisEvent{name: "delta", data: map[string]string{"id": e.ID, "text": e.Text}})
+}
+
+func (f *analysisFeed) finished(e events.LLMStreamFinished) {
+    f.mu.Lock()
+    defer f.mu.Unlock()
+    if _, ok := f.active[e.ID]; !ok {
+        return
+    }
+    delete(f.active, e.ID)
+    data := map[string]string{"id": e.ID}
+    if e.Err != nil {
+        data["error"] = e.Err.Error()
+    }
+    f.broadcast(analysisEvent{name: "finished", data: data})
+}
+
+// broadcast sends event to every client, dropping those too far behind;
+// callers must hold mu
+func (f *analysisFeed) broadcast(event analysisEvent) {
+    for sub := range f.subs {
+        select {
+        case sub <- event:
+        default:
+            delete(f.subs, sub)
+            close(sub)
+        }
+    }
+}
+
+// snapshot returns the analyses in progress, oldest first
+func (f *analysisFeed) snapshot() []LiveAnalysis {
+    f.mu.Lock()
+    defer f.mu.Unlock()
+    return f.snapshotLocked()
+}
+
+func (f *analysisFeed) snapshotLocked() []LiveAnalysis {
+    analyses := make([]LiveAnalysis, 0, len(f.active))
+    for _, analysis := range f.active {
+        analyses = append(analyses, *analysis)
+    }
+    sort.Slice(analyses, func(i, j int) bool { return analyses[i].StartedAt.Before(analyses[j].StartedAt) })
+    return analyses
+}
+
+// subscribe returns the analyses in progress and a channel of everything
+// after them, closed if the client falls too far behind
+func (f *analysisFeed) subscribe() ([]LiveAnalysis, chan analysisEvent, func()) {
+    f.mu.Lock()
+    defer f.mu.Unlock()
+    sub := make(chan analysisEvent, analysisFeedBuffer)
+    f.subs[sub] = struct{}{}
+    unsubscribe := func() {
+        f.mu.Lock()
+        defer f.mu.Unlock()
+        if _, ok := f.subs[sub]; ok {
+            delete(f.subs, sub)
+            close(sub)
+        }
+    }
+    return f.snapshotLocked(), sub, unsubscribe
+}
+
+// SetEvents enables the live analysis endpoints, following streamed LLM
+// completions published on bus
+func (s *APIServer) SetEvents(bus *events.Bus) {
+    s.analyses = newAnalysisFeed(bus)
+}
+
+// handleGetAnalyses returns the LLM analyses being written, with their text so far
+func (s *APIServer) handleGetAnalyses(w http.ResponseWriter, r *http.Request) {
+    if !requireAdmin(w, r) {
+        return
+    }
+    if s.analyses == nil {
+        writeError(w, http.StatusNotFound, CodeNotFound, "Live analyses are not enabled", nil)
+        return
+    }
+    writeData(w, r, s.analyses.snapshot())
+}
+
+// handleStreamAnalyses streams LLM analyses as they are written, as
+// server-sent events: "started" with the text so far, then "delta" with each
+// new piece and "finished" at the end. Analyses already in progress are sent
+// as started when the client connects, so reconnecting catches up.
+func (s *APIServer) handleStreamAnalyses(w http.ResponseWriter, r *http.Request) {
+    if !requireAdmin(w, r) {
+        return
+    }
+    if s.analyses == nil {
+        writeError(w, http.StatusNotFound, CodeNotFound, "Live analyses are not enabled", nil)
+        return
+    }
+    controller := http.NewResponseController(w)
+    // The stream outlives the server's write timeout
+    if err := controller.SetWriteDeadline(time.Time{}); err != nil {
+        trace.Logf(r.Context(), s.logger, "Unable to lift write deadline for analysis stream: %v", err)
+    }
+
+    active, feed, unsubscribe := s.analyses.subscribe()
+    defer unsubscribe()
+
+    w.Header().Set("Content-Type", "text/event-stream")
+    w.Header().Set("Cache-Control", "no-cache")
+    w.Header().Set("X-Accel-Buffering", "no")
+    w.WriteHeader(http.StatusOK)
+    trace.Logf(r.Context(), s.logger, "Streaming live analyses, %d in progress", len(active))
+
+    for _, analysis := range active {
+        if writeServerEvent(w, analysisEvent{name: "started", data: analysis}) != nil {
+            return
+        }
+    }
+    if controller.Flush() != nil {
+        return
+    }
+
+    keepAlive := time.NewTicker(analysisKeepAlive)
+    defer keepAlive.Stop()
+    for {
+        select {
+        case event, ok := <-feed:
+            if !ok {
+                trace.Logf(r.Context(), s.logger, "Analysis stream client fell behind, disconnecting")
+                return
+            }
+            if writeServerEvent(w, event) != nil {
+                return
+            }
+        case <-keepAlive.C:
+            if _, err := fmt.Fprint(w, ": keep-alive\n\n"); err != nil {
+                return
+            }
+        case <-r.Context().Done():
+            return
+        }
+        if controller.Flush() != nil {
+            return
+        }
+    }
+}
+
+// writeServerEvent writes one server-sent event with a JSON payload
+func writeServerEvent(w http.ResponseWriter, event analysisEvent) error {
+    data, err := json.Marshal(event.data)
+    if err != nil {
+        return err
+    }
+    // JSON has no raw newlines, so the payload fits one data line
+    _, err = fmt.Fprintf(w, "event: %s\ndata: %s\n\n", event.name, strings.TrimSpace(string(data)))
+    return err
+}
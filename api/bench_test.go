@@ -0,0 +1,63 @@
+package api
+
+import (
+    "io"
+    "log"
+    "net/http"
+    "net/http/httptest"
+    "testing"
+    "anondd/utils/perf"
+    "anondd/utils/storage"
+)
+
+// benchAgents is how many synthetic agents the benchmarks serve
+const benchAgents = 2000
+
+// newBenchServer serves the API over a freshly seeded data directory
+func newBenchServer(b *testing.B) (*APIServer, []string) {
+    b.Helper()
+    logger := log.New(io.Discard, "", 0)
+    store := storage.NewAgentStore(b.TempDir(), logger)
+    ids, err := perf.Seed(store, benchAgents)
+    if err != nil {
+        b.Fatalf("Failed to seed agents: %v", err)
+    }
+    server := NewAPIServer(store, DefaultServerConfig(), logger)
+    server.SetupRoutes()
+    return server, ids
+}
+
+// benchmarkGet sends GET requests for the paths in turn through the
+// server's handler
+func benchmarkGet(b *testing.B, server *APIServer, paths []string) {
+    b.Helper()
+    handler := server.Handler()
+    b.ReportAllocs()
+    b.ResetTimer()
+    for i := 0; i < b.N; i++ {
+        rec := httptest.NewRecorder()
+        handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, paths[i%len(paths)], nil))
+        if rec.Code != http.StatusOK {
+            b.Fatalf("GET %s: HTTP %d", paths[i%len(paths)], rec.Code)
+        }
+    }
+}
+
+func BenchmarkGetAgents(b *testing.B) {
+    server, _ := newBenchServer(b)
+    benchmarkGet(b, server, []string{"/api/agents"})
+}
+
+func BenchmarkGetAgent(b *testing.B) {
+    server, ids := newBenchServer(b)
+    paths := make([]string, 0, len(ids))
+    for _, id := range ids {
+        paths = append(paths, "/api/agents/"+id)
+    }
+    benchmarkGet(b, server, paths)
+}
+
+func BenchmarkGetIndex(b *testing.B) {
+    server, _ := newBenchServer(b)
+    benchmarkGet(b, server, []string{"/api/index"})
+}
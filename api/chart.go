@@ -0,0 +1,70 @@
+package api
+
+import (
+    "errors"
+    "net/http"
+    "strings"
+    "time"
+    "anondd/utils/chart"
+    "anondd/utils/storage"
+    "anondd/utils/trace"
+    "github.com/gorilla/mux"
+)
+
+// handleGetAgentChart renders ?metric= (price, holders, mindshare, mcap or
+// volume_24h) over ?range= (24h, 7d or 30d) from the agent's history as a PNG
+func (s *APIServer) handleGetAgentChart(w http.ResponseWriter, r *http.Request) {
+    id := mux.Vars(r)["id"]
+    metric := r.URL.Query().Get("metric")
+    if metric == "" {
+        metric = chart.DefaultMetric
+    }
+    rangeName := r.URL.Query().Get("range")
+    if rangeName == "" {
+        rangeName = chart.DefaultRange
+    }
+    trace.Logf(r.Context(), s.logger, "Received request to chart %s over %s for agent %s", metric, rangeName, id)
+
+    if !chart.ValidMetric(metric) {
+        writeError(w, http.StatusBadRequest, CodeBadRequest, "Unsupported metric, use "+strings.Join(chart.Metrics, ", "),
+            map[string]string{"metric": metric})
+        return
+    }
+    window, ok := chart.Ranges[rangeName]
+    if !ok {
+        writeError(w, http.StatusBadRequest, CodeBadRequest, "Unsupported range, use 24h, 7d or 30d",
+            map[string]string{"range": rangeName})
+        return
+    }
+
+    agent, err := s.store.GetAgentContext(r.Context(), id)
+    if err == nil && !tenantFrom(r).canSeeAgent(agent.Status) {
+        err = storage.ErrNotFound
+    }
+    if err != nil {
+        writeStoreError(w, err, "Agent not found")
+        trace.Logf(r.Context(), s.logger, "Error getting agent %s: %v", id, err)
+        return
+    }
+    history, err := s.store.GetHistory(agent.SourceID)
+    if err != nil {
+        writeStoreError(w, err, "Failed to retrieve agent history")
+        trace.Logf(r.Context(), s.logger, "Error getting history for agent %s: %v", id, err)
+        return
+    }
+
+    image, err := chart.Render(chart.Series(history, metric, window, time.Now()))
+    if errors.Is(err, chart.ErrNotEnoughData) {
+        writeError(w, http.StatusNotFound, CodeNotFound, err.Error(), nil)
+        return
+    }
+    if err != nil {
+        writeError(w, http.StatusInternalServerError, CodeInternal, "Failed to render chart", nil)
+        trace.Logf(r.Context(), s.logger, "Error rendering chart for agent %s: %v", id, err)
+        return
+    }
+
+    w.Header().Set("Content-Type", "image/png")
+    w.Header().Set("Cache-Control", "public, max-age=300")
+    w.Write(image)
+}
@@ -0,0 +1,83 @@
+package api
+
+import (
+    "encoding/json"
+    "net/http"
+    "strings"
+    "anondd/llm"
+    "anondd/utils/models"
+    "anondd/utils/storage"
+    "anondd/utils/trace"
+)
+
+// maxCompareBytes caps the size of a comparison request body
+const maxCompareBytes = 16 << 10
+
+type compareRequest struct {
+    IDs        []string `json:"ids"`
+    Commentary bool     `json:"commentary"` // Ask the LLM for a short take on the matrix
+}
+
+// SetLLM enables LLM commentary on comparisons
+func (s *APIServer) SetLLM(client *llm.OpenRouterClient) {
+    s.llm = client
+}
+
+// handleCompareAgents returns the metric matrix of 2 to 10 agents, with
+// each metric's values aligned by agent and ranked, plus optional commentary
+func (s *APIServer) handleCompareAgents(w http.ResponseWriter, r *http.Request) {
+    var req compareRequest
+    if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxCompareBytes)).Decode(&req); err != nil {
+        writeError(w, http.StatusBadRequest, CodeBadRequest, "Invalid compare request body", nil)
+        return
+    }
+
+    // Drop blanks and repeats, keeping the order given
+    seen := make(map[string]bool)
+    var ids []string
+    for _, id := range req.IDs {
+        id = strings.TrimSpace(id)
+        if id != "" && !seen[id] {
+            seen[id] = true
+            ids = append(ids, id)
+        }
+    }
+    if len(ids) < 2 || len(ids) > models.MaxCompareAgents {
+        writeError(w, http.StatusBadRequest, CodeBadRequest, "Compare between 2 and 10 distinct agent IDs",
+            map[string]int{"ids": len(ids)})
+        return
+    }
+    trace.Logf(r.Context(), s.logger, "Received request to compare %d agents", len(ids))
+
+    tenant := tenantFrom(r)
+    agents := make([]*models.Agent, 0, len(ids))
+    for _, id := range ids {
+        agent, err := s.store.GetAgentContext(r.Context(), id)
+        if err == nil && !tenant.canSeeAgent(agent.Status) {
+            err = storage.ErrNotFound
+        }
+        if err != nil {
+            writeError(w, http.StatusNotFound, CodeNotFound, "Agent not found", map[string]string{"id": id})
+            trace.Logf(r.Context(), s.logger, "Error getting agent %s to compare: %v", id, err)
+            return
+        }
+        agents = append(agents, agent)
+    }
+
+    matrix := models.CompareAgents(agents)
+    if req.Commentary {
+        if s.llm == nil {
+            writeError(w, http.StatusNotFound, CodeNotFound, "Commentary is not configured", nil)
+            return
+        }
+        ctx := llm.WithCommand(r.Context(), "api:compare")
+        commentary, err := s.llm.GetResponse(ctx, "compare_agents", matrix.Table())
+        if err != nil {
+            // The matrix is still useful without the commentary
+            trace.Logf(r.Context(), s.logger, "Error getting comparison commentary: %v", err)
+        } else {
+            matrix.Commentary = s.llm.PostProcess(ctx, "compare_agents", "", commentary)
+        }
+    }
+    writeData(w, r, matrix)
+}
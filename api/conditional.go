@@ -0,0 +1,49 @@
+package api
+
+import (
+    "fmt"
+    "net/http"
+    "strings"
+    "time"
+)
+
+// weakETag derives an ETag from a resource name and its modification time
+func weakETag(resource string, modTime time.Time) string {
+    return fmt.Sprintf(`W/"%s-%x"`, resource, modTime.UnixNano())
+}
+
+// writeNotModified sets ETag and Last-Modified on the response and, if the
+// request's If-None-Match or If-Modified-Since shows the client is current,
+// writes a 304 and returns true
+func writeNotModified(w http.ResponseWriter, r *http.Request, etag string, modTime time.Time) bool {
+    // Each response format is a separate representation with its own ETag
+    if format := requestFormat(r); format != FormatJSON {
+        etag = strings.TrimSuffix(etag, `"`) + "-" + format + `"`
+    }
+    w.Header().Set("ETag", etag)
+    w.Header().Set("Vary", "Accept")
+    if !modTime.IsZero() {
+        w.Header().Set("Last-Modified", modTime.UTC().Format(http.TimeFormat))
+    }
+
+    // If-None-Match takes precedence over If-Modified-Since
+    if match := r.Header.Get("If-None-Match"); match != "" {
+        for _, candidate := range strings.Split(match, ",") {
+            candidate = strings.TrimSpace(candidate)
+            if candidate == "*" || strings.TrimPrefix(candidate, "W/") == strings.TrimPrefix(etag, "W/") {
+                w.WriteHeader(http.StatusNotModified)
+                return true
+            }
+        }
+        return false
+    }
+
+    if since := r.Header.Get("If-Modified-Since"); since != "" && !modTime.IsZero() {
+        if t, err := http.ParseTime(since); err == nil && !modTime.Truncate(time.Second).After(t) {
+            w.WriteHeader(http.StatusNotModified)
+            return true
+        }
+    }
+
+    return false
+}
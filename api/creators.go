@@ -0,0 +1,67 @@
+package api
+
+import (
+    "net/http"
+    "strings"
+    "anondd/utils/models"
+    "anondd/utils/trace"
+    "github.com/gorilla/mux"
+)
+
+// CreatorView is a developer wallet and its launches, flagged when it left
+// enough dead agents behind to be a serial launcher
+type CreatorView struct {
+    models.Creator
+    SerialLauncher bool `json:"serial_launcher"`
+}
+
+// creatorView hides agents outside the tenant's view. Dead counts stay whole
+// since they are the creator's track record, not agent data.
+func creatorView(tenant *Tenant, creator models.Creator) CreatorView {
+    creator.Agents = visibleSummaries(tenant, creator.Agents)
+    return CreatorView{Creator: creator, SerialLauncher: creator.SerialLauncher()}
+}
+
+// handleGetCreators lists developer wallets with their agents, those with the
+// most dead agents first; ?serial=true keeps only serial launchers
+func (s *APIServer) handleGetCreators(w http.ResponseWriter, r *http.Request) {
+    trace.Logf(r.Context(), s.logger, "Received request for creators")
+
+    creators, err := s.store.Creators(r.Context())
+    if err != nil {
+        writeStoreError(w, err, "Failed to retrieve creators")
+        trace.Logf(r.Context(), s.logger, "Error grouping agents by creator: %v", err)
+        return
+    }
+    serialOnly := r.URL.Query().Get("serial") == "true"
+
+    views := []CreatorView{}
+    for _, creator := range creators {
+        if serialOnly && !creator.SerialLauncher() {
+            continue
+        }
+        views = append(views, creatorView(tenantFrom(r), creator))
+    }
+    writeData(w, r, views)
+}
+
+// handleGetCreator returns the agents launched from one developer wallet
+func (s *APIServer) handleGetCreator(w http.ResponseWriter, r *http.Request) {
+    address := strings.ToLower(mux.Vars(r)["address"])
+    if !strings.HasPrefix(address, "0x") || len(address) != 42 {
+        writeError(w, http.StatusBadRequest, CodeBadRequest, "Invalid wallet address", map[string]string{"address": address})
+        return
+    }
+
+    creator, exists, err := s.store.CreatorOf(r.Context(), address)
+    if err != nil {
+        writeStoreError(w, err, "Failed to retrieve creator")
+        trace.Logf(r.Context(), s.logger, "Error loading creator %s: %v", address, err)
+        return
+    }
+    if !exists {
+        writeError(w, http.StatusNotFound, CodeNotFound, "No agents launched from that wallet", map[string]string{"address": address})
+        return
+    }
+    writeData(w, r, creatorView(tenantFrom(r), creator))
+}
@@ -0,0 +1,103 @@
+package api
+
+import (
+    "errors"
+    "net/http"
+    "strconv"
+    "anondd/utils/trace"
+    "anondd/utils/webscraper"
+    "github.com/gorilla/mux"
+)
+
+// DeadLetterList is the pages that keep failing to parse, with counts by kind
+type DeadLetterList struct {
+    Count   int                     `json:"count"`
+    Kinds   map[string]int          `json:"kinds"`
+    Letters []webscraper.DeadLetter `json:"letters"` // Most recently failed first
+}
+
+// handleGetDeadLetters lists the dead letters, optionally only those that
+// failed with ?kind=
+func (s *APIServer) handleGetDeadLetters(w http.ResponseWriter, r *http.Request) {
+    if !requireAdmin(w, r) {
+        return
+    }
+    if s.scraper == nil {
+        writeError(w, http.StatusNotFound, CodeNotFound, "Scraper is not configured", nil)
+        return
+    }
+    trace.Logf(r.Context(), s.logger, "Received request for dead letters")
+
+    list := DeadLetterList{Kinds: make(map[string]int), Letters: []webscraper.DeadLetter{}}
+    for _, letter := range s.scraper.DeadLetters().List("") {
+        list.Kinds[letter.ErrorKind]++
+    }
+    if letters := s.scraper.DeadLetters().List(r.URL.Query().Get("kind")); len(letters) > 0 {
+        list.Letters = letters
+    }
+    list.Count = len(list.Letters)
+    writeData(w, r, list)
+}
+
+// handleGetDeadLetterHTML returns the raw page kept for a dead letter, as
+// plain text so it isn't rendered
+func (s *APIServer) handleGetDeadLetterHTML(w http.ResponseWriter, r *http.Request) {
+    if !requireAdmin(w, r) {
+        return
+    }
+    if s.scraper == nil {
+        writeError(w, http.StatusNotFound, CodeNotFound, "Scraper is not configured", nil)
+        return
+    }
+    raw := mux.Vars(r)["id"]
+    id, err := strconv.Atoi(raw)
+    if err != nil {
+        writeError(w, http.StatusBadRequest, CodeBadRequest, "Invalid agent ID", map[string]string{"id": raw})
+        return
+    }
+
+    html, err := s.scraper.DeadLetters().LoadHTML(id)
+    if errors.Is(err, webscraper.ErrNoDeadLetter) {
+        writeError(w, http.StatusNotFound, CodeNotFound, "No dead letter for that ID", map[string]int{"id": id})
+        return
+    }
+    if err != nil {
+        writeError(w, http.StatusInternalServerError, CodeInternal, "Failed to read dead letter page", nil)
+        trace.Logf(r.Context(), s.logger, "Error reading dead letter %d: %v", id, err)
+        return
+    }
+    w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+    w.Header().Set("X-Content-Type-Options", "nosniff")
+    w.Write([]byte(html))
+}
+
+// handleRequeueDeadLetters requeues and parses the dead letters of ?ids=, or
+// all of them, e.g. after a parser fix
+func (s *APIServer) handleRequeueDeadLetters(w http.ResponseWriter, r *http.Request) {
+    if !requireAdmin(w, r) {
+        return
+    }
+    if s.scraper == nil {
+        writeError(w, http.StatusNotFound, CodeNotFound, "Scraper is not configured", nil)
+        return
+    }
+    raw := r.URL.Query().Get("ids")
+    ids, err := webscraper.ParseIDs(raw)
+    if err != nil {
+        writeError(w, http.StatusBadRequest, CodeBadRequest, err.Error(), map[string]string{"ids": raw})
+        return
+    }
+    trace.Logf(r.Context(), s.logger, "Received request to requeue dead letters %v", ids)
+
+    result, err := s.scraper.RequeueDeadLetters(ids)
+    switch {
+    case errors.Is(err, webscraper.ErrScrapeInProgress):
+        writeError(w, http.StatusConflict, CodeConflict, "A scrape is already running", nil)
+        return
+    case err != nil:
+        writeError(w, http.StatusInternalServerError, CodeInternal, "Failed to requeue dead letters", nil)
+        trace.Logf(r.Context(), s.logger, "Error requeueing dead letters: %v", err)
+        return
+    }
+    writeData(w, r, result)
+}
@@ -0,0 +1,282 @@
+package api
+
+import (
+    "encoding/csv"
+    "encoding/json"
+    "fmt"
+    "io"
+    "mime"
+    "net/http"
+    "reflect"
+    "sort"
+    "strings"
+    "time"
+)
+
+// Response formats selectable with ?format= or the Accept header
+const (
+    FormatJSON   = "json"
+    FormatNDJSON = "ndjson"
+    FormatCSV    = "csv"
+)
+
+// encoder writes a response value in one format
+type encoder interface {
+    contentType() string
+    encode(w io.Writer, v interface{}) error
+}
+
+var encoders = map[string]encoder{
+    FormatJSON:   jsonEncoder{},
+    FormatNDJSON: ndjsonEncoder{},
+    FormatCSV:    csvEncoder{},
+}
+
+// acceptFormats maps Accept media types to response formats
+var acceptFormats = map[string]string{
+    "application/json":     FormatJSON,
+    "application/x-ndjson": FormatNDJSON,
+    "application/ndjson":   FormatNDJSON,
+    "application/jsonl":    FormatNDJSON,
+    "text/csv":             FormatCSV,
+}
+
+// requestFormat picks the response format: ?format= wins, then the first
+// Accept media type we support, then JSON. Unknown ?format= values are
+// returned as-is so writeData can reject them.
+func requestFormat(r *http.Request) string {
+    if format := strings.ToLower(r.URL.Query().Get("format")); format != "" {
+        return format
+    }
+    for _, part := range strings.Split(r.Header.Get("Accept"), ",") {
+        mediaType, _, err := mime.ParseMediaType(strings.TrimSpace(part))
+        if err != nil {
+            continue
+        }
+        if format, ok := acceptFormats[mediaType]; ok {
+            return format
+        }
+    }
+    return FormatJSON
+}
+
+// writeData encodes v in the format negotiated for the request, reduced to
+// the fields selected with ?fields=. Slices become one NDJSON line or CSV row
+// per element; nested fields are flattened into dotted CSV columns.
+func writeData(w http.ResponseWriter, r *http.Request, v interface{}) error {
+    format := requestFormat(r)
+    enc, ok := encoders[format]
+    if !ok {
+        writeError(w, http.StatusBadRequest, CodeBadRequest, "Unsupported format, use json, ndjson or csv",
+            map[string]string{"format": format})
+        return fmt.Errorf("unsupported format %q", format)
+    }
+    v, err := selectRequestFields(w, r, v)
+    if err != nil {
+        return err
+    }
+
+    w.Header().Set("Content-Type", enc.contentType())
+    w.Header().Set("Vary", "Accept")
+    return enc.encode(w, v)
+}
+
+type jsonEncoder struct{}
+
+func (jsonEncoder) contentType() string { return "application/json" }
+
+func (jsonEncoder) encode(w io.Writer, v interface{}) error {
+    return json.NewEncoder(w).Encode(v)
+}
+
+type ndjsonEncoder struct{}
+
+func (ndjsonEncoder) contentType() string { return "application/x-ndjson" }
+
+func (ndjsonEncoder) encode(w io.Writer, v interface{}) error {
+    stream := newNDJSONStream(w)
+    value := reflect.ValueOf(v)
+    if value.Kind() != reflect.Slice && value.Kind() != reflect.Array {
+        return stream.Write(v)
+    }
+    for i := 0; i < value.Len(); i++ {
+        if err := stream.Write(value.Index(i).Interface()); err != nil {
+            return err
+        }
+    }
+    return nil
+}
+
+// ndjsonFlushEvery is how many records are written between flushes
+const ndjsonFlushEvery = 100
+
+// ndjsonStream writes one JSON document per line, flushing periodically so
+// large exports reach the client while they are still being produced
+type ndjsonStream struct {
+    encoder *json.Encoder
+    flusher http.Flusher
+    pending int
+}
+
+func newNDJSONStream(w io.Writer) *ndjsonStream {
+    flusher, _ := w.(http.Flusher)
+    return &ndjsonStream{encoder: json.NewEncoder(w), flusher: flusher}
+}
+
+// Write appends one record to the stream
+func (s *ndjsonStream) Write(v interface{}) error {
+    if err := s.encoder.Encode(v); err != nil {
+        return err
+    }
+    s.pending++
+    if s.pending >= ndjsonFlushEvery {
+        s.Flush()
+    }
+    return nil
+}
+
+// Flush sends buffered records to the client
+func (s *ndjsonStream) Flush() {
+    if s.flusher != nil {
+        s.flusher.Flush()
+    }
+    s.pending = 0
+}
+
+type csvEncoder struct{}
+
+func (csvEncoder) contentType() string { return "text/csv; charset=utf-8" }
+
+func (csvEncoder) encode(w io.Writer, v interface{}) error {
+    var records []map[string]string
+    value := reflect.ValueOf(v)
+    if value.Kind() == reflect.Slice || value.Kind() == reflect.Array {
+        for i := 0; i < value.Len(); i++ {
+            record := make(map[string]string)
+            flattenCSV(record, "", value.Index(i))
+            records = append(records, record)
+        }
+    } else {
+        record := make(map[string]string)
+        flattenCSV(record, "", value)
+        records = append(records, record)
+    }
+
+    header := csvHeader(v, records)
+    writer := csv.NewWriter(w)
+    if err := writer.Write(header); err != nil {
+        return fmt.Errorf("failed to write csv header: %w", err)
+    }
+    row := make([]string, len(header))
+    for _, record := range records {
+        for i, column := range header {
+            row[i] = record[column]
+        }
+        if err := writer.Write(row); err != nil {
+            return fmt.Errorf("failed to write csv row: %w", err)
+        }
+    }
+    writer.Flush()
+    return writer.Error()
+}
+
+// csvHeader lists struct columns in field order, or the sorted union of keys
+// for maps and other dynamic values
+func csvHeader(v interface{}, records []map[string]string) []string {
+    elem := reflect.TypeOf(v)
+    for elem != nil && (elem.Kind() == reflect.Slice || elem.Kind() == reflect.Array || elem.Kind() == reflect.Ptr) {
+        elem = elem.Elem()
+    }
+    if elem != nil && elem.Kind() == reflect.Struct && elem != reflect.TypeOf(time.Time{}) {
+        return structColumns("", elem)
+    }
+
+    seen := make(map[string]bool)
+    var header []string
+    for _, record := range records {
+        for column := range record {
+            if !seen[column] {
+                seen[column] = true
+                header = append(header, column)
+            }
+        }
+    }
+    sort.Strings(header)
+    return header
+}
+
+// structColumns returns the flattened column names of a struct type
+func structColumns(prefix string, t reflect.Type) []string {
+    var columns []string
+    for i := 0; i < t.NumField(); i++ {
+        field := t.Field(i)
+        name, ok := jsonFieldName(field)
+        if !ok {
+            continue
+        }
+        fieldType := field.Type
+        if fieldType.Kind() == reflect.Ptr {
+            fieldType = fieldType.Elem()
+        }
+        if fieldType.Kind() == reflect.Struct && fieldType != reflect.TypeOf(time.Time{}) {
+            columns = append(columns, structColumns(prefix+name+".", fieldType)...)
+            continue
+        }
+        columns = append(columns, prefix+name)
+    }
+    return columns
+}
+
+// flattenCSV writes v into record, naming nested struct fields "parent.child"
+// and JSON-encoding slices and maps that don't fit in one cell
+func flattenCSV(record map[string]string, prefix string, v reflect.Value) {
+    for v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface {
+        if v.IsNil() {
+            return
+        }
+        v = v.Elem()
+    }
+
+    switch {
+    case v.Type() == reflect.TypeOf(fieldObject{}):
+        // Selected fields flatten at any depth, like the structs they came from
+        for _, key := range v.MapKeys() {
+            flattenCSV(record, prefix+key.String()+".", v.MapIndex(key))
+        }
+    case v.Type() == reflect.TypeOf(time.Time{}):
+        if t := v.Interface().(time.Time); !t.IsZero() {
+            record[strings.TrimSuffix(prefix, ".")] = t.Format(time.RFC3339)
+        }
+    case v.Kind() == reflect.Struct:
+        for i := 0; i < v.NumField(); i++ {
+            if name, ok := jsonFieldName(v.Type().Field(i)); ok {
+                flattenCSV(record, prefix+name+".", v.Field(i))
+            }
+        }
+    case v.Kind() == reflect.Map && prefix == "":
+        for _, key := range v.MapKeys() {
+            flattenCSV(record, fmt.Sprint(key.Interface())+".", v.MapIndex(key))
+        }
+    case v.Kind() == reflect.Map || v.Kind() == reflect.Slice || v.Kind() == reflect.Array:
+        if data, err := json.Marshal(v.Interface()); err == nil {
+            record[strings.TrimSuffix(prefix, ".")] = string(data)
+        }
+    default:
+        record[strings.TrimSuffix(prefix, ".")] = fmt.Sprint(v.Interface())
+    }
+}
+
+// jsonFieldName returns the name a field is encoded under, honouring json tags
+func jsonFieldName(field reflect.StructField) (string, bool) {
+    if !field.IsExported() {
+        return "", false
+    }
+    tag := field.Tag.Get("json")
+    if tag == "-" {
+        return "", false
+    }
+    if name := strings.Split(tag, ",")[0]; name != "" {
+        return name, true
+    }
+    return field.Name, true
+}
@@ -0,0 +1,63 @@
+package api
+
+import (
+    "encoding/json"
+    "errors"
+    "net/http"
+    "time"
+    "anondd/utils/storage"
+)
+
+// Machine-readable error codes returned in the error envelope
+const (
+    CodeBadRequest   = "bad_request"
+    CodeUnauthorized = "unauthorized"
+    CodeForbidden    = "forbidden"
+    CodeNotFound     = "not_found"
+    CodeRateLimited  = "rate_limited"
+    CodeConflict     = "conflict"
+    CodeCorruptData  = "corrupt_data"
+    CodeInternal     = "internal_error"
+)
+
+// APIError is the JSON envelope for every error response
+type APIError struct {
+    Code    string      `json:"code"`
+    Message string      `json:"message"`
+    Details interface{} `json:"details,omitempty"`
+}
+
+// writeError writes an error envelope with the given status
+func writeError(w http.ResponseWriter, status int, code, message string, details interface{}) {
+    w.Header().Set("Content-Type", "application/json")
+    w.WriteHeader(status)
+    json.NewEncoder(w).Encode(APIError{Code: code, Message: message, Details: details})
+}
+
+// writeStoreError maps typed storage errors to a status and code
+func writeStoreError(w http.ResponseWriter, err error, message string) {
+    switch {
+    case errors.Is(err, storage.ErrNotFound):
+        writeError(w, http.StatusNotFound, CodeNotFound, message, nil)
+    case errors.Is(err, storage.ErrCorrupt):
+        writeError(w, http.StatusInternalServerError, CodeCorruptData, message, nil)
+    default:
+        writeError(w, http.StatusInternalServerError, CodeInternal, message, nil)
+    }
+}
+
+// parseSince reads the RFC3339 "since" query parameter, defaulting to 24h ago.
+// It writes a bad_request error and returns false if the value is invalid.
+func parseSince(w http.ResponseWriter, r *http.Request) (time.Time, bool) {
+    raw := r.URL.Query().Get("since")
+    if raw == "" {
+        return time.Now().Add(-24 * time.Hour), true
+    }
+    since, err := time.Parse(time.RFC3339, raw)
+    if err != nil {
+        writeError(w, http.StatusBadRequest, CodeBadRequest, "Invalid since, use RFC3339",
+            map[string]string{"since": raw})
+        return time.Time{}, false
+    }
+    return since, true
+}
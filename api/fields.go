@@ -0,0 +1,226 @@
+package api
+
+import (
+    "bytes"
+    "encoding/json"
+    "fmt"
+    "net/http"
+    "reflect"
+    "regexp"
+    "sort"
+    "strings"
+    "time"
+)
+
+// maxSelectedFields bounds how many paths one ?fields= may list
+const maxSelectedFields = 50
+
+var fieldSegmentPattern = regexp.MustCompile(`^[A-Za-z0-9_]+$`)
+
+// fieldTree is a parsed ?fields= selection. A nil subtree selects the whole
+// value; a non-nil one selects only its own keys.
+type fieldTree map[string]fieldTree
+
+// fieldObject is a JSON object reduced to the selected fields. It is its own
+// type so CSV flattens its nested objects into dotted columns.
+type fieldObject map[string]interface{}
+
+// requestFields parses ?fields=name,price,token_data.holders. It returns nil
+// when the request selects no fields.
+func requestFields(r *http.Request) (fieldTree, error) {
+    raw := strings.TrimSpace(r.URL.Query().Get("fields"))
+    if raw == "" {
+        return nil, nil
+    }
+    paths := strings.Split(raw, ",")
+    if len(paths) > maxSelectedFields {
+        return nil, fmt.Errorf("at most %d fields may be selected", maxSelectedFields)
+    }
+
+    tree := fieldTree{}
+    for _, path := range paths {
+        path = strings.TrimSpace(path)
+        if path == "" {
+            continue
+        }
+        segments := strings.Split(path, ".")
+        node := tree
+        for i, segment := range segments {
+            if !fieldSegmentPattern.MatchString(segment) {
+                return nil, fmt.Errorf("invalid field %q", path)
+            }
+            sub, exists := node[segment]
+            if i == len(segments)-1 {
+                // The whole value wins over any of its parts
+                node[segment] = nil
+                break
+            }
+            if exists && sub == nil {
+                break
+            }
+            if !exists {
+                sub = fieldTree{}
+                node[segment] = sub
+            }
+            node = sub
+        }
+    }
+    if len(tree) == 0 {
+        return nil, nil
+    }
+    return tree, nil
+}
+
+// validate checks that every selected path exists on values of type t,
+// following json tags. Maps and interfaces accept any key.
+func (t fieldTree) validate(typ reflect.Type, prefix string) error {
+    for typ.Kind() == reflect.Ptr || typ.Kind() == reflect.Slice || typ.Kind() == reflect.Array {
+        typ = typ.Elem()
+    }
+    switch {
+    case typ.Kind() == reflect.Interface:
+        return nil
+    case typ.Kind() == reflect.Map:
+        for key, sub := range t {
+            if sub != nil {
+                if err := sub.validate(typ.Elem(), prefix+key+"."); err != nil {
+                    return err
+                }
+            }
+        }
+        return nil
+    case typ.Kind() == reflect.Struct && typ != reflect.TypeOf(time.Time{}):
+        fields := make(map[string]reflect.Type, typ.NumField())
+        for i := 0; i < typ.NumField(); i++ {
+            if name, ok := jsonFieldName(typ.Field(i)); ok {
+                fields[name] = typ.Field(i).Type
+            }
+        }
+        for key, sub := range t {
+            fieldType, ok := fields[key]
+            if !ok {
+                return fmt.Errorf("unknown field %q", prefix+key)
+            }
+            if sub != nil {
+                if err := sub.validate(fieldType, prefix+key+"."); err != nil {
+                    return err
+                }
+            }
+        }
+        return nil
+    default:
+        if len(t) > 0 && prefix != "" {
+            return fmt.Errorf("field %q has no fields", strings.TrimSuffix(prefix, "."))
+        }
+        if len(t) > 0 {
+            return fmt.Errorf("this response has no fields to select")
+        }
+        return nil
+    }
+}
+
+// apply reduces a decoded JSON value to the selected fields. Arrays have the
+// selection applied to each element; fields the value omits stay omitted.
+func (t fieldTree) apply(v interface{}) interface{} {
+    switch value := v.(type) {
+    case []interface{}:
+        for i, elem := range value {
+            value[i] = t.apply(elem)
+        }
+        return value
+    case map[string]interface{}:
+        selected := make(fieldObject, len(t))
+        for key, sub := range t {
+            field, ok := value[key]
+            if !ok {
+                continue
+            }
+            if sub != nil {
+                field = sub.apply(field)
+            } else if object, ok := field.(map[string]interface{}); ok {
+                field = wholeObject(object)
+            }
+            selected[key] = field
+        }
+        return selected
+    default:
+        return v
+    }
+}
+
+// wholeObject marks a fully selected object, and the objects nested in it,
+// as selected so CSV flattens them too
+func wholeObject(object map[string]interface{}) fieldObject {
+    selected := make(fieldObject, len(object))
+    for key, field := range object {
+        if nested, ok := field.(map[string]interface{}); ok {
+            field = wholeObject(nested)
+        }
+        selected[key] = field
+    }
+    return selected
+}
+
+// selectFields reduces v to the fields in tree. Any value works: it is
+// encoded as JSON and decoded generically, numbers kept exactly as encoded.
+func selectFields(v interface{}, tree fieldTree) (interface{}, error) {
+    if v != nil {
+        if err := tree.validate(reflect.TypeOf(v), ""); err != nil {
+            return nil, err
+        }
+    }
+    data, err := json.Marshal(v)
+    if err != nil {
+        return nil, err
+    }
+    decoder := json.NewDecoder(bytes.NewReader(data))
+    decoder.UseNumber()
+    var generic interface{}
+    if err := decoder.Decode(&generic); err != nil {
+        return nil, err
+    }
+    return tree.apply(generic), nil
+}
+
+// selectRequestFields applies the request's ?fields= selection to v, writing
+// a bad request response when the selection is invalid
+func selectRequestFields(w http.ResponseWriter, r *http.Request, v interface{}) (interface{}, error) {
+    tree, err := requestFields(r)
+    if err == nil && tree != nil {
+        v, err = selectFields(v, tree)
+    }
+    if err != nil {
+        writeError(w, http.StatusBadRequest, CodeBadRequest, "Invalid field selection",
+            map[string]string{"fields": r.URL.Query().Get("fields"), "error": err.Error()})
+        return nil, err
+    }
+    return v, nil
+}
+
+// requestFieldsKey identifies the request's ?fields= selection in response
+// cache keys; it is empty without one
+func requestFieldsKey(r *http.Request) string {
+    tree, err := requestFields(r)
+    if err != nil || tree == nil {
+        return ""
+    }
+    return "|fields=" + tree.key()
+}
+
+// key identifies the selection for response cache keys
+func (t fieldTree) key() string {
+    var paths []string
+    t.collect("", &paths)
+    sort.Strings(paths)
+    return strings.Join(paths, ",")
+}
+
+func (t fieldTree) collect(prefix string, paths *[]string) {
+    for key, sub := range t {
+        if sub == nil {
+            *paths = append(*paths, prefix+key)
+        } else {
+            sub.collect(prefix+key+".", paths)
+        }
+    }
+}
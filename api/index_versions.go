@@ -0,0 +1,41 @@
+package api
+
+import (
+    "net/http"
+    "anondd/utils/trace"
+    "github.com/gorilla/mux"
+)
+
+// handleListIndexVersions lists the saved index versions, newest first
+func (s *APIServer) handleListIndexVersions(w http.ResponseWriter, r *http.Request) {
+    if !requireAdmin(w, r) {
+        return
+    }
+    trace.Logf(r.Context(), s.logger, "Received request for index versions")
+
+    versions, err := s.store.IndexVersions()
+    if err != nil {
+        writeStoreError(w, err, "Failed to list index versions")
+        trace.Logf(r.Context(), s.logger, "Error listing index versions: %v", err)
+        return
+    }
+    writeData(w, r, versions)
+}
+
+// handleRollbackIndex restores the index to a saved version; the replaced
+// index is saved as a new version first
+func (s *APIServer) handleRollbackIndex(w http.ResponseWriter, r *http.Request) {
+    if !requireAdmin(w, r) {
+        return
+    }
+    id := mux.Vars(r)["id"]
+    trace.Logf(r.Context(), s.logger, "Received request to roll the index back to version %s", id)
+
+    version, err := s.store.RollbackIndex(id)
+    if err != nil {
+        writeStoreError(w, err, "Failed to roll back the index")
+        trace.Logf(r.Context(), s.logger, "Error rolling the index back to %s: %v", id, err)
+        return
+    }
+    writeData(w, r, version)
+}
@@ -0,0 +1,100 @@
+package api
+
+import (
+    "fmt"
+    "net/http"
+    "strconv"
+    "unicode/utf8"
+    "anondd/utils/storage"
+    "anondd/utils/trace"
+    "github.com/gorilla/mux"
+)
+
+const (
+    // maxLLMAuditListed bounds ?limit for the LLM audit listing
+    maxLLMAuditListed = 200
+
+    // llmAuditPreview is how much of each body the listing shows; the
+    // single-call endpoint returns them whole
+    llmAuditPreview = 1000
+)
+
+// SetLLMAudit enables the LLM audit endpoints with the given log
+func (s *APIServer) SetLLMAudit(auditLog *storage.LLMAuditLog) {
+    s.llmAudit = auditLog
+}
+
+// handleGetLLMAudit lists the most recent LLM calls (?limit, 20 by default),
+// optionally only those for ?prompt_key, ?command or that ?failed, with
+// their bodies cut to a preview
+func (s *APIServer) handleGetLLMAudit(w http.ResponseWriter, r *http.Request) {
+    if !requireAdmin(w, r) {
+        return
+    }
+    if s.llmAudit == nil {
+        writeError(w, http.StatusNotFound, CodeNotFound, "LLM calls are not audited", nil)
+        return
+    }
+    query := r.URL.Query()
+    limit := 20
+    if raw := query.Get("limit"); raw != "" {
+        parsed, err := strconv.Atoi(raw)
+        if err != nil || parsed < 1 || parsed > maxLLMAuditListed {
+            writeError(w, http.StatusBadRequest, CodeBadRequest, fmt.Sprintf("Invalid limit, use 1 to %d", maxLLMAuditListed),
+                map[string]string{"limit": raw})
+            return
+        }
+        limit = parsed
+    }
+    filter := storage.LLMAuditFilter{
+        PromptKey:  query.Get("prompt_key"),
+        Command:    query.Get("command"),
+        FailedOnly: query.Get("failed") == "true",
+    }
+    trace.Logf(r.Context(), s.logger, "Received request for %d audited LLM calls", limit)
+
+    entries, err := s.llmAudit.Recent(limit, filter)
+    if err != nil {
+        writeStoreError(w, err, "Failed to read the LLM audit log")
+        trace.Logf(r.Context(), s.logger, "Error reading LLM audit log: %v", err)
+        return
+    }
+    if entries == nil {
+        entries = []storage.LLMAuditEntry{}
+    }
+    for i := range entries {
+        entries[i].Request = auditPreview(entries[i].Request)
+        entries[i].Response = auditPreview(entries[i].Response)
+    }
+    writeData(w, r, entries)
+}
+
+// handleGetLLMAuditEntry returns one audited LLM call with its full bodies
+func (s *APIServer) handleGetLLMAuditEntry(w http.ResponseWriter, r *http.Request) {
+    if !requireAdmin(w, r) {
+        return
+    }
+    if s.llmAudit == nil {
+        writeError(w, http.StatusNotFound, CodeNotFound, "LLM calls are not audited", nil)
+        return
+    }
+    id := mux.Vars(r)["id"]
+    entry, err := s.llmAudit.Get(id)
+    if err != nil {
+        writeStoreError(w, err, "Audited LLM call not found")
+        trace.Logf(r.Context(), s.logger, "Error reading audited LLM call %s: %v", id, err)
+        return
+    }
+    writeData(w, r, entry)
+}
+
+func auditPreview(body string) string {
+    if len(body) <= llmAuditPreview {
+        return body
+    }
+    cut := llmAuditPreview
+    for cut > 0 && !utf8.RuneStart(body[cut]) {
+        cut--
+    }
+    return body[:cut] + "…"
+}
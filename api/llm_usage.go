@@ -0,0 +1,43 @@
+package api
+
+import (
+    "fmt"
+    "net/http"
+    "strconv"
+    "time"
+    "anondd/utils/storage"
+    "anondd/utils/trace"
+)
+
+// maxLLMUsageDays bounds ?days for the LLM usage endpoint
+const maxLLMUsageDays = 90
+
+// SetLLMUsage enables the LLM usage endpoint with the given ledger
+func (s *APIServer) SetLLMUsage(ledger *storage.LLMUsageLedger) {
+    s.llmUsage = ledger
+}
+
+// handleGetLLMUsage returns LLM tokens and estimated cost for the last ?days
+// (7 by default) by prompt key, by command and by day
+func (s *APIServer) handleGetLLMUsage(w http.ResponseWriter, r *http.Request) {
+    if !requireAdmin(w, r) {
+        return
+    }
+    if s.llmUsage == nil {
+        writeError(w, http.StatusNotFound, CodeNotFound, "LLM usage is not tracked", nil)
+        return
+    }
+    days := 7
+    if raw := r.URL.Query().Get("days"); raw != "" {
+        parsed, err := strconv.Atoi(raw)
+        if err != nil || parsed < 1 || parsed > maxLLMUsageDays {
+            writeError(w, http.StatusBadRequest, CodeBadRequest, fmt.Sprintf("Invalid days, use 1 to %d", maxLLMUsageDays),
+                map[string]string{"days": raw})
+            return
+        }
+        days = parsed
+    }
+    trace.Logf(r.Context(), s.logger, "Received request for %d days of LLM usage", days)
+
+    writeData(w, r, s.llmUsage.Report(days, time.Now()))
+}
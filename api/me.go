@@ -0,0 +1,191 @@
+package api
+
+import (
+    "context"
+    "encoding/json"
+    "net/http"
+    "strconv"
+    "time"
+    "anondd/utils/models"
+    "anondd/utils/storage"
+    "anondd/utils/trace"
+)
+
+// Limits of the tenants personal API keys act as when tenancy is enabled
+const (
+    userRateLimit  = 30
+    userDailyQuota = 1000
+)
+
+// maxAlertsBytes bounds the body of an alerts update
+const maxAlertsBytes = 1 << 10
+
+// UserStores hold the Telegram user data served by the /api/me endpoints
+type UserStores struct {
+    Keys        *storage.UserKeyStore
+    Watchlists  *storage.WatchlistStore
+    Subscribers *storage.SubscriberStore
+    Profiles    *storage.ProfileStore
+    Quiet       *storage.QuietHoursStore
+}
+
+// SetUserStores accepts the personal API keys Telegram users make with
+// /apikey and enables the /api/me endpoints over their data
+func (s *APIServer) SetUserStores(stores *UserStores) {
+    s.users = stores
+}
+
+type userKey struct{}
+
+// userFrom returns the Telegram user whose personal key made the request, if any
+func userFrom(r *http.Request) *storage.UserAPIKey {
+    user, _ := r.Context().Value(userKey{}).(*storage.UserAPIKey)
+    return user
+}
+
+// withUser resolves a personal API key on the request, adding its user to
+// the request context
+func (s *APIServer) withUser(r *http.Request, key string) (*http.Request, *storage.UserAPIKey) {
+    if s.users == nil || s.users.Keys == nil || key == "" {
+        return r, nil
+    }
+    record, ok := s.users.Keys.Lookup(key)
+    if !ok {
+        return r, nil
+    }
+    return r.WithContext(context.WithValue(r.Context(), userKey{}, &record)), &record
+}
+
+// userTenant is the tenant a personal API key acts as: a non-admin with
+// fixed limits and an unrestricted view
+func userTenant(user *storage.UserAPIKey) *Tenant {
+    id := strconv.FormatInt(user.UserID, 10)
+    return &Tenant{ID: "user:" + id, Name: "Telegram user " + id, RateLimit: userRateLimit, DailyQuota: userDailyQuota}
+}
+
+// requireUser writes an error and returns nil unless the request carries a
+// personal API key
+func (s *APIServer) requireUser(w http.ResponseWriter, r *http.Request) *storage.UserAPIKey {
+    if s.users == nil {
+        writeError(w, http.StatusNotFound, CodeNotFound, "User endpoints are not enabled", nil)
+        return nil
+    }
+    user := userFrom(r)
+    if user == nil {
+        writeError(w, http.StatusUnauthorized, CodeUnauthorized, "Personal API key required, create one with /apikey in Telegram", nil)
+        return nil
+    }
+    return user
+}
+
+// UserInfo is the caller's profile and preferences from the bot
+type UserInfo struct {
+    UserID     int64                   `json:"user_id"`
+    Bot        string                  `json:"bot"`
+    Username   string                  `json:"username,omitempty"`
+    Language   string                  `json:"language,omitempty"`
+    Persona    string                  `json:"persona,omitempty"`
+    Favorites  []storage.FavoriteAgent `json:"favorites"`
+    KeyHint    string                  `json:"key_hint"`
+    KeyCreated time.Time               `json:"key_created_at"`
+}
+
+// UserAlerts is whether the caller gets new agent alerts, and when they are held
+type UserAlerts struct {
+    Enabled    bool               `json:"enabled"`
+    QuietHours *models.QuietHours `json:"quiet_hours,omitempty"`
+}
+
+// handleGetMe returns the profile of the user owning the API key
+func (s *APIServer) handleGetMe(w http.ResponseWriter, r *http.Request) {
+    user := s.requireUser(w, r)
+    if user == nil {
+        return
+    }
+    info := UserInfo{UserID: user.UserID, Bot: user.Bot, Favorites: []storage.FavoriteAgent{}, KeyHint: user.Hint, KeyCreated: user.CreatedAt}
+    if s.users.Profiles != nil {
+        if profile, exists := s.users.Profiles.Get(user.UserID); exists {
+            info.Username, info.Language, info.Persona = profile.Username, profile.Language, profile.Persona
+            if len(profile.Favorites) > 0 {
+                info.Favorites = profile.Favorites
+            }
+        }
+    }
+    writeData(w, r, info)
+}
+
+// handleGetMyWatchlist returns the agents the user watches in their private
+// chat with the bot the key was made on
+func (s *APIServer) handleGetMyWatchlist(w http.ResponseWriter, r *http.Request) {
+    user := s.requireUser(w, r)
+    if user == nil {
+        return
+    }
+    entries := []storage.WatchEntry{}
+    if s.users.Watchlists != nil {
+        if list := s.users.Watchlists.List(user.Bot, user.UserID); len(list) > 0 {
+            entries = list
+        }
+    }
+    writeData(w, r, entries)
+}
+
+// handleGetMyAlerts returns the user's alert subscription and quiet hours
+func (s *APIServer) handleGetMyAlerts(w http.ResponseWriter, r *http.Request) {
+    user := s.requireUser(w, r)
+    if user == nil {
+        return
+    }
+    writeData(w, r, s.userAlerts(user))
+}
+
+// handleUpdateMyAlerts subscribes or unsubscribes the user from new agent
+// alerts, as /alerts does in their private chat
+func (s *APIServer) handleUpdateMyAlerts(w http.ResponseWriter, r *http.Request) {
+    user := s.requireUser(w, r)
+    if user == nil {
+        return
+    }
+    if s.users.Subscribers == nil {
+        writeError(w, http.StatusNotFound, CodeNotFound, "Alerts are not enabled", nil)
+        return
+    }
+    var req struct {
+        Enabled *bool `json:"enabled"`
+    }
+    if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxAlertsBytes)).Decode(&req); err != nil || req.Enabled == nil {
+        writeError(w, http.StatusBadRequest, CodeBadRequest, `Invalid alerts body, send {"enabled": true|false}`, nil)
+        return
+    }
+
+    var err error
+    if *req.Enabled {
+        _, err = s.users.Subscribers.Subscribe(user.Bot, user.UserID)
+    } else {
+        _, err = s.users.Subscribers.Unsubscribe(user.Bot, user.UserID)
+    }
+    if err != nil {
+        writeError(w, http.StatusInternalServerError, CodeInternal, "Failed to update alerts", nil)
+        trace.Logf(r.Context(), s.logger, "Error updating alerts for user %d: %v", user.UserID, err)
+        return
+    }
+    writeData(w, r, s.userAlerts(user))
+}
+
+func (s *APIServer) userAlerts(user *storage.UserAPIKey) UserAlerts {
+    var alerts UserAlerts
+    if s.users.Subscribers != nil {
+        for _, chatID := range s.users.Subscribers.Subscribers(user.Bot) {
+            if chatID == user.UserID {
+                alerts.Enabled = true
+                break
+            }
+        }
+    }
+    if s.users.Quiet != nil {
+        if hours, exists := s.users.Quiet.Get(user.UserID); exists {
+            alerts.QuietHours = &hours
+        }
+    }
+    return alerts
+}
@@ -0,0 +1,31 @@
+package api
+
+import (
+    "net/http"
+    "strings"
+    "anondd/utils/trace"
+)
+
+// requestIDHeader carries the trace ID in and out of the API
+const requestIDHeader = "X-Request-ID"
+
+// traceMiddleware tags each request with a trace ID (reusing an incoming
+// X-Request-ID if it is a valid trace ID), echoes it in the response and
+// records a span. Anything else a client sends is replaced, so it can't
+// reach logs, headers or the trace exporter.
+func (s *APIServer) traceMiddleware(next http.Handler) http.Handler {
+    return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+        id := strings.ToLower(r.Header.Get(requestIDHeader))
+        if !trace.ValidID(id) {
+            id = trace.NewID()
+        }
+        w.Header().Set(requestIDHeader, id)
+
+        ctx, span := trace.StartSpan(trace.WithID(r.Context(), id), "http "+r.Method+" "+r.URL.Path)
+        span.SetAttribute("http.method", r.Method)
+        span.SetAttribute("http.target", r.URL.RequestURI())
+        defer span.Finish(nil)
+
+        next.ServeHTTP(w, r.WithContext(ctx))
+    })
+}
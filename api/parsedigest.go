@@ -0,0 +1,73 @@
+package api
+
+import (
+    "net/http"
+    "strconv"
+    "anondd/llm"
+    "anondd/utils/parsedigest"
+    "anondd/utils/trace"
+    "github.com/gorilla/mux"
+)
+
+// SetParseDigester enables the parse digest endpoints with the given digester
+func (s *APIServer) SetParseDigester(digester *parsedigest.Digester) {
+    s.digester = digester
+}
+
+// handleGetParseDigest returns the latest parse failure digest with its
+// suggested selectors, or runs a fresh one with ?run=1
+func (s *APIServer) handleGetParseDigest(w http.ResponseWriter, r *http.Request) {
+    if !requireAdmin(w, r) {
+        return
+    }
+    if s.digester == nil {
+        writeError(w, http.StatusNotFound, CodeNotFound, "Parse digests are disabled", nil)
+        return
+    }
+
+    var digest *parsedigest.Digest
+    var err error
+    if r.URL.Query().Get("run") == "1" {
+        trace.Logf(r.Context(), s.logger, "Running parse digest")
+        digest, err = s.digester.Run(llm.WithCommand(r.Context(), "parse_digest"))
+    } else {
+        digest, err = s.digester.Latest()
+    }
+    if err != nil {
+        writeError(w, http.StatusInternalServerError, CodeInternal, "Unable to build the parse digest", nil)
+        trace.Logf(r.Context(), s.logger, "Error getting parse digest: %v", err)
+        return
+    }
+    if digest == nil {
+        writeError(w, http.StatusNotFound, CodeNotFound, "No parse digest yet, run one with ?run=1", nil)
+        return
+    }
+    writeData(w, r, digest)
+}
+
+// handleAdoptSelector adopts the n-th suggestion of the latest parse digest,
+// counting from zero across fields, into the selector config
+func (s *APIServer) handleAdoptSelector(w http.ResponseWriter, r *http.Request) {
+    if !requireAdmin(w, r) {
+        return
+    }
+    if s.digester == nil {
+        writeError(w, http.StatusNotFound, CodeNotFound, "Parse digests are disabled", nil)
+        return
+    }
+    raw := mux.Vars(r)["n"]
+    n, err := strconv.Atoi(raw)
+    if err != nil || n < 0 {
+        writeError(w, http.StatusBadRequest, CodeBadRequest, "Invalid suggestion number", map[string]string{"n": raw})
+        return
+    }
+
+    field, selector, err := s.digester.Adopt(n)
+    if err != nil {
+        writeError(w, http.StatusBadRequest, CodeBadRequest, "Unable to adopt selector",
+            map[string]string{"n": raw, "error": err.Error()})
+        return
+    }
+    trace.Logf(r.Context(), s.logger, "Adopted selector %q for %s", selector, field)
+    writeData(w, r, map[string]string{"field": field, "selector": selector, "status": "adopted"})
+}
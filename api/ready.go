@@ -0,0 +1,45 @@
+package api
+
+import (
+    "net/http"
+    "anondd/utils/webscraper"
+)
+
+// CodeNotReady is returned by /readyz while the instance can't scrape
+const CodeNotReady = "not_ready"
+
+// Readiness is whether the instance is ready to scrape, with the self-test
+// it is based on
+type Readiness struct {
+    Ready    bool                       `json:"ready"`
+    Reason   string                     `json:"reason,omitempty"`
+    SelfTest *webscraper.SelfTestReport `json:"self_test,omitempty"`
+}
+
+// RequireSelfTest makes /readyz answer 503 until the scraper's startup
+// self-test has passed
+func (s *APIServer) RequireSelfTest() {
+    s.selfTest = true
+}
+
+// handleReadyz reports readiness for load balancers and orchestrators: 200
+// once the scrape self-test passed, or without one required, 503 otherwise
+func (s *APIServer) handleReadyz(w http.ResponseWriter, r *http.Request) {
+    readiness := Readiness{Ready: true}
+    if s.scraper != nil {
+        readiness.SelfTest = s.scraper.LastSelfTest()
+    }
+    if s.selfTest {
+        switch {
+        case readiness.SelfTest == nil:
+            readiness.Ready, readiness.Reason = false, "scrape self-test hasn't finished yet"
+        case !readiness.SelfTest.Passed():
+            readiness.Ready, readiness.Reason = false, "scrape self-test failed"
+        }
+    }
+    if !readiness.Ready {
+        writeError(w, http.StatusServiceUnavailable, CodeNotReady, "Not ready: "+readiness.Reason, readiness)
+        return
+    }
+    writeData(w, r, readiness)
+}
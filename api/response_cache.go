@@ -0,0 +1,97 @@
+package api
+
+import (
+    "bytes"
+    "fmt"
+    "net/http"
+    "sync"
+    "time"
+)
+
+// maxCachedResponses bounds the encoded responses kept in memory; the cache
+// is emptied when it fills up
+const maxCachedResponses = 1024
+
+type cachedResponse struct {
+    version     time.Time
+    contentType string
+    body        []byte
+}
+
+// responseCache keeps encoded response bodies so hot read endpoints don't
+// re-filter, re-sort and re-encode the index on every request. Each entry is
+// tagged with the modification time of the data it was built from and is
+// rebuilt once that changes.
+type responseCache struct {
+    mu      sync.RWMutex
+    entries map[string]cachedResponse
+}
+
+func newResponseCache() *responseCache {
+    return &responseCache{entries: make(map[string]cachedResponse)}
+}
+
+func (c *responseCache) get(key string, version time.Time) (cachedResponse, bool) {
+    c.mu.RLock()
+    defer c.mu.RUnlock()
+    entry, exists := c.entries[key]
+    if !exists || !entry.version.Equal(version) {
+        return cachedResponse{}, false
+    }
+    return entry, true
+}
+
+func (c *responseCache) put(key string, entry cachedResponse) {
+    c.mu.Lock()
+    defer c.mu.Unlock()
+    if _, exists := c.entries[key]; !exists && len(c.entries) >= maxCachedResponses {
+        c.entries = make(map[string]cachedResponse)
+    }
+    c.entries[key] = entry
+}
+
+// writeCached serves the response cached under key if it was built from data
+// at version. Keys must already identify the tenant's view; the negotiated
+// format and field selection are added here.
+func (s *APIServer) writeCached(w http.ResponseWriter, r *http.Request, key string, version time.Time) bool {
+    // Invalid selections fall through to be rejected when writing
+    if _, err := requestFields(r); err != nil {
+        return false
+    }
+    entry, hit := s.responses.get(key+"|"+requestFormat(r)+requestFieldsKey(r), version)
+    if !hit {
+        return false
+    }
+    w.Header().Set("Content-Type", entry.contentType)
+    w.Header().Set("Vary", "Accept")
+    w.Write(entry.body)
+    return true
+}
+
+// writeAndCache is writeData that also caches the encoded response under key
+// for data at version
+func (s *APIServer) writeAndCache(w http.ResponseWriter, r *http.Request, key string, version time.Time, v interface{}) error {
+    format := requestFormat(r)
+    enc, ok := encoders[format]
+    if !ok {
+        return writeData(w, r, v)
+    }
+
+    v, err := selectRequestFields(w, r, v)
+    if err != nil {
+        return err
+    }
+
+    var buf bytes.Buffer
+    if err := enc.encode(&buf, v); err != nil {
+        writeError(w, http.StatusInternalServerError, CodeInternal, "Failed to encode response", nil)
+        return fmt.Errorf("failed to encode response: %w", err)
+    }
+    entry := cachedResponse{version: version, contentType: enc.contentType(), body: buf.Bytes()}
+    s.responses.put(key+"|"+format+requestFieldsKey(r), entry)
+
+    w.Header().Set("Content-Type", entry.contentType)
+    w.Header().Set("Vary", "Accept")
+    _, err = w.Write(entry.body)
+    return err
+}
@@ -1,79 +1,591 @@
 package api
 
 import (
-    "encoding/json"
+    "fmt"
     "log"
     "net/http"
+    "reflect"
+    "time"
+    "anondd/llm"
+    "anondd/utils/export"
+    "anondd/utils/models"
+    "anondd/utils/parsedigest"
+    "anondd/utils/pipeline"
+    "anondd/utils/search"
+    "anondd/utils/shared"
     "anondd/utils/storage"
+    "anondd/utils/trace"
+    "anondd/utils/trend"
+    "anondd/utils/webscraper"
     "github.com/gorilla/mux"
 )
 
 type APIServer struct {
-    store  *storage.AgentStore
-    logger *log.Logger
+    store     *storage.AgentStore
+    pipelines *pipeline.Engine
+    feedback  *storage.FeedbackStore
+    scraper   *webscraper.VirtualsScraper
+    digester  *parsedigest.Digester
+    analyses  *analysisFeed
+    search    *search.Index
+    llmUsage  *storage.LLMUsageLedger
+    llmAudit  *storage.LLMAuditLog
+    llm       *llm.OpenRouterClient
+    tenants   *Tenants
+    users     *UserStores
+    selfTest  bool // /readyz waits for a passing scrape self-test
+    usage     shared.Store
+    responses *responseCache
+    logger    *log.Logger
+    config ServerConfig
+    router *mux.Router
+    server *http.Server
 }
 
-func NewAPIServer(store *storage.AgentStore, logger *log.Logger) *APIServer {
+func NewAPIServer(store *storage.AgentStore, config ServerConfig, logger *log.Logger) *APIServer {
+    router := mux.NewRouter()
     return &APIServer{
-        store:  store,
-        logger: logger,
+        store:     store,
+        responses: newResponseCache(),
+        logger:    logger,
+        config:    config,
+        router:    router,
+        server:    newHTTPServer(config, router),
     }
 }
 
+// SetPipelines enables the pipeline endpoints with the given engine
+func (s *APIServer) SetPipelines(engine *pipeline.Engine) {
+    s.pipelines = engine
+}
+
+// SetFeedback enables the feedback endpoint with the given store
+func (s *APIServer) SetFeedback(feedback *storage.FeedbackStore) {
+    s.feedback = feedback
+}
+
 func (s *APIServer) SetupRoutes() {
-    router := mux.NewRouter()
+    router := s.router
+    router.Use(s.traceMiddleware)
+    router.Use(s.tenantMiddleware)
 
     // API routes
     router.HandleFunc("/api/agents", s.handleGetAllAgents).Methods("GET")
+    router.HandleFunc("/api/agents/new", s.handleGetNewAgents).Methods("GET")
     router.HandleFunc("/api/agents/{id}", s.handleGetAgent).Methods("GET")
+    router.HandleFunc("/api/agents/{id}/trend", s.handleGetAgentTrend).Methods("GET")
+    router.HandleFunc("/api/agents/{id}/chart.png", s.handleGetAgentChart).Methods("GET")
+    router.HandleFunc("/api/creators", s.handleGetCreators).Methods("GET")
+    router.HandleFunc("/api/creators/{address}", s.handleGetCreator).Methods("GET")
     router.HandleFunc("/api/index", s.handleGetIndex).Methods("GET")
+    router.HandleFunc("/api/index/delta", s.handleGetIndexDelta).Methods("GET")
+    router.HandleFunc("/api/index/versions", s.handleListIndexVersions).Methods("GET")
+    router.HandleFunc("/api/index/versions/{id}/rollback", s.handleRollbackIndex).Methods("POST")
+    router.HandleFunc("/api/compare", s.handleCompareAgents).Methods("POST")
+    router.HandleFunc("/api/validate", s.handleValidateAgent).Methods("POST")
+    router.HandleFunc("/api/search", s.handleSearch).Methods("GET")
+    router.HandleFunc("/api/changes", s.handleGetChanges).Methods("GET")
+    router.HandleFunc("/api/anomalies", s.handleGetAnomalies).Methods("GET")
+    router.HandleFunc("/api/pipelines", s.handleListPipelines).Methods("GET")
+    router.HandleFunc("/api/pipelines/{name}/run", s.handleRunPipeline).Methods("POST")
+    router.HandleFunc("/api/reports/weekly/latest", s.handleGetLatestWeeklyReport).Methods("GET")
+    router.HandleFunc("/api/feedback", s.handleGetFeedback).Methods("GET")
+    router.HandleFunc("/api/shares", s.handleCreateShare).Methods("POST")
+    router.HandleFunc("/r/{id}", s.handleViewShare).Methods("GET")
+    router.HandleFunc("/readyz", s.handleReadyz).Methods("GET")
+    router.HandleFunc("/api/export", s.handleExport).Methods("GET")
+    router.HandleFunc("/api/cache/stats", s.handleGetCacheStats).Methods("GET")
+    router.HandleFunc("/api/scrape/dry_run", s.handleDryRunScrape).Methods("GET")
+    router.HandleFunc("/api/scrape/runs", s.handleGetScrapeRuns).Methods("GET")
+    router.HandleFunc("/api/scrape/run", s.handleStartScrape).Methods("POST")
+    router.HandleFunc("/api/scrape/profiles", s.handleGetScrapeProfiles).Methods("GET")
+    router.HandleFunc("/api/scrape/pipeline", s.handleGetScrapePipeline).Methods("GET")
+    router.HandleFunc("/api/scrape/strategies", s.handleGetScrapeStrategies).Methods("GET")
+    router.HandleFunc("/api/scrape/sessions", s.handleGetScrapeSessions).Methods("GET")
+    router.HandleFunc("/api/scrape/sessions/{source}/reset", s.handleResetScrapeSession).Methods("POST")
+    router.HandleFunc("/api/scrape/failures", s.handleGetScrapeFailures).Methods("GET")
+    router.HandleFunc("/api/scrape/dead_letters", s.handleGetDeadLetters).Methods("GET")
+    router.HandleFunc("/api/scrape/dead_letters/requeue", s.handleRequeueDeadLetters).Methods("POST")
+    router.HandleFunc("/api/scrape/dead_letters/{id}/html", s.handleGetDeadLetterHTML).Methods("GET")
+    router.HandleFunc("/api/scrape/parse_digest", s.handleGetParseDigest).Methods("GET")
+    router.HandleFunc("/api/scrape/parse_digest/{n}/adopt", s.handleAdoptSelector).Methods("POST")
+    router.HandleFunc("/api/me", s.handleGetMe).Methods("GET")
+    router.HandleFunc("/api/me/watchlist", s.handleGetMyWatchlist).Methods("GET")
+    router.HandleFunc("/api/me/alerts", s.handleGetMyAlerts).Methods("GET")
+    router.HandleFunc("/api/me/alerts", s.handleUpdateMyAlerts).Methods("PUT")
+    router.HandleFunc("/api/usage", s.handleGetUsage).Methods("GET")
+    router.HandleFunc("/api/usage/llm", s.handleGetLLMUsage).Methods("GET")
+    router.HandleFunc("/api/llm/audit", s.handleGetLLMAudit).Methods("GET")
+    router.HandleFunc("/api/llm/audit/{id}", s.handleGetLLMAuditEntry).Methods("GET")
+    router.HandleFunc("/api/analyses", s.handleGetAnalyses).Methods("GET")
+    router.HandleFunc("/api/analyses/stream", s.handleStreamAnalyses).Methods("GET")
 
-    // Set router as default HTTP handler
-    http.Handle("/", router)
     s.logger.Println("API routes set up successfully")
 }
 
 func (s *APIServer) handleGetAllAgents(w http.ResponseWriter, r *http.Request) {
-    s.logger.Println("Received request to get all agents")
-    index, err := s.store.GetIndex()
+    trace.Logf(r.Context(), s.logger, "Received request to get all agents")
+
+    // ?max_age=7d keeps agents launched within that long
+    maxAge := r.URL.Query().Get("max_age")
+    var age time.Duration
+    if maxAge != "" {
+        var err error
+        if age, err = models.ParseAge(maxAge); err != nil {
+            writeError(w, http.StatusBadRequest, CodeBadRequest, "Invalid max_age, use days like 7d or a duration like 36h",
+                map[string]string{"max_age": maxAge})
+            return
+        }
+    }
+
+    // ?sort=risk|name|first_seen|launched, prefixed with "-" for descending
+    sortField := r.URL.Query().Get("sort")
+    if sortField != "" && !validSort(sortField) {
+        writeError(w, http.StatusBadRequest, CodeBadRequest, "Unsupported sort, use risk, name, first_seen or launched",
+            map[string]string{"sort": sortField})
+        return
+    }
+
+    updated, err := s.store.IndexUpdated(r.Context())
     if err != nil {
-        http.Error(w, "Failed to retrieve agents", http.StatusInternalServerError)
-        s.logger.Printf("Error getting agents: %v", err)
+        writeStoreError(w, err, "Failed to retrieve agents")
+        trace.Logf(r.Context(), s.logger, "Error getting agents: %v", err)
+        return
+    }
+    key := tenantETag(r, "agents"+sortField+maxAge)
+    if writeNotModified(w, r, weakETag(key, updated), updated) {
+        trace.Logf(r.Context(), s.logger, "Agents not modified")
         return
     }
+    // Ages change with the clock, so max_age responses aren't cached
+    if maxAge == "" && s.writeCached(w, r, key, updated) {
+        trace.Logf(r.Context(), s.logger, "Served cached agents")
+        return
+    }
+
+    index, err := s.store.GetIndexContext(r.Context())
+    if err != nil {
+        writeStoreError(w, err, "Failed to retrieve agents")
+        trace.Logf(r.Context(), s.logger, "Error getting agents: %v", err)
+        return
+    }
+    agents := visibleSummaries(tenantFrom(r), index.Agents)
+    if maxAge != "" {
+        agents = launchedWithin(agents, age, time.Now())
+    }
+    if sortField != "" {
+        sortSummaries(agents, sortField)
+    }
 
-    w.Header().Set("Content-Type", "application/json")
-    json.NewEncoder(w).Encode(index.Agents)
-    s.logger.Println("Successfully retrieved all agents")
+    if maxAge != "" {
+        writeData(w, r, agents)
+    } else {
+        s.writeAndCache(w, r, key, index.LastUpdated, agents)
+    }
+    trace.Logf(r.Context(), s.logger, "Successfully retrieved all agents")
 }
 
 func (s *APIServer) handleGetAgent(w http.ResponseWriter, r *http.Request) {
     vars := mux.Vars(r)
     id := vars["id"]
-    s.logger.Printf("Received request to get agent with ID: %s", id)
+    trace.Logf(r.Context(), s.logger, "Received request to get agent with ID: %s", id)
 
-    agent, err := s.store.GetAgent(id)
+    agent, err := s.store.GetAgentContext(r.Context(), id)
     if err != nil {
-        http.Error(w, "Agent not found", http.StatusNotFound)
-        s.logger.Printf("Error getting agent %s: %v", id, err)
+        writeStoreError(w, err, "Agent not found")
+        trace.Logf(r.Context(), s.logger, "Error getting agent %s: %v", id, err)
+        return
+    }
+    if !tenantFrom(r).canSeeAgent(agent.Status) {
+        writeError(w, http.StatusNotFound, CodeNotFound, "Agent not found", nil)
         return
     }
 
-    w.Header().Set("Content-Type", "application/json")
-    json.NewEncoder(w).Encode(agent)
-    s.logger.Printf("Successfully retrieved agent with ID: %s", id)
+    agent = s.presentAgent(w, agent)
+    modTime, err := s.store.AgentModTime(id)
+    if err != nil {
+        writeData(w, r, agent)
+        trace.Logf(r.Context(), s.logger, "Successfully retrieved agent with ID: %s", id)
+        return
+    }
+    if writeNotModified(w, r, weakETag(tenantETag(r, id), modTime), modTime) {
+        trace.Logf(r.Context(), s.logger, "Agent %s not modified", id)
+        return
+    }
+
+    // Agent responses don't depend on the tenant once it may see the agent
+    if !s.writeCached(w, r, "agent:"+id, modTime) {
+        s.writeAndCache(w, r, "agent:"+id, modTime, agent)
+    }
+    trace.Logf(r.Context(), s.logger, "Successfully retrieved agent with ID: %s", id)
+}
+
+// presentAgent prepares agent for a response. The raw stats text is
+// deprecated in favor of stats_detail and only kept with LegacyStats, in
+// which case the response says so with a Deprecation header.
+func (s *APIServer) presentAgent(w http.ResponseWriter, agent *models.Agent) *models.Agent {
+    if s.config.LegacyStats {
+        if agent.Stats != "" {
+            w.Header().Set("Deprecation", "true")
+        }
+        return agent
+    }
+    presented := *agent
+    presented.Stats = ""
+    return &presented
+}
+
+// handleGetAgentTrend returns the moving averages, momentum and linear
+// projection computed from an agent's metric history
+func (s *APIServer) handleGetAgentTrend(w http.ResponseWriter, r *http.Request) {
+    id := mux.Vars(r)["id"]
+    trace.Logf(r.Context(), s.logger, "Received request to get trend for agent %s", id)
+
+    agent, err := s.store.GetAgentContext(r.Context(), id)
+    if err == nil && !tenantFrom(r).canSeeAgent(agent.Status) {
+        err = storage.ErrNotFound
+    }
+    if err != nil {
+        writeStoreError(w, err, "Agent not found")
+        trace.Logf(r.Context(), s.logger, "Error getting agent %s: %v", id, err)
+        return
+    }
+
+    history, err := s.store.GetHistory(agent.SourceID)
+    if err != nil {
+        writeStoreError(w, err, "Failed to retrieve agent history")
+        trace.Logf(r.Context(), s.logger, "Error getting history for agent %s: %v", id, err)
+        return
+    }
+
+    writeData(w, r, trend.Compute(agent, history, time.Now()))
+    trace.Logf(r.Context(), s.logger, "Successfully computed trend for agent %s", id)
+}
+
+func (s *APIServer) handleGetNewAgents(w http.ResponseWriter, r *http.Request) {
+    since, ok := parseSince(w, r)
+    if !ok {
+        return
+    }
+    trace.Logf(r.Context(), s.logger, "Received request to get agents first seen since %s", since.Format(time.RFC3339))
+
+    newAgents, err := s.store.NewAgentsSince(since)
+    if err != nil {
+        writeStoreError(w, err, "Failed to retrieve new agents")
+        trace.Logf(r.Context(), s.logger, "Error getting new agents: %v", err)
+        return
+    }
+    if newAgents == nil {
+        newAgents = []models.AgentSummary{}
+    }
+    newAgents = visibleSummaries(tenantFrom(r), newAgents)
+
+    writeData(w, r, newAgents)
+    trace.Logf(r.Context(), s.logger, "Successfully retrieved %d new agents", len(newAgents))
+}
+
+func (s *APIServer) handleGetChanges(w http.ResponseWriter, r *http.Request) {
+    since, ok := parseSince(w, r)
+    if !ok {
+        return
+    }
+    trace.Logf(r.Context(), s.logger, "Received request to get changes since %s", since.Format(time.RFC3339))
+
+    events, err := s.store.GetChanges(since)
+    if err != nil {
+        writeStoreError(w, err, "Failed to retrieve changes")
+        trace.Logf(r.Context(), s.logger, "Error getting changes: %v", err)
+        return
+    }
+    if events == nil {
+        events = []models.ChangeEvent{}
+    }
+    if events, err = s.visibleChanges(r.Context(), tenantFrom(r), events); err != nil {
+        writeStoreError(w, err, "Failed to retrieve changes")
+        trace.Logf(r.Context(), s.logger, "Error filtering changes: %v", err)
+        return
+    }
+
+    writeData(w, r, events)
+    trace.Logf(r.Context(), s.logger, "Successfully retrieved %d changes", len(events))
+}
+
+func (s *APIServer) handleGetAnomalies(w http.ResponseWriter, r *http.Request) {
+    since, ok := parseSince(w, r)
+    if !ok {
+        return
+    }
+    trace.Logf(r.Context(), s.logger, "Received request to get anomalies since %s", since.Format(time.RFC3339))
+
+    events, err := s.store.GetChanges(since)
+    if err != nil {
+        writeStoreError(w, err, "Failed to retrieve anomalies")
+        trace.Logf(r.Context(), s.logger, "Error getting anomalies: %v", err)
+        return
+    }
+    if events, err = s.visibleChanges(r.Context(), tenantFrom(r), events); err != nil {
+        writeStoreError(w, err, "Failed to retrieve anomalies")
+        trace.Logf(r.Context(), s.logger, "Error filtering anomalies: %v", err)
+        return
+    }
+
+    anomalies := []models.ChangeEvent{}
+    for _, event := range events {
+        if models.IsAnomaly(event.Type) {
+            anomalies = append(anomalies, event)
+        }
+    }
+
+    writeData(w, r, anomalies)
+    trace.Logf(r.Context(), s.logger, "Successfully retrieved %d anomalies", len(anomalies))
+}
+
+// handleGetIndexDelta returns the index entries added or changed since the
+// since query parameter and the IDs removed since then, for incremental sync
+func (s *APIServer) handleGetIndexDelta(w http.ResponseWriter, r *http.Request) {
+    if r.URL.Query().Get("since") == "" {
+        writeError(w, http.StatusBadRequest, CodeBadRequest, "since is required, use RFC3339", nil)
+        return
+    }
+    since, ok := parseSince(w, r)
+    if !ok {
+        return
+    }
+    trace.Logf(r.Context(), s.logger, "Received request to get index delta since %s", since.Format(time.RFC3339))
+
+    delta, err := s.store.IndexDelta(r.Context(), since)
+    if err != nil {
+        writeStoreError(w, err, "Failed to retrieve index delta")
+        trace.Logf(r.Context(), s.logger, "Error getting index delta: %v", err)
+        return
+    }
+
+    if tenant := tenantFrom(r); tenant.restricted() {
+        delta.Added = visibleSummaries(tenant, delta.Added)
+        // An agent that moved out of the tenant's view is gone as far as
+        // its mirror is concerned
+        changed := []models.AgentSummary{}
+        for _, summary := range delta.Changed {
+            if tenant.canSeeAgent(summary.Status) {
+                changed = append(changed, summary)
+            } else {
+                delta.Removed = append(delta.Removed, summary.ID)
+            }
+        }
+        delta.Changed = changed
+    }
+    writeData(w, r, delta)
+    trace.Logf(r.Context(), s.logger, "Successfully retrieved index delta: %d added, %d changed, %d removed",
+        len(delta.Added), len(delta.Changed), len(delta.Removed))
 }
 
 func (s *APIServer) handleGetIndex(w http.ResponseWriter, r *http.Request) {
-    s.logger.Println("Received request to get agent index")
-    index, err := s.store.GetIndex()
+    trace.Logf(r.Context(), s.logger, "Received request to get agent index")
+    index, err := s.store.GetIndexContext(r.Context())
+    if err != nil {
+        writeStoreError(w, err, "Failed to retrieve index")
+        trace.Logf(r.Context(), s.logger, "Error getting index: %v", err)
+        return
+    }
+
+    if writeNotModified(w, r, weakETag(tenantETag(r, "index"), index.LastUpdated), index.LastUpdated) {
+        trace.Logf(r.Context(), s.logger, "Agent index not modified")
+        return
+    }
+
+    if tenant := tenantFrom(r); tenant.restricted() {
+        index = &models.AgentIndex{LastUpdated: index.LastUpdated, Agents: visibleSummaries(tenant, index.Agents)}
+    }
+    writeData(w, r, index)
+    trace.Logf(r.Context(), s.logger, "Successfully retrieved agent index")
+}
+
+func (s *APIServer) handleGetLatestWeeklyReport(w http.ResponseWriter, r *http.Request) {
+    trace.Logf(r.Context(), s.logger, "Received request to get latest weekly report")
+    report, err := s.store.LatestReport(models.ReportWeekly)
+    if err != nil {
+        writeStoreError(w, err, "No weekly report available")
+        trace.Logf(r.Context(), s.logger, "Error getting weekly report: %v", err)
+        return
+    }
+
+    if writeNotModified(w, r, weakETag("report-weekly", report.GeneratedAt), report.GeneratedAt) {
+        trace.Logf(r.Context(), s.logger, "Weekly report not modified")
+        return
+    }
+
+    writeData(w, r, report)
+    trace.Logf(r.Context(), s.logger, "Successfully retrieved weekly report")
+}
+
+func (s *APIServer) handleGetFeedback(w http.ResponseWriter, r *http.Request) {
+    if !requireAdmin(w, r) {
+        return
+    }
+    stats := []storage.VariantFeedback{}
+    if s.feedback != nil {
+        stats = s.feedback.Stats()
+    }
+
+    writeData(w, r, stats)
+}
+
+func (s *APIServer) handleGetCacheStats(w http.ResponseWriter, r *http.Request) {
+    if !requireAdmin(w, r) {
+        return
+    }
+    writeData(w, r, s.store.CacheStats())
+}
+
+func (s *APIServer) handleExport(w http.ResponseWriter, r *http.Request) {
+    format := r.URL.Query().Get("format")
+    if format == "" {
+        format = export.FormatCSV
+    }
+    trace.Logf(r.Context(), s.logger, "Received request to export agents as %s", format)
+
+    if format == FormatNDJSON {
+        s.streamAgents(w, r)
+        return
+    }
+    if format != export.FormatCSV && format != export.FormatXLSX {
+        writeError(w, http.StatusBadRequest, CodeBadRequest, "Unsupported format, use csv, xlsx or ndjson",
+            map[string]string{"format": format})
+        return
+    }
+
+    columns, err := export.ParseColumns(r.URL.Query().Get("metrics"))
+    if err != nil {
+        writeError(w, http.StatusBadRequest, CodeBadRequest, err.Error(), nil)
+        return
+    }
+
+    tenant := tenantFrom(r)
+    rows, err := export.RowsWhere(s.store, columns, func(agent *models.Agent) bool { return tenant.canSeeAgent(agent.Status) })
+    if err != nil {
+        writeStoreError(w, err, "Failed to export agents")
+        trace.Logf(r.Context(), s.logger, "Error building export: %v", err)
+        return
+    }
+
+    w.Header().Set("Content-Type", export.ContentType(format))
+    w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=\"agents.%s\"", format))
+    if err := export.Write(w, format, columns, rows); err != nil {
+        trace.Logf(r.Context(), s.logger, "Error writing export: %v", err)
+        return
+    }
+    trace.Logf(r.Context(), s.logger, "Successfully exported %d agents as %s", len(rows), format)
+}
+
+// streamAgents writes every full agent record as NDJSON, loading one agent at
+// a time so large exports don't have to fit in memory
+func (s *APIServer) streamAgents(w http.ResponseWriter, r *http.Request) {
+    index, err := s.store.GetIndexContext(r.Context())
+    if err != nil {
+        writeStoreError(w, err, "Failed to export agents")
+        trace.Logf(r.Context(), s.logger, "Error building export: %v", err)
+        return
+    }
+
+    fields, err := requestFields(r)
+    if err == nil && fields != nil {
+        err = fields.validate(reflect.TypeOf(models.Agent{}), "")
+    }
+    if err != nil {
+        writeError(w, http.StatusBadRequest, CodeBadRequest, "Invalid field selection",
+            map[string]string{"fields": r.URL.Query().Get("fields"), "error": err.Error()})
+        return
+    }
+
+    w.Header().Set("Content-Type", encoders[FormatNDJSON].contentType())
+    w.Header().Set("Content-Disposition", "attachment; filename=\"agents.ndjson\"")
+    stream := newNDJSONStream(w)
+    defer stream.Flush()
+
+    exported := 0
+    for _, summary := range visibleSummaries(tenantFrom(r), index.Agents) {
+        if r.Context().Err() != nil {
+            trace.Logf(r.Context(), s.logger, "Export cancelled after %d agents", exported)
+            return
+        }
+        agent, err := s.store.GetAgentContext(r.Context(), summary.ID)
+        if err != nil {
+            trace.Logf(r.Context(), s.logger, "Skipping agent %s in export: %v", summary.ID, err)
+            continue
+        }
+        if !tenantFrom(r).canSeeAgent(agent.Status) {
+            continue
+        }
+        var record interface{} = s.presentAgent(w, agent)
+        if fields != nil {
+            if record, err = selectFields(record, fields); err != nil {
+                trace.Logf(r.Context(), s.logger, "Skipping agent %s in export: %v", summary.ID, err)
+                continue
+            }
+        }
+        if err := stream.Write(record); err != nil {
+            trace.Logf(r.Context(), s.logger, "Error writing export: %v", err)
+            return
+        }
+        exported++
+    }
+    trace.Logf(r.Context(), s.logger, "Successfully exported %d agents as ndjson", exported)
+}
+
+func (s *APIServer) handleListPipelines(w http.ResponseWriter, r *http.Request) {
+    pipelines := []pipeline.Pipeline{}
+    if s.pipelines != nil {
+        pipelines = s.pipelines.List()
+    }
+
+    writeData(w, r, pipelines)
+}
+
+// handleRunPipeline runs a pipeline on the agent named by ?agent=. Runs make
+// paid LLM calls, so they take a POST from an admin.
+func (s *APIServer) handleRunPipeline(w http.ResponseWriter, r *http.Request) {
+    if !requireAdmin(w, r) {
+        return
+    }
+    name := mux.Vars(r)["name"]
+    agentQuery := r.URL.Query().Get("agent")
+    trace.Logf(r.Context(), s.logger, "Received request to run pipeline %s for %s", name, agentQuery)
+
+    if s.pipelines == nil {
+        writeError(w, http.StatusNotFound, CodeNotFound, "Pipelines are not configured", nil)
+        return
+    }
+    if agentQuery == "" {
+        writeError(w, http.StatusBadRequest, CodeBadRequest, "Missing agent parameter", nil)
+        return
+    }
+
+    // Resolve the agent first so a restricted tenant can't run pipelines on
+    // agents outside its view
+    if tenant := tenantFrom(r); tenant.restricted() {
+        agent, err := s.store.FindAgent(r.Context(), agentQuery)
+        if err == nil && !tenant.canSeeAgent(agent.Status) {
+            err = storage.ErrNotFound
+        }
+        if err != nil {
+            writeStoreError(w, err, "Agent not found")
+            return
+        }
+    }
+
+    state, err := s.pipelines.Run(llm.WithCommand(r.Context(), "api:pipeline"), name, agentQuery)
     if err != nil {
-        http.Error(w, "Failed to retrieve index", http.StatusInternalServerError)
-        s.logger.Printf("Error getting index: %v", err)
+        writeStoreError(w, err, "Failed to run pipeline")
+        trace.Logf(r.Context(), s.logger, "Error running pipeline %s: %v", name, err)
         return
     }
 
-    w.Header().Set("Content-Type", "application/json")
-    json.NewEncoder(w).Encode(index)
-    s.logger.Println("Successfully retrieved agent index")
+    writeData(w, r, map[string]interface{}{
+        "pipeline": name,
+        "agent_id": state.Agent.ID,
+        "data":     state.Data,
+        "analysis": state.LLM,
+        "output":   state.Output,
+    })
+    trace.Logf(r.Context(), s.logger, "Successfully ran pipeline %s", name)
 }
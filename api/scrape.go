@@ -0,0 +1,248 @@
+package api
+
+import (
+    "encoding/json"
+    "errors"
+    "fmt"
+    "net/http"
+    "strconv"
+    "time"
+    "anondd/utils/models"
+    "anondd/utils/storage"
+    "anondd/utils/trace"
+    "anondd/utils/webscraper"
+    "github.com/gorilla/mux"
+)
+
+// maxDryRunIDs bounds how many pages one dry run request renders
+const maxDryRunIDs = 10
+
+// dryRunTimePerID extends the write deadline for each page rendered
+const dryRunTimePerID = 90 * time.Second
+
+// maxScrapeRunsListed bounds ?runs for the scrape runs endpoint
+const maxScrapeRunsListed = 100
+
+// ScrapeRunReport is the recent run history of a source with its failures
+// totalled by kind
+type ScrapeRunReport struct {
+    Source string             `json:"source"`
+    Runs   []models.ScrapeRun `json:"runs"` // Newest first
+    Errors map[string]int     `json:"errors"`
+}
+
+// ScrapeFailures lists the failure records of failing agent IDs
+type ScrapeFailures struct {
+    Count    int                              `json:"count"`
+    Failures map[string]storage.FailureRecord `json:"failures"` // By agent ID
+}
+
+// SetScraper enables the scrape endpoints with the given scraper
+func (s *APIServer) SetScraper(scraper *webscraper.VirtualsScraper) {
+    s.scraper = scraper
+}
+
+// handleDryRunScrape fetches and parses ?ids= (or the most urgent due IDs)
+// and returns what a scrape would change, writing nothing
+func (s *APIServer) handleDryRunScrape(w http.ResponseWriter, r *http.Request) {
+    if !requireAdmin(w, r) {
+        return
+    }
+    if s.scraper == nil {
+        writeError(w, http.StatusNotFound, CodeNotFound, "Scraper is not configured", nil)
+        return
+    }
+
+    raw := r.URL.Query().Get("ids")
+    ids, err := webscraper.ParseIDs(raw)
+    if err != nil {
+        writeError(w, http.StatusBadRequest, CodeBadRequest, err.Error(), map[string]string{"ids": raw})
+        return
+    }
+    if len(ids) > maxDryRunIDs {
+        writeError(w, http.StatusBadRequest, CodeBadRequest, "Too many ids for one dry run",
+            map[string]int{"max": maxDryRunIDs})
+        return
+    }
+    trace.Logf(r.Context(), s.logger, "Received request for a dry run scrape of %v", ids)
+
+    pages := len(ids)
+    if pages == 0 {
+        pages = webscraper.DryRunDefaultLimit
+    }
+    deadline := time.Now().Add(time.Duration(pages) * dryRunTimePerID)
+    if err := http.NewResponseController(w).SetWriteDeadline(deadline); err != nil {
+        trace.Logf(r.Context(), s.logger, "Unable to extend write deadline for dry run: %v", err)
+    }
+
+    report, err := s.scraper.DryRun(ids)
+    if err != nil {
+        writeError(w, http.StatusInternalServerError, CodeInternal, "Dry run failed", nil)
+        trace.Logf(r.Context(), s.logger, "Error running dry run scrape: %v", err)
+        return
+    }
+    writeData(w, r, report)
+}
+
+// handleStartScrape starts a scrape run with ?profile= (full by default) in
+// the background; its result shows up in /api/scrape/runs
+func (s *APIServer) handleStartScrape(w http.ResponseWriter, r *http.Request) {
+    if !requireAdmin(w, r) {
+        return
+    }
+    if s.scraper == nil {
+        writeError(w, http.StatusNotFound, CodeNotFound, "Scraper is not configured", nil)
+        return
+    }
+    profile := r.URL.Query().Get("profile")
+    if profile == "" {
+        profile = webscraper.ProfileFull
+    }
+    trace.Logf(r.Context(), s.logger, "Received request to start a %s scrape", profile)
+
+    err := s.scraper.StartProfile(profile)
+    switch {
+    case errors.Is(err, webscraper.ErrUnknownProfile):
+        writeError(w, http.StatusBadRequest, CodeBadRequest, "Unknown scrape profile", map[string]string{"profile": profile})
+        return
+    case errors.Is(err, webscraper.ErrScrapeInProgress):
+        writeError(w, http.StatusConflict, CodeConflict, "A scrape is already running", nil)
+        return
+    case err != nil:
+        writeError(w, http.StatusInternalServerError, CodeInternal, "Failed to start scrape", nil)
+        trace.Logf(r.Context(), s.logger, "Error starting %s scrape: %v", profile, err)
+        return
+    }
+    w.Header().Set("Content-Type", "application/json")
+    w.WriteHeader(http.StatusAccepted)
+    json.NewEncoder(w).Encode(map[string]string{"profile": profile, "status": "started"})
+}
+
+// handleGetScrapeProfiles lists the profiles scrapes can be run with
+func (s *APIServer) handleGetScrapeProfiles(w http.ResponseWriter, r *http.Request) {
+    if !requireAdmin(w, r) {
+        return
+    }
+    if s.scraper == nil {
+        writeError(w, http.StatusNotFound, CodeNotFound, "Scraper is not configured", nil)
+        return
+    }
+    writeData(w, r, s.scraper.Profiles())
+}
+
+// handleGetScrapeStrategies returns how each wait strategy has done when
+// retrying pages that rendered without agent content
+func (s *APIServer) handleGetScrapeStrategies(w http.ResponseWriter, r *http.Request) {
+    if !requireAdmin(w, r) {
+        return
+    }
+    if s.scraper == nil {
+        writeError(w, http.StatusNotFound, CodeNotFound, "Scraper is not configured", nil)
+        return
+    }
+    writeData(w, r, s.scraper.WaitStrategyStats())
+}
+
+// handleGetScrapeSessions lists the browser sessions saved per source
+func (s *APIServer) handleGetScrapeSessions(w http.ResponseWriter, r *http.Request) {
+    if !requireAdmin(w, r) {
+        return
+    }
+    sessions, err := webscraper.Sessions()
+    if err != nil {
+        writeError(w, http.StatusInternalServerError, CodeInternal, "Failed to read sessions", nil)
+        trace.Logf(r.Context(), s.logger, "Error listing scrape sessions: %v", err)
+        return
+    }
+    writeData(w, r, sessions)
+}
+
+// handleResetScrapeSession forgets a source's saved browser session
+func (s *APIServer) handleResetScrapeSession(w http.ResponseWriter, r *http.Request) {
+    if !requireAdmin(w, r) {
+        return
+    }
+    source := mux.Vars(r)["source"]
+    if err := webscraper.ResetSession(source); err != nil {
+        writeError(w, http.StatusBadRequest, CodeBadRequest, "Unable to reset session", map[string]string{"source": source})
+        trace.Logf(r.Context(), s.logger, "Error resetting %s session: %v", source, err)
+        return
+    }
+    trace.Logf(r.Context(), s.logger, "Reset the %s browser session", source)
+    writeData(w, r, map[string]string{"source": source, "status": "reset"})
+}
+
+// handleGetScrapePipeline returns the saturation of the scrape stage queues,
+// live while a scrape runs and from the last run otherwise
+func (s *APIServer) handleGetScrapePipeline(w http.ResponseWriter, r *http.Request) {
+    if !requireAdmin(w, r) {
+        return
+    }
+    if s.scraper == nil {
+        writeError(w, http.StatusNotFound, CodeNotFound, "Scraper is not configured", nil)
+        return
+    }
+    writeData(w, r, s.scraper.PipelineStats())
+}
+
+// handleGetScrapeRuns returns the last ?runs (20 by default) runs of ?source
+// (virtuals by default) with failure counts by kind
+func (s *APIServer) handleGetScrapeRuns(w http.ResponseWriter, r *http.Request) {
+    if !requireAdmin(w, r) {
+        return
+    }
+    source := r.URL.Query().Get("source")
+    if source == "" {
+        source = models.SourceVirtuals
+    }
+    limit := 20
+    if raw := r.URL.Query().Get("runs"); raw != "" {
+        parsed, err := strconv.Atoi(raw)
+        if err != nil || parsed < 1 || parsed > maxScrapeRunsListed {
+            writeError(w, http.StatusBadRequest, CodeBadRequest, fmt.Sprintf("Invalid runs, use 1 to %d", maxScrapeRunsListed),
+                map[string]string{"runs": raw})
+            return
+        }
+        limit = parsed
+    }
+    trace.Logf(r.Context(), s.logger, "Received request for %d %s scrape runs", limit, source)
+
+    runs, err := s.store.ScrapeRuns(source)
+    if err != nil {
+        writeStoreError(w, err, "Failed to retrieve scrape runs")
+        trace.Logf(r.Context(), s.logger, "Error getting scrape runs: %v", err)
+        return
+    }
+    if len(runs) > limit {
+        runs = runs[len(runs)-limit:]
+    }
+
+    report := ScrapeRunReport{Source: source, Runs: make([]models.ScrapeRun, 0, len(runs)), Errors: make(map[string]int)}
+    for i := len(runs) - 1; i >= 0; i-- {
+        report.Runs = append(report.Runs, runs[i])
+        for kind, count := range runs[i].Errors {
+            report.Errors[kind] += count
+        }
+    }
+    writeData(w, r, report)
+}
+
+// handleGetScrapeFailures returns the failure records of IDs that failed
+// since their last success, with counts by kind, optionally only those that
+// failed with ?kind=
+func (s *APIServer) handleGetScrapeFailures(w http.ResponseWriter, r *http.Request) {
+    if !requireAdmin(w, r) {
+        return
+    }
+    trace.Logf(r.Context(), s.logger, "Received request for scrape failure records")
+
+    records := s.store.FailureRecords()
+    if kind := r.URL.Query().Get("kind"); kind != "" {
+        for id, record := range records {
+            if record.ErrorKinds[kind] == 0 {
+                delete(records, id)
+            }
+        }
+    }
+    writeData(w, r, ScrapeFailures{Count: len(records), Failures: records})
+}
@@ -0,0 +1,63 @@
+package api
+
+import (
+    "fmt"
+    "net/http"
+    "strconv"
+    "strings"
+    "anondd/utils/search"
+    "anondd/utils/trace"
+)
+
+// SetSearch enables the full-text search endpoint with the given index
+func (s *APIServer) SetSearch(index *search.Index) {
+    s.search = index
+}
+
+// handleSearch ranks agents and reports against ?q=, with snippets around
+// the matches. ?kind=agent|report narrows the documents and ?limit= caps
+// the hits.
+func (s *APIServer) handleSearch(w http.ResponseWriter, r *http.Request) {
+    if s.search == nil {
+        writeError(w, http.StatusNotFound, CodeNotFound, "Search is not enabled", nil)
+        return
+    }
+    query := strings.TrimSpace(r.URL.Query().Get("q"))
+    if query == "" {
+        writeError(w, http.StatusBadRequest, CodeBadRequest, "Missing search query q", nil)
+        return
+    }
+    kind := r.URL.Query().Get("kind")
+    if kind != "" && kind != search.KindAgent && kind != search.KindReport {
+        writeError(w, http.StatusBadRequest, CodeBadRequest, "Unsupported kind, use agent or report",
+            map[string]string{"kind": kind})
+        return
+    }
+    limit := search.DefaultLimit
+    if raw := r.URL.Query().Get("limit"); raw != "" {
+        parsed, err := strconv.Atoi(raw)
+        if err != nil || parsed < 1 || parsed > search.MaxLimit {
+            writeError(w, http.StatusBadRequest, CodeBadRequest, fmt.Sprintf("Invalid limit, use 1 to %d", search.MaxLimit),
+                map[string]string{"limit": raw})
+            return
+        }
+        limit = parsed
+    }
+    trace.Logf(r.Context(), s.logger, "Received search for %q", query)
+
+    tenant := tenantFrom(r)
+    hits, err := s.search.Search(query, search.Options{
+        Kind:  kind,
+        Limit: limit,
+        Allow: func(hit search.Hit) bool { return hit.Kind != search.KindAgent || tenant.canSeeAgent(hit.Status) },
+    })
+    if err != nil {
+        writeError(w, http.StatusInternalServerError, CodeInternal, "Search failed", nil)
+        trace.Logf(r.Context(), s.logger, "Error searching for %q: %v", query, err)
+        return
+    }
+    if hits == nil {
+        hits = []search.Hit{}
+    }
+    writeData(w, r, hits)
+}
@@ -0,0 +1,73 @@
+package api
+
+import (
+    "context"
+    "net/http"
+    "time"
+)
+
+// ServerConfig controls how the API server listens
+type ServerConfig struct {
+    Addr         string
+    TLSCertFile  string
+    TLSKeyFile   string
+    ReadTimeout  time.Duration
+    WriteTimeout time.Duration
+    IdleTimeout  time.Duration
+    // PublicURL is the externally reachable base URL used in share links;
+    // empty leaves the links relative
+    PublicURL string
+    // LegacyStats keeps the deprecated raw stats text in agent responses
+    // alongside stats_detail
+    LegacyStats bool
+    // AdminKey unlocks the operational endpoints when tenancy is disabled;
+    // empty keeps them closed
+    AdminKey string
+}
+
+// DefaultServerConfig returns plain HTTP on :8080 with conservative timeouts
+func DefaultServerConfig() ServerConfig {
+    return ServerConfig{
+        Addr:         ":8080",
+        ReadTimeout:  15 * time.Second,
+        WriteTimeout: 30 * time.Second,
+        IdleTimeout:  60 * time.Second,
+    }
+}
+
+// TLSEnabled reports whether both a certificate and key were configured
+func (c ServerConfig) TLSEnabled() bool {
+    return c.TLSCertFile != "" && c.TLSKeyFile != ""
+}
+
+// newHTTPServer builds the http.Server serving router with the configured timeouts
+func newHTTPServer(config ServerConfig, router http.Handler) *http.Server {
+    return &http.Server{
+        Addr:         config.Addr,
+        Handler:      router,
+        ReadTimeout:  config.ReadTimeout,
+        WriteTimeout: config.WriteTimeout,
+        IdleTimeout:  config.IdleTimeout,
+    }
+}
+
+// Start serves the API until Shutdown is called. It returns http.ErrServerClosed
+// after a graceful shutdown.
+func (s *APIServer) Start() error {
+    if s.config.TLSEnabled() {
+        s.logger.Printf("Starting HTTPS server on %s...", s.config.Addr)
+        return s.server.ListenAndServeTLS(s.config.TLSCertFile, s.config.TLSKeyFile)
+    }
+    s.logger.Printf("Starting HTTP server on %s...", s.config.Addr)
+    return s.server.ListenAndServe()
+}
+
+// Shutdown gracefully stops the server
+func (s *APIServer) Shutdown(ctx context.Context) error {
+    return s.server.Shutdown(ctx)
+}
+
+// Handler returns the API router, e.g. for mounting under another server
+func (s *APIServer) Handler() http.Handler {
+    return s.router
+}
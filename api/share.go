@@ -0,0 +1,96 @@
+package api
+
+import (
+    "encoding/json"
+    "html/template"
+    "net/http"
+    "strings"
+    "anondd/utils/trace"
+    "github.com/gorilla/mux"
+)
+
+// maxShareBytes caps the size of a shared report body
+const maxShareBytes = 256 << 10
+
+var shareTemplate = template.Must(template.New("share").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<title>{{.Title}}</title>
+<style>
+body { font-family: -apple-system, sans-serif; max-width: 760px; margin: 2em auto; padding: 0 1em; line-height: 1.5; color: #222; }
+pre { white-space: pre-wrap; font-family: inherit; }
+footer { color: #888; font-size: 0.85em; margin-top: 2em; }
+</style>
+</head>
+<body>
+<h1>{{.Title}}</h1>
+<pre>{{.Text}}</pre>
+<footer>Generated {{.CreatedAt.Format "Jan 2, 2006 15:04 MST"}} by anondd. Not financial advice.</footer>
+</body>
+</html>
+`))
+
+type shareRequest struct {
+    Title string `json:"title"`
+    Text  string `json:"text"`
+}
+
+type shareResponse struct {
+    ID  string `json:"id"`
+    URL string `json:"url"`
+}
+
+// shareURL builds the link for a shared report from the configured public
+// URL, never the request's Host header; without one the path is relative
+func (s *APIServer) shareURL(id string) string {
+    return strings.TrimSuffix(s.config.PublicURL, "/") + "/r/" + id
+}
+
+// handleCreateShare stores a report for /r/{id}. Only admins may create
+// shares, so the domain can't be used to host arbitrary text.
+func (s *APIServer) handleCreateShare(w http.ResponseWriter, r *http.Request) {
+    if !requireAdmin(w, r) {
+        return
+    }
+    var req shareRequest
+    if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxShareBytes)).Decode(&req); err != nil {
+        writeError(w, http.StatusBadRequest, CodeBadRequest, "Invalid share request body", nil)
+        return
+    }
+    if strings.TrimSpace(req.Text) == "" {
+        writeError(w, http.StatusBadRequest, CodeBadRequest, "Missing text", nil)
+        return
+    }
+    if req.Title == "" {
+        req.Title = "anondd report"
+    }
+
+    share, err := s.store.SaveShare(req.Title, req.Text)
+    if err != nil {
+        writeStoreError(w, err, "Failed to store report")
+        trace.Logf(r.Context(), s.logger, "Error saving share: %v", err)
+        return
+    }
+
+    w.Header().Set("Content-Type", "application/json")
+    w.WriteHeader(http.StatusCreated)
+    json.NewEncoder(w).Encode(shareResponse{ID: share.ID, URL: s.shareURL(share.ID)})
+    trace.Logf(r.Context(), s.logger, "Stored shared report %s", share.ID)
+}
+
+func (s *APIServer) handleViewShare(w http.ResponseWriter, r *http.Request) {
+    id := mux.Vars(r)["id"]
+    share, err := s.store.GetShare(id)
+    if err != nil {
+        writeStoreError(w, err, "Report not found")
+        trace.Logf(r.Context(), s.logger, "Error getting share %s: %v", id, err)
+        return
+    }
+
+    w.Header().Set("Content-Type", "text/html; charset=utf-8")
+    if err := shareTemplate.Execute(w, share); err != nil {
+        trace.Logf(r.Context(), s.logger, "Error rendering share %s: %v", id, err)
+    }
+}
@@ -0,0 +1,66 @@
+package api
+
+import (
+    "sort"
+    "strings"
+    "time"
+    "anondd/utils/models"
+)
+
+// summaryLess orders agent summaries by one field, ascending
+var summaryLess = map[string]func(a, b models.AgentSummary) bool{
+    "name": func(a, b models.AgentSummary) bool {
+        return strings.ToLower(a.Name) < strings.ToLower(b.Name)
+    },
+    "first_seen": func(a, b models.AgentSummary) bool {
+        return a.FirstSeen.Before(b.FirstSeen)
+    },
+    "launched": func(a, b models.AgentSummary) bool {
+        return a.LaunchDate().Before(b.LaunchDate())
+    },
+    "risk": func(a, b models.AgentSummary) bool {
+        return *a.RiskScore < *b.RiskScore
+    },
+}
+
+// validSort reports whether sortSummaries accepts field
+func validSort(field string) bool {
+    _, ok := summaryLess[strings.TrimPrefix(field, "-")]
+    return ok
+}
+
+// sortSummaries sorts agents by a field name, prefixed with "-" for descending.
+// Agents without a risk score always sort last when sorting by risk. It
+// returns false for unknown fields.
+func sortSummaries(agents []models.AgentSummary, field string) bool {
+    descending := strings.HasPrefix(field, "-")
+    less, ok := summaryLess[strings.TrimPrefix(field, "-")]
+    if !ok {
+        return false
+    }
+    byRisk := strings.TrimPrefix(field, "-") == "risk"
+
+    sort.SliceStable(agents, func(i, j int) bool {
+        a, b := agents[i], agents[j]
+        if byRisk && (a.RiskScore == nil || b.RiskScore == nil) {
+            return a.RiskScore != nil && b.RiskScore == nil
+        }
+        if descending {
+            return less(b, a)
+        }
+        return less(a, b)
+    })
+    return true
+}
+
+// launchedWithin keeps agents whose launch date is known and within maxAge of now
+func launchedWithin(agents []models.AgentSummary, maxAge time.Duration, now time.Time) []models.AgentSummary {
+    cutoff := now.Add(-maxAge)
+    kept := make([]models.AgentSummary, 0, len(agents))
+    for _, agent := range agents {
+        if launched := agent.LaunchDate(); !launched.IsZero() && launched.After(cutoff) {
+            kept = append(kept, agent)
+        }
+    }
+    return kept
+}
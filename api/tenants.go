@@ -0,0 +1,357 @@
+package api
+
+import (
+    "context"
+    "crypto/sha256"
+    "crypto/subtle"
+    "encoding/hex"
+    "encoding/json"
+    "fmt"
+    "net/http"
+    "os"
+    "strconv"
+    "strings"
+    "time"
+    "anondd/utils/models"
+    "anondd/utils/shared"
+    "anondd/utils/trace"
+)
+
+// apiKeyHeader carries a tenant's API key; "Authorization: Bearer <key>" also works
+const apiKeyHeader = "X-API-Key"
+
+// usageRetention is how long daily usage counters are kept
+const usageRetention = 32 * 24 * time.Hour
+
+// maxUsageDays bounds the ?days window of the usage endpoint
+const maxUsageDays = 31
+
+// Tenant is one API consumer with its keys, limits and data view
+type Tenant struct {
+    ID         string   `json:"id"`
+    Name       string   `json:"name"`
+    Keys       []string `json:"keys"`
+    RateLimit  int      `json:"rate_limit"`         // Requests per minute, 0 for unlimited
+    DailyQuota int      `json:"daily_quota"`        // Requests per UTC day, 0 for unlimited
+    Sources    []string `json:"sources,omitempty"`  // Sources the tenant may see, empty for all
+    Statuses   []string `json:"statuses,omitempty"` // Agent statuses the tenant may see, empty for all
+    Admin      bool     `json:"admin"`              // May use operational endpoints and see all usage
+}
+
+// CanSee reports whether the tenant's view includes an agent from source
+// with status. A nil tenant (tenancy disabled) sees everything.
+func (t *Tenant) CanSee(source, status string) bool {
+    if t == nil {
+        return true
+    }
+    return allowed(t.Sources, source) && allowed(t.Statuses, status)
+}
+
+// restricted reports whether the tenant's view excludes any agents
+func (t *Tenant) restricted() bool {
+    return t != nil && (len(t.Sources) > 0 || len(t.Statuses) > 0)
+}
+
+// canSeeAgent applies the tenant's view to an agent; every stored agent is
+// scraped from Virtuals
+func (t *Tenant) canSeeAgent(status string) bool {
+    return t.CanSee(models.SourceVirtuals, status)
+}
+
+func allowed(list []string, value string) bool {
+    if len(list) == 0 {
+        return true
+    }
+    for _, item := range list {
+        if strings.EqualFold(item, value) {
+            return true
+        }
+    }
+    return false
+}
+
+// Tenants maps API keys to the tenants that own them
+type Tenants struct {
+    byKey map[string]*Tenant // Keyed by the SHA-256 of the API key
+    byID  map[string]*Tenant
+}
+
+// LoadTenants reads the tenant list from a JSON file. A missing file returns
+// nil, leaving the API open without keys or quotas.
+func LoadTenants(path string) (*Tenants, error) {
+    data, err := os.ReadFile(path)
+    if os.IsNotExist(err) {
+        return nil, nil
+    }
+    if err != nil {
+        return nil, fmt.Errorf("failed to read tenants: %w", err)
+    }
+
+    var list []Tenant
+    if err := json.Unmarshal(data, &list); err != nil {
+        return nil, fmt.Errorf("failed to unmarshal tenants: %w", err)
+    }
+
+    tenants := &Tenants{byKey: make(map[string]*Tenant), byID: make(map[string]*Tenant)}
+    for i := range list {
+        tenant := &list[i]
+        if tenant.ID == "" {
+            return nil, fmt.Errorf("tenant %d: missing id", i+1)
+        }
+        if _, exists := tenants.byID[tenant.ID]; exists {
+            return nil, fmt.Errorf("tenant %s: duplicate id", tenant.ID)
+        }
+        if len(tenant.Keys) == 0 {
+            return nil, fmt.Errorf("tenant %s: no keys", tenant.ID)
+        }
+        for _, key := range tenant.Keys {
+            hash := hashKey(key)
+            if _, exists := tenants.byKey[hash]; exists || key == "" {
+                return nil, fmt.Errorf("tenant %s: empty or duplicate key", tenant.ID)
+            }
+            tenants.byKey[hash] = tenant
+        }
+        // Keys are only needed to build the lookup
+        tenant.Keys = nil
+        tenants.byID[tenant.ID] = tenant
+    }
+    return tenants, nil
+}
+
+// Len returns the number of tenants
+func (t *Tenants) Len() int {
+    return len(t.byID)
+}
+
+func (t *Tenants) lookup(key string) *Tenant {
+    return t.byKey[hashKey(key)]
+}
+
+func hashKey(key string) string {
+    sum := sha256.Sum256([]byte(key))
+    return hex.EncodeToString(sum[:])
+}
+
+// SetTenants requires API keys from the given tenants, metering their usage
+// and enforcing their limits in the shared store
+func (s *APIServer) SetTenants(tenants *Tenants, usage shared.Store) {
+    s.tenants = tenants
+    s.usage = usage
+}
+
+type tenantKey struct{}
+
+type adminKey struct{}
+
+// tenantFrom returns the tenant making the request, or nil when tenancy is disabled
+func tenantFrom(r *http.Request) *Tenant {
+    tenant, _ := r.Context().Value(tenantKey{}).(*Tenant)
+    return tenant
+}
+
+// requestKey reads the API key from the X-API-Key or Authorization header
+func requestKey(r *http.Request) string {
+    if key := r.Header.Get(apiKeyHeader); key != "" {
+        return key
+    }
+    if auth := r.Header.Get("Authorization"); strings.HasPrefix(auth, "Bearer ") {
+        return strings.TrimSpace(strings.TrimPrefix(auth, "Bearer "))
+    }
+    return ""
+}
+
+func rateKey(tenantID string, now time.Time) string {
+    return "anondd:api:rate:" + tenantID + ":" + now.UTC().Format("200601021504")
+}
+
+func usageKey(tenantID string, day time.Time) string {
+    return "anondd:api:usage:" + tenantID + ":" + day.UTC().Format("2006-01-02")
+}
+
+// tenantMiddleware resolves the request's API key to a tenant, enforces its
+// rate limit and daily quota, and meters the request. Public share links and
+// the /readyz probe stay open. Counter errors let requests through rather
+// than failing the API. Personal keys made with /apikey identify their user,
+// and act as a tenant of their own when tenancy is enabled.
+func (s *APIServer) tenantMiddleware(next http.Handler) http.Handler {
+    return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+        key := requestKey(r)
+        r, user := s.withUser(r, key)
+        if s.tenants == nil || strings.HasPrefix(r.URL.Path, "/r/") || r.URL.Path == "/readyz" {
+            if s.tenants == nil && s.config.AdminKey != "" &&
+                subtle.ConstantTimeCompare([]byte(key), []byte(s.config.AdminKey)) == 1 {
+                r = r.WithContext(context.WithValue(r.Context(), adminKey{}, true))
+            }
+            next.ServeHTTP(w, r)
+            return
+        }
+
+        if key == "" {
+            writeError(w, http.StatusUnauthorized, CodeUnauthorized, "Missing API key", nil)
+            return
+        }
+        tenant := s.tenants.lookup(key)
+        if tenant == nil && user != nil {
+            tenant = userTenant(user)
+        }
+        if tenant == nil {
+            writeError(w, http.StatusUnauthorized, CodeUnauthorized, "Invalid API key", nil)
+            return
+        }
+
+        now := time.Now()
+        if tenant.RateLimit > 0 {
+            count, err := s.usage.Incr(r.Context(), rateKey(tenant.ID, now), time.Minute)
+            if err != nil {
+                trace.Logf(r.Context(), s.logger, "Failed to count requests for tenant %s: %v", tenant.ID, err)
+            } else {
+                w.Header().Set("X-RateLimit-Limit", strconv.Itoa(tenant.RateLimit))
+                w.Header().Set("X-RateLimit-Remaining", strconv.FormatInt(max(int64(tenant.RateLimit)-count, 0), 10))
+                if count > int64(tenant.RateLimit) {
+                    retry := 60 - now.Second()
+                    w.Header().Set("Retry-After", strconv.Itoa(retry))
+                    writeError(w, http.StatusTooManyRequests, CodeRateLimited, "Rate limit exceeded",
+                        map[string]int{"limit": tenant.RateLimit, "retry_after": retry})
+                    return
+                }
+            }
+        }
+
+        used, err := s.usage.Incr(r.Context(), usageKey(tenant.ID, now), usageRetention)
+        if err != nil {
+            trace.Logf(r.Context(), s.logger, "Failed to meter usage for tenant %s: %v", tenant.ID, err)
+        } else if tenant.DailyQuota > 0 && used > int64(tenant.DailyQuota) {
+            tomorrow := now.UTC().Truncate(24 * time.Hour).Add(24 * time.Hour)
+            w.Header().Set("Retry-After", strconv.Itoa(int(tomorrow.Sub(now).Seconds())+1))
+            writeError(w, http.StatusTooManyRequests, CodeRateLimited, "Daily quota exceeded",
+                map[string]int{"quota": tenant.DailyQuota})
+            return
+        }
+
+        next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), tenantKey{}, tenant)))
+    })
+}
+
+// requireAdmin writes a forbidden error and returns false unless the tenant
+// is an admin or, with tenancy disabled, the request carries the configured
+// admin key. Without tenants or an admin key the endpoints stay closed.
+func requireAdmin(w http.ResponseWriter, r *http.Request) bool {
+    if tenant := tenantFrom(r); tenant != nil && tenant.Admin {
+        return true
+    }
+    if admin, _ := r.Context().Value(adminKey{}).(bool); admin {
+        return true
+    }
+    writeError(w, http.StatusForbidden, CodeForbidden, "Admin API key required", nil)
+    return false
+}
+
+// tenantETag scopes a cache validator to the tenant's view of the data
+func tenantETag(r *http.Request, resource string) string {
+    if tenant := tenantFrom(r); tenant.restricted() {
+        return resource + "@" + tenant.ID
+    }
+    return resource
+}
+
+// visibleSummaries returns the summaries in the tenant's view
+func visibleSummaries(tenant *Tenant, summaries []models.AgentSummary) []models.AgentSummary {
+    if !tenant.restricted() {
+        return summaries
+    }
+    visible := []models.AgentSummary{}
+    for _, summary := range summaries {
+        if tenant.canSeeAgent(summary.Status) {
+            visible = append(visible, summary)
+        }
+    }
+    return visible
+}
+
+// visibleChanges returns the change events for agents in the tenant's view
+func (s *APIServer) visibleChanges(ctx context.Context, tenant *Tenant, events []models.ChangeEvent) ([]models.ChangeEvent, error) {
+    if !tenant.restricted() {
+        return events, nil
+    }
+    index, err := s.store.GetIndexContext(ctx)
+    if err != nil {
+        return nil, err
+    }
+    statuses := make(map[string]string, len(index.Agents))
+    for _, summary := range index.Agents {
+        statuses[summary.ID] = summary.Status
+    }
+
+    visible := []models.ChangeEvent{}
+    for _, event := range events {
+        if status, known := statuses[event.AgentID]; known && tenant.canSeeAgent(status) {
+            visible = append(visible, event)
+        }
+    }
+    return visible, nil
+}
+
+// UsageDay is one tenant's request count for a UTC day
+type UsageDay struct {
+    Date     string `json:"date"`
+    Requests int64  `json:"requests"`
+}
+
+// TenantUsage is a tenant's recent metered usage and limits
+type TenantUsage struct {
+    Tenant     string     `json:"tenant"`
+    RateLimit  int        `json:"rate_limit"`
+    DailyQuota int        `json:"daily_quota"`
+    Days       []UsageDay `json:"days"`
+}
+
+// handleGetUsage returns the calling tenant's usage for the last ?days (7 by
+// default). Admins may pass ?tenant= to see another tenant's usage.
+func (s *APIServer) handleGetUsage(w http.ResponseWriter, r *http.Request) {
+    tenant := tenantFrom(r)
+    if tenant == nil {
+        writeError(w, http.StatusNotFound, CodeNotFound, "API tenants are not configured", nil)
+        return
+    }
+    if id := r.URL.Query().Get("tenant"); id != "" && id != tenant.ID {
+        if !requireAdmin(w, r) {
+            return
+        }
+        other, exists := s.tenants.byID[id]
+        if !exists {
+            writeError(w, http.StatusNotFound, CodeNotFound, "Unknown tenant", map[string]string{"tenant": id})
+            return
+        }
+        tenant = other
+    }
+
+    days := 7
+    if raw := r.URL.Query().Get("days"); raw != "" {
+        parsed, err := strconv.Atoi(raw)
+        if err != nil || parsed < 1 || parsed > maxUsageDays {
+            writeError(w, http.StatusBadRequest, CodeBadRequest, fmt.Sprintf("Invalid days, use 1 to %d", maxUsageDays),
+                map[string]string{"days": raw})
+            return
+        }
+        days = parsed
+    }
+
+    usage := TenantUsage{Tenant: tenant.ID, RateLimit: tenant.RateLimit, DailyQuota: tenant.DailyQuota}
+    today := time.Now().UTC()
+    for i := 0; i < days; i++ {
+        day := today.AddDate(0, 0, -i)
+        var requests int64
+        value, found, err := s.usage.Get(r.Context(), usageKey(tenant.ID, day))
+        if err != nil {
+            writeError(w, http.StatusInternalServerError, CodeInternal, "Failed to read usage", nil)
+            trace.Logf(r.Context(), s.logger, "Error reading usage for tenant %s: %v", tenant.ID, err)
+            return
+        }
+        if found {
+            requests, _ = strconv.ParseInt(value, 10, 64)
+        }
+        usage.Days = append(usage.Days, UsageDay{Date: day.Format("2006-01-02"), Requests: requests})
+    }
+
+    writeData(w, r, usage)
+}
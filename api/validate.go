@@ -0,0 +1,28 @@
+package api
+
+import (
+    "encoding/json"
+    "net/http"
+    "anondd/utils/models"
+    "anondd/utils/trace"
+)
+
+// maxValidateBytes caps the size of an agent submitted for validation
+const maxValidateBytes = 256 << 10
+
+// handleValidateAgent runs a submitted agent through the cleaning,
+// normalization and validation applied before agents are stored, and
+// returns the errors and warnings found with the agent as it would be saved.
+// Nothing is written to the store.
+func (s *APIServer) handleValidateAgent(w http.ResponseWriter, r *http.Request) {
+    var agent models.Agent
+    if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxValidateBytes)).Decode(&agent); err != nil {
+        writeError(w, http.StatusBadRequest, CodeBadRequest, "Invalid agent JSON", map[string]string{"error": err.Error()})
+        return
+    }
+
+    result := models.ValidateAgent(agent)
+    trace.Logf(r.Context(), s.logger, "Validated submitted agent %q: %d errors, %d warnings",
+        result.Agent.Name, len(result.Errors), len(result.Warnings))
+    writeData(w, r, result)
+}
@@ -0,0 +1,382 @@
+// Command agentctl manages the agent data directory offline, without the bot
+// or API running: listing and showing agents, rebuilding the index, running
+// migrations, exporting datasets, purging old raw pages, replaying the
+// parser over archived pages and maintaining the parser fixtures.
+package main
+
+import (
+    "context"
+    "encoding/json"
+    "flag"
+    "fmt"
+    "io"
+    "log"
+    "os"
+    "path/filepath"
+    "strings"
+    "text/tabwriter"
+    "time"
+    "anondd/utils/export"
+    "anondd/utils/models"
+    "anondd/utils/storage"
+    "anondd/utils/webscraper"
+)
+
+const usage = `Usage: agentctl [-data dir] <command> [flags]
+
+Commands:
+  list            List stored agents (-status, -json)
+  show <agent>    Print one agent by ID or name
+  rebuild-index   Rebuild agent_index.json from the stored agent records
+  migrate         Upgrade agent records to the current schema (-dry-run)
+  export          Export agents as csv or xlsx (-format, -o, -status)
+  purge-raw       Delete parsed raw pages older than -older-than (-dry-run)
+  replay          Re-parse archived raw pages and report parser regressions
+                  (-ids, -pin, -json, -v); exits 1 on regressions
+  fixtures add    Copy the newest archived pages of -ids into the parser
+                  fixtures, anonymized, with the current parser's output
+  fixtures update Regenerate every fixture's expected output after an
+                  intended parser change; review the diff before committing
+  fixtures check  Parse every fixture and compare with its expected output
+                  (-json, -v); exits 1 on mismatches
+
+Set AGENT_STORAGE_FORMAT=compact when the data uses compact storage.
+replay reads the scraper's files under ./training_data whatever -data is.
+fixtures reads and writes utils/webscraper/testdata/parser unless given -dir.
+`
+
+func main() {
+    logger := log.New(os.Stderr, "[agentctl] ", log.LstdFlags)
+
+    flags := flag.NewFlagSet("agentctl", flag.ExitOnError)
+    dataDir := flags.String("data", "training_data", "data directory")
+    flags.Usage = func() { fmt.Fprint(os.Stderr, usage) }
+    flags.Parse(os.Args[1:])
+    if flags.NArg() == 0 {
+        flags.Usage()
+        os.Exit(2)
+    }
+
+    ctx, cancel := context.WithCancel(context.Background())
+    defer cancel()
+
+    store := storage.NewAgentStore(*dataDir, logger)
+    if os.Getenv("AGENT_STORAGE_FORMAT") == "compact" {
+        if err := store.EnableCompactStorage(ctx, storage.DefaultCompactInterval); err != nil {
+            logger.Fatalf("Failed to open compact agent storage: %v", err)
+        }
+    }
+
+    command, args := flags.Arg(0), flags.Args()[1:]
+    var err error
+    switch command {
+    case "list":
+        err = runList(store, args)
+    case "show":
+        err = runShow(ctx, store, args)
+    case "rebuild-index":
+        err = runRebuildIndex(store)
+    case "migrate":
+        err = runMigrate(store, args)
+    case "export":
+        err = runExport(store, args)
+    case "purge-raw":
+        err = runPurgeRaw(*dataDir, args)
+    case "replay":
+        err = runReplay(store, logger, args)
+    case "fixtures":
+        err = runFixtures(store, logger, args)
+    default:
+        flags.Usage()
+        os.Exit(2)
+    }
+    if err != nil {
+        logger.Fatalf("%s: %v", command, err)
+    }
+}
+
+func runList(store *storage.AgentStore, args []string) error {
+    flags := flag.NewFlagSet("list", flag.ExitOnError)
+    status := flags.String("status", "", "only agents with this status")
+    asJSON := flags.Bool("json", false, "print the index entries as JSON")
+    flags.Parse(args)
+
+    index, err := store.GetIndex()
+    if err != nil {
+        return err
+    }
+    var summaries []models.AgentSummary
+    for _, summary := range index.Agents {
+        if *status == "" || summary.Status == *status {
+            summaries = append(summaries, summary)
+        }
+    }
+
+    if *asJSON {
+        return printJSON(summaries)
+    }
+    w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+    fmt.Fprintln(w, "ID\tNAME\tPRICE\tSTATUS\tFIRST SEEN")
+    for _, summary := range summaries {
+        fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", summary.ID, summary.Name, summary.Price, summary.Status,
+            summary.FirstSeen.Format("2006-01-02"))
+    }
+    if err := w.Flush(); err != nil {
+        return err
+    }
+    fmt.Fprintf(os.Stderr, "%d of %d agents, index updated %s\n", len(summaries), len(index.Agents),
+        index.LastUpdated.Format(time.RFC3339))
+    return nil
+}
+
+func runShow(ctx context.Context, store *storage.AgentStore, args []string) error {
+    if len(args) == 0 {
+        return fmt.Errorf("usage: agentctl show <agent id or name>")
+    }
+    agent, err := store.FindAgent(ctx, strings.Join(args, " "))
+    if err != nil {
+        return err
+    }
+    return printJSON(agent)
+}
+
+func runRebuildIndex(store *storage.AgentStore) error {
+    count, err := store.RebuildIndex()
+    if err != nil {
+        return err
+    }
+    fmt.Printf("Rebuilt the index from %d agent records; the previous index was saved as a version\n", count)
+    return nil
+}
+
+func runMigrate(store *storage.AgentStore, args []string) error {
+    flags := flag.NewFlagSet("migrate", flag.ExitOnError)
+    dryRun := flags.Bool("dry-run", false, "report what would change without writing")
+    flags.Parse(args)
+
+    report, err := store.Migrate(*dryRun)
+    if err != nil {
+        return err
+    }
+    return printJSON(report)
+}
+
+func runExport(store *storage.AgentStore, args []string) error {
+    flags := flag.NewFlagSet("export", flag.ExitOnError)
+    format := flags.String("format", export.FormatCSV, "csv or xlsx")
+    output := flags.String("o", "", "output file (default stdout)")
+    status := flags.String("status", "", "only agents with this status")
+    metrics := flags.String("metrics", "", "comma-separated metrics to export (default all standard ones)")
+    flags.Parse(args)
+
+    columns, err := export.ParseColumns(*metrics)
+    if err != nil {
+        return err
+    }
+
+    var keep func(*models.Agent) bool
+    if *status != "" {
+        keep = func(agent *models.Agent) bool { return agent.Status == *status }
+    }
+    rows, err := export.RowsWhere(store, columns, keep)
+    if err != nil {
+        return err
+    }
+
+    var w io.Writer = os.Stdout
+    if *output != "" {
+        file, err := os.Create(*output)
+        if err != nil {
+            return err
+        }
+        defer file.Close()
+        w = file
+    }
+    if err := export.Write(w, *format, columns, rows); err != nil {
+        return err
+    }
+    fmt.Fprintf(os.Stderr, "Exported %d agents\n", len(rows))
+    return nil
+}
+
+func runPurgeRaw(dataDir string, args []string) error {
+    flags := flag.NewFlagSet("purge-raw", flag.ExitOnError)
+    olderThan := flags.Duration("older-than", 30*24*time.Hour, "age of parsed pages to delete")
+    dryRun := flags.Bool("dry-run", false, "count the pages without deleting them")
+    flags.Parse(args)
+
+    pages := webscraper.NewPageQueue(filepath.Join(dataDir, "raw", "pages"))
+    cutoff := time.Now().Add(-*olderThan)
+    if *dryRun {
+        ids, err := pages.Purgeable(cutoff)
+        if err != nil {
+            return err
+        }
+        fmt.Printf("Would delete %d parsed pages fetched before %s\n", len(ids), cutoff.Format(time.RFC3339))
+        return nil
+    }
+
+    removed, err := pages.Purge(cutoff)
+    if err != nil {
+        return err
+    }
+    fmt.Printf("Deleted %d parsed pages fetched before %s\n", removed, cutoff.Format(time.RFC3339))
+    return nil
+}
+
+func runReplay(store *storage.AgentStore, logger *log.Logger, args []string) error {
+    flags := flag.NewFlagSet("replay", flag.ExitOnError)
+    rawIDs := flags.String("ids", "", "only these agent IDs, comma separated")
+    pin := flags.Bool("pin", false, "pin the results of snapshots without regressions as their baseline")
+    asJSON := flags.Bool("json", false, "print the full report as JSON")
+    verbose := flags.Bool("v", false, "show the parser's log")
+    flags.Parse(args)
+
+    ids, err := webscraper.ParseIDs(*rawIDs)
+    if err != nil {
+        return err
+    }
+    scraperLogger := log.New(io.Discard, "", 0)
+    if *verbose {
+        scraperLogger = logger
+    }
+    report, err := webscraper.NewVirtualsScraper(scraperLogger, store).Replay(ids, *pin)
+    if err != nil {
+        return err
+    }
+
+    if *asJSON {
+        if err := printJSON(report); err != nil {
+            return err
+        }
+    } else {
+        w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+        fmt.Fprintln(w, "ID\tKIND\tCAPTURED\tBASELINE\tRESULT")
+        for _, result := range report.Results {
+            if !result.Regressed() && len(result.Gained) == 0 {
+                continue
+            }
+            fmt.Fprintf(w, "%d\t%s\t%s\t%s\t%s\n", result.SourceID, result.Kind,
+                result.CapturedAt.Format("2006-01-02 15:04"), result.Baseline, describeReplay(result))
+        }
+        if err := w.Flush(); err != nil {
+            return err
+        }
+    }
+    fmt.Fprintf(os.Stderr, "%d snapshots: %d parsed, %d failed, %d regressed, %d improved, %d without a baseline, %d pinned\n",
+        report.Snapshots, report.Parsed, report.Failed, report.Regressed, report.Improved, report.NoBaseline, report.Pinned)
+    if report.Regressed > 0 {
+        os.Exit(1)
+    }
+    return nil
+}
+
+// describeReplay summarizes what changed for one snapshot
+func describeReplay(result webscraper.ReplayResult) string {
+    var parts []string
+    if result.Error != "" {
+        parts = append(parts, "error: "+result.Error)
+    }
+    for _, change := range result.Regressions {
+        parts = append(parts, fmt.Sprintf("%s %q -> %q", change.Field, change.Before, change.After))
+    }
+    if len(result.Gained) > 0 {
+        parts = append(parts, "gained "+strings.Join(result.Gained, ", "))
+    }
+    return strings.Join(parts, "; ")
+}
+
+func runFixtures(store *storage.AgentStore, logger *log.Logger, args []string) error {
+    if len(args) == 0 {
+        return fmt.Errorf("missing subcommand, use add, update or check")
+    }
+    subcommand := args[0]
+    flags := flag.NewFlagSet("fixtures "+subcommand, flag.ExitOnError)
+    dir := flags.String("dir", webscraper.DefaultFixtureDir, "fixture directory")
+    rawIDs := flags.String("ids", "", "agent IDs to add, comma separated")
+    asJSON := flags.Bool("json", false, "print the results as JSON")
+    verbose := flags.Bool("v", false, "show the parser's log")
+    flags.Parse(args[1:])
+
+    parserLogger := log.New(io.Discard, "", 0)
+    if *verbose {
+        parserLogger = logger
+    }
+
+    switch subcommand {
+    case "add", "update":
+        var fixtures []webscraper.Fixture
+        var err error
+        if subcommand == "add" {
+            ids, parseErr := webscraper.ParseIDs(*rawIDs)
+            if parseErr != nil {
+                return parseErr
+            }
+            fixtures, err = webscraper.NewVirtualsScraper(parserLogger, store).AddFixtures(*dir, ids)
+        } else {
+            fixtures, err = webscraper.UpdateFixtures(*dir, parserLogger)
+        }
+        for _, fixture := range fixtures {
+            outcome := "parsed"
+            if fixture.Error != "" {
+                outcome = "error: " + fixture.Error
+            } else if fixture.Agent != nil {
+                outcome = fmt.Sprintf("parsed %q", fixture.Agent.Name)
+            }
+            fmt.Printf("%s\t%s\n", fixture.Name, outcome)
+        }
+        return err
+    case "check":
+        results, err := webscraper.CheckFixtures(*dir, parserLogger)
+        if err != nil {
+            return err
+        }
+        failed := 0
+        for _, result := range results {
+            if !result.Passed() {
+                failed++
+            }
+        }
+        if *asJSON {
+            if err := printJSON(results); err != nil {
+                return err
+            }
+        } else {
+            w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+            fmt.Fprintln(w, "FIXTURE\tRESULT")
+            for _, result := range results {
+                fmt.Fprintf(w, "%s\t%s\n", result.Name, describeFixture(result))
+            }
+            if err := w.Flush(); err != nil {
+                return err
+            }
+        }
+        fmt.Fprintf(os.Stderr, "%d fixtures: %d passed, %d failed\n", len(results), len(results)-failed, failed)
+        if failed > 0 {
+            os.Exit(1)
+        }
+        return nil
+    }
+    return fmt.Errorf("unknown subcommand %q, use add, update or check", subcommand)
+}
+
+// describeFixture summarizes how a fixture's parse differs from its expected output
+func describeFixture(result webscraper.FixtureResult) string {
+    if result.Error != "" {
+        return "error: " + result.Error
+    }
+    if result.Passed() {
+        return "ok"
+    }
+    parts := []string{"mismatched " + strings.Join(result.Mismatches, ", ")}
+    for _, change := range result.Changes {
+        parts = append(parts, fmt.Sprintf("%s %q -> %q", change.Field, change.Before, change.After))
+    }
+    return strings.Join(parts, "; ")
+}
+
+func printJSON(v interface{}) error {
+    encoder := json.NewEncoder(os.Stdout)
+    encoder.SetIndent("", "  ")
+    return encoder.Encode(v)
+}
@@ -0,0 +1,105 @@
+// Command loadtest checks the API against its performance budget: it serves
+// the API in-process over a seeded or existing data directory, drives
+// /api/agents and /api/agents/{id} at a constant rate and fails when p99
+// latency or the error rate exceeds the budget.
+package main
+
+import (
+    "context"
+    "flag"
+    "fmt"
+    "io"
+    "log"
+    "net/http"
+    "net/http/httptest"
+    "os"
+    "time"
+    "anondd/api"
+    "anondd/utils/perf"
+    "anondd/utils/storage"
+)
+
+func main() {
+    dataDir := flag.String("data", "", "data directory to serve; empty seeds a temporary one")
+    agents := flag.Int("agents", 20000, "synthetic agents to seed when -data is empty")
+    rate := flag.Int("rate", 500, "requests per second")
+    duration := flag.Duration("duration", 30*time.Second, "how long to send requests")
+    p99 := flag.Duration("p99", perf.DefaultBudget.P99, "p99 latency budget")
+    errorRate := flag.Float64("error-rate", perf.DefaultBudget.ErrorRate, "share of requests allowed to fail")
+    verbose := flag.Bool("v", false, "log API requests")
+    flag.Parse()
+
+    logger := log.New(os.Stderr, "[loadtest] ", log.LstdFlags)
+    storeLogger := log.New(io.Discard, "", 0)
+    if *verbose {
+        storeLogger = logger
+    }
+
+    dir, tmpDir := *dataDir, ""
+    if dir == "" {
+        var err error
+        if tmpDir, err = os.MkdirTemp("", "anondd-loadtest-"); err != nil {
+            logger.Fatalf("Failed to create data directory: %v", err)
+        }
+        defer os.RemoveAll(tmpDir)
+        dir = tmpDir
+    }
+
+    store := storage.NewAgentStore(dir, storeLogger)
+    var ids []string
+    if *dataDir == "" {
+        logger.Printf("Seeding %d synthetic agents...", *agents)
+        seeded, err := perf.Seed(store, *agents)
+        if err != nil {
+            logger.Fatalf("Failed to seed agents: %v", err)
+        }
+        ids = seeded
+    } else {
+        index, err := store.GetIndex()
+        if err != nil {
+            logger.Fatalf("Failed to load index: %v", err)
+        }
+        for _, summary := range index.Agents {
+            ids = append(ids, summary.ID)
+        }
+    }
+    if len(ids) == 0 {
+        logger.Fatalf("No agents to request")
+    }
+
+    server := api.NewAPIServer(store, api.DefaultServerConfig(), storeLogger)
+    server.SetupRoutes()
+    httpServer := httptest.NewServer(server.Handler())
+    defer httpServer.Close()
+
+    // Every other request lists agents; the rest spread over single agents
+    var targets []perf.Target
+    for i, id := range ids {
+        if i == 1000 {
+            break
+        }
+        targets = append(targets,
+            perf.Target{URL: httpServer.URL + "/api/agents"},
+            perf.Target{URL: httpServer.URL + "/api/agents/" + id})
+    }
+
+    client := &http.Client{
+        Timeout:   10 * time.Second,
+        Transport: &http.Transport{MaxIdleConnsPerHost: *rate},
+    }
+    logger.Printf("Sending %d requests/s for %v over %d agents...", *rate, *duration, len(ids))
+    result := perf.Attack(context.Background(), client, targets, *rate, *duration)
+    fmt.Println(result)
+
+    budget := perf.Budget{P99: *p99, ErrorRate: *errorRate}
+    if err := result.Check(budget); err != nil {
+        logger.Printf("Over budget: %v", err)
+        // os.Exit skips the deferred cleanup
+        httpServer.Close()
+        if tmpDir != "" {
+            os.RemoveAll(tmpDir)
+        }
+        os.Exit(1)
+    }
+    logger.Printf("Within budget: p99 %v <= %v", result.P99, budget.P99)
+}
@@ -0,0 +1,165 @@
+package llm
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"anondd/utils/events"
+)
+
+// maxAuditBody caps how much of each request and response body is audited
+const maxAuditBody = 64 << 10
+
+// redactedSecret replaces API keys in audited requests and responses
+const redactedSecret = "[REDACTED]"
+
+// secretPatterns match credentials that may appear in bodies or URLs besides
+// the client's own key
+var secretPatterns = []*regexp.Regexp{
+	regexp.MustCompile(`sk-[A-Za-z0-9_-]{16,}`),
+	regexp.MustCompile(`(?i)(bearer\s+)[A-Za-z0-9._~+/=-]+`),
+	regexp.MustCompile(`(?i)((?:api_?key|token)=)[^&\s"]+`),
+}
+
+// EnableAudit wraps the client's HTTP transport so every call to the LLM API
+// is published as an LLMExchange event, with API keys redacted, for the
+// audit log to store. Calls are only audited once SetEvents gave a bus.
+func (client *OpenRouterClient) EnableAudit() {
+	if _, wrapped := client.HTTPClient.Transport.(*auditTransport); wrapped {
+		return
+	}
+	base := client.HTTPClient.Transport
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	// Copy the client so a shared one keeps its transport for other callers
+	audited := *client.HTTPClient
+	audited.Transport = &auditTransport{base: base, client: client}
+	client.HTTPClient = &audited
+}
+
+// redact replaces the client's API key and anything shaped like a credential
+func (client *OpenRouterClient) redact(text string) string {
+	if client.APIKey != "" {
+		text = strings.ReplaceAll(text, client.APIKey, redactedSecret)
+	}
+	for i, pattern := range secretPatterns {
+		if i == 0 {
+			text = pattern.ReplaceAllString(text, redactedSecret)
+		} else {
+			text = pattern.ReplaceAllString(text, "${1}"+redactedSecret)
+		}
+	}
+	return text
+}
+
+// auditTransport publishes each request and its response once the caller
+// finished reading the response body
+type auditTransport struct {
+	base   http.RoundTripper
+	client *OpenRouterClient
+}
+
+func (t *auditTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	exchange := events.LLMExchange{
+		At:        time.Now(),
+		PromptKey: auditPromptKeyFrom(req.Context()),
+		Command:   CommandFrom(req.Context()),
+		Method:    req.Method,
+		URL:       req.URL.String(),
+	}
+	// GetBody hands out a fresh copy, leaving the body sent untouched
+	if req.GetBody != nil {
+		if body, err := req.GetBody(); err == nil {
+			var truncated bool
+			exchange.Request, truncated = readCapped(body)
+			exchange.Truncated = truncated
+			body.Close()
+		}
+	}
+
+	resp, err := t.base.RoundTrip(req)
+	if err != nil {
+		exchange.Err = err
+		t.publish(exchange)
+		return nil, err
+	}
+	exchange.Status = resp.StatusCode
+	resp.Body = &auditBody{ReadCloser: resp.Body, transport: t, exchange: exchange}
+	return resp, nil
+}
+
+func (t *auditTransport) publish(exchange events.LLMExchange) {
+	exchange.Duration = time.Since(exchange.At)
+	exchange.URL = t.client.redact(exchange.URL)
+	exchange.Request = t.client.redact(exchange.Request)
+	exchange.Response = t.client.redact(exchange.Response)
+	t.client.events.Publish(exchange)
+}
+
+// auditBody keeps what the caller reads of a response, up to maxAuditBody,
+// and publishes the exchange at EOF, on a read error or on Close
+type auditBody struct {
+	io.ReadCloser
+	transport *auditTransport
+	exchange  events.LLMExchange
+	buf       bytes.Buffer
+	once      sync.Once
+}
+
+func (b *auditBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	if room := maxAuditBody - b.buf.Len(); n > room {
+		b.buf.Write(p[:room])
+		b.exchange.Truncated = true
+	} else {
+		b.buf.Write(p[:n])
+	}
+	if err != nil {
+		if !errors.Is(err, io.EOF) {
+			b.exchange.Err = err
+		}
+		b.finish()
+	}
+	return n, err
+}
+
+func (b *auditBody) Close() error {
+	b.finish()
+	return b.ReadCloser.Close()
+}
+
+func (b *auditBody) finish() {
+	b.once.Do(func() {
+		b.exchange.Response = b.buf.String()
+		b.transport.publish(b.exchange)
+	})
+}
+
+// readCapped reads up to maxAuditBody bytes and reports whether there was more
+func readCapped(r io.Reader) (string, bool) {
+	data, _ := io.ReadAll(io.LimitReader(r, maxAuditBody+1))
+	if len(data) > maxAuditBody {
+		return string(data[:maxAuditBody]), true
+	}
+	return string(data), false
+}
+
+type auditPromptKey struct{}
+
+// withAuditPromptKey labels an HTTP request's ctx with the prompt key it completes
+func withAuditPromptKey(ctx context.Context, promptKey string) context.Context {
+	return context.WithValue(ctx, auditPromptKey{}, promptKey)
+}
+
+func auditPromptKeyFrom(ctx context.Context) string {
+	promptKey, _ := ctx.Value(auditPromptKey{}).(string)
+	return promptKey
+}
@@ -0,0 +1,206 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+// ErrCircuitOpen is returned without calling the API while the provider is
+// considered down. The response returned with it is the fallback text.
+var ErrCircuitOpen = errors.New("LLM provider unavailable, circuit open")
+
+// DefaultFallbackResponse is served while the circuit is open
+const DefaultFallbackResponse = "🛠 My AI brain is having a moment. Try again in a few minutes."
+
+// Circuit breaker states
+const (
+	CircuitClosed   = "closed"
+	CircuitOpen     = "open"
+	CircuitHalfOpen = "half_open"
+)
+
+// BreakerConfig sets when the circuit breaker trips and recovers
+type BreakerConfig struct {
+	Window       int           // Recent calls the error rate is computed over
+	MinCalls     int           // Calls needed in the window before the circuit can open
+	ErrorRate    float64       // Share of failed or slow calls that opens the circuit
+	SlowCall     time.Duration // Calls slower than this count as failures
+	OpenFor      time.Duration // How long the circuit stays open before a probe
+	ProbeTimeout time.Duration // Bounds the single probe call while half open
+}
+
+// DefaultBreakerConfig opens after half of the last 20 calls fail or take over
+// 30s, and probes for recovery after a minute
+func DefaultBreakerConfig() BreakerConfig {
+	return BreakerConfig{
+		Window:       20,
+		MinCalls:     5,
+		ErrorRate:    0.5,
+		SlowCall:     30 * time.Second,
+		OpenFor:      time.Minute,
+		ProbeTimeout: 20 * time.Second,
+	}
+}
+
+// ProviderHealth is the circuit breaker's view of the LLM provider
+type ProviderHealth struct {
+	State      string        `json:"state"`
+	Calls      int           `json:"calls"`      // Calls in the window
+	ErrorRate  float64       `json:"error_rate"` // Share failed or slow
+	AvgLatency time.Duration `json:"avg_latency"`
+	OpenedAt   time.Time     `json:"opened_at,omitempty"`
+	Rejected   int           `json:"rejected"` // Calls served the fallback since the circuit last opened
+}
+
+type callOutcome struct {
+	failed  bool
+	latency time.Duration
+}
+
+// breaker tracks recent call outcomes and fails fast while the provider is down
+type breaker struct {
+	mu       sync.Mutex
+	config   BreakerConfig
+	state    string
+	outcomes []callOutcome // Ring of the last Window outcomes
+	next     int
+	openedAt time.Time
+	probing  bool
+	rejected int
+	fallback string
+}
+
+func newBreaker(config BreakerConfig) *breaker {
+	return &breaker{config: config, state: CircuitClosed, fallback: DefaultFallbackResponse}
+}
+
+// SetBreaker replaces the circuit breaker settings, resetting its state
+func (client *OpenRouterClient) SetBreaker(config BreakerConfig) {
+	fallback := client.breaker.fallbackText()
+	client.breaker = newBreaker(config)
+	client.breaker.fallback = fallback
+}
+
+// SetFallbackResponse changes the text served while the circuit is open
+func (client *OpenRouterClient) SetFallbackResponse(text string) {
+	client.breaker.mu.Lock()
+	defer client.breaker.mu.Unlock()
+	client.breaker.fallback = text
+}
+
+// Health reports the circuit state and recent error rate and latency
+func (client *OpenRouterClient) Health() ProviderHealth {
+	return client.breaker.health()
+}
+
+// allow reports whether a call may go to the provider. While open it rejects
+// calls until OpenFor has passed, then lets a single probe through. The
+// returned context bounds the probe.
+func (b *breaker) allow(ctx context.Context, now time.Time) (context.Context, context.CancelFunc, bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch b.state {
+	case CircuitOpen:
+		if now.Sub(b.openedAt) < b.config.OpenFor {
+			b.rejected++
+			return ctx, func() {}, false
+		}
+		b.state = CircuitHalfOpen
+		fallthrough
+	case CircuitHalfOpen:
+		if b.probing {
+			b.rejected++
+			return ctx, func() {}, false
+		}
+		b.probing = true
+		probeCtx, cancel := context.WithTimeout(ctx, b.config.ProbeTimeout)
+		return probeCtx, cancel, true
+	}
+	return ctx, func() {}, true
+}
+
+// record adds a call outcome, closing the circuit after a successful probe
+// and opening it after a failed probe or when the error rate crosses the threshold
+func (b *breaker) record(err error, latency time.Duration, now time.Time) {
+	// Callers giving up isn't the provider's fault
+	if errors.Is(err, context.Canceled) {
+		b.mu.Lock()
+		b.probing = false
+		b.mu.Unlock()
+		return
+	}
+	failed := err != nil || latency > b.config.SlowCall
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.state == CircuitHalfOpen {
+		b.probing = false
+		if failed {
+			b.open(now)
+			return
+		}
+		b.state = CircuitClosed
+		b.outcomes, b.next = nil, 0
+	}
+
+	outcome := callOutcome{failed: failed, latency: latency}
+	if len(b.outcomes) < b.config.Window {
+		b.outcomes = append(b.outcomes, outcome)
+	} else {
+		b.outcomes[b.next] = outcome
+		b.next = (b.next + 1) % b.config.Window
+	}
+
+	if b.state == CircuitClosed && len(b.outcomes) >= b.config.MinCalls && b.errorRate() >= b.config.ErrorRate {
+		b.open(now)
+	}
+}
+
+// open trips the circuit; callers must hold mu
+func (b *breaker) open(now time.Time) {
+	b.state = CircuitOpen
+	b.openedAt = now
+	b.rejected = 0
+}
+
+// errorRate is the share of failed outcomes in the window; callers must hold mu
+func (b *breaker) errorRate() float64 {
+	if len(b.outcomes) == 0 {
+		return 0
+	}
+	failed := 0
+	for _, outcome := range b.outcomes {
+		if outcome.failed {
+			failed++
+		}
+	}
+	return float64(failed) / float64(len(b.outcomes))
+}
+
+func (b *breaker) fallbackText() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.fallback
+}
+
+func (b *breaker) health() ProviderHealth {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	health := ProviderHealth{State: b.state, Calls: len(b.outcomes), ErrorRate: b.errorRate(), Rejected: b.rejected}
+	if b.state != CircuitClosed {
+		health.OpenedAt = b.openedAt
+	}
+	if len(b.outcomes) > 0 {
+		var total time.Duration
+		for _, outcome := range b.outcomes {
+			total += outcome.latency
+		}
+		health.AvgLatency = total / time.Duration(len(b.outcomes))
+	}
+	return health
+}
@@ -0,0 +1,60 @@
+package llm
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"time"
+
+	"anondd/utils/shared"
+	"anondd/utils/trace"
+)
+
+// responseCacheKeyPrefix namespaces cached completions in the shared store
+const responseCacheKeyPrefix = "anondd:llm:"
+
+// responseCache stores completions by system prompt, prompt key and query
+type responseCache struct {
+	store shared.Store
+	ttl   time.Duration
+}
+
+// SetResponseCache caches GetResponse and GetResponseAs results in store for
+// ttl, so identical requests on any instance sharing the store reuse one
+// completion. A nil store or zero ttl disables caching.
+func (client *OpenRouterClient) SetResponseCache(store shared.Store, ttl time.Duration) {
+	if store == nil || ttl <= 0 {
+		client.cache = nil
+		return
+	}
+	client.cache = &responseCache{store: store, ttl: ttl}
+}
+
+func responseCacheKey(key string) string {
+	hash := sha256.Sum256([]byte(key))
+	return responseCacheKeyPrefix + hex.EncodeToString(hash[:])
+}
+
+// cachedResponse runs fetch unless a cached response for key exists, caching its result
+func (client *OpenRouterClient) cachedResponse(ctx context.Context, key string, fetch func() (string, error)) (string, error) {
+	if client.cache == nil {
+		return fetch()
+	}
+
+	cacheKey := responseCacheKey(key)
+	if response, found, err := client.cache.store.Get(ctx, cacheKey); err != nil {
+		trace.Logf(ctx, client.Logger, "Error reading LLM response cache: %v", err)
+	} else if found {
+		trace.Logf(ctx, client.Logger, "Serving cached LLM response")
+		return response, nil
+	}
+
+	response, err := fetch()
+	if err != nil {
+		return "", err
+	}
+	if err := client.cache.store.Set(ctx, cacheKey, response, client.cache.ttl); err != nil {
+		trace.Logf(ctx, client.Logger, "Error writing LLM response cache: %v", err)
+	}
+	return response, nil
+}
@@ -4,10 +4,17 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io/ioutil"
 	"log"
 	"net/http"
+	"strings"
+	"time"
+
+	"anondd/utils/events"
+	"anondd/utils/httpclient"
+	"anondd/utils/trace"
 )
 
 // OpenRouterClient interacts with the OpenRouter API.
@@ -17,23 +24,70 @@ type OpenRouterClient struct {
 	HTTPClient *http.Client
 	Logger     *log.Logger
 	Prompts    map[string]string // Predefined prompts for injection
+	flights    flightGroup       // Coalesces concurrent identical requests
+	filters    []ResponseFilter  // Post-processing applied by PostProcess
+	variants   map[string][]PromptVariant // A/B prompt variants per prompt key
+	calls      callCounter                // Completion requests sent today
+	cache      *responseCache             // Optional shared cache of completions
+	events     *events.Bus                // Receives LLMCallFinished events
+	guard      GuardLevel                 // Prompt injection defense for user content
+	breaker    *breaker                   // Fails fast while the provider is down
+	pricing    Pricing                    // Estimates cost when the provider reports none
+	policy     *advicePolicy              // Financial advice guardrails, when set
+	streamKeys map[string]bool            // Prompt keys whose completions are streamed
+	routes     RoutingConfig              // Model and provider preferences by prompt key
+	styles     StyleConfig                // Reply length and register by verbosity level
 }
 
+// completionModel is the model requested unless routing picks another
+const completionModel = "meta-llama/llama-3.2-3b-instruct:free"
+
 // NewOpenRouterClient creates a new OpenRouterClient with predefined prompts.
 func NewOpenRouterClient(apiKey, baseURL string, logger *log.Logger) *OpenRouterClient {
-	return &OpenRouterClient{
+	client := &OpenRouterClient{
 		APIKey:     apiKey,
 		BaseURL:    baseURL,
-		HTTPClient: &http.Client{},
+		HTTPClient: httpclient.WithTimeout(90 * time.Second),
 		Logger:     logger,
+		guard:      GuardNeutralize,
+		breaker:    newBreaker(DefaultBreakerConfig()),
+		styles:     DefaultStyleConfig(),
 		Prompts: map[string]string{
 			"default":    "You are anon dd agent, you have to reply to messages in engaging way, if asked for advice on crypto give solid dd on any random ai name like agent ( advice on crypto, ai agents bull run and politics, be a degen but keep it cool, sometimes be dark , and be nice sometimes like a regen. talk about memes, but be Absurd boy Keep your response concise and not more than two sentences and your name is anonddagent or add, dont be over the top, stay little easy: %s",
 			"summarize":  "Summarize the following text: %s",
 			"translate":  "Translate the following text to Spanish: %s",
 			"custom":     "Analyze and provide detailed insights: %s",
+			"dd_quick":   "Give a quick two-sentence take on this AI agent token, focusing on whether it is worth a closer look: %s",
+			"dd_full":    "As a crypto and AI market analyst, write a full due diligence report on this AI agent covering narrative, influence metrics, token data and overall outlook: %s",
+			"dd_risks":   "Act as a skeptical crypto risk analyst. List only the key risks and red flags for this AI agent token, no upside: %s",
+			"anomaly_dd": "As a crypto and AI market analyst, an AI agent token just made the unusual move given as its trigger. Using the freshly scraped data below, write a short due diligence update: what likely drove the move, whether the data backs it up, and the key risks. Stick to the numbers given: %s",
+			"selector_suggest": "You maintain a web scraper. The CSS selectors for the field below stopped finding it on these agent pages. From the HTML, propose up to five CSS selectors that select the element holding the field's text, most specific and stable first. goquery syntax is supported, including :contains('text'). Reply with one selector per line and nothing else: %s",
+			"persona_chat": "Reply to the following message in character. Keep it concise, no more than two sentences: %s",
+			"new_listing": "Write a catchy one-line intro announcing this newly listed AI agent to a crypto channel. No financial advice, one sentence only: %s",
+			"description_diff": "An AI agent's bio was updated. In one or two sentences, summarize what changed and whether it signals anything (pivot, new feature, rebrand): %s",
+			"tokenomics": "As a crypto tokenomics analyst, assess this AI agent token's market cap, liquidity, holder distribution and volume. Be concise and concrete: %s",
+			"market_chunk":    "Summarize the standout AI agents in this batch for a market overview, noting prices, momentum and anything unusual. Be brief: %s",
+			"market_overview": "As a crypto and AI market analyst, combine the following agent data or batch summaries into one brief market analysis: %s",
+			"ask_agent":  "You are anon dd agent. Answer the user's question about this specific AI agent using only the record, history and earlier conversation below. If the data doesn't cover it, say so. Keep it under four sentences.\n\n%s",
+			"predict":    "You are a cautious crypto analyst. From the AI agent's record and the trend statistics below (moving averages, momentum and a straight-line extrapolation of the recent slope), describe in three or four sentences where the metrics appear to be heading if recent trends continue. Say plainly that this is speculative, that short histories and straight-line extrapolation are unreliable, and never give price targets or trading advice.\n\n%s",
+			"rag":        "You are anon dd agent. Answer the user's question using only the numbered agent data below. Cite the agents you used with their bracket numbers like [1]. If the data does not answer the question, say so. Keep it under five sentences.\n\n%s",
+			"persona_rewrite": "Rewrite the following text in your own voice and tone. Keep every fact, number and name unchanged and don't make it longer: %s",
+			"risk_assessment": "Act as a skeptical crypto risk analyst. Rate how risky this AI agent token is on a scale of 0 (very safe) to 100 (very risky). Start your reply with \"SCORE: <number>\" on its own line, then give one sentence explaining the rating: %s",
+			"weekly_report": "As a crypto and AI market analyst, write a long-form weekly \"State of the Agents\" report for a Telegram channel from the data below. Use short sections: market overview, new launches, top gainers and losers, agents that went quiet, and what to watch next week. Stick to the numbers given and end with a one-line not-financial-advice note:\n\n%s",
+			"compare_agents": "As a crypto and AI market analyst, compare these AI agents using the metric table below, where each value is followed by its rank. In three or four sentences, say which stand out on which metrics and note any trade-offs. Stick to the numbers given: %s",
 			"agent_analysis": "As a crypto and AI market analyst, provide a brief analysis of these agents focusing on their potential value and unique features. Keep it concise and highlight the most interesting aspects: %s",
 		},
 	}
+	client.SetPostProcessing(DefaultPostProcessConfig())
+	return client
+}
+
+// PersonaPresets are the built-in personas selectable with /persona choose.
+var PersonaPresets = map[string]string{
+	"degen":   "You are anon dd agent, a cool crypto degen who talks about AI agents, memes and the bull run. Be absurd sometimes, dark sometimes, nice sometimes, but never over the top.",
+	"analyst": "You are anon dd agent, a sober crypto and AI market analyst. Be precise, cite numbers when you have them and avoid hype.",
+	"skeptic": "You are anon dd agent, a skeptical crypto researcher. Question narratives, point out risks and never shill.",
+	"hype":    "You are anon dd agent, an upbeat crypto hype man. Be enthusiastic and fun, but never promise returns.",
 }
 
 // OpenRouterResponse represents the response from OpenRouter API.
@@ -43,34 +97,128 @@ type OpenRouterResponse struct {
 			Content string `json:"content"`
 		} `json:"message"`
 	} `json:"choices"`
+	Usage *completionUsage `json:"usage,omitempty"`
 }
 
 // GetResponse sends a query to OpenRouter with a specific prompt injected.
+// Concurrent calls with the same prompt key and query share a single request.
 func (client *OpenRouterClient) GetResponse(ctx context.Context, promptKey string, userQuery string) (string, error) {
+	return client.GetResponseAs(ctx, "", promptKey, userQuery)
+}
+
+// GetResponseAs is GetResponse with a system message, such as a chat persona,
+// sent ahead of the prompt. An empty system prompt sends no system message
+// unless the prompt injection guard adds one.
+func (client *OpenRouterClient) GetResponseAs(ctx context.Context, systemPrompt string, promptKey string, userQuery string) (string, error) {
+	ctx, span := trace.StartSpan(ctx, "llm.GetResponse")
+	span.SetAttribute("prompt_key", promptKey)
+
+	key := systemPrompt + "\x00" + promptKey + "\x00" + userQuery + "\x00" + routingCacheKey(ctx) + "\x00" + client.styleCacheKey(ctx)
+	response, err, shared := client.flights.Do(ctx, key, func(ctx context.Context) (string, error) {
+		return client.cachedResponse(ctx, key, func() (string, error) {
+			return client.getResponse(ctx, systemPrompt, promptKey, userQuery)
+		})
+	})
+	if shared {
+		trace.Logf(ctx, client.Logger, "Coalesced duplicate request for prompt key '%s'", promptKey)
+	}
+	if errors.Is(err, ErrCircuitOpen) {
+		response = client.breaker.fallbackText()
+	}
+	span.Finish(err)
+	return response, err
+}
+
+func (client *OpenRouterClient) getResponse(ctx context.Context, systemPrompt string, promptKey string, userQuery string) (string, error) {
 	// Retrieve the prompt template
 	promptTemplate, exists := client.Prompts[promptKey]
 	if !exists {
-		client.Logger.Printf("Prompt key '%s' not found, falling back to default.", promptKey)
+		trace.Logf(ctx, client.Logger, "Prompt key '%s' not found, falling back to default.", promptKey)
 		promptTemplate = client.Prompts["default"]
 	}
+	return client.complete(ctx, systemPrompt, promptKey, promptTemplate, userQuery)
+}
+
+// complete sends one chat completion built from a prompt template and query.
+// Its usage is published under promptKey and the command ctx is labeled with.
+func (client *OpenRouterClient) complete(ctx context.Context, systemPrompt string, promptKey string, promptTemplate string, userQuery string) (response string, err error) {
+	// The verbosity the caller asked for shapes the reply's register and length
+	style := client.style(ctx)
+	if style.Instruction != "" {
+		systemPrompt = strings.TrimSpace(systemPrompt + "\n\n" + style.Instruction)
+	}
+
+	// Fence the user query first; rejected queries never reach the API
+	systemPrompt, userQuery, err = client.guardInput(ctx, systemPrompt, userQuery)
+	if err != nil {
+		return "", err
+	}
+
+	// Fail fast while the provider is down; open-circuit rejections aren't calls
+	ctx, done, allowed := client.breaker.allow(ctx, time.Now())
+	defer done()
+	if !allowed {
+		trace.Logf(ctx, client.Logger, "LLM circuit open, serving fallback response")
+		return client.breaker.fallbackText(), ErrCircuitOpen
+	}
 
 	// Inject the user query into the prompt
 	prompt := fmt.Sprintf(promptTemplate, userQuery)
-	client.Logger.Printf("Generated prompt: %s", prompt)
+	trace.Logf(ctx, client.Logger, "Generated prompt: %s", prompt)
+
+	routing := client.routing(ctx, promptKey)
+	var usage completionUsage
+	startedAt := time.Now()
+	defer func() {
+		client.calls.record(err)
+		client.breaker.record(err, time.Since(startedAt), time.Now())
+		// Estimate what the provider didn't report; failed calls aren't billed
+		if err == nil && usage.PromptTokens == 0 && usage.CompletionTokens == 0 {
+			usage.PromptTokens = estimateTokens(systemPrompt + prompt)
+			usage.CompletionTokens = estimateTokens(response)
+		}
+		if usage.Cost == 0 {
+			usage.Cost = client.pricing.estimate(usage.PromptTokens, usage.CompletionTokens)
+		}
+		client.events.Publish(events.LLMCallFinished{
+			Model:            routing.Model,
+			PromptKey:        promptKey,
+			Command:          CommandFrom(ctx),
+			PromptTokens:     usage.PromptTokens,
+			CompletionTokens: usage.CompletionTokens,
+			Cost:             usage.Cost,
+			Duration:         time.Since(startedAt),
+			Err:              err,
+		})
+	}()
 
 	// Construct the request payload
-	requestBody, err := json.Marshal(map[string]interface{}{
-		"messages": []map[string]string{
-			{"role": "user", "content": prompt},
-		},
-		"model": "meta-llama/llama-3.2-3b-instruct:free",
-	})
+	var messages []map[string]string
+	if systemPrompt != "" {
+		messages = append(messages, map[string]string{"role": "system", "content": systemPrompt})
+	}
+	messages = append(messages, map[string]string{"role": "user", "content": prompt})
+
+	stream := client.streams(promptKey)
+	payload := map[string]interface{}{
+		"messages": messages,
+		"model": routing.Model,
+		"usage": map[string]bool{"include": true},
+		"stream": stream,
+	}
+	if style.MaxTokens > 0 {
+		payload["max_tokens"] = style.MaxTokens
+	}
+	if routing.Provider != nil {
+		payload["provider"] = routing.Provider
+	}
+	requestBody, err := json.Marshal(payload)
 	if err != nil {
 		return "", fmt.Errorf("failed to encode request body: %w", err)
 	}
 
 	// Create the HTTP request
-	req, err := http.NewRequestWithContext(ctx, "POST", client.BaseURL, bytes.NewBuffer(requestBody))
+	req, err := http.NewRequestWithContext(withAuditPromptKey(ctx, promptKey), "POST", client.BaseURL, bytes.NewBuffer(requestBody))
 	if err != nil {
 		return "", fmt.Errorf("failed to create request: %w", err)
 	}
@@ -85,13 +233,18 @@ func (client *OpenRouterClient) GetResponse(ctx context.Context, promptKey strin
 	}
 	defer resp.Body.Close()
 
+	// Streamed responses arrive as server-sent events; errors still don't
+	if stream && resp.StatusCode == http.StatusOK {
+		return client.readStream(ctx, resp.Body, promptKey, &usage)
+	}
+
 	// Read the response body
 	body, err := ioutil.ReadAll(resp.Body)
 	if err != nil {
 		return "", fmt.Errorf("failed to read response body: %w", err)
 	}
 
-	client.Logger.Printf("OpenRouter API Response: %s", string(body))
+	trace.Logf(ctx, client.Logger, "OpenRouter API Response: %s", string(body))
 	if resp.StatusCode != http.StatusOK {
 		return "", fmt.Errorf("OpenRouter API error: %s", string(body))
 	}
@@ -102,6 +255,9 @@ func (client *OpenRouterClient) GetResponse(ctx context.Context, promptKey strin
 		return "", fmt.Errorf("failed to unmarshal response: %w", err)
 	}
 
+	if openRouterResponse.Usage != nil {
+		usage = *openRouterResponse.Usage
+	}
 	if len(openRouterResponse.Choices) > 0 {
 		return openRouterResponse.Choices[0].Message.Content, nil
 	}
@@ -0,0 +1,104 @@
+package llm
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// MapReduceOptions controls how MapReduce splits and summarizes its input
+type MapReduceOptions struct {
+	MaxChunkChars   int    // Upper bound on characters per map prompt
+	Concurrency     int    // Maximum map calls in flight
+	MapPromptKey    string // Prompt used to summarize each chunk
+	ReducePromptKey string // Prompt used to synthesize the final answer
+}
+
+// DefaultMapReduceOptions suits summarizing the agent index with the market prompts
+func DefaultMapReduceOptions() MapReduceOptions {
+	return MapReduceOptions{
+		MaxChunkChars:   6000,
+		Concurrency:     3,
+		MapPromptKey:    "market_chunk",
+		ReducePromptKey: "market_overview",
+	}
+}
+
+// MapReduce summarizes items that may not fit into one prompt: items are
+// grouped into chunks, each chunk is summarized concurrently, and the partial
+// summaries are reduced (recursively if they are still too large) into one answer.
+func (client *OpenRouterClient) MapReduce(ctx context.Context, items []string, opts MapReduceOptions) (string, error) {
+	if len(items) == 0 {
+		return "", fmt.Errorf("nothing to summarize")
+	}
+	if opts.Concurrency < 1 {
+		opts.Concurrency = 1
+	}
+
+	chunks := chunkItems(items, opts.MaxChunkChars)
+	if len(chunks) == 1 {
+		return client.GetResponse(ctx, opts.ReducePromptKey, chunks[0])
+	}
+
+	partials := make([]string, len(chunks))
+	errs := make([]error, len(chunks))
+	sem := make(chan struct{}, opts.Concurrency)
+	var wg sync.WaitGroup
+
+	for i, chunk := range chunks {
+		wg.Add(1)
+		go func(i int, chunk string) {
+			defer wg.Done()
+			select {
+			case sem <- struct{}{}:
+				defer func() { <-sem }()
+			case <-ctx.Done():
+				errs[i] = ctx.Err()
+				return
+			}
+			partials[i], errs[i] = client.GetResponse(ctx, opts.MapPromptKey, chunk)
+		}(i, chunk)
+	}
+	wg.Wait()
+
+	var summaries []string
+	var lastErr error
+	for i, partial := range partials {
+		if errs[i] != nil {
+			lastErr = errs[i]
+			client.Logger.Printf("Map step %d/%d failed: %v", i+1, len(chunks), errs[i])
+			continue
+		}
+		summaries = append(summaries, partial)
+	}
+	if len(summaries) == 0 {
+		return "", fmt.Errorf("all map steps failed: %w", lastErr)
+	}
+
+	// Partial summaries may themselves exceed a chunk; reduce them the same way
+	if len(chunkItems(summaries, opts.MaxChunkChars)) > 1 && len(summaries) < len(items) {
+		return client.MapReduce(ctx, summaries, opts)
+	}
+	return client.GetResponse(ctx, opts.ReducePromptKey, strings.Join(summaries, "\n\n"))
+}
+
+// chunkItems groups items into strings of at most maxChars (a single oversized
+// item becomes its own chunk)
+func chunkItems(items []string, maxChars int) []string {
+	var chunks []string
+	var current strings.Builder
+
+	for _, item := range items {
+		if current.Len() > 0 && maxChars > 0 && current.Len()+len(item) > maxChars {
+			chunks = append(chunks, current.String())
+			current.Reset()
+		}
+		current.WriteString(item)
+		current.WriteString("\n")
+	}
+	if current.Len() > 0 {
+		chunks = append(chunks, current.String())
+	}
+	return chunks
+}
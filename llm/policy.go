@@ -0,0 +1,357 @@
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"anondd/utils/trace"
+)
+
+// AdviceClass is how strongly a response reads as financial advice
+type AdviceClass string
+
+const (
+	// AdviceNone is a response with no investment opinion
+	AdviceNone AdviceClass = "none"
+	// AdviceOpinion gives an investment opinion, such as calling a token undervalued
+	AdviceOpinion AdviceClass = "opinion"
+	// AdviceDirective tells the reader to buy or sell
+	AdviceDirective AdviceClass = "directive"
+)
+
+// PolicyAction is what the advice policy does with a classified response
+type PolicyAction string
+
+const (
+	PolicyAllow    PolicyAction = "allow"    // Send unchanged
+	PolicyDisclaim PolicyAction = "disclaim" // Append the disclaimer
+	PolicyRedact   PolicyAction = "redact"   // Drop sentences with directives and append the disclaimer
+	PolicyBlock    PolicyAction = "block"    // Replace the response with the blocked message
+)
+
+// AdviceRule sets the actions for each class of response. Rules in
+// AdvicePolicyConfig.Rules apply to the listed chats or jurisdictions; an
+// empty action falls back to the default rule's.
+type AdviceRule struct {
+	Chats         []int64      `json:"chats,omitempty"`
+	Jurisdictions []string     `json:"jurisdictions,omitempty"` // Codes such as "US" or "EU"
+	Opinion       PolicyAction `json:"opinion,omitempty"`
+	Directive     PolicyAction `json:"directive,omitempty"`
+}
+
+// AdvicePolicyConfig controls the financial advice guardrails on responses
+type AdvicePolicyConfig struct {
+	// Keys are the prompt keys whose responses are classified
+	Keys    []string     `json:"keys"`
+	Default AdviceRule   `json:"default"`
+	Rules   []AdviceRule `json:"rules"` // A rule naming the chat wins over one naming its jurisdiction
+	// ChatJurisdictions maps chat IDs to jurisdictions, overriding the bot's
+	ChatJurisdictions map[string]string `json:"chat_jurisdictions"`
+	// Disclaimer is appended by disclaim and redact; empty uses the post-processing disclaimer
+	Disclaimer     string `json:"disclaimer"`
+	BlockedMessage string `json:"blocked_message"`
+	// AuditLog is a JSON lines file recording every action other than allow
+	AuditLog string `json:"audit_log"`
+}
+
+// DefaultAdvicePolicyConfig disclaims opinions and redacts buy/sell
+// directives in DD-style responses everywhere.
+func DefaultAdvicePolicyConfig() AdvicePolicyConfig {
+	return AdvicePolicyConfig{
+		Keys:           []string{"dd_quick", "dd_full", "dd_risks", "anomaly_dd", "tokenomics", "agent_analysis", "ask_agent", "compare_agents", "pipeline", "predict"},
+		Default:        AdviceRule{Opinion: PolicyDisclaim, Directive: PolicyRedact},
+		BlockedMessage: "🚫 This response was withheld: it reads as financial advice, which isn't available in this chat.",
+		AuditLog:       "training_data/advice_policy_audit.jsonl",
+	}
+}
+
+// LoadAdvicePolicyConfig reads the advice policy from a JSON file, falling
+// back to the defaults when the file does not exist.
+func LoadAdvicePolicyConfig(path string) (AdvicePolicyConfig, error) {
+	config := DefaultAdvicePolicyConfig()
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return config, nil
+	}
+	if err != nil {
+		return config, fmt.Errorf("failed to read advice policy: %w", err)
+	}
+	if err := json.Unmarshal(data, &config); err != nil {
+		return config, fmt.Errorf("failed to unmarshal advice policy: %w", err)
+	}
+	if err := config.validate(); err != nil {
+		return config, err
+	}
+	return config, nil
+}
+
+func (c AdvicePolicyConfig) validate() error {
+	rules := append([]AdviceRule{c.Default}, c.Rules...)
+	for _, rule := range rules {
+		for _, action := range []PolicyAction{rule.Opinion, rule.Directive} {
+			switch action {
+			case "", PolicyAllow, PolicyDisclaim, PolicyRedact, PolicyBlock:
+			default:
+				return fmt.Errorf("unknown advice policy action %q, use allow, disclaim, redact or block", action)
+			}
+		}
+	}
+	return nil
+}
+
+// directivePatterns match explicit instructions to trade
+var directivePatterns = []*regexp.Regexp{
+	regexp.MustCompile(`(?i)\b(you\s+should|you\s+must|i\s+(would\s+|'d\s+)?recommend|i\s+suggest|definitely|go\s+ahead\s+and|time\s+to|make\s+sure\s+(to|you))\s+(buy|sell|short|ape(\s+into)?|dump|accumulate|load\s+up(\s+on)?|get\s+in(to)?|exit)\b`),
+	regexp.MustCompile(`(?i)\b(buy|sell|short|ape\s+into|load\s+up\s+on|dump)\s+(it\s+|this\s+|now\s+)?(now|immediately|today|asap|before\s+it)\b`),
+	regexp.MustCompile(`(?i)\bstrong\s+(buy|sell)\b`),
+	regexp.MustCompile(`(?im)^\W*(buy|sell|ape\s+in(to)?|load\s+up)\b`),
+}
+
+// opinionPatterns match investment opinions that stop short of a directive
+var opinionPatterns = []*regexp.Regexp{
+	regexp.MustCompile(`(?i)\b(price\s+target|target\s+price|entry\s+(point|zone|price)|take\s+profits?|stop[\s-]loss)\b`),
+	regexp.MustCompile(`(?i)\b(under|over)valued\b`),
+	regexp.MustCompile(`(?i)\b(good|great|solid|bad|safe|risky)\s+(investment|entry|bet|buy|play)\b`),
+	regexp.MustCompile(`(?i)\b(will|could|going\s+to)\s+(moon|pump|dump|10x|100x|\d+x)\b`),
+	regexp.MustCompile(`(?i)\b(bullish|bearish)\s+on\b`),
+}
+
+// ClassifyAdvice reports how strongly text reads as financial advice, and
+// the phrases that decided it
+func ClassifyAdvice(text string) (AdviceClass, []string) {
+	if matches := findAll(directivePatterns, text); len(matches) > 0 {
+		return AdviceDirective, matches
+	}
+	if matches := findAll(opinionPatterns, text); len(matches) > 0 {
+		return AdviceOpinion, matches
+	}
+	return AdviceNone, nil
+}
+
+func findAll(patterns []*regexp.Regexp, text string) []string {
+	var found []string
+	for _, pattern := range patterns {
+		found = append(found, pattern.FindAllString(text, -1)...)
+	}
+	return found
+}
+
+// sentencePattern splits text into sentences, keeping their punctuation and
+// trailing whitespace so dropping some leaves the rest intact
+var sentencePattern = regexp.MustCompile(`[^.!?\n]*(?:[.!?]+["')\]]*|\n|$)\s*`)
+
+// redactDirectives drops every sentence containing a directive
+func redactDirectives(text string) string {
+	var kept strings.Builder
+	for _, sentence := range sentencePattern.FindAllString(text, -1) {
+		if len(findAll(directivePatterns, sentence)) == 0 {
+			kept.WriteString(sentence)
+		}
+	}
+	return strings.TrimSpace(kept.String())
+}
+
+type chatKey struct{}
+
+type chatAudience struct {
+	chatID       int64
+	jurisdiction string
+}
+
+// WithChat records the chat a response is for and the jurisdiction of the
+// bot serving it, so the advice policy can apply that chat's rules
+func WithChat(ctx context.Context, chatID int64, jurisdiction string) context.Context {
+	return context.WithValue(ctx, chatKey{}, chatAudience{chatID: chatID, jurisdiction: jurisdiction})
+}
+
+// ChatFrom returns the chat and jurisdiction ctx was labeled with
+func ChatFrom(ctx context.Context) (int64, string) {
+	audience, _ := ctx.Value(chatKey{}).(chatAudience)
+	return audience.chatID, audience.jurisdiction
+}
+
+// PolicyAuditEntry is one action the advice policy took
+type PolicyAuditEntry struct {
+	Time         time.Time    `json:"time"`
+	TraceID      string       `json:"trace_id,omitempty"`
+	ChatID       int64        `json:"chat_id,omitempty"`
+	Jurisdiction string       `json:"jurisdiction,omitempty"`
+	Command      string       `json:"command,omitempty"`
+	PromptKey    string       `json:"prompt_key"`
+	Class        AdviceClass  `json:"class"`
+	Action       PolicyAction `json:"action"`
+	Matches      []string     `json:"matches,omitempty"`
+}
+
+// advicePolicy applies an AdvicePolicyConfig and writes its audit log
+type advicePolicy struct {
+	config AdvicePolicyConfig
+	keys   map[string]bool
+	mu     sync.Mutex // Serializes audit log appends
+}
+
+// SetAdvicePolicy enables the financial advice guardrails. They run in
+// PostProcess after the length trim, so set the post-processing disclaimer
+// to leave room for the policy's.
+func (client *OpenRouterClient) SetAdvicePolicy(config AdvicePolicyConfig) error {
+	if err := config.validate(); err != nil {
+		return err
+	}
+	policy := &advicePolicy{config: config, keys: make(map[string]bool, len(config.Keys))}
+	for _, key := range config.Keys {
+		policy.keys[key] = true
+	}
+	client.policy = policy
+	return nil
+}
+
+// jurisdiction returns the chat's configured jurisdiction, or the bot's
+func (p *advicePolicy) jurisdiction(chatID int64, botJurisdiction string) string {
+	if override, ok := p.config.ChatJurisdictions[strconv.FormatInt(chatID, 10)]; ok {
+		return override
+	}
+	return botJurisdiction
+}
+
+// rule returns the actions for a chat: a rule naming the chat, else one
+// naming its jurisdiction, else the default, with empty actions defaulted
+func (p *advicePolicy) rule(chatID int64, jurisdiction string) AdviceRule {
+	var matched *AdviceRule
+	for i, rule := range p.config.Rules {
+		if chatID != 0 && containsChat(rule.Chats, chatID) {
+			matched = &p.config.Rules[i]
+			break
+		}
+		if matched == nil && jurisdiction != "" && containsFold(rule.Jurisdictions, jurisdiction) {
+			matched = &p.config.Rules[i]
+		}
+	}
+
+	rule := p.config.Default
+	if matched != nil {
+		if matched.Opinion != "" {
+			rule.Opinion = matched.Opinion
+		}
+		if matched.Directive != "" {
+			rule.Directive = matched.Directive
+		}
+	}
+	return rule
+}
+
+func containsChat(chats []int64, chatID int64) bool {
+	for _, chat := range chats {
+		if chat == chatID {
+			return true
+		}
+	}
+	return false
+}
+
+func containsFold(values []string, value string) bool {
+	for _, v := range values {
+		if strings.EqualFold(v, value) {
+			return true
+		}
+	}
+	return false
+}
+
+// audit appends an entry to the audit log
+func (p *advicePolicy) audit(entry PolicyAuditEntry) error {
+	if p.config.AuditLog == "" {
+		return nil
+	}
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return fmt.Errorf("failed to marshal audit entry: %w", err)
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if err := os.MkdirAll(filepath.Dir(p.config.AuditLog), 0755); err != nil {
+		return fmt.Errorf("failed to create directory: %w", err)
+	}
+	file, err := os.OpenFile(p.config.AuditLog, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to open advice policy audit log: %w", err)
+	}
+	defer file.Close()
+	_, err = file.Write(append(data, '\n'))
+	return err
+}
+
+// advicePolicyFilter classifies responses for the policy's prompt keys and
+// applies the chat's rule. fallbackDisclaimer is used when the policy has
+// no disclaimer of its own.
+func (client *OpenRouterClient) advicePolicyFilter(fallbackDisclaimer string) ResponseFilter {
+	return func(ctx context.Context, promptKey, persona, text string) string {
+		policy := client.policy
+		if policy == nil || !policy.keys[promptKey] {
+			return text
+		}
+		class, matches := ClassifyAdvice(text)
+		if class == AdviceNone {
+			return text
+		}
+
+		chatID, jurisdiction := ChatFrom(ctx)
+		jurisdiction = policy.jurisdiction(chatID, jurisdiction)
+		rule := policy.rule(chatID, jurisdiction)
+		action := rule.Opinion
+		if class == AdviceDirective {
+			action = rule.Directive
+		}
+		if action == "" || action == PolicyAllow {
+			return text
+		}
+
+		disclaimer := policy.config.Disclaimer
+		if disclaimer == "" {
+			disclaimer = fallbackDisclaimer
+		}
+		switch action {
+		case PolicyBlock:
+			text = policy.config.BlockedMessage
+		case PolicyRedact:
+			// Opinions have nothing to redact, so they are only disclaimed
+			if class == AdviceDirective {
+				text = redactDirectives(text)
+			}
+			if strings.TrimSpace(text) == "" {
+				text = policy.config.BlockedMessage
+				action = PolicyBlock
+				break
+			}
+			fallthrough
+		case PolicyDisclaim:
+			if disclaimer != "" && !strings.Contains(text, disclaimer) {
+				text += "\n\n" + disclaimer
+			}
+		}
+
+		err := policy.audit(PolicyAuditEntry{
+			Time:         time.Now(),
+			TraceID:      trace.FromContext(ctx),
+			ChatID:       chatID,
+			Jurisdiction: jurisdiction,
+			Command:      CommandFrom(ctx),
+			PromptKey:    promptKey,
+			Class:        class,
+			Action:       action,
+			Matches:      matches,
+		})
+		if err != nil {
+			trace.Logf(ctx, client.Logger, "Failed to write advice policy audit entry: %v", err)
+		}
+		return text
+	}
+}
@@ -0,0 +1,155 @@
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+
+	"anondd/utils/trace"
+)
+
+// PostProcessConfig controls how LLM responses are cleaned up before they reach users.
+type PostProcessConfig struct {
+	// MaxLength caps responses in characters; Telegram rejects messages over 4096.
+	MaxLength int `json:"max_length"`
+	// BlockedWords are masked wherever they appear as whole words.
+	BlockedWords []string `json:"blocked_words"`
+	// Disclaimer is appended to responses for the prompt keys in DisclaimerKeys.
+	Disclaimer     string   `json:"disclaimer"`
+	DisclaimerKeys []string `json:"disclaimer_keys"`
+	// PersonaRewrite rewrites responses into the chat persona's tone.
+	PersonaRewrite bool `json:"persona_rewrite"`
+}
+
+// DefaultPostProcessConfig trims to Telegram's limit, masks common profanity
+// and adds a disclaimer to DD-style answers.
+func DefaultPostProcessConfig() PostProcessConfig {
+	return PostProcessConfig{
+		MaxLength:      4000,
+		BlockedWords:   []string{"fuck", "fucking", "shit", "bitch", "cunt", "asshole", "retard", "retarded"},
+		Disclaimer:     "⚠️ Not financial advice. DYOR.",
+		DisclaimerKeys: []string{"dd_quick", "dd_full", "dd_risks", "anomaly_dd", "tokenomics", "agent_analysis", "market_overview", "pipeline", "compare_agents"},
+	}
+}
+
+// LoadPostProcessConfig reads post-processing settings from a JSON file,
+// falling back to the defaults when the file does not exist.
+func LoadPostProcessConfig(path string) (PostProcessConfig, error) {
+	config := DefaultPostProcessConfig()
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return config, nil
+	}
+	if err != nil {
+		return config, fmt.Errorf("failed to read post-processing config: %w", err)
+	}
+	if err := json.Unmarshal(data, &config); err != nil {
+		return config, fmt.Errorf("failed to unmarshal post-processing config: %w", err)
+	}
+	return config, nil
+}
+
+// ResponseFilter transforms a response; promptKey and persona describe the request
+// that produced it.
+type ResponseFilter func(ctx context.Context, promptKey, persona, text string) string
+
+// SetPostProcessing replaces the filter chain with one built from config. Filters
+// run in order: persona rewrite, content masking, length trim, advice policy,
+// disclaimer.
+func (client *OpenRouterClient) SetPostProcessing(config PostProcessConfig) {
+	var filters []ResponseFilter
+	if config.PersonaRewrite {
+		filters = append(filters, client.personaRewriteFilter())
+	}
+	if len(config.BlockedWords) > 0 {
+		filters = append(filters, blockedWordsFilter(config.BlockedWords))
+	}
+	if config.MaxLength > 0 {
+		// Leave room for the disclaimer so it is never the part that gets cut
+		limit := config.MaxLength
+		if config.Disclaimer != "" {
+			limit -= utf8.RuneCountInString(config.Disclaimer) + 2
+		}
+		filters = append(filters, trimFilter(limit))
+	}
+	filters = append(filters, client.advicePolicyFilter(config.Disclaimer))
+	if config.Disclaimer != "" && len(config.DisclaimerKeys) > 0 {
+		filters = append(filters, disclaimerFilter(config.Disclaimer, config.DisclaimerKeys))
+	}
+	client.filters = filters
+}
+
+// PostProcess runs a response through the filter chain before it is shown to users.
+// Pass the chat persona only when the response was not already generated in it.
+func (client *OpenRouterClient) PostProcess(ctx context.Context, promptKey, persona, text string) string {
+	for _, filter := range client.filters {
+		text = filter(ctx, promptKey, persona, text)
+	}
+	return text
+}
+
+// personaRewriteFilter restyles a response in the persona's voice, keeping the
+// original when there is no persona or the rewrite fails.
+func (client *OpenRouterClient) personaRewriteFilter() ResponseFilter {
+	return func(ctx context.Context, promptKey, persona, text string) string {
+		if persona == "" || strings.TrimSpace(text) == "" {
+			return text
+		}
+		rewritten, err := client.GetResponseAs(ctx, persona, "persona_rewrite", text)
+		if err != nil || strings.TrimSpace(rewritten) == "" {
+			trace.Logf(ctx, client.Logger, "Persona rewrite failed for prompt key '%s': %v", promptKey, err)
+			return text
+		}
+		return rewritten
+	}
+}
+
+// blockedWordsFilter masks blocked words, keeping their first letter.
+func blockedWordsFilter(words []string) ResponseFilter {
+	quoted := make([]string, len(words))
+	for i, word := range words {
+		quoted[i] = regexp.QuoteMeta(word)
+	}
+	pattern := regexp.MustCompile(`(?i)\b(` + strings.Join(quoted, "|") + `)\b`)
+
+	return func(ctx context.Context, promptKey, persona, text string) string {
+		return pattern.ReplaceAllStringFunc(text, func(word string) string {
+			first, size := utf8.DecodeRuneInString(word)
+			return string(first) + strings.Repeat("*", utf8.RuneCountInString(word[size:]))
+		})
+	}
+}
+
+// trimFilter cuts responses over limit characters, preferring a sentence or
+// line break so the text doesn't end mid-word.
+func trimFilter(limit int) ResponseFilter {
+	return func(ctx context.Context, promptKey, persona, text string) string {
+		runes := []rune(text)
+		if limit <= 0 || len(runes) <= limit {
+			return text
+		}
+		cut := string(runes[:limit-1])
+		if i := strings.LastIndexAny(cut, ".!?\n"); i > len(cut)/2 {
+			cut = cut[:i+1]
+		}
+		return strings.TrimSpace(cut) + "…"
+	}
+}
+
+// disclaimerFilter appends the disclaimer to responses for the given prompt keys.
+func disclaimerFilter(disclaimer string, keys []string) ResponseFilter {
+	applies := make(map[string]bool, len(keys))
+	for _, key := range keys {
+		applies[key] = true
+	}
+	return func(ctx context.Context, promptKey, persona, text string) string {
+		if !applies[promptKey] || strings.Contains(text, disclaimer) {
+			return text
+		}
+		return text + "\n\n" + disclaimer
+	}
+}
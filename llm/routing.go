@@ -0,0 +1,142 @@
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+)
+
+// ProviderPreferences are OpenRouter's provider routing options, sent as the
+// request's "provider" object
+type ProviderPreferences struct {
+	Order          []string  `json:"order,omitempty"`           // Providers to try first, in order
+	AllowFallbacks *bool     `json:"allow_fallbacks,omitempty"` // Whether providers outside Order may serve the request; OpenRouter allows them by default
+	MaxPrice       *MaxPrice `json:"max_price,omitempty"`       // Providers charging more are skipped
+}
+
+// MaxPrice caps what a provider may charge, in USD per million tokens. Zero
+// leaves a side uncapped.
+type MaxPrice struct {
+	Prompt     float64 `json:"prompt,omitempty"`
+	Completion float64 `json:"completion,omitempty"`
+}
+
+// Routing picks the model and providers serving a completion. Empty fields
+// keep what a less specific routing chose.
+type Routing struct {
+	Model    string               `json:"model,omitempty"` // Replaces the default model
+	Provider *ProviderPreferences `json:"provider,omitempty"`
+}
+
+// RoutingConfig is the routing for every completion and, by prompt key, the
+// routing that overrides it, e.g. cheap fast providers for chat and a better
+// model for reports
+type RoutingConfig struct {
+	Default Routing            `json:"default"`
+	Keys    map[string]Routing `json:"keys,omitempty"`
+}
+
+// LoadRoutingConfig reads routing preferences from a JSON file. A missing
+// file routes every completion to the default model with OpenRouter's
+// default provider selection.
+func LoadRoutingConfig(path string) (RoutingConfig, error) {
+	var config RoutingConfig
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return config, nil
+	}
+	if err != nil {
+		return config, fmt.Errorf("failed to read routing config: %w", err)
+	}
+	if err := json.Unmarshal(data, &config); err != nil {
+		return config, fmt.Errorf("failed to unmarshal routing config: %w", err)
+	}
+	return config, nil
+}
+
+// validate rejects negative price caps
+func (r Routing) validate() error {
+	if r.Provider != nil && r.Provider.MaxPrice != nil && (r.Provider.MaxPrice.Prompt < 0 || r.Provider.MaxPrice.Completion < 0) {
+		return fmt.Errorf("max price can't be negative")
+	}
+	return nil
+}
+
+// SetRouting sets the model and provider preferences for completions, by
+// prompt key. Calls can override them with WithRouting.
+func (client *OpenRouterClient) SetRouting(config RoutingConfig) error {
+	if err := config.Default.validate(); err != nil {
+		return fmt.Errorf("default routing: %w", err)
+	}
+	for key, routing := range config.Keys {
+		if err := routing.validate(); err != nil {
+			return fmt.Errorf("routing for '%s': %w", key, err)
+		}
+	}
+	client.routes = config
+	return nil
+}
+
+// merge returns r with the fields set in override replacing its own
+func (r Routing) merge(override Routing) Routing {
+	if override.Model != "" {
+		r.Model = override.Model
+	}
+	if override.Provider == nil {
+		return r
+	}
+	provider := ProviderPreferences{}
+	if r.Provider != nil {
+		provider = *r.Provider
+	}
+	if len(override.Provider.Order) > 0 {
+		provider.Order = override.Provider.Order
+	}
+	if override.Provider.AllowFallbacks != nil {
+		provider.AllowFallbacks = override.Provider.AllowFallbacks
+	}
+	if override.Provider.MaxPrice != nil {
+		provider.MaxPrice = override.Provider.MaxPrice
+	}
+	r.Provider = &provider
+	return r
+}
+
+// routing returns the routing for a completion: the default, then the
+// prompt key's, then the call's
+func (client *OpenRouterClient) routing(ctx context.Context, promptKey string) Routing {
+	routing := client.routes.Default.merge(client.routes.Keys[promptKey])
+	if override, ok := RoutingFrom(ctx); ok {
+		routing = routing.merge(override)
+	}
+	if routing.Model == "" {
+		routing.Model = completionModel
+	}
+	return routing
+}
+
+type routingKey struct{}
+
+// WithRouting overrides the configured routing for LLM calls made with ctx;
+// fields left empty keep the configured values
+func WithRouting(ctx context.Context, routing Routing) context.Context {
+	return context.WithValue(ctx, routingKey{}, routing)
+}
+
+// RoutingFrom returns the routing override ctx carries, if any
+func RoutingFrom(ctx context.Context) (Routing, bool) {
+	routing, ok := ctx.Value(routingKey{}).(Routing)
+	return routing, ok
+}
+
+// routingCacheKey distinguishes responses to calls with a routing override,
+// which may come from another model, from the configured routing's
+func routingCacheKey(ctx context.Context) string {
+	override, ok := RoutingFrom(ctx)
+	if !ok {
+		return ""
+	}
+	data, _ := json.Marshal(override)
+	return string(data)
+}
@@ -0,0 +1,68 @@
+package llm
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// flightTimeout bounds a shared request. It runs detached from the caller
+// that started it, so one caller giving up doesn't fail the others.
+const flightTimeout = 2 * time.Minute
+
+// call is an in-flight or completed request shared by coalesced callers
+type call struct {
+	done   chan struct{} // Closed once result and err are set
+	result string
+	err    error
+	dups   int
+}
+
+// flightGroup coalesces concurrent calls with the same key into one execution
+type flightGroup struct {
+	mu    sync.Mutex
+	calls map[string]*call
+}
+
+// Do runs fn once for all concurrent callers sharing key. fn gets a context
+// carrying ctx's values but not its cancellation, bounded by flightTimeout;
+// each caller stops waiting when its own ctx is done. shared reports whether
+// the result was handed to more than one caller.
+func (g *flightGroup) Do(ctx context.Context, key string, fn func(context.Context) (string, error)) (result string, err error, shared bool) {
+	g.mu.Lock()
+	if g.calls == nil {
+		g.calls = make(map[string]*call)
+	}
+	c, exists := g.calls[key]
+	if exists {
+		c.dups++
+	} else {
+		c = &call{done: make(chan struct{})}
+		g.calls[key] = c
+		go g.run(ctx, key, c, fn)
+	}
+	g.mu.Unlock()
+
+	select {
+	case <-c.done:
+	case <-ctx.Done():
+		return "", ctx.Err(), exists
+	}
+
+	g.mu.Lock()
+	shared = exists || c.dups > 0
+	g.mu.Unlock()
+	return c.result, c.err, shared
+}
+
+// run executes the shared call and hands its result to the waiting callers
+func (g *flightGroup) run(ctx context.Context, key string, c *call, fn func(context.Context) (string, error)) {
+	flightCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), flightTimeout)
+	defer cancel()
+	c.result, c.err = fn(flightCtx)
+
+	g.mu.Lock()
+	delete(g.calls, key)
+	g.mu.Unlock()
+	close(c.done)
+}
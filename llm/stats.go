@@ -0,0 +1,56 @@
+package llm
+
+import (
+	"sync"
+	"time"
+
+	"anondd/utils/events"
+)
+
+// CallStats counts completion requests sent to the LLM API on one day
+type CallStats struct {
+	Day    string `json:"day"` // YYYY-MM-DD, local time
+	Calls  int    `json:"calls"`
+	Errors int    `json:"errors"`
+}
+
+// callCounter tracks today's completion requests, resetting at midnight
+type callCounter struct {
+	mu    sync.Mutex
+	stats CallStats
+}
+
+// record counts one completion request and whether it failed
+func (c *callCounter) record(err error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.rollover()
+	c.stats.Calls++
+	if err != nil {
+		c.stats.Errors++
+	}
+}
+
+// rollover starts a new day's counts; callers must hold mu
+func (c *callCounter) rollover() {
+	if today := time.Now().Format("2006-01-02"); c.stats.Day != today {
+		c.stats = CallStats{Day: today}
+	}
+}
+
+// Stats returns the number of completion requests sent today. Coalesced and
+// cached responses are not counted.
+func (client *OpenRouterClient) Stats() CallStats {
+	client.calls.mu.Lock()
+	defer client.calls.mu.Unlock()
+
+	client.calls.rollover()
+	return client.calls.stats
+}
+
+// SetEvents makes the client publish an LLMCallFinished event after every
+// completion request
+func (client *OpenRouterClient) SetEvents(bus *events.Bus) {
+	client.events = bus
+}
@@ -0,0 +1,98 @@
+package llm
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"anondd/utils/events"
+	"anondd/utils/trace"
+)
+
+// DefaultStreamKeys are the prompt keys of long analyses worth watching as
+// they are written
+var DefaultStreamKeys = []string{"dd_full", "anomaly_dd", "weekly_report", "market_overview", "tokenomics", "predict"}
+
+// streamChunk is one server-sent event of a streamed completion
+type streamChunk struct {
+	Choices []struct {
+		Delta struct {
+			Content string `json:"content"`
+		} `json:"delta"`
+	} `json:"choices"`
+	Usage *completionUsage `json:"usage,omitempty"`
+	Error *struct {
+		Message string `json:"message"`
+	} `json:"error,omitempty"`
+}
+
+// SetStreaming streams completions for the given prompt keys, publishing
+// their text on the event bus as it is written so dashboards can follow long
+// analyses live. Callers still get the whole response at once. Nil keys turn
+// streaming off.
+func (client *OpenRouterClient) SetStreaming(keys []string) {
+	client.streamKeys = make(map[string]bool, len(keys))
+	for _, key := range keys {
+		client.streamKeys[key] = true
+	}
+}
+
+// streams reports whether completions for promptKey are streamed; there is
+// no point without a bus to publish them on
+func (client *OpenRouterClient) streams(promptKey string) bool {
+	return client.events != nil && client.streamKeys[promptKey]
+}
+
+// readStream reads a streamed completion's server-sent events, publishing
+// each piece of text as it arrives, and returns the whole text
+func (client *OpenRouterClient) readStream(ctx context.Context, body io.Reader, promptKey string, usage *completionUsage) (response string, err error) {
+	id := trace.NewID()
+	client.events.Publish(events.LLMStreamStarted{ID: id, PromptKey: promptKey, Command: CommandFrom(ctx), StartedAt: time.Now()})
+	defer func() {
+		client.events.Publish(events.LLMStreamFinished{ID: id, Err: err})
+	}()
+
+	var text strings.Builder
+	scanner := bufio.NewScanner(body)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		// Lines starting with ":" are keep-alive comments
+		data, found := strings.CutPrefix(scanner.Text(), "data:")
+		if !found {
+			continue
+		}
+		data = strings.TrimSpace(data)
+		if data == "[DONE]" {
+			break
+		}
+
+		var chunk streamChunk
+		if err := json.Unmarshal([]byte(data), &chunk); err != nil {
+			return "", fmt.Errorf("failed to unmarshal stream chunk: %w", err)
+		}
+		if chunk.Error != nil {
+			return "", fmt.Errorf("OpenRouter API error: %s", chunk.Error.Message)
+		}
+		if chunk.Usage != nil {
+			*usage = *chunk.Usage
+		}
+		if len(chunk.Choices) > 0 && chunk.Choices[0].Delta.Content != "" {
+			delta := chunk.Choices[0].Delta.Content
+			text.WriteString(delta)
+			client.events.Publish(events.LLMStreamDelta{ID: id, Text: delta})
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return "", fmt.Errorf("failed to read response stream: %w", err)
+	}
+
+	trace.Logf(ctx, client.Logger, "OpenRouter API streamed response: %s", text.String())
+	if text.Len() == 0 {
+		return "", fmt.Errorf("no response received from OpenRouter")
+	}
+	return text.String(), nil
+}
@@ -0,0 +1,173 @@
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// Response verbosity levels users can pick with /style
+const (
+	VerbosityConcise  = "concise"
+	VerbosityNormal   = "normal"
+	VerbosityDetailed = "detailed"
+)
+
+// ParseVerbosity maps a user's input to a verbosity level
+func ParseVerbosity(raw string) (string, bool) {
+	switch strings.ToLower(strings.TrimSpace(raw)) {
+	case "concise", "terse", "short", "brief":
+		return VerbosityConcise, true
+	case "normal", "default":
+		return VerbosityNormal, true
+	case "detailed", "verbose", "long", "explain":
+		return VerbosityDetailed, true
+	}
+	return "", false
+}
+
+// Style is how long and in what register a completion is written. Empty
+// fields keep what a less specific style chose.
+type Style struct {
+	MaxTokens   int    `json:"max_tokens,omitempty"`  // Sent as the request's max_tokens
+	Instruction string `json:"instruction,omitempty"` // Appended to the system prompt
+}
+
+// StyleConfig is the style of each verbosity level and, by command label
+// such as "/give_dd" or "chat", the styles that override it
+type StyleConfig struct {
+	Levels   map[string]Style            `json:"levels,omitempty"`
+	Commands map[string]map[string]Style `json:"commands,omitempty"`
+}
+
+// DefaultStyleConfig keeps normal replies as the prompts write them, cuts
+// concise ones down to the numbers and lets detailed ones explain terms
+// for newcomers. Reports get more room at every level.
+func DefaultStyleConfig() StyleConfig {
+	return StyleConfig{
+		Levels: map[string]Style{
+			VerbosityConcise: {
+				MaxTokens:   300,
+				Instruction: "Reply tersely for an experienced trader: lead with the key numbers, use short fragments or bullet points, and skip introductions, explanations of common terms and disclaimers beyond one line.",
+			},
+			VerbosityDetailed: {
+				MaxTokens:   1200,
+				Instruction: "The reader may be new to crypto and AI agents. Explain what the numbers mean and briefly define terms like market cap, liquidity or holders the first time you use them, while keeping the reply well organized.",
+			},
+		},
+		Commands: map[string]map[string]Style{
+			"/give_dd": {
+				VerbosityConcise:  {MaxTokens: 400},
+				VerbosityDetailed: {MaxTokens: 2000},
+			},
+			"/dd": {
+				VerbosityConcise:  {MaxTokens: 400},
+				VerbosityDetailed: {MaxTokens: 2000},
+			},
+		},
+	}
+}
+
+// LoadStyleConfig reads response styles from a JSON file on top of the
+// defaults. A missing file keeps the defaults.
+func LoadStyleConfig(path string) (StyleConfig, error) {
+	config := DefaultStyleConfig()
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return config, nil
+	}
+	if err != nil {
+		return config, fmt.Errorf("failed to read style config: %w", err)
+	}
+	var loaded StyleConfig
+	if err := json.Unmarshal(data, &loaded); err != nil {
+		return config, fmt.Errorf("failed to unmarshal style config: %w", err)
+	}
+	for level, style := range loaded.Levels {
+		config.Levels[level] = config.Levels[level].merge(style)
+	}
+	for command, levels := range loaded.Commands {
+		if config.Commands[command] == nil {
+			config.Commands[command] = make(map[string]Style)
+		}
+		for level, style := range levels {
+			config.Commands[command][level] = config.Commands[command][level].merge(style)
+		}
+	}
+	return config, nil
+}
+
+// validateLevels rejects unknown verbosity levels and negative token limits
+func validateLevels(levels map[string]Style) error {
+	for level, style := range levels {
+		if _, ok := ParseVerbosity(level); !ok || level != strings.ToLower(level) {
+			return fmt.Errorf("unknown verbosity '%s'", level)
+		}
+		if style.MaxTokens < 0 {
+			return fmt.Errorf("max tokens for '%s' can't be negative", level)
+		}
+	}
+	return nil
+}
+
+// SetStyles sets the response style of each verbosity level, by command.
+// Calls pick a level with WithVerbosity.
+func (client *OpenRouterClient) SetStyles(config StyleConfig) error {
+	if err := validateLevels(config.Levels); err != nil {
+		return err
+	}
+	for command, levels := range config.Commands {
+		if err := validateLevels(levels); err != nil {
+			return fmt.Errorf("styles for '%s': %w", command, err)
+		}
+	}
+	client.styles = config
+	return nil
+}
+
+// merge returns s with the fields set in override replacing its own
+func (s Style) merge(override Style) Style {
+	if override.MaxTokens > 0 {
+		s.MaxTokens = override.MaxTokens
+	}
+	if override.Instruction != "" {
+		s.Instruction = override.Instruction
+	}
+	return s
+}
+
+// style returns the style of a completion: the verbosity level's, then the
+// command's for that level
+func (client *OpenRouterClient) style(ctx context.Context) Style {
+	verbosity := VerbosityFrom(ctx)
+	if verbosity == "" {
+		return Style{}
+	}
+	style := client.styles.Levels[verbosity]
+	return style.merge(client.styles.Commands[CommandFrom(ctx)][verbosity])
+}
+
+type verbosityKey struct{}
+
+// WithVerbosity asks for replies at a verbosity level, such as the user's
+// /style preference, for LLM calls made with ctx
+func WithVerbosity(ctx context.Context, verbosity string) context.Context {
+	return context.WithValue(ctx, verbosityKey{}, verbosity)
+}
+
+// VerbosityFrom returns the verbosity level ctx asks for, or ""
+func VerbosityFrom(ctx context.Context) string {
+	verbosity, _ := ctx.Value(verbosityKey{}).(string)
+	return verbosity
+}
+
+// styleCacheKey distinguishes responses written in different styles
+func (client *OpenRouterClient) styleCacheKey(ctx context.Context) string {
+	style := client.style(ctx)
+	if style == (Style{}) {
+		return ""
+	}
+	return fmt.Sprintf("%d\x00%s", style.MaxTokens, style.Instruction)
+}
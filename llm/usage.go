@@ -0,0 +1,70 @@
+package llm
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Pricing is what the provider charges in USD per million tokens. It is used
+// to estimate a call's cost when the provider doesn't report one.
+type Pricing struct {
+	PromptPerMillion     float64 `json:"prompt_per_million"`
+	CompletionPerMillion float64 `json:"completion_per_million"`
+}
+
+// ParsePricing reads "prompt,completion" USD prices per million tokens, e.g. "0.15,0.60"
+func ParsePricing(raw string) (Pricing, error) {
+	promptRaw, completionRaw, found := strings.Cut(raw, ",")
+	if !found {
+		return Pricing{}, fmt.Errorf("pricing must look like 0.15,0.60")
+	}
+	prompt, err := strconv.ParseFloat(strings.TrimSpace(promptRaw), 64)
+	if err != nil || prompt < 0 {
+		return Pricing{}, fmt.Errorf("invalid prompt price %q", promptRaw)
+	}
+	completion, err := strconv.ParseFloat(strings.TrimSpace(completionRaw), 64)
+	if err != nil || completion < 0 {
+		return Pricing{}, fmt.Errorf("invalid completion price %q", completionRaw)
+	}
+	return Pricing{PromptPerMillion: prompt, CompletionPerMillion: completion}, nil
+}
+
+// estimate returns the cost of a call at these prices
+func (p Pricing) estimate(promptTokens, completionTokens int) float64 {
+	return (float64(promptTokens)*p.PromptPerMillion + float64(completionTokens)*p.CompletionPerMillion) / 1e6
+}
+
+// SetPricing sets the prices used to estimate the cost of calls the provider
+// doesn't report a cost for. The default model is free.
+func (client *OpenRouterClient) SetPricing(pricing Pricing) {
+	client.pricing = pricing
+}
+
+// completionUsage is the token accounting returned with a completion
+type completionUsage struct {
+	PromptTokens     int     `json:"prompt_tokens"`
+	CompletionTokens int     `json:"completion_tokens"`
+	Cost             float64 `json:"cost"` // USD, when usage accounting is enabled
+}
+
+// estimateTokens approximates a token count from text length for responses
+// that come back without usage
+func estimateTokens(text string) int {
+	return (len(text) + 3) / 4
+}
+
+type commandKey struct{}
+
+// WithCommand labels LLM calls made with ctx as made for command, such as
+// "/give_dd" or "risk_scoring", so their usage can be attributed to it
+func WithCommand(ctx context.Context, command string) context.Context {
+	return context.WithValue(ctx, commandKey{}, command)
+}
+
+// CommandFrom returns the command ctx was labeled with, or ""
+func CommandFrom(ctx context.Context) string {
+	command, _ := ctx.Value(commandKey{}).(string)
+	return command
+}
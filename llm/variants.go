@@ -0,0 +1,116 @@
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"os"
+
+	"anondd/utils/trace"
+)
+
+// BaseVariant names the plain prompt when a key has no variants configured.
+const BaseVariant = "base"
+
+// PromptVariant is one arm of an A/B test on a prompt key.
+type PromptVariant struct {
+	Name string `json:"name"`
+	// Template replaces the prompt key's template; empty keeps the built-in one.
+	Template string `json:"template,omitempty"`
+	// SystemPrompt replaces the chat persona; empty keeps the caller's.
+	SystemPrompt string `json:"system_prompt,omitempty"`
+	// Weight is this variant's share of traffic relative to the others.
+	Weight int `json:"weight"`
+}
+
+// LoadPromptVariants reads variants keyed by prompt key from a JSON file.
+// A missing file means no experiments are running.
+func LoadPromptVariants(path string) (map[string][]PromptVariant, error) {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read prompt variants: %w", err)
+	}
+
+	var variants map[string][]PromptVariant
+	if err := json.Unmarshal(data, &variants); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal prompt variants: %w", err)
+	}
+	for key, arms := range variants {
+		seen := make(map[string]bool, len(arms))
+		for _, arm := range arms {
+			if arm.Name == "" || arm.Weight <= 0 {
+				return nil, fmt.Errorf("prompt variant for '%s' needs a name and a positive weight", key)
+			}
+			if seen[arm.Name] {
+				return nil, fmt.Errorf("duplicate prompt variant '%s' for '%s'", arm.Name, key)
+			}
+			seen[arm.Name] = true
+		}
+	}
+	return variants, nil
+}
+
+// SetPromptVariants installs the A/B variants. Call it before serving requests.
+func (client *OpenRouterClient) SetPromptVariants(variants map[string][]PromptVariant) {
+	client.variants = variants
+}
+
+// chooseVariant assigns unit (such as a chat ID) to a variant of promptKey.
+// Assignment is deterministic, so a chat keeps seeing the same arm.
+func (client *OpenRouterClient) chooseVariant(promptKey, unit string) (PromptVariant, bool) {
+	arms := client.variants[promptKey]
+	if len(arms) == 0 {
+		return PromptVariant{}, false
+	}
+
+	total := 0
+	for _, arm := range arms {
+		total += arm.Weight
+	}
+	h := fnv.New32a()
+	h.Write([]byte(promptKey + "\x00" + unit))
+	bucket := int(h.Sum32() % uint32(total))
+
+	for _, arm := range arms {
+		if bucket < arm.Weight {
+			return arm, true
+		}
+		bucket -= arm.Weight
+	}
+	return arms[len(arms)-1], true
+}
+
+// GetResponseVariant is GetResponseAs with the prompt key's A/B variant for unit
+// applied. It also returns the variant name so feedback can be attributed.
+func (client *OpenRouterClient) GetResponseVariant(ctx context.Context, systemPrompt string, promptKey string, userQuery string, unit string) (string, string, error) {
+	arm, ok := client.chooseVariant(promptKey, unit)
+	if !ok {
+		response, err := client.GetResponseAs(ctx, systemPrompt, promptKey, userQuery)
+		return response, BaseVariant, err
+	}
+
+	ctx, span := trace.StartSpan(ctx, "llm.GetResponse")
+	span.SetAttribute("prompt_key", promptKey)
+	span.SetAttribute("variant", arm.Name)
+
+	if arm.SystemPrompt != "" {
+		systemPrompt = arm.SystemPrompt
+	}
+	key := systemPrompt + "\x00" + promptKey + "\x00" + arm.Name + "\x00" + userQuery + "\x00" + client.styleCacheKey(ctx)
+	response, err, _ := client.flights.Do(ctx, key, func(ctx context.Context) (string, error) {
+		if arm.Template == "" {
+			return client.getResponse(ctx, systemPrompt, promptKey, userQuery)
+		}
+		return client.complete(ctx, systemPrompt, promptKey, arm.Template, userQuery)
+	})
+	if errors.Is(err, ErrCircuitOpen) {
+		response = client.breaker.fallbackText()
+	}
+	span.Finish(err)
+	return response, arm.Name, err
+}
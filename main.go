@@ -2,15 +2,42 @@ package main
 
 import (
     "context"
+    "encoding/json"
+    "fmt"
     "log"
     "net/http"
     "os"
     "os/signal"
+    "strconv"
+    "strings"
+    "sync"
     "syscall"
+    "time"
     "anondd/api"
     "anondd/llm"
     "anondd/telegram"
     "anondd/utils"
+    "anondd/utils/changes"
+    "anondd/utils/clock"
+    "anondd/utils/deepdive"
+    "anondd/utils/export"
+    "anondd/utils/heartbeat"
+    "anondd/utils/models"
+    "anondd/utils/news"
+    "anondd/utils/onchain"
+    "anondd/utils/parsedigest"
+    "anondd/utils/pipeline"
+    "anondd/utils/plugins"
+    "anondd/utils/report"
+    "anondd/utils/risk"
+    "anondd/utils/search"
+    "anondd/utils/shared"
+    "anondd/utils/shutdown"
+    "anondd/utils/socials"
+    "anondd/utils/speech"
+    "anondd/utils/storage"
+    "anondd/utils/trace"
+    "anondd/utils/webscraper"
 )
 
 func main() {
@@ -19,14 +46,215 @@ func main() {
     // Initialize utils manager
     logger.Println("Initializing utils manager...")
     utilsManager := utils.NewUtilsManager(logger)
+
+    // CLOCK_REPLAY_START (RFC 3339) runs the stores, scraper, schedules and
+    // ticker-based jobs on a replayed clock starting then, CLOCK_REPLAY_SPEED
+    // times as fast as real time, to see what a scrape would have done then.
+    // Replayed timestamps must never reach live data, so it only runs with
+    // SCRAPE_DRY_RUN.
+    replaying := os.Getenv("CLOCK_REPLAY_START") != ""
+    if raw := os.Getenv("CLOCK_REPLAY_START"); raw != "" {
+        if os.Getenv("SCRAPE_DRY_RUN") == "" {
+            logger.Fatalf("CLOCK_REPLAY_START requires SCRAPE_DRY_RUN")
+        }
+        start, err := time.Parse(time.RFC3339, raw)
+        if err != nil {
+            logger.Fatalf("Invalid CLOCK_REPLAY_START: %q", raw)
+        }
+        speed := 1.0
+        if raw := os.Getenv("CLOCK_REPLAY_SPEED"); raw != "" {
+            if speed, err = strconv.ParseFloat(raw, 64); err != nil || speed <= 0 {
+                logger.Fatalf("Invalid CLOCK_REPLAY_SPEED: %q", raw)
+            }
+        }
+        utilsManager.SetClock(clock.NewReplay(start, speed))
+        logger.Printf("Replaying time from %s at %gx", start.Format(time.RFC3339), speed)
+    }
+
     if err := utilsManager.Initialize(); err != nil {
         logger.Fatalf("Failed to initialize utils: %v", err)
     }
     logger.Println("Utils manager initialized successfully")
 
-    // Setup graceful shutdown
+    // Shutdown runs in stages: stop intake, drain workers, flush the store,
+    // close HTTP. Components add their part as they start.
+    shutdowns := shutdown.New(logger)
+
+    // Optional OpenTelemetry span export
+    if endpoint := os.Getenv("OTEL_EXPORTER_OTLP_ENDPOINT"); endpoint != "" {
+        exporter := trace.NewOTLPExporter(endpoint, "anondd", logger)
+        trace.SetExporter(exporter)
+        shutdowns.Add(shutdown.Flush, "trace exporter", func(context.Context) error {
+            exporter.Flush()
+            return nil
+        })
+        logger.Printf("Exporting trace spans to %s", endpoint)
+    }
+
+    // Background workers and bots stop taking new work when ctx is cancelled
     ctx, cancel := context.WithCancel(context.Background())
     defer cancel()
+    shutdowns.Add(shutdown.StopIntake, "scrape scheduler", func(context.Context) error {
+        utilsManager.GetScraper().StopScheduler()
+        return nil
+    })
+    shutdowns.Add(shutdown.StopIntake, "workers", func(context.Context) error {
+        cancel()
+        return nil
+    })
+    shutdowns.Add(shutdown.Drain, "scraper", utilsManager.GetScraper().WaitIdle)
+    shutdowns.Add(shutdown.Flush, "agent store", func(context.Context) error {
+        return utilsManager.GetStore().Flush()
+    })
+
+    // Optional Redis for running several instances over the same data directory
+    if redisURL := os.Getenv("REDIS_URL"); redisURL != "" {
+        sharedStore, err := shared.Open(redisURL)
+        if err != nil {
+            logger.Fatalf("Failed to configure Redis: %v", err)
+        }
+        if redisStore, ok := sharedStore.(*shared.RedisStore); ok {
+            pingCtx, pingCancel := context.WithTimeout(ctx, 5*time.Second)
+            err := redisStore.Ping(pingCtx)
+            pingCancel()
+            if err != nil {
+                logger.Fatalf("Failed to connect to Redis: %v", err)
+            }
+        }
+        utilsManager.SetShared(ctx, sharedStore)
+        logger.Println("Sharing caches and agent updates through Redis")
+    }
+
+    // Page rendering backends per source, local Chrome unless configured
+    fetchersPath := os.Getenv("SCRAPER_FETCHERS_CONFIG")
+    if fetchersPath == "" {
+        fetchersPath = "training_data/fetchers.json"
+    }
+    fetcherConfig, err := webscraper.LoadFetcherConfig(fetchersPath)
+    if err != nil {
+        logger.Fatalf("Failed to load fetcher config: %v", err)
+    }
+    virtualsFetcher := webscraper.NewFetcher(models.SourceVirtuals, fetcherConfig[models.SourceVirtuals], logger)
+    utilsManager.GetScraper().SetFetcher(virtualsFetcher)
+    logger.Printf("Rendering %s pages with %s", models.SourceVirtuals, virtualsFetcher.Name())
+
+    // When raw HTML and screenshots of fetched pages are kept: never,
+    // on_change (default), on_error or always, per source from
+    // RAW_CAPTURE_CONFIG, with RAW_CAPTURE for sources it doesn't list
+    capturePath := os.Getenv("RAW_CAPTURE_CONFIG")
+    if capturePath == "" {
+        capturePath = "training_data/capture.json"
+    }
+    captureConfig, err := webscraper.LoadCaptureConfig(capturePath)
+    if err != nil {
+        logger.Fatalf("Failed to load capture config: %v", err)
+    }
+    capturePolicy, configured := captureConfig[models.SourceVirtuals]
+    if !configured {
+        capturePolicy = os.Getenv("RAW_CAPTURE")
+    }
+    if capturePolicy != "" {
+        if err := utilsManager.GetScraper().SetCapturePolicy(capturePolicy); err != nil {
+            logger.Fatalf("Invalid raw capture policy: %v", err)
+        }
+    }
+    logger.Printf("Capturing raw %s pages: %s", models.SourceVirtuals, utilsManager.GetScraper().CapturePolicy())
+
+    // Scrape profiles (quick, full, deep and any custom ones) for runs and schedules
+    profilesPath := os.Getenv("SCRAPE_PROFILES_CONFIG")
+    if profilesPath == "" {
+        profilesPath = "training_data/scrape_profiles.json"
+    }
+    scrapeProfiles, err := webscraper.LoadScrapeProfiles(profilesPath)
+    if err != nil {
+        logger.Fatalf("Failed to load scrape profiles: %v", err)
+    }
+    utilsManager.GetScraper().SetProfiles(scrapeProfiles)
+
+    // Backpressure between fetching, parsing and storage: SCRAPE_BACKPRESSURE
+    // is pause (default) or drop, SCRAPE_QUEUE_SIZE bounds each stage queue
+    // and SCRAPE_INDEX_BATCH is how many saved agents each index write merges
+    pipelineConfig := webscraper.DefaultPipelineConfig()
+    if policy := os.Getenv("SCRAPE_BACKPRESSURE"); policy != "" {
+        pipelineConfig.Policy = policy
+    }
+    if raw := os.Getenv("SCRAPE_QUEUE_SIZE"); raw != "" {
+        n, err := strconv.Atoi(raw)
+        if err != nil {
+            logger.Fatalf("Invalid SCRAPE_QUEUE_SIZE: %q", raw)
+        }
+        pipelineConfig.QueueSize = n
+    }
+    if raw := os.Getenv("SCRAPE_INDEX_BATCH"); raw != "" {
+        n, err := strconv.Atoi(raw)
+        if err != nil {
+            logger.Fatalf("Invalid SCRAPE_INDEX_BATCH: %q", raw)
+        }
+        pipelineConfig.IndexBatch = n
+    }
+    if err := utilsManager.GetScraper().SetPipelineConfig(pipelineConfig); err != nil {
+        logger.Fatalf("Invalid scrape backpressure settings: %v", err)
+    }
+
+    // Optional single-file agent storage for filesystems that are slow with many small files
+    if os.Getenv("AGENT_STORAGE_FORMAT") == "compact" {
+        if err := utilsManager.GetStore().EnableCompactStorage(ctx, storage.DefaultCompactInterval); err != nil {
+            logger.Fatalf("Failed to enable compact agent storage: %v", err)
+        }
+        logger.Println("Using compact agent storage")
+    }
+
+    // Previous agent indexes kept for /rollback_index
+    if raw := os.Getenv("INDEX_VERSIONS"); raw != "" {
+        n, err := strconv.Atoi(raw)
+        if err != nil || n < 1 {
+            logger.Fatalf("Invalid INDEX_VERSIONS: %q", raw)
+        }
+        utilsManager.GetStore().SetIndexVersions(n)
+    }
+
+    // Upgrade stored agent records to the current schema; MIGRATE_DRY_RUN only
+    // reports, as does a replay, which must leave the data as it found it
+    dryRun := os.Getenv("MIGRATE_DRY_RUN") != ""
+    migration, err := utilsManager.GetStore().Migrate(dryRun || replaying)
+    if err != nil {
+        logger.Fatalf("Failed to migrate agent data: %v", err)
+    }
+    if migration.Migrated > 0 {
+        logger.Printf("Agent schema migration (dry run: %t): %d of %d records upgraded to v%d, changes: %v",
+            dryRun || replaying, migration.Migrated, migration.Scanned, storage.CurrentSchemaVersion(), migration.Applied)
+    }
+    if dryRun {
+        logger.Println("Migration dry run complete, exiting")
+        return
+    }
+
+    // Index entries written before the index kept source IDs get them now,
+    // before any bot can announce re-keyed agents as new
+    if replaying {
+        logger.Println("Replaying, skipping index source backfill")
+    } else if filled, err := utilsManager.GetStore().BackfillIndexSources(); err != nil {
+        logger.Printf("Failed to backfill index source IDs: %v", err)
+    } else if filled > 0 {
+        logger.Printf("Backfilled source IDs for %d index entries", filled)
+    }
+
+    // SCRAPE_DRY_RUN=ids (or "due") fetches and parses, prints what would change and exits
+    if raw := os.Getenv("SCRAPE_DRY_RUN"); raw != "" {
+        var ids []int
+        if raw != "due" {
+            if ids, err = webscraper.ParseIDs(raw); err != nil {
+                logger.Fatalf("Invalid SCRAPE_DRY_RUN: %v", err)
+            }
+        }
+        report, err := utilsManager.GetScraper().DryRun(ids)
+        if err != nil {
+            logger.Fatalf("Dry run scrape failed: %v", err)
+        }
+        output, _ := json.MarshalIndent(report, "", "  ")
+        fmt.Println(string(output))
+        return
+    }
 
     // Handle shutdown signals
     sigChan := make(chan os.Signal, 1)
@@ -35,54 +263,542 @@ func main() {
     go func() {
         <-sigChan
         logger.Println("Received shutdown signal, shutting down gracefully...")
-        utilsManager.GetScraper().StopScheduler()
-        cancel()
+        shutdowns.Shutdown()
     }()
 
     // Get environment variables
     logger.Println("Fetching environment variables...")
     botToken := os.Getenv("TELEGRAM_BOT_TOKEN")
+    botsConfigPath := os.Getenv("TELEGRAM_BOTS_CONFIG")
     openRouterAPIKey := os.Getenv("OPENROUTER_API_KEY")
 
-    if botToken == "" || openRouterAPIKey == "" {
-        logger.Fatal("Please set TELEGRAM_BOT_TOKEN and OPENROUTER_API_KEY environment variables")
+    if (botToken == "" && botsConfigPath == "") || openRouterAPIKey == "" {
+        logger.Fatal("Please set TELEGRAM_BOT_TOKEN (or TELEGRAM_BOTS_CONFIG) and OPENROUTER_API_KEY environment variables")
     }
     logger.Println("Environment variables fetched successfully")
 
+    // Optional Google Sheets sync after each scrape, authorised by an OAuth
+    // client and refresh token; GOOGLE_SHEETS_METRICS picks the columns
+    if sheetID := os.Getenv("GOOGLE_SHEETS_ID"); sheetID != "" {
+        credentials := export.SheetsCredentials{
+            ClientID:     os.Getenv("GOOGLE_SHEETS_CLIENT_ID"),
+            ClientSecret: os.Getenv("GOOGLE_SHEETS_CLIENT_SECRET"),
+            RefreshToken: os.Getenv("GOOGLE_SHEETS_REFRESH_TOKEN"),
+        }
+        if credentials.ClientID == "" || credentials.ClientSecret == "" || credentials.RefreshToken == "" {
+            logger.Fatal("GOOGLE_SHEETS_ID needs GOOGLE_SHEETS_CLIENT_ID, GOOGLE_SHEETS_CLIENT_SECRET and GOOGLE_SHEETS_REFRESH_TOKEN")
+        }
+        columns, err := export.ParseColumns(os.Getenv("GOOGLE_SHEETS_METRICS"))
+        if err != nil {
+            logger.Fatalf("Invalid GOOGLE_SHEETS_METRICS: %v", err)
+        }
+        sheetsSync := export.NewSheetsSync(sheetID, os.Getenv("GOOGLE_SHEETS_RANGE"),
+            credentials, columns, utilsManager.GetStore(), logger)
+        utilsManager.GetScraper().AddScrapeHook(func() {
+            if err := sheetsSync.Sync(ctx); err != nil {
+                logger.Printf("Google Sheets sync failed: %v", err)
+            }
+        })
+        logger.Println("Google Sheets sync enabled")
+    }
+
+    // Optional on-chain enrichment for deeper DD reports
+    baseRPC, ethRPC := os.Getenv("BASE_RPC_URL"), os.Getenv("ETH_RPC_URL")
+    if baseRPC != "" || ethRPC != "" {
+        enricher := onchain.NewEnricher([]onchain.Chain{onchain.BaseChain(baseRPC), onchain.EthereumChain(ethRPC)}, logger)
+        utilsManager.SetOnChain(enricher)
+        utilsManager.GetScraper().AddEnrichHook(webscraper.EnrichOnChain, func() {
+            enricher.RefreshAll(ctx, utilsManager.GetStore())
+        })
+        logger.Println("On-chain enrichment enabled")
+    }
+
+    // Optional voice note transcription and spoken replies
+    if speechConfig := speech.ConfigFromEnv(); speechConfig.APIKey != "" {
+        utilsManager.SetSpeech(speech.NewClient(speechConfig))
+        logger.Println("Voice messages enabled")
+    }
+
     openRouterClient := llm.NewOpenRouterClient(openRouterAPIKey, "https://openrouter.ai/api/v1/chat/completions", logger)
+    openRouterClient.SetEvents(utilsManager.GetEvents())
+
+    // Every LLM request and response is kept, API keys redacted, for
+    // LLM_AUDIT_RETENTION (default 7 days); LLM_AUDIT=off disables it
+    if os.Getenv("LLM_AUDIT") != "off" {
+        openRouterClient.EnableAudit()
+        if raw := os.Getenv("LLM_AUDIT_RETENTION"); raw != "" {
+            retention, err := time.ParseDuration(raw)
+            if err != nil || retention <= 0 {
+                logger.Fatalf("Invalid LLM_AUDIT_RETENTION: %q", raw)
+            }
+            utilsManager.GetLLMAudit().SetRetention(retention)
+        }
+        utilsManager.GetLLMAudit().StartExpiry(ctx, storage.LLMAuditExpiryInterval, logger)
+    }
+
+    // Prices per million prompt and completion tokens, e.g. "0.15,0.60", to
+    // estimate costs the provider doesn't report
+    if raw := os.Getenv("LLM_PRICING"); raw != "" {
+        pricing, err := llm.ParsePricing(raw)
+        if err != nil {
+            logger.Fatalf("Invalid LLM_PRICING: %v", err)
+        }
+        openRouterClient.SetPricing(pricing)
+    }
+
+    // Optional LLM response cache, shared between instances when Redis is configured
+    if raw := os.Getenv("LLM_CACHE_TTL"); raw != "" {
+        ttl, err := time.ParseDuration(raw)
+        if err != nil {
+            logger.Fatalf("Invalid LLM_CACHE_TTL: %v", err)
+        }
+        openRouterClient.SetResponseCache(utilsManager.GetShared(), ttl)
+        logger.Printf("Caching LLM responses for %s", ttl)
+    }
+
+    // Prompt injection defense for user content: off, fence, neutralize (default) or reject
+    if raw := os.Getenv("LLM_GUARD_LEVEL"); raw != "" {
+        level, err := llm.ParseGuardLevel(raw)
+        if err != nil {
+            logger.Fatalf("Invalid LLM_GUARD_LEVEL: %v", err)
+        }
+        openRouterClient.SetGuardLevel(level)
+    }
+
+    // Stream long analyses so the dashboard can show them being written:
+    // LLM_STREAM_KEYS lists prompt keys, default the long reports, "off" disables
+    if raw := os.Getenv("LLM_STREAM_KEYS"); raw != "off" {
+        keys := llm.DefaultStreamKeys
+        if raw != "" {
+            keys = strings.Split(raw, ",")
+            for i := range keys {
+                keys[i] = strings.TrimSpace(keys[i])
+            }
+        }
+        openRouterClient.SetStreaming(keys)
+    }
+
+    // Fail fast with a canned reply while the LLM provider is down
+    if fallback := os.Getenv("LLM_FALLBACK_RESPONSE"); fallback != "" {
+        openRouterClient.SetFallbackResponse(fallback)
+    }
+
+    // Post-process LLM output before it reaches Telegram
+    postProcessPath := os.Getenv("POSTPROCESS_CONFIG")
+    if postProcessPath == "" {
+        postProcessPath = "training_data/postprocess.json"
+    }
+    postProcessConfig, err := llm.LoadPostProcessConfig(postProcessPath)
+    if err != nil {
+        logger.Fatalf("Failed to load post-processing config: %v", err)
+    }
+    openRouterClient.SetPostProcessing(postProcessConfig)
 
-    // Initialize API server - use GetStore instead of accessing Store directly
+    // Financial advice guardrails on DD responses, per chat or jurisdiction
+    advicePolicyPath := os.Getenv("ADVICE_POLICY_CONFIG")
+    if advicePolicyPath == "" {
+        advicePolicyPath = "training_data/advice_policy.json"
+    }
+    advicePolicyConfig, err := llm.LoadAdvicePolicyConfig(advicePolicyPath)
+    if err != nil {
+        logger.Fatalf("Failed to load advice policy: %v", err)
+    }
+    if err := openRouterClient.SetAdvicePolicy(advicePolicyConfig); err != nil {
+        logger.Fatalf("Failed to set advice policy: %v", err)
+    }
+
+    // Model and OpenRouter provider preferences per prompt key, e.g. cheap
+    // fast providers for chat and a better model for reports
+    routingPath := os.Getenv("LLM_ROUTING_CONFIG")
+    if routingPath == "" {
+        routingPath = "training_data/llm_routing.json"
+    }
+    routingConfig, err := llm.LoadRoutingConfig(routingPath)
+    if err != nil {
+        logger.Fatalf("Failed to load LLM routing config: %v", err)
+    }
+    if err := openRouterClient.SetRouting(routingConfig); err != nil {
+        logger.Fatalf("Invalid LLM routing config: %v", err)
+    }
+
+    // Reply length and instructions per /style verbosity level and command
+    stylePath := os.Getenv("LLM_STYLE_CONFIG")
+    if stylePath == "" {
+        stylePath = "training_data/llm_styles.json"
+    }
+    styleConfig, err := llm.LoadStyleConfig(stylePath)
+    if err != nil {
+        logger.Fatalf("Failed to load LLM style config: %v", err)
+    }
+    if err := openRouterClient.SetStyles(styleConfig); err != nil {
+        logger.Fatalf("Invalid LLM style config: %v", err)
+    }
+
+    // A/B prompt variants, rated with the feedback buttons under responses
+    variantsPath := os.Getenv("PROMPT_VARIANTS_CONFIG")
+    if variantsPath == "" {
+        variantsPath = "training_data/prompt_variants.json"
+    }
+    variants, err := llm.LoadPromptVariants(variantsPath)
+    if err != nil {
+        logger.Fatalf("Failed to load prompt variants: %v", err)
+    }
+    openRouterClient.SetPromptVariants(variants)
+
+    // Summarize detected agent changes after each scrape
+    changeSummarizer := changes.NewSummarizer(utilsManager.GetStore(), openRouterClient, logger)
+    utilsManager.GetScraper().AddEnrichHook(webscraper.EnrichStandard, func() {
+        changeSummarizer.SummarizePending(llm.WithCommand(ctx, "change_summaries"))
+    })
+
+    // Pull news feeds and link articles to the agents they mention
+    newsFeedsPath := os.Getenv("NEWS_FEEDS_CONFIG")
+    if newsFeedsPath == "" {
+        newsFeedsPath = "training_data/news_feeds.json"
+    }
+    newsFeeds, err := news.LoadFeeds(newsFeedsPath)
+    if err != nil {
+        logger.Fatalf("Failed to load news feeds: %v", err)
+    }
+    news.NewIngester(utilsManager.GetStore(), newsFeeds, logger).Start(ctx, news.DefaultPollInterval)
+    logger.Printf("Polling %d news feeds", len(newsFeeds))
+
+    // Cheap liveness checks between full scrapes catch delistings early;
+    // HEARTBEAT_INTERVAL defaults to 10m, "off" disables
+    if raw := os.Getenv("HEARTBEAT_INTERVAL"); raw != "off" {
+        heartbeatInterval := heartbeat.DefaultInterval
+        if raw != "" {
+            if heartbeatInterval, err = time.ParseDuration(raw); err != nil || heartbeatInterval <= 0 {
+                logger.Fatalf("Invalid HEARTBEAT_INTERVAL: %q", raw)
+            }
+        }
+        heartbeat.NewChecker(utilsManager.GetStore(), os.Getenv("HEARTBEAT_URL"), logger).Start(ctx, heartbeatInterval)
+        logger.Printf("Checking agent heartbeats every %s", heartbeatInterval)
+    }
+
+    // Anomalies queue a deep scrape and fresh DD of their agent for its
+    // watchers; ANOMALY_DEEP_DIVE_COOLDOWN (default 6h) spaces them out per
+    // agent and "off" disables them
+    if raw := os.Getenv("ANOMALY_DEEP_DIVE_COOLDOWN"); raw != "off" {
+        cooldown := deepdive.DefaultCooldown
+        if raw != "" {
+            if cooldown, err = time.ParseDuration(raw); err != nil || cooldown <= 0 {
+                logger.Fatalf("Invalid ANOMALY_DEEP_DIVE_COOLDOWN: %q", raw)
+            }
+        }
+        deepdive.NewRunner(utilsManager.GetScraper(), openRouterClient, utilsManager.GetEvents(), cooldown, logger).Start(ctx)
+        logger.Printf("Deep diving anomalous agents at most every %s", cooldown)
+    }
+
+    // After scrapes, fields the parser keeps missing get LLM-suggested
+    // selectors for admins to adopt; PARSE_DIGEST_INTERVAL (default 24h)
+    // spaces the digests out and "off" disables them
+    if raw := os.Getenv("PARSE_DIGEST_INTERVAL"); raw != "off" {
+        interval := parsedigest.DefaultInterval
+        if raw != "" {
+            if interval, err = time.ParseDuration(raw); err != nil || interval <= 0 {
+                logger.Fatalf("Invalid PARSE_DIGEST_INTERVAL: %q", raw)
+            }
+        }
+        digester := parsedigest.NewDigester(utilsManager.GetScraper(), openRouterClient, interval, logger)
+        digester.Start(ctx)
+        utilsManager.SetParseDigester(digester)
+        logger.Printf("Digesting parse failures at most every %s", interval)
+    }
+
+    // Keep agent risk scores current, a batch of stale ones after each scrape
+    riskScorer := risk.NewScorer(utilsManager.GetStore(), openRouterClient, utilsManager.GetOnChain(), logger)
+    utilsManager.GetScraper().AddEnrichHook(webscraper.EnrichStandard, func() {
+        riskScorer.RescoreStale(llm.WithCommand(ctx, "risk_scoring"))
+    })
+
+    // Check that agents' Twitter, Telegram and website links still resolve
+    socialVerifier := socials.NewVerifier(utilsManager.GetStore(), logger)
+    utilsManager.GetScraper().AddEnrichHook(webscraper.EnrichStandard, func() {
+        socialVerifier.VerifyStale(ctx)
+    })
+
+    // Stored conversations expire after CONVERSATION_RETENTION (default 30 days)
+    if raw := os.Getenv("CONVERSATION_RETENTION"); raw != "" {
+        retention, err := time.ParseDuration(raw)
+        if err != nil || retention <= 0 {
+            logger.Fatalf("Invalid CONVERSATION_RETENTION: %q", raw)
+        }
+        utilsManager.GetConversations().SetRetention(retention)
+    }
+    utilsManager.GetConversations().StartExpiry(ctx, storage.ConversationExpiryInterval, logger)
+
+    // Agent records no index lists are archived every AGENT_GC_INTERVAL
+    // (default daily, "off" disables) once untouched for AGENT_GC_GRACE
+    if raw := os.Getenv("AGENT_GC_INTERVAL"); raw != "off" {
+        gcInterval, gcGrace := storage.DefaultGCInterval, storage.DefaultGCGrace
+        if raw != "" {
+            if gcInterval, err = time.ParseDuration(raw); err != nil || gcInterval <= 0 {
+                logger.Fatalf("Invalid AGENT_GC_INTERVAL: %q", raw)
+            }
+        }
+        if raw := os.Getenv("AGENT_GC_GRACE"); raw != "" {
+            if gcGrace, err = time.ParseDuration(raw); err != nil || gcGrace < 0 {
+                logger.Fatalf("Invalid AGENT_GC_GRACE: %q", raw)
+            }
+        }
+        utilsManager.GetStore().StartGC(ctx, gcInterval, gcGrace, logger)
+    }
+
+    // Time zone for scheduled jobs and for quiet hours that don't name their own
+    scheduleLocation := time.Local
+    if tz := os.Getenv("SCHEDULE_TIMEZONE"); tz != "" {
+        scheduleLocation, err = time.LoadLocation(tz)
+        if err != nil {
+            logger.Fatalf("Invalid SCHEDULE_TIMEZONE: %v", err)
+        }
+    }
+    utilsManager.GetQuietHours().SetLocation(scheduleLocation)
+
+    // Weekly "state of the agents" report, published by bots with a report channel
+    reporter := report.NewReporter(utilsManager.GetStore(), openRouterClient, logger)
+    reporter.SetLocation(scheduleLocation)
+    reportSchedule := os.Getenv("WEEKLY_REPORT_SCHEDULE")
+    if reportSchedule == "" {
+        reportSchedule = report.DefaultWeeklySchedule
+    }
+    if err := reporter.Start(llm.WithCommand(ctx, "weekly_report"), reportSchedule); err != nil {
+        logger.Fatalf("Failed to schedule weekly report: %v", err)
+    }
+    utilsManager.SetReporter(reporter)
+
+    // Full-text search over agent names, descriptions and reports, rebuilt
+    // after scrapes and new reports
+    searchIndex := search.NewIndex(utilsManager.GetStore(), logger)
+    rebuildSearch := func() {
+        if err := searchIndex.Rebuild(); err != nil {
+            logger.Printf("Error rebuilding search index: %v", err)
+        }
+    }
+    utilsManager.GetScraper().AddScrapeHook(rebuildSearch)
+    reporter.AddPublishHook(func(*models.Report) { rebuildSearch() })
+    utilsManager.SetSearch(searchIndex)
+
+    // Scrape profiles run on their own schedules only when SCRAPE_SCHEDULES=on;
+    // otherwise scrapes are started by admins from the bot or API
+    schedules := os.Getenv("SCRAPE_SCHEDULES") == "on"
+
+    // A startup self-test checks the outbound IP, Chrome, one known agent page
+    // and the selectors before schedules start, retrying until it passes, so a
+    // broken environment doesn't run a whole scrape of failing fetches.
+    // SCRAPE_SELF_TEST=off skips it.
+    selfTest := os.Getenv("SCRAPE_SELF_TEST") != "off"
+    if selfTest {
+        selfTestConfig := webscraper.SelfTestConfig{IPEchoURL: os.Getenv("SCRAPE_SELF_TEST_IP_URL")}
+        if raw := os.Getenv("SCRAPE_SELF_TEST_AGENT_ID"); raw != "" {
+            id, err := strconv.Atoi(raw)
+            if err != nil || id <= 0 {
+                logger.Fatalf("Invalid SCRAPE_SELF_TEST_AGENT_ID: %q", raw)
+            }
+            selfTestConfig.AgentID = id
+        }
+        go func() {
+            for !utilsManager.GetScraper().SelfTest(ctx, selfTestConfig).Passed() {
+                logger.Println("Scrape self-test failed, holding scheduled scrapes and retrying in 5 minutes")
+                select {
+                case <-ctx.Done():
+                    return
+                case <-time.After(5 * time.Minute):
+                }
+            }
+            if schedules && ctx.Err() == nil {
+                if err := utilsManager.GetScraper().StartSchedules(scheduleLocation); err != nil {
+                    logger.Printf("Failed to schedule scrapes: %v", err)
+                }
+            }
+        }()
+    } else if schedules {
+        if err := utilsManager.GetScraper().StartSchedules(scheduleLocation); err != nil {
+            logger.Fatalf("Failed to schedule scrapes: %v", err)
+        }
+    }
+
+    // Load analysis pipelines, falling back to the built-in ones
+    pipelinesPath := os.Getenv("PIPELINES_CONFIG")
+    if pipelinesPath == "" {
+        pipelinesPath = "training_data/pipelines.json"
+    }
+    pipelines, err := pipeline.LoadPipelines(pipelinesPath)
+    if err != nil {
+        logger.Fatalf("Failed to load pipelines: %v", err)
+    }
+    pipelineEngine := pipeline.NewEngine(utilsManager.GetStore(), openRouterClient, pipelines, logger)
+    utilsManager.SetPipelines(pipelineEngine)
+    logger.Printf("Loaded %d analysis pipelines", len(pipelines))
+
+    // Command plugins built as shared objects are loaded from PLUGINS_DIR;
+    // compiled-in ones are already installed
+    pluginsDir := os.Getenv("PLUGINS_DIR")
+    if pluginsDir == "" {
+        pluginsDir = "training_data/plugins"
+    }
+    if pluginsDir != "off" {
+        loaded, err := plugins.LoadDir(pluginsDir)
+        if err != nil {
+            logger.Fatalf("Failed to load plugins: %v", err)
+        }
+        for _, p := range loaded {
+            if err := utilsManager.RegisterPlugin(p); err != nil {
+                logger.Fatalf("Failed to register plugin: %v", err)
+            }
+        }
+    }
+    logger.Printf("Installed %d command plugins", len(utilsManager.GetPlugins().List()))
+
+    // Initialize API server with its own router and http.Server
     logger.Println("Initializing API server...")
-    apiServer := api.NewAPIServer(utilsManager.GetStore(), logger)
+    apiConfig := api.DefaultServerConfig()
+    if addr := os.Getenv("API_ADDR"); addr != "" {
+        apiConfig.Addr = addr
+    }
+    apiConfig.TLSCertFile = os.Getenv("API_TLS_CERT")
+    apiConfig.TLSKeyFile = os.Getenv("API_TLS_KEY")
+    apiConfig.PublicURL = os.Getenv("API_PUBLIC_URL")
+    // Agent responses drop the raw stats text unless clients still need it
+    apiConfig.LegacyStats = os.Getenv("API_LEGACY_STATS") == "on"
+    // Operational endpoints need an admin tenant, or this key when tenancy is off
+    apiConfig.AdminKey = os.Getenv("API_ADMIN_KEY")
+
+    apiServer := api.NewAPIServer(utilsManager.GetStore(), apiConfig, logger)
+    apiServer.SetPipelines(pipelineEngine)
+    apiServer.SetFeedback(utilsManager.GetFeedbackStore())
+    apiServer.SetScraper(utilsManager.GetScraper())
+    if selfTest {
+        apiServer.RequireSelfTest()
+    }
+    apiServer.SetLLMUsage(utilsManager.GetLLMUsage())
+    apiServer.SetLLMAudit(utilsManager.GetLLMAudit())
+    apiServer.SetLLM(openRouterClient)
+    apiServer.SetParseDigester(utilsManager.GetParseDigester())
+    apiServer.SetEvents(utilsManager.GetEvents())
+    apiServer.SetSearch(utilsManager.GetSearch())
+    apiServer.SetUserStores(&api.UserStores{
+        Keys:        utilsManager.GetUserKeys(),
+        Watchlists:  utilsManager.GetWatchlists(),
+        Subscribers: utilsManager.GetAlertSubscribers(),
+        Profiles:    utilsManager.GetProfiles(),
+        Quiet:       utilsManager.GetQuietHours(),
+    })
+
+    // Optional API keys per consumer, with rate limits, quotas and data views
+    tenantsPath := os.Getenv("API_TENANTS_CONFIG")
+    if tenantsPath == "" {
+        tenantsPath = "training_data/api_tenants.json"
+    }
+    tenants, err := api.LoadTenants(tenantsPath)
+    if err != nil {
+        logger.Fatalf("Failed to load API tenants: %v", err)
+    }
+    if tenants != nil {
+        apiServer.SetTenants(tenants, utilsManager.GetShared())
+        logger.Printf("Requiring API keys for %d tenants", tenants.Len())
+    }
     apiServer.SetupRoutes()
     logger.Println("API server initialized successfully")
 
-    // Start HTTP server in a goroutine with context
-    srv := &http.Server{
-        Addr:    ":8080",
-        Handler: http.DefaultServeMux,
-    }
-    
     go func() {
-        logger.Println("Starting HTTP server on port 8080...")
-        if err := srv.ListenAndServe(); err != http.ErrServerClosed {
+        if err := apiServer.Start(); err != http.ErrServerClosed {
             logger.Printf("API server error: %v", err)
         }
     }()
 
-    // Shutdown on context cancellation
-    go func() {
-        <-ctx.Done()
-        logger.Println("Shutting down HTTP server...")
-        if err := srv.Shutdown(context.Background()); err != nil {
-            logger.Printf("HTTP server shutdown error: %v", err)
+    // The API keeps serving until everything else has stopped
+    shutdowns.Add(shutdown.CloseHTTP, "API server", apiServer.Shutdown)
+
+    // Bots come from TELEGRAM_BOTS_CONFIG, or a single bot from TELEGRAM_BOT_TOKEN
+    var botConfigs []telegram.BotConfig
+    if botsConfigPath != "" {
+        configs, err := telegram.LoadBotConfigs(botsConfigPath)
+        if err != nil {
+            logger.Fatalf("Failed to load bot configs: %v", err)
         }
-    }()
+        botConfigs = configs
+    } else {
+        config := telegram.BotConfig{Name: "default", Token: botToken}
+        if raw := os.Getenv("TELEGRAM_ANNOUNCE_CHAT_ID"); raw != "" {
+            id, err := strconv.ParseInt(raw, 10, 64)
+            if err != nil {
+                logger.Fatalf("Invalid TELEGRAM_ANNOUNCE_CHAT_ID: %v", err)
+            }
+            config.AnnounceChatID = id
+        }
+        if raw := os.Getenv("TELEGRAM_ADMIN_CHAT_ID"); raw != "" {
+            id, err := strconv.ParseInt(raw, 10, 64)
+            if err != nil {
+                logger.Fatalf("Invalid TELEGRAM_ADMIN_CHAT_ID: %v", err)
+            }
+            config.AdminChatID = id
+        }
+        if raw := os.Getenv("TELEGRAM_RATE_LIMIT"); raw != "" {
+            limit, err := strconv.Atoi(raw)
+            if err != nil {
+                logger.Fatalf("Invalid TELEGRAM_RATE_LIMIT: %v", err)
+            }
+            config.RateLimit = limit
+        }
+        if raw := os.Getenv("TELEGRAM_REPORT_CHAT_ID"); raw != "" {
+            id, err := strconv.ParseInt(raw, 10, 64)
+            if err != nil {
+                logger.Fatalf("Invalid TELEGRAM_REPORT_CHAT_ID: %v", err)
+            }
+            config.ReportChatID = id
+        }
+        if raw := os.Getenv("TELEGRAM_DIGEST_MINUTES"); raw != "" {
+            if raw == "off" {
+                config.DigestMinutes = -1
+            } else {
+                minutes, err := strconv.Atoi(raw)
+                if err != nil || minutes <= 0 {
+                    logger.Fatalf("Invalid TELEGRAM_DIGEST_MINUTES: %q", raw)
+                }
+                config.DigestMinutes = minutes
+            }
+        }
+        botConfigs = append(botConfigs, config)
+    }
 
-    // Start the bot with context
-    logger.Println("Starting Telegram bot...")
-    if err := telegram.StartBot(ctx, botToken, openRouterClient, utilsManager, logger); err != nil {
-        logger.Fatalf("Failed to start Telegram bot: %v", err)
+    // Long responses link to the API's share page when it is publicly reachable
+    for i := range botConfigs {
+        if botConfigs[i].ShareBaseURL == "" {
+            botConfigs[i].ShareBaseURL = apiConfig.PublicURL
+        }
     }
-    logger.Println("Telegram bot started successfully")
+
+    // Start each bot in its own goroutine; a failing bot does not stop the others
+    var wg sync.WaitGroup
+    shutdowns.Add(shutdown.Drain, "telegram bots", func(drainCtx context.Context) error {
+        stopped := make(chan struct{})
+        go func() {
+            wg.Wait()
+            close(stopped)
+        }()
+        select {
+        case <-stopped:
+            return nil
+        case <-drainCtx.Done():
+            return drainCtx.Err()
+        }
+    })
+    for _, config := range botConfigs {
+        wg.Add(1)
+        go func(config telegram.BotConfig) {
+            defer wg.Done()
+            logger.Printf("Starting Telegram bot %s...", config.Name)
+            if err := telegram.StartBot(ctx, config, openRouterClient, utilsManager, logger); err != nil {
+                logger.Printf("Telegram bot %s stopped with error: %v", config.Name, err)
+                return
+            }
+            logger.Printf("Telegram bot %s stopped", config.Name)
+        }(config)
+    }
+    wg.Wait()
+
+    // Bots stop once a shutdown cancels ctx, or on their own if they all
+    // failed; either way the remaining stages finish before exit
+    shutdowns.Shutdown()
 }
@@ -0,0 +1,37 @@
+// Package agentcount is an example command plugin. Build the bot with
+// -tags plugin_agentcount to link it in.
+package agentcount
+
+import (
+    "context"
+    "fmt"
+    "anondd/utils/models"
+    "anondd/utils/plugins"
+)
+
+func init() {
+    plugins.Register(agentCount{})
+}
+
+// agentCount answers /agent_count with how many agents are tracked by status
+type agentCount struct{}
+
+func (agentCount) Info() plugins.CommandInfo {
+    return plugins.CommandInfo{
+        Command:     "/agent_count",
+        Description: "How many agents are tracked, by status",
+    }
+}
+
+func (agentCount) Handle(ctx context.Context, req plugins.Request) (plugins.Response, error) {
+    index, err := req.Store.GetIndexContext(ctx)
+    if err != nil {
+        return plugins.Response{}, err
+    }
+    counts := make(map[string]int)
+    for _, summary := range index.Agents {
+        counts[summary.Status]++
+    }
+    return plugins.Response{Text: fmt.Sprintf("📊 Tracking %d agents: %d active, %d latent, %d dead.",
+        len(index.Agents), counts[models.StatusActive], counts[models.StatusLatent], counts[models.StatusDead])}, nil
+}
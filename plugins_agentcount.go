@@ -0,0 +1,6 @@
+//go:build plugin_agentcount
+
+package main
+
+// Links in the example /agent_count plugin
+import _ "anondd/plugins/agentcount"
@@ -0,0 +1,224 @@
+package telegram
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+
+	"anondd/utils/models"
+	"anondd/utils/storage"
+	"anondd/utils/webscraper"
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// isAdmin reports whether the user is listed in TELEGRAM_ADMIN_IDS (comma-separated user IDs)
+func isAdmin(userID int64) bool {
+	for _, raw := range strings.Split(os.Getenv("TELEGRAM_ADMIN_IDS"), ",") {
+		if id, err := strconv.ParseInt(strings.TrimSpace(raw), 10, 64); err == nil && id == userID {
+			return true
+		}
+	}
+	return false
+}
+
+// requireAdmin replies with an error and returns false for non-admin users
+func requireAdmin(bot *Bot, update tgbotapi.Update) bool {
+	if update.Message.From != nil && isAdmin(update.Message.From.ID) {
+		return true
+	}
+	bot.Send(tgbotapi.NewMessage(update.Message.Chat.ID, "⛔ This command is for admins only."))
+	return false
+}
+
+// handleResetFailures implements /reset_failures [agent_id], clearing one or all quarantined IDs
+func handleResetFailures(bot *Bot, update tgbotapi.Update, store *storage.AgentStore, args []string, logger *log.Logger) {
+	if !requireAdmin(bot, update) {
+		return
+	}
+	chatID := update.Message.Chat.ID
+
+	agentID := ""
+	if len(args) > 0 {
+		agentID = args[0]
+	}
+
+	cleared, err := store.ResetFailures(agentID)
+	if err != nil {
+		logger.Printf("Error resetting failures: %v", err)
+		bot.Send(tgbotapi.NewMessage(chatID, "❌ Unable to reset failure records."))
+		return
+	}
+
+	if agentID == "" {
+		bot.Send(tgbotapi.NewMessage(chatID, fmt.Sprintf("🧹 Cleared failure records for %d IDs.", cleared)))
+		return
+	}
+	if cleared == 0 {
+		bot.Send(tgbotapi.NewMessage(chatID, fmt.Sprintf("ℹ️ ID %s has no failure record.", agentID)))
+		return
+	}
+	bot.Send(tgbotapi.NewMessage(chatID, fmt.Sprintf("🧹 ID %s released from quarantine.", agentID)))
+}
+
+// handleReparseAll implements /reparse_all, re-running the parser over every
+// stored page. It runs in the background and reports when it finishes, so the
+// bot keeps answering.
+func handleReparseAll(bot *Bot, update tgbotapi.Update, scraper *webscraper.VirtualsScraper, logger *log.Logger) {
+	if !requireAdmin(bot, update) {
+		return
+	}
+	chatID := update.Message.Chat.ID
+	bot.Send(tgbotapi.NewMessage(chatID, "🔁 Reparsing stored pages, I'll report back when it's done..."))
+
+	go func() {
+		parsed, failed, err := scraper.ReparseAll()
+		if err != nil {
+			logger.Printf("Error reparsing stored pages: %v", err)
+			bot.Send(tgbotapi.NewMessage(chatID, "❌ Unable to reparse stored pages."))
+			return
+		}
+		bot.Send(tgbotapi.NewMessage(chatID, fmt.Sprintf("✅ Reparsed %d pages (%d failed).", parsed, failed)))
+	}()
+}
+
+// handleAcceptLayout implements /accept_layout, releasing pages held after a
+// layout change once the parser handles the new layout
+func handleAcceptLayout(bot *Bot, update tgbotapi.Update, scraper *webscraper.VirtualsScraper, logger *log.Logger) {
+	if !requireAdmin(bot, update) {
+		return
+	}
+	chatID := update.Message.Chat.ID
+
+	if err := scraper.AcceptLayout(); err != nil {
+		logger.Printf("Error accepting page layout: %v", err)
+		bot.Send(tgbotapi.NewMessage(chatID, "❌ Unable to reset the layout baseline."))
+		return
+	}
+	bot.Send(tgbotapi.NewMessage(chatID, "✅ Layout accepted. Held pages will be parsed on the next scrape."))
+}
+
+// handleResetSession implements /reset_session [source], forgetting the saved
+// browser session of a source, virtuals by default
+func handleResetSession(bot *Bot, update tgbotapi.Update, args []string, logger *log.Logger) {
+	if !requireAdmin(bot, update) {
+		return
+	}
+	chatID := update.Message.Chat.ID
+
+	source := models.SourceVirtuals
+	if len(args) > 0 {
+		source = strings.ToLower(args[0])
+	}
+	if err := webscraper.ResetSession(source); err != nil {
+		logger.Printf("Error resetting %s session: %v", source, err)
+		bot.Send(tgbotapi.NewMessage(chatID, "❌ Unable to reset the session."))
+		return
+	}
+	bot.Send(tgbotapi.NewMessage(chatID, fmt.Sprintf("🍪 Cleared the %s session. The next fetch starts without cookies or localStorage.", source)))
+}
+
+// handleGCAgents implements /gc_agents [run], listing the orphaned and
+// duplicate agent records garbage collection would archive, or archiving them
+func handleGCAgents(bot *Bot, update tgbotapi.Update, store *storage.AgentStore, args []string, logger *log.Logger) {
+	if !requireAdmin(bot, update) {
+		return
+	}
+	chatID := update.Message.Chat.ID
+
+	dryRun := len(args) == 0 || args[0] != "run"
+	report, err := store.CollectGarbage(dryRun, storage.DefaultGCGrace)
+	if err != nil {
+		logger.Printf("Error collecting agent garbage: %v", err)
+		bot.Send(tgbotapi.NewMessage(chatID, "❌ Unable to collect agent garbage."))
+		return
+	}
+
+	found := len(report.Orphans) + len(report.Duplicates)
+	summary := fmt.Sprintf("Scanned %d records (%d indexed, %d too recent to touch): %d orphaned, %d duplicates, %.1f KB.",
+		report.Scanned, report.Indexed, report.Skipped, len(report.Orphans), len(report.Duplicates), float64(report.ReclaimedBytes)/1024)
+	switch {
+	case found == 0:
+		bot.Send(tgbotapi.NewMessage(chatID, "✨ "+summary))
+	case dryRun:
+		bot.Send(tgbotapi.NewMessage(chatID, "🔍 "+summary+"\nRun /gc_agents run to archive them."))
+	default:
+		bot.Send(tgbotapi.NewMessage(chatID, fmt.Sprintf("🗑️ %s\nArchived %d records to %s.", summary, report.Archived, report.ArchiveDir)))
+	}
+}
+
+// layoutAlert returns a hook warning an admin chat about site layout changes
+func layoutAlert(bot *Bot, chatID int64, logger *log.Logger) func(webscraper.LayoutDrift) {
+	return func(drift webscraper.LayoutDrift) {
+		text := "⚠️ " + drift.Summary() + "\nParsing is paused so empty fields aren't stored. Fix the parser, then run /accept_layout."
+		bot.Post(tgbotapi.NewMessage(chatID, text))
+		logger.Printf("Queued layout alert for chat %d", chatID)
+	}
+}
+
+// selfTestAlert reports the scrape self-test to an admin chat: the startup
+// result, then whenever it starts or stops failing
+func selfTestAlert(bot *Bot, chatID int64, logger *log.Logger) func(*webscraper.SelfTestReport) {
+	return func(report *webscraper.SelfTestReport) {
+		text := report.Summary()
+		if !report.Passed() {
+			text += "\nScheduled scrapes are held until it passes; it is retried every few minutes."
+		}
+		bot.Post(tgbotapi.NewMessage(chatID, text))
+		logger.Printf("Queued self-test alert for chat %d", chatID)
+	}
+}
+
+// handleIndexVersions implements /index_versions, listing saved index versions newest first
+func handleIndexVersions(bot *Bot, update tgbotapi.Update, store *storage.AgentStore, logger *log.Logger) {
+	if !requireAdmin(bot, update) {
+		return
+	}
+	chatID := update.Message.Chat.ID
+
+	versions, err := store.IndexVersions()
+	if err != nil {
+		logger.Printf("Error listing index versions: %v", err)
+		bot.Send(tgbotapi.NewMessage(chatID, "❌ Unable to list index versions."))
+		return
+	}
+	if len(versions) == 0 {
+		bot.Send(tgbotapi.NewMessage(chatID, "ℹ️ No index versions saved yet."))
+		return
+	}
+
+	var sb strings.Builder
+	sb.WriteString("🗂 Index versions (newest first):\n\n")
+	for _, version := range versions {
+		sb.WriteString(fmt.Sprintf("%s: %d agents, %s\n", version.ID, version.Agents, version.Reason))
+	}
+	sb.WriteString("\nRestore one with /rollback_index <version>")
+	bot.Send(tgbotapi.NewMessage(chatID, sb.String()))
+}
+
+// handleRollbackIndex implements /rollback_index <version>, restoring a saved index
+func handleRollbackIndex(bot *Bot, update tgbotapi.Update, store *storage.AgentStore, args []string, logger *log.Logger) {
+	if !requireAdmin(bot, update) {
+		return
+	}
+	chatID := update.Message.Chat.ID
+	if len(args) == 0 {
+		bot.Send(tgbotapi.NewMessage(chatID, "Usage: /rollback_index <version> (see /index_versions)"))
+		return
+	}
+
+	version, err := store.RollbackIndex(args[0])
+	if errors.Is(err, storage.ErrNotFound) {
+		bot.Send(tgbotapi.NewMessage(chatID, fmt.Sprintf("❌ No index version %s.", args[0])))
+		return
+	}
+	if err != nil {
+		logger.Printf("Error rolling back index: %v", err)
+		bot.Send(tgbotapi.NewMessage(chatID, "❌ Unable to roll back the index."))
+		return
+	}
+	bot.Send(tgbotapi.NewMessage(chatID, fmt.Sprintf("⏪ Index rolled back to %s (%d agents). The replaced index was saved as a new version.",
+		version.ID, version.Agents)))
+}
@@ -0,0 +1,97 @@
+package telegram
+
+import (
+	"fmt"
+	"log"
+	"strings"
+
+	"anondd/utils/events"
+	"anondd/utils/models"
+	"anondd/utils/storage"
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// alerter pushes anomaly events to the chats subscribed on this bot
+type alerter struct {
+	notifier    *notifier
+	subscribers *storage.SubscriberStore
+	store       *storage.AgentStore
+	settings    *storage.ChatSettingsStore
+}
+
+func newAlerter(notifier *notifier, subscribers *storage.SubscriberStore, store *storage.AgentStore, settings *storage.ChatSettingsStore) *alerter {
+	return &alerter{
+		notifier:    notifier,
+		subscribers: subscribers,
+		store:       store,
+		settings:    settings,
+	}
+}
+
+// send delivers a change to subscribed chats whose filter allows the agent,
+// if it is an anomaly or a launch stage alert
+func (a *alerter) send(changed events.AgentChanged) {
+	event := changed.Change
+	icon := "🚨"
+	switch {
+	case models.IsStageAlert(event.Type):
+		icon = "🎓"
+	case !models.IsAnomaly(event.Type):
+		return
+	}
+
+	text := fmt.Sprintf("%s %s: %s (%s → %s)", icon, event.AgentName, event.Summary, event.Before, event.After)
+	var agent *models.Agent
+	// Queued so one unreachable chat doesn't hold up the rest
+	for _, chatID := range a.subscribers.Subscribers(a.notifier.botName) {
+		if filter := chatFilter(a.settings, chatID); !filter.Empty() {
+			if agent == nil {
+				loaded, err := a.store.GetAgent(event.AgentID)
+				if err != nil {
+					a.notifier.logger.Printf("Error loading agent %s to filter alerts: %v", event.AgentID, err)
+					loaded = &models.Agent{ID: event.AgentID, Name: event.AgentName}
+				}
+				agent = loaded
+			}
+			if !filter.Allows(agent) {
+				continue
+			}
+		}
+		a.notifier.notifyChange(chatID, event, text)
+	}
+}
+
+// handleAlerts implements /alerts on|off for the current chat
+func handleAlerts(bot *Bot, update tgbotapi.Update, botName string, subscribers *storage.SubscriberStore, args []string, logger *log.Logger) {
+	chatID := update.Message.Chat.ID
+
+	var reply string
+	switch {
+	case len(args) > 0 && strings.ToLower(args[0]) == "on":
+		added, err := subscribers.Subscribe(botName, chatID)
+		switch {
+		case err != nil:
+			logger.Printf("Error subscribing chat %d to alerts: %v", chatID, err)
+			reply = "❌ Unable to subscribe right now."
+		case added:
+			reply = "🚨 Anomaly alerts enabled for this chat."
+		default:
+			reply = "ℹ️ Alerts are already enabled for this chat."
+		}
+	case len(args) > 0 && strings.ToLower(args[0]) == "off":
+		removed, err := subscribers.Unsubscribe(botName, chatID)
+		switch {
+		case err != nil:
+			logger.Printf("Error unsubscribing chat %d from alerts: %v", chatID, err)
+			reply = "❌ Unable to unsubscribe right now."
+		case removed:
+			reply = "🔕 Anomaly alerts disabled for this chat."
+		default:
+			reply = "ℹ️ Alerts were not enabled for this chat."
+		}
+	default:
+		reply = "Usage: /alerts on|off - holder and volume anomaly alerts, graduations and agents about to graduate"
+	}
+
+	bot.Send(tgbotapi.NewMessage(chatID, reply))
+}
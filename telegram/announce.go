@@ -0,0 +1,82 @@
+package telegram
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"anondd/llm"
+	"anondd/utils/storage"
+)
+
+// announcer posts newly discovered agents to a Telegram channel
+type announcer struct {
+	notifier *notifier
+	store    *storage.AgentStore
+	settings *storage.ChatSettingsStore
+	client   *llm.OpenRouterClient
+	chatID   int64
+	logger   *log.Logger
+
+	mu        sync.Mutex
+	cursor    time.Time
+	announced map[string]bool // Identity keys already posted, so re-keyed agents aren't posted twice
+}
+
+func newAnnouncer(notifier *notifier, store *storage.AgentStore, settings *storage.ChatSettingsStore, client *llm.OpenRouterClient, chatID int64, logger *log.Logger) *announcer {
+	return &announcer{
+		notifier: notifier,
+		store:    store,
+		settings: settings,
+		client:   client,
+		chatID:   chatID,
+		logger:   logger,
+		cursor:   time.Now(),
+
+		announced: make(map[string]bool),
+	}
+}
+
+// announceNew posts every agent first seen since the last announcement that
+// the announcement chat's filter allows
+func (a *announcer) announceNew() {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	newAgents, err := a.store.NewAgentsSince(a.cursor)
+	if err != nil {
+		a.logger.Printf("Error loading new agents for announcement: %v", err)
+		return
+	}
+
+	filter := chatFilter(a.settings, a.chatID)
+	for _, summary := range newAgents {
+		a.cursor = summary.FirstSeen
+		if a.announced[summary.IdentityKey()] {
+			continue
+		}
+		a.announced[summary.IdentityKey()] = true
+
+		details := fmt.Sprintf("Name: %s\nPrice: %s", summary.Name, summary.Price)
+		agent, err := a.store.GetAgent(summary.ID)
+		if err == nil {
+			details += fmt.Sprintf("\nDescription: %s", agent.Description)
+		}
+		if !filter.Empty() && (err != nil || !filter.Allows(agent)) {
+			continue
+		}
+
+		intro, err := a.client.GetResponse(llm.WithCommand(context.Background(), "announce"), "new_listing", details)
+		if err != nil {
+			a.logger.Printf("Error writing intro for new agent %s: %v", summary.ID, err)
+			intro = "Fresh agent just dropped."
+		} else {
+			intro = a.client.PostProcess(context.Background(), "new_listing", "", intro)
+		}
+
+		text := fmt.Sprintf("🆕 New agent spotted: %s (%s)\n\n%s", summary.Name, summary.Price, intro)
+		a.notifier.notify(a.chatID, text)
+	}
+}
@@ -0,0 +1,71 @@
+package telegram
+
+import (
+	"fmt"
+	"log"
+	"strings"
+
+	"anondd/utils/storage"
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// handleAPIKey implements /apikey [new|revoke], a personal REST API key that
+// serves the user's watchlist, alerts and preferences from /api/me. Keys are
+// shown once and only in private chats, whose watchlist and alerts they read.
+func handleAPIKey(bot *Bot, update tgbotapi.Update, botName string, keys *storage.UserKeyStore, args []string, logger *log.Logger) {
+	message := update.Message
+	chatID := message.Chat.ID
+	if keys == nil {
+		bot.Send(tgbotapi.NewMessage(chatID, "ℹ️ Personal API keys are disabled."))
+		return
+	}
+	if message.From == nil {
+		return
+	}
+	if !message.Chat.IsPrivate() {
+		bot.Send(tgbotapi.NewMessage(chatID, fmt.Sprintf("🔑 Message me directly with /apikey to get a personal API key, @%s.", bot.Self.UserName)))
+		return
+	}
+	userID := message.From.ID
+
+	action := ""
+	if len(args) > 0 {
+		action = strings.ToLower(args[0])
+	}
+	var reply string
+	switch action {
+	case "":
+		if record, exists := keys.Get(userID); exists {
+			reply = fmt.Sprintf("🔑 Your API key %s… was created %s.\n\n/apikey new - replace it\n/apikey revoke - delete it",
+				record.Hint, record.CreatedAt.Format("Jan 2, 2006"))
+		} else {
+			reply = "🔑 You have no API key yet. /apikey new creates one for your watchlist, alerts and preferences."
+		}
+	case "new":
+		key, _, err := keys.Issue(userID, botName)
+		if err != nil {
+			logger.Printf("Error issuing API key for user %d: %v", userID, err)
+			reply = "❌ Unable to create an API key right now."
+			break
+		}
+		logger.Printf("Issued API key for user %d on bot %s", userID, botName)
+		reply = fmt.Sprintf("🔑 Your new API key, shown only once:\n\n%s\n\n"+
+			"Send it as the X-API-Key header to GET /api/me, /api/me/watchlist and /api/me/alerts, "+
+			"or PUT /api/me/alerts to turn alerts on or off. Any earlier key stopped working.", key)
+	case "revoke":
+		removed, err := keys.Revoke(userID)
+		switch {
+		case err != nil:
+			logger.Printf("Error revoking API key for user %d: %v", userID, err)
+			reply = "❌ Unable to revoke your API key right now."
+		case removed:
+			reply = "🗑 Your API key was revoked."
+		default:
+			reply = "ℹ️ You have no API key."
+		}
+	default:
+		reply = "Usage: /apikey [new|revoke] - personal REST API key"
+	}
+
+	bot.Send(tgbotapi.NewMessage(chatID, reply))
+}
@@ -0,0 +1,233 @@
+package telegram
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"anondd/llm"
+	"anondd/utils/models"
+	"anondd/utils/storage"
+	"anondd/utils/trace"
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// Follow-up context kept per chat for /ask
+const (
+	askSessionTTL   = 15 * time.Minute
+	askMaxTurns     = 4
+	askHistoryLimit = 10
+)
+
+type askTurn struct {
+	Question string
+	Answer   string
+}
+
+// askSession is the agent and recent Q&A a chat is drilling into
+type askSession struct {
+	agentID   string
+	turns     []askTurn
+	answers   []int // Message IDs of the answers sent, so replies to them follow up
+	updatedAt time.Time
+}
+
+// answered reports whether messageID is one of the session's answers
+func (s askSession) answered(messageID int) bool {
+	for _, id := range s.answers {
+		if id == messageID {
+			return true
+		}
+	}
+	return false
+}
+
+// askSessionStore holds the in-memory /ask sessions keyed by bot and chat
+type askSessionStore struct {
+	mu       sync.Mutex
+	sessions map[string]*askSession
+}
+
+var askSessions = &askSessionStore{sessions: make(map[string]*askSession)}
+
+func askSessionKey(botName string, chatID int64) string {
+	return fmt.Sprintf("%s:%d", botName, chatID)
+}
+
+// forget drops the chat's session
+func (s *askSessionStore) forget(key string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.sessions, key)
+}
+
+// get returns a copy of the chat's session if it has not expired
+func (s *askSessionStore) get(key string) (askSession, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	session, exists := s.sessions[key]
+	if !exists || time.Since(session.updatedAt) > askSessionTTL {
+		delete(s.sessions, key)
+		return askSession{}, false
+	}
+	copied := *session
+	copied.turns = append([]askTurn(nil), session.turns...)
+	copied.answers = append([]int(nil), session.answers...)
+	return copied, true
+}
+
+// noteAnswer remembers the message an answer about agentID was sent as
+func (s *askSessionStore) noteAnswer(key, agentID string, messageID int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	session, exists := s.sessions[key]
+	if !exists || session.agentID != agentID {
+		return
+	}
+	session.answers = append(session.answers, messageID)
+	if len(session.answers) > askMaxTurns {
+		session.answers = session.answers[len(session.answers)-askMaxTurns:]
+	}
+}
+
+// record appends a turn, starting a new session if the agent changed
+func (s *askSessionStore) record(key, agentID string, turn askTurn) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	session, exists := s.sessions[key]
+	if !exists || session.agentID != agentID {
+		session = &askSession{agentID: agentID}
+		s.sessions[key] = session
+	}
+	session.turns = append(session.turns, turn)
+	if len(session.turns) > askMaxTurns {
+		session.turns = session.turns[len(session.turns)-askMaxTurns:]
+	}
+	session.updatedAt = time.Now()
+}
+
+// handleAsk implements /ask <agent> <question>. The first word must be an
+// agent's ID or exact name; otherwise, if the chat has an active session,
+// the whole text is a follow-up.
+func handleAsk(ctx context.Context, bot *Bot, update tgbotapi.Update, botName string, store *storage.AgentStore, conversations *storage.ConversationStore, client *llm.OpenRouterClient, args []string, logger *log.Logger) {
+	chatID := update.Message.Chat.ID
+	key := askSessionKey(botName, chatID)
+
+	if len(args) == 0 {
+		bot.Send(tgbotapi.NewMessage(chatID, "Usage: /ask <agent> <question>\nThen follow up with /ask <question> or reply to my answer."))
+		return
+	}
+
+	var agent *models.Agent
+	question := strings.Join(args[1:], " ")
+	if found, err := store.FindAgentExact(ctx, args[0]); err == nil && question != "" {
+		agent = found
+	} else if session, ok := askSessions.get(key); ok {
+		question = strings.Join(args, " ")
+		agent, err = store.GetAgentContext(ctx, session.agentID)
+		if err != nil {
+			trace.Logf(ctx, logger, "Error loading agent %s for follow-up: %v", session.agentID, err)
+		}
+	}
+
+	if agent == nil {
+		if question == "" {
+			bot.Send(tgbotapi.NewMessage(chatID, "Usage: /ask <agent> <question>"))
+		} else {
+			bot.Send(tgbotapi.NewMessage(chatID, fmt.Sprintf("❌ No agent named '%s'", args[0])))
+		}
+		return
+	}
+
+	answerAgentQuestion(ctx, bot, update.Message, botName, store, conversations, client, agent, question, logger)
+}
+
+// handleAskFollowUp answers a reply to one of the bot's /ask answers as a
+// follow-up question. It returns false for replies to anything else or once
+// the chat's /ask session expired.
+func handleAskFollowUp(ctx context.Context, bot *Bot, update tgbotapi.Update, botName string, store *storage.AgentStore, conversations *storage.ConversationStore, client *llm.OpenRouterClient, logger *log.Logger) bool {
+	message := update.Message
+	if message.ReplyToMessage == nil || message.ReplyToMessage.From == nil || message.ReplyToMessage.From.ID != bot.Self.ID {
+		return false
+	}
+
+	key := askSessionKey(botName, message.Chat.ID)
+	session, ok := askSessions.get(key)
+	if !ok || !session.answered(message.ReplyToMessage.MessageID) {
+		return false
+	}
+	agent, err := store.GetAgentContext(ctx, session.agentID)
+	if err != nil {
+		trace.Logf(ctx, logger, "Error loading agent %s for follow-up: %v", session.agentID, err)
+		return false
+	}
+
+	answerAgentQuestion(ctx, bot, message, botName, store, conversations, client, agent, message.Text, logger)
+	return true
+}
+
+func answerAgentQuestion(ctx context.Context, bot *Bot, message *tgbotapi.Message, botName string, store *storage.AgentStore, conversations *storage.ConversationStore, client *llm.OpenRouterClient, agent *models.Agent, question string, logger *log.Logger) {
+	chatID := message.Chat.ID
+	key := askSessionKey(botName, chatID)
+
+	var b strings.Builder
+	b.WriteString(agentRecord(agent))
+
+	if history, err := store.GetHistory(agent.SourceID); err == nil && len(history) > 0 {
+		if len(history) > askHistoryLimit {
+			history = history[len(history)-askHistoryLimit:]
+		}
+		b.WriteString("\nMetric history (oldest first):\n")
+		for _, snapshot := range history {
+			fmt.Fprintf(&b, "- %s: price %s, mcap %.0f, holders %.0f, 24h volume %.0f, mindshare %s\n",
+				snapshot.At.Format("2006-01-02 15:04"), snapshot.Price, snapshot.MCap,
+				snapshot.Holders, snapshot.Volume24h, snapshot.Mindshare)
+		}
+	}
+
+	if session, ok := askSessions.get(key); ok && session.agentID == agent.ID {
+		b.WriteString("\nEarlier in this conversation:\n")
+		for _, turn := range session.turns {
+			fmt.Fprintf(&b, "Q: %s\nA: %s\n", turn.Question, turn.Answer)
+		}
+	}
+	fmt.Fprintf(&b, "\nQuestion: %s", question)
+
+	answer, err := client.GetResponse(ctx, "ask_agent", b.String())
+	if err != nil {
+		trace.Logf(ctx, logger, "Error answering question about %s: %v", agent.Name, err)
+		bot.Send(tgbotapi.NewMessage(chatID, "Unable to answer that right now."))
+		return
+	}
+	askSessions.record(key, agent.ID, askTurn{Question: question, Answer: answer})
+
+	answer = client.PostProcess(ctx, "ask_agent", "", answer)
+	recordTurn(ctx, conversations, botName, message, storage.ConversationTurn{Kind: storage.TurnAsk, Agent: agent.Name, Message: question, Reply: answer}, logger)
+
+	reply := tgbotapi.NewMessage(chatID, fmt.Sprintf("🤖 %s\n\n%s", agent.Name, answer))
+	reply.ReplyMarkup = withMentions(ctx, store, nil, answer, agent.ID, logger)
+	sent, err := bot.Send(reply)
+	if err != nil {
+		trace.Logf(ctx, logger, "Error sending answer: %v", err)
+		return
+	}
+	askSessions.noteAnswer(key, agent.ID, sent.MessageID)
+}
+
+// agentRecord formats every stored field of an agent for a prompt
+func agentRecord(a *models.Agent) string {
+	return fmt.Sprintf("Name: %s\nPrice: %s\nStatus: %s\nStats: %s\nDescription: %s\n"+
+		"Mindshare: %s\nImpressions: %s\nEngagement: %s\nFollowers: %s\nSmart Followers: %s\nTop Tweets: %s\n"+
+		"MC (FDV): %s\n24h Change: %s\nTVL: %s\nHolders: %s\n24h Volume: %s\nInferences: %s\nLast Checked: %s\n",
+		a.Name, a.Price, a.Status, a.StatsText(), a.Description,
+		a.InfluenceMetrics.Mindshare, a.InfluenceMetrics.Impressions, a.InfluenceMetrics.Engagement,
+		a.InfluenceMetrics.Followers, a.InfluenceMetrics.SmartFollowers, a.InfluenceMetrics.TopTweets,
+		a.TokenData.MCFDV, a.TokenData.Change24h, a.TokenData.TVL, a.TokenData.Holders,
+		a.TokenData.Volume24h, a.TokenData.Inferences, a.LastChecked.Format("2006-01-02 15:04"))
+}
@@ -0,0 +1,336 @@
+package telegram
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"anondd/llm"
+	"anondd/utils/models"
+	"anondd/utils/onchain"
+	"anondd/utils/storage"
+	"anondd/utils/trace"
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// DD depth options offered after /give_dd
+const (
+	ddDepthQuick = "quick"
+	ddDepthFull  = "full"
+	ddDepthRisks = "risks"
+
+	ddCallbackPrefix = "dd"
+)
+
+// ddDepthPromptKeys maps each depth to the prompt key used for the analysis
+var ddDepthPromptKeys = map[string]string{
+	ddDepthQuick: "dd_quick",
+	ddDepthFull:  "dd_full",
+	ddDepthRisks: "dd_risks",
+}
+
+// ddDepthKeyboard builds the inline keyboard for selecting DD depth on an agent
+func ddDepthKeyboard(agentID string) tgbotapi.InlineKeyboardMarkup {
+	return tgbotapi.NewInlineKeyboardMarkup(
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData("⚡ Quick take", ddCallbackData(ddDepthQuick, agentID)),
+			tgbotapi.NewInlineKeyboardButtonData("📑 Full report", ddCallbackData(ddDepthFull, agentID)),
+			tgbotapi.NewInlineKeyboardButtonData("⚠️ Risks only", ddCallbackData(ddDepthRisks, agentID)),
+		),
+	)
+}
+
+// ddCallbackData encodes a depth selection as "dd:<depth>:<agentID>"
+func ddCallbackData(depth, agentID string) string {
+	return fmt.Sprintf("%s:%s:%s", ddCallbackPrefix, depth, agentID)
+}
+
+// parseDDCallbackData decodes callback data produced by ddCallbackData
+func parseDDCallbackData(data string) (depth, agentID string, ok bool) {
+	parts := strings.SplitN(data, ":", 3)
+	if len(parts) != 3 || parts[0] != ddCallbackPrefix {
+		return "", "", false
+	}
+	if _, exists := ddDepthPromptKeys[parts[1]]; !exists || parts[2] == "" {
+		return "", "", false
+	}
+	return parts[1], parts[2], true
+}
+
+// handleCallbackQuery routes inline keyboard callbacks to their handlers
+func handleCallbackQuery(ctx context.Context, bot *Bot, update tgbotapi.Update, config BotConfig, store *storage.AgentStore, feedback *storage.FeedbackStore, entitlements *storage.EntitlementStore, enricher *onchain.Enricher, persona string, client *llm.OpenRouterClient, logger *log.Logger) {
+	query := update.CallbackQuery
+
+	if depth, agentID, ok := parseDDCallbackData(query.Data); ok {
+		// The full report is the deep DD, reserved for premium users
+		if depth == ddDepthFull && !isPremium(config, entitlements, query.From) {
+			if _, err := bot.Request(tgbotapi.NewCallbackWithAlert(query.ID, "💎 Full reports are a premium feature, see /premium.")); err != nil {
+				trace.Logf(ctx, logger, "Error answering callback: %v", err)
+			}
+			return
+		}
+		handleDDDepthCallback(ctx, bot, query, config, store, enricher, persona, client, depth, agentID, logger)
+		return
+	}
+	if agentID, ok := parseMentionCallbackData(query.Data); ok {
+		handleMentionCallback(ctx, bot, query, store, agentID, logger)
+		return
+	}
+	if up, promptKey, variant, ok := parseFeedbackCallbackData(query.Data); ok {
+		handleFeedbackCallback(ctx, bot, query, feedback, up, promptKey, variant, logger)
+		return
+	}
+
+	trace.Logf(ctx, logger, "Unknown callback data: %s", query.Data)
+	if _, err := bot.Request(tgbotapi.NewCallback(query.ID, "Unknown action")); err != nil {
+		trace.Logf(ctx, logger, "Error answering callback: %v", err)
+	}
+}
+
+// handleDDDepthCallback runs the analysis for the selected depth and edits the
+// original message in place, keeping the keyboard so the user can switch depth
+func handleDDDepthCallback(ctx context.Context, bot *Bot, query *tgbotapi.CallbackQuery, config BotConfig, store *storage.AgentStore, enricher *onchain.Enricher, persona string, client *llm.OpenRouterClient, depth, agentID string, logger *log.Logger) {
+	if _, err := bot.Request(tgbotapi.NewCallback(query.ID, "Crunching the numbers...")); err != nil {
+		trace.Logf(ctx, logger, "Error answering callback: %v", err)
+	}
+
+	if query.Message == nil {
+		return
+	}
+	chatID := query.Message.Chat.ID
+	messageID := query.Message.MessageID
+	keyboard := ddDepthKeyboard(agentID)
+
+	agent, err := store.GetAgentContext(ctx, agentID)
+	if err != nil {
+		trace.Logf(ctx, logger, "Error loading agent %s for DD: %v", agentID, err)
+		edit := tgbotapi.NewEditMessageText(chatID, messageID, "❌ Agent data is no longer available.")
+		bot.Send(edit)
+		return
+	}
+
+	loading := tgbotapi.NewEditMessageTextAndMarkup(chatID, messageID,
+		fmt.Sprintf("🔍 Digging into %s...", agent.Name), keyboard)
+	bot.Send(loading)
+
+	// Deeper reports include on-chain data; enrich a copy so the cached agent stays untouched
+	if depth != ddDepthQuick && enricher.Enabled() && agent.ContractAddress != "" {
+		enriched := *agent
+		if err := enricher.Enrich(ctx, &enriched); err != nil {
+			trace.Logf(ctx, logger, "Error enriching agent %s on-chain: %v", agentID, err)
+		}
+		agent = &enriched
+	}
+
+	var news []models.NewsArticle
+	if depth != ddDepthQuick {
+		if news, err = store.GetAgentNews(agent.ID, ddNewsLimit); err != nil {
+			trace.Logf(ctx, logger, "Error loading news for agent %s: %v", agentID, err)
+		}
+	}
+
+	creator, hasCreator, err := store.CreatorOf(ctx, agent.Creator())
+	if err != nil {
+		trace.Logf(ctx, logger, "Error loading creator of agent %s: %v", agentID, err)
+	}
+	var launcher *models.Creator
+	if hasCreator {
+		launcher = &creator
+	}
+
+	promptKey := ddDepthPromptKeys[depth]
+	analysis, variant, err := client.GetResponseVariant(ctx, "", promptKey, ddAgentSlice(agent, depth, news, launcher), strconv.FormatInt(chatID, 10))
+	if err != nil {
+		trace.Logf(ctx, logger, "Error getting %s DD for agent %s: %v", depth, agentID, err)
+		analysis = "Unable to analyze agent at this time."
+	} else {
+		analysis = client.PostProcess(ctx, promptKey, persona, analysis)
+		analysis = shareLongText(ctx, store, config, fmt.Sprintf("%s for %s", ddDepthTitle(depth), agent.Name), analysis, logger)
+		keyboard.InlineKeyboard = append(keyboard.InlineKeyboard, mentionRows(ctx, store, analysis, agent.ID, logger)...)
+		keyboard.InlineKeyboard = append(keyboard.InlineKeyboard, feedbackRow(promptKey, variant))
+	}
+
+	var badges []string
+	if launched := agent.LaunchDate(); !launched.IsZero() {
+		badges = append(badges, ageBadge(launched, time.Now()))
+	}
+	if agent.Risk != nil {
+		badges = append(badges, riskBadge(agent.Risk))
+	}
+	if flagged := agent.FlaggedSocials(); len(flagged) > 0 {
+		badges = append(badges, socialsBadge(flagged))
+	}
+	if launcher != nil {
+		if dead := launcher.DeadAgents(agent.ID); len(dead) >= models.SerialLauncherThreshold {
+			badges = append(badges, serialLauncherBadge(dead))
+		}
+	}
+	response := fmt.Sprintf("🤖 %s for %s:\n\n%s", ddDepthTitle(depth), agent.Name, analysis)
+	if len(badges) > 0 {
+		response = fmt.Sprintf("🤖 %s for %s:\n%s\n\n%s", ddDepthTitle(depth), agent.Name, strings.Join(badges, "\n"), analysis)
+	}
+	edit := tgbotapi.NewEditMessageTextAndMarkup(chatID, messageID, response, keyboard)
+	if _, err := bot.Send(edit); err != nil {
+		trace.Logf(ctx, logger, "Error editing DD message: %v", err)
+	}
+}
+
+// ddAgentSlice selects the agent data relevant to the chosen depth, followed by
+// any recent news about the agent. creator is nil when the agent's developer
+// wallet is unknown.
+func ddAgentSlice(agent *models.Agent, depth string, news []models.NewsArticle, creator *models.Creator) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Name: %s\nPrice: %s\n", agent.Name, agent.Price)
+	if launched := agent.LaunchDate(); !launched.IsZero() {
+		fmt.Fprintf(&b, "Launched: %s (%s ago)\n", launched.Format("2006-01-02"), models.FormatAge(time.Since(launched)))
+	}
+
+	switch depth {
+	case ddDepthQuick:
+		fmt.Fprintf(&b, "Stats: %s\n", agent.StatsText())
+	case ddDepthRisks:
+		fmt.Fprintf(&b, "Status: %s\nDescription: %s\n", agent.Status, agent.Description)
+		fmt.Fprintf(&b, "MC (FDV): %s\n24h Change: %s\nTVL: %s\nHolders: %s\n24h Volume: %s\n",
+			agent.TokenData.MCFDV, agent.TokenData.Change24h, agent.TokenData.TVL,
+			agent.TokenData.Holders, agent.TokenData.Volume24h)
+	default:
+		fmt.Fprintf(&b, "Status: %s\nStats: %s\nDescription: %s\n", agent.Status, agent.StatsText(), agent.Description)
+		fmt.Fprintf(&b, "Mindshare: %s\nImpressions: %s\nEngagement: %s\nFollowers: %s\nSmart Followers: %s\n",
+			agent.InfluenceMetrics.Mindshare, agent.InfluenceMetrics.Impressions, agent.InfluenceMetrics.Engagement,
+			agent.InfluenceMetrics.Followers, agent.InfluenceMetrics.SmartFollowers)
+		fmt.Fprintf(&b, "MC (FDV): %s\n24h Change: %s\nTVL: %s\nHolders: %s\n24h Volume: %s\nInferences: %s\n",
+			agent.TokenData.MCFDV, agent.TokenData.Change24h, agent.TokenData.TVL,
+			agent.TokenData.Holders, agent.TokenData.Volume24h, agent.TokenData.Inferences)
+	}
+
+	if agent.OnChain != nil && depth != ddDepthQuick {
+		writeOnChain(&b, agent)
+	}
+	if agent.Risk != nil && depth != ddDepthQuick {
+		writeRisk(&b, agent.Risk)
+	}
+	if len(agent.Socials) > 0 && depth != ddDepthQuick {
+		writeSocials(&b, agent.Socials)
+	}
+	if creator != nil && depth != ddDepthQuick {
+		writeCreator(&b, agent, creator)
+	}
+	if len(news) > 0 {
+		writeNews(&b, news)
+	}
+
+	return b.String()
+}
+
+// writeOnChain appends the agent's on-chain data to a DD data slice
+func writeOnChain(b *strings.Builder, agent *models.Agent) {
+	data := agent.OnChain
+	fmt.Fprintf(b, "Contract: %s (%s)\nTotal Supply: %s\n", agent.ContractAddress, data.Chain, data.TotalSupply)
+	if data.HoldersSampled > 0 {
+		fmt.Fprintf(b, "Top 10 Holder Share: %.1f%% (of %d recent holders sampled)\n",
+			data.TopHolderShare*100, data.HoldersSampled)
+	}
+	if data.Pool != nil {
+		fmt.Fprintf(b, "Liquidity Pool: %s\nPool Reserves: %s tokens / %s quote\n",
+			data.Pool.Address, data.Pool.TokenReserve, data.Pool.QuoteReserve)
+	} else {
+		fmt.Fprintf(b, "Liquidity Pool: none found\n")
+	}
+}
+
+// writeRisk appends the agent's risk score and its factors to a DD data slice
+func writeRisk(b *strings.Builder, risk *models.RiskScore) {
+	fmt.Fprintf(b, "Risk Score: %d/100 (%s)\n", risk.Score, risk.Level)
+	for _, factor := range risk.Factors {
+		fmt.Fprintf(b, "- %s: %d (%s)\n", factor.Name, factor.Score, factor.Detail)
+	}
+}
+
+// writeSocials appends the agent's social links and their check results to a DD data slice
+func writeSocials(b *strings.Builder, links []models.SocialLink) {
+	b.WriteString("Social Links:\n")
+	for _, link := range links {
+		status := link.Status
+		if status == "" {
+			status = "unchecked"
+		}
+		if link.Detail != "" {
+			status += ", " + link.Detail
+		}
+		fmt.Fprintf(b, "- %s %s (%s)\n", link.Kind, link.URL, status)
+	}
+}
+
+// writeCreator appends the agent's developer wallet and its other launches to
+// a DD data slice
+func writeCreator(b *strings.Builder, agent *models.Agent, creator *models.Creator) {
+	dead := creator.DeadAgents(agent.ID)
+	fmt.Fprintf(b, "Creator Wallet: %s (%d agents launched, %d others dead)\n", creator.Address, len(creator.Agents), len(dead))
+	if len(dead) >= models.SerialLauncherThreshold {
+		fmt.Fprintf(b, "Serial Launcher: yes, the creator's dead agents are %s\n", launchNames(dead))
+	}
+}
+
+// serialLauncherBadge warns that the agent's creator left other agents dead
+func serialLauncherBadge(dead []models.AgentSummary) string {
+	return fmt.Sprintf("🚩 Serial launcher: creator has %d dead agents (%s)", len(dead), launchNames(dead))
+}
+
+// launchNames lists a few agent names, noting how many more there are
+func launchNames(agents []models.AgentSummary) string {
+	const shown = 5
+	names := make([]string, 0, shown)
+	for i, agent := range agents {
+		if i == shown {
+			names = append(names, fmt.Sprintf("+%d more", len(agents)-shown))
+			break
+		}
+		names = append(names, agent.Name)
+	}
+	return strings.Join(names, ", ")
+}
+
+// socialsBadge warns about dead or mismatched social links in DD messages
+func socialsBadge(flagged []models.SocialLink) string {
+	parts := make([]string, 0, len(flagged))
+	for _, link := range flagged {
+		parts = append(parts, fmt.Sprintf("%s %s", link.Kind, link.Status))
+	}
+	return "⚠️ Socials: " + strings.Join(parts, ", ")
+}
+
+// ageBadge shows how long ago the agent launched in DD messages, flagging
+// agents under a week old
+func ageBadge(launched, now time.Time) string {
+	icon := "📅"
+	if now.Sub(launched) < freshWindow {
+		icon = "🐣"
+	}
+	return fmt.Sprintf("%s Age: %s (launched %s)", icon, models.FormatAge(now.Sub(launched)), launched.Format("Jan 2, 2006"))
+}
+
+// riskBadge renders a risk score as a one-line highlight for DD messages
+func riskBadge(risk *models.RiskScore) string {
+	icon := "🟢"
+	switch risk.Level {
+	case models.RiskHigh:
+		icon = "🔴"
+	case models.RiskMedium:
+		icon = "🟡"
+	}
+	return fmt.Sprintf("%s Risk score: %d/100 (%s)", icon, risk.Score, risk.Level)
+}
+
+func ddDepthTitle(depth string) string {
+	switch depth {
+	case ddDepthQuick:
+		return "Quick take"
+	case ddDepthRisks:
+		return "Risk check"
+	default:
+		return "Full report"
+	}
+}
@@ -0,0 +1,78 @@
+package telegram
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"anondd/utils/chart"
+	"anondd/utils/storage"
+	"anondd/utils/trace"
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+const chartUsage = "Usage: /chart <agent> [price|holders|mindshare|mcap|volume_24h] [24h|7d|30d]"
+
+// handleChart implements /chart <agent> [metric] [range]: a PNG line chart of
+// one metric from the agent's stored history
+func handleChart(ctx context.Context, bot *Bot, update tgbotapi.Update, store *storage.AgentStore, args []string, logger *log.Logger) {
+	chatID := update.Message.Chat.ID
+
+	// Metric and range may trail the agent name in either order
+	metric, rangeName := chart.DefaultMetric, chart.DefaultRange
+	for len(args) > 0 {
+		last := strings.ToLower(args[len(args)-1])
+		if chart.ValidMetric(last) {
+			metric = last
+		} else if _, ok := chart.Ranges[last]; ok {
+			rangeName = last
+		} else {
+			break
+		}
+		args = args[:len(args)-1]
+	}
+	if len(args) == 0 {
+		bot.Send(tgbotapi.NewMessage(chatID, chartUsage))
+		return
+	}
+
+	query := strings.Join(args, " ")
+	agent, err := store.FindAgent(ctx, query)
+	if err != nil {
+		bot.Send(tgbotapi.NewMessage(chatID, fmt.Sprintf("❌ No agent found matching '%s'", query)))
+		return
+	}
+
+	history, err := store.GetHistory(agent.SourceID)
+	if err != nil {
+		trace.Logf(ctx, logger, "Error loading history for %s: %v", agent.Name, err)
+		bot.Send(tgbotapi.NewMessage(chatID, "Error accessing agent history"))
+		return
+	}
+
+	points := chart.Series(history, metric, chart.Ranges[rangeName], time.Now())
+	image, err := chart.Render(points)
+	if errors.Is(err, chart.ErrNotEnoughData) {
+		bot.Send(tgbotapi.NewMessage(chatID, fmt.Sprintf("📉 Not enough %s history for %s over %s yet.", metric, agent.Name, rangeName)))
+		return
+	}
+	if err != nil {
+		trace.Logf(ctx, logger, "Error rendering chart for %s: %v", agent.Name, err)
+		bot.Send(tgbotapi.NewMessage(chatID, "Error rendering chart"))
+		return
+	}
+
+	first, last := points[0].Value, points[len(points)-1].Value
+	caption := fmt.Sprintf("📈 %s %s, last %s\nNow %s", agent.Name, metric, rangeName, chart.FormatValue(last))
+	if first != 0 {
+		caption += fmt.Sprintf(" (%+.1f%%)", (last-first)/first*100)
+	}
+	photo := tgbotapi.NewPhoto(chatID, tgbotapi.FileBytes{Name: "chart.png", Bytes: image})
+	photo.Caption = caption
+	if _, err := bot.Send(photo); err != nil {
+		trace.Logf(ctx, logger, "Error sending chart: %v", err)
+	}
+}
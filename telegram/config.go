@@ -0,0 +1,118 @@
+package telegram
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// BotConfig describes one Telegram bot run by the process
+type BotConfig struct {
+	Name              string   `json:"name"`
+	Token             string   `json:"token"`
+	DefaultPersona    string   `json:"default_persona,omitempty"`      // System message for chats without their own persona
+	AllowedCommands   []string `json:"allowed_commands,omitempty"`     // Empty allows every command; "inline" allows inline queries
+	AnnounceChatID    int64    `json:"announce_chat_id,omitempty"`     // Chat receiving new agent announcements
+	ReportChatID      int64    `json:"report_chat_id,omitempty"`       // Channel receiving the weekly report
+	ShareBaseURL      string   `json:"share_base_url,omitempty"`       // Public API URL; enables share links for long responses
+	ShareThreshold    int      `json:"share_threshold,omitempty"`      // Response length that triggers a share link
+	RateLimit         int      `json:"rate_limit,omitempty"`           // Messages per chat per minute; 0 disables
+	AdminChatID       int64    `json:"admin_chat_id,omitempty"`        // Chat receiving operational alerts such as layout changes
+	Premium           bool     `json:"premium,omitempty"`              // Gate premium features behind invite codes or Stars payments
+	PremiumCommands   []string `json:"premium_commands,omitempty"`     // Commands only premium users may run, besides deep DD
+	PremiumStars      int      `json:"premium_stars,omitempty"`        // Stars price of PremiumDays of premium; 0 disables purchases
+	PremiumDays       int      `json:"premium_days,omitempty"`         // Days of premium per purchase, 30 by default
+	FreeWatchLimit    int      `json:"free_watch_limit,omitempty"`     // Watchlist size without premium, 3 by default
+	Jurisdiction      string   `json:"jurisdiction,omitempty"`         // Where the bot's chats are, for the financial advice policy
+	UpdateWorkers     int      `json:"update_workers,omitempty"`       // Updates handled at once across chats, 8 by default
+	MaxPendingPerChat int      `json:"max_pending_per_chat,omitempty"` // Updates queued per chat before more are dropped, 20 by default
+	DigestMinutes     int      `json:"digest_minutes,omitempty"`       // Minutes between digests of informational updates, 60 by default; -1 sends them at once
+}
+
+// LoadBotConfigs reads a JSON array of bot configs from path
+func LoadBotConfigs(path string) ([]BotConfig, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read bot config: %w", err)
+	}
+
+	var configs []BotConfig
+	if err := json.Unmarshal(data, &configs); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal bot config: %w", err)
+	}
+
+	for i, config := range configs {
+		if config.Token == "" {
+			return nil, fmt.Errorf("bot config %d (%s) has no token", i, config.Name)
+		}
+		if config.Name == "" {
+			configs[i].Name = fmt.Sprintf("bot%d", i+1)
+		}
+		// The chat keeps a preview of shared responses, so sharing anything
+		// shorter than it would only add a link
+		if config.ShareThreshold != 0 && config.ShareThreshold < sharePreviewLength {
+			return nil, fmt.Errorf("bot config %d (%s) has share_threshold %d, below the %d-character preview",
+				i, configs[i].Name, config.ShareThreshold, sharePreviewLength)
+		}
+	}
+	return configs, nil
+}
+
+// premiumDays is the number of days of premium one Stars purchase buys
+func (c BotConfig) premiumDays() int {
+	if c.PremiumDays > 0 {
+		return c.PremiumDays
+	}
+	return 30
+}
+
+// freeWatchLimit is how many agents a chat can watch without premium
+func (c BotConfig) freeWatchLimit() int {
+	if c.FreeWatchLimit > 0 {
+		return c.FreeWatchLimit
+	}
+	return 3
+}
+
+// premiumCommand reports whether a slash command is gated behind premium
+func (c BotConfig) premiumCommand(command string) bool {
+	for _, premium := range c.PremiumCommands {
+		if strings.TrimPrefix(premium, "/") == strings.TrimPrefix(command, "/") {
+			return true
+		}
+	}
+	return false
+}
+
+// inlineCommand stands for inline queries in AllowedCommands
+const inlineCommand = "inline"
+
+// allowsCallback reports whether the command whose buttons send this
+// callback data is enabled for this bot. Feedback votes belong to no
+// command and are always allowed.
+func (c BotConfig) allowsCallback(data string) bool {
+	prefix, _, _ := strings.Cut(data, ":")
+	switch prefix {
+	case ddCallbackPrefix, mentionCallbackPrefix:
+		return c.allows("/give_dd") || c.allows("/dd")
+	case onboardingCallbackPrefix:
+		return c.allows("/start")
+	case selectorCallbackPrefix:
+		return c.allows("/parse_digest")
+	}
+	return true
+}
+
+// allows reports whether a slash command is enabled for this bot
+func (c BotConfig) allows(command string) bool {
+	if len(c.AllowedCommands) == 0 {
+		return true
+	}
+	for _, allowed := range c.AllowedCommands {
+		if strings.TrimPrefix(allowed, "/") == strings.TrimPrefix(command, "/") {
+			return true
+		}
+	}
+	return false
+}
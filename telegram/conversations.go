@@ -0,0 +1,80 @@
+package telegram
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"anondd/utils/storage"
+	"anondd/utils/trace"
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// recordTurn stores a user's message and the bot's reply in the
+// conversation store; failures are logged, never shown to the user
+func recordTurn(ctx context.Context, conversations *storage.ConversationStore, botName string, message *tgbotapi.Message, turn storage.ConversationTurn, logger *log.Logger) {
+	if conversations == nil || message.From == nil {
+		return
+	}
+	turn.UserID, turn.Username, turn.At = message.From.ID, message.From.UserName, time.Now()
+	if err := conversations.Append(botName, message.Chat.ID, turn); err != nil {
+		trace.Logf(ctx, logger, "Error recording conversation turn: %v", err)
+	}
+}
+
+// handleExportChat implements /export_chat, sending the user's stored
+// conversation in this chat as a text file
+func handleExportChat(ctx context.Context, bot *Bot, update tgbotapi.Update, botName string, conversations *storage.ConversationStore, logger *log.Logger) {
+	chatID := update.Message.Chat.ID
+	if conversations == nil || update.Message.From == nil {
+		bot.Send(tgbotapi.NewMessage(chatID, "ℹ️ No conversation is stored for you here."))
+		return
+	}
+
+	turns := conversations.Turns(botName, chatID, update.Message.From.ID)
+	if len(turns) == 0 {
+		bot.Send(tgbotapi.NewMessage(chatID, "ℹ️ No conversation is stored for you here."))
+		return
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "Conversation with %s, exported %s\n", bot.Self.UserName, time.Now().UTC().Format("2006-01-02 15:04 MST"))
+	for _, turn := range turns {
+		fmt.Fprintf(&b, "\n[%s]", turn.At.UTC().Format("2006-01-02 15:04"))
+		if turn.Kind == storage.TurnAsk {
+			fmt.Fprintf(&b, " /ask about %s", turn.Agent)
+		}
+		fmt.Fprintf(&b, "\nYou: %s\nBot: %s\n", turn.Message, turn.Reply)
+	}
+
+	document := tgbotapi.NewDocument(chatID, tgbotapi.FileBytes{Name: "conversation.txt", Bytes: []byte(b.String())})
+	document.Caption = fmt.Sprintf("🗂 %d messages. Use /forget to delete them.", len(turns))
+	if _, err := bot.Send(document); err != nil {
+		trace.Logf(ctx, logger, "Error sending conversation export: %v", err)
+	}
+}
+
+// handleForget implements /forget, deleting the user's stored conversation in
+// this chat along with the chat's /ask follow-up context
+func handleForget(bot *Bot, update tgbotapi.Update, botName string, conversations *storage.ConversationStore, logger *log.Logger) {
+	chatID := update.Message.Chat.ID
+	if conversations == nil || update.Message.From == nil {
+		bot.Send(tgbotapi.NewMessage(chatID, "ℹ️ No conversation is stored for you here."))
+		return
+	}
+
+	askSessions.forget(askSessionKey(botName, chatID))
+	removed, err := conversations.Forget(botName, chatID, update.Message.From.ID)
+	if err != nil {
+		logger.Printf("Error forgetting conversation in chat %d: %v", chatID, err)
+		bot.Send(tgbotapi.NewMessage(chatID, "❌ Unable to delete your conversation right now."))
+		return
+	}
+	if removed == 0 {
+		bot.Send(tgbotapi.NewMessage(chatID, "ℹ️ No conversation is stored for you here."))
+		return
+	}
+	bot.Send(tgbotapi.NewMessage(chatID, fmt.Sprintf("🧽 Deleted %d stored messages.", removed)))
+}
@@ -0,0 +1,113 @@
+package telegram
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"anondd/utils/storage"
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// maxCostsListed caps the prompt keys and commands listed by /costs
+const maxCostsListed = 8
+
+const costsUsage = "Usage: /costs [today|week|month|<days>]"
+
+// handleCosts implements the admin /costs command: LLM tokens and estimated
+// cost for today, or per day over the last week, month or number of days,
+// with the prompt keys and commands that cost the most
+func handleCosts(bot *Bot, update tgbotapi.Update, ledger *storage.LLMUsageLedger, args []string) {
+	if !requireAdmin(bot, update) {
+		return
+	}
+	chatID := update.Message.Chat.ID
+	if ledger == nil {
+		bot.Send(tgbotapi.NewMessage(chatID, "LLM usage is not being tracked."))
+		return
+	}
+
+	days := 1
+	if len(args) > 0 {
+		switch strings.ToLower(args[0]) {
+		case "today", "day":
+			days = 1
+		case "week":
+			days = 7
+		case "month":
+			days = 30
+		default:
+			n, err := strconv.Atoi(args[0])
+			if err != nil || n < 1 || n > 90 {
+				bot.Send(tgbotapi.NewMessage(chatID, costsUsage))
+				return
+			}
+			days = n
+		}
+	}
+
+	report := ledger.Report(days, time.Now())
+	bot.Send(tgbotapi.NewMessage(chatID, formatCosts(report, days)))
+}
+
+// formatCosts renders a usage report for /costs
+func formatCosts(report storage.LLMUsageReport, days int) string {
+	var b strings.Builder
+	if days == 1 {
+		fmt.Fprintf(&b, "💸 LLM usage today (%s)\n", report.To)
+	} else {
+		fmt.Fprintf(&b, "💸 LLM usage %s to %s\n", report.From, report.To)
+	}
+	fmt.Fprintf(&b, "\nTotal: %s\n", formatUsage(report.Total))
+	if report.Total.Calls == 0 {
+		return b.String()
+	}
+
+	if days > 1 {
+		b.WriteString("\n📅 By day\n")
+		for _, day := range report.Days {
+			fmt.Fprintf(&b, "  • %s: %s\n", day.Day, formatUsage(day.Total))
+		}
+	}
+
+	sections := []struct {
+		title     string
+		breakdown map[string]storage.LLMUsage
+	}{
+		{"🔑 By prompt key", report.ByPromptKey},
+		{"⌨️ By command", report.ByCommand},
+	}
+	for _, section := range sections {
+		fmt.Fprintf(&b, "\n%s\n", section.title)
+		for i, key := range storage.TopUsage(section.breakdown) {
+			if i == maxCostsListed {
+				fmt.Fprintf(&b, "  …and %d more\n", len(section.breakdown)-maxCostsListed)
+				break
+			}
+			fmt.Fprintf(&b, "  • %s: %s\n", key, formatUsage(section.breakdown[key]))
+		}
+	}
+	return b.String()
+}
+
+// formatUsage renders calls, tokens and cost on one line
+func formatUsage(usage storage.LLMUsage) string {
+	text := fmt.Sprintf("%d calls, %s tokens, $%.4f", usage.Calls, formatTokens(usage.Tokens()), usage.Cost)
+	if usage.Errors > 0 {
+		text += fmt.Sprintf(", %d errors", usage.Errors)
+	}
+	return text
+}
+
+// formatTokens abbreviates large token counts, e.g. 12.3k or 4.5M
+func formatTokens(tokens int) string {
+	switch {
+	case tokens >= 1000000:
+		return fmt.Sprintf("%.1fM", float64(tokens)/1e6)
+	case tokens >= 1000:
+		return fmt.Sprintf("%.1fk", float64(tokens)/1e3)
+	default:
+		return strconv.Itoa(tokens)
+	}
+}
@@ -0,0 +1,125 @@
+package telegram
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"anondd/utils/webscraper"
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// maxDeadLettersListed bounds the dead letters shown by /dead_letters
+const maxDeadLettersListed = 20
+
+// handleDeadLetters implements /dead_letters [kind|id], listing pages that
+// keep failing to parse, or sending the kept page of one of them
+func handleDeadLetters(bot *Bot, update tgbotapi.Update, scraper *webscraper.VirtualsScraper, args []string, logger *log.Logger) {
+	if !requireAdmin(bot, update) {
+		return
+	}
+	chatID := update.Message.Chat.ID
+	letters := scraper.DeadLetters()
+
+	if len(args) > 0 {
+		if id, err := strconv.Atoi(args[0]); err == nil {
+			sendDeadLetter(bot, chatID, letters, id, logger)
+			return
+		}
+	}
+	kind := ""
+	if len(args) > 0 {
+		kind = args[0]
+	}
+
+	list := letters.List(kind)
+	if len(list) == 0 {
+		if kind != "" {
+			bot.Send(tgbotapi.NewMessage(chatID, fmt.Sprintf("📭 No dead letters failed with %s.", kind)))
+		} else {
+			bot.Send(tgbotapi.NewMessage(chatID, "📭 No dead letters, every page parses."))
+		}
+		return
+	}
+
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("🪦 %d pages keep failing to parse (most recent first):\n\n", len(list)))
+	for i, letter := range list {
+		if i == maxDeadLettersListed {
+			sb.WriteString(fmt.Sprintf("…and %d more\n", len(list)-i))
+			break
+		}
+		sb.WriteString(fmt.Sprintf("%d: %s, %d attempts, last %s ago\n  %s\n", letter.SourceID, letter.ErrorKind,
+			letter.Attempts, formatDuration(time.Since(letter.LastFailedAt)), truncateText(letter.Error, 120)))
+	}
+	sb.WriteString("\n/dead_letters <id> - the page's HTML\n/requeue <id ...|all> - parse again after a parser fix")
+	bot.Send(tgbotapi.NewMessage(chatID, sb.String()))
+}
+
+// sendDeadLetter sends the kept page of a dead letter as a document
+func sendDeadLetter(bot *Bot, chatID int64, letters *webscraper.DeadLetterStore, id int, logger *log.Logger) {
+	letter, exists := letters.Get(id)
+	if !exists {
+		bot.Send(tgbotapi.NewMessage(chatID, fmt.Sprintf("ℹ️ ID %d has no dead letter.", id)))
+		return
+	}
+	html, err := letters.LoadHTML(id)
+	if err != nil {
+		logger.Printf("Error reading dead letter %d: %v", id, err)
+		bot.Send(tgbotapi.NewMessage(chatID, "❌ Unable to read the kept page."))
+		return
+	}
+
+	document := tgbotapi.NewDocument(chatID, tgbotapi.FileBytes{Name: fmt.Sprintf("agent_%d.html", id), Bytes: []byte(html)})
+	document.Caption = truncateText(fmt.Sprintf("🪦 %d: %s after %d attempts since %s, requeued %d times\n%s", id, letter.ErrorKind,
+		letter.Attempts, letter.FirstFailedAt.UTC().Format("Jan 2 15:04 MST"), letter.Requeues, letter.Error), 1000)
+	if _, err := bot.Send(document); err != nil {
+		logger.Printf("Error sending dead letter %d: %v", id, err)
+	}
+}
+
+// handleRequeue implements /requeue <id ...|all>, parsing dead letters again
+// with the current parser
+func handleRequeue(bot *Bot, update tgbotapi.Update, scraper *webscraper.VirtualsScraper, args []string, logger *log.Logger) {
+	if !requireAdmin(bot, update) {
+		return
+	}
+	chatID := update.Message.Chat.ID
+	if len(args) == 0 {
+		bot.Send(tgbotapi.NewMessage(chatID, "Usage: /requeue <id ...|all> - parse dead letters again"))
+		return
+	}
+
+	var ids []int
+	if strings.ToLower(args[0]) != "all" {
+		var err error
+		if ids, err = webscraper.ParseIDs(strings.Join(args, ",")); err != nil {
+			bot.Send(tgbotapi.NewMessage(chatID, fmt.Sprintf("❌ %v", err)))
+			return
+		}
+	}
+
+	result, err := scraper.RequeueDeadLetters(ids)
+	if errors.Is(err, webscraper.ErrScrapeInProgress) {
+		bot.Send(tgbotapi.NewMessage(chatID, "⏳ A scrape is running, try again when it finishes."))
+		return
+	}
+	if err != nil {
+		logger.Printf("Error requeueing dead letters: %v", err)
+		bot.Send(tgbotapi.NewMessage(chatID, "❌ Unable to requeue dead letters."))
+		return
+	}
+
+	text := fmt.Sprintf("🔁 Requeued %d pages: %d parsed, %d still failing.", result.Requeued, result.Parsed, result.Failed)
+	if len(result.Missing) > 0 {
+		missing := make([]string, len(result.Missing))
+		for i, id := range result.Missing {
+			missing[i] = strconv.Itoa(id)
+		}
+		text += fmt.Sprintf("\nNo dead letter for %s.", strings.Join(missing, ", "))
+	}
+	bot.Send(tgbotapi.NewMessage(chatID, text))
+}
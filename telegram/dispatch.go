@@ -0,0 +1,168 @@
+package telegram
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// Update dispatch defaults
+const (
+	defaultUpdateWorkers     = 8
+	defaultMaxPendingPerChat = 20
+	// updateDrainTimeout bounds how long shutdown waits for queued and
+	// in-flight updates before cancelling them
+	updateDrainTimeout = 30 * time.Second
+)
+
+// updateLane holds one chat's pending updates; a goroutine drains it while running is set
+type updateLane struct {
+	pending []tgbotapi.Update
+	running bool
+}
+
+// dispatcher runs update handlers on a bounded pool of workers. Updates from
+// the same chat are handled one at a time in arrival order, so a slow LLM call
+// only holds up its own chat.
+type dispatcher struct {
+	handle     func(tgbotapi.Update)
+	slots      chan struct{} // One per worker; held while a handler runs
+	maxPending int
+	mu         sync.Mutex
+	lanes      map[int64]*updateLane
+	closed     bool
+	wg         sync.WaitGroup
+	logger     *log.Logger
+}
+
+// newDispatcher creates a dispatcher running at most workers handlers at once
+// and queueing at most maxPending updates per chat; zero uses the defaults
+func newDispatcher(handle func(tgbotapi.Update), workers, maxPending int, logger *log.Logger) *dispatcher {
+	if workers <= 0 {
+		workers = defaultUpdateWorkers
+	}
+	if maxPending <= 0 {
+		maxPending = defaultMaxPendingPerChat
+	}
+	return &dispatcher{
+		handle:     handle,
+		slots:      make(chan struct{}, workers),
+		maxPending: maxPending,
+		lanes:      make(map[int64]*updateLane),
+		logger:     logger,
+	}
+}
+
+// dispatch queues update behind the earlier updates of its chat. Updates over
+// the chat's limit, or arriving after shutdown began, are dropped.
+func (d *dispatcher) dispatch(update tgbotapi.Update) {
+	chatID := updateChatID(update)
+
+	d.mu.Lock()
+	if d.closed {
+		d.mu.Unlock()
+		d.logger.Printf("Dropping update %d from chat %d: shutting down", update.UpdateID, chatID)
+		return
+	}
+	lane, exists := d.lanes[chatID]
+	if !exists {
+		lane = &updateLane{}
+		d.lanes[chatID] = lane
+	}
+	if len(lane.pending) >= d.maxPending {
+		d.mu.Unlock()
+		d.logger.Printf("Dropping update %d from chat %d: %d updates already queued", update.UpdateID, chatID, d.maxPending)
+		return
+	}
+	lane.pending = append(lane.pending, update)
+	startLane := !lane.running
+	lane.running = true
+	if startLane {
+		d.wg.Add(1)
+	}
+	d.mu.Unlock()
+
+	if startLane {
+		go d.drain(chatID, lane)
+	}
+}
+
+// drain handles a chat's updates in order until its lane is empty
+func (d *dispatcher) drain(chatID int64, lane *updateLane) {
+	defer d.wg.Done()
+	for {
+		d.mu.Lock()
+		if len(lane.pending) == 0 {
+			lane.running = false
+			delete(d.lanes, chatID)
+			d.mu.Unlock()
+			return
+		}
+		next := lane.pending[0]
+		lane.pending = lane.pending[1:]
+		d.mu.Unlock()
+
+		d.slots <- struct{}{}
+		d.run(next)
+		<-d.slots
+	}
+}
+
+// run handles one update, keeping a panicking handler from taking the bot down
+func (d *dispatcher) run(update tgbotapi.Update) {
+	defer func() {
+		if r := recover(); r != nil {
+			d.logger.Printf("Panic handling update %d: %v", update.UpdateID, r)
+		}
+	}()
+	d.handle(update)
+}
+
+// shutdown stops accepting updates and waits for the queued and in-flight
+// ones to finish, up to timeout. It reports whether they all finished.
+func (d *dispatcher) shutdown(timeout time.Duration) bool {
+	d.mu.Lock()
+	d.closed = true
+	d.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		d.wg.Wait()
+		close(done)
+	}()
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	select {
+	case <-done:
+		return true
+	case <-timer.C:
+		return false
+	}
+}
+
+// updateChatID returns the chat an update belongs to, falling back to the
+// sender for updates outside a chat such as inline queries
+func updateChatID(update tgbotapi.Update) int64 {
+	switch {
+	case update.Message != nil:
+		return update.Message.Chat.ID
+	case update.CallbackQuery != nil && update.CallbackQuery.Message != nil:
+		return update.CallbackQuery.Message.Chat.ID
+	case update.CallbackQuery != nil:
+		return update.CallbackQuery.From.ID
+	case update.InlineQuery != nil:
+		return update.InlineQuery.From.ID
+	case update.PreCheckoutQuery != nil:
+		return update.PreCheckoutQuery.From.ID
+	}
+	return 0
+}
+
+// withDrain returns a context that outlives parent's cancellation until
+// cancel is called, so handlers and sends can finish during shutdown
+func withDrain(parent context.Context) (context.Context, context.CancelFunc) {
+	return context.WithCancel(context.WithoutCancel(parent))
+}
@@ -0,0 +1,137 @@
+package telegram
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+
+	"anondd/utils/models"
+	"anondd/utils/storage"
+	"anondd/utils/trace"
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+const favUsage = "Usage: /fav add <agent> | /fav remove <agent> | /fav list"
+
+// handleFav implements /fav add|remove|list, the user's pinned agents. They
+// are offered as buttons by /give_dd without arguments and come first in
+// catch-ups after quiet hours.
+func handleFav(ctx context.Context, bot *Bot, update tgbotapi.Update, store *storage.AgentStore, profiles *storage.ProfileStore, args []string, logger *log.Logger) {
+	chatID := update.Message.Chat.ID
+	if update.Message.From == nil {
+		return
+	}
+	userID := update.Message.From.ID
+
+	if len(args) == 0 || strings.ToLower(args[0]) == "list" {
+		bot.Send(tgbotapi.NewMessage(chatID, formatFavorites(profiles.Favorites(userID))))
+		return
+	}
+
+	query := strings.TrimSpace(strings.Join(args[1:], " "))
+	if query == "" {
+		bot.Send(tgbotapi.NewMessage(chatID, favUsage))
+		return
+	}
+
+	var reply string
+	switch strings.ToLower(args[0]) {
+	case "add":
+		agent, err := store.FindAgent(ctx, query)
+		if err != nil {
+			reply = fmt.Sprintf("❌ No agent found matching '%s'", query)
+			break
+		}
+		added, err := profiles.AddFavorite(userID, agent)
+		switch {
+		case errors.Is(err, storage.ErrFull):
+			reply = fmt.Sprintf("ℹ️ You can pin up to %d agents. Remove one with /fav remove <agent> first.", storage.MaxFavorites)
+		case err != nil:
+			trace.Logf(ctx, logger, "Error adding %s to favorites of user %d: %v", agent.Name, userID, err)
+			reply = "❌ Unable to update your favorites right now."
+		case added:
+			reply = fmt.Sprintf("⭐ %s pinned. Send /give_dd to pick it quickly.", agent.Name)
+		default:
+			reply = fmt.Sprintf("ℹ️ %s is already one of your favorites.", agent.Name)
+		}
+	case "remove":
+		favorite, err := profiles.RemoveFavorite(userID, query)
+		switch {
+		case errors.Is(err, storage.ErrNotFound):
+			reply = fmt.Sprintf("ℹ️ None of your favorites match '%s'.", query)
+		case err != nil:
+			trace.Logf(ctx, logger, "Error removing %s from favorites of user %d: %v", query, userID, err)
+			reply = "❌ Unable to update your favorites right now."
+		default:
+			reply = fmt.Sprintf("🗑 %s unpinned.", favorite.Name)
+		}
+	default:
+		reply = favUsage
+	}
+
+	bot.Send(tgbotapi.NewMessage(chatID, reply))
+}
+
+// formatFavorites lists a user's pinned agents
+func formatFavorites(favorites []storage.FavoriteAgent) string {
+	if len(favorites) == 0 {
+		return "⭐ You have no favorites yet.\n\n" + favUsage
+	}
+	var b strings.Builder
+	fmt.Fprintf(&b, "⭐ Favorites (%d):\n", len(favorites))
+	for _, favorite := range favorites {
+		fmt.Fprintf(&b, "- %s\n", favorite.Name)
+	}
+	return b.String()
+}
+
+// handleFavoritesDD offers the user's favorites as buttons for a quick DD,
+// reporting false when they have none
+func handleFavoritesDD(ctx context.Context, bot *Bot, update tgbotapi.Update, store *storage.AgentStore, profiles *storage.ProfileStore, logger *log.Logger) bool {
+	if update.Message.From == nil {
+		return false
+	}
+	favorites := profiles.Favorites(update.Message.From.ID)
+	if len(favorites) == 0 {
+		return false
+	}
+
+	var rows [][]tgbotapi.InlineKeyboardButton
+	var row []tgbotapi.InlineKeyboardButton
+	for _, favorite := range favorites {
+		agent, err := resolveFavorite(ctx, store, favorite)
+		if err != nil {
+			trace.Logf(ctx, logger, "Skipping favorite %s: %v", favorite.Name, err)
+			continue
+		}
+		row = append(row, tgbotapi.NewInlineKeyboardButtonData("⭐ "+agent.Name, ddCallbackData(ddDepthQuick, agent.ID)))
+		if len(row) == 2 {
+			rows = append(rows, row)
+			row = nil
+		}
+	}
+	if len(row) > 0 {
+		rows = append(rows, row)
+	}
+	if len(rows) == 0 {
+		return false
+	}
+
+	msg := tgbotapi.NewMessage(update.Message.Chat.ID, "⭐ Which favorite should I dig into? Or send /give_dd <agent> for any other.")
+	msg.ReplyMarkup = tgbotapi.NewInlineKeyboardMarkup(rows...)
+	if _, err := bot.Send(msg); err != nil {
+		trace.Logf(ctx, logger, "Error sending favorites: %v", err)
+	}
+	return true
+}
+
+// resolveFavorite loads a favorite's current record. Record IDs change with
+// price, so a stale ID falls back to the name.
+func resolveFavorite(ctx context.Context, store *storage.AgentStore, favorite storage.FavoriteAgent) (*models.Agent, error) {
+	if agent, err := store.GetAgentContext(ctx, favorite.AgentID); err == nil {
+		return agent, nil
+	}
+	return store.FindAgent(ctx, favorite.Name)
+}
@@ -0,0 +1,96 @@
+package telegram
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+
+	"anondd/utils/storage"
+	"anondd/utils/trace"
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+const feedbackCallbackPrefix = "fb"
+
+// feedbackRow builds thumbs-up/down buttons attributing a vote to a prompt variant
+func feedbackRow(promptKey, variant string) []tgbotapi.InlineKeyboardButton {
+	return tgbotapi.NewInlineKeyboardRow(
+		tgbotapi.NewInlineKeyboardButtonData("👍", feedbackCallbackData(true, promptKey, variant)),
+		tgbotapi.NewInlineKeyboardButtonData("👎", feedbackCallbackData(false, promptKey, variant)),
+	)
+}
+
+// feedbackKeyboard is feedbackRow as a standalone inline keyboard
+func feedbackKeyboard(promptKey, variant string) tgbotapi.InlineKeyboardMarkup {
+	return tgbotapi.NewInlineKeyboardMarkup(feedbackRow(promptKey, variant))
+}
+
+// feedbackCallbackData encodes a vote as "fb:<up|down>:<promptKey>:<variant>"
+func feedbackCallbackData(up bool, promptKey, variant string) string {
+	vote := "down"
+	if up {
+		vote = "up"
+	}
+	return fmt.Sprintf("%s:%s:%s:%s", feedbackCallbackPrefix, vote, promptKey, variant)
+}
+
+// parseFeedbackCallbackData decodes callback data produced by feedbackCallbackData
+func parseFeedbackCallbackData(data string) (up bool, promptKey, variant string, ok bool) {
+	parts := strings.SplitN(data, ":", 4)
+	if len(parts) != 4 || parts[0] != feedbackCallbackPrefix || parts[2] == "" || parts[3] == "" {
+		return false, "", "", false
+	}
+	switch parts[1] {
+	case "up":
+		return true, parts[2], parts[3], true
+	case "down":
+		return false, parts[2], parts[3], true
+	}
+	return false, "", "", false
+}
+
+// handleFeedbackCallback records a thumbs-up/down vote; each user gets one
+// vote per message and can change it
+func handleFeedbackCallback(ctx context.Context, bot *Bot, query *tgbotapi.CallbackQuery, feedback *storage.FeedbackStore, up bool, promptKey, variant string, logger *log.Logger) {
+	answer := "Thanks for the feedback!"
+	if query.Message == nil || feedback == nil {
+		answer = "Feedback isn't available right now."
+	} else {
+		voteID := fmt.Sprintf("%d:%d:%d", query.Message.Chat.ID, query.Message.MessageID, query.From.ID)
+		if err := feedback.Record(voteID, promptKey, variant, up); err != nil {
+			trace.Logf(ctx, logger, "Error recording feedback for %s/%s: %v", promptKey, variant, err)
+			answer = "Couldn't save your feedback."
+		}
+	}
+
+	if _, err := bot.Request(tgbotapi.NewCallback(query.ID, answer)); err != nil {
+		trace.Logf(ctx, logger, "Error answering callback: %v", err)
+	}
+}
+
+// handleFeedbackStats implements /ab_stats, showing vote tallies per prompt variant
+func handleFeedbackStats(bot *Bot, update tgbotapi.Update, feedback *storage.FeedbackStore) {
+	if !requireAdmin(bot, update) {
+		return
+	}
+	chatID := update.Message.Chat.ID
+
+	stats := feedback.Stats()
+	if len(stats) == 0 {
+		bot.Send(tgbotapi.NewMessage(chatID, "No feedback recorded yet."))
+		return
+	}
+
+	var b strings.Builder
+	b.WriteString("🧪 Feedback by prompt variant:\n")
+	for _, s := range stats {
+		total := s.Up + s.Down
+		rate := 0.0
+		if total > 0 {
+			rate = float64(s.Up) / float64(total) * 100
+		}
+		fmt.Fprintf(&b, "\n%s / %s: 👍 %d 👎 %d (%.0f%% positive)", s.PromptKey, s.Variant, s.Up, s.Down, rate)
+	}
+	bot.Send(tgbotapi.NewMessage(chatID, b.String()))
+}
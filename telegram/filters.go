@@ -0,0 +1,75 @@
+package telegram
+
+import (
+	"fmt"
+	"log"
+	"strings"
+
+	"anondd/utils/models"
+	"anondd/utils/storage"
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+const filtersUsage = "Usage: /filters - show this chat's filters\n" +
+	"/filters source virtuals[,other] | any\n" +
+	"/filters category entertainment[,productivity] | any\n" +
+	"/filters mcap 1m | 0\n" +
+	"/filters clear\n\n" +
+	"Filters apply to /fresh, /scrape_agents, new agent announcements and anomaly alerts."
+
+// handleFilters implements /filters, which limits the agents this chat sees
+// by source, category or minimum market cap
+func handleFilters(bot *Bot, update tgbotapi.Update, settings *storage.ChatSettingsStore, args []string, logger *log.Logger) {
+	chatID := update.Message.Chat.ID
+	if settings == nil {
+		bot.Send(tgbotapi.NewMessage(chatID, "❌ Chat settings are unavailable right now."))
+		return
+	}
+	if len(args) == 0 {
+		bot.Send(tgbotapi.NewMessage(chatID, fmt.Sprintf("🔎 This chat shows %s.\n\n%s", settings.Filter(chatID), filtersUsage)))
+		return
+	}
+
+	var change func(*models.AgentFilter)
+	switch strings.ToLower(args[0]) {
+	case "clear", "off", "reset":
+		change = func(f *models.AgentFilter) { *f = models.AgentFilter{} }
+	case "source", "sources":
+		sources := models.ParseFilterList(strings.Join(args[1:], ","))
+		change = func(f *models.AgentFilter) { f.Sources = sources }
+	case "category", "categories":
+		categories := models.ParseFilterList(strings.Join(args[1:], ","))
+		change = func(f *models.AgentFilter) { f.Categories = categories }
+	case "mcap", "marketcap", "min_mcap":
+		if len(args) < 2 {
+			bot.Send(tgbotapi.NewMessage(chatID, filtersUsage))
+			return
+		}
+		minimum, ok := models.ParseAmount(strings.Join(args[1:], " "))
+		if !ok || minimum < 0 {
+			bot.Send(tgbotapi.NewMessage(chatID, "Invalid market cap, use an amount like 500k or $1.5m, or 0 for none."))
+			return
+		}
+		change = func(f *models.AgentFilter) { f.MinMarketCap = minimum }
+	default:
+		bot.Send(tgbotapi.NewMessage(chatID, filtersUsage))
+		return
+	}
+
+	updated, err := settings.Update(chatID, func(s *models.ChatSettings) { change(&s.Filter) })
+	if err != nil {
+		logger.Printf("Error saving filters for chat %d: %v", chatID, err)
+		bot.Send(tgbotapi.NewMessage(chatID, "❌ Unable to save filters right now."))
+		return
+	}
+	bot.Send(tgbotapi.NewMessage(chatID, fmt.Sprintf("✅ This chat now shows %s.", updated.Filter)))
+}
+
+// chatFilter returns a chat's agent filter; without a settings store every
+// agent is allowed
+func chatFilter(settings *storage.ChatSettingsStore, chatID int64) models.AgentFilter {
+	if settings == nil {
+		return models.AgentFilter{}
+	}
+	return settings.Filter(chatID)
+}
@@ -0,0 +1,71 @@
+package telegram
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"anondd/utils/models"
+	"anondd/utils/storage"
+	"anondd/utils/trace"
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+const (
+	// freshWindow is how recently an agent must have launched to count as fresh
+	freshWindow = 7 * 24 * time.Hour
+
+	// maxFreshAgents caps the agents listed by /fresh
+	maxFreshAgents = 20
+)
+
+// handleFresh implements /fresh [age]: agents launched within the last week,
+// or the given age such as 3d or 12h, newest first, that pass the chat's filter
+func handleFresh(ctx context.Context, bot *Bot, update tgbotapi.Update, store *storage.AgentStore, filter models.AgentFilter, args []string, logger *log.Logger) {
+	chatID := update.Message.Chat.ID
+	window := freshWindow
+	if len(args) > 0 {
+		age, err := models.ParseAge(args[0])
+		if err != nil {
+			bot.Send(tgbotapi.NewMessage(chatID, "Usage: /fresh [age], e.g. /fresh 3d or /fresh 12h"))
+			return
+		}
+		window = age
+	}
+
+	now := time.Now()
+	agents, err := store.LaunchedSince(now.Add(-window))
+	if err != nil {
+		trace.Logf(ctx, logger, "Error listing fresh agents: %v", err)
+		bot.Send(tgbotapi.NewMessage(chatID, "Error accessing agent data"))
+		return
+	}
+	if !filter.Empty() {
+		allowed := agents[:0]
+		for _, summary := range agents {
+			if agent, err := store.GetAgentContext(ctx, summary.ID); err == nil && filter.Allows(agent) {
+				allowed = append(allowed, summary)
+			}
+		}
+		agents = allowed
+	}
+	if len(agents) == 0 {
+		bot.Send(tgbotapi.NewMessage(chatID, fmt.Sprintf("🌱 No agents launched in the last %s.", models.FormatAge(window))))
+		return
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "🐣 %d agents launched in the last %s:\n\n", len(agents), models.FormatAge(window))
+	for i, agent := range agents {
+		if i == maxFreshAgents {
+			fmt.Fprintf(&b, "\n…and %d more", len(agents)-maxFreshAgents)
+			break
+		}
+		fmt.Fprintf(&b, "• %s (%s) — %s old\n", agent.Name, agent.Price, models.FormatAge(now.Sub(agent.LaunchDate())))
+	}
+	if _, err := bot.Send(tgbotapi.NewMessage(chatID, b.String())); err != nil {
+		trace.Logf(ctx, logger, "Error sending fresh agents: %v", err)
+	}
+}
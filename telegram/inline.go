@@ -0,0 +1,103 @@
+package telegram
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+
+	"anondd/utils/models"
+	"anondd/utils/storage"
+	"anondd/utils/trace"
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// maxInlineResults is the number of agent cards returned per inline query
+const maxInlineResults = 20
+
+// handleInlineQuery answers "@botname <agent name>" with matching agent cards
+func handleInlineQuery(ctx context.Context, bot *Bot, update tgbotapi.Update, store *storage.AgentStore, logger *log.Logger) {
+	query := update.InlineQuery
+	term := strings.ToLower(strings.TrimSpace(query.Query))
+
+	index, err := store.GetIndexContext(ctx)
+	if err != nil {
+		trace.Logf(ctx, logger, "Error loading index for inline query: %v", err)
+		return
+	}
+
+	var results []interface{}
+	for _, summary := range index.Agents {
+		if term != "" && !strings.Contains(strings.ToLower(summary.Name), term) {
+			continue
+		}
+
+		agent, err := store.GetAgentContext(ctx, summary.ID)
+		if err != nil {
+			agent = &models.Agent{ID: summary.ID, Name: summary.Name, Price: summary.Price}
+		}
+
+		article := tgbotapi.NewInlineQueryResultArticle(agent.ID, agent.Name, agentCard(agent))
+		article.Description = agentStatLine(agent)
+		results = append(results, article)
+
+		if len(results) == maxInlineResults {
+			break
+		}
+	}
+
+	answer := tgbotapi.InlineConfig{
+		InlineQueryID: query.ID,
+		Results:       results,
+		CacheTime:     60,
+	}
+	if _, err := bot.Request(answer); err != nil {
+		trace.Logf(ctx, logger, "Error answering inline query: %v", err)
+	}
+}
+
+// agentStatLine is the compact one-line summary shown under an inline result
+func agentStatLine(agent *models.Agent) string {
+	parts := []string{}
+	if agent.Price != "" {
+		parts = append(parts, agent.Price)
+	}
+	if agent.TokenData.MCFDV != "" {
+		parts = append(parts, "MC "+agent.TokenData.MCFDV)
+	}
+	if agent.TokenData.Holders != "" {
+		parts = append(parts, agent.TokenData.Holders+" holders")
+	}
+	if agent.InfluenceMetrics.Mindshare != "" {
+		parts = append(parts, "Mindshare "+agent.InfluenceMetrics.Mindshare)
+	}
+	if len(parts) == 0 {
+		return "No stats yet"
+	}
+	return strings.Join(parts, " · ")
+}
+
+// agentCard is the message shared into a chat when an inline result is picked
+func agentCard(agent *models.Agent) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "🤖 %s\n", agent.Name)
+	if agent.Price != "" {
+		fmt.Fprintf(&b, "💰 Price: %s\n", agent.Price)
+	}
+	if agent.TokenData.MCFDV != "" {
+		fmt.Fprintf(&b, "📈 MC (FDV): %s\n", agent.TokenData.MCFDV)
+	}
+	if agent.TokenData.Change24h != "" {
+		fmt.Fprintf(&b, "⏱ 24h: %s\n", agent.TokenData.Change24h)
+	}
+	if agent.TokenData.Holders != "" {
+		fmt.Fprintf(&b, "👥 Holders: %s\n", agent.TokenData.Holders)
+	}
+	if agent.InfluenceMetrics.Mindshare != "" {
+		fmt.Fprintf(&b, "🧠 Mindshare: %s\n", agent.InfluenceMetrics.Mindshare)
+	}
+	if agent.Status != "" {
+		fmt.Fprintf(&b, "📌 Status: %s\n", agent.Status)
+	}
+	return strings.TrimSpace(b.String())
+}
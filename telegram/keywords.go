@@ -0,0 +1,171 @@
+package telegram
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"anondd/utils/models"
+	"anondd/utils/shared"
+	"anondd/utils/storage"
+	"anondd/utils/trace"
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+const keywordsUsage = "Usage: /keywords add <keyword> [agent] | /keywords remove <keyword> | /keywords cooldown <keyword> <duration, e.g. 30m> | /keywords"
+
+// minKeywordCooldown keeps a busy group from turning a keyword into a flood
+const minKeywordCooldown = time.Minute
+
+// isGroupChat reports whether the message came from a group or supergroup
+func isGroupChat(message *tgbotapi.Message) bool {
+	return message.Chat.IsGroup() || message.Chat.IsSuperGroup()
+}
+
+// handleKeywords implements /keywords, the group's passive keyword triggers.
+// Any member can manage them, like /teamwatch.
+func handleKeywords(ctx context.Context, bot *Bot, update tgbotapi.Update, botName string, store *storage.AgentStore, keywords *storage.KeywordStore, args []string, logger *log.Logger) {
+	chatID := update.Message.Chat.ID
+	if !isGroupChat(update.Message) {
+		bot.Send(tgbotapi.NewMessage(chatID, "ℹ️ Keyword monitoring works in group chats. Add me to a group and set it up there."))
+		return
+	}
+	if len(args) == 0 {
+		bot.Send(tgbotapi.NewMessage(chatID, formatKeywords(keywords.List(botName, chatID))))
+		return
+	}
+	if len(args) < 2 {
+		bot.Send(tgbotapi.NewMessage(chatID, keywordsUsage))
+		return
+	}
+	keyword := storage.NormalizeKeyword(args[1])
+
+	var reply string
+	switch strings.ToLower(args[0]) {
+	case "add":
+		// The agent defaults to the keyword itself, minus any ticker $
+		query := strings.Join(args[2:], " ")
+		if query == "" {
+			query = strings.TrimPrefix(keyword, "$")
+		}
+		agent, err := store.FindAgent(ctx, query)
+		if err != nil {
+			reply = fmt.Sprintf("❌ No agent found matching '%s'", query)
+			break
+		}
+		addedBy := ""
+		if update.Message.From != nil {
+			addedBy = update.Message.From.UserName
+		}
+		added, err := keywords.Add(botName, chatID, keyword, agent, storage.DefaultKeywordCooldown, addedBy)
+		switch {
+		case err != nil:
+			trace.Logf(ctx, logger, "Error adding keyword %s in chat %d: %v", keyword, chatID, err)
+			reply = "❌ Unable to update keywords right now."
+		case added:
+			reply = fmt.Sprintf("🔔 Mentions of '%s' will now get %s's stat card (at most every %s).", keyword, agent.Name, storage.DefaultKeywordCooldown)
+		default:
+			reply = fmt.Sprintf("🔔 '%s' now points to %s.", keyword, agent.Name)
+		}
+	case "remove":
+		trigger, err := keywords.Remove(botName, chatID, keyword)
+		switch {
+		case errors.Is(err, storage.ErrNotFound):
+			reply = fmt.Sprintf("ℹ️ '%s' isn't a keyword here.", keyword)
+		case err != nil:
+			trace.Logf(ctx, logger, "Error removing keyword %s in chat %d: %v", keyword, chatID, err)
+			reply = "❌ Unable to update keywords right now."
+		default:
+			reply = fmt.Sprintf("🔕 Stopped watching for '%s'.", trigger.Keyword)
+		}
+	case "cooldown":
+		if len(args) < 3 {
+			reply = keywordsUsage
+			break
+		}
+		cooldown, err := time.ParseDuration(args[2])
+		if err != nil || cooldown < minKeywordCooldown {
+			reply = fmt.Sprintf("❌ Cooldown must be a duration of at least %s, e.g. 30m or 2h.", minKeywordCooldown)
+			break
+		}
+		err = keywords.SetCooldown(botName, chatID, keyword, cooldown)
+		switch {
+		case errors.Is(err, storage.ErrNotFound):
+			reply = fmt.Sprintf("ℹ️ '%s' isn't a keyword here.", keyword)
+		case err != nil:
+			trace.Logf(ctx, logger, "Error setting cooldown for keyword %s in chat %d: %v", keyword, chatID, err)
+			reply = "❌ Unable to update keywords right now."
+		default:
+			reply = fmt.Sprintf("⏱ '%s' now triggers at most every %s.", keyword, cooldown)
+		}
+	default:
+		reply = keywordsUsage
+	}
+
+	bot.Send(tgbotapi.NewMessage(chatID, reply))
+}
+
+func formatKeywords(triggers []storage.KeywordTrigger) string {
+	if len(triggers) == 0 {
+		return "🔔 No keywords set for this chat.\n\n" + keywordsUsage
+	}
+	var b strings.Builder
+	fmt.Fprintf(&b, "🔔 Keywords (%d):\n", len(triggers))
+	for _, trigger := range triggers {
+		fmt.Fprintf(&b, "\n• %s → %s, every %s", trigger.Keyword, trigger.Name, trigger.Cooldown)
+		if trigger.AddedBy != "" {
+			fmt.Fprintf(&b, " (added by @%s)", trigger.AddedBy)
+		}
+	}
+	return b.String()
+}
+
+// handleKeywordMention posts stat cards for the chat's keywords mentioned in
+// a group message, each at most once per its cooldown. The cooldowns live in
+// the shared store so they hold across instances. It returns true if a card
+// was sent, in which case the message needs no other reply.
+func handleKeywordMention(ctx context.Context, bot *Bot, update tgbotapi.Update, botName string, store *storage.AgentStore, keywords *storage.KeywordStore, state shared.Store, logger *log.Logger) bool {
+	message := update.Message
+	if keywords == nil || state == nil || !isGroupChat(message) {
+		return false
+	}
+
+	sent := false
+	for _, trigger := range keywords.Match(botName, message.Chat.ID, message.Text) {
+		key := fmt.Sprintf("anondd:keyword:%s:%d:%s", botName, message.Chat.ID, trigger.Keyword)
+		count, err := state.Incr(ctx, key, trigger.Cooldown)
+		if err != nil {
+			trace.Logf(ctx, logger, "Error checking keyword cooldown: %v", err)
+			continue
+		}
+		if count > 1 {
+			continue
+		}
+
+		agent, err := keywordAgent(ctx, store, trigger)
+		if err != nil {
+			trace.Logf(ctx, logger, "Error loading agent %s for keyword %s: %v", trigger.Name, trigger.Keyword, err)
+			continue
+		}
+		card := tgbotapi.NewMessage(message.Chat.ID, agentCard(agent))
+		card.ReplyToMessageID = message.MessageID
+		if _, err := bot.Send(card); err != nil {
+			trace.Logf(ctx, logger, "Error sending keyword card: %v", err)
+			continue
+		}
+		sent = true
+	}
+	return sent
+}
+
+// keywordAgent loads a trigger's agent, falling back to its name since
+// record IDs change with price
+func keywordAgent(ctx context.Context, store *storage.AgentStore, trigger storage.KeywordTrigger) (*models.Agent, error) {
+	if agent, err := store.GetAgentContext(ctx, trigger.AgentID); err == nil {
+		return agent, nil
+	}
+	return store.FindAgent(ctx, trigger.Name)
+}
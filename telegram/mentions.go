@@ -0,0 +1,171 @@
+package telegram
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"anondd/utils/models"
+	"anondd/utils/storage"
+	"anondd/utils/trace"
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// Mention buttons under LLM replies
+const (
+	mentionCallbackPrefix = "mention"
+	maxMentionButtons     = 4
+	mentionButtonsPerRow  = 2
+	// minMentionLength skips names so short they match ordinary words
+	minMentionLength = 3
+)
+
+// mentionCallbackData encodes a tap on a mention as "mention:<agentID>"
+func mentionCallbackData(agentID string) string {
+	return mentionCallbackPrefix + ":" + agentID
+}
+
+// parseMentionCallbackData decodes callback data produced by mentionCallbackData
+func parseMentionCallbackData(data string) (agentID string, ok bool) {
+	agentID, ok = strings.CutPrefix(data, mentionCallbackPrefix+":")
+	return agentID, ok && agentID != ""
+}
+
+// findMentions returns the indexed agents named in text, in the order they
+// first appear. Names must match as whole words; when names overlap, such as
+// "Luna" inside "Luna AI", the longer one wins. exclude skips the agent the
+// text is about.
+func findMentions(index *models.AgentIndex, text, exclude string) []models.AgentSummary {
+	lower := strings.ToLower(text)
+	candidates := make([]models.AgentSummary, 0)
+	seen := make(map[string]bool)
+	for _, summary := range index.Agents {
+		name := strings.ToLower(strings.TrimSpace(summary.Name))
+		if summary.ID == exclude || utf8.RuneCountInString(name) < minMentionLength || seen[name] {
+			continue
+		}
+		seen[name] = true
+		if strings.Contains(lower, name) {
+			candidates = append(candidates, summary)
+		}
+	}
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return len(candidates[i].Name) > len(candidates[j].Name)
+	})
+
+	type mention struct {
+		summary models.AgentSummary
+		at      int
+	}
+	var mentions []mention
+	claimed := make([]bool, len(lower))
+	for _, summary := range candidates {
+		name := strings.ToLower(strings.TrimSpace(summary.Name))
+		for from := 0; from < len(lower); {
+			i := strings.Index(lower[from:], name)
+			if i < 0 {
+				break
+			}
+			start, end := from+i, from+i+len(name)
+			from = end
+			if !wordBoundary(lower, start, end) || claimed[start] || claimed[end-1] {
+				continue
+			}
+			for k := start; k < end; k++ {
+				claimed[k] = true
+			}
+			mentions = append(mentions, mention{summary: summary, at: start})
+			break
+		}
+	}
+	sort.Slice(mentions, func(i, j int) bool { return mentions[i].at < mentions[j].at })
+
+	found := make([]models.AgentSummary, len(mentions))
+	for i, m := range mentions {
+		found[i] = m.summary
+	}
+	return found
+}
+
+// wordBoundary reports whether text[start:end] isn't part of a longer word
+func wordBoundary(text string, start, end int) bool {
+	if start > 0 {
+		if r, _ := utf8.DecodeLastRuneInString(text[:start]); isWordRune(r) {
+			return false
+		}
+	}
+	if end < len(text) {
+		if r, _ := utf8.DecodeRuneInString(text[end:]); isWordRune(r) {
+			return false
+		}
+	}
+	return true
+}
+
+func isWordRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_'
+}
+
+// mentionRows returns keyboard rows with a DD shortcut for each tracked
+// agent text mentions, or nil when it mentions none
+func mentionRows(ctx context.Context, store *storage.AgentStore, text, exclude string, logger *log.Logger) [][]tgbotapi.InlineKeyboardButton {
+	index, err := store.GetIndexContext(ctx)
+	if err != nil {
+		trace.Logf(ctx, logger, "Error loading index for mentions: %v", err)
+		return nil
+	}
+	mentions := findMentions(index, text, exclude)
+	if len(mentions) > maxMentionButtons {
+		mentions = mentions[:maxMentionButtons]
+	}
+
+	var rows [][]tgbotapi.InlineKeyboardButton
+	for i, summary := range mentions {
+		if i%mentionButtonsPerRow == 0 {
+			rows = append(rows, []tgbotapi.InlineKeyboardButton{})
+		}
+		button := tgbotapi.NewInlineKeyboardButtonData("🔎 DD "+summary.Name, mentionCallbackData(summary.ID))
+		rows[len(rows)-1] = append(rows[len(rows)-1], button)
+	}
+	return rows
+}
+
+// withMentions adds the mention rows for text to keyboard, returning a
+// keyboard to attach, or nil when there is nothing to attach
+func withMentions(ctx context.Context, store *storage.AgentStore, keyboard *tgbotapi.InlineKeyboardMarkup, text, exclude string, logger *log.Logger) interface{} {
+	rows := mentionRows(ctx, store, text, exclude, logger)
+	if keyboard == nil {
+		if len(rows) == 0 {
+			return nil
+		}
+		return tgbotapi.NewInlineKeyboardMarkup(rows...)
+	}
+	keyboard.InlineKeyboard = append(rows, keyboard.InlineKeyboard...)
+	return *keyboard
+}
+
+// handleMentionCallback offers DD on a mentioned agent in a new message,
+// leaving the reply that mentioned it as it was
+func handleMentionCallback(ctx context.Context, bot *Bot, query *tgbotapi.CallbackQuery, store *storage.AgentStore, agentID string, logger *log.Logger) {
+	agent, err := store.GetAgentContext(ctx, agentID)
+	if err != nil || query.Message == nil {
+		trace.Logf(ctx, logger, "Error loading mentioned agent %s: %v", agentID, err)
+		if _, err := bot.Request(tgbotapi.NewCallback(query.ID, "Agent data is no longer available.")); err != nil {
+			trace.Logf(ctx, logger, "Error answering callback: %v", err)
+		}
+		return
+	}
+	if _, err := bot.Request(tgbotapi.NewCallback(query.ID, "")); err != nil {
+		trace.Logf(ctx, logger, "Error answering callback: %v", err)
+	}
+
+	msg := tgbotapi.NewMessage(query.Message.Chat.ID, fmt.Sprintf("🤖 %s found. How deep should I dig?", agent.Name))
+	msg.ReplyMarkup = ddDepthKeyboard(agent.ID)
+	if _, err := bot.Send(msg); err != nil {
+		trace.Logf(ctx, logger, "Error sending DD depth selection: %v", err)
+	}
+}
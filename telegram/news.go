@@ -0,0 +1,66 @@
+package telegram
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+
+	"anondd/utils/models"
+	"anondd/utils/storage"
+	"anondd/utils/trace"
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// News shown by /news and included in deeper DD reports
+const (
+	newsCommandLimit = 5
+	ddNewsLimit      = 3
+)
+
+// handleNews implements /news <agent>, listing recent articles that mention it
+func handleNews(ctx context.Context, bot *Bot, update tgbotapi.Update, store *storage.AgentStore, args []string, logger *log.Logger) {
+	chatID := update.Message.Chat.ID
+	if len(args) == 0 {
+		bot.Send(tgbotapi.NewMessage(chatID, "Usage: /news <agent name or id>"))
+		return
+	}
+
+	query := strings.Join(args, " ")
+	agent, err := store.FindAgent(ctx, query)
+	if err != nil {
+		bot.Send(tgbotapi.NewMessage(chatID, fmt.Sprintf("❌ No agent found matching '%s'", query)))
+		return
+	}
+
+	articles, err := store.GetAgentNews(agent.ID, newsCommandLimit)
+	if err != nil {
+		trace.Logf(ctx, logger, "Error loading news for %s: %v", agent.Name, err)
+		bot.Send(tgbotapi.NewMessage(chatID, "Error accessing news data"))
+		return
+	}
+	if len(articles) == 0 {
+		bot.Send(tgbotapi.NewMessage(chatID, fmt.Sprintf("📰 No recent news mentions %s.", agent.Name)))
+		return
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "📰 Recent news for %s:\n", agent.Name)
+	for _, article := range articles {
+		fmt.Fprintf(&b, "\n• %s (%s, %s)\n%s\n", article.Title, article.Source,
+			article.PublishedAt.Format("Jan 2"), article.URL)
+	}
+	msg := tgbotapi.NewMessage(chatID, b.String())
+	msg.DisableWebPagePreview = true
+	if _, err := bot.Send(msg); err != nil {
+		trace.Logf(ctx, logger, "Error sending news: %v", err)
+	}
+}
+
+// writeNews appends recent headlines to a DD data slice
+func writeNews(b *strings.Builder, articles []models.NewsArticle) {
+	b.WriteString("Recent News:\n")
+	for _, article := range articles {
+		fmt.Fprintf(b, "- %s: %s (%s)\n", article.PublishedAt.Format("2006-01-02"), article.Title, article.Source)
+	}
+}
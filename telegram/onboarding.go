@@ -0,0 +1,247 @@
+package telegram
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"time"
+
+	"anondd/llm"
+	"anondd/utils"
+	"anondd/utils/storage"
+	"anondd/utils/trace"
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+const (
+	onboardingCallbackPrefix = "ob"
+
+	onboardingLanguage = "lang"
+	onboardingPersona  = "persona"
+	onboardingAlerts   = "alerts"
+
+	// defaultPersonaChoice keeps the bot's own persona
+	defaultPersonaChoice = "default"
+)
+
+// onboardingLanguageOption is a language offered during onboarding
+type onboardingLanguageOption struct {
+	Code  string
+	Label string
+	Name  string // English name, used to instruct the LLM
+}
+
+var onboardingLanguages = []onboardingLanguageOption{
+	{Code: "en", Label: "🇬🇧 English", Name: "English"},
+	{Code: "es", Label: "🇪🇸 Español", Name: "Spanish"},
+	{Code: "pt", Label: "🇧🇷 Português", Name: "Portuguese"},
+	{Code: "ru", Label: "🇷🇺 Русский", Name: "Russian"},
+	{Code: "zh", Label: "🇨🇳 中文", Name: "Chinese"},
+}
+
+const onboardingExamples = `✅ You're all set! A few things to try:
+
+/give_dd <agent> - due diligence report
+/fav add <agent> - pin agents for quick /give_dd
+/ask <agent> <question> - ask about an agent
+/search <words> - search descriptions and reports
+/chart <agent> [metric] [range] - price and metric charts
+/fresh - agents launched this week
+/upcoming - agents about to graduate
+/whatsnew - what changed since you last looked
+/predict <agent> - speculative trend outlook
+/teamwatch add <agent> - watch agents together
+/alerts on|off - anomaly and graduation alerts
+/apikey - personal key for the REST API
+/filters - only see some sources, categories or market caps
+/persona choose <preset> - change my voice
+/style concise|detailed - shorter or more explanatory replies
+
+Run /start again any time to change these settings.`
+
+// handleStart implements /start: a short inline keyboard walk through language,
+// persona and alert choices, saved to the user's profile. Onboarding runs in
+// private chats only, since the choices also apply to the chat.
+func handleStart(ctx context.Context, bot *Bot, update tgbotapi.Update, profiles *storage.ProfileStore, logger *log.Logger) {
+	message := update.Message
+	if message.From == nil {
+		return
+	}
+	if !message.Chat.IsPrivate() {
+		bot.Send(tgbotapi.NewMessage(message.Chat.ID, fmt.Sprintf("👋 Message me directly with /start to set up, @%s.", bot.Self.UserName)))
+		return
+	}
+
+	if _, err := profiles.Update(message.From.ID, func(p *storage.UserProfile) {
+		p.Username = message.From.UserName
+	}); err != nil {
+		trace.Logf(ctx, logger, "Error saving profile for user %d: %v", message.From.ID, err)
+	}
+
+	msg := tgbotapi.NewMessage(message.Chat.ID, "👋 Welcome to anon dd agent! Let's get you set up.\n\nStep 1/3: which language should I reply in?")
+	msg.ReplyMarkup = languageKeyboard()
+	if _, err := bot.Send(msg); err != nil {
+		trace.Logf(ctx, logger, "Error sending onboarding: %v", err)
+	}
+}
+
+func languageKeyboard() tgbotapi.InlineKeyboardMarkup {
+	var row []tgbotapi.InlineKeyboardButton
+	for _, language := range onboardingLanguages {
+		row = append(row, tgbotapi.NewInlineKeyboardButtonData(language.Label, onboardingCallbackData(onboardingLanguage, language.Code)))
+	}
+	return tgbotapi.NewInlineKeyboardMarkup(row)
+}
+
+func personaKeyboard() tgbotapi.InlineKeyboardMarkup {
+	names := make([]string, 0, len(llm.PersonaPresets))
+	for name := range llm.PersonaPresets {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	row := []tgbotapi.InlineKeyboardButton{tgbotapi.NewInlineKeyboardButtonData("Default", onboardingCallbackData(onboardingPersona, defaultPersonaChoice))}
+	for _, name := range names {
+		row = append(row, tgbotapi.NewInlineKeyboardButtonData(strings.ToUpper(name[:1])+name[1:], onboardingCallbackData(onboardingPersona, name)))
+	}
+	return tgbotapi.NewInlineKeyboardMarkup(row)
+}
+
+func alertsKeyboard() tgbotapi.InlineKeyboardMarkup {
+	return tgbotapi.NewInlineKeyboardMarkup(tgbotapi.NewInlineKeyboardRow(
+		tgbotapi.NewInlineKeyboardButtonData("🚨 Yes, alert me", onboardingCallbackData(onboardingAlerts, "on")),
+		tgbotapi.NewInlineKeyboardButtonData("🔕 No thanks", onboardingCallbackData(onboardingAlerts, "off")),
+	))
+}
+
+// onboardingCallbackData encodes a choice as "ob:<step>:<value>"
+func onboardingCallbackData(step, value string) string {
+	return fmt.Sprintf("%s:%s:%s", onboardingCallbackPrefix, step, value)
+}
+
+// parseOnboardingCallbackData decodes callback data produced by onboardingCallbackData
+func parseOnboardingCallbackData(data string) (step, value string, ok bool) {
+	parts := strings.SplitN(data, ":", 3)
+	if len(parts) != 3 || parts[0] != onboardingCallbackPrefix || parts[2] == "" {
+		return "", "", false
+	}
+	return parts[1], parts[2], true
+}
+
+// handleOnboardingCallback saves an onboarding choice and moves the message on
+// to the next step. It returns false for callbacks that aren't onboarding's.
+func handleOnboardingCallback(ctx context.Context, bot *Bot, query *tgbotapi.CallbackQuery, botName string, utilsManager *utils.UtilsManager, logger *log.Logger) bool {
+	step, value, ok := parseOnboardingCallbackData(query.Data)
+	if !ok {
+		return false
+	}
+	if query.Message == nil {
+		bot.Request(tgbotapi.NewCallback(query.ID, "This setup has expired, send /start again."))
+		return true
+	}
+	chatID, userID := query.Message.Chat.ID, query.From.ID
+
+	var text string
+	var keyboard *tgbotapi.InlineKeyboardMarkup
+	var change func(*storage.UserProfile)
+	switch step {
+	case onboardingLanguage:
+		if languageName(value) == "" {
+			return answerUnknownOnboarding(ctx, bot, query, logger)
+		}
+		change = func(p *storage.UserProfile) { p.Language = value }
+		text = "Step 2/3: pick a persona for my replies."
+		markup := personaKeyboard()
+		keyboard = &markup
+	case onboardingPersona:
+		preset, exists := llm.PersonaPresets[value]
+		if !exists && value != defaultPersonaChoice {
+			return answerUnknownOnboarding(ctx, bot, query, logger)
+		}
+		var err error
+		if exists {
+			err = utilsManager.GetPersonaStore().Set(chatID, preset)
+		} else {
+			err = utilsManager.GetPersonaStore().Reset(chatID)
+		}
+		if err != nil {
+			trace.Logf(ctx, logger, "Error saving persona for chat %d: %v", chatID, err)
+		}
+		change = func(p *storage.UserProfile) { p.Persona = value }
+		text = "Step 3/3: should I alert you about holder and volume anomalies?"
+		markup := alertsKeyboard()
+		keyboard = &markup
+	case onboardingAlerts:
+		enabled := value == "on"
+		var err error
+		if enabled {
+			_, err = utilsManager.GetAlertSubscribers().Subscribe(botName, chatID)
+		} else {
+			_, err = utilsManager.GetAlertSubscribers().Unsubscribe(botName, chatID)
+		}
+		if err != nil {
+			trace.Logf(ctx, logger, "Error saving alert choice for chat %d: %v", chatID, err)
+		}
+		change = func(p *storage.UserProfile) {
+			p.Alerts = enabled
+			if !p.Onboarded {
+				p.OnboardedAt = time.Now()
+			}
+			p.Onboarded = true
+		}
+		text = onboardingExamples
+	default:
+		return answerUnknownOnboarding(ctx, bot, query, logger)
+	}
+
+	if _, err := utilsManager.GetProfiles().Update(userID, change); err != nil {
+		trace.Logf(ctx, logger, "Error saving profile for user %d: %v", userID, err)
+	}
+	if _, err := bot.Request(tgbotapi.NewCallback(query.ID, "Saved")); err != nil {
+		trace.Logf(ctx, logger, "Error answering callback: %v", err)
+	}
+
+	edit := tgbotapi.NewEditMessageText(chatID, query.Message.MessageID, text)
+	edit.ReplyMarkup = keyboard
+	if _, err := bot.Send(edit); err != nil {
+		trace.Logf(ctx, logger, "Error editing onboarding message: %v", err)
+	}
+	return true
+}
+
+func answerUnknownOnboarding(ctx context.Context, bot *Bot, query *tgbotapi.CallbackQuery, logger *log.Logger) bool {
+	trace.Logf(ctx, logger, "Unknown onboarding choice: %s", query.Data)
+	if _, err := bot.Request(tgbotapi.NewCallback(query.ID, "Unknown choice")); err != nil {
+		trace.Logf(ctx, logger, "Error answering callback: %v", err)
+	}
+	return true
+}
+
+// languageName returns a language code's English name, or "" if it isn't offered
+func languageName(code string) string {
+	for _, language := range onboardingLanguages {
+		if language.Code == code {
+			return language.Name
+		}
+	}
+	return ""
+}
+
+// withLanguage asks for replies in the user's chosen language on top of the
+// chat's persona. English needs no instruction.
+func withLanguage(persona string, profiles *storage.ProfileStore, from *tgbotapi.User) string {
+	if from == nil {
+		return persona
+	}
+	profile, exists := profiles.Get(from.ID)
+	if !exists || profile.Language == "" || profile.Language == "en" {
+		return persona
+	}
+	name := languageName(profile.Language)
+	if name == "" {
+		return persona
+	}
+	return strings.TrimSpace(fmt.Sprintf("%s Always reply in %s.", persona, name))
+}
@@ -0,0 +1,169 @@
+package telegram
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+
+	"anondd/utils/parsedigest"
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+const selectorCallbackPrefix = "selector"
+
+// selectorCallbackData encodes adopting the n-th suggestion of the latest
+// digest as "selector:<n>"; selectors themselves can outgrow callback data
+func selectorCallbackData(n int) string {
+	return fmt.Sprintf("%s:%d", selectorCallbackPrefix, n)
+}
+
+// parseSelectorCallbackData decodes callback data produced by selectorCallbackData
+func parseSelectorCallbackData(data string) (int, bool) {
+	rest, found := strings.CutPrefix(data, selectorCallbackPrefix+":")
+	if !found {
+		return 0, false
+	}
+	n, err := strconv.Atoi(rest)
+	return n, err == nil && n >= 0
+}
+
+// parseDigestReport renders a digest for admins, numbering its suggestions
+// with a button to adopt each one not adopted yet
+func parseDigestReport(digest *parsedigest.Digest) (string, *tgbotapi.InlineKeyboardMarkup) {
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("🧩 Parse digest (%s), %d stored pages scanned\n", digest.GeneratedAt.Format("2006-01-02 15:04"), digest.Pages))
+	if len(digest.Fields) == 0 {
+		sb.WriteString("\nNo field is failing often enough to need new selectors.")
+		return sb.String(), nil
+	}
+
+	var rows [][]tgbotapi.InlineKeyboardButton
+	n := 0
+	for _, field := range digest.Fields {
+		sb.WriteString(fmt.Sprintf("\n%s: missing on %d pages (%.0f%%), e.g. %s\n", field.Field, field.Missed, field.Share*100, joinInts(field.Samples)))
+		if len(field.Suggestions) == 0 {
+			sb.WriteString("No working selector suggested.\n")
+		}
+		for _, suggestion := range field.Suggestions {
+			n++
+			status := ""
+			if suggestion.Adopted {
+				status = " ✅ adopted"
+			}
+			sb.WriteString(fmt.Sprintf("%d. %s finds it on %d/%d%s\n", n, suggestion.Selector, suggestion.Fixes, suggestion.Tested, status))
+			if suggestion.Example != "" {
+				sb.WriteString(fmt.Sprintf("   → %s\n", suggestion.Example))
+			}
+			if !suggestion.Adopted {
+				rows = append(rows, tgbotapi.NewInlineKeyboardRow(
+					tgbotapi.NewInlineKeyboardButtonData(fmt.Sprintf("Adopt %d (%s)", n, field.Field), selectorCallbackData(n-1)),
+				))
+			}
+		}
+	}
+	if len(rows) == 0 {
+		return sb.String(), nil
+	}
+	keyboard := tgbotapi.NewInlineKeyboardMarkup(rows...)
+	return sb.String(), &keyboard
+}
+
+func joinInts(values []int) string {
+	parts := make([]string, len(values))
+	for i, value := range values {
+		parts[i] = strconv.Itoa(value)
+	}
+	return strings.Join(parts, ", ")
+}
+
+// parseDigestMessage builds the report message for chatID
+func parseDigestMessage(chatID int64, digest *parsedigest.Digest) tgbotapi.MessageConfig {
+	text, keyboard := parseDigestReport(digest)
+	msg := tgbotapi.NewMessage(chatID, text)
+	if keyboard != nil {
+		msg.ReplyMarkup = *keyboard
+	}
+	return msg
+}
+
+// handleParseDigest implements /parse_digest [run], showing the latest parse
+// failure digest or running a fresh one
+func handleParseDigest(ctx context.Context, bot *Bot, update tgbotapi.Update, digester *parsedigest.Digester, args []string, logger *log.Logger) {
+	if !requireAdmin(bot, update) {
+		return
+	}
+	chatID := update.Message.Chat.ID
+	if digester == nil {
+		bot.Send(tgbotapi.NewMessage(chatID, "ℹ️ Parse digests are disabled."))
+		return
+	}
+
+	var digest *parsedigest.Digest
+	var err error
+	if len(args) > 0 && args[0] == "run" {
+		bot.Send(tgbotapi.NewMessage(chatID, "⏳ Scanning stored pages and asking for selectors..."))
+		digest, err = digester.Run(ctx)
+	} else {
+		digest, err = digester.Latest()
+	}
+	if err != nil {
+		logger.Printf("Error getting parse digest: %v", err)
+		bot.Send(tgbotapi.NewMessage(chatID, "❌ Unable to build the parse digest."))
+		return
+	}
+	if digest == nil {
+		bot.Send(tgbotapi.NewMessage(chatID, "ℹ️ No parse digest yet. Run /parse_digest run to build one."))
+		return
+	}
+	bot.Send(parseDigestMessage(chatID, digest))
+}
+
+// handleSelectorCallback adopts a suggested selector from a digest report and
+// refreshes the report. It returns false for callbacks that aren't adoptions.
+func handleSelectorCallback(ctx context.Context, bot *Bot, query *tgbotapi.CallbackQuery, digester *parsedigest.Digester, logger *log.Logger) bool {
+	n, ok := parseSelectorCallbackData(query.Data)
+	if !ok {
+		return false
+	}
+	if !isAdmin(query.From.ID) {
+		bot.Request(tgbotapi.NewCallbackWithAlert(query.ID, "⛔ Only admins can adopt selectors."))
+		return true
+	}
+	if digester == nil {
+		bot.Request(tgbotapi.NewCallback(query.ID, "Parse digests are disabled."))
+		return true
+	}
+
+	field, selector, err := digester.Adopt(n)
+	if err != nil {
+		logger.Printf("Error adopting selector %d: %v", n, err)
+		bot.Request(tgbotapi.NewCallbackWithAlert(query.ID, "❌ "+err.Error()))
+		return true
+	}
+	bot.Request(tgbotapi.NewCallback(query.ID, fmt.Sprintf("✅ Adopted %s for %s", selector, field)))
+
+	if query.Message == nil {
+		return true
+	}
+	digest, err := digester.Latest()
+	if err != nil || digest == nil {
+		return true
+	}
+	text, keyboard := parseDigestReport(digest)
+	edit := tgbotapi.NewEditMessageText(query.Message.Chat.ID, query.Message.MessageID, text)
+	edit.ReplyMarkup = keyboard
+	if _, err := bot.Request(edit); err != nil {
+		logger.Printf("Error updating parse digest: %v", err)
+	}
+	return true
+}
+
+// parseDigestAlert returns a hook sending new parse digests to an admin chat
+func parseDigestAlert(bot *Bot, chatID int64, logger *log.Logger) func(*parsedigest.Digest) {
+	return func(digest *parsedigest.Digest) {
+		bot.Post(parseDigestMessage(chatID, digest))
+		logger.Printf("Queued parse digest for chat %d", chatID)
+	}
+}
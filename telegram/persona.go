@@ -0,0 +1,96 @@
+package telegram
+
+import (
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+
+	"anondd/llm"
+	"anondd/utils/storage"
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// maxPersonaLength caps custom persona descriptions to keep prompts small
+const maxPersonaLength = 500
+
+const personaUsage = "Usage:\n/persona set <description> - define a custom persona\n/persona choose <preset> - pick a preset\n/persona reset - go back to the default\n\nPresets: %s"
+
+// handlePersona implements /persona set|choose|reset for the current chat
+func handlePersona(bot *Bot, update tgbotapi.Update, personas *storage.PersonaStore, args []string, logger *log.Logger) {
+	chatID := update.Message.Chat.ID
+
+	if len(args) == 0 {
+		current, exists := personas.Get(chatID)
+		if !exists {
+			current = "default"
+		}
+		text := fmt.Sprintf("🎭 Current persona: %s\n\n%s", current, fmt.Sprintf(personaUsage, presetNames()))
+		bot.Send(tgbotapi.NewMessage(chatID, text))
+		return
+	}
+
+	var reply string
+	switch strings.ToLower(args[0]) {
+	case "set":
+		description := strings.TrimSpace(strings.Join(args[1:], " "))
+		if description == "" {
+			reply = fmt.Sprintf(personaUsage, presetNames())
+			break
+		}
+		if len(description) > maxPersonaLength {
+			reply = fmt.Sprintf("❌ Persona is too long, keep it under %d characters.", maxPersonaLength)
+			break
+		}
+		if err := personas.Set(chatID, description); err != nil {
+			logger.Printf("Error saving persona for chat %d: %v", chatID, err)
+			reply = "❌ Unable to save persona right now."
+			break
+		}
+		reply = "🎭 Custom persona saved for this chat."
+	case "choose":
+		if len(args) < 2 {
+			reply = fmt.Sprintf(personaUsage, presetNames())
+			break
+		}
+		preset, exists := llm.PersonaPresets[strings.ToLower(args[1])]
+		if !exists {
+			reply = fmt.Sprintf("❌ Unknown preset '%s'. Presets: %s", args[1], presetNames())
+			break
+		}
+		if err := personas.Set(chatID, preset); err != nil {
+			logger.Printf("Error saving persona for chat %d: %v", chatID, err)
+			reply = "❌ Unable to save persona right now."
+			break
+		}
+		reply = fmt.Sprintf("🎭 Persona switched to %s.", strings.ToLower(args[1]))
+	case "reset":
+		if err := personas.Reset(chatID); err != nil {
+			logger.Printf("Error resetting persona for chat %d: %v", chatID, err)
+			reply = "❌ Unable to reset persona right now."
+			break
+		}
+		reply = "🎭 Persona reset to default."
+	default:
+		reply = fmt.Sprintf(personaUsage, presetNames())
+	}
+
+	bot.Send(tgbotapi.NewMessage(chatID, reply))
+}
+
+// chatPersona returns the chat's own persona, falling back to the bot's default persona
+func chatPersona(personas *storage.PersonaStore, config BotConfig, chatID int64) string {
+	if persona, ok := personas.Get(chatID); ok {
+		return persona
+	}
+	return config.DefaultPersona
+}
+
+func presetNames() string {
+	names := make([]string, 0, len(llm.PersonaPresets))
+	for name := range llm.PersonaPresets {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return strings.Join(names, ", ")
+}
@@ -0,0 +1,57 @@
+package telegram
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+
+	"anondd/llm"
+	"anondd/utils/pipeline"
+	"anondd/utils/storage"
+	"anondd/utils/trace"
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// handlePipeline runs a configured analysis pipeline for the named agent
+func handlePipeline(ctx context.Context, bot *Bot, update tgbotapi.Update, config BotConfig, store *storage.AgentStore, engine *pipeline.Engine, client *llm.OpenRouterClient, persona, name, agentQuery string, logger *log.Logger) {
+	chatID := update.Message.Chat.ID
+
+	if agentQuery == "" {
+		bot.Send(tgbotapi.NewMessage(chatID, fmt.Sprintf("Usage: %s <agent name or id>", update.Message.Text)))
+		return
+	}
+
+	state, err := engine.Run(ctx, name, agentQuery)
+	if err != nil {
+		trace.Logf(ctx, logger, "Error running pipeline %s: %v", name, err)
+		bot.Send(tgbotapi.NewMessage(chatID, fmt.Sprintf("❌ Unable to run %s for '%s'.", name, agentQuery)))
+		return
+	}
+
+	output := client.PostProcess(ctx, "pipeline", persona, state.Output)
+	output = shareLongText(ctx, store, config, fmt.Sprintf("%s: %s", name, state.Agent.Name), output, logger)
+	if _, err := bot.Send(tgbotapi.NewMessage(chatID, output)); err != nil {
+		trace.Logf(ctx, logger, "Error sending pipeline output: %v", err)
+	}
+}
+
+// handleListPipelines lists the pipelines and the commands that run them
+func handleListPipelines(bot *Bot, update tgbotapi.Update, engine *pipeline.Engine) {
+	chatID := update.Message.Chat.ID
+	if engine == nil {
+		bot.Send(tgbotapi.NewMessage(chatID, "No analysis pipelines are configured."))
+		return
+	}
+
+	var b strings.Builder
+	b.WriteString("🧪 Analysis pipelines:\n")
+	for _, p := range engine.List() {
+		if p.Command != "" {
+			fmt.Fprintf(&b, "\n%s <agent> - %s", p.Command, p.Description)
+		} else {
+			fmt.Fprintf(&b, "\n%s - %s (API only)", p.Name, p.Description)
+		}
+	}
+	bot.Send(tgbotapi.NewMessage(chatID, b.String()))
+}
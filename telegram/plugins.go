@@ -0,0 +1,73 @@
+package telegram
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+
+	"anondd/llm"
+	"anondd/utils/plugins"
+	"anondd/utils/storage"
+	"anondd/utils/trace"
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// handlePlugin runs a command plugin and sends its response
+func handlePlugin(ctx context.Context, bot *Bot, update tgbotapi.Update, config BotConfig, store *storage.AgentStore, client *llm.OpenRouterClient, p plugins.CommandPlugin, args []string, logger *log.Logger) {
+	message := update.Message
+	info := p.Info()
+	if info.Admin && !requireAdmin(bot, update) {
+		return
+	}
+
+	req := plugins.Request{
+		Env:    plugins.Env{Store: store, LLM: client, Logger: logger},
+		Bot:    config.Name,
+		ChatID: message.Chat.ID,
+		Args:   args,
+	}
+	if message.From != nil {
+		req.UserID = message.From.ID
+	}
+	resp, err := p.Handle(ctx, req)
+	if err != nil {
+		trace.Logf(ctx, logger, "Error running plugin %s: %v", info.Command, err)
+		bot.Send(tgbotapi.NewMessage(message.Chat.ID, fmt.Sprintf("❌ %s failed, try again later.", info.Command)))
+		return
+	}
+	if resp.Text == "" {
+		return
+	}
+	msg := tgbotapi.NewMessage(message.Chat.ID, resp.Text)
+	if resp.Markdown {
+		msg.ParseMode = tgbotapi.ModeMarkdown
+	}
+	if _, err := bot.Send(msg); err != nil {
+		trace.Logf(ctx, logger, "Error sending plugin %s response: %v", info.Command, err)
+	}
+}
+
+// handleListPlugins lists the commands added by plugins
+func handleListPlugins(bot *Bot, update tgbotapi.Update, registry *plugins.Registry) {
+	chatID := update.Message.Chat.ID
+	list := registry.List()
+	if len(list) == 0 {
+		bot.Send(tgbotapi.NewMessage(chatID, "No command plugins are installed."))
+		return
+	}
+
+	var b strings.Builder
+	b.WriteString("🧩 Plugin commands:\n")
+	for _, info := range list {
+		command := info.Command
+		if info.Usage != "" {
+			command += " " + info.Usage
+		}
+		if info.Admin {
+			command += " (admin)"
+		}
+		fmt.Fprintf(&b, "\n%s - %s", command, info.Description)
+	}
+	bot.Send(tgbotapi.NewMessage(chatID, b.String()))
+}
@@ -0,0 +1,63 @@
+package telegram
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"anondd/llm"
+	"anondd/utils/storage"
+	"anondd/utils/trace"
+	"anondd/utils/trend"
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// predictDisclaimer closes every /predict reply
+const predictDisclaimer = "⚠️ Speculative: a straight-line extrapolation of recent data, not a forecast or financial advice."
+
+// handlePredict implements /predict <agent>: trend statistics from the
+// agent's metric history, narrated by the LLM as a cautious projection
+func handlePredict(ctx context.Context, bot *Bot, update tgbotapi.Update, store *storage.AgentStore, client *llm.OpenRouterClient, persona string, args []string, logger *log.Logger) {
+	chatID := update.Message.Chat.ID
+	if len(args) == 0 {
+		bot.Send(tgbotapi.NewMessage(chatID, "Usage: /predict <agent>"))
+		return
+	}
+
+	query := strings.Join(args, " ")
+	agent, err := store.FindAgent(ctx, query)
+	if err != nil {
+		bot.Send(tgbotapi.NewMessage(chatID, fmt.Sprintf("❌ No agent found matching '%s'", query)))
+		return
+	}
+
+	history, err := store.GetHistory(agent.SourceID)
+	if err != nil {
+		trace.Logf(ctx, logger, "Error loading history for %s: %v", agent.Name, err)
+		bot.Send(tgbotapi.NewMessage(chatID, "Error accessing agent history"))
+		return
+	}
+
+	t := trend.Compute(agent, history, time.Now())
+	if !t.Sufficient() {
+		bot.Send(tgbotapi.NewMessage(chatID, fmt.Sprintf("📉 Not enough history for %s yet: need %d snapshots from the last week.",
+			agent.Name, trend.MinSamples)))
+		return
+	}
+
+	numbers := t.Format()
+	narration, err := client.GetResponse(ctx, "predict", agentRecord(agent)+"\nTrend statistics:\n"+numbers)
+	if err != nil {
+		trace.Logf(ctx, logger, "Error narrating trend for %s: %v", agent.Name, err)
+		narration = "Unable to narrate the trend right now."
+	} else {
+		narration = client.PostProcess(ctx, "predict", persona, narration)
+	}
+
+	reply := fmt.Sprintf("🔮 Speculative outlook for %s\n\n%s\n%s\n\n%s", agent.Name, numbers, narration, predictDisclaimer)
+	if _, err := bot.Send(tgbotapi.NewMessage(chatID, reply)); err != nil {
+		trace.Logf(ctx, logger, "Error sending prediction: %v", err)
+	}
+}
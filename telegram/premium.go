@@ -0,0 +1,334 @@
+package telegram
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"anondd/utils/storage"
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// starsCurrency is the currency code of Telegram Stars; Stars invoices need
+// no payment provider token
+const starsCurrency = "XTR"
+
+// premiumPayloadPrefix starts the payload of premium invoices, followed by
+// the days bought so a price change can't alter a pending purchase
+const premiumPayloadPrefix = "premium:"
+
+const premiumPitch = "Premium unlocks deep DD and larger watchlists, see /premium."
+
+const premiumUsage = "Usage: /premium - your premium status\n" +
+	"/premium redeem <code> - redeem an invite code\n" +
+	"/premium buy - pay with Telegram Stars"
+
+const premiumAdminUsage = "Usage: /premium_admin list\n" +
+	"/premium_admin grant <user_id> [days] - 0 days never expires\n" +
+	"/premium_admin revoke <user_id>\n" +
+	"/premium_admin invite [days] [uses] - 30 days, 1 use by default\n" +
+	"/premium_admin invites\n" +
+	"/premium_admin delete_invite <code>"
+
+// isPremium reports whether user may use premium features on this bot:
+// always when the bot doesn't gate them, and always for admins
+func isPremium(config BotConfig, entitlements *storage.EntitlementStore, user *tgbotapi.User) bool {
+	if !config.Premium {
+		return true
+	}
+	if user == nil {
+		return false
+	}
+	if isAdmin(user.ID) {
+		return true
+	}
+	return entitlements != nil && entitlements.Premium(user.ID, time.Now())
+}
+
+// requirePremium replies with how to get premium and returns false for users without it
+func requirePremium(bot *Bot, update tgbotapi.Update, config BotConfig, entitlements *storage.EntitlementStore) bool {
+	if isPremium(config, entitlements, update.Message.From) {
+		return true
+	}
+	bot.Send(tgbotapi.NewMessage(update.Message.Chat.ID, "💎 That's a premium feature. "+premiumPitch))
+	return false
+}
+
+// handlePremium implements /premium: the user's status, redeeming invite
+// codes and buying premium with Stars
+func handlePremium(bot *Bot, update tgbotapi.Update, config BotConfig, entitlements *storage.EntitlementStore, args []string, logger *log.Logger) {
+	chatID := update.Message.Chat.ID
+	user := update.Message.From
+	if !config.Premium {
+		bot.Send(tgbotapi.NewMessage(chatID, "Every feature of this bot is free to use."))
+		return
+	}
+	if entitlements == nil || user == nil {
+		bot.Send(tgbotapi.NewMessage(chatID, "❌ Premium is unavailable right now."))
+		return
+	}
+
+	if len(args) == 0 {
+		status := "You don't have premium. " + premiumPitch
+		if entitlement, ok := entitlements.Get(user.ID); ok {
+			if entitlement.Active(time.Now()) {
+				status = "💎 You have premium " + formatExpiry(entitlement) + "."
+			} else {
+				status = fmt.Sprintf("Your premium expired on %s.", entitlement.ExpiresAt.Format("2006-01-02"))
+			}
+		}
+		bot.Send(tgbotapi.NewMessage(chatID, status+"\n\n"+premiumUsage))
+		return
+	}
+
+	switch strings.ToLower(args[0]) {
+	case "redeem":
+		if len(args) < 2 {
+			bot.Send(tgbotapi.NewMessage(chatID, premiumUsage))
+			return
+		}
+		entitlement, err := entitlements.Redeem(args[1], user.ID, time.Now())
+		switch {
+		case errors.Is(err, storage.ErrNotFound):
+			bot.Send(tgbotapi.NewMessage(chatID, "❌ That invite code doesn't exist."))
+		case errors.Is(err, storage.ErrRedeemed):
+			bot.Send(tgbotapi.NewMessage(chatID, "ℹ️ You already redeemed that invite code."))
+		case errors.Is(err, storage.ErrUsedUp):
+			bot.Send(tgbotapi.NewMessage(chatID, "❌ That invite code has been used up."))
+		case err != nil:
+			logger.Printf("Error redeeming invite code for user %d: %v", user.ID, err)
+			bot.Send(tgbotapi.NewMessage(chatID, "❌ Unable to redeem the code right now."))
+		default:
+			logger.Printf("User %d redeemed invite code %s", user.ID, entitlement.Reference)
+			bot.Send(tgbotapi.NewMessage(chatID, "💎 Welcome to premium! You have it "+formatExpiry(entitlement)+"."))
+		}
+	case "buy":
+		if config.PremiumStars <= 0 {
+			bot.Send(tgbotapi.NewMessage(chatID, "Premium can't be bought on this bot, ask an admin for an invite code."))
+			return
+		}
+		days := config.premiumDays()
+		invoice := tgbotapi.NewInvoice(chatID, "Premium",
+			fmt.Sprintf("%d days of deep DD and larger watchlists", days),
+			premiumPayloadPrefix+strconv.Itoa(days), "", "", starsCurrency,
+			[]tgbotapi.LabeledPrice{{Label: fmt.Sprintf("%d days of premium", days), Amount: config.PremiumStars}})
+		invoice.SuggestedTipAmounts = []int{} // Stars invoices take no tips; nil would be sent as null
+		if _, err := bot.Send(invoice); err != nil {
+			logger.Printf("Error sending premium invoice to chat %d: %v", chatID, err)
+			bot.Send(tgbotapi.NewMessage(chatID, "❌ Unable to start the payment right now."))
+		}
+	default:
+		bot.Send(tgbotapi.NewMessage(chatID, premiumUsage))
+	}
+}
+
+// handlePreCheckout approves a premium purchase if it still matches the
+// bot's offer; Telegram charges the user only after this answer
+func handlePreCheckout(bot *Bot, query *tgbotapi.PreCheckoutQuery, config BotConfig, logger *log.Logger) {
+	_, valid := premiumPayloadDays(query.InvoicePayload)
+	valid = valid && config.Premium && config.PremiumStars > 0 &&
+		query.Currency == starsCurrency && query.TotalAmount == config.PremiumStars
+	answer := tgbotapi.PreCheckoutConfig{PreCheckoutQueryID: query.ID, OK: valid}
+	if !valid {
+		answer.ErrorMessage = "This offer has changed, please run /premium buy again."
+	}
+	if _, err := bot.Request(answer); err != nil {
+		logger.Printf("Error answering pre-checkout query %s: %v", query.ID, err)
+	}
+}
+
+// handleSuccessfulPayment credits a completed Stars payment. Telegram may
+// deliver the same payment twice, so it is credited once per charge ID.
+func handleSuccessfulPayment(bot *Bot, update tgbotapi.Update, entitlements *storage.EntitlementStore, logger *log.Logger) {
+	chatID := update.Message.Chat.ID
+	payment := update.Message.SuccessfulPayment
+	days, ok := premiumPayloadDays(payment.InvoicePayload)
+	if !ok || update.Message.From == nil {
+		logger.Printf("Ignoring payment %s with payload %q", payment.TelegramPaymentChargeID, payment.InvoicePayload)
+		return
+	}
+	userID := update.Message.From.ID
+	if entitlements == nil {
+		logger.Printf("Error crediting payment %s for user %d: entitlements are unavailable", payment.TelegramPaymentChargeID, userID)
+		bot.Send(tgbotapi.NewMessage(chatID, "⚠️ Your payment went through but premium couldn't be activated yet. An admin will sort it out."))
+		return
+	}
+
+	entitlement, credited, err := entitlements.RecordPayment(payment.TelegramPaymentChargeID, userID, days, time.Now())
+	if err != nil {
+		logger.Printf("Error crediting payment %s for user %d: %v", payment.TelegramPaymentChargeID, userID, err)
+		bot.Send(tgbotapi.NewMessage(chatID, "⚠️ Your payment went through but premium couldn't be activated yet. An admin will sort it out."))
+		return
+	}
+	if !credited {
+		return
+	}
+	logger.Printf("User %d bought %d days of premium (charge %s)", userID, days, payment.TelegramPaymentChargeID)
+	bot.Send(tgbotapi.NewMessage(chatID, "💎 Thanks! You have premium "+formatExpiry(entitlement)+"."))
+}
+
+// premiumPayloadDays reads the days bought from a premium invoice payload
+func premiumPayloadDays(payload string) (int, bool) {
+	raw, found := strings.CutPrefix(payload, premiumPayloadPrefix)
+	if !found {
+		return 0, false
+	}
+	days, err := strconv.Atoi(raw)
+	return days, err == nil && days > 0
+}
+
+// handlePremiumAdmin implements the admin /premium_admin command for
+// granting and revoking premium and managing invite codes
+func handlePremiumAdmin(bot *Bot, update tgbotapi.Update, entitlements *storage.EntitlementStore, args []string, logger *log.Logger) {
+	if !requireAdmin(bot, update) {
+		return
+	}
+	chatID := update.Message.Chat.ID
+	if entitlements == nil {
+		bot.Send(tgbotapi.NewMessage(chatID, "❌ Entitlements are unavailable right now."))
+		return
+	}
+	if len(args) == 0 {
+		bot.Send(tgbotapi.NewMessage(chatID, premiumAdminUsage))
+		return
+	}
+
+	now := time.Now()
+	var reply string
+	switch strings.ToLower(args[0]) {
+	case "list":
+		list := entitlements.List()
+		if len(list) == 0 {
+			reply = "No one has premium yet."
+			break
+		}
+		var b strings.Builder
+		fmt.Fprintf(&b, "💎 Premium users (%d):\n", len(list))
+		for _, entitlement := range list {
+			state := formatExpiry(entitlement)
+			if !entitlement.Active(now) {
+				state = "expired " + entitlement.ExpiresAt.Format("2006-01-02")
+			}
+			fmt.Fprintf(&b, "\n• %d - %s, via %s", entitlement.UserID, state, entitlement.Source)
+		}
+		reply = b.String()
+	case "grant":
+		if len(args) < 2 {
+			reply = premiumAdminUsage
+			break
+		}
+		userID, err := strconv.ParseInt(args[1], 10, 64)
+		days := 30
+		if err == nil && len(args) > 2 {
+			days, err = strconv.Atoi(args[2])
+		}
+		if err != nil || days < 0 {
+			reply = premiumAdminUsage
+			break
+		}
+		reference := ""
+		if update.Message.From != nil {
+			reference = strconv.FormatInt(update.Message.From.ID, 10)
+		}
+		entitlement, err := entitlements.Grant(userID, storage.EntitlementAdmin, reference, days, now)
+		if err != nil {
+			logger.Printf("Error granting premium to user %d: %v", userID, err)
+			reply = "❌ Unable to grant premium right now."
+			break
+		}
+		reply = fmt.Sprintf("💎 User %d has premium %s.", userID, formatExpiry(entitlement))
+	case "revoke":
+		if len(args) < 2 {
+			reply = premiumAdminUsage
+			break
+		}
+		userID, err := strconv.ParseInt(args[1], 10, 64)
+		if err != nil {
+			reply = premiumAdminUsage
+			break
+		}
+		revoked, err := entitlements.Revoke(userID)
+		switch {
+		case err != nil:
+			logger.Printf("Error revoking premium of user %d: %v", userID, err)
+			reply = "❌ Unable to revoke premium right now."
+		case revoked:
+			reply = fmt.Sprintf("🗑 User %d no longer has premium.", userID)
+		default:
+			reply = fmt.Sprintf("ℹ️ User %d doesn't have premium.", userID)
+		}
+	case "invite":
+		days, uses := 30, 1
+		var err error
+		if len(args) > 1 {
+			days, err = strconv.Atoi(args[1])
+		}
+		if err == nil && len(args) > 2 {
+			uses, err = strconv.Atoi(args[2])
+		}
+		if err != nil || days < 0 || uses < 1 {
+			reply = premiumAdminUsage
+			break
+		}
+		var createdBy int64
+		if update.Message.From != nil {
+			createdBy = update.Message.From.ID
+		}
+		invite, err := entitlements.CreateInvite(days, uses, createdBy, now)
+		if err != nil {
+			logger.Printf("Error creating invite code: %v", err)
+			reply = "❌ Unable to create an invite code right now."
+			break
+		}
+		reply = fmt.Sprintf("🎟 Invite code %s: %s, %d uses.\nRedeem with /premium redeem %s", invite.Code, formatInviteDays(invite.Days), invite.MaxUses, invite.Code)
+	case "invites":
+		invites := entitlements.Invites()
+		if len(invites) == 0 {
+			reply = "No invite codes yet."
+			break
+		}
+		var b strings.Builder
+		fmt.Fprintf(&b, "🎟 Invite codes (%d):\n", len(invites))
+		for _, invite := range invites {
+			fmt.Fprintf(&b, "\n• %s - %s, %d of %d uses left", invite.Code, formatInviteDays(invite.Days), invite.Remaining(), invite.MaxUses)
+		}
+		reply = b.String()
+	case "delete_invite":
+		if len(args) < 2 {
+			reply = premiumAdminUsage
+			break
+		}
+		deleted, err := entitlements.DeleteInvite(args[1])
+		switch {
+		case err != nil:
+			logger.Printf("Error deleting invite code %s: %v", args[1], err)
+			reply = "❌ Unable to delete the invite code right now."
+		case deleted:
+			reply = fmt.Sprintf("🗑 Invite code %s deleted.", strings.ToUpper(args[1]))
+		default:
+			reply = fmt.Sprintf("ℹ️ There is no invite code %s.", strings.ToUpper(args[1]))
+		}
+	default:
+		reply = premiumAdminUsage
+	}
+	bot.Send(tgbotapi.NewMessage(chatID, reply))
+}
+
+// formatExpiry describes how long an entitlement lasts, e.g. "until 2025-03-01"
+func formatExpiry(entitlement storage.Entitlement) string {
+	if entitlement.ExpiresAt.IsZero() {
+		return "with no expiry"
+	}
+	return "until " + entitlement.ExpiresAt.Format("2006-01-02")
+}
+
+// formatInviteDays describes the premium an invite code grants
+func formatInviteDays(days int) string {
+	if days == 0 {
+		return "premium with no expiry"
+	}
+	return fmt.Sprintf("%d days of premium", days)
+}
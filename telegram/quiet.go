@@ -0,0 +1,237 @@
+package telegram
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"anondd/utils/models"
+	"anondd/utils/storage"
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// quietReleaseInterval is how often held notifications are checked for delivery
+const quietReleaseInterval = time.Minute
+
+// defaultDigestInterval is how often informational updates are batched into
+// a digest when the bot config doesn't say
+const defaultDigestInterval = time.Hour
+
+const quietUsage = "Usage: /quiet 23:00-07:00 [time zone, e.g. Europe/Berlin] | /quiet off"
+
+// notifier delivers notifications by severity: critical ones at once, normal
+// ones unless the receiving chat is in its quiet hours, and informational
+// ones batched into a digest every digestInterval
+type notifier struct {
+	bot            *Bot
+	botName        string
+	quiet          *storage.QuietHoursStore
+	profiles       *storage.ProfileStore // Puts favorites first in catch-ups, when set
+	digestInterval time.Duration         // 0 sends informational updates like normal ones
+	logger         *log.Logger
+}
+
+func newNotifier(bot *Bot, botName string, quiet *storage.QuietHoursStore, profiles *storage.ProfileStore, digestInterval time.Duration, logger *log.Logger) *notifier {
+	return &notifier{bot: bot, botName: botName, quiet: quiet, profiles: profiles, digestInterval: digestInterval, logger: logger}
+}
+
+// notify queues text for chatID now, or holds it until quiet hours end
+func (n *notifier) notify(chatID int64, text string) {
+	n.notifyAgent(chatID, 0, "", text)
+}
+
+// notifyAgent is notify for a message about one agent, which the catch-up
+// after quiet hours puts first if the chat's user favorited it
+func (n *notifier) notifyAgent(chatID int64, sourceID int, agentID string, text string) {
+	n.deliver(chatID, models.SeverityNormal, sourceID, agentID, text)
+}
+
+// notifyChange is notifyAgent for a change event, at the event's severity
+func (n *notifier) notifyChange(chatID int64, event models.ChangeEvent, text string) {
+	n.deliver(chatID, models.ChangeSeverity(event), event.SourceID, event.AgentID, text)
+}
+
+func (n *notifier) deliver(chatID int64, severity string, sourceID int, agentID string, text string) {
+	if severity == models.SeverityCritical {
+		n.bot.PostUrgent(tgbotapi.NewMessage(chatID, "‼️ CRITICAL ‼️\n"+text))
+		return
+	}
+	if n.quiet == nil {
+		n.bot.Post(tgbotapi.NewMessage(chatID, text))
+		return
+	}
+
+	now := time.Now()
+	message := storage.HeldMessage{Bot: n.botName, ChatID: chatID, Text: text, HeldAt: now, SourceID: sourceID, AgentID: agentID}
+	switch {
+	case severity == models.SeverityInfo && n.digestInterval > 0:
+		message.Digest = true
+	case !n.quiet.IsQuiet(chatID, now):
+		n.bot.Post(tgbotapi.NewMessage(chatID, text))
+		return
+	}
+	if err := n.quiet.Hold(message); err != nil {
+		n.logger.Printf("[%s] Error holding notification for chat %d, sending now: %v", n.botName, chatID, err)
+		n.bot.Post(tgbotapi.NewMessage(chatID, text))
+	}
+}
+
+// notifyPhoto sends photo to chatID ahead of a notification, unless the chat
+// is in its quiet hours; held notifications are text only
+func (n *notifier) notifyPhoto(chatID int64, name string, photo []byte) {
+	if len(photo) == 0 || (n.quiet != nil && n.quiet.IsQuiet(chatID, time.Now())) {
+		return
+	}
+	n.bot.Post(tgbotapi.NewPhoto(chatID, tgbotapi.FileBytes{Name: name, Bytes: photo}))
+}
+
+// run delivers held notifications as chats leave their quiet hours, and
+// digests every digestInterval, until ctx is done
+func (n *notifier) run(ctx context.Context) {
+	if n.quiet == nil {
+		return
+	}
+	ticker := time.NewTicker(quietReleaseInterval)
+	defer ticker.Stop()
+	// Digests still go out after the interval is turned off, for updates
+	// held before
+	digestInterval := n.digestInterval
+	if digestInterval <= 0 {
+		digestInterval = quietReleaseInterval
+	}
+	digests := time.NewTicker(digestInterval)
+	defer digests.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			held, err := n.quiet.Release(n.botName, time.Now())
+			if err != nil {
+				n.logger.Printf("[%s] Error releasing held notifications: %v", n.botName, err)
+			}
+			for _, message := range n.favoritesFirst(held) {
+				n.bot.Post(tgbotapi.NewMessage(message.ChatID, message.Text))
+			}
+		case <-digests.C:
+			held, err := n.quiet.ReleaseDigest(n.botName, time.Now())
+			if err != nil {
+				n.logger.Printf("[%s] Error releasing digest updates: %v", n.botName, err)
+			}
+			n.sendDigests(held)
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// sendDigests posts each chat's informational updates as one digest,
+// favorites first, split only where Telegram's length limit requires
+func (n *notifier) sendDigests(held []storage.HeldMessage) {
+	byChat := make(map[int64][]storage.HeldMessage)
+	var chats []int64
+	for _, message := range n.favoritesFirst(held) {
+		if _, seen := byChat[message.ChatID]; !seen {
+			chats = append(chats, message.ChatID)
+		}
+		byChat[message.ChatID] = append(byChat[message.ChatID], message)
+	}
+	for _, chatID := range chats {
+		for _, part := range splitMessage(formatDigest(byChat[chatID]), telegramMessageLimit) {
+			n.bot.Post(tgbotapi.NewMessage(chatID, part))
+		}
+	}
+}
+
+// formatDigest renders a chat's batched updates in the order given
+func formatDigest(messages []storage.HeldMessage) string {
+	since := messages[0].HeldAt
+	for _, message := range messages {
+		if message.HeldAt.Before(since) {
+			since = message.HeldAt
+		}
+	}
+	var b strings.Builder
+	fmt.Fprintf(&b, "🗞 Digest: %d update", len(messages))
+	if len(messages) != 1 {
+		b.WriteString("s")
+	}
+	fmt.Fprintf(&b, " since %s\n", since.Format("Jan 2 15:04"))
+	for _, message := range messages {
+		fmt.Fprintf(&b, "\n• %s\n", message.Text)
+	}
+	return b.String()
+}
+
+// favoritesFirst orders released messages so those about agents the chat's
+// user favorited come first, marked with a star, keeping the order otherwise.
+// Favorites belong to users, so only private chats, whose ID is the user's,
+// have any.
+func (n *notifier) favoritesFirst(held []storage.HeldMessage) []storage.HeldMessage {
+	if n.profiles == nil {
+		return held
+	}
+	favorite := make([]bool, len(held))
+	for i, message := range held {
+		if message.AgentID != "" || message.SourceID != 0 {
+			favorite[i] = n.profiles.IsFavorite(message.ChatID, message.SourceID, message.AgentID)
+		}
+	}
+	ordered := make([]storage.HeldMessage, 0, len(held))
+	for i, message := range held {
+		if favorite[i] {
+			message.Text = "⭐ " + message.Text
+			ordered = append(ordered, message)
+		}
+	}
+	for i, message := range held {
+		if !favorite[i] {
+			ordered = append(ordered, message)
+		}
+	}
+	return ordered
+}
+
+// handleQuiet implements /quiet, setting or clearing the chat's quiet hours
+func handleQuiet(bot *Bot, update tgbotapi.Update, quiet *storage.QuietHoursStore, args []string, logger *log.Logger) {
+	chatID := update.Message.Chat.ID
+
+	var reply string
+	switch {
+	case len(args) == 0:
+		if hours, exists := quiet.Get(chatID); exists {
+			reply = fmt.Sprintf("🌙 Quiet hours: %s\n\n%s", hours, quietUsage)
+		} else {
+			reply = "🔔 No quiet hours set.\n\n" + quietUsage
+		}
+	case strings.ToLower(args[0]) == "off":
+		cleared, err := quiet.Clear(chatID)
+		switch {
+		case err != nil:
+			logger.Printf("Error clearing quiet hours for chat %d: %v", chatID, err)
+			reply = "❌ Unable to clear quiet hours right now."
+		case cleared:
+			reply = "🔔 Quiet hours off. Held notifications will arrive shortly."
+		default:
+			reply = "ℹ️ No quiet hours were set for this chat."
+		}
+	default:
+		timezone := ""
+		if len(args) > 1 {
+			timezone = args[1]
+		}
+		hours, err := models.ParseQuietHours(args[0], timezone)
+		if err != nil {
+			reply = fmt.Sprintf("❌ %v\n\n%s", err, quietUsage)
+			break
+		}
+		if err := quiet.Set(chatID, hours); err != nil {
+			logger.Printf("Error saving quiet hours for chat %d: %v", chatID, err)
+			reply = "❌ Unable to save quiet hours right now."
+			break
+		}
+		reply = fmt.Sprintf("🌙 Quiet hours set to %s. Alerts and announcements will wait until they end; critical alerts still come through.", hours)
+	}
+
+	bot.Send(tgbotapi.NewMessage(chatID, reply))
+}
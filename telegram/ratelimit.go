@@ -0,0 +1,35 @@
+package telegram
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"anondd/utils/shared"
+	"anondd/utils/trace"
+)
+
+// rateLimitWindow is the fixed window RateLimit is counted over
+const rateLimitWindow = time.Minute
+
+// allowMessage counts a message from chatID against the bot's per-minute limit.
+// The counters live in the shared store so the limit holds across instances.
+// It returns false once the limit is exceeded, with notify set only for the
+// first rejected message in the window so the chat is told once.
+func allowMessage(ctx context.Context, store shared.Store, config BotConfig, chatID int64, logger *log.Logger) (allowed, notify bool) {
+	if config.RateLimit <= 0 || store == nil {
+		return true, false
+	}
+
+	window := time.Now().Truncate(rateLimitWindow).Unix()
+	key := fmt.Sprintf("anondd:ratelimit:%s:%d:%d", config.Name, chatID, window)
+	count, err := store.Incr(ctx, key, rateLimitWindow)
+	if err != nil {
+		// Fail open; a broken limiter shouldn't take the bot down
+		trace.Logf(ctx, logger, "Error checking rate limit: %v", err)
+		return true, false
+	}
+	limit := int64(config.RateLimit)
+	return count <= limit, count == limit+1
+}
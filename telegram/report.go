@@ -0,0 +1,52 @@
+package telegram
+
+import (
+	"fmt"
+	"log"
+	"strings"
+
+	"anondd/utils/models"
+)
+
+// telegramMessageLimit is the maximum length of a single Telegram message
+const telegramMessageLimit = 4096
+
+// reportPublisher returns a hook posting generated reports to a channel
+func reportPublisher(notifier *notifier, chatID int64, logger *log.Logger) func(*models.Report) {
+	return func(report *models.Report) {
+		header := fmt.Sprintf("📰 State of the Agents: %s - %s\n\n",
+			report.PeriodStart.Format("Jan 2"), report.PeriodEnd.Format("Jan 2, 2006"))
+		// Parts are queued in order and delivered in order
+		parts := splitMessage(header+report.Text, telegramMessageLimit)
+		for _, part := range parts {
+			notifier.notify(chatID, part)
+		}
+		logger.Printf("Queued %s report for chat %d in %d parts", report.Kind, chatID, len(parts))
+	}
+}
+
+// splitMessage breaks long text into parts of at most limit characters,
+// preferring paragraph and line boundaries
+func splitMessage(text string, limit int) []string {
+	var parts []string
+	for {
+		runes := []rune(text)
+		if len(runes) <= limit {
+			break
+		}
+		chunk := string(runes[:limit])
+		cut := strings.LastIndex(chunk, "\n\n")
+		if cut < len(chunk)/2 {
+			cut = strings.LastIndex(chunk, "\n")
+		}
+		if cut < len(chunk)/2 {
+			cut = len(chunk)
+		}
+		parts = append(parts, strings.TrimSpace(chunk[:cut]))
+		text = strings.TrimSpace(text[cut:])
+	}
+	if text != "" {
+		parts = append(parts, text)
+	}
+	return parts
+}
@@ -0,0 +1,138 @@
+package telegram
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"anondd/llm"
+	"anondd/utils/models"
+	"anondd/utils/storage"
+	"anondd/utils/trace"
+	"anondd/utils/webscraper"
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// handleAdminScrape runs a scrape cycle for an admin's /scrape_agents
+// [profile], full by default, editing one message with live progress, then
+// posts the usual analysis of the refreshed data. It runs in the background so
+// the bot keeps answering.
+func handleAdminScrape(ctx context.Context, bot *Bot, update tgbotapi.Update, config BotConfig, scraper *webscraper.VirtualsScraper, args []string, store *storage.AgentStore, filter models.AgentFilter, persona string, client *llm.OpenRouterClient, logger *log.Logger) {
+	chatID := update.Message.Chat.ID
+
+	name := webscraper.ProfileFull
+	if len(args) > 0 {
+		name = strings.ToLower(args[0])
+	}
+	if _, exists := scraper.Profile(name); !exists {
+		var names []string
+		for _, profile := range scraper.Profiles() {
+			names = append(names, profile.Name)
+		}
+		bot.Send(tgbotapi.NewMessage(chatID, fmt.Sprintf("Unknown scrape profile %q. Usage: /scrape_agents [%s] or /scrape_agents dry [id ...]",
+			name, strings.Join(names, "|"))))
+		return
+	}
+
+	status, err := bot.Send(tgbotapi.NewMessage(chatID, fmt.Sprintf("🕷 Starting %s scrape...", name)))
+	if err != nil {
+		trace.Logf(ctx, logger, "Error sending scrape status: %v", err)
+		return
+	}
+
+	go func() {
+		start := time.Now()
+		err := scraper.ScrapeProfileWithProgress(name, func(progress webscraper.ScrapeProgress) {
+			// Queued rather than sent so a slow Telegram API never stalls the scrape
+			bot.Post(tgbotapi.NewEditMessageText(chatID, status.MessageID, formatScrapeProgress(progress, time.Since(start))))
+		})
+		if errors.Is(err, webscraper.ErrScrapeInProgress) {
+			bot.Send(tgbotapi.NewEditMessageText(chatID, status.MessageID, "⏳ A scrape is already running, try again when it finishes."))
+			return
+		}
+		if err != nil {
+			trace.Logf(ctx, logger, "Error running scrape: %v", err)
+			bot.Send(tgbotapi.NewEditMessageText(chatID, status.MessageID, "❌ Scrape failed, check the logs."))
+			return
+		}
+
+		handleScrapeAgents(ctx, bot, update, config, store, filter, persona, client, logger)
+	}()
+}
+
+// formatScrapeProgress renders a progress snapshot for the status message
+func formatScrapeProgress(progress webscraper.ScrapeProgress, elapsed time.Duration) string {
+	switch progress.Stage {
+	case webscraper.StageFetch:
+		return fmt.Sprintf("🕷 Fetching ID %d (%d/%d), %d fetched, %d errors, %s elapsed",
+			progress.CurrentID, progress.Done, progress.Total, progress.Fetched, progress.Errors, formatDuration(elapsed))
+	case webscraper.StageParse:
+		return fmt.Sprintf("🧩 Parsing page %d/%d, %d found, %d errors, %s elapsed",
+			progress.Done, progress.Total, progress.Found, progress.Errors, formatDuration(elapsed))
+	default:
+		return fmt.Sprintf("✅ Scrape complete: %d pages fetched, %d agents found, %d errors in %s",
+			progress.Fetched, progress.Found, progress.Errors, formatDuration(elapsed))
+	}
+}
+
+// handleDryRunScrape implements /scrape_agents dry [ids], reporting what a
+// scrape would change without writing anything
+func handleDryRunScrape(ctx context.Context, bot *Bot, update tgbotapi.Update, scraper *webscraper.VirtualsScraper, args []string, logger *log.Logger) {
+	chatID := update.Message.Chat.ID
+
+	ids, err := webscraper.ParseIDs(strings.Join(args, " "))
+	if err != nil {
+		bot.Send(tgbotapi.NewMessage(chatID, fmt.Sprintf("Usage: /scrape_agents dry [id ...] (%v)", err)))
+		return
+	}
+	bot.Send(tgbotapi.NewMessage(chatID, "🧪 Starting dry run, nothing will be saved..."))
+
+	go func() {
+		report, err := scraper.DryRun(ids)
+		if err != nil {
+			trace.Logf(ctx, logger, "Error running dry run scrape: %v", err)
+			bot.Send(tgbotapi.NewMessage(chatID, "❌ Dry run failed, check the logs."))
+			return
+		}
+		for _, part := range splitMessage(formatDryRun(report), telegramMessageLimit) {
+			bot.Send(tgbotapi.NewMessage(chatID, part))
+		}
+	}()
+}
+
+// formatDryRun renders a dry run report for chat
+func formatDryRun(report *webscraper.DryRunReport) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "🧪 Dry run via %s: %d IDs in %s\n%d parsed, %d failed, %d new, %d changed, %d unchanged\n",
+		report.Fetcher, report.Attempted, formatDuration(report.Duration),
+		report.Parsed, report.Failed, report.New, report.Changed, report.Unchanged)
+
+	for _, result := range report.Results {
+		switch {
+		case result.Error != "":
+			fmt.Fprintf(&b, "\n❌ %d: %s failed: %s\n", result.SourceID, result.Stage, result.Error)
+		case result.NewRecord || len(result.Changes) > 0:
+			label := "changed"
+			if result.NewRecord {
+				label = "new record"
+			}
+			fmt.Fprintf(&b, "\n• %d %s (%s)\n", result.SourceID, result.Name, label)
+			for _, change := range result.Changes {
+				fmt.Fprintf(&b, "  %s: %q → %q\n", change.Field, truncateText(change.Before, 60), truncateText(change.After, 60))
+			}
+		}
+	}
+	return b.String()
+}
+
+// truncateText shortens s to at most n runes, marking the cut with an ellipsis
+func truncateText(s string, n int) string {
+	runes := []rune(s)
+	if len(runes) <= n {
+		return s
+	}
+	return string(runes[:n-1]) + "…"
+}
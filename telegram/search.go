@@ -0,0 +1,74 @@
+package telegram
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+
+	"anondd/utils/search"
+	"anondd/utils/trace"
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// searchResultLimit is how many hits /search lists
+const searchResultLimit = 5
+
+// handleSearch implements /search <words>, full-text search over agent
+// descriptions and reports, with a quick DD button per agent found
+func handleSearch(ctx context.Context, bot *Bot, update tgbotapi.Update, index *search.Index, args []string, logger *log.Logger) {
+	chatID := update.Message.Chat.ID
+	if index == nil {
+		bot.Send(tgbotapi.NewMessage(chatID, "ℹ️ Search is disabled."))
+		return
+	}
+	query := strings.TrimSpace(strings.Join(args, " "))
+	if query == "" {
+		bot.Send(tgbotapi.NewMessage(chatID, "Usage: /search <words>, e.g. /search defi trading"))
+		return
+	}
+
+	hits, err := index.Search(query, search.Options{Limit: searchResultLimit})
+	if err != nil {
+		trace.Logf(ctx, logger, "Error searching for %q: %v", query, err)
+		bot.Send(tgbotapi.NewMessage(chatID, "❌ Unable to search right now."))
+		return
+	}
+	if len(hits) == 0 {
+		bot.Send(tgbotapi.NewMessage(chatID, fmt.Sprintf("🔎 Nothing matches '%s'.", query)))
+		return
+	}
+
+	msg := tgbotapi.NewMessage(chatID, formatSearchHits(query, hits))
+	var rows [][]tgbotapi.InlineKeyboardButton
+	for _, hit := range hits {
+		if hit.Kind == search.KindAgent {
+			rows = append(rows, tgbotapi.NewInlineKeyboardRow(
+				tgbotapi.NewInlineKeyboardButtonData("🔍 DD "+hit.Title, ddCallbackData(ddDepthQuick, hit.ID)),
+			))
+		}
+	}
+	if len(rows) > 0 {
+		msg.ReplyMarkup = tgbotapi.NewInlineKeyboardMarkup(rows...)
+	}
+	if _, err := bot.Send(msg); err != nil {
+		trace.Logf(ctx, logger, "Error sending search results: %v", err)
+	}
+}
+
+// formatSearchHits lists hits, best first, with their snippets
+func formatSearchHits(query string, hits []search.Hit) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "🔎 Results for '%s':\n", query)
+	for i, hit := range hits {
+		icon := "🤖"
+		if hit.Kind == search.KindReport {
+			icon = "📰"
+		}
+		fmt.Fprintf(&b, "\n%d. %s %s\n", i+1, icon, hit.Title)
+		if hit.Snippet != "" {
+			fmt.Fprintf(&b, "%s\n", hit.Snippet)
+		}
+	}
+	return b.String()
+}
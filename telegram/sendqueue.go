@@ -0,0 +1,305 @@
+package telegram
+
+import (
+	"context"
+	"errors"
+	"log"
+	"sync"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// Retry policy for outbound messages
+const (
+	sendMaxAttempts = 5
+	sendBaseBackoff = time.Second
+	sendMaxBackoff  = 30 * time.Second
+	sendMaxQueued   = 200 // Per chat; further messages are dropped
+	// sendDrainTimeout bounds how long a stopping bot waits for its queued
+	// messages to go out
+	sendDrainTimeout = 15 * time.Second
+)
+
+// errQueueFull is returned when a chat already has sendMaxQueued messages waiting
+var errQueueFull = errors.New("telegram send queue is full")
+
+// Bot wraps the Telegram API so that messages go through a send queue that
+// retries transient failures and keeps each chat's messages in order.
+// Other API methods are used directly.
+type Bot struct {
+	*tgbotapi.BotAPI
+	queue *sendQueue
+}
+
+// newBot starts the send queue for api; queued messages are abandoned when ctx is done
+func newBot(ctx context.Context, api *tgbotapi.BotAPI, logger *log.Logger) *Bot {
+	return &Bot{
+		BotAPI: api,
+		queue: &sendQueue{
+			ctx:    ctx,
+			send:   api.Send,
+			chats:  make(map[int64]*chatQueue),
+			logger: logger,
+		},
+	}
+}
+
+// Send queues c behind the chat's earlier messages and waits until it is
+// delivered or its retries are exhausted
+func (b *Bot) Send(c tgbotapi.Chattable) (tgbotapi.Message, error) {
+	result := make(chan sendResult, 1)
+	b.queue.enqueue(c, result)
+	res := <-result
+	return res.message, res.err
+}
+
+// Post queues c without waiting for delivery. Failures are logged and counted.
+func (b *Bot) Post(c tgbotapi.Chattable) {
+	b.queue.enqueue(c, nil)
+}
+
+// PostUrgent is Post for critical alerts: c goes ahead of the chat's other
+// waiting messages, behind earlier urgent ones, and a full queue doesn't drop it
+func (b *Bot) PostUrgent(c tgbotapi.Chattable) {
+	b.queue.enqueueUrgent(c)
+}
+
+// SendStats returns the send queue counters
+func (b *Bot) SendStats() SendStats {
+	return b.queue.snapshot()
+}
+
+// SendStats counts outbound messages by outcome
+type SendStats struct {
+	Sent    int64 // Delivered, including after retries
+	Delayed int64 // Delivered only after at least one retry
+	Retries int64 // Failed attempts that were retried
+	Dropped int64 // Given up on: retries exhausted, permanent error, full queue or shutdown
+	Queued  int   // Waiting or in flight right now
+}
+
+type sendResult struct {
+	message tgbotapi.Message
+	err     error
+}
+
+type outgoing struct {
+	chattable tgbotapi.Chattable
+	result    chan sendResult // nil for Post
+	queuedAt  time.Time
+	urgent    bool
+}
+
+// chatQueue holds one chat's pending messages; a worker drains it while running is set
+type chatQueue struct {
+	pending []*outgoing
+	running bool
+}
+
+type sendQueue struct {
+	ctx    context.Context
+	send   func(tgbotapi.Chattable) (tgbotapi.Message, error)
+	mu     sync.Mutex
+	chats  map[int64]*chatQueue
+	stats  SendStats
+	logger *log.Logger
+}
+
+func (q *sendQueue) enqueue(c tgbotapi.Chattable, result chan sendResult) {
+	chatID := chattableChatID(c)
+
+	q.mu.Lock()
+	chat, exists := q.chats[chatID]
+	if !exists {
+		chat = &chatQueue{}
+		q.chats[chatID] = chat
+	}
+	if len(chat.pending) >= sendMaxQueued {
+		q.stats.Dropped++
+		q.mu.Unlock()
+		q.logger.Printf("Dropping message to chat %d: %v", chatID, errQueueFull)
+		q.finish(result, tgbotapi.Message{}, errQueueFull)
+		return
+	}
+	chat.pending = append(chat.pending, &outgoing{chattable: c, result: result, queuedAt: time.Now()})
+	q.stats.Queued++
+	startWorker := !chat.running
+	chat.running = true
+	q.mu.Unlock()
+
+	if startWorker {
+		go q.drain(chatID, chat)
+	}
+}
+
+// enqueueUrgent queues c ahead of the chat's non-urgent messages
+func (q *sendQueue) enqueueUrgent(c tgbotapi.Chattable) {
+	chatID := chattableChatID(c)
+
+	q.mu.Lock()
+	chat, exists := q.chats[chatID]
+	if !exists {
+		chat = &chatQueue{}
+		q.chats[chatID] = chat
+	}
+	at := 0
+	for at < len(chat.pending) && chat.pending[at].urgent {
+		at++
+	}
+	out := &outgoing{chattable: c, queuedAt: time.Now(), urgent: true}
+	chat.pending = append(chat.pending[:at], append([]*outgoing{out}, chat.pending[at:]...)...)
+	q.stats.Queued++
+	startWorker := !chat.running
+	chat.running = true
+	q.mu.Unlock()
+
+	if startWorker {
+		go q.drain(chatID, chat)
+	}
+}
+
+// drain delivers a chat's messages in order until its queue is empty
+func (q *sendQueue) drain(chatID int64, chat *chatQueue) {
+	for {
+		q.mu.Lock()
+		if len(chat.pending) == 0 {
+			chat.running = false
+			delete(q.chats, chatID)
+			q.mu.Unlock()
+			return
+		}
+		next := chat.pending[0]
+		chat.pending = chat.pending[1:]
+		q.mu.Unlock()
+
+		message, err := q.deliver(chatID, next)
+		q.finish(next.result, message, err)
+	}
+}
+
+// deliver sends one message, retrying transient failures with backoff
+func (q *sendQueue) deliver(chatID int64, out *outgoing) (tgbotapi.Message, error) {
+	var err error
+	for attempt := 1; ; attempt++ {
+		var message tgbotapi.Message
+		message, err = q.send(out.chattable)
+		if err == nil {
+			q.mu.Lock()
+			q.stats.Sent++
+			q.stats.Queued--
+			if attempt > 1 {
+				q.stats.Delayed++
+			}
+			q.mu.Unlock()
+			if attempt > 1 {
+				q.logger.Printf("Delivered message to chat %d after %d attempts (%s late)",
+					chatID, attempt, time.Since(out.queuedAt).Round(time.Second))
+			}
+			return message, nil
+		}
+
+		wait, retry := retryDelay(err, attempt)
+		if !retry || attempt >= sendMaxAttempts {
+			break
+		}
+		q.mu.Lock()
+		q.stats.Retries++
+		q.mu.Unlock()
+		q.logger.Printf("Send to chat %d failed (attempt %d/%d), retrying in %s: %v",
+			chatID, attempt, sendMaxAttempts, wait, err)
+
+		if !q.sleep(wait) {
+			err = q.ctx.Err()
+			break
+		}
+	}
+
+	q.mu.Lock()
+	q.stats.Dropped++
+	q.stats.Queued--
+	q.mu.Unlock()
+	q.logger.Printf("Dropping message to chat %d: %v", chatID, err)
+	return tgbotapi.Message{}, err
+}
+
+// sleep waits for d and reports false if the queue was shut down first
+func (q *sendQueue) sleep(d time.Duration) bool {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return true
+	case <-q.ctx.Done():
+		return false
+	}
+}
+
+func (q *sendQueue) finish(result chan sendResult, message tgbotapi.Message, err error) {
+	if result != nil {
+		result <- sendResult{message: message, err: err}
+	}
+}
+
+// flush waits until no messages are waiting or in flight, up to timeout. It
+// reports whether the queue emptied.
+func (q *sendQueue) flush(timeout time.Duration) bool {
+	deadline := time.Now().Add(timeout)
+	for q.snapshot().Queued > 0 {
+		if time.Now().After(deadline) {
+			return false
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+	return true
+}
+
+func (q *sendQueue) snapshot() SendStats {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return q.stats
+}
+
+// retryDelay decides whether a send error is worth retrying and how long to
+// wait first. Telegram's retry_after wins over exponential backoff; other
+// API errors below 500 (bad request, blocked by user) are permanent.
+func retryDelay(err error, attempt int) (time.Duration, bool) {
+	backoff := sendBaseBackoff << (attempt - 1)
+	if backoff > sendMaxBackoff {
+		backoff = sendMaxBackoff
+	}
+
+	var apiErr *tgbotapi.Error
+	if !errors.As(err, &apiErr) {
+		return backoff, true // Network error, Telegram unreachable
+	}
+	switch {
+	case apiErr.RetryAfter > 0:
+		return time.Duration(apiErr.RetryAfter) * time.Second, true
+	case apiErr.Code == 429 || apiErr.Code >= 500:
+		return backoff, true
+	default:
+		return 0, false
+	}
+}
+
+// chattableChatID returns the chat a message is for, or 0 for messages that
+// target no single chat; those share one queue
+func chattableChatID(c tgbotapi.Chattable) int64 {
+	switch m := c.(type) {
+	case tgbotapi.MessageConfig:
+		return m.ChatID
+	case tgbotapi.EditMessageTextConfig:
+		return m.ChatID
+	case tgbotapi.EditMessageReplyMarkupConfig:
+		return m.ChatID
+	case tgbotapi.PhotoConfig:
+		return m.ChatID
+	case tgbotapi.VoiceConfig:
+		return m.ChatID
+	case tgbotapi.DocumentConfig:
+		return m.ChatID
+	default:
+		return 0
+	}
+}
@@ -0,0 +1,46 @@
+package telegram
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+
+	"anondd/utils/storage"
+	"anondd/utils/trace"
+)
+
+const (
+	// defaultShareThreshold is the response length above which a share link is used
+	defaultShareThreshold = 1500
+
+	// sharePreviewLength is how much of a shared response is still shown in chat
+	sharePreviewLength = 600
+)
+
+// shareLongText stores responses longer than the bot's threshold as a shared
+// report and returns a preview with the link. Shorter text, or any text when
+// sharing is not configured or fails, is returned unchanged.
+func shareLongText(ctx context.Context, store *storage.AgentStore, config BotConfig, title, text string, logger *log.Logger) string {
+	threshold := config.ShareThreshold
+	if threshold <= 0 {
+		threshold = defaultShareThreshold
+	}
+	if config.ShareBaseURL == "" || len([]rune(text)) <= threshold {
+		return text
+	}
+
+	share, err := store.SaveShare(title, text)
+	if err != nil {
+		trace.Logf(ctx, logger, "Error saving share link: %v", err)
+		return text
+	}
+
+	runes := []rune(text)
+	cut := string(runes[:min(len(runes), sharePreviewLength)])
+	if i := strings.LastIndexAny(cut, "\n."); i > len(cut)/2 {
+		cut = cut[:i+1]
+	}
+	url := strings.TrimSuffix(config.ShareBaseURL, "/") + "/r/" + share.ID
+	return fmt.Sprintf("%s…\n\n📄 Full report: %s", strings.TrimSpace(cut), url)
+}
@@ -0,0 +1,50 @@
+package telegram
+
+import (
+	"context"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"anondd/utils/storage"
+)
+
+func TestShareLongTextBelowPreviewLength(t *testing.T) {
+	logger := log.New(io.Discard, "", 0)
+	store := storage.NewAgentStore(t.TempDir(), logger)
+	config := BotConfig{ShareBaseURL: "https://example.com/", ShareThreshold: 100}
+
+	// Longer than the threshold but shorter than the preview
+	text := strings.Repeat("é", 300)
+	got := shareLongText(context.Background(), store, config, "Report", text, logger)
+	if !strings.HasPrefix(got, text) {
+		t.Errorf("preview dropped text shorter than the preview length: %q", got)
+	}
+	if !strings.Contains(got, "https://example.com/r/") {
+		t.Errorf("shareLongText() = %q, want a share link", got)
+	}
+
+	if short := shareLongText(context.Background(), store, config, "Report", "short", logger); short != "short" {
+		t.Errorf("shareLongText() = %q for text under the threshold, want it unchanged", short)
+	}
+}
+
+func TestLoadBotConfigsRejectsShareThresholdBelowPreview(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "bots.json")
+	if err := os.WriteFile(path, []byte(`[{"token": "t", "share_threshold": 100}]`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadBotConfigs(path); err == nil {
+		t.Error("LoadBotConfigs accepted a share threshold below the preview length")
+	}
+
+	if err := os.WriteFile(path, []byte(`[{"token": "t", "share_threshold": 2000}]`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadBotConfigs(path); err != nil {
+		t.Errorf("LoadBotConfigs: %v", err)
+	}
+}
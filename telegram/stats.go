@@ -0,0 +1,170 @@
+package telegram
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"time"
+
+	"anondd/llm"
+	"anondd/utils/models"
+	"anondd/utils/storage"
+	"anondd/utils/trace"
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// processStart is used to report uptime in /stats
+var processStart = time.Now()
+
+// statsRecentRuns is how many recent runs per source feed the success rate
+const statsRecentRuns = 10
+
+// statsSources are the scrape sources reported by /stats, in display order
+var statsSources = []string{models.SourceVirtuals, models.SourceNews}
+
+// handleStats implements the admin /stats command
+func handleStats(ctx context.Context, bot *Bot, update tgbotapi.Update, store *storage.AgentStore, client *llm.OpenRouterClient, logger *log.Logger) {
+	if !requireAdmin(bot, update) {
+		return
+	}
+	chatID := update.Message.Chat.ID
+
+	var b strings.Builder
+	b.WriteString("📈 Bot stats\n")
+
+	counts, err := store.StatusCounts(ctx)
+	if err != nil {
+		trace.Logf(ctx, logger, "Error counting agents by status: %v", err)
+		b.WriteString("\n🤖 Agents: unavailable\n")
+	} else {
+		total := 0
+		statuses := make([]string, 0, len(counts))
+		for status, count := range counts {
+			total += count
+			statuses = append(statuses, status)
+		}
+		sort.Strings(statuses)
+		fmt.Fprintf(&b, "\n🤖 Agents stored: %d\n", total)
+		for _, status := range statuses {
+			fmt.Fprintf(&b, "  • %s: %d\n", status, counts[status])
+		}
+	}
+
+	b.WriteString("\n🕷 Scrapes\n")
+	for _, source := range statsSources {
+		runs, err := store.ScrapeRuns(source)
+		if err != nil {
+			trace.Logf(ctx, logger, "Error loading %s scrape runs: %v", source, err)
+			fmt.Fprintf(&b, "  • %s: unavailable\n", source)
+			continue
+		}
+		if len(runs) == 0 {
+			fmt.Fprintf(&b, "  • %s: no runs yet\n", source)
+			continue
+		}
+		if len(runs) > statsRecentRuns {
+			runs = runs[len(runs)-statsRecentRuns:]
+		}
+		attempted, succeeded := 0, 0
+		for _, run := range runs {
+			attempted += run.Attempted
+			succeeded += run.Succeeded
+		}
+		last := runs[len(runs)-1]
+		fmt.Fprintf(&b, "  • %s: last run %s ago, took %s, %d/%d ok\n", source,
+			formatDuration(time.Since(last.StartedAt.Add(last.Duration))), formatDuration(last.Duration),
+			last.Succeeded, last.Attempted)
+		fmt.Fprintf(&b, "    success rate over last %d runs: %s\n", len(runs), formatRate(succeeded, attempted))
+		if failures := formatErrorKinds(runs); failures != "" {
+			fmt.Fprintf(&b, "    failures: %s\n", failures)
+		}
+	}
+
+	sends := bot.SendStats()
+	fmt.Fprintf(&b, "\n📤 Messages: %d sent, %d delayed, %d dropped, %d queued\n",
+		sends.Sent, sends.Delayed, sends.Dropped, sends.Queued)
+
+	calls := client.Stats()
+	fmt.Fprintf(&b, "🧠 LLM calls today: %d (%d failed)\n", calls.Calls, calls.Errors)
+	health := client.Health()
+	fmt.Fprintf(&b, "🩺 LLM provider: circuit %s, %s errors, %s avg latency over last %d calls\n",
+		health.State, formatRate(int(health.ErrorRate*float64(health.Calls)+0.5), health.Calls),
+		formatDuration(health.AvgLatency), health.Calls)
+	if health.State != llm.CircuitClosed {
+		fmt.Fprintf(&b, "   opened %s ago, %d requests served the fallback\n",
+			formatDuration(time.Since(health.OpenedAt)), health.Rejected)
+	}
+
+	if size, err := store.DiskUsage(); err != nil {
+		trace.Logf(ctx, logger, "Error measuring storage size: %v", err)
+		b.WriteString("💾 Storage: unavailable\n")
+	} else {
+		fmt.Fprintf(&b, "💾 Storage: %s\n", formatBytes(size))
+	}
+	fmt.Fprintf(&b, "⏱ Uptime: %s", formatDuration(time.Since(processStart)))
+
+	if _, err := bot.Send(tgbotapi.NewMessage(chatID, b.String())); err != nil {
+		trace.Logf(ctx, logger, "Error sending stats: %v", err)
+	}
+}
+
+// formatErrorKinds totals the runs' failures by kind, most common first
+func formatErrorKinds(runs []models.ScrapeRun) string {
+	totals := make(map[string]int)
+	for _, run := range runs {
+		for kind, count := range run.Errors {
+			totals[kind] += count
+		}
+	}
+	kinds := make([]string, 0, len(totals))
+	for kind := range totals {
+		kinds = append(kinds, kind)
+	}
+	sort.Slice(kinds, func(i, j int) bool {
+		if totals[kinds[i]] != totals[kinds[j]] {
+			return totals[kinds[i]] > totals[kinds[j]]
+		}
+		return kinds[i] < kinds[j]
+	})
+	parts := make([]string, len(kinds))
+	for i, kind := range kinds {
+		parts[i] = fmt.Sprintf("%s %d", kind, totals[kind])
+	}
+	return strings.Join(parts, ", ")
+}
+
+func formatRate(succeeded, attempted int) string {
+	if attempted == 0 {
+		return "n/a"
+	}
+	return fmt.Sprintf("%.1f%%", float64(succeeded)/float64(attempted)*100)
+}
+
+// formatDuration renders a duration at a precision suited to its size
+func formatDuration(d time.Duration) string {
+	switch {
+	case d < time.Minute:
+		return d.Round(time.Second).String()
+	case d < time.Hour:
+		return fmt.Sprintf("%dm", int(d.Minutes()))
+	case d < 24*time.Hour:
+		return fmt.Sprintf("%dh%dm", int(d.Hours()), int(d.Minutes())%60)
+	default:
+		return fmt.Sprintf("%dd%dh", int(d.Hours())/24, int(d.Hours())%24)
+	}
+}
+
+func formatBytes(size int64) string {
+	const unit = 1024
+	if size < unit {
+		return fmt.Sprintf("%d B", size)
+	}
+	div, exp := int64(unit), 0
+	for n := size / unit; n >= unit; n /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(size)/float64(div), "KMGTPE"[exp])
+}
@@ -0,0 +1,120 @@
+package telegram
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"anondd/llm"
+	"anondd/utils/models"
+	"anondd/utils/storage"
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// newcomerPeriod is how long after onboarding users without a /style
+// preference get detailed, explanatory replies
+const newcomerPeriod = 7 * 24 * time.Hour
+
+const styleUsage = "Usage: /style - show your reply style\n" +
+	"/style concise|normal|detailed - set your own\n" +
+	"/style chat concise|normal|detailed|clear - set this chat's default\n\n" +
+	"Concise replies are short and data-dense, detailed ones explain the numbers and terms."
+
+// handleStyle implements /style, the verbosity of the bot's LLM replies for
+// the user, or for everyone in the chat who hasn't picked one
+func handleStyle(bot *Bot, update tgbotapi.Update, profiles *storage.ProfileStore, settings *storage.ChatSettingsStore, args []string, logger *log.Logger) {
+	message := update.Message
+	chatID := message.Chat.ID
+	if message.From == nil {
+		return
+	}
+	userID := message.From.ID
+
+	if len(args) == 0 {
+		current, source := replyVerbosity(profiles, settings, chatID, message.From)
+		bot.Send(tgbotapi.NewMessage(chatID, fmt.Sprintf("📝 Replies here are %s (%s).\n\n%s", current, source, styleUsage)))
+		return
+	}
+
+	if strings.ToLower(args[0]) == "chat" {
+		if settings == nil {
+			bot.Send(tgbotapi.NewMessage(chatID, "❌ Chat settings are unavailable right now."))
+			return
+		}
+		if len(args) < 2 {
+			bot.Send(tgbotapi.NewMessage(chatID, styleUsage))
+			return
+		}
+		verbosity, ok := llm.ParseVerbosity(args[1])
+		if !ok && !isClear(args[1]) {
+			bot.Send(tgbotapi.NewMessage(chatID, styleUsage))
+			return
+		}
+		if _, err := settings.Update(chatID, func(s *models.ChatSettings) { s.Verbosity = verbosity }); err != nil {
+			logger.Printf("Error saving reply style for chat %d: %v", chatID, err)
+			bot.Send(tgbotapi.NewMessage(chatID, "❌ Unable to save the reply style right now."))
+			return
+		}
+		if verbosity == "" {
+			bot.Send(tgbotapi.NewMessage(chatID, "✅ This chat no longer has a default reply style."))
+			return
+		}
+		bot.Send(tgbotapi.NewMessage(chatID, fmt.Sprintf("✅ Replies in this chat are now %s, unless members picked their own.", verbosity)))
+		return
+	}
+
+	verbosity, ok := llm.ParseVerbosity(args[0])
+	if !ok && !isClear(args[0]) {
+		bot.Send(tgbotapi.NewMessage(chatID, styleUsage))
+		return
+	}
+	if _, err := profiles.Update(userID, func(p *storage.UserProfile) { p.Verbosity = verbosity }); err != nil {
+		logger.Printf("Error saving reply style for user %d: %v", userID, err)
+		bot.Send(tgbotapi.NewMessage(chatID, "❌ Unable to save your reply style right now."))
+		return
+	}
+	if verbosity == "" {
+		bot.Send(tgbotapi.NewMessage(chatID, "✅ Your reply style was cleared."))
+		return
+	}
+	bot.Send(tgbotapi.NewMessage(chatID, fmt.Sprintf("✅ Your replies are now %s.", verbosity)))
+}
+
+func isClear(arg string) bool {
+	switch strings.ToLower(arg) {
+	case "clear", "off", "reset":
+		return true
+	}
+	return false
+}
+
+// replyVerbosity picks the verbosity of replies to a user in a chat: their
+// own /style, then the chat's, then detailed for recent newcomers, and
+// normal otherwise. The second result says where it came from.
+func replyVerbosity(profiles *storage.ProfileStore, settings *storage.ChatSettingsStore, chatID int64, from *tgbotapi.User) (string, string) {
+	var profile storage.UserProfile
+	exists := false
+	if from != nil && profiles != nil {
+		profile, exists = profiles.Get(from.ID)
+	}
+	if exists && profile.Verbosity != "" {
+		return profile.Verbosity, "your choice"
+	}
+	if settings != nil {
+		if verbosity := settings.Get(chatID).Verbosity; verbosity != "" {
+			return verbosity, "this chat's default"
+		}
+	}
+	if exists && profile.Onboarded && time.Since(profile.OnboardedAt) < newcomerPeriod {
+		return llm.VerbosityDetailed, "you're new here"
+	}
+	return llm.VerbosityNormal, "the default"
+}
+
+// withReplyStyle asks for LLM replies at the user's verbosity
+func withReplyStyle(ctx context.Context, profiles *storage.ProfileStore, settings *storage.ChatSettingsStore, chatID int64, from *tgbotapi.User) context.Context {
+	verbosity, _ := replyVerbosity(profiles, settings, chatID, from)
+	return llm.WithVerbosity(ctx, verbosity)
+}
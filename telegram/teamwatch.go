@@ -0,0 +1,150 @@
+package telegram
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+
+	"anondd/utils/events"
+	"anondd/utils/models"
+	"anondd/utils/storage"
+	"anondd/utils/trace"
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+const teamWatchUsage = "Usage: /teamwatch add <agent> | /teamwatch remove <agent> | /teamwatch"
+
+// watchAlerter posts every change to a watched agent into the chats watching it
+type watchAlerter struct {
+	notifier   *notifier
+	watchlists *storage.WatchlistStore
+}
+
+func newWatchAlerter(notifier *notifier, watchlists *storage.WatchlistStore) *watchAlerter {
+	return &watchAlerter{notifier: notifier, watchlists: watchlists}
+}
+
+// send delivers a change to the chats watching its agent
+func (w *watchAlerter) send(changed events.AgentChanged) {
+	event := changed.Change
+	chats := w.watchlists.Watchers(w.notifier.botName, event.SourceID, event.AgentID)
+	if len(chats) == 0 {
+		return
+	}
+
+	var text string
+	if event.Type == models.ChangeDescriptionUpdated {
+		text = fmt.Sprintf("👀 %s updated its bio:\n\n%s", event.AgentName, truncateText(event.After, 500))
+	} else {
+		text = fmt.Sprintf("👀 %s: %s (%s → %s)", event.AgentName, event.Summary, event.Before, event.After)
+	}
+	for _, chatID := range chats {
+		w.notifier.notifyChange(chatID, event, text)
+	}
+}
+
+// sendDeepDive delivers an anomaly's deep dive, the fresh screenshot and DD
+// report, to the chats watching its agent
+func (w *watchAlerter) sendDeepDive(done events.DeepDiveCompleted) {
+	chats := w.watchlists.Watchers(w.notifier.botName, done.Agent.SourceID, done.Agent.ID)
+	if len(chats) == 0 {
+		return
+	}
+
+	text := fmt.Sprintf("🔬 %s: %s, so I took a fresh look.\n\n%s",
+		done.Agent.Name, done.Trigger.Summary, truncateText(done.Analysis, 3500))
+	for _, chatID := range chats {
+		w.notifier.notifyPhoto(chatID, fmt.Sprintf("agent_%d.png", done.Agent.SourceID), done.Screenshot)
+		w.notifier.notifyAgent(chatID, done.Agent.SourceID, done.Agent.ID, text)
+	}
+}
+
+// handleTeamWatch implements /teamwatch, the chat's shared watchlist. Any
+// member of a group can add and remove agents; alerts go to the group. With
+// limit set, adding stops once the watchlist holds that many agents.
+func handleTeamWatch(ctx context.Context, bot *Bot, update tgbotapi.Update, botName string, store *storage.AgentStore, watchlists *storage.WatchlistStore, limit int, args []string, logger *log.Logger) {
+	chatID := update.Message.Chat.ID
+
+	if len(args) == 0 {
+		bot.Send(tgbotapi.NewMessage(chatID, formatWatchlist(ctx, store, watchlists.List(botName, chatID))))
+		return
+	}
+
+	query := strings.TrimSpace(strings.Join(args[1:], " "))
+	if query == "" {
+		bot.Send(tgbotapi.NewMessage(chatID, teamWatchUsage))
+		return
+	}
+
+	var reply string
+	switch strings.ToLower(args[0]) {
+	case "add":
+		agent, err := store.FindAgent(ctx, query)
+		if err != nil {
+			reply = fmt.Sprintf("❌ No agent found matching '%s'", query)
+			break
+		}
+		if limit > 0 && len(watchlists.List(botName, chatID)) >= limit {
+			reply = fmt.Sprintf("💎 Free chats can watch up to %d agents. %s", limit, premiumPitch)
+			break
+		}
+		addedBy := ""
+		if update.Message.From != nil {
+			addedBy = update.Message.From.UserName
+		}
+		added, err := watchlists.Add(botName, chatID, agent, addedBy)
+		switch {
+		case err != nil:
+			trace.Logf(ctx, logger, "Error adding %s to watchlist of chat %d: %v", agent.Name, chatID, err)
+			reply = "❌ Unable to update the watchlist right now."
+		case added:
+			reply = fmt.Sprintf("👀 %s added to this chat's watchlist. Changes will be posted here.", agent.Name)
+		default:
+			reply = fmt.Sprintf("ℹ️ %s is already on this chat's watchlist.", agent.Name)
+		}
+	case "remove":
+		entry, err := watchlists.Remove(botName, chatID, query)
+		switch {
+		case errors.Is(err, storage.ErrNotFound):
+			reply = fmt.Sprintf("ℹ️ Nothing on the watchlist matches '%s'.", query)
+		case err != nil:
+			trace.Logf(ctx, logger, "Error removing %s from watchlist of chat %d: %v", query, chatID, err)
+			reply = "❌ Unable to update the watchlist right now."
+		default:
+			reply = fmt.Sprintf("🗑 %s removed from this chat's watchlist.", entry.Name)
+		}
+	default:
+		reply = teamWatchUsage
+	}
+
+	bot.Send(tgbotapi.NewMessage(chatID, reply))
+}
+
+// formatWatchlist lists watched agents with their latest stored price
+func formatWatchlist(ctx context.Context, store *storage.AgentStore, entries []storage.WatchEntry) string {
+	if len(entries) == 0 {
+		return "👀 This chat's watchlist is empty.\n\n" + teamWatchUsage
+	}
+
+	prices := make(map[string]string)
+	if index, err := store.GetIndexContext(ctx); err == nil {
+		for _, summary := range index.Agents {
+			prices[summary.Name] = summary.Price
+		}
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "👀 Watchlist (%d):\n", len(entries))
+	for _, entry := range entries {
+		fmt.Fprintf(&b, "\n• %s", entry.Name)
+		if price := prices[entry.Name]; price != "" {
+			fmt.Fprintf(&b, " - %s", price)
+		}
+		if entry.AddedBy != "" {
+			fmt.Fprintf(&b, " (added by @%s)", entry.AddedBy)
+		}
+	}
+	return b.String()
+}
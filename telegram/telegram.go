@@ -2,6 +2,7 @@ package telegram
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log"
 	"math/rand"
@@ -14,103 +15,356 @@ import (
 	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
 	"anondd/llm"
 	"anondd/utils"
+	"anondd/utils/events"
 	"anondd/utils/models"
+	"anondd/utils/rag"
 	"anondd/utils/storage"
+	"anondd/utils/trace"
 )
 
-// StartBot starts the Telegram bot with utils manager support.
-func StartBot(ctx context.Context, botToken string, openRouterClient *llm.OpenRouterClient, utils *utils.UtilsManager, logger *log.Logger) error {
+// StartBot starts the Telegram bot described by config with utils manager support.
+// Several bots may run at once; they share the store and scraper.
+func StartBot(ctx context.Context, config BotConfig, openRouterClient *llm.OpenRouterClient, utils *utils.UtilsManager, logger *log.Logger) error {
 	// Initialize the Telegram bot.
-	bot, err := tgbotapi.NewBotAPI(botToken)
+	api, err := tgbotapi.NewBotAPI(config.Token)
 	if err != nil {
 		return err
 	}
-	bot.Debug = true
-	logger.Printf("Authorized on account %s", bot.Self.UserName)
+	api.Debug = true
+	// Handlers and their replies keep running through a graceful drain after
+	// ctx is cancelled; runCtx is cancelled once the drain ends
+	runCtx, stopRun := withDrain(ctx)
+	defer stopRun()
+	bot := newBot(runCtx, api, logger)
+	logger.Printf("[%s] Authorized on account %s", config.Name, bot.Self.UserName)
+
+	digestInterval := defaultDigestInterval
+	if config.DigestMinutes != 0 {
+		digestInterval = time.Duration(max(config.DigestMinutes, 0)) * time.Minute
+	}
+	notifier := newNotifier(bot, config.Name, utils.GetQuietHours(), utils.GetProfiles(), digestInterval, logger)
+	go notifier.run(ctx)
+
+	if config.AnnounceChatID != 0 {
+		announcer := newAnnouncer(notifier, utils.GetStore(), utils.GetChatSettings(), openRouterClient, config.AnnounceChatID, logger)
+		utils.GetScraper().AddScrapeHook(announcer.announceNew)
+		logger.Printf("[%s] Announcing new agents to chat %d", config.Name, config.AnnounceChatID)
+	}
+
+	if config.ReportChatID != 0 && utils.GetReporter() != nil {
+		utils.GetReporter().AddPublishHook(reportPublisher(notifier, config.ReportChatID, logger))
+		logger.Printf("[%s] Publishing weekly reports to chat %d", config.Name, config.ReportChatID)
+	}
+
+	if config.AdminChatID != 0 {
+		utils.GetScraper().AddLayoutHook(layoutAlert(bot, config.AdminChatID, logger))
+		utils.GetScraper().AddSelfTestHook(selfTestAlert(bot, config.AdminChatID, logger))
+		logger.Printf("[%s] Sending layout and self-test alerts to chat %d", config.Name, config.AdminChatID)
+		if digester := utils.GetParseDigester(); digester != nil {
+			digester.AddReportHook(parseDigestAlert(bot, config.AdminChatID, logger))
+		}
+	}
+
+	alerter := newAlerter(notifier, utils.GetAlertSubscribers(), utils.GetStore(), utils.GetChatSettings())
+	events.Subscribe(utils.GetEvents(), alerter.send)
+	watchAlerter := newWatchAlerter(notifier, utils.GetWatchlists())
+	events.Subscribe(utils.GetEvents(), watchAlerter.send)
+	events.Subscribe(utils.GetEvents(), watchAlerter.sendDeepDive)
 
 	// Configure the update receiver.
 	u := tgbotapi.NewUpdate(0)
 	u.Timeout = 60
 	updates := bot.GetUpdatesChan(u)
 
-	// Process incoming updates (messages) until context is cancelled
+	// Dispatch incoming updates to the worker pool until context is cancelled
+	handle := func(update tgbotapi.Update) {
+		handleUpdate(runCtx, bot, update, config, openRouterClient, utils, logger)
+	}
+	dispatcher := newDispatcher(handle, config.UpdateWorkers, config.MaxPendingPerChat, logger)
 	for {
 		select {
 		case update := <-updates:
-			if update.Message != nil {
-				handleCommand(bot, update, utils, openRouterClient, logger)
+			// Telegram gives a checkout only seconds to be answered, so it
+			// never waits behind the chat's other updates
+			if update.PreCheckoutQuery != nil {
+				go handlePreCheckout(bot, update.PreCheckoutQuery, config, logger)
+				continue
 			}
+			dispatcher.dispatch(update)
 		case <-ctx.Done():
-			logger.Println("Shutting down Telegram bot...")
+			logger.Printf("[%s] Shutting down Telegram bot...", config.Name)
+			bot.StopReceivingUpdates()
+			if !dispatcher.shutdown(updateDrainTimeout) {
+				logger.Printf("[%s] Gave up waiting for updates after %s", config.Name, updateDrainTimeout)
+			}
+			// Replies and alerts queued by the drained handlers still go out
+			if !bot.queue.flush(sendDrainTimeout) {
+				logger.Printf("[%s] Gave up on %d queued messages after %s", config.Name, bot.SendStats().Queued, sendDrainTimeout)
+			}
 			return nil
 		}
 	}
 }
 
-func handleCommand(bot *tgbotapi.BotAPI, update tgbotapi.Update, utilsManager *utils.UtilsManager, openRouterClient *llm.OpenRouterClient, logger *log.Logger) {
+// handleUpdate routes one update to its handler
+func handleUpdate(ctx context.Context, bot *Bot, update tgbotapi.Update, config BotConfig, openRouterClient *llm.OpenRouterClient, utils *utils.UtilsManager, logger *log.Logger) {
+	// Every update gets its own trace ID, carried into llm and storage calls
+	updateCtx := trace.WithID(ctx, trace.NewID())
+	if update.Message != nil && update.Message.SuccessfulPayment != nil {
+		handleSuccessfulPayment(bot, update, utils.GetEntitlements(), logger)
+	} else if update.InlineQuery != nil {
+		if !config.allows(inlineCommand) {
+			trace.Logf(updateCtx, logger, "[%s] Ignoring inline query, not enabled on this bot", config.Name)
+			return
+		}
+		handleInlineQuery(llm.WithCommand(updateCtx, "inline"), bot, update, utils.GetStore(), logger)
+	} else if update.CallbackQuery != nil {
+		if !config.allowsCallback(update.CallbackQuery.Data) {
+			if _, err := bot.Request(tgbotapi.NewCallback(update.CallbackQuery.ID, "🚫 That isn't available on this bot.")); err != nil {
+				trace.Logf(updateCtx, logger, "Error answering callback: %v", err)
+			}
+			return
+		}
+		if handleOnboardingCallback(updateCtx, bot, update.CallbackQuery, config.Name, utils, logger) {
+			return
+		}
+		if handleSelectorCallback(updateCtx, bot, update.CallbackQuery, utils.GetParseDigester(), logger) {
+			return
+		}
+		persona := ""
+		if update.CallbackQuery.Message != nil {
+			persona = chatPersona(utils.GetPersonaStore(), config, update.CallbackQuery.Message.Chat.ID)
+			updateCtx = llm.WithChat(updateCtx, update.CallbackQuery.Message.Chat.ID, config.Jurisdiction)
+		}
+		handleCallbackQuery(llm.WithCommand(updateCtx, "callback"), bot, update, config, utils.GetStore(), utils.GetFeedbackStore(), utils.GetEntitlements(), utils.GetOnChain(), persona, openRouterClient, logger)
+	} else if update.Message != nil {
+		if update.Message.Voice != nil {
+			if !transcribeVoice(updateCtx, bot, &update, utils.GetSpeech(), logger) {
+				return
+			}
+			updateCtx = withVoiceReply(updateCtx, utils.GetSpeech())
+		}
+		trace.Logf(updateCtx, logger, "[%s] Message from chat %d: %s", config.Name, update.Message.Chat.ID, update.Message.Text)
+		updateCtx = llm.WithChat(updateCtx, update.Message.Chat.ID, config.Jurisdiction)
+		handleCommand(updateCtx, bot, update, config, utils, openRouterClient, logger)
+	}
+}
+
+func handleCommand(ctx context.Context, bot *Bot, update tgbotapi.Update, config BotConfig, utilsManager *utils.UtilsManager, openRouterClient *llm.OpenRouterClient, logger *log.Logger) {
 	message := update.Message
 	parts := strings.Fields(message.Text)
+	if len(parts) == 0 {
+		return
+	}
 	command := parts[0]
 
-	// Get store from utils manager
+	if strings.HasPrefix(command, "/") && !config.allows(command) {
+		bot.Send(tgbotapi.NewMessage(message.Chat.ID, "🚫 That command isn't available on this bot."))
+		return
+	}
+
+	if allowed, notify := allowMessage(ctx, utilsManager.GetShared(), config, message.Chat.ID, logger); !allowed {
+		if notify {
+			bot.Send(tgbotapi.NewMessage(message.Chat.ID, "🐢 Slow down a little, try again in a minute."))
+		}
+		return
+	}
+
+	// Commands the bot gates behind premium
+	entitlements := utilsManager.GetEntitlements()
+	if strings.HasPrefix(command, "/") && config.premiumCommand(command) && !requirePremium(bot, update, config, entitlements) {
+		return
+	}
+
+	// Attribute LLM usage to the command, or to chat for plain messages
+	if strings.HasPrefix(command, "/") {
+		ctx = llm.WithCommand(ctx, command)
+	} else {
+		ctx = llm.WithCommand(ctx, "chat")
+	}
+	ctx = withReplyStyle(ctx, utilsManager.GetProfiles(), utilsManager.GetChatSettings(), message.Chat.ID, message.From)
+
+	// Get stores from utils manager
 	store := utilsManager.GetStore()
+	personas := utilsManager.GetPersonaStore()
+
+	persona := withLanguage(chatPersona(personas, config, message.Chat.ID), utilsManager.GetProfiles(), message.From)
+	filter := chatFilter(utilsManager.GetChatSettings(), message.Chat.ID)
+	visit := touchChat(ctx, utilsManager.GetLastSeen(), config.Name, message.Chat.ID, logger)
 
 	switch command {
+	case "/start":
+		handleStart(ctx, bot, update, utilsManager.GetProfiles(), logger)
 	case "/scrape_agents":
-		handleScrapeAgents(bot, update, store, openRouterClient, logger)
-	case "/give_dd":
+		// Admins get a fresh scrape (or a dry run) first; everyone else gets the stored data
+		if message.From != nil && isAdmin(message.From.ID) && len(parts) > 1 && parts[1] == "dry" {
+			handleDryRunScrape(ctx, bot, update, utilsManager.GetScraper(), parts[2:], logger)
+		} else if message.From != nil && isAdmin(message.From.ID) {
+			handleAdminScrape(ctx, bot, update, config, utilsManager.GetScraper(), parts[1:], store, filter, persona, openRouterClient, logger)
+		} else {
+			handleScrapeAgents(ctx, bot, update, config, store, filter, persona, openRouterClient, logger)
+		}
+	case "/give_dd", "/dd":
 		if len(parts) > 1 {
 			if agentID, err := strconv.Atoi(parts[1]); err == nil {
-				handleAgentDDScreenshot(bot, update, store, openRouterClient, agentID, logger)
+				// Deep DD renders and reads the agent's page, so it is premium
+				if !requirePremium(bot, update, config, entitlements) {
+					return
+				}
+				handleAgentDDScreenshot(ctx, bot, update, store, openRouterClient, agentID, logger)
 			} else {
-				handleAgentDD(bot, update, store, openRouterClient, strings.Join(parts[1:], " "), logger)
+				handleAgentDD(ctx, bot, update, store, openRouterClient, strings.Join(parts[1:], " "), logger)
 			}
-		} else {
-			handleRandomAgentDD(bot, update, store, openRouterClient, logger)
+		} else if !handleFavoritesDD(ctx, bot, update, store, utilsManager.GetProfiles(), logger) {
+			handleRandomAgentDD(ctx, bot, update, store, openRouterClient, logger)
 		}
+	case "/reset_failures":
+		handleResetFailures(bot, update, store, parts[1:], logger)
+	case "/reparse_all":
+		handleReparseAll(bot, update, utilsManager.GetScraper(), logger)
+	case "/dead_letters":
+		handleDeadLetters(bot, update, utilsManager.GetScraper(), parts[1:], logger)
+	case "/requeue":
+		handleRequeue(bot, update, utilsManager.GetScraper(), parts[1:], logger)
+	case "/index_versions":
+		handleIndexVersions(bot, update, store, logger)
+	case "/rollback_index":
+		handleRollbackIndex(bot, update, store, parts[1:], logger)
+	case "/accept_layout":
+		handleAcceptLayout(bot, update, utilsManager.GetScraper(), logger)
+	case "/parse_digest":
+		handleParseDigest(llm.WithCommand(ctx, "parse_digest"), bot, update, utilsManager.GetParseDigester(), parts[1:], logger)
+	case "/gc_agents":
+		handleGCAgents(bot, update, store, parts[1:], logger)
+	case "/reset_session":
+		handleResetSession(bot, update, parts[1:], logger)
+	case "/persona":
+		handlePersona(bot, update, personas, parts[1:], logger)
+	case "/ask":
+		handleAsk(ctx, bot, update, config.Name, store, utilsManager.GetConversations(), openRouterClient, parts[1:], logger)
+	case "/search":
+		handleSearch(ctx, bot, update, utilsManager.GetSearch(), parts[1:], logger)
+	case "/export_chat":
+		handleExportChat(ctx, bot, update, config.Name, utilsManager.GetConversations(), logger)
+	case "/forget":
+		handleForget(bot, update, config.Name, utilsManager.GetConversations(), logger)
+	case "/predict":
+		handlePredict(ctx, bot, update, store, openRouterClient, persona, parts[1:], logger)
+	case "/chart":
+		handleChart(ctx, bot, update, store, parts[1:], logger)
+	case "/fresh":
+		handleFresh(ctx, bot, update, store, filter, parts[1:], logger)
+	case "/upcoming":
+		handleUpcoming(ctx, bot, update, store, filter, logger)
+	case "/whatsnew":
+		handleWhatsNew(ctx, bot, update, config.Name, store, utilsManager.GetWatchlists(), filter, visit, parts[1:], logger)
+	case "/alerts":
+		handleAlerts(bot, update, config.Name, utilsManager.GetAlertSubscribers(), parts[1:], logger)
+	case "/apikey":
+		handleAPIKey(bot, update, config.Name, utilsManager.GetUserKeys(), parts[1:], logger)
+	case "/fav":
+		handleFav(ctx, bot, update, store, utilsManager.GetProfiles(), parts[1:], logger)
+	case "/teamwatch":
+		watchLimit := 0
+		if !isPremium(config, entitlements, message.From) {
+			watchLimit = config.freeWatchLimit()
+		}
+		handleTeamWatch(ctx, bot, update, config.Name, store, utilsManager.GetWatchlists(), watchLimit, parts[1:], logger)
+	case "/keywords":
+		handleKeywords(ctx, bot, update, config.Name, store, utilsManager.GetKeywords(), parts[1:], logger)
+	case "/filters":
+		handleFilters(bot, update, utilsManager.GetChatSettings(), parts[1:], logger)
+	case "/style":
+		handleStyle(bot, update, utilsManager.GetProfiles(), utilsManager.GetChatSettings(), parts[1:], logger)
+	case "/quiet":
+		handleQuiet(bot, update, utilsManager.GetQuietHours(), parts[1:], logger)
+	case "/stats":
+		handleStats(ctx, bot, update, store, openRouterClient, logger)
+	case "/costs":
+		handleCosts(bot, update, utilsManager.GetLLMUsage(), parts[1:])
+	case "/premium":
+		handlePremium(bot, update, config, entitlements, parts[1:], logger)
+	case "/premium_admin":
+		handlePremiumAdmin(bot, update, entitlements, parts[1:], logger)
+	case "/ab_stats":
+		handleFeedbackStats(bot, update, utilsManager.GetFeedbackStore())
+	case "/news":
+		handleNews(ctx, bot, update, store, parts[1:], logger)
+	case "/pipelines":
+		handleListPipelines(bot, update, utilsManager.GetPipelines())
+	case "/plugins":
+		handleListPlugins(bot, update, utilsManager.GetPlugins())
 	default:
-		handleRegularMessage(bot, update, openRouterClient, logger)
+		// Plugins add commands; built-in commands above take precedence
+		if p, ok := utilsManager.GetPlugins().Lookup(command); ok {
+			handlePlugin(ctx, bot, update, config, store, openRouterClient, p, parts[1:], logger)
+			return
+		}
+		if engine := utilsManager.GetPipelines(); engine != nil {
+			if p, ok := engine.ForCommand(command); ok {
+				handlePipeline(ctx, bot, update, config, store, engine, openRouterClient, persona, p.Name, strings.Join(parts[1:], " "), logger)
+				return
+			}
+		}
+		if handleAskFollowUp(ctx, bot, update, config.Name, store, utilsManager.GetConversations(), openRouterClient, logger) {
+			return
+		}
+		if handleKeywordMention(ctx, bot, update, config.Name, store, utilsManager.GetKeywords(), utilsManager.GetShared(), logger) {
+			return
+		}
+		if strings.Contains(message.Text, "?") && handleQuestion(ctx, bot, update, store, utilsManager.GetRetriever(), persona, openRouterClient, logger) {
+			return
+		}
+		handleRegularMessage(ctx, bot, update, config.Name, store, utilsManager.GetConversations(), persona, openRouterClient, logger)
 	}
 }
 
-func handleScrapeAgents(bot *tgbotapi.BotAPI, update tgbotapi.Update, store *storage.AgentStore, client *llm.OpenRouterClient, logger *log.Logger) {
+func handleScrapeAgents(ctx context.Context, bot *Bot, update tgbotapi.Update, config BotConfig, store *storage.AgentStore, filter models.AgentFilter, persona string, client *llm.OpenRouterClient, logger *log.Logger) {
 	chatID := update.Message.Chat.ID
 
 	msg := tgbotapi.NewMessage(chatID, "🔍 Analyzing stored agent data...")
 	bot.Send(msg)
 
-	index, err := store.GetIndex()
+	index, err := store.GetIndexContext(ctx)
 	if err != nil {
 		bot.Send(tgbotapi.NewMessage(chatID, "Error accessing agent data"))
 		return
 	}
 
-	var agentInfo strings.Builder
-	agentInfo.WriteString("Current Agents Overview:\n\n")
-
+	// Summarize in chunks so large indexes don't overflow the context window
+	var agentInfo []string
 	for _, summary := range index.Agents {
-		if agent, err := store.GetAgent(summary.ID); err == nil {
-			agentInfo.WriteString(fmt.Sprintf("Name: %s\nPrice: %s\nStats: %s\n\n",
-				agent.Name, agent.Price, agent.Stats))
+		if agent, err := store.GetAgentContext(ctx, summary.ID); err == nil && filter.Allows(agent) {
+			agentInfo = append(agentInfo, fmt.Sprintf("Name: %s\nPrice: %s\nStats: %s\n",
+				agent.Name, agent.Price, agent.StatsText()))
 		}
 	}
 
-	ctx := context.Background()
-	prompt := fmt.Sprintf("Analyze these AI agents and give a brief market analysis: %s", agentInfo.String())
-	analysis, err := client.GetResponse(ctx, "custom", prompt)
-	if err != nil {
-		logger.Printf("Error getting AI analysis: %v", err)
-		analysis = "Unable to analyze agents at this time."
+	analysis := "No agent data available to analyze."
+	if len(agentInfo) > 0 {
+		analysis, err = client.MapReduce(ctx, agentInfo, llm.DefaultMapReduceOptions())
+		if err != nil {
+			trace.Logf(ctx, logger, "Error getting AI analysis: %v", err)
+			analysis = "Unable to analyze agents at this time."
+		} else {
+			analysis = client.PostProcess(ctx, "market_overview", persona, analysis)
+			analysis = shareLongText(ctx, store, config, "Market overview", analysis, logger)
+		}
 	}
 
 	response := fmt.Sprintf("📊 Found %d agents\n\n%s", len(index.Agents), analysis)
-	bot.Send(tgbotapi.NewMessage(chatID, response))
+	if !filter.Empty() {
+		response = fmt.Sprintf("📊 Found %d agents, %d matching %s\n\n%s", len(index.Agents), len(agentInfo), filter, analysis)
+	}
+	reply := tgbotapi.NewMessage(chatID, response)
+	reply.ReplyMarkup = withMentions(ctx, store, nil, analysis, "", logger)
+	bot.Send(reply)
 }
 
-func handleAgentDD(bot *tgbotapi.BotAPI, update tgbotapi.Update, store *storage.AgentStore, client *llm.OpenRouterClient, agentName string, logger *log.Logger) {
+func handleAgentDD(ctx context.Context, bot *Bot, update tgbotapi.Update, store *storage.AgentStore, client *llm.OpenRouterClient, agentName string, logger *log.Logger) {
 	chatID := update.Message.Chat.ID
 
-	index, err := store.GetIndex()
+	index, err := store.GetIndexContext(ctx)
 	if err != nil {
 		bot.Send(tgbotapi.NewMessage(chatID, "Error accessing agent data"))
 		return
@@ -119,7 +373,7 @@ func handleAgentDD(bot *tgbotapi.BotAPI, update tgbotapi.Update, store *storage.
 	var targetAgent *models.Agent
 	for _, summary := range index.Agents {
 		if strings.Contains(strings.ToLower(summary.Name), strings.ToLower(agentName)) {
-			if agent, err := store.GetAgent(summary.ID); err == nil {
+			if agent, err := store.GetAgentContext(ctx, summary.ID); err == nil {
 				targetAgent = agent
 				break
 			}
@@ -131,21 +385,14 @@ func handleAgentDD(bot *tgbotapi.BotAPI, update tgbotapi.Update, store *storage.
 		return
 	}
 
-	prompt := fmt.Sprintf("Analyze this AI agent in detail:\nName: %s\nPrice: %s\nStats: %s\nDescription: %s",
-		targetAgent.Name, targetAgent.Price, targetAgent.Stats, targetAgent.Description)
-
-	analysis, err := client.GetResponse(context.Background(), "agent_analysis", prompt)
-	if err != nil {
-		logger.Printf("Error getting agent analysis: %v", err)
-		bot.Send(tgbotapi.NewMessage(chatID, "Unable to analyze agent at this time."))
-		return
+	msg := tgbotapi.NewMessage(chatID, fmt.Sprintf("🤖 %s found. How deep should I dig?", targetAgent.Name))
+	msg.ReplyMarkup = ddDepthKeyboard(targetAgent.ID)
+	if _, err := bot.Send(msg); err != nil {
+		trace.Logf(ctx, logger, "Error sending DD depth selection: %v", err)
 	}
-
-	response := fmt.Sprintf("🤖 Analysis for %s:\n\n%s", targetAgent.Name, analysis)
-	bot.Send(tgbotapi.NewMessage(chatID, response))
 }
 
-func handleAgentDDScreenshot(bot *tgbotapi.BotAPI, update tgbotapi.Update, store *storage.AgentStore, client *llm.OpenRouterClient, agentID int, logger *log.Logger) {
+func handleAgentDDScreenshot(ctx context.Context, bot *Bot, update tgbotapi.Update, store *storage.AgentStore, client *llm.OpenRouterClient, agentID int, logger *log.Logger) {
 	chatID := update.Message.Chat.ID
 
 	// Loading texts
@@ -174,7 +421,7 @@ func handleAgentDDScreenshot(bot *tgbotapi.BotAPI, update tgbotapi.Update, store
 	debugDir := "training_data/raw/debug"
 	files, err := os.ReadDir(debugDir)
 	if err != nil {
-		logger.Printf("Error reading debug directory: %v", err)
+		trace.Logf(ctx, logger, "Error reading debug directory: %v", err)
 		bot.Send(tgbotapi.NewMessage(chatID, "❌ Unable to read debug directory."))
 		return
 	}
@@ -209,18 +456,18 @@ func handleAgentDDScreenshot(bot *tgbotapi.BotAPI, update tgbotapi.Update, store
 	bot.Send(tgbotapi.NewMessage(chatID, funMessage))
 }
 
-func handleRandomAgentDD(bot *tgbotapi.BotAPI, update tgbotapi.Update, store *storage.AgentStore, client *llm.OpenRouterClient, logger *log.Logger) {
+func handleRandomAgentDD(ctx context.Context, bot *Bot, update tgbotapi.Update, store *storage.AgentStore, client *llm.OpenRouterClient, logger *log.Logger) {
 	// Pick a random agent ID between 0 and 100
 	rand.Seed(time.Now().UnixNano())
 	agentID := rand.Intn(101)
 
-	handleAgentDDScreenshot(bot, update, store, client, agentID, logger)
+	handleAgentDDScreenshot(ctx, bot, update, store, client, agentID, logger)
 }
 
-func handleTopAgentsDD(bot *tgbotapi.BotAPI, update tgbotapi.Update, store *storage.AgentStore, client *llm.OpenRouterClient, logger *log.Logger) {
+func handleTopAgentsDD(ctx context.Context, bot *Bot, update tgbotapi.Update, store *storage.AgentStore, client *llm.OpenRouterClient, logger *log.Logger) {
 	chatID := update.Message.Chat.ID
 
-	index, err := store.GetIndex()
+	index, err := store.GetIndexContext(ctx)
 	if err != nil {
 		bot.Send(tgbotapi.NewMessage(chatID, "Error accessing agent data"))
 		return
@@ -238,20 +485,57 @@ func handleTopAgentsDD(bot *tgbotapi.BotAPI, update tgbotapi.Update, store *stor
 		agentInfo.WriteString(fmt.Sprintf("%d. %s - %s\n", i+1, summary.Name, summary.Price))
 	}
 
-	analysis, err := client.GetResponse(context.Background(), "agent_analysis", agentInfo.String())
+	analysis, err := client.GetResponse(ctx, "agent_analysis", agentInfo.String())
 	if err != nil {
-		logger.Printf("Error getting market analysis: %v", err)
+		trace.Logf(ctx, logger, "Error getting market analysis: %v", err)
 		bot.Send(tgbotapi.NewMessage(chatID, "Unable to analyze market at this time."))
 		return
 	}
+	analysis = client.PostProcess(ctx, "agent_analysis", "", analysis)
+
+	response := tgbotapi.NewMessage(chatID, fmt.Sprintf("📊 Market Analysis\n\n%s", analysis))
+	response.ReplyMarkup = withMentions(ctx, store, nil, analysis, "", logger)
+	bot.Send(response)
+}
+
+// handleQuestion answers free-form questions from stored agent data with citations.
+// It returns false when the question names no agent and matches none closely,
+// so small talk falls through to the regular chat reply.
+func handleQuestion(ctx context.Context, bot *Bot, update tgbotapi.Update, store *storage.AgentStore, retriever *rag.Retriever, persona string, client *llm.OpenRouterClient, logger *log.Logger) bool {
+	question := update.Message.Text
+
+	results, err := retriever.TopK(question, rag.DefaultTopK)
+	if err != nil {
+		trace.Logf(ctx, logger, "Error retrieving agents for question: %v", err)
+		return false
+	}
+	if len(results) == 0 {
+		return false
+	}
+
+	query := fmt.Sprintf("Agent data:\n%s\nQuestion: %s", rag.BuildContext(results), question)
+	answer, variant, err := client.GetResponseVariant(ctx, persona, "rag", query, strconv.FormatInt(update.Message.Chat.ID, 10))
+	if err != nil {
+		trace.Logf(ctx, logger, "Error answering question from agent data: %v", err)
+		answer = "I'm sorry, something went wrong while processing your request."
+	} else {
+		answer = client.PostProcess(ctx, "rag", "", answer)
+	}
 
-	response := fmt.Sprintf("📊 Market Analysis\n\n%s", analysis)
-	bot.Send(tgbotapi.NewMessage(chatID, response))
+	reply := tgbotapi.NewMessage(update.Message.Chat.ID, fmt.Sprintf("%s\n\n%s", answer, rag.Citations(results)))
+	if err == nil {
+		keyboard := feedbackKeyboard("rag", variant)
+		reply.ReplyMarkup = withMentions(ctx, store, &keyboard, answer, "", logger)
+	}
+	if _, err := bot.Send(reply); err != nil {
+		trace.Logf(ctx, logger, "Error sending message: %v", err)
+	}
+	sendVoiceReply(ctx, bot, update.Message.Chat.ID, answer, logger)
+	return true
 }
 
-func handleRegularMessage(bot *tgbotapi.BotAPI, update tgbotapi.Update, client *llm.OpenRouterClient, logger *log.Logger) {
+func handleRegularMessage(ctx context.Context, bot *Bot, update tgbotapi.Update, botName string, store *storage.AgentStore, conversations *storage.ConversationStore, persona string, client *llm.OpenRouterClient, logger *log.Logger) {
 	userQuery := update.Message.Text
-	ctx := context.Background()
 
 	parts := strings.SplitN(userQuery, " ", 2)
 	promptKey := "default"
@@ -260,16 +544,33 @@ func handleRegularMessage(bot *tgbotapi.BotAPI, update tgbotapi.Update, client *
 		userQuery = parts[1]
 	}
 
-	openRouterResponse, err := client.GetResponse(ctx, promptKey, userQuery)
-	if err != nil {
-		logger.Printf("Error retrieving response from OpenRouter: %v", err)
+	// Chats with a persona get it as the system message instead of the default prompt
+	if _, known := client.Prompts[promptKey]; persona != "" && (!known || promptKey == "default") {
+		promptKey = "persona_chat"
+		userQuery = update.Message.Text
+	}
+
+	openRouterResponse, variant, err := client.GetResponseVariant(ctx, persona, promptKey, userQuery, strconv.FormatInt(update.Message.Chat.ID, 10))
+	if errors.Is(err, llm.ErrCircuitOpen) {
+		// The fallback response explains the outage
+		trace.Logf(ctx, logger, "LLM circuit open, replying with fallback")
+	} else if err != nil {
+		trace.Logf(ctx, logger, "Error retrieving response from OpenRouter: %v", err)
 		openRouterResponse = "I'm sorry, something went wrong while processing your request."
+	} else {
+		openRouterResponse = client.PostProcess(ctx, promptKey, "", openRouterResponse)
+		recordTurn(ctx, conversations, botName, update.Message, storage.ConversationTurn{Kind: storage.TurnChat, Message: update.Message.Text, Reply: openRouterResponse}, logger)
 	}
 
 	reply := tgbotapi.NewMessage(update.Message.Chat.ID, openRouterResponse)
+	if err == nil {
+		keyboard := feedbackKeyboard(promptKey, variant)
+		reply.ReplyMarkup = withMentions(ctx, store, &keyboard, openRouterResponse, "", logger)
+	}
 	if _, err := bot.Send(reply); err != nil {
-		logger.Printf("Error sending message: %v", err)
+		trace.Logf(ctx, logger, "Error sending message: %v", err)
 	}
+	sendVoiceReply(ctx, bot, update.Message.Chat.ID, openRouterResponse, logger)
 }
 
 func min(a, b int) int {
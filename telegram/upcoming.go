@@ -0,0 +1,56 @@
+package telegram
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+
+	"anondd/utils/models"
+	"anondd/utils/storage"
+	"anondd/utils/trace"
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// maxUpcomingAgents caps the agents listed by /upcoming
+const maxUpcomingAgents = 20
+
+// handleUpcoming implements /upcoming: agents on their bonding curve that are
+// about to graduate, furthest along first, that pass the chat's filter
+func handleUpcoming(ctx context.Context, bot *Bot, update tgbotapi.Update, store *storage.AgentStore, filter models.AgentFilter, logger *log.Logger) {
+	chatID := update.Message.Chat.ID
+
+	agents, err := store.NearGraduation()
+	if err != nil {
+		trace.Logf(ctx, logger, "Error listing agents near graduation: %v", err)
+		bot.Send(tgbotapi.NewMessage(chatID, "Error accessing agent data"))
+		return
+	}
+	if !filter.Empty() {
+		allowed := agents[:0]
+		for _, summary := range agents {
+			if agent, err := store.GetAgentContext(ctx, summary.ID); err == nil && filter.Allows(agent) {
+				allowed = append(allowed, summary)
+			}
+		}
+		agents = allowed
+	}
+	if len(agents) == 0 {
+		bot.Send(tgbotapi.NewMessage(chatID, fmt.Sprintf("🎓 No agents past %.0f%% of their bonding curve right now.", models.NearGraduationProgress)))
+		return
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "🎓 %d agents about to graduate:\n\n", len(agents))
+	for i, agent := range agents {
+		if i == maxUpcomingAgents {
+			fmt.Fprintf(&b, "\n…and %d more", len(agents)-maxUpcomingAgents)
+			break
+		}
+		fmt.Fprintf(&b, "• %s (%s) — %s\n", agent.Name, agent.Price, models.FormatStage(agent.Stage, agent.BondingProgress))
+	}
+	b.WriteString("\n/alerts on to hear when they graduate")
+	if _, err := bot.Send(tgbotapi.NewMessage(chatID, b.String())); err != nil {
+		trace.Logf(ctx, logger, "Error sending upcoming agents: %v", err)
+	}
+}
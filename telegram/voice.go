@@ -0,0 +1,108 @@
+package telegram
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"time"
+
+	"anondd/utils/httpclient"
+	"anondd/utils/speech"
+	"anondd/utils/trace"
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// maxVoiceDuration caps the length of voice notes sent for transcription
+const maxVoiceDuration = 120 // seconds
+
+// voiceReplyKey marks a context whose reply should also be spoken
+type voiceReplyKey struct{}
+
+// withVoiceReply asks reply handlers to send TTS audio alongside their text
+func withVoiceReply(ctx context.Context, client *speech.Client) context.Context {
+	return context.WithValue(ctx, voiceReplyKey{}, client)
+}
+
+// transcribeVoice downloads a voice note and replaces the message text with its
+// transcript so it can go through the normal command and message pipeline.
+// It returns false when the note could not be transcribed or the bot has no
+// speech client.
+func transcribeVoice(ctx context.Context, bot *Bot, update *tgbotapi.Update, client *speech.Client, logger *log.Logger) bool {
+	message := update.Message
+	chatID := message.Chat.ID
+
+	// Without a speech client voice notes are ignored, like any other media
+	if client == nil {
+		return false
+	}
+	if message.Voice.Duration > maxVoiceDuration {
+		bot.Send(tgbotapi.NewMessage(chatID, fmt.Sprintf("🎙️ Voice notes are limited to %d seconds.", maxVoiceDuration)))
+		return false
+	}
+
+	fileURL, err := bot.GetFileDirectURL(message.Voice.FileID)
+	if err != nil {
+		trace.Logf(ctx, logger, "Error resolving voice file: %v", withoutURL(err))
+		bot.Send(tgbotapi.NewMessage(chatID, "❌ Couldn't download your voice note."))
+		return false
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, fileURL, nil)
+	if err != nil {
+		trace.Logf(ctx, logger, "Error creating voice download request: %v", err)
+		return false
+	}
+	resp, err := httpclient.WithTimeout(30 * time.Second).Do(req)
+	if err != nil || resp.StatusCode != http.StatusOK {
+		if err == nil {
+			resp.Body.Close()
+			err = fmt.Errorf("status %d", resp.StatusCode)
+		}
+		trace.Logf(ctx, logger, "Error downloading voice note: %v", withoutURL(err))
+		bot.Send(tgbotapi.NewMessage(chatID, "❌ Couldn't download your voice note."))
+		return false
+	}
+	defer resp.Body.Close()
+
+	transcript, err := client.Transcribe(ctx, "voice.ogg", resp.Body)
+	if err != nil || transcript == "" {
+		trace.Logf(ctx, logger, "Error transcribing voice note: %v", err)
+		bot.Send(tgbotapi.NewMessage(chatID, "❌ Couldn't make out that voice note."))
+		return false
+	}
+
+	trace.Logf(ctx, logger, "Transcribed voice note from chat %d: %s", chatID, transcript)
+	message.Text = transcript
+	return true
+}
+
+// withoutURL strips the request URL from an HTTP client error. Telegram file
+// and API URLs embed the bot token, which must not reach the logs.
+func withoutURL(err error) error {
+	var urlErr *url.Error
+	if errors.As(err, &urlErr) {
+		return fmt.Errorf("%s request failed: %w", urlErr.Op, urlErr.Err)
+	}
+	return err
+}
+
+// sendVoiceReply speaks text back when the request came from a voice note and TTS is on
+func sendVoiceReply(ctx context.Context, bot *Bot, chatID int64, text string, logger *log.Logger) {
+	client, _ := ctx.Value(voiceReplyKey{}).(*speech.Client)
+	if !client.TTSEnabled() {
+		return
+	}
+
+	audio, err := client.Synthesize(ctx, text)
+	if err != nil {
+		trace.Logf(ctx, logger, "Error synthesizing voice reply: %v", err)
+		return
+	}
+	voice := tgbotapi.NewVoice(chatID, tgbotapi.FileBytes{Name: "reply.ogg", Bytes: audio})
+	if _, err := bot.Send(voice); err != nil {
+		trace.Logf(ctx, logger, "Error sending voice reply: %v", err)
+	}
+}
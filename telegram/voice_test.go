@@ -0,0 +1,19 @@
+package telegram
+
+import (
+	"errors"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func TestWithoutURLDropsBotToken(t *testing.T) {
+	err := &url.Error{Op: "Get", URL: "https://api.telegram.org/file/bot123:SECRET/voice.ogg", Err: errors.New("connection refused")}
+	got := withoutURL(err).Error()
+	if strings.Contains(got, "SECRET") {
+		t.Errorf("withoutURL() = %q, still has the token", got)
+	}
+	if !strings.Contains(got, "connection refused") {
+		t.Errorf("withoutURL() = %q, lost the cause", got)
+	}
+}
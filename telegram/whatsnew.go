@@ -0,0 +1,192 @@
+package telegram
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"time"
+
+	"anondd/utils/models"
+	"anondd/utils/storage"
+	"anondd/utils/trace"
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+const (
+	// whatsNewFirstVisit is how far back /whatsnew looks for chats without
+	// an earlier visit
+	whatsNewFirstVisit = 24 * time.Hour
+
+	// maxWhatsNewLines caps each section of /whatsnew
+	maxWhatsNewLines = 10
+)
+
+// whatsNew is what changed for a chat over a window, straight from the
+// change log
+type whatsNew struct {
+	launched  []models.AgentSummary
+	watchlist []models.ChangeEvent
+	movers    []models.ChangeEvent
+}
+
+func (w whatsNew) empty() bool {
+	return len(w.launched) == 0 && len(w.watchlist) == 0 && len(w.movers) == 0
+}
+
+// touchChat records the message as the chat's latest interaction with the bot
+func touchChat(ctx context.Context, lastSeen *storage.LastSeenStore, botName string, chatID int64, logger *log.Logger) storage.ChatVisit {
+	if lastSeen == nil {
+		return storage.ChatVisit{}
+	}
+	visit, err := lastSeen.Touch(botName, chatID, time.Now())
+	if err != nil {
+		trace.Logf(ctx, logger, "Error recording last seen time for chat %d: %v", chatID, err)
+	}
+	return visit
+}
+
+// handleWhatsNew implements /whatsnew [age]: new agents, changes to the
+// chat's watchlist and other notable moves since the chat's previous visit,
+// or over the given age such as 3d or 12h
+func handleWhatsNew(ctx context.Context, bot *Bot, update tgbotapi.Update, botName string, store *storage.AgentStore, watchlists *storage.WatchlistStore, filter models.AgentFilter, visit storage.ChatVisit, args []string, logger *log.Logger) {
+	chatID := update.Message.Chat.ID
+	now := time.Now()
+	var since time.Time
+	var label string
+	switch {
+	case len(args) > 0:
+		age, err := models.ParseAge(args[0])
+		if err != nil {
+			bot.Send(tgbotapi.NewMessage(chatID, "Usage: /whatsnew [age] - changes since your last visit, or e.g. /whatsnew 3d"))
+			return
+		}
+		since, label = now.Add(-age), "in the last "+models.FormatAge(age)
+	case visit.Since.IsZero():
+		since, label = now.Add(-whatsNewFirstVisit), "in the last "+models.FormatAge(whatsNewFirstVisit)
+	default:
+		since = visit.Since
+		label = fmt.Sprintf("since you last looked (%s ago)", models.FormatAge(now.Sub(since)))
+	}
+
+	news, err := collectWhatsNew(ctx, store, watchlists, botName, chatID, filter, since)
+	if err != nil {
+		trace.Logf(ctx, logger, "Error collecting changes for chat %d: %v", chatID, err)
+		bot.Send(tgbotapi.NewMessage(chatID, "Error accessing agent data"))
+		return
+	}
+	if news.empty() {
+		bot.Send(tgbotapi.NewMessage(chatID, fmt.Sprintf("😴 Nothing changed %s.", label)))
+		return
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "🆕 What's new %s:\n", label)
+	if len(news.launched) > 0 {
+		fmt.Fprintf(&b, "\n🐣 %d new agents:\n", len(news.launched))
+		for i, agent := range news.launched {
+			if i == maxWhatsNewLines {
+				fmt.Fprintf(&b, "…and %d more, see /fresh\n", len(news.launched)-i)
+				break
+			}
+			fmt.Fprintf(&b, "• %s (%s)\n", agent.Name, agent.Price)
+		}
+	}
+	writeChangeSection(&b, "👀 Your watchlist", news.watchlist)
+	writeChangeSection(&b, "🚨 Other movers", news.movers)
+	if _, err := bot.Send(tgbotapi.NewMessage(chatID, b.String())); err != nil {
+		trace.Logf(ctx, logger, "Error sending what's new: %v", err)
+	}
+}
+
+// collectWhatsNew reads the change log since a time for a chat: agents
+// first seen, changes to watched agents, and anomalies, launch stage and
+// status changes of other agents that pass the chat's filter. Repeated
+// changes of one kind to an agent collapse into the latest.
+func collectWhatsNew(ctx context.Context, store *storage.AgentStore, watchlists *storage.WatchlistStore, botName string, chatID int64, filter models.AgentFilter, since time.Time) (whatsNew, error) {
+	var news whatsNew
+	agents := make(map[string]*models.Agent)
+	allowed := func(agentID string) bool {
+		if filter.Empty() {
+			return true
+		}
+		agent, loaded := agents[agentID]
+		if !loaded {
+			agent, _ = store.GetAgentContext(ctx, agentID)
+			agents[agentID] = agent
+		}
+		return agent != nil && filter.Allows(agent)
+	}
+
+	launched, err := store.NewAgentsSince(since)
+	if err != nil {
+		return news, err
+	}
+	for _, summary := range launched {
+		if allowed(summary.ID) {
+			news.launched = append(news.launched, summary)
+		}
+	}
+
+	changes, err := store.GetChanges(since)
+	if err != nil {
+		return news, err
+	}
+	seen := make(map[string]bool)
+	// Newest first, so the latest change of each kind is the one kept
+	for i := len(changes) - 1; i >= 0; i-- {
+		event := changes[i]
+		key := fmt.Sprintf("%d:%s:%s", event.SourceID, event.AgentID, event.Type)
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		switch {
+		case watchlists != nil && watchlists.Watches(botName, chatID, event.SourceID, event.AgentID):
+			news.watchlist = append(news.watchlist, event)
+		case notableChange(event) && allowed(event.AgentID):
+			news.movers = append(news.movers, event)
+		}
+	}
+	sort.SliceStable(news.movers, func(i, j int) bool {
+		return severityRank(news.movers[i]) > severityRank(news.movers[j])
+	})
+	return news, nil
+}
+
+// notableChange reports whether a change to an unwatched agent is worth
+// listing: anomalies, launch stage alerts and status changes
+func notableChange(event models.ChangeEvent) bool {
+	return models.IsAnomaly(event.Type) || models.IsStageAlert(event.Type) || event.Type == models.ChangeStatusChanged
+}
+
+// severityRank orders critical changes before normal and informational ones
+func severityRank(event models.ChangeEvent) int {
+	switch models.ChangeSeverity(event) {
+	case models.SeverityCritical:
+		return 2
+	case models.SeverityNormal:
+		return 1
+	}
+	return 0
+}
+
+// writeChangeSection appends a titled list of changes, newest first
+func writeChangeSection(b *strings.Builder, title string, changes []models.ChangeEvent) {
+	if len(changes) == 0 {
+		return
+	}
+	fmt.Fprintf(b, "\n%s:\n", title)
+	for i, event := range changes {
+		if i == maxWhatsNewLines {
+			fmt.Fprintf(b, "…and %d more\n", len(changes)-i)
+			break
+		}
+		if event.Type == models.ChangeDescriptionUpdated {
+			fmt.Fprintf(b, "• %s updated its bio\n", event.AgentName)
+			continue
+		}
+		fmt.Fprintf(b, "• %s: %s (%s → %s)\n", event.AgentName, event.Summary, event.Before, event.After)
+	}
+}
@@ -0,0 +1,127 @@
+package anomaly
+
+import (
+    "fmt"
+    "math"
+    "time"
+    "anondd/utils/models"
+    "anondd/utils/storage"
+)
+
+// Anomaly thresholds
+const (
+    HoldersGrowthThreshold = 0.5 // +50% holders versus ~24h ago
+    VolumeSpikeMultiplier  = 10  // 10x the rolling volume baseline
+    PriceMoveThreshold     = 0.5 // Price up or down 50% versus ~24h ago
+    holdersLookback        = 24 * time.Hour
+    priceLookback          = 24 * time.Hour
+    volumeBaselineWindow   = 7 * 24 * time.Hour
+    minBaselineSamples     = 3
+)
+
+// Detect compares the agent's current metrics to its prior history and
+// returns anomaly events for holder growth and volume spikes
+func Detect(agent *models.Agent, history []storage.MetricSnapshot, now time.Time) []models.ChangeEvent {
+    current := storage.SnapshotFromAgent(agent, now)
+    var events []models.ChangeEvent
+
+    if baseline, ok := holdersBaseline(history, now); ok && current.Holders > 0 {
+        growth := (current.Holders - baseline) / baseline
+        if growth >= HoldersGrowthThreshold {
+            events = append(events, newEvent(agent, models.ChangeHoldersSpike, now,
+                fmt.Sprintf("%.0f", baseline), fmt.Sprintf("%.0f", current.Holders),
+                fmt.Sprintf("Holders up %.0f%% in 24h", growth*100)))
+        }
+    }
+
+    if baseline, ok := volumeBaseline(history, now); ok && current.Volume24h > 0 {
+        if multiple := current.Volume24h / baseline; multiple >= VolumeSpikeMultiplier {
+            events = append(events, newEvent(agent, models.ChangeVolumeSpike, now,
+                fmt.Sprintf("%.0f", baseline), fmt.Sprintf("%.0f", current.Volume24h),
+                fmt.Sprintf("24h volume %.1fx its 7-day baseline", multiple)))
+        }
+    }
+
+    if baseline, before, ok := priceBaseline(history, now); ok {
+        if price, ok := models.ParseAmount(current.Price); ok && price > 0 {
+            if move := (price - baseline) / baseline; math.Abs(move) >= PriceMoveThreshold {
+                direction := "up"
+                if move < 0 {
+                    direction = "down"
+                }
+                events = append(events, newEvent(agent, models.ChangePriceMove, now,
+                    before, current.Price,
+                    fmt.Sprintf("Price %s %.0f%% in 24h", direction, math.Abs(move)*100)))
+            }
+        }
+    }
+
+    return events
+}
+
+// priceBaseline returns the price, parsed and as shown, from the latest
+// snapshot at least 24h old, or the oldest snapshot if history is shorter
+// than that
+func priceBaseline(history []storage.MetricSnapshot, now time.Time) (float64, string, bool) {
+    cutoff := now.Add(-priceLookback)
+    var baseline float64
+    var shown string
+    for _, snapshot := range history {
+        price, ok := models.ParseAmount(snapshot.Price)
+        if !ok || price <= 0 {
+            continue
+        }
+        if baseline == 0 || !snapshot.At.After(cutoff) {
+            baseline, shown = price, snapshot.Price
+        }
+    }
+    return baseline, shown, baseline > 0
+}
+
+// holdersBaseline returns the holder count from the latest snapshot at least
+// 24h old, or the oldest snapshot if history is shorter than that
+func holdersBaseline(history []storage.MetricSnapshot, now time.Time) (float64, bool) {
+    cutoff := now.Add(-holdersLookback)
+    var baseline float64
+    for _, snapshot := range history {
+        if snapshot.Holders <= 0 {
+            continue
+        }
+        if baseline == 0 || !snapshot.At.After(cutoff) {
+            baseline = snapshot.Holders
+        }
+    }
+    return baseline, baseline > 0
+}
+
+// volumeBaseline is the mean 24h volume across snapshots in the baseline window
+func volumeBaseline(history []storage.MetricSnapshot, now time.Time) (float64, bool) {
+    cutoff := now.Add(-volumeBaselineWindow)
+    var total float64
+    samples := 0
+    for _, snapshot := range history {
+        if snapshot.At.Before(cutoff) || snapshot.Volume24h <= 0 {
+            continue
+        }
+        total += snapshot.Volume24h
+        samples++
+    }
+    if samples < minBaselineSamples {
+        return 0, false
+    }
+    return total / float64(samples), true
+}
+
+func newEvent(agent *models.Agent, eventType string, at time.Time, before, after, summary string) models.ChangeEvent {
+    return models.ChangeEvent{
+        ID:        fmt.Sprintf("%d-%s-%d", agent.SourceID, eventType, at.UnixNano()),
+        AgentID:   agent.ID,
+        SourceID:  agent.SourceID,
+        AgentName: agent.Name,
+        Type:      eventType,
+        Before:    before,
+        After:     after,
+        Summary:   summary,
+        At:        at,
+    }
+}
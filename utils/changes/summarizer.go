@@ -0,0 +1,57 @@
+package changes
+
+import (
+    "context"
+    "fmt"
+    "log"
+    "strings"
+    "time"
+    "anondd/llm"
+    "anondd/utils/storage"
+)
+
+// summaryWindow bounds how far back unsummarized events are picked up
+const summaryWindow = 7 * 24 * time.Hour
+
+// Summarizer fills in LLM-written summaries for change events
+type Summarizer struct {
+    store  *storage.AgentStore
+    client *llm.OpenRouterClient
+    logger *log.Logger
+}
+
+// NewSummarizer creates a summarizer over the store's change feed
+func NewSummarizer(store *storage.AgentStore, client *llm.OpenRouterClient, logger *log.Logger) *Summarizer {
+    return &Summarizer{
+        store:  store,
+        client: client,
+        logger: logger,
+    }
+}
+
+// SummarizePending writes a summary for every recent event that lacks one
+func (s *Summarizer) SummarizePending(ctx context.Context) {
+    events, err := s.store.GetChanges(time.Now().Add(-summaryWindow))
+    if err != nil {
+        s.logger.Printf("Error loading change feed: %v", err)
+        return
+    }
+
+    for _, event := range events {
+        if event.Summary != "" {
+            continue
+        }
+
+        query := fmt.Sprintf("Agent: %s\nAdded:\n- %s\nRemoved:\n- %s",
+            event.AgentName, strings.Join(event.Added, "\n- "), strings.Join(event.Removed, "\n- "))
+        summary, err := s.client.GetResponse(ctx, "description_diff", query)
+        if err != nil {
+            s.logger.Printf("Error summarizing change %s: %v", event.ID, err)
+            continue
+        }
+
+        if err := s.store.SetChangeSummary(event.ID, summary); err != nil {
+            s.logger.Printf("Error saving summary for change %s: %v", event.ID, err)
+        }
+    }
+}
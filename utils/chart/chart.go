@@ -0,0 +1,250 @@
+package chart
+
+import (
+    "bytes"
+    "fmt"
+    "image"
+    "image/color"
+    "image/draw"
+    "image/png"
+    "math"
+    "strings"
+    "time"
+    "anondd/utils/storage"
+    "anondd/utils/trend"
+)
+
+// Chart sizes in pixels
+const (
+    Width  = 640
+    Height = 320
+)
+
+// Metrics that can be charted
+var Metrics = []string{"price", "holders", "mindshare", "mcap", "volume_24h"}
+
+// Ranges that can be charted, by name
+var Ranges = map[string]time.Duration{
+    "24h": 24 * time.Hour,
+    "7d":  7 * 24 * time.Hour,
+    "30d": 30 * 24 * time.Hour,
+}
+
+// DefaultMetric and DefaultRange are charted when none are given
+const (
+    DefaultMetric = "price"
+    DefaultRange  = "7d"
+)
+
+// minPoints is the fewest values that make a line
+const minPoints = 2
+
+// ErrNotEnoughData is returned when the range holds fewer than two values
+var ErrNotEnoughData = fmt.Errorf("not enough history to chart, need at least %d snapshots", minPoints)
+
+// Point is one charted value
+type Point struct {
+    At    time.Time
+    Value float64
+}
+
+// ValidMetric reports whether metric can be charted
+func ValidMetric(metric string) bool {
+    for _, m := range Metrics {
+        if m == metric {
+            return true
+        }
+    }
+    return false
+}
+
+// Series picks a metric's values from history within the range ending at now
+func Series(history []storage.MetricSnapshot, metric string, window time.Duration, now time.Time) []Point {
+    cutoff := now.Add(-window)
+    var points []Point
+    for _, snapshot := range history {
+        if snapshot.At.Before(cutoff) {
+            continue
+        }
+        if value, ok := trend.Value(metric, snapshot); ok {
+            points = append(points, Point{At: snapshot.At, Value: value})
+        }
+    }
+    return points
+}
+
+// Colors
+var (
+    background = color.RGBA{255, 255, 255, 255}
+    gridColor  = color.RGBA{232, 232, 236, 255}
+    axisColor  = color.RGBA{120, 120, 130, 255}
+    upColor    = color.RGBA{22, 163, 74, 255}
+    downColor  = color.RGBA{220, 38, 38, 255}
+)
+
+// Plot area margins
+const (
+    marginLeft   = 80
+    marginRight  = 16
+    marginTop    = 16
+    marginBottom = 32
+    gridLines    = 4
+)
+
+// Render draws points as a line chart PNG, green when the series ended
+// higher than it started and red otherwise, with value and date labels
+func Render(points []Point) ([]byte, error) {
+    if len(points) < minPoints {
+        return nil, ErrNotEnoughData
+    }
+
+    img := image.NewRGBA(image.Rect(0, 0, Width, Height))
+    draw.Draw(img, img.Bounds(), &image.Uniform{background}, image.Point{}, draw.Src)
+
+    low, high := points[0].Value, points[0].Value
+    for _, p := range points {
+        low, high = math.Min(low, p.Value), math.Max(high, p.Value)
+    }
+    if high == low {
+        pad := math.Max(math.Abs(high)*0.05, 1e-9)
+        low, high = low-pad, high+pad
+    }
+    start, end := points[0].At, points[len(points)-1].At
+    span := end.Sub(start)
+    if span <= 0 {
+        span = time.Second
+    }
+
+    plotW := Width - marginLeft - marginRight
+    plotH := Height - marginTop - marginBottom
+    x := func(at time.Time) int {
+        return marginLeft + int(float64(at.Sub(start))/float64(span)*float64(plotW-1))
+    }
+    y := func(v float64) int {
+        return marginTop + plotH - 1 - int((v-low)/(high-low)*float64(plotH-1))
+    }
+
+    // Grid and value labels
+    for i := 0; i <= gridLines; i++ {
+        gy := marginTop + i*(plotH-1)/gridLines
+        hLine(img, marginLeft, Width-marginRight, gy, gridColor)
+        value := high - float64(i)*(high-low)/gridLines
+        drawText(img, FormatValue(value), 4, gy-glyphHeight, axisColor)
+    }
+    vLine(img, marginLeft, marginTop, marginTop+plotH, axisColor)
+    hLine(img, marginLeft, Width-marginRight, marginTop+plotH-1, axisColor)
+
+    // Date labels
+    layout := "01/02"
+    if span <= 48*time.Hour {
+        layout = "15:04"
+    }
+    bottom := Height - marginBottom + 10
+    drawText(img, start.Format(layout), marginLeft, bottom, axisColor)
+    endLabel := end.Format(layout)
+    drawText(img, endLabel, Width-marginRight-textWidth(endLabel), bottom, axisColor)
+
+    // Series
+    line := upColor
+    if points[len(points)-1].Value < points[0].Value {
+        line = downColor
+    }
+    fill := color.RGBA{line.R, line.G, line.B, 40}
+    for i := 1; i < len(points); i++ {
+        x0, y0 := x(points[i-1].At), y(points[i-1].Value)
+        x1, y1 := x(points[i].At), y(points[i].Value)
+        fillUnder(img, x0, y0, x1, y1, marginTop+plotH-1, fill)
+        drawLine(img, x0, y0, x1, y1, line)
+        drawLine(img, x0, y0+1, x1, y1+1, line)
+    }
+
+    var buf bytes.Buffer
+    if err := png.Encode(&buf, img); err != nil {
+        return nil, fmt.Errorf("failed to encode chart: %w", err)
+    }
+    return buf.Bytes(), nil
+}
+
+// FormatValue abbreviates a value for axis labels and captions
+func FormatValue(v float64) string {
+    abs := math.Abs(v)
+    switch {
+    case abs >= 1e9:
+        return fmt.Sprintf("%.2fB", v/1e9)
+    case abs >= 1e6:
+        return fmt.Sprintf("%.2fM", v/1e6)
+    case abs >= 1e3:
+        return fmt.Sprintf("%.2fK", v/1e3)
+    case abs >= 1 || abs == 0:
+        return fmt.Sprintf("%.2f", v)
+    default:
+        return strings.TrimRight(fmt.Sprintf("%.6f", v), "0")
+    }
+}
+
+func hLine(img *image.RGBA, x0, x1, y int, c color.Color) {
+    for x := x0; x < x1; x++ {
+        img.Set(x, y, c)
+    }
+}
+
+func vLine(img *image.RGBA, x, y0, y1 int, c color.Color) {
+    for y := y0; y < y1; y++ {
+        img.Set(x, y, c)
+    }
+}
+
+// drawLine draws a segment with Bresenham's algorithm
+func drawLine(img *image.RGBA, x0, y0, x1, y1 int, c color.Color) {
+    dx, dy := abs(x1-x0), -abs(y1-y0)
+    sx, sy := 1, 1
+    if x0 > x1 {
+        sx = -1
+    }
+    if y0 > y1 {
+        sy = -1
+    }
+    err := dx + dy
+    for {
+        img.Set(x0, y0, c)
+        if x0 == x1 && y0 == y1 {
+            return
+        }
+        e2 := 2 * err
+        if e2 >= dy {
+            err += dy
+            x0 += sx
+        }
+        if e2 <= dx {
+            err += dx
+            y0 += sy
+        }
+    }
+}
+
+// fillUnder shades the area between a segment and the baseline, excluding
+// the segment's last column so adjacent segments don't shade it twice
+func fillUnder(img *image.RGBA, x0, y0, x1, y1, baseline int, c color.RGBA) {
+    for x := x0; x < x1; x++ {
+        top := y0
+        if x1 != x0 {
+            top = y0 + (y1-y0)*(x-x0)/(x1-x0)
+        }
+        for y := top + 1; y < baseline; y++ {
+            img.Set(x, y, blend(img.RGBAAt(x, y), c))
+        }
+    }
+}
+
+func blend(under, over color.RGBA) color.RGBA {
+    a := uint32(over.A)
+    mix := func(u, o uint8) uint8 { return uint8((uint32(o)*a + uint32(u)*(255-a)) / 255) }
+    return color.RGBA{mix(under.R, over.R), mix(under.G, over.G), mix(under.B, over.B), 255}
+}
+
+func abs(n int) int {
+    if n < 0 {
+        return -n
+    }
+    return n
+}
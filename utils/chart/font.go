@@ -0,0 +1,64 @@
+package chart
+
+import (
+    "image"
+    "image/color"
+)
+
+// Axis labels use a tiny built-in 3x5 pixel font scaled up, covering digits
+// and the few symbols value and date labels need
+const (
+    glyphScale   = 2
+    glyphColumns = 3
+    glyphRows    = 5
+    glyphWidth   = (glyphColumns + 1) * glyphScale // Including spacing
+    glyphHeight  = glyphRows * glyphScale
+)
+
+// glyphs maps a character to its rows, top first, using the low 3 bits of each
+var glyphs = map[rune][glyphRows]uint8{
+    '0': {0b111, 0b101, 0b101, 0b101, 0b111},
+    '1': {0b010, 0b110, 0b010, 0b010, 0b111},
+    '2': {0b111, 0b001, 0b111, 0b100, 0b111},
+    '3': {0b111, 0b001, 0b111, 0b001, 0b111},
+    '4': {0b101, 0b101, 0b111, 0b001, 0b001},
+    '5': {0b111, 0b100, 0b111, 0b001, 0b111},
+    '6': {0b111, 0b100, 0b111, 0b101, 0b111},
+    '7': {0b111, 0b001, 0b010, 0b010, 0b010},
+    '8': {0b111, 0b101, 0b111, 0b101, 0b111},
+    '9': {0b111, 0b101, 0b111, 0b001, 0b111},
+    '.': {0b000, 0b000, 0b000, 0b000, 0b010},
+    '-': {0b000, 0b000, 0b111, 0b000, 0b000},
+    ':': {0b000, 0b010, 0b000, 0b010, 0b000},
+    '/': {0b001, 0b001, 0b010, 0b100, 0b100},
+    '%': {0b101, 0b001, 0b010, 0b100, 0b101},
+    'K': {0b101, 0b110, 0b100, 0b110, 0b101},
+    'M': {0b101, 0b111, 0b111, 0b101, 0b101},
+    'B': {0b110, 0b101, 0b110, 0b101, 0b110},
+}
+
+func textWidth(text string) int {
+    return len([]rune(text)) * glyphWidth
+}
+
+// drawText draws text with its top-left corner at x, y; unknown characters
+// are left blank
+func drawText(img *image.RGBA, text string, x, y int, c color.Color) {
+    for _, r := range text {
+        if rows, ok := glyphs[r]; ok {
+            for row, bits := range rows {
+                for col := 0; col < glyphColumns; col++ {
+                    if bits&(1<<(glyphColumns-1-col)) == 0 {
+                        continue
+                    }
+                    for dy := 0; dy < glyphScale; dy++ {
+                        for dx := 0; dx < glyphScale; dx++ {
+                            img.Set(x+col*glyphScale+dx, y+row*glyphScale+dy, c)
+                        }
+                    }
+                }
+            }
+        }
+        x += glyphWidth
+    }
+}
@@ -0,0 +1,243 @@
+package clock
+
+import (
+    "sort"
+    "sync"
+    "time"
+)
+
+// Clock tells the time. Components that check staleness or run on a
+// schedule take one instead of calling time.Now, so tests can control time
+// and historical data can be replayed as if it were live.
+type Clock interface {
+    Now() time.Time
+    // NewTicker ticks every d of this clock's time
+    NewTicker(d time.Duration) Ticker
+    // After sends this clock's time once d of it has passed
+    After(d time.Duration) <-chan time.Time
+}
+
+// Ticker delivers ticks on C until stopped
+type Ticker interface {
+    C() <-chan time.Time
+    Stop()
+}
+
+// Since is the time elapsed on c since t
+func Since(c Clock, t time.Time) time.Duration {
+    return c.Now().Sub(t)
+}
+
+// Sleep waits for d of c's time
+func Sleep(c Clock, d time.Duration) {
+    <-c.After(d)
+}
+
+// Real is the wall clock
+func Real() Clock {
+    return realClock{}
+}
+
+type realClock struct{}
+
+func (realClock) Now() time.Time                         { return time.Now() }
+func (realClock) After(d time.Duration) <-chan time.Time { return time.After(d) }
+func (realClock) NewTicker(d time.Duration) Ticker       { return realTicker{time.NewTicker(d)} }
+
+type realTicker struct{ ticker *time.Ticker }
+
+func (t realTicker) C() <-chan time.Time { return t.ticker.C }
+func (t realTicker) Stop()               { t.ticker.Stop() }
+
+// Fake is a clock that only moves when told to, for tests. Tickers and
+// timers fire as Set or Advance moves past them.
+type Fake struct {
+    mu      sync.Mutex
+    now     time.Time
+    waiters []*fakeWaiter
+}
+
+// fakeWaiter is a pending timer, or a ticker when period is set
+type fakeWaiter struct {
+    at     time.Time
+    period time.Duration
+    c      chan time.Time
+}
+
+// NewFake creates a fake clock reading start
+func NewFake(start time.Time) *Fake {
+    return &Fake{now: start}
+}
+
+// Now returns the fake time
+func (f *Fake) Now() time.Time {
+    f.mu.Lock()
+    defer f.mu.Unlock()
+    return f.now
+}
+
+// After fires once Advance or Set moves d past the current fake time
+func (f *Fake) After(d time.Duration) <-chan time.Time {
+    f.mu.Lock()
+    defer f.mu.Unlock()
+    w := &fakeWaiter{at: f.now.Add(d), c: make(chan time.Time, 1)}
+    if d <= 0 {
+        w.c <- f.now
+        return w.c
+    }
+    f.waiters = append(f.waiters, w)
+    return w.c
+}
+
+// NewTicker ticks every d of fake time. Like time.Ticker it drops ticks a
+// slow receiver misses.
+func (f *Fake) NewTicker(d time.Duration) Ticker {
+    if d <= 0 {
+        panic("clock: non-positive interval for NewTicker")
+    }
+    f.mu.Lock()
+    defer f.mu.Unlock()
+    w := &fakeWaiter{at: f.now.Add(d), period: d, c: make(chan time.Time, 1)}
+    f.waiters = append(f.waiters, w)
+    return &fakeTicker{clock: f, waiter: w}
+}
+
+// Advance moves the fake time forward by d
+func (f *Fake) Advance(d time.Duration) {
+    f.Set(f.Now().Add(d))
+}
+
+// Set moves the fake time to t, firing every timer and ticker due by then in
+// order. Moving backwards fires nothing.
+func (f *Fake) Set(t time.Time) {
+    f.mu.Lock()
+    defer f.mu.Unlock()
+    for {
+        sort.SliceStable(f.waiters, func(i, j int) bool { return f.waiters[i].at.Before(f.waiters[j].at) })
+        if len(f.waiters) == 0 || f.waiters[0].at.After(t) {
+            break
+        }
+        w := f.waiters[0]
+        f.now = w.at
+        select {
+        case w.c <- w.at:
+        default:
+        }
+        if w.period > 0 {
+            w.at = w.at.Add(w.period)
+        } else {
+            f.waiters = f.waiters[1:]
+        }
+    }
+    f.now = t
+}
+
+// Waiters is how many timers and tickers are pending, so tests can wait for
+// a goroutine to start waiting before advancing the clock
+func (f *Fake) Waiters() int {
+    f.mu.Lock()
+    defer f.mu.Unlock()
+    return len(f.waiters)
+}
+
+type fakeTicker struct {
+    clock  *Fake
+    waiter *fakeWaiter
+}
+
+func (t *fakeTicker) C() <-chan time.Time { return t.waiter.c }
+
+func (t *fakeTicker) Stop() {
+    t.clock.mu.Lock()
+    defer t.clock.mu.Unlock()
+    for i, w := range t.clock.waiters {
+        if w == t.waiter {
+            t.clock.waiters = append(t.clock.waiters[:i], t.clock.waiters[i+1:]...)
+            break
+        }
+    }
+}
+
+// Replay is a clock that starts at a point in the past and runs at speed
+// times real time, for reprocessing historical data as if it were live.
+// Schedules fire at the replayed pace: an hourly job runs every minute at
+// speed 60.
+type Replay struct {
+    start time.Time
+    began time.Time
+    speed float64
+}
+
+// NewReplay creates a replay clock reading start now and advancing speed
+// times as fast as real time; speeds of zero or less run at real time
+func NewReplay(start time.Time, speed float64) *Replay {
+    if speed <= 0 {
+        speed = 1
+    }
+    return &Replay{start: start, began: time.Now(), speed: speed}
+}
+
+// Now returns the replayed time
+func (r *Replay) Now() time.Time {
+    return r.start.Add(r.scaleUp(time.Since(r.began)))
+}
+
+// After fires once d of replayed time has passed
+func (r *Replay) After(d time.Duration) <-chan time.Time {
+    c := make(chan time.Time, 1)
+    timer := time.NewTimer(r.scaleDown(d))
+    go func() {
+        <-timer.C
+        c <- r.Now()
+    }()
+    return c
+}
+
+// NewTicker ticks every d of replayed time
+func (r *Replay) NewTicker(d time.Duration) Ticker {
+    t := &replayTicker{ticker: time.NewTicker(r.scaleDown(d)), c: make(chan time.Time, 1), done: make(chan struct{})}
+    go func() {
+        for {
+            select {
+            case <-t.ticker.C:
+                select {
+                case t.c <- r.Now():
+                default:
+                }
+            case <-t.done:
+                return
+            }
+        }
+    }()
+    return t
+}
+
+func (r *Replay) scaleUp(d time.Duration) time.Duration {
+    return time.Duration(float64(d) * r.speed)
+}
+
+// scaleDown converts replayed time to real time, at least a millisecond so
+// tickers stay valid at high speeds
+func (r *Replay) scaleDown(d time.Duration) time.Duration {
+    real := time.Duration(float64(d) / r.speed)
+    if real < time.Millisecond {
+        real = time.Millisecond
+    }
+    return real
+}
+
+type replayTicker struct {
+    ticker   *time.Ticker
+    c        chan time.Time
+    done     chan struct{}
+    stopOnce sync.Once
+}
+
+func (t *replayTicker) C() <-chan time.Time { return t.c }
+
+func (t *replayTicker) Stop() {
+    t.stopOnce.Do(func() {
+        t.ticker.Stop()
+        close(t.done)
+    })
+}
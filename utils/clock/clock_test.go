@@ -0,0 +1,184 @@
+package clock
+
+import (
+    "testing"
+    "time"
+)
+
+var testStart = time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+
+// receive waits briefly for a value on c, which should already be there
+func receive(t *testing.T, c <-chan time.Time) time.Time {
+    t.Helper()
+    select {
+    case at := <-c:
+        return at
+    case <-time.After(time.Second):
+        t.Fatal("timed out waiting for the clock")
+        return time.Time{}
+    }
+}
+
+func pending(c <-chan time.Time) bool {
+    select {
+    case <-c:
+        return true
+    default:
+        return false
+    }
+}
+
+func TestFakeAfter(t *testing.T) {
+    f := NewFake(testStart)
+    c := f.After(time.Minute)
+    f.Advance(59 * time.Second)
+    if pending(c) {
+        t.Fatal("After fired before its time")
+    }
+    f.Advance(time.Second)
+    if at := receive(t, c); !at.Equal(testStart.Add(time.Minute)) {
+        t.Errorf("After fired at %v, want %v", at, testStart.Add(time.Minute))
+    }
+    if f.Waiters() != 0 {
+        t.Errorf("Waiters() = %d after the timer fired, want 0", f.Waiters())
+    }
+    if at := receive(t, f.After(0)); !at.Equal(f.Now()) {
+        t.Errorf("After(0) fired at %v, want %v", at, f.Now())
+    }
+}
+
+func TestFakeSetFiresInOrder(t *testing.T) {
+    f := NewFake(testStart)
+    late := f.After(2 * time.Hour)
+    early := f.After(time.Hour)
+    f.Set(testStart.Add(3 * time.Hour))
+    if at := receive(t, early); !at.Equal(testStart.Add(time.Hour)) {
+        t.Errorf("early timer fired at %v", at)
+    }
+    if at := receive(t, late); !at.Equal(testStart.Add(2 * time.Hour)) {
+        t.Errorf("late timer fired at %v", at)
+    }
+    if !f.Now().Equal(testStart.Add(3 * time.Hour)) {
+        t.Errorf("Now() = %v after Set, want %v", f.Now(), testStart.Add(3*time.Hour))
+    }
+
+    f.Set(testStart)
+    if !f.Now().Equal(testStart) {
+        t.Errorf("Now() = %v after moving back, want %v", f.Now(), testStart)
+    }
+}
+
+func TestFakeTicker(t *testing.T) {
+    f := NewFake(testStart)
+    ticker := f.NewTicker(time.Minute)
+    f.Advance(time.Minute)
+    if at := receive(t, ticker.C()); !at.Equal(testStart.Add(time.Minute)) {
+        t.Errorf("first tick at %v", at)
+    }
+
+    // Ticks the receiver misses are dropped, like time.Ticker
+    f.Advance(5 * time.Minute)
+    if at := receive(t, ticker.C()); !at.Equal(testStart.Add(2 * time.Minute)) {
+        t.Errorf("buffered tick at %v, want the first missed one", at)
+    }
+    if pending(ticker.C()) {
+        t.Error("more than one missed tick was kept")
+    }
+
+    ticker.Stop()
+    f.Advance(time.Hour)
+    if pending(ticker.C()) {
+        t.Error("stopped ticker ticked")
+    }
+    if f.Waiters() != 0 {
+        t.Errorf("Waiters() = %d after Stop, want 0", f.Waiters())
+    }
+}
+
+func TestReplay(t *testing.T) {
+    r := NewReplay(testStart, 3600)
+    if now := r.Now(); now.Before(testStart) || now.After(testStart.Add(time.Hour)) {
+        t.Fatalf("Now() = %v right after start, want close to %v", now, testStart)
+    }
+    // An hour of replayed time is a second of real time
+    at := receive(t, r.After(time.Hour/2))
+    if at.Before(testStart.Add(time.Hour / 2)) {
+        t.Errorf("After fired at %v, before half an hour of replayed time", at)
+    }
+
+    if NewReplay(testStart, 0).speed != 1 {
+        t.Error("speed 0 should run at real time")
+    }
+}
+
+// waitForWaiters waits for goroutines to start waiting on f
+func waitForWaiters(t *testing.T, f *Fake, n int) {
+    t.Helper()
+    deadline := time.Now().Add(time.Second)
+    for f.Waiters() < n {
+        if time.Now().After(deadline) {
+            t.Fatalf("Waiters() = %d, want %d", f.Waiters(), n)
+        }
+        time.Sleep(time.Millisecond)
+    }
+}
+
+func TestCronFollowsClock(t *testing.T) {
+    f := NewFake(testStart)
+    runs := make(chan time.Time, 10)
+    c := NewCron(f, time.UTC)
+    if err := c.AddFunc("0 * * * *", func() { runs <- f.Now() }); err != nil {
+        t.Fatalf("AddFunc: %v", err)
+    }
+    if err := c.AddFunc("not a schedule", func() {}); err == nil {
+        t.Error("AddFunc accepted an invalid spec")
+    }
+    c.Start()
+    defer c.Stop()
+
+    for hour := 1; hour <= 3; hour++ {
+        waitForWaiters(t, f, 1)
+        f.Advance(time.Hour)
+        want := testStart.Add(time.Duration(hour) * time.Hour)
+        if at := receive(t, runs); !at.Equal(want) {
+            t.Errorf("run %d at %v, want %v", hour, at, want)
+        }
+    }
+}
+
+func TestCronLocation(t *testing.T) {
+    loc := time.FixedZone("UTC+2", 2*60*60)
+    f := NewFake(testStart)
+    runs := make(chan time.Time, 1)
+    c := NewCron(f, loc)
+    if err := c.AddFunc("0 16 * * *", func() { runs <- f.Now() }); err != nil {
+        t.Fatalf("AddFunc: %v", err)
+    }
+    c.Start()
+    defer c.Stop()
+
+    // 16:00 at UTC+2 is 14:00 UTC, two hours after the start
+    waitForWaiters(t, f, 1)
+    f.Advance(2 * time.Hour)
+    if at := receive(t, runs); !at.Equal(testStart.Add(2 * time.Hour)) {
+        t.Errorf("ran at %v, want %v", at, testStart.Add(2*time.Hour))
+    }
+}
+
+func TestCronStop(t *testing.T) {
+    f := NewFake(testStart)
+    runs := make(chan time.Time, 1)
+    c := NewCron(f, time.UTC)
+    if err := c.AddFunc("@every 1m", func() { runs <- f.Now() }); err != nil {
+        t.Fatalf("AddFunc: %v", err)
+    }
+    c.Start()
+    waitForWaiters(t, f, 1)
+    c.Stop()
+    f.Advance(time.Hour)
+    select {
+    case at := <-runs:
+        t.Errorf("stopped scheduler ran a job at %v", at)
+    case <-time.After(20 * time.Millisecond):
+    }
+}
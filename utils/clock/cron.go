@@ -0,0 +1,96 @@
+package clock
+
+import (
+    "sync"
+    "time"
+    "github.com/robfig/cron/v3"
+)
+
+// Cron runs jobs on standard cron schedules by a Clock's time rather than
+// the wall clock, so schedules follow a fake or replayed clock like
+// everything else. Specs are parsed as cron.ParseStandard does.
+type Cron struct {
+    clock    Clock
+    location *time.Location
+    mu       sync.Mutex
+    entries  []cronEntry
+    stop     chan struct{}
+    wg       sync.WaitGroup
+}
+
+type cronEntry struct {
+    schedule cron.Schedule
+    job      func()
+}
+
+// NewCron creates a scheduler reading time from c, evaluating schedules in loc
+func NewCron(c Clock, loc *time.Location) *Cron {
+    if loc == nil {
+        loc = time.Local
+    }
+    return &Cron{clock: c, location: loc}
+}
+
+// AddFunc runs job at every time spec matches. Add jobs before Start.
+func (c *Cron) AddFunc(spec string, job func()) error {
+    schedule, err := cron.ParseStandard(spec)
+    if err != nil {
+        return err
+    }
+    c.mu.Lock()
+    defer c.mu.Unlock()
+    c.entries = append(c.entries, cronEntry{schedule: schedule, job: job})
+    return nil
+}
+
+// Start begins running the jobs, each in its own goroutine when it comes due
+func (c *Cron) Start() {
+    c.mu.Lock()
+    defer c.mu.Unlock()
+    if c.stop != nil {
+        return
+    }
+    c.stop = make(chan struct{})
+    for _, entry := range c.entries {
+        c.wg.Add(1)
+        go c.run(entry, c.stop)
+    }
+}
+
+// Stop stops scheduling jobs; ones already running finish on their own
+func (c *Cron) Stop() {
+    c.mu.Lock()
+    if c.stop == nil {
+        c.mu.Unlock()
+        return
+    }
+    close(c.stop)
+    c.stop = nil
+    c.mu.Unlock()
+    c.wg.Wait()
+}
+
+func (c *Cron) run(entry cronEntry, stop chan struct{}) {
+    defer c.wg.Done()
+    var last time.Time
+    for {
+        now := c.clock.Now().In(c.location)
+        // A timer can wake a hair before its time on a scaled clock;
+        // never schedule the same run twice
+        from := now
+        if last.After(from) {
+            from = last
+        }
+        next := entry.schedule.Next(from)
+        if next.IsZero() {
+            return
+        }
+        select {
+        case <-c.clock.After(next.Sub(now)):
+            last = next
+            go entry.job()
+        case <-stop:
+            return
+        }
+    }
+}
@@ -0,0 +1,151 @@
+package deepdive
+
+import (
+    "context"
+    "fmt"
+    "log"
+    "strings"
+    "sync"
+    "time"
+    "anondd/llm"
+    "anondd/utils/events"
+    "anondd/utils/models"
+    "anondd/utils/webscraper"
+)
+
+const (
+    // DefaultCooldown is how long an agent waits between deep dives, however
+    // many anomalies it raises
+    DefaultCooldown = 6 * time.Hour
+
+    // PromptKey is the prompt the fresh DD report is written with
+    PromptKey = "anomaly_dd"
+
+    // queueSize bounds the deep dives waiting to run; more are dropped
+    queueSize = 32
+
+    // runTimeout bounds one deep dive, scrape and report together
+    runTimeout = 5 * time.Minute
+)
+
+// Runner closes the loop between anomaly detection and analysis: every
+// anomaly on the change feed queues a deep scrape with a screenshot and a
+// fresh DD report of its agent, published as DeepDiveCompleted for bots to
+// send to the agent's watchers. Deep dives run one at a time.
+type Runner struct {
+    scraper  *webscraper.VirtualsScraper
+    client   *llm.OpenRouterClient
+    bus      *events.Bus
+    cooldown time.Duration
+    jobs     chan models.ChangeEvent
+    mu       sync.Mutex
+    last     map[string]time.Time // Last deep dive queued, by agent ID
+    logger   *log.Logger
+}
+
+// NewRunner creates a runner that deep dives an agent at most once per
+// cooldown; zero uses DefaultCooldown
+func NewRunner(scraper *webscraper.VirtualsScraper, client *llm.OpenRouterClient, bus *events.Bus, cooldown time.Duration, logger *log.Logger) *Runner {
+    if cooldown <= 0 {
+        cooldown = DefaultCooldown
+    }
+    return &Runner{
+        scraper:  scraper,
+        client:   client,
+        bus:      bus,
+        cooldown: cooldown,
+        jobs:     make(chan models.ChangeEvent, queueSize),
+        last:     make(map[string]time.Time),
+        logger:   logger,
+    }
+}
+
+// Start subscribes to anomalies and runs their deep dives until ctx is cancelled
+func (r *Runner) Start(ctx context.Context) {
+    unsubscribe := events.Subscribe(r.bus, func(changed events.AgentChanged) {
+        if models.IsAnomaly(changed.Change.Type) {
+            r.Enqueue(changed.Change)
+        }
+    })
+    go func() {
+        defer unsubscribe()
+        for {
+            select {
+            case trigger := <-r.jobs:
+                r.run(ctx, trigger)
+            case <-ctx.Done():
+                return
+            }
+        }
+    }()
+}
+
+// Enqueue queues a deep dive of the agent trigger is about. It reports false
+// when the agent had one within the cooldown or the queue is full.
+func (r *Runner) Enqueue(trigger models.ChangeEvent) bool {
+    if trigger.SourceID <= 0 {
+        return false
+    }
+    now := r.scraper.GetStore().Clock().Now()
+    r.mu.Lock()
+    if last, ok := r.last[trigger.AgentID]; ok && now.Sub(last) < r.cooldown {
+        r.mu.Unlock()
+        return false
+    }
+    r.last[trigger.AgentID] = now
+    r.mu.Unlock()
+
+    select {
+    case r.jobs <- trigger:
+        r.logger.Printf("[DEEP] Queued deep dive of %s after %s", trigger.AgentName, trigger.Type)
+        return true
+    default:
+        r.mu.Lock()
+        delete(r.last, trigger.AgentID)
+        r.mu.Unlock()
+        r.logger.Printf("[DEEP] Dropping deep dive of %s: queue is full", trigger.AgentName)
+        return false
+    }
+}
+
+// run deep scrapes the trigger's agent, writes a fresh DD report and
+// publishes the result
+func (r *Runner) run(ctx context.Context, trigger models.ChangeEvent) {
+    ctx, cancel := context.WithTimeout(llm.WithCommand(ctx, "anomaly_dd"), runTimeout)
+    defer cancel()
+
+    agent, screenshot, err := r.scraper.DeepScrape(ctx, trigger.SourceID)
+    if err != nil {
+        r.logger.Printf("[DEEP] Error deep scraping %s: %v", trigger.AgentName, err)
+        return
+    }
+    analysis, err := r.client.GetResponse(ctx, PromptKey, describe(agent, trigger))
+    if err != nil {
+        r.logger.Printf("[DEEP] Error writing DD for %s: %v", agent.Name, err)
+        return
+    }
+    analysis = r.client.PostProcess(ctx, PromptKey, "", analysis)
+
+    r.bus.Publish(events.DeepDiveCompleted{
+        Trigger:    trigger,
+        Agent:      *agent,
+        Screenshot: screenshot,
+        Analysis:   analysis,
+    })
+}
+
+// describe renders the freshly scraped agent and the move that triggered the
+// deep dive for the DD prompt
+func describe(agent *models.Agent, trigger models.ChangeEvent) string {
+    var b strings.Builder
+    fmt.Fprintf(&b, "Trigger: %s (%s → %s)\n", trigger.Summary, trigger.Before, trigger.After)
+    fmt.Fprintf(&b, "Name: %s\nPrice: %s\nStatus: %s\nStats: %s\nDescription: %s\n",
+        agent.Name, agent.Price, agent.Status, agent.StatsText(), agent.Description)
+    fmt.Fprintf(&b, "Mindshare: %s\nImpressions: %s\nEngagement: %s\nFollowers: %s\nSmart Followers: %s\n",
+        agent.InfluenceMetrics.Mindshare, agent.InfluenceMetrics.Impressions, agent.InfluenceMetrics.Engagement,
+        agent.InfluenceMetrics.Followers, agent.InfluenceMetrics.SmartFollowers)
+    fmt.Fprintf(&b, "MC (FDV): %s\n24h Change: %s\nTVL: %s\nHolders: %s\n24h Volume: %s\n",
+        agent.TokenData.MCFDV, agent.TokenData.Change24h, agent.TokenData.TVL,
+        agent.TokenData.Holders, agent.TokenData.Volume24h)
+    return b.String()
+}
@@ -0,0 +1,88 @@
+// Package events is an in-process publish/subscribe bus that lets components
+// react to each other without direct calls
+package events
+
+import (
+    "log"
+    "sync"
+)
+
+// Event is anything published on the bus; events of one type share a topic
+type Event interface {
+    Topic() string
+}
+
+type subscription struct {
+    handle func(Event)
+}
+
+// Bus delivers published events to subscribers synchronously, in
+// subscription order. A nil *Bus discards everything published on it.
+type Bus struct {
+    mu     sync.RWMutex
+    subs   map[string][]*subscription
+    logger *log.Logger
+}
+
+// NewBus creates an empty bus
+func NewBus(logger *log.Logger) *Bus {
+    return &Bus{
+        subs:   make(map[string][]*subscription),
+        logger: logger,
+    }
+}
+
+// Publish delivers event to every subscriber of its type. A panicking
+// subscriber is logged and doesn't stop delivery to the rest.
+func (b *Bus) Publish(event Event) {
+    if b == nil {
+        return
+    }
+    b.mu.RLock()
+    subs := append([]*subscription(nil), b.subs[event.Topic()]...)
+    b.mu.RUnlock()
+
+    for _, sub := range subs {
+        b.deliver(sub, event)
+    }
+}
+
+func (b *Bus) deliver(sub *subscription, event Event) {
+    defer func() {
+        if r := recover(); r != nil {
+            b.logger.Printf("[EVENTS] Subscriber to %s panicked: %v", event.Topic(), r)
+        }
+    }()
+    sub.handle(event)
+}
+
+// Subscribe calls handle with every event of type E published on the bus
+// and returns a function that removes the subscription
+func Subscribe[E Event](b *Bus, handle func(E)) (unsubscribe func()) {
+    if b == nil {
+        return func() {}
+    }
+    var zero E
+    topic := zero.Topic()
+    sub := &subscription{handle: func(event Event) {
+        if typed, ok := event.(E); ok {
+            handle(typed)
+        }
+    }}
+
+    b.mu.Lock()
+    b.subs[topic] = append(b.subs[topic], sub)
+    b.mu.Unlock()
+
+    return func() {
+        b.mu.Lock()
+        defer b.mu.Unlock()
+        subs := b.subs[topic]
+        for i, s := range subs {
+            if s == sub {
+                b.subs[topic] = append(subs[:i:i], subs[i+1:]...)
+                return
+            }
+        }
+    }
+}
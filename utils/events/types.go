@@ -0,0 +1,106 @@
+package events
+
+import (
+    "time"
+    "anondd/utils/models"
+)
+
+// AgentScraped is published for every agent page the scraper parses
+type AgentScraped struct {
+    Agent models.Agent
+}
+
+func (AgentScraped) Topic() string { return "agent_scraped" }
+
+// AgentSaved is published as soon as a parsed agent is saved and indexed,
+// during scrapes and reparses alike
+type AgentSaved struct {
+    Agent models.Agent
+}
+
+func (AgentSaved) Topic() string { return "agent_saved" }
+
+// AgentChanged is published when a change event is added to the feed,
+// including anomalies
+type AgentChanged struct {
+    Change models.ChangeEvent
+}
+
+func (AgentChanged) Topic() string { return "agent_changed" }
+
+// ScrapeCompleted is published at the end of every scrape cycle
+type ScrapeCompleted struct {
+    Run models.ScrapeRun
+}
+
+func (ScrapeCompleted) Topic() string { return "scrape_completed" }
+
+// LLMCallFinished is published after every completion request sent to the LLM API
+type LLMCallFinished struct {
+    Model            string
+    PromptKey        string
+    Command          string // Bot command or background job the call was made for; empty if unlabeled
+    PromptTokens     int
+    CompletionTokens int
+    Cost             float64 // USD, reported by the provider or estimated
+    Duration         time.Duration
+    Err              error
+}
+
+func (LLMCallFinished) Topic() string { return "llm_call_finished" }
+
+// LLMExchange is published with the raw request and response of every HTTP
+// call to the LLM API, secrets redacted, for the audit log
+type LLMExchange struct {
+    At        time.Time
+    PromptKey string
+    Command   string // Bot command or background job the call was made for; empty if unlabeled
+    Method    string
+    URL       string
+    Status    int    // HTTP status; 0 when no response arrived
+    Request   string // Request body, possibly truncated
+    Response  string // Response body as read by the client, possibly truncated
+    Truncated bool
+    Duration  time.Duration // Until the response body was read
+    Err       error
+}
+
+func (LLMExchange) Topic() string { return "llm_exchange" }
+
+// DeepDiveCompleted is published when an anomaly's automatic deep scrape and
+// fresh DD report are ready
+type DeepDiveCompleted struct {
+    Trigger    models.ChangeEvent // The anomaly that started the deep dive
+    Agent      models.Agent       // As saved by the deep scrape
+    Screenshot []byte             // Empty when the fetcher couldn't capture one
+    Analysis   string
+}
+
+func (DeepDiveCompleted) Topic() string { return "deep_dive_completed" }
+
+// LLMStreamStarted is published when a streamed completion starts
+type LLMStreamStarted struct {
+    ID        string // Identifies the completion in its deltas and finish
+    PromptKey string
+    Command   string
+    StartedAt time.Time
+}
+
+func (LLMStreamStarted) Topic() string { return "llm_stream_started" }
+
+// LLMStreamDelta is the next piece of a streamed completion's text
+type LLMStreamDelta struct {
+    ID   string
+    Text string
+}
+
+func (LLMStreamDelta) Topic() string { return "llm_stream_delta" }
+
+// LLMStreamFinished is published when a streamed completion ends, with Err
+// set if it failed part way
+type LLMStreamFinished struct {
+    ID  string
+    Err error
+}
+
+func (LLMStreamFinished) Topic() string { return "llm_stream_finished" }
@@ -0,0 +1,202 @@
+package export
+
+import (
+    "encoding/csv"
+    "fmt"
+    "io"
+    "strconv"
+    "strings"
+    "time"
+    "anondd/utils/models"
+    "anondd/utils/storage"
+)
+
+// Supported export formats
+const (
+    FormatCSV  = "csv"
+    FormatXLSX = "xlsx"
+)
+
+// Column is one exported field of an agent
+type Column struct {
+    Name  string
+    value func(*models.Agent) string
+}
+
+// columns lists every metric that can be exported, in export order. The
+// first DefaultColumns of them are exported when none are selected.
+var columns = []Column{
+    {"id", func(a *models.Agent) string { return a.ID }},
+    {"name", func(a *models.Agent) string { return a.Name }},
+    {"price", func(a *models.Agent) string { return a.Price }},
+    {"status", func(a *models.Agent) string { return a.Status }},
+    {"last_checked", func(a *models.Agent) string { return formatTime(a.LastChecked) }},
+    {"mindshare", func(a *models.Agent) string { return a.InfluenceMetrics.Mindshare }},
+    {"impressions", func(a *models.Agent) string { return a.InfluenceMetrics.Impressions }},
+    {"engagement", func(a *models.Agent) string { return a.InfluenceMetrics.Engagement }},
+    {"followers", func(a *models.Agent) string { return a.InfluenceMetrics.Followers }},
+    {"smart_followers", func(a *models.Agent) string { return a.InfluenceMetrics.SmartFollowers }},
+    {"mc_fdv", func(a *models.Agent) string { return a.TokenData.MCFDV }},
+    {"change_24h", func(a *models.Agent) string { return a.TokenData.Change24h }},
+    {"tvl", func(a *models.Agent) string { return a.TokenData.TVL }},
+    {"holders", func(a *models.Agent) string { return a.TokenData.Holders }},
+    {"volume_24h", func(a *models.Agent) string { return a.TokenData.Volume24h }},
+    {"inferences", func(a *models.Agent) string { return a.TokenData.Inferences }},
+    {"first_seen", func(a *models.Agent) string { return formatTime(a.FirstSeen) }},
+    {"stage", func(a *models.Agent) string { return a.Stage }},
+    {"bonding_progress", func(a *models.Agent) string {
+        if a.Stage != models.StageBonding {
+            return ""
+        }
+        return strconv.FormatFloat(a.BondingProgress, 'f', -1, 64)
+    }},
+    {"score", func(a *models.Agent) string {
+        if a.StatsDetail == nil || a.StatsDetail.Score == 0 {
+            return ""
+        }
+        return strconv.FormatFloat(a.StatsDetail.Score, 'f', -1, 64)
+    }},
+    {"rank", func(a *models.Agent) string {
+        if a.StatsDetail == nil || a.StatsDetail.Rank == 0 {
+            return ""
+        }
+        return strconv.Itoa(a.StatsDetail.Rank)
+    }},
+    {"risk_score", func(a *models.Agent) string {
+        if a.Risk == nil {
+            return ""
+        }
+        return strconv.Itoa(a.Risk.Score)
+    }},
+    {"risk_level", func(a *models.Agent) string {
+        if a.Risk == nil {
+            return ""
+        }
+        return a.Risk.Level
+    }},
+}
+
+// DefaultColumns is how many leading columns are exported by default: the
+// index fields, influence metrics and token data
+const DefaultColumns = 16
+
+// Columns resolves metric names to columns, always led by id and name. No
+// names selects the default columns; an unknown name is an error, so a
+// config can't ask for a metric that is silently left out.
+func Columns(names []string) ([]Column, error) {
+    if len(names) == 0 {
+        return columns[:DefaultColumns], nil
+    }
+    selected := []Column{columns[0], columns[1]}
+    seen := map[string]bool{"id": true, "name": true}
+    for _, name := range names {
+        name = strings.ToLower(strings.TrimSpace(name))
+        if name == "" || seen[name] {
+            continue
+        }
+        column, ok := findColumn(name)
+        if !ok {
+            return nil, fmt.Errorf("unknown export metric %q, use one of %s", name, strings.Join(ColumnNames(columns), ", "))
+        }
+        selected = append(selected, column)
+        seen[name] = true
+    }
+    return selected, nil
+}
+
+// ParseColumns is Columns for a comma-separated list of metric names
+func ParseColumns(list string) ([]Column, error) {
+    if strings.TrimSpace(list) == "" {
+        return Columns(nil)
+    }
+    return Columns(strings.Split(list, ","))
+}
+
+func findColumn(name string) (Column, bool) {
+    for _, column := range columns {
+        if column.Name == name {
+            return column, true
+        }
+    }
+    return Column{}, false
+}
+
+// ColumnNames returns the header for the columns
+func ColumnNames(cols []Column) []string {
+    names := make([]string, len(cols))
+    for i, column := range cols {
+        names[i] = column.Name
+    }
+    return names
+}
+
+// Rows builds one row per indexed agent, filling metrics from the stored agent when available
+func Rows(store *storage.AgentStore, cols []Column) ([][]string, error) {
+    return RowsWhere(store, cols, nil)
+}
+
+// RowsWhere is Rows limited to the agents keep accepts; a nil keep accepts all
+func RowsWhere(store *storage.AgentStore, cols []Column, keep func(*models.Agent) bool) ([][]string, error) {
+    index, err := store.GetIndex()
+    if err != nil {
+        return nil, fmt.Errorf("failed to load index: %w", err)
+    }
+
+    rows := make([][]string, 0, len(index.Agents))
+    for _, summary := range index.Agents {
+        agent, err := store.GetAgent(summary.ID)
+        if err != nil {
+            agent = &models.Agent{ID: summary.ID, Name: summary.Name, Price: summary.Price, Status: summary.Status,
+                FirstSeen: summary.FirstSeen, Stage: summary.Stage, BondingProgress: summary.BondingProgress}
+        }
+        if keep != nil && !keep(agent) {
+            continue
+        }
+        row := make([]string, len(cols))
+        for i, column := range cols {
+            row[i] = column.value(agent)
+        }
+        rows = append(rows, row)
+    }
+    return rows, nil
+}
+
+func formatTime(t time.Time) string {
+    if t.IsZero() {
+        return ""
+    }
+    return t.Format("2006-01-02 15:04:05")
+}
+
+// Write streams the columns' header and rows to w in the given format
+func Write(w io.Writer, format string, cols []Column, rows [][]string) error {
+    header := ColumnNames(cols)
+    switch format {
+    case FormatCSV:
+        return WriteCSV(w, header, rows)
+    case FormatXLSX:
+        return WriteXLSX(w, header, rows)
+    default:
+        return fmt.Errorf("unsupported export format: %s", format)
+    }
+}
+
+// WriteCSV writes the header and rows as CSV
+func WriteCSV(w io.Writer, header []string, rows [][]string) error {
+    writer := csv.NewWriter(w)
+    if err := writer.Write(header); err != nil {
+        return fmt.Errorf("failed to write csv header: %w", err)
+    }
+    if err := writer.WriteAll(rows); err != nil {
+        return fmt.Errorf("failed to write csv rows: %w", err)
+    }
+    return nil
+}
+
+// ContentType returns the MIME type for a format
+func ContentType(format string) string {
+    if format == FormatXLSX {
+        return "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+    }
+    return "text/csv"
+}
@@ -0,0 +1,180 @@
+package export
+
+import (
+    "bytes"
+    "context"
+    "encoding/json"
+    "fmt"
+    "io"
+    "log"
+    "net/http"
+    "net/url"
+    "strings"
+    "sync"
+    "time"
+    "anondd/utils/httpclient"
+    "anondd/utils/storage"
+)
+
+const (
+    sheetsAPIBase  = "https://sheets.googleapis.com/v4/spreadsheets"
+    googleTokenURL = "https://oauth2.googleapis.com/token"
+
+    // tokenRefreshMargin renews an access token this long before it expires
+    tokenRefreshMargin = time.Minute
+)
+
+// SheetsCredentials is an OAuth client and a refresh token for it, from which
+// short-lived access tokens are minted as needed
+type SheetsCredentials struct {
+    ClientID     string
+    ClientSecret string
+    RefreshToken string
+}
+
+// SheetsSync replaces a Google Sheet's contents with the exported rows after
+// each scrape
+type SheetsSync struct {
+    SpreadsheetID string
+    Range         string
+    HTTPClient    *http.Client
+    credentials   SheetsCredentials
+    columns       []Column
+    store         *storage.AgentStore
+    logger        *log.Logger
+
+    tokenMu     sync.Mutex
+    accessToken string
+    expiresAt   time.Time
+}
+
+// NewSheetsSync creates a sync job writing the columns to sheetRange (e.g.
+// "Agents!A1") of the spreadsheet
+func NewSheetsSync(spreadsheetID, sheetRange string, credentials SheetsCredentials, columns []Column, store *storage.AgentStore, logger *log.Logger) *SheetsSync {
+    if sheetRange == "" {
+        sheetRange = "Agents!A1"
+    }
+    return &SheetsSync{
+        SpreadsheetID: spreadsheetID,
+        Range:         sheetRange,
+        HTTPClient:    httpclient.WithTimeout(30 * time.Second),
+        credentials:   credentials,
+        columns:       columns,
+        store:         store,
+        logger:        logger,
+    }
+}
+
+// Sync clears the sheet the range is on and writes the current export to
+// the range, so agents dropped since the last run don't linger below it
+func (s *SheetsSync) Sync(ctx context.Context) error {
+    rows, err := Rows(s.store, s.columns)
+    if err != nil {
+        return err
+    }
+
+    values := make([][]string, 0, len(rows)+1)
+    values = append(values, ColumnNames(s.columns))
+    values = append(values, rows...)
+
+    body, err := json.Marshal(map[string]interface{}{
+        "range":          s.Range,
+        "majorDimension": "ROWS",
+        "values":         values,
+    })
+    if err != nil {
+        return fmt.Errorf("failed to encode sheet values: %w", err)
+    }
+
+    sheet, _, _ := strings.Cut(s.Range, "!")
+    clearURL := fmt.Sprintf("%s/%s/values/%s:clear", sheetsAPIBase, url.PathEscape(s.SpreadsheetID), url.PathEscape(sheet))
+    if err := s.call(ctx, http.MethodPost, clearURL, []byte("{}")); err != nil {
+        return fmt.Errorf("failed to clear sheet: %w", err)
+    }
+    updateURL := fmt.Sprintf("%s/%s/values/%s?valueInputOption=RAW",
+        sheetsAPIBase, url.PathEscape(s.SpreadsheetID), url.PathEscape(s.Range))
+    if err := s.call(ctx, http.MethodPut, updateURL, body); err != nil {
+        return fmt.Errorf("failed to write sheet: %w", err)
+    }
+
+    s.logger.Printf("Synced %d agents to Google Sheet %s", len(rows), s.SpreadsheetID)
+    return nil
+}
+
+// call sends a Sheets API request, renewing the access token and trying
+// once more if it was rejected
+func (s *SheetsSync) call(ctx context.Context, method, endpoint string, body []byte) error {
+    for attempt := 0; ; attempt++ {
+        token, err := s.token(ctx, attempt > 0)
+        if err != nil {
+            return err
+        }
+        req, err := http.NewRequestWithContext(ctx, method, endpoint, bytes.NewReader(body))
+        if err != nil {
+            return fmt.Errorf("failed to create request: %w", err)
+        }
+        req.Header.Set("Content-Type", "application/json")
+        req.Header.Set("Authorization", "Bearer "+token)
+
+        resp, err := s.HTTPClient.Do(req)
+        if err != nil {
+            return fmt.Errorf("failed to execute request: %w", err)
+        }
+        respBody, _ := io.ReadAll(resp.Body)
+        resp.Body.Close()
+
+        if resp.StatusCode == http.StatusUnauthorized && attempt == 0 {
+            continue
+        }
+        if resp.StatusCode != http.StatusOK {
+            return fmt.Errorf("google sheets API error: %s", string(respBody))
+        }
+        return nil
+    }
+}
+
+// token returns a valid access token, exchanging the refresh token for a new
+// one when the current one is about to expire or force is set
+func (s *SheetsSync) token(ctx context.Context, force bool) (string, error) {
+    s.tokenMu.Lock()
+    defer s.tokenMu.Unlock()
+    if !force && s.accessToken != "" && time.Now().Add(tokenRefreshMargin).Before(s.expiresAt) {
+        return s.accessToken, nil
+    }
+
+    form := url.Values{
+        "client_id":     {s.credentials.ClientID},
+        "client_secret": {s.credentials.ClientSecret},
+        "refresh_token": {s.credentials.RefreshToken},
+        "grant_type":    {"refresh_token"},
+    }
+    req, err := http.NewRequestWithContext(ctx, http.MethodPost, googleTokenURL, strings.NewReader(form.Encode()))
+    if err != nil {
+        return "", fmt.Errorf("failed to create token request: %w", err)
+    }
+    req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+    resp, err := s.HTTPClient.Do(req)
+    if err != nil {
+        return "", fmt.Errorf("failed to refresh access token: %w", err)
+    }
+    defer resp.Body.Close()
+    if resp.StatusCode != http.StatusOK {
+        respBody, _ := io.ReadAll(resp.Body)
+        return "", fmt.Errorf("google token error: %s", string(respBody))
+    }
+
+    var result struct {
+        AccessToken string `json:"access_token"`
+        ExpiresIn   int    `json:"expires_in"`
+    }
+    if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+        return "", fmt.Errorf("failed to decode token response: %w", err)
+    }
+    if result.AccessToken == "" {
+        return "", fmt.Errorf("google token response has no access token")
+    }
+    s.accessToken = result.AccessToken
+    s.expiresAt = time.Now().Add(time.Duration(result.ExpiresIn) * time.Second)
+    return s.accessToken, nil
+}
@@ -0,0 +1,95 @@
+package export
+
+import (
+    "archive/zip"
+    "bytes"
+    "encoding/xml"
+    "fmt"
+    "io"
+)
+
+// Static parts of a minimal single-sheet workbook
+var xlsxStaticParts = []struct {
+    name    string
+    content string
+}{
+    {"[Content_Types].xml", `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types">
+<Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/>
+<Default Extension="xml" ContentType="application/xml"/>
+<Override PartName="/xl/workbook.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.sheet.main+xml"/>
+<Override PartName="/xl/worksheets/sheet1.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.worksheet+xml"/>
+</Types>`},
+    {"_rels/.rels", `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">
+<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="xl/workbook.xml"/>
+</Relationships>`},
+    {"xl/workbook.xml", `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships">
+<sheets><sheet name="Agents" sheetId="1" r:id="rId1"/></sheets>
+</workbook>`},
+    {"xl/_rels/workbook.xml.rels", `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">
+<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sheet1.xml"/>
+</Relationships>`},
+}
+
+// WriteXLSX writes the header and rows as a single-sheet XLSX workbook using inline strings
+func WriteXLSX(w io.Writer, header []string, rows [][]string) error {
+    zw := zip.NewWriter(w)
+
+    for _, part := range xlsxStaticParts {
+        f, err := zw.Create(part.name)
+        if err != nil {
+            return fmt.Errorf("failed to create %s: %w", part.name, err)
+        }
+        if _, err := io.WriteString(f, part.content); err != nil {
+            return fmt.Errorf("failed to write %s: %w", part.name, err)
+        }
+    }
+
+    sheet, err := zw.Create("xl/worksheets/sheet1.xml")
+    if err != nil {
+        return fmt.Errorf("failed to create sheet: %w", err)
+    }
+
+    io.WriteString(sheet, `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>`+
+        `<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><sheetData>`)
+    if err := writeXLSXRow(sheet, 1, header); err != nil {
+        return err
+    }
+    for i, row := range rows {
+        if err := writeXLSXRow(sheet, i+2, row); err != nil {
+            return err
+        }
+    }
+    io.WriteString(sheet, `</sheetData></worksheet>`)
+
+    return zw.Close()
+}
+
+func writeXLSXRow(w io.Writer, rowNum int, cells []string) error {
+    var buf bytes.Buffer
+    fmt.Fprintf(&buf, `<row r="%d">`, rowNum)
+    for col, value := range cells {
+        fmt.Fprintf(&buf, `<c r="%s%d" t="inlineStr"><is><t xml:space="preserve">`, xlsxColumn(col), rowNum)
+        if err := xml.EscapeText(&buf, []byte(value)); err != nil {
+            return fmt.Errorf("failed to escape cell: %w", err)
+        }
+        buf.WriteString(`</t></is></c>`)
+    }
+    buf.WriteString(`</row>`)
+
+    _, err := w.Write(buf.Bytes())
+    return err
+}
+
+// xlsxColumn converts a zero-based column index to its spreadsheet letter (0 -> A, 26 -> AA)
+func xlsxColumn(col int) string {
+    name := ""
+    for col >= 0 {
+        name = string(rune('A'+col%26)) + name
+        col = col/26 - 1
+    }
+    return name
+}
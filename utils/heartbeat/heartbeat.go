@@ -0,0 +1,209 @@
+package heartbeat
+
+import (
+    "context"
+    "fmt"
+    "io"
+    "log"
+    "net/http"
+    "sort"
+    "sync"
+    "time"
+    "anondd/utils/httpclient"
+    "anondd/utils/models"
+    "anondd/utils/storage"
+)
+
+const (
+    // DefaultInterval is how often a round of checks runs
+    DefaultInterval = 10 * time.Minute
+
+    // DefaultURL is the cheap per-agent endpoint checked for virtuals agents,
+    // formatted with the agent's source ID. It answers 404 once an agent is
+    // removed, without rendering anything.
+    DefaultURL = "https://api.virtuals.io/api/virtuals/%d"
+
+    // batchSize caps the agents checked per round, least recently checked first
+    batchSize = 200
+
+    // workers is how many checks run at once
+    workers = 8
+
+    requestTimeout = 10 * time.Second
+
+    // maxBodyBytes bounds how much of a GET response is read
+    maxBodyBytes = 4 << 10
+)
+
+// Checker makes lightweight liveness checks of tracked agents between full
+// scrapes, so delistings show up in minutes instead of at the next scrape
+type Checker struct {
+    store  *storage.AgentStore
+    client *http.Client
+    url    string
+    logger *log.Logger
+    mu     sync.Mutex // One round at a time
+}
+
+// NewChecker creates a checker that requests urlPattern, formatted with each
+// agent's source ID; empty uses DefaultURL
+func NewChecker(store *storage.AgentStore, urlPattern string, logger *log.Logger) *Checker {
+    if urlPattern == "" {
+        urlPattern = DefaultURL
+    }
+    return &Checker{
+        store:  store,
+        client: httpclient.WithTimeout(requestTimeout),
+        url:    urlPattern,
+        logger: logger,
+    }
+}
+
+// Start runs a round of checks every interval until ctx is cancelled
+func (c *Checker) Start(ctx context.Context, interval time.Duration) {
+    go func() {
+        ticker := c.store.Clock().NewTicker(interval)
+        defer ticker.Stop()
+        for {
+            select {
+            case <-ticker.C():
+                if _, err := c.Round(ctx); err != nil {
+                    c.logger.Printf("Error running heartbeat checks: %v", err)
+                }
+            case <-ctx.Done():
+                return
+            }
+        }
+    }()
+}
+
+// RoundResult counts the outcomes of a round of checks
+type RoundResult struct {
+    Checked int `json:"checked"`
+    Alive   int `json:"alive"`
+    Gone    int `json:"gone"`
+    Unknown int `json:"unknown"`
+    Changed int `json:"changed"` // Agents whose status changed
+}
+
+// Round checks up to batchSize virtuals agents, least recently checked first
+func (c *Checker) Round(ctx context.Context) (RoundResult, error) {
+    c.mu.Lock()
+    defer c.mu.Unlock()
+
+    var result RoundResult
+    index, err := c.store.GetIndexContext(ctx)
+    if err != nil {
+        return result, err
+    }
+
+    // Only agents on the source the endpoint knows, with an ID there
+    type target struct {
+        agent     *models.Agent
+        checkedAt time.Time
+    }
+    var targets []target
+    for _, summary := range index.Agents {
+        agent, err := c.store.GetAgentContext(ctx, summary.ID)
+        if err != nil || agent.SourceName() != models.SourceVirtuals || agent.SourceID <= 0 {
+            continue
+        }
+        var checkedAt time.Time
+        if agent.Heartbeat != nil {
+            checkedAt = agent.Heartbeat.CheckedAt
+        }
+        targets = append(targets, target{agent: agent, checkedAt: checkedAt})
+    }
+    sort.SliceStable(targets, func(i, j int) bool { return targets[i].checkedAt.Before(targets[j].checkedAt) })
+    if len(targets) > batchSize {
+        targets = targets[:batchSize]
+    }
+
+    jobs := make(chan *models.Agent)
+    var mu sync.Mutex
+    var wg sync.WaitGroup
+    for w := 0; w < workers; w++ {
+        wg.Add(1)
+        go func() {
+            defer wg.Done()
+            for agent := range jobs {
+                outcome, status := c.Check(ctx, agent.SourceID)
+                updated, err := c.store.RecordHeartbeat(ctx, agent.ID, outcome, status)
+                if err != nil {
+                    c.logger.Printf("Error recording heartbeat for agent %s: %v", agent.ID, err)
+                }
+
+                mu.Lock()
+                result.Checked++
+                switch outcome {
+                case models.HeartbeatAlive:
+                    result.Alive++
+                case models.HeartbeatGone:
+                    result.Gone++
+                default:
+                    result.Unknown++
+                }
+                if updated != nil && updated.Status != agent.Status {
+                    result.Changed++
+                    c.logger.Printf("[HEARTBEAT] %s is now %s", agent.Name, updated.Status)
+                }
+                mu.Unlock()
+            }
+        }()
+    }
+    for _, t := range targets {
+        if ctx.Err() != nil {
+            break
+        }
+        jobs <- t.agent
+    }
+    close(jobs)
+    wg.Wait()
+
+    if result.Checked > 0 {
+        c.logger.Printf("Heartbeat checked %d agents: %d alive, %d gone, %d unknown, %d status changes",
+            result.Checked, result.Alive, result.Gone, result.Unknown, result.Changed)
+    }
+    return result, ctx.Err()
+}
+
+// Check requests the endpoint of one source ID with HEAD, falling back to a
+// bounded GET where HEAD isn't allowed, and classifies the answer
+func (c *Checker) Check(ctx context.Context, sourceID int) (string, int) {
+    url := fmt.Sprintf(c.url, sourceID)
+    status, err := c.request(ctx, http.MethodHead, url)
+    if err == nil && (status == http.StatusMethodNotAllowed || status == http.StatusNotImplemented) {
+        status, err = c.request(ctx, http.MethodGet, url)
+    }
+    if err != nil {
+        return models.HeartbeatUnknown, 0
+    }
+    return classify(status), status
+}
+
+func (c *Checker) request(ctx context.Context, method, url string) (int, error) {
+    req, err := http.NewRequestWithContext(ctx, method, url, nil)
+    if err != nil {
+        return 0, err
+    }
+    resp, err := c.client.Do(req)
+    if err != nil {
+        return 0, err
+    }
+    defer resp.Body.Close()
+    io.Copy(io.Discard, io.LimitReader(resp.Body, maxBodyBytes))
+    return resp.StatusCode, nil
+}
+
+// classify maps a status code to a heartbeat outcome. Only "not found"
+// answers count against an agent; rate limits and outages are unknown.
+func classify(status int) string {
+    switch {
+    case status >= 200 && status < 300:
+        return models.HeartbeatAlive
+    case status == http.StatusNotFound || status == http.StatusGone:
+        return models.HeartbeatGone
+    default:
+        return models.HeartbeatUnknown
+    }
+}
@@ -0,0 +1,128 @@
+package httpclient
+
+import (
+    "net"
+    "net/http"
+    "net/url"
+    "os"
+    "sync"
+    "time"
+)
+
+const (
+    // DefaultUserAgent identifies API calls made by the bot
+    DefaultUserAgent = "anondd/1.0 (+https://github.com/w0w/generic_agent)"
+
+    // BrowserUserAgent is sent when fetching pages that expect a regular browser
+    BrowserUserAgent = "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/91.0.4472.124 Safari/537.36"
+)
+
+// Config describes how outbound HTTP clients are built
+type Config struct {
+    // Timeout bounds a whole request including reading the body
+    Timeout             time.Duration
+    DialTimeout         time.Duration
+    TLSHandshakeTimeout time.Duration
+    IdleConnTimeout     time.Duration
+    MaxIdleConns        int
+    MaxIdleConnsPerHost int
+    // ProxyURL routes all requests through a proxy; empty falls back to
+    // HTTP_PROXY/HTTPS_PROXY/NO_PROXY
+    ProxyURL string
+    // UserAgent is set on requests that don't carry their own
+    UserAgent string
+    // Headers are added to requests that don't already set them
+    Headers map[string]string
+}
+
+// DefaultConfig returns pooled, time-bounded settings. OUTBOUND_PROXY overrides
+// the proxy taken from the environment.
+func DefaultConfig() Config {
+    return Config{
+        Timeout:             30 * time.Second,
+        DialTimeout:         10 * time.Second,
+        TLSHandshakeTimeout: 10 * time.Second,
+        IdleConnTimeout:     90 * time.Second,
+        MaxIdleConns:        100,
+        MaxIdleConnsPerHost: 10,
+        ProxyURL:            os.Getenv("OUTBOUND_PROXY"),
+        UserAgent:           DefaultUserAgent,
+    }
+}
+
+// New builds a client with its own connection pool
+func New(config Config) *http.Client {
+    return &http.Client{
+        Timeout:   config.Timeout,
+        Transport: &headerTransport{base: newTransport(config), userAgent: config.UserAgent, headers: config.Headers},
+    }
+}
+
+var (
+    sharedOnce      sync.Once
+    sharedTransport http.RoundTripper
+)
+
+// WithTimeout returns a client with the given overall timeout that shares the
+// default connection pool with every other client made this way
+func WithTimeout(timeout time.Duration) *http.Client {
+    sharedOnce.Do(func() {
+        config := DefaultConfig()
+        sharedTransport = &headerTransport{base: newTransport(config), userAgent: config.UserAgent}
+    })
+    return &http.Client{Timeout: timeout, Transport: sharedTransport}
+}
+
+// Default returns a client using the default pool and timeout
+func Default() *http.Client {
+    return WithTimeout(DefaultConfig().Timeout)
+}
+
+func newTransport(config Config) *http.Transport {
+    proxy := http.ProxyFromEnvironment
+    if config.ProxyURL != "" {
+        if proxyURL, err := url.Parse(config.ProxyURL); err == nil {
+            proxy = http.ProxyURL(proxyURL)
+        }
+    }
+
+    dialer := &net.Dialer{
+        Timeout:   config.DialTimeout,
+        KeepAlive: 30 * time.Second,
+    }
+    return &http.Transport{
+        Proxy:                 proxy,
+        DialContext:           dialer.DialContext,
+        ForceAttemptHTTP2:     true,
+        MaxIdleConns:          config.MaxIdleConns,
+        MaxIdleConnsPerHost:   config.MaxIdleConnsPerHost,
+        IdleConnTimeout:       config.IdleConnTimeout,
+        TLSHandshakeTimeout:   config.TLSHandshakeTimeout,
+        ExpectContinueTimeout: time.Second,
+    }
+}
+
+// headerTransport fills in the user agent and default headers
+type headerTransport struct {
+    base      http.RoundTripper
+    userAgent string
+    headers   map[string]string
+}
+
+func (t *headerTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+    if (t.userAgent == "" || req.Header.Get("User-Agent") != "") && len(t.headers) == 0 {
+        return t.base.RoundTrip(req)
+    }
+
+    // RoundTrippers must not modify the caller's request
+    req = req.Clone(req.Context())
+    if t.userAgent != "" && req.Header.Get("User-Agent") == "" {
+        req.Header.Set("User-Agent", t.userAgent)
+    }
+    for name, value := range t.headers {
+        if req.Header.Get(name) == "" {
+            req.Header.Set(name, value)
+        }
+    }
+    return t.base.RoundTrip(req)
+}
@@ -1,32 +1,188 @@
 package utils
 
 import (
+	"context"
 	"log"
+	"time"
+	"anondd/utils/clock"
+	"anondd/utils/events"
+	"anondd/utils/onchain"
+	"anondd/utils/parsedigest"
+	"anondd/utils/pipeline"
+	"anondd/utils/plugins"
+	"anondd/utils/rag"
+	"anondd/utils/report"
+	"anondd/utils/search"
+	"anondd/utils/shared"
+	"anondd/utils/speech"
 	"anondd/utils/storage"
 	"anondd/utils/webscraper"
 )
 
 // UtilsManager handles all utility services
 type UtilsManager struct {
-	scraper *webscraper.VirtualsScraper
-	store   *storage.AgentStore
-	logger  *log.Logger
+	scraper   *webscraper.VirtualsScraper
+	store     *storage.AgentStore
+	personas  *storage.PersonaStore
+	alerts    *storage.SubscriberStore
+	quiet     *storage.QuietHoursStore
+	watch     *storage.WatchlistStore
+	lastSeen  *storage.LastSeenStore
+	keywords  *storage.KeywordStore
+	convos    *storage.ConversationStore
+	profiles  *storage.ProfileStore
+	settings  *storage.ChatSettingsStore
+	llmUsage  *storage.LLMUsageLedger
+	llmAudit  *storage.LLMAuditLog
+	premium   *storage.EntitlementStore
+	feedback  *storage.FeedbackStore
+	userKeys  *storage.UserKeyStore
+	pipelines *pipeline.Engine
+	plugins   *plugins.Registry
+	onchain   *onchain.Enricher
+	reporter  *report.Reporter
+	digester  *parsedigest.Digester
+	search    *search.Index
+	retriever *rag.Retriever
+	speech    *speech.Client
+	shared    shared.Store
+	events    *events.Bus
+	logger    *log.Logger
 }
 
 // NewUtilsManager creates and initializes all utilities
 func NewUtilsManager(logger *log.Logger) *UtilsManager {
+	bus := events.NewBus(logger)
 	store := storage.NewAgentStore("training_data", logger)
+	store.SetEvents(bus)
+	alerts, err := storage.NewSubscriberStore("training_data", "alert_subscribers.json")
+	if err != nil {
+		logger.Printf("Error loading alert subscribers: %v", err)
+	}
+	quiet, err := storage.NewQuietHoursStore("training_data")
+	if err != nil {
+		logger.Printf("Error loading quiet hours: %v", err)
+	}
+	watch, err := storage.NewWatchlistStore("training_data")
+	if err != nil {
+		logger.Printf("Error loading watchlists: %v", err)
+	}
+	lastSeen, err := storage.NewLastSeenStore("training_data")
+	if err != nil {
+		logger.Printf("Error loading last seen times: %v", err)
+	}
+	keywords, err := storage.NewKeywordStore("training_data")
+	if err != nil {
+		logger.Printf("Error loading keyword triggers: %v", err)
+	}
+	convos, err := storage.NewConversationStore("training_data")
+	if err != nil {
+		logger.Printf("Error loading conversations: %v", err)
+	}
+	profiles, err := storage.NewProfileStore("training_data")
+	if err != nil {
+		logger.Printf("Error loading user profiles: %v", err)
+	}
+	settings, err := storage.NewChatSettingsStore("training_data")
+	if err != nil {
+		logger.Printf("Error loading chat settings: %v", err)
+	}
+	llmUsage, err := storage.NewLLMUsageLedger("training_data")
+	if err != nil {
+		logger.Printf("Error loading LLM usage ledger: %v", err)
+	}
+	events.Subscribe(bus, func(call events.LLMCallFinished) {
+		usage := storage.LLMUsage{Calls: 1, PromptTokens: call.PromptTokens, CompletionTokens: call.CompletionTokens, Cost: call.Cost}
+		if call.Err != nil {
+			usage.Errors = 1
+		}
+		if err := llmUsage.Record(time.Now(), call.PromptKey, call.Command, usage); err != nil {
+			logger.Printf("Error recording LLM usage: %v", err)
+		}
+	})
+	llmAudit, err := storage.NewLLMAuditLog("training_data")
+	if err != nil {
+		logger.Printf("Error opening LLM audit log: %v", err)
+	}
+	events.Subscribe(bus, func(exchange events.LLMExchange) {
+		entry := storage.LLMAuditEntry{
+			At:         exchange.At,
+			PromptKey:  exchange.PromptKey,
+			Command:    exchange.Command,
+			Method:     exchange.Method,
+			URL:        exchange.URL,
+			Status:     exchange.Status,
+			DurationMS: exchange.Duration.Milliseconds(),
+			Request:    exchange.Request,
+			Response:   exchange.Response,
+			Truncated:  exchange.Truncated,
+		}
+		if exchange.Err != nil {
+			entry.Error = exchange.Err.Error()
+		}
+		if _, err := llmAudit.Append(entry); err != nil {
+			logger.Printf("Error writing LLM audit log: %v", err)
+		}
+	})
+	premium, err := storage.NewEntitlementStore("training_data")
+	if err != nil {
+		logger.Printf("Error loading entitlements: %v", err)
+	}
+	feedback, err := storage.NewFeedbackStore("training_data")
+	if err != nil {
+		logger.Printf("Error loading feedback: %v", err)
+	}
+	userKeys, err := storage.NewUserKeyStore("training_data")
+	if err != nil {
+		logger.Printf("Error loading user API keys: %v", err)
+	}
+	// Plugins compiled in with build tags are installed up front
+	registry := plugins.NewRegistry()
+	for _, p := range plugins.Builtin() {
+		if err := registry.Register(p); err != nil {
+			logger.Printf("Error registering plugin: %v", err)
+		}
+	}
 	return &UtilsManager{
-		store:  store,
-		logger: logger,
+		store:     store,
+		personas:  storage.NewPersonaStore("training_data", logger),
+		alerts:    alerts,
+		quiet:     quiet,
+		watch:     watch,
+		lastSeen:  lastSeen,
+		keywords:  keywords,
+		convos:    convos,
+		profiles:  profiles,
+		settings:  settings,
+		llmUsage:  llmUsage,
+		llmAudit:  llmAudit,
+		premium:   premium,
+		feedback:  feedback,
+		userKeys:  userKeys,
+		plugins:   registry,
+		retriever: rag.NewRetriever(store),
+		shared:    shared.NewMemoryStore(),
+		events:    bus,
+		logger:    logger,
 	}
 }
 
+// SetClock makes the store, the scraper Initialize builds on it and the
+// stores that stamp or expire records read time from c. Call it before
+// Initialize.
+func (m *UtilsManager) SetClock(c clock.Clock) {
+	m.store.SetClock(c)
+	m.convos.SetClock(c)
+	m.llmAudit.SetClock(c)
+	m.userKeys.SetClock(c)
+}
+
 // Initialize sets up the scraper and other components
 func (m *UtilsManager) Initialize() error {
 	m.logger.Println("Initializing VirtualsScraper...")
 	// Initialize scraper with store directly
 	m.scraper = webscraper.NewVirtualsScraper(m.logger, m.store)
+	m.scraper.SetEvents(m.events)
 	
 	return nil
 }
@@ -40,3 +196,166 @@ func (m *UtilsManager) GetScraper() *webscraper.VirtualsScraper {
 func (m *UtilsManager) GetStore() *storage.AgentStore {
 	return m.store
 }
+
+// GetPersonaStore returns the per-chat PersonaStore instance
+func (m *UtilsManager) GetPersonaStore() *storage.PersonaStore {
+	return m.personas
+}
+
+// GetAlertSubscribers returns the store of chats subscribed to anomaly alerts
+func (m *UtilsManager) GetAlertSubscribers() *storage.SubscriberStore {
+	return m.alerts
+}
+
+// GetQuietHours returns the store of per-chat quiet hours and held notifications
+func (m *UtilsManager) GetQuietHours() *storage.QuietHoursStore {
+	return m.quiet
+}
+
+// GetWatchlists returns the store of shared per-chat watchlists
+func (m *UtilsManager) GetWatchlists() *storage.WatchlistStore {
+	return m.watch
+}
+
+// GetLastSeen returns the store of when chats last talked to the bots
+func (m *UtilsManager) GetLastSeen() *storage.LastSeenStore {
+	return m.lastSeen
+}
+
+// GetKeywords returns the store of per-chat keyword triggers
+func (m *UtilsManager) GetKeywords() *storage.KeywordStore {
+	return m.keywords
+}
+
+// GetConversations returns the store of users' conversations with the bots
+func (m *UtilsManager) GetConversations() *storage.ConversationStore {
+	return m.convos
+}
+
+// GetProfiles returns the store of users' onboarding preferences
+func (m *UtilsManager) GetProfiles() *storage.ProfileStore {
+	return m.profiles
+}
+
+// GetLLMUsage returns the ledger of LLM tokens and cost per prompt key and command
+func (m *UtilsManager) GetLLMUsage() *storage.LLMUsageLedger {
+	return m.llmUsage
+}
+
+// GetLLMAudit returns the log of LLM API requests and responses
+func (m *UtilsManager) GetLLMAudit() *storage.LLMAuditLog {
+	return m.llmAudit
+}
+
+// GetEntitlements returns the store of premium entitlements and invite codes
+func (m *UtilsManager) GetEntitlements() *storage.EntitlementStore {
+	return m.premium
+}
+
+// GetChatSettings returns the store of per-chat settings such as agent filters
+func (m *UtilsManager) GetChatSettings() *storage.ChatSettingsStore {
+	return m.settings
+}
+
+// GetFeedbackStore returns the store of response ratings per prompt variant
+func (m *UtilsManager) GetFeedbackStore() *storage.FeedbackStore {
+	return m.feedback
+}
+
+// GetUserKeys returns the personal API keys made with /apikey
+func (m *UtilsManager) GetUserKeys() *storage.UserKeyStore {
+	return m.userKeys
+}
+
+// SetPipelines installs the analysis pipeline engine
+func (m *UtilsManager) SetPipelines(engine *pipeline.Engine) {
+	m.pipelines = engine
+}
+
+// RegisterPlugin installs a command plugin, such as one loaded from the
+// plugins directory
+func (m *UtilsManager) RegisterPlugin(p plugins.CommandPlugin) error {
+	return m.plugins.Register(p)
+}
+
+// GetPlugins returns the installed command plugins
+func (m *UtilsManager) GetPlugins() *plugins.Registry {
+	return m.plugins
+}
+
+// GetPipelines returns the analysis pipeline engine, or nil if none is configured
+func (m *UtilsManager) GetPipelines() *pipeline.Engine {
+	return m.pipelines
+}
+
+// SetOnChain installs the on-chain enricher
+func (m *UtilsManager) SetOnChain(enricher *onchain.Enricher) {
+	m.onchain = enricher
+}
+
+// GetOnChain returns the on-chain enricher, or nil if none is configured
+func (m *UtilsManager) GetOnChain() *onchain.Enricher {
+	return m.onchain
+}
+
+// SetReporter installs the periodic report generator
+func (m *UtilsManager) SetReporter(reporter *report.Reporter) {
+	m.reporter = reporter
+}
+
+// GetReporter returns the periodic report generator, or nil if none is configured
+func (m *UtilsManager) GetReporter() *report.Reporter {
+	return m.reporter
+}
+
+// SetParseDigester installs the parse failure digest
+func (m *UtilsManager) SetParseDigester(digester *parsedigest.Digester) {
+	m.digester = digester
+}
+
+// GetParseDigester returns the parse failure digest, or nil if none is configured
+func (m *UtilsManager) GetParseDigester() *parsedigest.Digester {
+	return m.digester
+}
+
+// SetSearch installs the full-text search index
+func (m *UtilsManager) SetSearch(index *search.Index) {
+	m.search = index
+}
+
+// GetSearch returns the full-text search index, or nil if none is configured
+func (m *UtilsManager) GetSearch() *search.Index {
+	return m.search
+}
+
+// GetRetriever returns the retriever answering free-form questions from agent data
+func (m *UtilsManager) GetRetriever() *rag.Retriever {
+	return m.retriever
+}
+
+// SetSpeech installs the speech-to-text and text-to-speech client
+func (m *UtilsManager) SetSpeech(client *speech.Client) {
+	m.speech = client
+}
+
+// GetSpeech returns the speech client, or nil if voice messages are disabled
+func (m *UtilsManager) GetSpeech() *speech.Client {
+	return m.speech
+}
+
+// SetShared replaces the in-memory shared state with a store shared between
+// instances, and points the agent store's caches at it
+func (m *UtilsManager) SetShared(ctx context.Context, store shared.Store) {
+	m.shared = store
+	m.store.EnableSharedState(ctx, store)
+}
+
+// GetShared returns the store for state shared between instances
+func (m *UtilsManager) GetShared() shared.Store {
+	return m.shared
+}
+
+// GetEvents returns the bus components publish internal events on
+func (m *UtilsManager) GetEvents() *events.Bus {
+	return m.events
+}
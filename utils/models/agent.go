@@ -4,6 +4,7 @@ import (
     "crypto/sha256"
     "encoding/hex"
     "fmt"
+    "strconv"
     "strings"
     "time"
 )
@@ -36,16 +37,31 @@ type TokenData struct {
 // Agent represents a single agent with all its details
 type Agent struct {
     ID              string          `json:"id"`
+    SchemaVersion   int             `json:"schema_version"`
+    SourceID        int             `json:"source_id,omitempty"`
+    Source          string          `json:"source,omitempty"`   // Site the agent was scraped from; empty means virtuals
+    Category        string          `json:"category,omitempty"` // Lowercase category shown on the agent's page
     Name            string          `json:"name"`
     Description     string          `json:"description"`
-    Stats           string          `json:"stats"`
+    Stats           string          `json:"stats,omitempty"`        // Deprecated: raw stats text, see StatsDetail
+    StatsDetail     *AgentStats     `json:"stats_detail,omitempty"` // Stats section parsed into typed fields
     Price           string          `json:"price"`
     ScrapedAt       time.Time       `json:"scraped_at"`
+    FirstSeen       time.Time       `json:"first_seen"`
+    LaunchedAt      time.Time       `json:"launched_at,omitempty"` // Creation date shown on the agent's page
+    Stage           string          `json:"stage,omitempty"`            // Launch stage, bonding or sentient
+    BondingProgress float64         `json:"bonding_progress,omitempty"` // Percent of the bonding curve filled, while bonding
     Status          string          `json:"status"`
     LastChecked     time.Time       `json:"last_checked"`
     UpdateCount     int             `json:"update_count"`
     InfluenceMetrics InfluenceMetrics `json:"influence_metrics"`
     TokenData        TokenData        `json:"token_data"`
+    ContractAddress  string          `json:"contract_address,omitempty"`
+    CreatorAddress   string          `json:"creator_address,omitempty"` // Developer wallet shown on the agent's page
+    Socials          []SocialLink    `json:"socials,omitempty"`
+    OnChain          *OnChainData    `json:"on_chain,omitempty"`
+    Risk             *RiskScore      `json:"risk,omitempty"`
+    Heartbeat        *Heartbeat      `json:"heartbeat,omitempty"` // Latest lightweight liveness check
     LastError        string          `json:"last_error,omitempty"`
     ParseSuccess     bool            `json:"parse_success"`
     RetryCount      int             `json:"retry_count"`
@@ -57,11 +73,34 @@ type AgentIndex struct {
     Agents      []AgentSummary `json:"agents"`
 }
 
+// IndexDelta is what changed in the index since a point in time, so mirrors
+// can sync without re-fetching the whole index
+type IndexDelta struct {
+    Since       time.Time      `json:"since"`
+    LastUpdated time.Time      `json:"last_updated"`
+    Added       []AgentSummary `json:"added"`   // First seen after Since
+    Changed     []AgentSummary `json:"changed"` // Seen before Since, record written after it
+    Removed     []string       `json:"removed"` // IDs dropped from the index after Since
+    // Resync is set when no saved index version is old enough to tell what
+    // was removed; Removed is then empty and mirrors should fetch the full index
+    Resync bool `json:"resync,omitempty"`
+}
+
 // AgentSummary represents basic agent info for the index
 type AgentSummary struct {
-    ID    string `json:"id"`
-    Name  string `json:"name"`
-    Price string `json:"price"`
+    ID         string    `json:"id"`
+    Source     string    `json:"source,omitempty"`
+    SourceID   int       `json:"source_id,omitempty"`
+    Name       string    `json:"name"`
+    Price      string    `json:"price"`
+    FirstSeen  time.Time `json:"first_seen"`
+    LaunchedAt time.Time `json:"launched_at,omitempty"`
+    Status     string    `json:"status,omitempty"`
+    RiskScore  *int      `json:"risk_score,omitempty"`
+    Creator    string    `json:"creator,omitempty"`
+
+    Stage           string  `json:"stage,omitempty"`
+    BondingProgress float64 `json:"bonding_progress,omitempty"`
 }
 
 // GenerateID creates a unique ID for an agent
@@ -70,10 +109,34 @@ func (a *Agent) GenerateID() {
     a.ID = hex.EncodeToString(hash[:8]) // Use first 8 bytes for shorter ID
 }
 
-// Validate checks if the agent data is valid
+// IsAgentID reports whether id has the form GenerateID produces: 8 bytes in
+// lowercase hex. Anything else can't name a stored agent.
+func IsAgentID(id string) bool {
+    if len(id) != 16 {
+        return false
+    }
+    for _, c := range id {
+        if (c < '0' || c > '9') && (c < 'a' || c > 'f') {
+            return false
+        }
+    }
+    return true
+}
+
+// FieldError is a validation failure of one field, named by its JSON path
+type FieldError struct {
+    Field   string
+    Message string
+}
+
+func (e *FieldError) Error() string {
+    return e.Message
+}
+
+// Validate checks if the agent data is valid. Failures are *FieldError.
 func (a *Agent) Validate() error {
     if a.Name == "" {
-        return fmt.Errorf("agent name is required")
+        return &FieldError{Field: "name", Message: "agent name is required"}
     }
     if a.ID == "" {
         a.GenerateID()
@@ -81,6 +144,40 @@ func (a *Agent) Validate() error {
     return nil
 }
 
+// AmountField is a scraped display amount, named by its JSON path
+type AmountField struct {
+    Name  string
+    Value *string
+}
+
+// AmountFields returns the influence metrics and token data amounts, which
+// the scraper stores in canonical form
+func (a *Agent) AmountFields() []AmountField {
+    m, t := &a.InfluenceMetrics, &a.TokenData
+    return []AmountField{
+        {"influence_metrics.mindshare", &m.Mindshare},
+        {"influence_metrics.impressions", &m.Impressions},
+        {"influence_metrics.engagement", &m.Engagement},
+        {"influence_metrics.followers", &m.Followers},
+        {"influence_metrics.smart_followers", &m.SmartFollowers},
+        {"influence_metrics.top_tweets", &m.TopTweets},
+        {"token_data.mc_fdv", &t.MCFDV},
+        {"token_data.change_24h", &t.Change24h},
+        {"token_data.tvl", &t.TVL},
+        {"token_data.holders", &t.Holders},
+        {"token_data.volume_24h", &t.Volume24h},
+        {"token_data.inferences", &t.Inferences},
+    }
+}
+
+// NormalizeAmounts rewrites every amount field in canonical form with
+// NormalizeAmount. The price is left as scraped, since agent IDs derive from it.
+func (a *Agent) NormalizeAmounts() {
+    for _, field := range a.AmountFields() {
+        *field.Value = NormalizeAmount(*field.Value)
+    }
+}
+
 // ValidateAndClean checks and cleans agent data
 func (a *Agent) ValidateAndClean() {
     // Clean name
@@ -109,22 +206,108 @@ func (a *Agent) ValidateAndClean() {
 
 // ToSummary converts an Agent to AgentSummary
 func (a *Agent) ToSummary() AgentSummary {
-    return AgentSummary{
-        ID:    a.ID,
-        Name:  a.Name,
-        Price: a.Price,
+    summary := AgentSummary{
+        ID:         a.ID,
+        Source:     a.Source,
+        SourceID:   a.SourceID,
+        Name:       a.Name,
+        Price:      a.Price,
+        FirstSeen:  a.FirstSeen,
+        LaunchedAt: a.LaunchedAt,
+        Status:     a.Status,
+        Creator:    a.Creator(),
+
+        Stage:           a.Stage,
+        BondingProgress: a.BondingProgress,
+    }
+    if a.Risk != nil {
+        score := a.Risk.Score
+        summary.RiskScore = &score
+    }
+    return summary
+}
+
+// SourceName is the site the agent was scraped from; agents stored before
+// sources were recorded all came from Virtuals
+func (a *Agent) SourceName() string {
+    if a.Source == "" {
+        return SourceVirtuals
     }
+    return a.Source
 }
 
-// IsStale checks if the agent needs to be rechecked
-func (a *Agent) IsStale(duration time.Duration) bool {
-    return time.Since(a.LastChecked) > duration
+// LaunchDate is the agent's creation date from its page, or when it was
+// first seen if the page doesn't show one. It is zero when neither is known.
+func (a *Agent) LaunchDate() time.Time {
+    return launchDate(a.LaunchedAt, a.FirstSeen)
+}
+
+// IdentityKey identifies the indexed agent across ID schemes: its ID on its
+// source when known, otherwise its price-derived ID
+func (s AgentSummary) IdentityKey() string {
+    return identityKey(s.Source, s.SourceID, s.ID)
+}
+
+// IdentityKey is the agent's identity across ID schemes, see AgentSummary.IdentityKey
+func (a *Agent) IdentityKey() string {
+    return identityKey(a.Source, a.SourceID, a.ID)
+}
+
+func identityKey(source string, sourceID int, id string) string {
+    if sourceID <= 0 {
+        return id
+    }
+    if source == "" {
+        source = SourceVirtuals
+    }
+    return source + "#" + strconv.Itoa(sourceID)
+}
+
+// LaunchDate is the indexed agent's creation date, or when it was first seen
+func (s AgentSummary) LaunchDate() time.Time {
+    return launchDate(s.LaunchedAt, s.FirstSeen)
+}
+
+func launchDate(launchedAt, firstSeen time.Time) time.Time {
+    if !launchedAt.IsZero() && (firstSeen.IsZero() || launchedAt.Before(firstSeen)) {
+        return launchedAt
+    }
+    return firstSeen
+}
+
+// FormatAge renders an agent's age in whole days, or hours under a day
+func FormatAge(age time.Duration) string {
+    if age < 24*time.Hour {
+        return fmt.Sprintf("%dh", int(age.Hours()))
+    }
+    return fmt.Sprintf("%dd", int(age.Hours()/24))
+}
+
+// ParseAge reads an age such as "7d" or "36h"; whole days take a "d" suffix
+func ParseAge(raw string) (time.Duration, error) {
+    if days, ok := strings.CutSuffix(raw, "d"); ok {
+        n, err := strconv.Atoi(days)
+        if err != nil || n <= 0 {
+            return 0, fmt.Errorf("invalid age %q", raw)
+        }
+        return time.Duration(n) * 24 * time.Hour, nil
+    }
+    age, err := time.ParseDuration(raw)
+    if err != nil || age <= 0 {
+        return 0, fmt.Errorf("invalid age %q", raw)
+    }
+    return age, nil
+}
+
+// IsStale checks if the agent needs to be rechecked as of now
+func (a *Agent) IsStale(now time.Time, duration time.Duration) bool {
+    return now.Sub(a.LastChecked) > duration
 }
 
 // UpdateStatus determines the agent's status based on its data
 func (a *Agent) UpdateStatus() {
     switch {
-    case a.Price == "" && a.Description == "":
+    case a.Price == "" && a.Description == "", a.Heartbeat.Gone():
         a.Status = StatusDead
     case a.UpdateCount == 0:
         a.Status = StatusDefault
@@ -154,6 +337,9 @@ func FromAgentData(data *AgentData) *Agent {
         Price:       data.Price,
         ScrapedAt:   data.ScrapedAt,
     }
+    if stats := ParseAgentStats(data.Stats); !stats.Empty() {
+        agent.StatsDetail = &stats
+    }
     agent.GenerateID()
     return agent
 }
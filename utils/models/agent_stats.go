@@ -0,0 +1,114 @@
+package models
+
+import (
+    "fmt"
+    "regexp"
+    "strconv"
+    "strings"
+)
+
+// AgentStats is the stats section of an agent's page in typed form
+type AgentStats struct {
+    Rank       int              `json:"rank,omitempty"`  // Leaderboard position, 1 being the top
+    Score      float64          `json:"score,omitempty"` // Overall score
+    Components []StatsComponent `json:"components,omitempty"`
+}
+
+// StatsComponent is one part of the score breakdown, e.g. "social 45/100"
+type StatsComponent struct {
+    Name  string  `json:"name"` // Lowercase label
+    Value float64 `json:"value"`
+    Max   float64 `json:"max,omitempty"` // Out of, when the page shows it
+}
+
+// Empty reports whether nothing was parsed
+func (s AgentStats) Empty() bool {
+    return s.Rank == 0 && s.Score == 0 && len(s.Components) == 0
+}
+
+// Component returns the named part of the score breakdown
+func (s AgentStats) Component(name string) (StatsComponent, bool) {
+    for _, component := range s.Components {
+        if strings.EqualFold(component.Name, name) {
+            return component, true
+        }
+    }
+    return StatsComponent{}, false
+}
+
+// String renders the stats compactly for prompts, e.g.
+// "rank #12, score 78.5, social 45/100, utility 30"
+func (s AgentStats) String() string {
+    var parts []string
+    if s.Rank > 0 {
+        parts = append(parts, fmt.Sprintf("rank #%d", s.Rank))
+    }
+    if s.Score != 0 {
+        parts = append(parts, "score "+formatStat(s.Score))
+    }
+    for _, component := range s.Components {
+        part := component.Name + " " + formatStat(component.Value)
+        if component.Max > 0 {
+            part += "/" + formatStat(component.Max)
+        }
+        parts = append(parts, part)
+    }
+    return strings.Join(parts, ", ")
+}
+
+func formatStat(value float64) string {
+    return strconv.FormatFloat(value, 'f', -1, 64)
+}
+
+// statsSeparators split a stats blob into "label value" segments. Commas are
+// left alone since they group thousands.
+var statsSeparators = regexp.MustCompile(`[\n|;•·]+`)
+
+// statsSegment matches "label: value", "label #value" or "label value/max"
+var statsSegment = regexp.MustCompile(`^(.*?)[\s:=#-]*(-?[$]?[\d][\d.,]*\s*[kKmMbB%]?)(?:\s*(?:/|out of|of)\s*([\d][\d.,]*))?$`)
+
+// scoreLabels name the overall score rather than a component of it
+var scoreLabels = []string{"score", "agent score", "total score", "overall", "overall score"}
+
+// ParseAgentStats reads the free-text stats section of an agent's page into
+// typed fields. Segments that don't parse are skipped.
+func ParseAgentStats(raw string) AgentStats {
+    var stats AgentStats
+    for _, segment := range statsSeparators.Split(raw, -1) {
+        segment = strings.TrimSpace(segment)
+        match := statsSegment.FindStringSubmatch(segment)
+        if match == nil {
+            continue
+        }
+        label := strings.ToLower(strings.TrimSpace(strings.TrimRight(match[1], " :=#-")))
+        value, ok := ParseAmount(match[2])
+        if !ok {
+            continue
+        }
+
+        switch {
+        case strings.Contains(label, "rank") || (label == "" && strings.Contains(segment, "#")):
+            if stats.Rank == 0 && value >= 1 {
+                stats.Rank = int(value)
+            }
+        case containsFold(scoreLabels, label):
+            stats.Score = value
+        case label != "":
+            component := StatsComponent{Name: label, Value: value}
+            if match[3] != "" {
+                component.Max, _ = ParseAmount(match[3])
+            }
+            stats.Components = append(stats.Components, component)
+        }
+    }
+    return stats
+}
+
+// StatsText is the agent's stats for prompts: the typed stats when they
+// parsed, otherwise the raw blob
+func (a *Agent) StatsText() string {
+    if a.StatsDetail != nil && !a.StatsDetail.Empty() {
+        return a.StatsDetail.String()
+    }
+    return a.Stats
+}
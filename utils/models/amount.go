@@ -0,0 +1,252 @@
+package models
+
+import (
+    "math"
+    "strconv"
+    "strings"
+)
+
+// Amount is a scraped display value such as "$4.5m", "4,500,000" or "-1,2 %"
+// split into its parts
+type Amount struct {
+    Value    float64 // Full value with the magnitude suffix applied
+    Currency string  // "$", "€", "£" or "¥"; empty when none was shown
+    Suffix   string  // "K", "M", "B" or "T" when the value was abbreviated
+    Percent  bool
+
+    number float64 // Value before the suffix, kept to format without rounding noise
+}
+
+// amountCurrencies maps currency symbols and codes to the symbol they normalize to.
+// Longer codes come first so "US$" isn't read as "$".
+var amountCurrencies = []struct {
+    token  string
+    symbol string
+}{
+    {"US$", "$"}, {"USD", "$"}, {"EUR", "€"}, {"GBP", "£"}, {"JPY", "¥"}, {"CNY", "¥"},
+    {"$", "$"}, {"€", "€"}, {"£", "£"}, {"¥", "¥"},
+}
+
+// amountSuffixes maps magnitude words and letters, lowercased, to their
+// normalized suffix. Longer words come first so "mm" isn't read as "m".
+var amountSuffixes = []struct {
+    word   string
+    suffix string
+}{
+    {"thousand", "K"}, {"million", "M"}, {"billion", "B"}, {"trillion", "T"},
+    {"mil", "M"}, {"mln", "M"}, {"bln", "B"},
+    {"mm", "M"}, {"mn", "M"}, {"bn", "B"}, {"tn", "T"},
+    {"k", "K"}, {"m", "M"}, {"b", "B"}, {"t", "T"},
+}
+
+var suffixMultipliers = map[string]float64{"": 1, "K": 1e3, "M": 1e6, "B": 1e9, "T": 1e12}
+
+// emptyAmounts are placeholders pages show instead of a value
+var emptyAmounts = map[string]bool{"-": true, "--": true, "—": true, "n/a": true, "na": true, "none": true}
+
+// ParseAmount parses scraped display values like "$1.2M", "12,345", "3.4K",
+// "€1.234,56", "4.5 million USD" or "(1,200)" into their full value. Percent
+// values parse to their number, so "12.5%" is 12.5.
+func ParseAmount(raw string) (float64, bool) {
+    amount, ok := ParseAmountParts(raw)
+    return amount.Value, ok
+}
+
+// ParseAmountParts parses a scraped display value, keeping its currency,
+// magnitude suffix and percent sign. Separators are read the way the value
+// was most likely written: with both "," and "." the later one is the decimal
+// point, a repeated separator groups thousands, a lone comma followed by
+// exactly three digits groups thousands and any other lone separator is the
+// decimal point.
+func ParseAmountParts(raw string) (Amount, bool) {
+    s := strings.NewReplacer("\u00a0", " ", "\u202f", " ", "\u2009", " ", "\u2212", "-").Replace(raw)
+    s = strings.TrimSpace(s)
+    if s == "" || emptyAmounts[strings.ToLower(s)] {
+        return Amount{}, false
+    }
+
+    var amount Amount
+    negative := false
+    if strings.HasPrefix(s, "(") && strings.HasSuffix(s, ")") {
+        negative = true
+        s = strings.TrimSpace(s[1 : len(s)-1])
+    }
+
+    // Markers, signs and currency can come in either order around the number
+    for changed := true; changed && s != ""; {
+        changed = false
+        if trimmed := strings.TrimLeft(s, "~≈<> "); trimmed != s {
+            s, changed = trimmed, true
+        }
+        if s == "" {
+            break
+        }
+        switch s[0] {
+        case '-':
+            negative, s, changed = !negative, s[1:], true
+        case '+':
+            s, changed = s[1:], true
+        }
+        if strings.HasSuffix(s, "%") {
+            amount.Percent, s, changed = true, strings.TrimSpace(strings.TrimSuffix(s, "%")), true
+        }
+        for _, currency := range amountCurrencies {
+            if hasPrefixFold(s, currency.token) {
+                amount.Currency, s, changed = currency.symbol, strings.TrimSpace(s[len(currency.token):]), true
+                break
+            }
+            if hasSuffixFold(s, currency.token) {
+                amount.Currency, s, changed = currency.symbol, strings.TrimSpace(s[:len(s)-len(currency.token)]), true
+                break
+            }
+        }
+        if amount.Suffix == "" {
+            for _, suffix := range amountSuffixes {
+                if !hasSuffixFold(s, suffix.word) {
+                    continue
+                }
+                // The suffix must follow the number directly or after a space
+                rest := strings.TrimSpace(s[:len(s)-len(suffix.word)])
+                if rest != "" && isDigit(rest[len(rest)-1]) {
+                    amount.Suffix, s, changed = suffix.suffix, rest, true
+                    break
+                }
+            }
+        }
+    }
+
+    number, ok := parseNumber(s)
+    if !ok {
+        return Amount{}, false
+    }
+    if negative {
+        number = -number
+    }
+    amount.number = number
+    amount.Value = number * suffixMultipliers[amount.Suffix]
+    return amount, true
+}
+
+// parseNumber parses digits with thousands and decimal separators and an
+// optional exponent
+func parseNumber(s string) (float64, bool) {
+    // Spaces, apostrophes and underscores only ever group digits
+    s = strings.NewReplacer(" ", "", "'", "", "\u2019", "", "_", "").Replace(s)
+    if s == "" {
+        return 0, false
+    }
+    for i := 0; i < len(s); i++ {
+        if c := s[i]; !isDigit(c) && c != ',' && c != '.' && c != 'e' && c != 'E' && c != '-' && c != '+' {
+            return 0, false
+        }
+    }
+
+    mantissa, exponent := s, ""
+    if i := strings.IndexAny(s, "eE"); i >= 0 {
+        mantissa, exponent = s[:i], s[i:]
+    }
+
+    commas, dots := strings.Count(mantissa, ","), strings.Count(mantissa, ".")
+    switch {
+    case commas > 0 && dots > 0:
+        if strings.LastIndex(mantissa, ",") > strings.LastIndex(mantissa, ".") {
+            mantissa = strings.ReplaceAll(strings.ReplaceAll(mantissa, ".", ""), ",", ".")
+        } else {
+            mantissa = strings.ReplaceAll(mantissa, ",", "")
+        }
+    case commas > 1:
+        mantissa = strings.ReplaceAll(mantissa, ",", "")
+    case commas == 1:
+        whole, fraction, _ := strings.Cut(mantissa, ",")
+        if len(fraction) == 3 && whole != "" && whole != "0" && whole != "-0" {
+            mantissa = whole + fraction
+        } else {
+            mantissa = whole + "." + fraction
+        }
+    case dots > 1:
+        mantissa = strings.ReplaceAll(mantissa, ".", "")
+    }
+
+    number, err := strconv.ParseFloat(mantissa+exponent, 64)
+    if err != nil || math.IsInf(number, 0) || math.IsNaN(number) {
+        return 0, false
+    }
+    return number, true
+}
+
+// hasPrefixFold is strings.HasPrefix ignoring ASCII case
+func hasPrefixFold(s, prefix string) bool {
+    return len(s) >= len(prefix) && strings.EqualFold(s[:len(prefix)], prefix)
+}
+
+// hasSuffixFold is strings.HasSuffix ignoring ASCII case
+func hasSuffixFold(s, suffix string) bool {
+    return len(s) >= len(suffix) && strings.EqualFold(s[len(s)-len(suffix):], suffix)
+}
+
+func isDigit(c byte) bool {
+    return c >= '0' && c <= '9'
+}
+
+// String formats the amount canonically: currency symbol first, "." as the
+// decimal point, "," grouping thousands in unabbreviated values and an
+// uppercase suffix, e.g. "$4.5M", "4,500,000" or "-1.2%". The result parses
+// back to the same value.
+func (a Amount) String() string {
+    var b strings.Builder
+    if a.number < 0 {
+        b.WriteByte('-')
+    }
+    b.WriteString(a.Currency)
+    number := strconv.FormatFloat(math.Abs(a.number), 'f', -1, 64)
+    if a.Suffix == "" {
+        number = groupThousands(number)
+    }
+    b.WriteString(number)
+    b.WriteString(a.Suffix)
+    if a.Percent {
+        b.WriteByte('%')
+    }
+    return b.String()
+}
+
+// groupThousands inserts commas into the integer part of a formatted number
+func groupThousands(number string) string {
+    whole, fraction, hasFraction := strings.Cut(number, ".")
+    if len(whole) <= 3 {
+        return number
+    }
+    var b strings.Builder
+    for i, digit := range whole {
+        if i > 0 && (len(whole)-i)%3 == 0 {
+            b.WriteByte(',')
+        }
+        b.WriteRune(digit)
+    }
+    if hasFraction {
+        b.WriteString("." + fraction)
+    }
+    return b.String()
+}
+
+// NormalizeAmount rewrites a scraped display value in canonical form so the
+// same value always reads the same way, keeping its abbreviation and
+// currency. Values that don't parse are returned trimmed.
+func NormalizeAmount(raw string) string {
+    amount, ok := ParseAmountParts(raw)
+    if !ok {
+        return strings.TrimSpace(raw)
+    }
+    return amount.String()
+}
+
+// SameAmount reports whether two display values are equal or parse to the
+// same amount, so "$4.5m" and "$4,500,000" compare equal
+func SameAmount(a, b string) bool {
+    if a == b {
+        return true
+    }
+    x, okX := ParseAmountParts(a)
+    y, okY := ParseAmountParts(b)
+    return okX && okY && x.Value == y.Value && x.Percent == y.Percent
+}
@@ -0,0 +1,136 @@
+package models
+
+import (
+    "testing"
+)
+
+func TestParseAmount(t *testing.T) {
+    tests := []struct {
+        raw   string
+        want  float64
+        valid bool
+    }{
+        // Plain numbers and thousands separators
+        {"0", 0, true},
+        {"42", 42, true},
+        {"12,345", 12345, true},
+        {"1,234,567", 1234567, true},
+        {"1.234.567", 1234567, true},
+        {"12 345", 12345, true},
+        {"1'234", 1234, true},
+        {"3.14", 3.14, true},
+        {"0,5", 0.5, true},
+        {"1,5", 1.5, true},
+        {"€1.234,56", 1234.56, true},
+        {"$1,234.56", 1234.56, true},
+
+        // Magnitude suffixes
+        {"3.4K", 3400, true},
+        {"3.4k", 3400, true},
+        {"$1.2M", 1.2e6, true},
+        {"$4.5m", 4.5e6, true},
+        {"2B", 2e9, true},
+        {"1.5T", 1.5e12, true},
+        {"4.5 million USD", 4.5e6, true},
+        {"7 thousand", 7000, true},
+        {"1.2bn", 1.2e9, true},
+        {"3mm", 3e6, true},
+
+        // Signs, parentheses and percents
+        {"-12", -12, true},
+        {"+12", 12, true},
+        {"−7.5", -7.5, true},
+        {"(1,200)", -1200, true},
+        {"-$3.2K", -3200, true},
+        {"$-3.2K", -3200, true},
+        {"12.5%", 12.5, true},
+        {"-1,2 %", -1.2, true},
+        {"~$10", 10, true},
+        {"1e3", 1000, true},
+
+        // Garbage and placeholders
+        {"", 0, false},
+        {"   ", 0, false},
+        {"-", 0, false},
+        {"--", 0, false},
+        {"—", 0, false},
+        {"N/A", 0, false},
+        {"none", 0, false},
+        {"abc", 0, false},
+        {"$", 0, false},
+        {"12abc", 0, false},
+        {"K", 0, false},
+        {"1..2,,3x", 0, false},
+        {"1e999", 0, false},
+    }
+    for _, tt := range tests {
+        got, ok := ParseAmount(tt.raw)
+        if ok != tt.valid {
+            t.Errorf("ParseAmount(%q) ok = %v, want %v", tt.raw, ok, tt.valid)
+            continue
+        }
+        if ok && got != tt.want {
+            t.Errorf("ParseAmount(%q) = %v, want %v", tt.raw, got, tt.want)
+        }
+    }
+}
+
+func TestNormalizeAmount(t *testing.T) {
+    tests := []struct {
+        raw  string
+        want string
+    }{
+        {"$4.5m", "$4.5M"},
+        {"4500000", "4,500,000"},
+        {"4.5 million USD", "$4.5M"},
+        {"(1,200)", "-1,200"},
+        {"-1,2 %", "-1.2%"},
+        {"€1.234,56", "€1,234.56"},
+        {" n/a ", "n/a"},
+    }
+    for _, tt := range tests {
+        if got := NormalizeAmount(tt.raw); got != tt.want {
+            t.Errorf("NormalizeAmount(%q) = %q, want %q", tt.raw, got, tt.want)
+        }
+    }
+}
+
+func TestSameAmount(t *testing.T) {
+    if !SameAmount("$4.5m", "$4,500,000") {
+        t.Error("SameAmount($4.5m, $4,500,000) = false, want true")
+    }
+    if SameAmount("12%", "12") {
+        t.Error("SameAmount(12%, 12) = true, want false")
+    }
+    if SameAmount("abc", "def") {
+        t.Error("SameAmount(abc, def) = true, want false")
+    }
+}
+
+// FuzzParseAmount checks that any value that parses formats canonically to a
+// string that parses back to the same amount, and that formatting is stable
+func FuzzParseAmount(f *testing.F) {
+    for _, seed := range []string{
+        "$1.2M", "12,345", "3.4K", "€1.234,56", "4.5 million USD", "(1,200)",
+        "12.5%", "-1,2 %", "0,5", "1e3", "US$ 7bn", "¥-0.001", "n/a", "",
+    } {
+        f.Add(seed)
+    }
+    f.Fuzz(func(t *testing.T, raw string) {
+        amount, ok := ParseAmountParts(raw)
+        if !ok {
+            return
+        }
+        formatted := amount.String()
+        again, ok := ParseAmountParts(formatted)
+        if !ok {
+            t.Fatalf("%q formatted as %q, which doesn't parse", raw, formatted)
+        }
+        if again.Value != amount.Value || again.Percent != amount.Percent || again.Currency != amount.Currency {
+            t.Fatalf("%q parsed as %+v but its format %q parsed as %+v", raw, amount, formatted, again)
+        }
+        if again.String() != formatted {
+            t.Fatalf("%q formatted as %q, then as %q", raw, formatted, again.String())
+        }
+    })
+}
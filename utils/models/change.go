@@ -0,0 +1,111 @@
+package models
+
+import (
+    "strings"
+    "time"
+)
+
+// Change event types surfaced in the change feed
+const (
+    ChangeDescriptionUpdated = "description_updated"
+    ChangeHoldersSpike       = "holders_spike"
+    ChangeVolumeSpike        = "volume_spike"
+    ChangePriceMove          = "price_move"
+    ChangeStatusChanged      = "status_changed"
+    ChangeStageChanged       = "stage_changed"   // Moved to another launch stage, e.g. graduated
+    ChangeNearGraduation     = "near_graduation" // Bonding curve progress reached NearGraduationProgress
+)
+
+// IsAnomaly reports whether the event type is a statistical anomaly alert
+func IsAnomaly(eventType string) bool {
+    return eventType == ChangeHoldersSpike || eventType == ChangeVolumeSpike || eventType == ChangePriceMove
+}
+
+// IsStageAlert reports whether the event type is a launch stage alert
+func IsStageAlert(eventType string) bool {
+    return eventType == ChangeStageChanged || eventType == ChangeNearGraduation
+}
+
+// Notification severity tiers
+const (
+    SeverityInfo     = "info"     // Batched into the next digest
+    SeverityNormal   = "normal"   // Sent when it happens, held during quiet hours
+    SeverityCritical = "critical" // Sent at once, even during quiet hours
+)
+
+// MassiveDumpThreshold is the 24h price drop, as a fraction, that makes a
+// price move critical
+const MassiveDumpThreshold = 0.7
+
+// ChangeSeverity decides how urgently subscribers hear about a change: an
+// agent marked dead or a massive dump is critical, other anomalies are
+// normal and the rest is informational
+func ChangeSeverity(event ChangeEvent) string {
+    switch event.Type {
+    case ChangeStatusChanged:
+        if event.After == StatusDead {
+            return SeverityCritical
+        }
+    case ChangePriceMove:
+        before, okBefore := ParseAmount(event.Before)
+        after, okAfter := ParseAmount(event.After)
+        if okBefore && okAfter && before > 0 && (before-after)/before >= MassiveDumpThreshold {
+            return SeverityCritical
+        }
+    }
+    if IsAnomaly(event.Type) || IsStageAlert(event.Type) {
+        return SeverityNormal
+    }
+    return SeverityInfo
+}
+
+// ChangeEvent records a notable change detected on an agent during a scrape
+type ChangeEvent struct {
+    ID        string    `json:"id"`
+    AgentID   string    `json:"agent_id"`
+    SourceID  int       `json:"source_id"`
+    AgentName string    `json:"agent_name"`
+    Type      string    `json:"type"`
+    Before    string    `json:"before,omitempty"`
+    After     string    `json:"after,omitempty"`
+    Added     []string  `json:"added,omitempty"`
+    Removed   []string  `json:"removed,omitempty"`
+    Summary   string    `json:"summary,omitempty"`
+    At        time.Time `json:"at"`
+}
+
+// DescriptionDiff compares two descriptions sentence by sentence and returns
+// the sentences that were added and removed
+func DescriptionDiff(before, after string) (added, removed []string) {
+    beforeSet := make(map[string]bool)
+    for _, sentence := range splitSentences(before) {
+        beforeSet[sentence] = true
+    }
+    afterSet := make(map[string]bool)
+    for _, sentence := range splitSentences(after) {
+        afterSet[sentence] = true
+        if !beforeSet[sentence] {
+            added = append(added, sentence)
+        }
+    }
+    for _, sentence := range splitSentences(before) {
+        if !afterSet[sentence] {
+            removed = append(removed, sentence)
+        }
+    }
+    return added, removed
+}
+
+func splitSentences(text string) []string {
+    fields := strings.FieldsFunc(text, func(r rune) bool {
+        return r == '.' || r == '!' || r == '?' || r == '\n'
+    })
+
+    var sentences []string
+    for _, field := range fields {
+        if sentence := strings.Join(strings.Fields(field), " "); sentence != "" {
+            sentences = append(sentences, sentence)
+        }
+    }
+    return sentences
+}
@@ -0,0 +1,85 @@
+package models
+
+import (
+    "fmt"
+    "strings"
+)
+
+// ChatSettings are a chat's preferences for what the bot shows it
+type ChatSettings struct {
+    Filter    AgentFilter `json:"filter"`
+    Verbosity string      `json:"verbosity,omitempty"` // Reply verbosity for members without their own, see /style
+}
+
+// AgentFilter limits the agents a chat sees in listings and alerts. Empty
+// fields don't filter, so the zero value allows every agent.
+type AgentFilter struct {
+    Sources      []string `json:"sources,omitempty"`    // Lowercase source names, e.g. "virtuals"
+    Categories   []string `json:"categories,omitempty"` // Lowercase categories, e.g. "entertainment"
+    MinMarketCap float64  `json:"min_market_cap,omitempty"`
+}
+
+// Empty reports whether the filter allows every agent
+func (f AgentFilter) Empty() bool {
+    return len(f.Sources) == 0 && len(f.Categories) == 0 && f.MinMarketCap <= 0
+}
+
+// Allows reports whether agent passes the filter. Agents whose market cap
+// doesn't parse are hidden by a minimum market cap.
+func (f AgentFilter) Allows(agent *Agent) bool {
+    if len(f.Sources) > 0 && !containsFold(f.Sources, agent.SourceName()) {
+        return false
+    }
+    if len(f.Categories) > 0 && (agent.Category == "" || !containsFold(f.Categories, agent.Category)) {
+        return false
+    }
+    if f.MinMarketCap > 0 {
+        mcap, ok := ParseAmount(agent.TokenData.MCFDV)
+        if !ok || mcap < f.MinMarketCap {
+            return false
+        }
+    }
+    return true
+}
+
+func (f AgentFilter) String() string {
+    if f.Empty() {
+        return "all agents"
+    }
+    var parts []string
+    if len(f.Sources) > 0 {
+        parts = append(parts, "source "+strings.Join(f.Sources, ", "))
+    }
+    if len(f.Categories) > 0 {
+        parts = append(parts, "category "+strings.Join(f.Categories, ", "))
+    }
+    if f.MinMarketCap > 0 {
+        parts = append(parts, fmt.Sprintf("market cap ≥ %s", Amount{Value: f.MinMarketCap, Currency: "$", number: f.MinMarketCap}))
+    }
+    return strings.Join(parts, "; ")
+}
+
+// ParseFilterList reads a comma-separated list of sources or categories,
+// lowercased; "any" or "all" clears it
+func ParseFilterList(raw string) []string {
+    var values []string
+    for _, value := range strings.Split(raw, ",") {
+        value = strings.ToLower(strings.TrimSpace(value))
+        if value == "any" || value == "all" {
+            return nil
+        }
+        if value != "" {
+            values = append(values, value)
+        }
+    }
+    return values
+}
+
+func containsFold(values []string, value string) bool {
+    for _, v := range values {
+        if strings.EqualFold(v, value) {
+            return true
+        }
+    }
+    return false
+}
@@ -0,0 +1,169 @@
+package models
+
+import (
+    "fmt"
+    "sort"
+    "strings"
+)
+
+// MaxCompareAgents caps how many agents one comparison covers
+const MaxCompareAgents = 10
+
+// ComparisonMetric is one numeric field compared across agents
+type ComparisonMetric struct {
+    Key          string `json:"key"`
+    Label        string `json:"label"`
+    HigherBetter bool   `json:"higher_is_better"` // Whether rank 1 goes to the highest value
+}
+
+// ComparedAgent identifies an agent in a comparison
+type ComparedAgent struct {
+    ID       string `json:"id"`
+    Name     string `json:"name"`
+    Status   string `json:"status,omitempty"`
+    Category string `json:"category,omitempty"`
+}
+
+// ComparisonMatrix aligns numeric metrics across agents for rendering as a
+// table. Values[m][a] is metric m of agent a, nil when the agent doesn't
+// report it; Ranks[m][a] is its rank among the agents that do, 1 being best,
+// with ties sharing a rank and 0 for missing values.
+type ComparisonMatrix struct {
+    Agents     []ComparedAgent    `json:"agents"`
+    Metrics    []ComparisonMetric `json:"metrics"`
+    Values     [][]*float64       `json:"values"`
+    Ranks      [][]int            `json:"ranks"`
+    Commentary string             `json:"commentary,omitempty"` // LLM's take on the comparison, when asked for
+}
+
+// comparedMetric pairs a metric with how to read it from an agent
+type comparedMetric struct {
+    ComparisonMetric
+    value func(*Agent) (float64, bool)
+}
+
+// amountMetric reads a scraped amount such as "$1.2m" or "-4.5%"
+func amountMetric(key, label string, higherBetter bool, field func(*Agent) string) comparedMetric {
+    return comparedMetric{
+        ComparisonMetric: ComparisonMetric{Key: key, Label: label, HigherBetter: higherBetter},
+        value:            func(a *Agent) (float64, bool) { return ParseAmount(field(a)) },
+    }
+}
+
+// comparedMetrics are the metrics in a comparison, in table order
+var comparedMetrics = []comparedMetric{
+    amountMetric("price", "Price", true, func(a *Agent) string { return a.Price }),
+    amountMetric("market_cap", "Market cap / FDV", true, func(a *Agent) string { return a.TokenData.MCFDV }),
+    amountMetric("change_24h", "24h change", true, func(a *Agent) string { return a.TokenData.Change24h }),
+    amountMetric("volume_24h", "24h volume", true, func(a *Agent) string { return a.TokenData.Volume24h }),
+    amountMetric("tvl", "TVL", true, func(a *Agent) string { return a.TokenData.TVL }),
+    amountMetric("holders", "Holders", true, func(a *Agent) string { return a.TokenData.Holders }),
+    amountMetric("inferences", "Inferences", true, func(a *Agent) string { return a.TokenData.Inferences }),
+    amountMetric("mindshare", "Mindshare", true, func(a *Agent) string { return a.InfluenceMetrics.Mindshare }),
+    amountMetric("impressions", "Impressions", true, func(a *Agent) string { return a.InfluenceMetrics.Impressions }),
+    amountMetric("engagement", "Engagement", true, func(a *Agent) string { return a.InfluenceMetrics.Engagement }),
+    amountMetric("followers", "Followers", true, func(a *Agent) string { return a.InfluenceMetrics.Followers }),
+    amountMetric("smart_followers", "Smart followers", true, func(a *Agent) string { return a.InfluenceMetrics.SmartFollowers }),
+    {
+        ComparisonMetric: ComparisonMetric{Key: "risk_score", Label: "Risk score", HigherBetter: false},
+        value: func(a *Agent) (float64, bool) {
+            if a.Risk == nil {
+                return 0, false
+            }
+            return float64(a.Risk.Score), true
+        },
+    },
+    {
+        ComparisonMetric: ComparisonMetric{Key: "top_holder_share", Label: "Top holder share", HigherBetter: false},
+        value: func(a *Agent) (float64, bool) {
+            if a.OnChain == nil || a.OnChain.HoldersSampled == 0 {
+                return 0, false
+            }
+            return a.OnChain.TopHolderShare, true
+        },
+    },
+}
+
+// CompareAgents builds the metric matrix for agents, in the order given
+func CompareAgents(agents []*Agent) ComparisonMatrix {
+    matrix := ComparisonMatrix{
+        Agents:  make([]ComparedAgent, len(agents)),
+        Metrics: make([]ComparisonMetric, len(comparedMetrics)),
+        Values:  make([][]*float64, len(comparedMetrics)),
+        Ranks:   make([][]int, len(comparedMetrics)),
+    }
+    for i, agent := range agents {
+        matrix.Agents[i] = ComparedAgent{ID: agent.ID, Name: agent.Name, Status: agent.Status, Category: agent.Category}
+    }
+    for m, metric := range comparedMetrics {
+        matrix.Metrics[m] = metric.ComparisonMetric
+        values := make([]*float64, len(agents))
+        for i, agent := range agents {
+            if value, ok := metric.value(agent); ok {
+                values[i] = &value
+            }
+        }
+        matrix.Values[m] = values
+        matrix.Ranks[m] = rankValues(values, metric.HigherBetter)
+    }
+    return matrix
+}
+
+// rankValues ranks the present values best first, ties sharing the better
+// rank ("1, 2, 2, 4"); missing values get 0
+func rankValues(values []*float64, higherBetter bool) []int {
+    order := make([]int, 0, len(values))
+    for i, value := range values {
+        if value != nil {
+            order = append(order, i)
+        }
+    }
+    sort.SliceStable(order, func(i, j int) bool {
+        a, b := *values[order[i]], *values[order[j]]
+        if higherBetter {
+            return a > b
+        }
+        return a < b
+    })
+
+    ranks := make([]int, len(values))
+    for position, i := range order {
+        if position > 0 && *values[i] == *values[order[position-1]] {
+            ranks[i] = ranks[order[position-1]]
+        } else {
+            ranks[i] = position + 1
+        }
+    }
+    return ranks
+}
+
+// Table renders the matrix as plain text, one line per metric, for prompts
+// and chat messages
+func (m ComparisonMatrix) Table() string {
+    var b strings.Builder
+    names := make([]string, len(m.Agents))
+    for i, agent := range m.Agents {
+        names[i] = agent.Name
+    }
+    fmt.Fprintf(&b, "Metric | %s\n", strings.Join(names, " | "))
+    for i, metric := range m.Metrics {
+        cells := make([]string, len(m.Agents))
+        for a := range m.Agents {
+            cells[a] = "n/a"
+            if value := m.Values[i][a]; value != nil {
+                cells[a] = fmt.Sprintf("%s (#%d)", formatComparedValue(*value), m.Ranks[i][a])
+            }
+        }
+        direction := "higher is better"
+        if !metric.HigherBetter {
+            direction = "lower is better"
+        }
+        fmt.Fprintf(&b, "%s (%s) | %s\n", metric.Label, direction, strings.Join(cells, " | "))
+    }
+    return b.String()
+}
+
+// formatComparedValue prints a value without trailing zeros
+func formatComparedValue(value float64) string {
+    return strings.TrimRight(strings.TrimRight(fmt.Sprintf("%.4f", value), "0"), ".")
+}
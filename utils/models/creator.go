@@ -0,0 +1,89 @@
+package models
+
+import (
+    "sort"
+    "strings"
+)
+
+// SerialLauncherThreshold is how many of a creator's agents must be dead
+// before the creator is flagged as a serial launcher
+const SerialLauncherThreshold = 2
+
+const zeroAddress = "0x0000000000000000000000000000000000000000"
+
+// Creator is a developer wallet and the agents launched from it
+type Creator struct {
+    Address string         `json:"address"`
+    Agents  []AgentSummary `json:"agents"`
+    Dead    int            `json:"dead"` // Agents whose status is dead
+}
+
+// SerialLauncher reports whether the creator has left enough dead agents
+// behind to be a risk signal for its other launches
+func (c Creator) SerialLauncher() bool {
+    return c.Dead >= SerialLauncherThreshold
+}
+
+// DeadAgents returns the creator's dead agents, except the one with skipID
+func (c Creator) DeadAgents(skipID string) []AgentSummary {
+    var dead []AgentSummary
+    for _, agent := range c.Agents {
+        if agent.Status == StatusDead && agent.ID != skipID {
+            dead = append(dead, agent)
+        }
+    }
+    return dead
+}
+
+// Creator returns the agent's developer wallet: the one shown on its page,
+// or else the token's on-chain owner. Renounced tokens, owned by the zero
+// address or by themselves, have no on-chain creator.
+func (a *Agent) Creator() string {
+    if a.CreatorAddress != "" {
+        return strings.ToLower(a.CreatorAddress)
+    }
+    if a.OnChain == nil {
+        return ""
+    }
+    owner := strings.ToLower(a.OnChain.Owner)
+    if owner == zeroAddress || owner == strings.ToLower(a.ContractAddress) {
+        return ""
+    }
+    return owner
+}
+
+// GroupByCreator groups indexed agents by developer wallet, skipping agents
+// with no known creator. Creators with the most dead agents come first, then
+// those with the most launches.
+func GroupByCreator(summaries []AgentSummary) []Creator {
+    byAddress := make(map[string]*Creator)
+    var order []string
+    for _, summary := range summaries {
+        address := strings.ToLower(summary.Creator)
+        if address == "" {
+            continue
+        }
+        creator, exists := byAddress[address]
+        if !exists {
+            creator = &Creator{Address: address}
+            byAddress[address] = creator
+            order = append(order, address)
+        }
+        creator.Agents = append(creator.Agents, summary)
+        if summary.Status == StatusDead {
+            creator.Dead++
+        }
+    }
+
+    creators := make([]Creator, 0, len(order))
+    for _, address := range order {
+        creators = append(creators, *byAddress[address])
+    }
+    sort.SliceStable(creators, func(i, j int) bool {
+        if creators[i].Dead != creators[j].Dead {
+            return creators[i].Dead > creators[j].Dead
+        }
+        return len(creators[i].Agents) > len(creators[j].Agents)
+    })
+    return creators
+}
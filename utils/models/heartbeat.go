@@ -0,0 +1,50 @@
+package models
+
+import "time"
+
+// HeartbeatMissesGone is how many checks in a row must find an agent gone
+// before it is treated as delisted
+const HeartbeatMissesGone = 3
+
+// Heartbeat is the latest lightweight liveness check of an agent, made far
+// more often than full scrapes
+type Heartbeat struct {
+    CheckedAt  time.Time `json:"checked_at"`
+    Alive      bool      `json:"alive"`
+    StatusCode int       `json:"status_code,omitempty"`
+    Misses     int       `json:"misses,omitempty"` // Consecutive checks that found the agent gone
+    LastAlive  time.Time `json:"last_alive,omitempty"`
+}
+
+// Gone reports whether enough checks in a row found the agent gone
+func (h *Heartbeat) Gone() bool {
+    return h != nil && h.Misses >= HeartbeatMissesGone
+}
+
+// Heartbeat check outcomes
+const (
+    HeartbeatAlive   = "alive"
+    HeartbeatGone    = "gone"
+    HeartbeatUnknown = "unknown" // Timeouts and server errors say nothing about the agent
+)
+
+// Next returns the heartbeat after a check at now with the given outcome.
+// Unknown outcomes leave the miss count alone.
+func (h *Heartbeat) Next(outcome string, statusCode int, now time.Time) Heartbeat {
+    var next Heartbeat
+    if h != nil {
+        next = *h
+    }
+    next.CheckedAt = now
+    next.StatusCode = statusCode
+    switch outcome {
+    case HeartbeatAlive:
+        next.Alive = true
+        next.Misses = 0
+        next.LastAlive = now
+    case HeartbeatGone:
+        next.Alive = false
+        next.Misses++
+    }
+    return next
+}
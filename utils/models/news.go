@@ -0,0 +1,15 @@
+package models
+
+import "time"
+
+// NewsArticle is a news item pulled from a feed, tagged with the agents it mentions
+type NewsArticle struct {
+    ID          string    `json:"id"`
+    Title       string    `json:"title"`
+    URL         string    `json:"url"`
+    Source      string    `json:"source"`
+    Summary     string    `json:"summary,omitempty"`
+    PublishedAt time.Time `json:"published_at"`
+    FetchedAt   time.Time `json:"fetched_at"`
+    AgentIDs    []string  `json:"agent_ids"`
+}
@@ -0,0 +1,23 @@
+package models
+
+import "time"
+
+// OnChainData holds token facts read directly from the chain
+type OnChainData struct {
+    Chain          string    `json:"chain"`
+    TotalSupply    string    `json:"total_supply"`
+    Decimals       int       `json:"decimals"`
+    TopHolderShare float64   `json:"top_holder_share"`
+    HoldersSampled int       `json:"holders_sampled"`
+    Pool           *PoolInfo `json:"pool,omitempty"`
+    Owner          string    `json:"owner,omitempty"` // What the token's owner() returns, when it has a live owner
+    FetchedAt      time.Time `json:"fetched_at"`
+}
+
+// PoolInfo describes the token's main liquidity pool
+type PoolInfo struct {
+    Address      string `json:"address"`
+    QuoteToken   string `json:"quote_token"`
+    TokenReserve string `json:"token_reserve"`
+    QuoteReserve string `json:"quote_reserve"`
+}
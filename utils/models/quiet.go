@@ -0,0 +1,62 @@
+package models
+
+import (
+    "fmt"
+    "strings"
+    "time"
+)
+
+// QuietHours is a daily window, in a chat's time zone, during which
+// non-critical notifications are held back
+type QuietHours struct {
+    Start    string `json:"start"`              // HH:MM
+    End      string `json:"end"`                // HH:MM; before Start wraps past midnight
+    Timezone string `json:"timezone,omitempty"` // IANA name; empty uses the server schedule zone
+}
+
+// ParseQuietHours reads a "HH:MM-HH:MM" window with an optional IANA time zone
+func ParseQuietHours(window, timezone string) (QuietHours, error) {
+    start, end, found := strings.Cut(window, "-")
+    if !found {
+        return QuietHours{}, fmt.Errorf("quiet hours must look like 23:00-07:00")
+    }
+    hours := QuietHours{Start: strings.TrimSpace(start), End: strings.TrimSpace(end), Timezone: timezone}
+    for _, clock := range []string{hours.Start, hours.End} {
+        if _, err := time.Parse("15:04", clock); err != nil {
+            return QuietHours{}, fmt.Errorf("invalid time %q, use HH:MM", clock)
+        }
+    }
+    if hours.Start == hours.End {
+        return QuietHours{}, fmt.Errorf("quiet hours must not start and end at the same time")
+    }
+    if timezone != "" {
+        if _, err := time.LoadLocation(timezone); err != nil {
+            return QuietHours{}, fmt.Errorf("unknown time zone %q", timezone)
+        }
+    }
+    return hours, nil
+}
+
+// Active reports whether now falls inside the window, using fallback when
+// no time zone is set
+func (q QuietHours) Active(now time.Time, fallback *time.Location) bool {
+    loc := fallback
+    if q.Timezone != "" {
+        if tz, err := time.LoadLocation(q.Timezone); err == nil {
+            loc = tz
+        }
+    }
+    clock := now.In(loc).Format("15:04")
+    if q.Start < q.End {
+        return clock >= q.Start && clock < q.End
+    }
+    return clock >= q.Start || clock < q.End
+}
+
+// String formats the window for display
+func (q QuietHours) String() string {
+    if q.Timezone == "" {
+        return q.Start + "-" + q.End
+    }
+    return fmt.Sprintf("%s-%s %s", q.Start, q.End, q.Timezone)
+}
@@ -0,0 +1,38 @@
+package models
+
+import "time"
+
+// ReportWeekly is the kind of the weekly "state of the agents" report
+const ReportWeekly = "weekly"
+
+// AgentMove is an agent's market cap change over a report period
+type AgentMove struct {
+    ID        string  `json:"id"`
+    Name      string  `json:"name"`
+    FromMCap  float64 `json:"from_mcap"`
+    ToMCap    float64 `json:"to_mcap"`
+    ChangePct float64 `json:"change_pct"`
+}
+
+// ReportAggregates are the numbers a periodic report is written from
+type ReportAggregates struct {
+    TotalAgents    int            `json:"total_agents"`
+    NewAgents      []AgentSummary `json:"new_agents"`
+    DeadAgents     []AgentSummary `json:"dead_agents"`
+    TopGainers     []AgentMove    `json:"top_gainers"`
+    TopLosers      []AgentMove    `json:"top_losers"`
+    TotalMCap      float64        `json:"total_mcap"`
+    TotalVolume24h float64        `json:"total_volume_24h"`
+    TotalHolders   float64        `json:"total_holders"`
+    MCapChangePct  float64        `json:"mcap_change_pct"`
+}
+
+// Report is a generated long-form market report
+type Report struct {
+    Kind        string           `json:"kind"`
+    PeriodStart time.Time        `json:"period_start"`
+    PeriodEnd   time.Time        `json:"period_end"`
+    GeneratedAt time.Time        `json:"generated_at"`
+    Aggregates  ReportAggregates `json:"aggregates"`
+    Text        string           `json:"text"`
+}
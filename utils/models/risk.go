@@ -0,0 +1,39 @@
+package models
+
+import "time"
+
+// Risk levels derived from the risk score
+const (
+    RiskLow    = "low"
+    RiskMedium = "medium"
+    RiskHigh   = "high"
+)
+
+// RiskFactor is one input to an agent's risk score, scored 0 (safe) to 100 (risky)
+type RiskFactor struct {
+    Name   string  `json:"name"`
+    Score  int     `json:"score"`
+    Weight float64 `json:"weight"`
+    Detail string  `json:"detail"`
+}
+
+// RiskScore is a 0-100 risk rating combining heuristics and an LLM assessment
+type RiskScore struct {
+    Score      int          `json:"score"`
+    Level      string       `json:"level"`
+    Factors    []RiskFactor `json:"factors"`
+    Assessment string       `json:"assessment,omitempty"` // LLM's qualitative take
+    ComputedAt time.Time    `json:"computed_at"`
+}
+
+// RiskLevel buckets a 0-100 score
+func RiskLevel(score int) string {
+    switch {
+    case score >= 67:
+        return RiskHigh
+    case score >= 34:
+        return RiskMedium
+    default:
+        return RiskLow
+    }
+}
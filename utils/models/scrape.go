@@ -0,0 +1,94 @@
+package models
+
+import (
+    "context"
+    "errors"
+    "time"
+)
+
+// Scrape sources recorded in the run history
+const (
+    SourceVirtuals = "virtuals"
+    SourceNews     = "news"
+)
+
+// ScrapeRun summarizes one completed scrape cycle of a source
+type ScrapeRun struct {
+    Source      string         `json:"source"`
+    Profile     string         `json:"profile,omitempty"` // Scrape profile the run used, if the source has them
+    StartedAt   time.Time      `json:"started_at"`
+    Duration    time.Duration  `json:"duration"`
+    Attempted   int            `json:"attempted"`
+    Succeeded   int            `json:"succeeded"`
+    Failed      int            `json:"failed"`
+    Quarantined int            `json:"quarantined,omitempty"`
+    Errors      map[string]int `json:"errors,omitempty"` // Failures by ScrapeError kind
+    Dropped     int            `json:"dropped,omitempty"` // Items shed by backpressure, retried next run
+    Stages      map[string]StageStats `json:"stages,omitempty"` // Queue saturation by stage
+}
+
+// StageStats are the saturation metrics of the bounded queue feeding one
+// scrape pipeline stage
+type StageStats struct {
+    Capacity   int           `json:"capacity"`
+    Depth      int           `json:"depth"` // Items waiting when the stats were taken
+    MaxDepth   int           `json:"max_depth"`
+    Passed     int           `json:"passed"` // Items handed to the stage
+    Dropped    int           `json:"dropped,omitempty"`
+    Blocked    time.Duration `json:"blocked,omitempty"` // Time producers waited on a full queue
+    SlowWrites int           `json:"slow_writes,omitempty"`
+    Trips      int           `json:"trips,omitempty"`  // Times slow writes tripped the breaker
+    Paused     time.Duration `json:"paused,omitempty"` // Time producers were held by the open breaker
+}
+
+// SuccessRate is the share of attempted items that succeeded, 0 when nothing was attempted
+func (r ScrapeRun) SuccessRate() float64 {
+    if r.Attempted == 0 {
+        return 0
+    }
+    return float64(r.Succeeded) / float64(r.Attempted)
+}
+
+// Scrape failure kinds
+const (
+    ScrapeErrTimeout  = "navigation_timeout" // Page didn't finish loading in time
+    ScrapeErrNotFound = "not_found"          // Page doesn't exist (404)
+    ScrapeErrBlocked  = "blocked"            // Refused, rate limited or challenged by bot protection
+    ScrapeErrSelector = "selector_miss"      // Page rendered but an expected element is missing
+    ScrapeErrEmpty    = "parse_empty"        // Page rendered with none of the expected content
+    ScrapeErrStorage  = "storage"            // Fetched or parsed fine but couldn't be stored
+    ScrapeErrOther    = "other"
+)
+
+// ScrapeError is a fetch or parse failure classified by kind
+type ScrapeError struct {
+    Kind string
+    Err  error
+}
+
+func (e *ScrapeError) Error() string {
+    return e.Kind + ": " + e.Err.Error()
+}
+
+func (e *ScrapeError) Unwrap() error {
+    return e.Err
+}
+
+// NewScrapeError wraps err with a failure kind
+func NewScrapeError(kind string, err error) error {
+    return &ScrapeError{Kind: kind, Err: err}
+}
+
+// ScrapeErrorKind returns the kind of a ScrapeError anywhere in err's chain.
+// Unclassified deadline errors count as timeouts and the rest as other.
+func ScrapeErrorKind(err error) string {
+    var scrapeErr *ScrapeError
+    switch {
+    case errors.As(err, &scrapeErr):
+        return scrapeErr.Kind
+    case errors.Is(err, context.DeadlineExceeded):
+        return ScrapeErrTimeout
+    default:
+        return ScrapeErrOther
+    }
+}
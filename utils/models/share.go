@@ -0,0 +1,11 @@
+package models
+
+import "time"
+
+// SharedReport is a long analysis stored under a short ID for sharing by link
+type SharedReport struct {
+    ID        string    `json:"id"`
+    Title     string    `json:"title"`
+    Text      string    `json:"text"`
+    CreatedAt time.Time `json:"created_at"`
+}
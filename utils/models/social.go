@@ -0,0 +1,94 @@
+package models
+
+import (
+    "net/url"
+    "strings"
+    "time"
+)
+
+// Social link kinds
+const (
+    SocialTwitter  = "twitter"
+    SocialTelegram = "telegram"
+    SocialWebsite  = "website"
+)
+
+// Social link verification results
+const (
+    SocialOK       = "ok"
+    SocialDead     = "dead"     // Link doesn't resolve or the account doesn't exist
+    SocialMismatch = "mismatch" // Link resolves to a different handle or site
+)
+
+// SocialLink is a Twitter, Telegram or website link listed on an agent's page
+type SocialLink struct {
+    Kind      string    `json:"kind"`
+    URL       string    `json:"url"`
+    Handle    string    `json:"handle,omitempty"`
+    Status    string    `json:"status,omitempty"` // Empty until verified
+    Detail    string    `json:"detail,omitempty"`
+    CheckedAt time.Time `json:"checked_at"`
+}
+
+// Flagged reports whether verification found the link dead or mismatched
+func (l SocialLink) Flagged() bool {
+    return l.Status == SocialDead || l.Status == SocialMismatch
+}
+
+// ParseSocialLink classifies an external link and reads its handle. It
+// returns false for links that aren't Twitter, Telegram or http(s) sites.
+func ParseSocialLink(raw string) (SocialLink, bool) {
+    u, err := url.Parse(strings.TrimSpace(raw))
+    if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+        return SocialLink{}, false
+    }
+    host := strings.TrimPrefix(strings.ToLower(u.Host), "www.")
+    segment := strings.Split(strings.Trim(u.Path, "/"), "/")[0]
+
+    switch host {
+    case "twitter.com", "x.com", "mobile.twitter.com":
+        if segment == "" || segment == "intent" || segment == "share" || segment == "i" || segment == "home" {
+            return SocialLink{}, false
+        }
+        return SocialLink{Kind: SocialTwitter, URL: "https://x.com/" + segment, Handle: segment}, true
+    case "t.me", "telegram.me":
+        if segment == "" || segment == "share" {
+            return SocialLink{}, false
+        }
+        // Invite links don't name the chat
+        if segment == "joinchat" || strings.HasPrefix(segment, "+") {
+            return SocialLink{Kind: SocialTelegram, URL: "https://t.me/" + strings.Trim(u.Path, "/")}, true
+        }
+        return SocialLink{Kind: SocialTelegram, URL: "https://t.me/" + segment, Handle: segment}, true
+    }
+
+    u.Fragment = ""
+    return SocialLink{Kind: SocialWebsite, URL: u.String(), Handle: host}, true
+}
+
+// FlaggedSocials returns the agent's social links that failed verification
+func (a *Agent) FlaggedSocials() []SocialLink {
+    var flagged []SocialLink
+    for _, link := range a.Socials {
+        if link.Flagged() {
+            flagged = append(flagged, link)
+        }
+    }
+    return flagged
+}
+
+// CarrySocialChecks copies verification results from previous links onto
+// fresh, unverified links with the same URL
+func CarrySocialChecks(fresh, previous []SocialLink) {
+    checked := make(map[string]SocialLink, len(previous))
+    for _, link := range previous {
+        if link.Status != "" {
+            checked[link.URL] = link
+        }
+    }
+    for i, link := range fresh {
+        if prior, ok := checked[link.URL]; ok && link.Status == "" {
+            fresh[i].Status, fresh[i].Detail, fresh[i].CheckedAt = prior.Status, prior.Detail, prior.CheckedAt
+        }
+    }
+}
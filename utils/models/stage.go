@@ -0,0 +1,52 @@
+package models
+
+import (
+    "fmt"
+    "strconv"
+    "strings"
+)
+
+// Launch stages of a Virtuals agent. New agents trade on a bonding curve,
+// shown as "Prototype", until it fills and they graduate to "Sentient"
+// with a liquidity pool.
+const (
+    StageBonding  = "bonding"
+    StageSentient = "sentient"
+)
+
+// NearGraduationProgress is the bonding curve progress, in percent, from
+// which an agent counts as about to graduate
+const NearGraduationProgress = 80.0
+
+// ParseStage maps a stage label shown on an agent page to a stage
+func ParseStage(label string) (string, bool) {
+    switch strings.ToLower(strings.TrimSpace(label)) {
+    case "prototype", "bonding", "bonding curve":
+        return StageBonding, true
+    case "sentient", "graduated":
+        return StageSentient, true
+    }
+    return "", false
+}
+
+// NearGraduation reports whether the agent is on its bonding curve and
+// close to filling it
+func (a *Agent) NearGraduation() bool {
+    return a.Stage == StageBonding && a.BondingProgress >= NearGraduationProgress
+}
+
+// NearGraduation reports whether the indexed agent is about to graduate
+func (s AgentSummary) NearGraduation() bool {
+    return s.Stage == StageBonding && s.BondingProgress >= NearGraduationProgress
+}
+
+// FormatStage renders a stage with the bonding curve progress, if known
+func FormatStage(stage string, progress float64) string {
+    switch {
+    case stage == "":
+        return "unknown"
+    case stage == StageBonding && progress > 0:
+        return fmt.Sprintf("%s %s%%", stage, strconv.FormatFloat(progress, 'f', -1, 64))
+    }
+    return stage
+}
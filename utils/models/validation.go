@@ -0,0 +1,119 @@
+package models
+
+import (
+    "errors"
+    "fmt"
+    "regexp"
+    "strings"
+)
+
+// walletAddressPattern matches a full EVM address
+var walletAddressPattern = regexp.MustCompile(`^0x[0-9a-fA-F]{40}$`)
+
+// ValidationIssue is one problem found in a submitted agent, tied to the
+// JSON field it concerns
+type ValidationIssue struct {
+    Field   string `json:"field"`
+    Message string `json:"message"`
+}
+
+// AgentValidation is the result of running a submitted agent through the
+// same cleaning and normalization the scraper applies before saving.
+// Errors would stop the agent being stored; warnings are values that were
+// rewritten or could not be read.
+type AgentValidation struct {
+    Valid    bool              `json:"valid"`
+    Errors   []ValidationIssue `json:"errors"`
+    Warnings []ValidationIssue `json:"warnings"`
+    Agent    Agent             `json:"agent"` // The agent as it would be stored
+}
+
+func (v *AgentValidation) errorf(field, format string, args ...interface{}) {
+    v.Errors = append(v.Errors, ValidationIssue{Field: field, Message: fmt.Sprintf(format, args...)})
+}
+
+func (v *AgentValidation) warnf(field, format string, args ...interface{}) {
+    v.Warnings = append(v.Warnings, ValidationIssue{Field: field, Message: fmt.Sprintf(format, args...)})
+}
+
+// ValidateAgent runs ValidateAndClean, the scraper's amount normalization
+// (NormalizeAmounts), stats parsing and Validate over a copy of the agent and
+// reports what failed or changed
+func ValidateAgent(agent Agent) AgentValidation {
+    v := AgentValidation{Errors: []ValidationIssue{}, Warnings: []ValidationIssue{}}
+    a := agent
+
+    a.ValidateAndClean()
+    if a.Name != agent.Name {
+        v.warnf("name", "cleaned from %q to %q", agent.Name, a.Name)
+    }
+    if a.Price != agent.Price {
+        v.warnf("price", "cleaned from %q to %q", agent.Price, a.Price)
+    }
+    if len(strings.TrimSpace(agent.Description)) > 1000 {
+        v.warnf("description", "truncated to 1000 characters")
+    }
+
+    for _, field := range a.AmountFields() {
+        v.checkAmount(field.Name, *field.Value)
+    }
+    a.NormalizeAmounts()
+
+    if a.Stats != "" && a.StatsDetail == nil {
+        stats := ParseAgentStats(a.Stats)
+        if stats.Empty() {
+            v.warnf("stats", "no score, rank or components could be read")
+        } else {
+            a.StatsDetail = &stats
+        }
+    }
+
+    switch a.Status {
+    case "", StatusDefault, StatusActive, StatusDead, StatusLatent:
+    default:
+        v.errorf("status", "unknown status %q", a.Status)
+    }
+    switch a.Stage {
+    case "", StageBonding, StageSentient:
+    default:
+        v.errorf("stage", "unknown launch stage %q", a.Stage)
+    }
+    if a.BondingProgress < 0 || a.BondingProgress > 100 {
+        v.errorf("bonding_progress", "must be between 0 and 100, got %g", a.BondingProgress)
+    }
+    if a.ContractAddress != "" && !walletAddressPattern.MatchString(a.ContractAddress) {
+        v.errorf("contract_address", "not a valid address: %q", a.ContractAddress)
+    }
+    if a.CreatorAddress != "" && !walletAddressPattern.MatchString(a.CreatorAddress) {
+        v.errorf("creator_address", "not a valid address: %q", a.CreatorAddress)
+    }
+
+    hadID := a.ID != ""
+    var fieldErr *FieldError
+    if err := a.Validate(); errors.As(err, &fieldErr) {
+        v.errorf(fieldErr.Field, "%s", fieldErr.Message)
+    } else if err != nil {
+        v.errorf("agent", "%v", err)
+    } else if !hadID {
+        v.warnf("id", "missing, generated %s", a.ID)
+    }
+
+    v.Valid = len(v.Errors) == 0
+    v.Agent = a
+    return v
+}
+
+// checkAmount warns when normalization will rewrite an amount or when it
+// doesn't read as an amount at all
+func (v *AgentValidation) checkAmount(field, raw string) {
+    if strings.TrimSpace(raw) == "" {
+        return
+    }
+    if _, ok := ParseAmount(raw); !ok {
+        v.warnf(field, "%q is not a recognised amount", raw)
+        return
+    }
+    if normalized := NormalizeAmount(raw); normalized != raw {
+        v.warnf(field, "normalized from %q to %q", raw, normalized)
+    }
+}
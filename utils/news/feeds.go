@@ -0,0 +1,164 @@
+package news
+
+import (
+    "encoding/json"
+    "encoding/xml"
+    "fmt"
+    "os"
+    "strings"
+    "time"
+)
+
+// Feed formats understood by the ingester
+const (
+    FormatRSS  = "rss"  // RSS 2.0 or Atom
+    FormatJSON = "json" // JSON Feed (jsonfeed.org) APIs
+)
+
+// Feed is a news source polled by the ingester
+type Feed struct {
+    Name   string `json:"name"`
+    URL    string `json:"url"`
+    Format string `json:"format,omitempty"` // Defaults to rss
+}
+
+// DefaultFeeds are used when no feed config file exists
+var DefaultFeeds = []Feed{
+    {Name: "CoinDesk", URL: "https://www.coindesk.com/arc/outboundfeeds/rss/"},
+    {Name: "Cointelegraph", URL: "https://cointelegraph.com/rss"},
+    {Name: "Decrypt", URL: "https://decrypt.co/feed"},
+    {Name: "Virtuals Protocol", URL: "https://medium.com/feed/@virtuals_protocol"},
+}
+
+// LoadFeeds reads the feed list from a JSON file, falling back to DefaultFeeds
+func LoadFeeds(path string) ([]Feed, error) {
+    data, err := os.ReadFile(path)
+    if os.IsNotExist(err) {
+        return DefaultFeeds, nil
+    }
+    if err != nil {
+        return nil, fmt.Errorf("failed to read news feeds: %w", err)
+    }
+
+    var feeds []Feed
+    if err := json.Unmarshal(data, &feeds); err != nil {
+        return nil, fmt.Errorf("failed to unmarshal news feeds: %w", err)
+    }
+    for i := range feeds {
+        if feeds[i].Name == "" || feeds[i].URL == "" {
+            return nil, fmt.Errorf("news feed %d needs a name and url", i+1)
+        }
+        if feeds[i].Format == "" {
+            feeds[i].Format = FormatRSS
+        }
+        if feeds[i].Format != FormatRSS && feeds[i].Format != FormatJSON {
+            return nil, fmt.Errorf("news feed %s: unknown format %q", feeds[i].Name, feeds[i].Format)
+        }
+    }
+    return feeds, nil
+}
+
+// item is a feed entry before it is matched to agents
+type item struct {
+    Title       string
+    URL         string
+    Summary     string
+    PublishedAt time.Time
+}
+
+type rssDocument struct {
+    Items []struct {
+        Title       string `xml:"title"`
+        Link        string `xml:"link"`
+        Description string `xml:"description"`
+        PubDate     string `xml:"pubDate"`
+    } `xml:"channel>item"`
+}
+
+type atomDocument struct {
+    Entries []struct {
+        Title string `xml:"title"`
+        Links []struct {
+            Href string `xml:"href,attr"`
+            Rel  string `xml:"rel,attr"`
+        } `xml:"link"`
+        Summary   string `xml:"summary"`
+        Content   string `xml:"content"`
+        Published string `xml:"published"`
+        Updated   string `xml:"updated"`
+    } `xml:"entry"`
+}
+
+type jsonFeedDocument struct {
+    Items []struct {
+        Title         string `json:"title"`
+        URL           string `json:"url"`
+        Summary       string `json:"summary"`
+        ContentText   string `json:"content_text"`
+        DatePublished string `json:"date_published"`
+    } `json:"items"`
+}
+
+// parseFeed decodes a feed body in the given format
+func parseFeed(format string, data []byte) ([]item, error) {
+    if format == FormatJSON {
+        var doc jsonFeedDocument
+        if err := json.Unmarshal(data, &doc); err != nil {
+            return nil, fmt.Errorf("failed to unmarshal JSON feed: %w", err)
+        }
+        items := make([]item, 0, len(doc.Items))
+        for _, entry := range doc.Items {
+            summary := entry.Summary
+            if summary == "" {
+                summary = entry.ContentText
+            }
+            items = append(items, item{Title: entry.Title, URL: entry.URL, Summary: summary, PublishedAt: parseTime(entry.DatePublished)})
+        }
+        return items, nil
+    }
+
+    var rss rssDocument
+    if err := xml.Unmarshal(data, &rss); err == nil && len(rss.Items) > 0 {
+        items := make([]item, 0, len(rss.Items))
+        for _, entry := range rss.Items {
+            items = append(items, item{Title: entry.Title, URL: strings.TrimSpace(entry.Link), Summary: entry.Description, PublishedAt: parseTime(entry.PubDate)})
+        }
+        return items, nil
+    }
+
+    var atom atomDocument
+    if err := xml.Unmarshal(data, &atom); err != nil {
+        return nil, fmt.Errorf("failed to parse feed: %w", err)
+    }
+    items := make([]item, 0, len(atom.Entries))
+    for _, entry := range atom.Entries {
+        var link string
+        for _, l := range entry.Links {
+            if l.Rel == "" || l.Rel == "alternate" {
+                link = l.Href
+                break
+            }
+        }
+        summary := entry.Summary
+        if summary == "" {
+            summary = entry.Content
+        }
+        published := entry.Published
+        if published == "" {
+            published = entry.Updated
+        }
+        items = append(items, item{Title: entry.Title, URL: link, Summary: summary, PublishedAt: parseTime(published)})
+    }
+    return items, nil
+}
+
+// parseTime accepts the date formats used by RSS, Atom and JSON Feed
+func parseTime(value string) time.Time {
+    value = strings.TrimSpace(value)
+    for _, layout := range []string{time.RFC1123Z, time.RFC1123, time.RFC3339, "Mon, 2 Jan 2006 15:04:05 -0700", "Mon, 2 Jan 2006 15:04:05 MST"} {
+        if t, err := time.Parse(layout, value); err == nil {
+            return t
+        }
+    }
+    return time.Time{}
+}
@@ -0,0 +1,209 @@
+package news
+
+import (
+    "context"
+    "crypto/sha256"
+    "encoding/hex"
+    "fmt"
+    "html"
+    "io"
+    "log"
+    "net/http"
+    "regexp"
+    "strings"
+    "sync"
+    "time"
+    "anondd/utils/httpclient"
+    "anondd/utils/models"
+    "anondd/utils/storage"
+)
+
+const (
+    // DefaultPollInterval is how often feeds are fetched
+    DefaultPollInterval = 30 * time.Minute
+
+    // minNameLength skips agent names too short to match reliably
+    minNameLength = 3
+    maxSummary    = 500
+    maxFeedBytes  = 5 << 20
+)
+
+var tagPattern = regexp.MustCompile(`<[^>]*>`)
+
+// Ingester polls news feeds and stores the articles that mention known agents
+type Ingester struct {
+    store  *storage.AgentStore
+    feeds  []Feed
+    client *http.Client
+    mu     sync.Mutex
+    logger *log.Logger
+}
+
+// NewIngester creates an ingester over the given feeds
+func NewIngester(store *storage.AgentStore, feeds []Feed, logger *log.Logger) *Ingester {
+    return &Ingester{
+        store:  store,
+        feeds:  feeds,
+        client: httpclient.WithTimeout(30 * time.Second),
+        logger: logger,
+    }
+}
+
+// Start polls the feeds immediately and then every interval until ctx is cancelled
+func (n *Ingester) Start(ctx context.Context, interval time.Duration) {
+    go func() {
+        ticker := time.NewTicker(interval)
+        defer ticker.Stop()
+        for {
+            if _, err := n.Poll(ctx); err != nil {
+                n.logger.Printf("Error polling news feeds: %v", err)
+            }
+            select {
+            case <-ticker.C:
+            case <-ctx.Done():
+                return
+            }
+        }
+    }()
+}
+
+// Poll fetches every feed once, matches the articles against the agent index
+// and stores the matches. It returns the number of newly stored articles.
+// A failing feed is logged and skipped.
+func (n *Ingester) Poll(ctx context.Context) (int, error) {
+    n.mu.Lock()
+    defer n.mu.Unlock()
+
+    index, err := n.store.GetIndexContext(ctx)
+    if err != nil {
+        return 0, err
+    }
+    matchers := newMatchers(index.Agents)
+    if len(matchers) == 0 {
+        return 0, nil
+    }
+
+    now := time.Now()
+    run := models.ScrapeRun{Source: models.SourceNews, StartedAt: now, Attempted: len(n.feeds)}
+    defer func() {
+        run.Duration = time.Since(now)
+        if err := n.store.RecordScrapeRun(run); err != nil {
+            n.logger.Printf("Error recording news scrape run: %v", err)
+        }
+    }()
+
+    var matched []models.NewsArticle
+    for _, feed := range n.feeds {
+        items, err := n.fetch(ctx, feed)
+        if err != nil {
+            run.Failed++
+            n.logger.Printf("Error fetching news feed %s: %v", feed.Name, err)
+            continue
+        }
+        run.Succeeded++
+        for _, it := range items {
+            if it.URL == "" {
+                continue
+            }
+            summary := cleanText(it.Summary)
+            agentIDs := matchAgents(matchers, it.Title+"\n"+summary)
+            if len(agentIDs) == 0 {
+                continue
+            }
+            published := it.PublishedAt
+            if published.IsZero() {
+                published = now
+            }
+            matched = append(matched, models.NewsArticle{
+                ID:          articleID(it.URL),
+                Title:       cleanText(it.Title),
+                URL:         it.URL,
+                Source:      feed.Name,
+                Summary:     summary,
+                PublishedAt: published,
+                FetchedAt:   now,
+                AgentIDs:    agentIDs,
+            })
+        }
+    }
+
+    if len(matched) == 0 {
+        return 0, nil
+    }
+    added, err := n.store.SaveNews(matched)
+    if err != nil {
+        return 0, fmt.Errorf("failed to save news: %w", err)
+    }
+    if added > 0 {
+        n.logger.Printf("Stored %d new agent news articles", added)
+    }
+    return added, nil
+}
+
+func (n *Ingester) fetch(ctx context.Context, feed Feed) ([]item, error) {
+    req, err := http.NewRequestWithContext(ctx, http.MethodGet, feed.URL, nil)
+    if err != nil {
+        return nil, fmt.Errorf("failed to create request: %w", err)
+    }
+    resp, err := n.client.Do(req)
+    if err != nil {
+        return nil, fmt.Errorf("failed to execute request: %w", err)
+    }
+    defer resp.Body.Close()
+    if resp.StatusCode != http.StatusOK {
+        return nil, fmt.Errorf("unexpected status %d", resp.StatusCode)
+    }
+
+    data, err := io.ReadAll(io.LimitReader(resp.Body, maxFeedBytes))
+    if err != nil {
+        return nil, fmt.Errorf("failed to read response body: %w", err)
+    }
+    return parseFeed(feed.Format, data)
+}
+
+// matcher finds mentions of one agent by name or $ticker
+type matcher struct {
+    agentID string
+    pattern *regexp.Regexp
+}
+
+func newMatchers(agents []models.AgentSummary) []matcher {
+    matchers := make([]matcher, 0, len(agents))
+    for _, agent := range agents {
+        name := strings.TrimPrefix(strings.TrimSpace(agent.Name), "$")
+        if len(name) < minNameLength {
+            continue
+        }
+        pattern, err := regexp.Compile(`(?i)(^|[^\w$])\$?` + regexp.QuoteMeta(name) + `($|[^\w])`)
+        if err != nil {
+            continue
+        }
+        matchers = append(matchers, matcher{agentID: agent.ID, pattern: pattern})
+    }
+    return matchers
+}
+
+func matchAgents(matchers []matcher, text string) []string {
+    var ids []string
+    for _, m := range matchers {
+        if m.pattern.MatchString(text) {
+            ids = append(ids, m.agentID)
+        }
+    }
+    return ids
+}
+
+// articleID derives a stable ID from the article URL
+func articleID(url string) string {
+    hash := sha256.Sum256([]byte(url))
+    return hex.EncodeToString(hash[:8])
+}
+
+// cleanText strips markup from feed text and caps its length
+func cleanText(text string) string {
+    text = strings.Join(strings.Fields(html.UnescapeString(tagPattern.ReplaceAllString(text, " "))), " ")
+    if len(text) > maxSummary {
+        text = text[:maxSummary] + "..."
+    }
+    return text
+}
@@ -0,0 +1,318 @@
+package onchain
+
+import (
+    "context"
+    "fmt"
+    "log"
+    "math/big"
+    "sort"
+    "strings"
+    "sync"
+    "time"
+    "anondd/utils/httpclient"
+    "anondd/utils/models"
+)
+
+const (
+    // DefaultCacheTTL is how long on-chain data is reused before being read again
+    DefaultCacheTTL = 15 * time.Minute
+
+    topHolderCount       = 10
+    maxHolderCandidates  = 150
+    holderLookbackBlocks = 20000
+    logWindowBlocks      = 2000
+)
+
+// Chain describes an EVM network the enricher can query
+type Chain struct {
+    Name string
+    // RPCURL is the JSON-RPC endpoint for the network
+    RPCURL string
+    // Factory is the Uniswap V2 style factory used to locate liquidity pools
+    Factory string
+    // QuoteTokens are tried in order when looking for the token's pool
+    QuoteTokens []string
+}
+
+// BaseChain returns the Base network config; agent tokens usually pair with VIRTUAL there
+func BaseChain(rpcURL string) Chain {
+    return Chain{
+        Name:    "base",
+        RPCURL:  rpcURL,
+        Factory: "0x8909Dc15e40173Ff4699343b6eB8132c65e18eC6",
+        QuoteTokens: []string{
+            "0x0b3e328455c4059EEb9e3f84b5543F74E24e7E1b", // VIRTUAL
+            "0x4200000000000000000000000000000000000006", // WETH
+        },
+    }
+}
+
+// EthereumChain returns the Ethereum mainnet config
+func EthereumChain(rpcURL string) Chain {
+    return Chain{
+        Name:    "ethereum",
+        RPCURL:  rpcURL,
+        Factory: "0x5C69bEe701ef814a2B6a3EDD4B1652CB9cc5aA6f",
+        QuoteTokens: []string{
+            "0x44ff8620b8cA30902395A7bD3F2407e1A091BF73", // VIRTUAL
+            "0xC02aaA39b223FE8D0A0e5C4F27eAD9083C756Cc2", // WETH
+        },
+    }
+}
+
+type chainClient struct {
+    chain Chain
+    rpc   *rpcClient
+}
+
+type cachedData struct {
+    data    *models.OnChainData
+    expires time.Time
+}
+
+// Enricher reads token supply, holder concentration and liquidity for an
+// agent's contract address from the first configured chain that has it
+type Enricher struct {
+    chains []chainClient
+    ttl    time.Duration
+    mu     sync.Mutex
+    cache  map[string]cachedData
+    logger *log.Logger
+}
+
+// NewEnricher creates an enricher querying chains in the given order
+func NewEnricher(chains []Chain, logger *log.Logger) *Enricher {
+    httpClient := httpclient.WithTimeout(15 * time.Second)
+    clients := make([]chainClient, 0, len(chains))
+    for _, chain := range chains {
+        if chain.RPCURL == "" {
+            continue
+        }
+        clients = append(clients, chainClient{chain: chain, rpc: &rpcClient{url: chain.RPCURL, client: httpClient}})
+    }
+    return &Enricher{
+        chains: clients,
+        ttl:    DefaultCacheTTL,
+        cache:  make(map[string]cachedData),
+        logger: logger,
+    }
+}
+
+// Enabled reports whether any chain is configured
+func (e *Enricher) Enabled() bool {
+    return e != nil && len(e.chains) > 0
+}
+
+// Enrich fills agent.OnChain from its contract address
+func (e *Enricher) Enrich(ctx context.Context, agent *models.Agent) error {
+    if agent.ContractAddress == "" {
+        return fmt.Errorf("agent %s has no contract address", agent.Name)
+    }
+    data, err := e.Lookup(ctx, agent.ContractAddress)
+    if err != nil {
+        return err
+    }
+    agent.OnChain = data
+    return nil
+}
+
+// Lookup returns on-chain data for a token address, serving from cache when fresh
+func (e *Enricher) Lookup(ctx context.Context, address string) (*models.OnChainData, error) {
+    if !e.Enabled() {
+        return nil, fmt.Errorf("no chains configured")
+    }
+    key := strings.ToLower(address)
+
+    e.mu.Lock()
+    if entry, ok := e.cache[key]; ok && time.Now().Before(entry.expires) {
+        e.mu.Unlock()
+        return entry.data, nil
+    }
+    e.mu.Unlock()
+
+    var lastErr error
+    for _, c := range e.chains {
+        deployed, err := c.rpc.hasCode(ctx, address)
+        if err != nil {
+            lastErr = fmt.Errorf("%s: %w", c.chain.Name, err)
+            continue
+        }
+        if !deployed {
+            continue
+        }
+
+        data, err := e.fetch(ctx, c, address)
+        if err != nil {
+            return nil, fmt.Errorf("%s: %w", c.chain.Name, err)
+        }
+
+        e.mu.Lock()
+        e.cache[key] = cachedData{data: data, expires: time.Now().Add(e.ttl)}
+        e.mu.Unlock()
+        return data, nil
+    }
+
+    if lastErr != nil {
+        return nil, lastErr
+    }
+    return nil, fmt.Errorf("token %s not found on any configured chain", address)
+}
+
+func (e *Enricher) fetch(ctx context.Context, c chainClient, token string) (*models.OnChainData, error) {
+    supply, err := c.rpc.callUint(ctx, token, selectorTotalSupply)
+    if err != nil {
+        return nil, fmt.Errorf("failed to read total supply: %w", err)
+    }
+    decimals, err := c.rpc.callUint(ctx, token, selectorDecimals)
+    if err != nil {
+        return nil, fmt.Errorf("failed to read decimals: %w", err)
+    }
+
+    data := &models.OnChainData{
+        Chain:       c.chain.Name,
+        TotalSupply: formatUnits(supply, int(decimals.Int64())),
+        Decimals:    int(decimals.Int64()),
+        FetchedAt:   time.Now(),
+    }
+
+    // Holder concentration and liquidity are best effort; supply alone is still useful
+    share, sampled, err := e.topHolderShare(ctx, c, token, supply)
+    if err != nil {
+        e.logger.Printf("Error reading holders for %s on %s: %v", token, c.chain.Name, err)
+    } else {
+        data.TopHolderShare = share
+        data.HoldersSampled = sampled
+    }
+
+    pool, err := e.findPool(ctx, c, token, int(decimals.Int64()))
+    if err != nil {
+        e.logger.Printf("Error reading liquidity pool for %s on %s: %v", token, c.chain.Name, err)
+    } else {
+        data.Pool = pool
+    }
+
+    // Tokens without Ownable revert; they just have no on-chain creator
+    if owner, err := c.rpc.callAddress(ctx, token, selectorOwner); err == nil {
+        data.Owner = owner
+    }
+
+    return data, nil
+}
+
+// topHolderShare estimates the share of supply held by the largest holders.
+// RPC nodes cannot list holders, so candidates come from recent Transfer
+// recipients and their current balances are read individually.
+func (e *Enricher) topHolderShare(ctx context.Context, c chainClient, token string, supply *big.Int) (float64, int, error) {
+    if supply.Sign() == 0 {
+        return 0, 0, nil
+    }
+
+    latest, err := c.rpc.blockNumber(ctx)
+    if err != nil {
+        return 0, 0, err
+    }
+    start := uint64(0)
+    if latest > holderLookbackBlocks {
+        start = latest - holderLookbackBlocks
+    }
+
+    // Walk backwards so the most recently active addresses are sampled first
+    seen := make(map[string]bool)
+    var candidates []string
+    for to := latest; to >= start && len(candidates) < maxHolderCandidates; {
+        from := start
+        if to-start >= logWindowBlocks {
+            from = to - logWindowBlocks + 1
+        }
+        logs, err := c.rpc.transferLogs(ctx, token, from, to)
+        if err != nil {
+            return 0, 0, err
+        }
+        for i := len(logs) - 1; i >= 0 && len(candidates) < maxHolderCandidates; i-- {
+            if len(logs[i].Topics) < 3 {
+                continue
+            }
+            holder := topicAddress(logs[i].Topics[2])
+            if holder == "" || seen[holder] {
+                continue
+            }
+            seen[holder] = true
+            candidates = append(candidates, holder)
+        }
+        if from == start {
+            break
+        }
+        to = from - 1
+    }
+
+    balances := make([]*big.Int, 0, len(candidates))
+    for _, holder := range candidates {
+        balance, err := c.rpc.callUint(ctx, token, selectorBalanceOf+encodeAddress(holder))
+        if err != nil {
+            return 0, 0, fmt.Errorf("failed to read balance of %s: %w", holder, err)
+        }
+        balances = append(balances, balance)
+    }
+    sort.Slice(balances, func(i, j int) bool { return balances[i].Cmp(balances[j]) > 0 })
+
+    top := new(big.Int)
+    for i := 0; i < len(balances) && i < topHolderCount; i++ {
+        top.Add(top, balances[i])
+    }
+    share, _ := new(big.Rat).SetFrac(top, supply).Float64()
+    return share, len(candidates), nil
+}
+
+// findPool locates the token's Uniswap V2 pair against the first quote token that has one
+func (e *Enricher) findPool(ctx context.Context, c chainClient, token string, decimals int) (*models.PoolInfo, error) {
+    if c.chain.Factory == "" {
+        return nil, fmt.Errorf("no factory configured")
+    }
+
+    for _, quote := range c.chain.QuoteTokens {
+        pair, err := c.rpc.callAddress(ctx, c.chain.Factory, selectorGetPair+encodeAddress(token)+encodeAddress(quote))
+        if err != nil {
+            return nil, fmt.Errorf("failed to look up pair: %w", err)
+        }
+        if pair == "0x0000000000000000000000000000000000000000" {
+            continue
+        }
+
+        reserves, err := c.rpc.ethCall(ctx, pair, selectorGetReserves)
+        if err != nil {
+            return nil, fmt.Errorf("failed to read reserves: %w", err)
+        }
+        reserve0, err := wordAt(reserves, 0)
+        if err != nil {
+            return nil, err
+        }
+        reserve1, err := wordAt(reserves, 1)
+        if err != nil {
+            return nil, err
+        }
+        token0, err := c.rpc.callAddress(ctx, pair, selectorToken0)
+        if err != nil {
+            return nil, fmt.Errorf("failed to read pair token0: %w", err)
+        }
+
+        tokenReserve, quoteReserve := reserve0, reserve1
+        if !strings.EqualFold(token0, token) {
+            tokenReserve, quoteReserve = reserve1, reserve0
+        }
+
+        // Both default quote tokens (VIRTUAL, WETH) use 18 decimals
+        return &models.PoolInfo{
+            Address:      pair,
+            QuoteToken:   quote,
+            TokenReserve: formatUnits(tokenReserve, decimals),
+            QuoteReserve: formatUnits(quoteReserve, 18),
+        }, nil
+    }
+    return nil, fmt.Errorf("no pool found")
+}
+
+// formatUnits renders a raw token amount with the given decimals, two places shown
+func formatUnits(amount *big.Int, decimals int) string {
+    value := new(big.Rat).SetFrac(amount, new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(decimals)), nil))
+    return value.FloatString(2)
+}
@@ -0,0 +1,44 @@
+package onchain
+
+import (
+    "context"
+    "anondd/utils/storage"
+)
+
+// RefreshAll reads on-chain data for every stored agent with a contract
+// address and saves it on the agent, returning how many were refreshed
+func (e *Enricher) RefreshAll(ctx context.Context, store *storage.AgentStore) int {
+    if !e.Enabled() {
+        return 0
+    }
+    index, err := store.GetIndexContext(ctx)
+    if err != nil {
+        e.logger.Printf("Error loading index for on-chain refresh: %v", err)
+        return 0
+    }
+
+    refreshed := 0
+    for _, summary := range index.Agents {
+        if ctx.Err() != nil {
+            break
+        }
+        agent, err := store.GetAgentContext(ctx, summary.ID)
+        if err != nil || agent.ContractAddress == "" {
+            continue
+        }
+        data, err := e.Lookup(ctx, agent.ContractAddress)
+        if err != nil {
+            e.logger.Printf("Error reading on-chain data for agent %s: %v", agent.ID, err)
+            continue
+        }
+        if err := store.SetAgentOnChain(ctx, agent.ID, data); err != nil {
+            e.logger.Printf("Error saving on-chain data for agent %s: %v", agent.ID, err)
+            continue
+        }
+        refreshed++
+    }
+    if refreshed > 0 {
+        e.logger.Printf("Refreshed on-chain data for %d agents", refreshed)
+    }
+    return refreshed
+}
@@ -0,0 +1,197 @@
+package onchain
+
+import (
+    "bytes"
+    "context"
+    "encoding/hex"
+    "encoding/json"
+    "fmt"
+    "io"
+    "math/big"
+    "net/http"
+    "strings"
+    "sync/atomic"
+)
+
+// ERC-20, Ownable and Uniswap V2 function selectors used by the enricher
+const (
+    selectorTotalSupply = "0x18160ddd"
+    selectorDecimals    = "0x313ce567"
+    selectorBalanceOf   = "0x70a08231"
+    selectorGetPair     = "0xe6a43905"
+    selectorGetReserves = "0x0902f1ac"
+    selectorToken0      = "0x0dfe1681"
+    selectorOwner       = "0x8da5cb5b"
+
+    transferTopic = "0xddf252ad1be2c89b69c2b068fc378daa952ba7f163c4a11628f55a4df523b3ef"
+)
+
+// rpcClient is a minimal Ethereum JSON-RPC client
+type rpcClient struct {
+    url    string
+    client *http.Client
+    nextID int64
+}
+
+type rpcRequest struct {
+    JSONRPC string        `json:"jsonrpc"`
+    ID      int64         `json:"id"`
+    Method  string        `json:"method"`
+    Params  []interface{} `json:"params"`
+}
+
+type rpcResponse struct {
+    Result json.RawMessage `json:"result"`
+    Error  *struct {
+        Code    int    `json:"code"`
+        Message string `json:"message"`
+    } `json:"error"`
+}
+
+type logEntry struct {
+    Topics []string `json:"topics"`
+}
+
+func (c *rpcClient) call(ctx context.Context, method string, result interface{}, params ...interface{}) error {
+    if params == nil {
+        params = []interface{}{}
+    }
+    body, err := json.Marshal(rpcRequest{
+        JSONRPC: "2.0",
+        ID:      atomic.AddInt64(&c.nextID, 1),
+        Method:  method,
+        Params:  params,
+    })
+    if err != nil {
+        return fmt.Errorf("failed to encode %s request: %w", method, err)
+    }
+
+    req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.url, bytes.NewReader(body))
+    if err != nil {
+        return fmt.Errorf("failed to create request: %w", err)
+    }
+    req.Header.Set("Content-Type", "application/json")
+
+    resp, err := c.client.Do(req)
+    if err != nil {
+        return fmt.Errorf("failed to execute %s: %w", method, err)
+    }
+    defer resp.Body.Close()
+
+    respBody, err := io.ReadAll(resp.Body)
+    if err != nil {
+        return fmt.Errorf("failed to read %s response: %w", method, err)
+    }
+    if resp.StatusCode != http.StatusOK {
+        return fmt.Errorf("rpc %s returned status %d: %s", method, resp.StatusCode, string(respBody))
+    }
+
+    var rpcResp rpcResponse
+    if err := json.Unmarshal(respBody, &rpcResp); err != nil {
+        return fmt.Errorf("failed to decode %s response: %w", method, err)
+    }
+    if rpcResp.Error != nil {
+        return fmt.Errorf("rpc %s error %d: %s", method, rpcResp.Error.Code, rpcResp.Error.Message)
+    }
+    if err := json.Unmarshal(rpcResp.Result, result); err != nil {
+        return fmt.Errorf("failed to decode %s result: %w", method, err)
+    }
+    return nil
+}
+
+// ethCall runs a read-only contract call and returns the raw return data
+func (c *rpcClient) ethCall(ctx context.Context, to, data string) ([]byte, error) {
+    var result string
+    call := map[string]string{"to": to, "data": data}
+    if err := c.call(ctx, "eth_call", &result, call, "latest"); err != nil {
+        return nil, err
+    }
+    return decodeHex(result)
+}
+
+// callUint runs a contract call returning a single uint256
+func (c *rpcClient) callUint(ctx context.Context, to, data string) (*big.Int, error) {
+    out, err := c.ethCall(ctx, to, data)
+    if err != nil {
+        return nil, err
+    }
+    return wordAt(out, 0)
+}
+
+// callAddress runs a contract call returning a single address
+func (c *rpcClient) callAddress(ctx context.Context, to, data string) (string, error) {
+    out, err := c.ethCall(ctx, to, data)
+    if err != nil {
+        return "", err
+    }
+    if len(out) < 32 {
+        return "", fmt.Errorf("short address result from %s", to)
+    }
+    return "0x" + hex.EncodeToString(out[12:32]), nil
+}
+
+func (c *rpcClient) hasCode(ctx context.Context, address string) (bool, error) {
+    var code string
+    if err := c.call(ctx, "eth_getCode", &code, address, "latest"); err != nil {
+        return false, err
+    }
+    return code != "" && code != "0x", nil
+}
+
+func (c *rpcClient) blockNumber(ctx context.Context) (uint64, error) {
+    var result string
+    if err := c.call(ctx, "eth_blockNumber", &result); err != nil {
+        return 0, err
+    }
+    n, ok := new(big.Int).SetString(strings.TrimPrefix(result, "0x"), 16)
+    if !ok {
+        return 0, fmt.Errorf("invalid block number %q", result)
+    }
+    return n.Uint64(), nil
+}
+
+// transferLogs returns ERC-20 Transfer logs for the token within a block range
+func (c *rpcClient) transferLogs(ctx context.Context, token string, from, to uint64) ([]logEntry, error) {
+    var logs []logEntry
+    filter := map[string]interface{}{
+        "address":   token,
+        "fromBlock": fmt.Sprintf("0x%x", from),
+        "toBlock":   fmt.Sprintf("0x%x", to),
+        "topics":    []string{transferTopic},
+    }
+    if err := c.call(ctx, "eth_getLogs", &logs, filter); err != nil {
+        return nil, err
+    }
+    return logs, nil
+}
+
+// encodeAddress pads an address to a 32-byte ABI word
+func encodeAddress(address string) string {
+    return fmt.Sprintf("%064s", strings.ToLower(strings.TrimPrefix(address, "0x")))
+}
+
+// topicAddress extracts the address from an indexed 32-byte topic
+func topicAddress(topic string) string {
+    topic = strings.TrimPrefix(topic, "0x")
+    if len(topic) < 40 {
+        return ""
+    }
+    return "0x" + strings.ToLower(topic[len(topic)-40:])
+}
+
+func decodeHex(s string) ([]byte, error) {
+    s = strings.TrimPrefix(s, "0x")
+    if len(s)%2 == 1 {
+        s = "0" + s
+    }
+    return hex.DecodeString(s)
+}
+
+// wordAt returns the i-th 32-byte ABI word as an unsigned integer
+func wordAt(data []byte, i int) (*big.Int, error) {
+    start := i * 32
+    if len(data) < start+32 {
+        return nil, fmt.Errorf("return data too short: %d bytes", len(data))
+    }
+    return new(big.Int).SetBytes(data[start : start+32]), nil
+}
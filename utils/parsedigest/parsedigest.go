@@ -0,0 +1,339 @@
+package parsedigest
+
+import (
+    "context"
+    "encoding/json"
+    "fmt"
+    "log"
+    "os"
+    "path/filepath"
+    "regexp"
+    "sort"
+    "strings"
+    "sync"
+    "time"
+    "anondd/llm"
+    "anondd/utils/webscraper"
+    "github.com/PuerkitoBio/goquery"
+)
+
+const (
+    // DefaultInterval is how long automatic digests wait between runs
+    DefaultInterval = 24 * time.Hour
+
+    // PromptKey is the prompt selectors are suggested with
+    PromptKey = "selector_suggest"
+
+    // MinMissed and MinShare decide when a field fails often enough to need
+    // new selectors: on at least MinMissed pages and MinShare of those scanned
+    MinMissed = 5
+    MinShare  = 0.2
+
+    digestFile = "training_data/parse_digest.json"
+
+    // samplePages is how many failing pages are shown to the LLM per field
+    samplePages = 2
+    // sampleChars bounds each page's trimmed HTML in the prompt
+    sampleChars = 6000
+    // testPages bounds the failing pages each suggestion is tried against
+    testPages = 50
+    // maxSuggestions bounds the suggestions kept per field
+    maxSuggestions = 5
+)
+
+// Suggestion is a selector proposed for a failing field, with how many of the
+// failing pages it finds text on
+type Suggestion struct {
+    Selector string `json:"selector"`
+    Fixes    int    `json:"fixes"`
+    Tested   int    `json:"tested"`
+    Example  string `json:"example,omitempty"`
+    Adopted  bool   `json:"adopted,omitempty"`
+}
+
+// FieldReport is one failing field in a digest
+type FieldReport struct {
+    Field       string       `json:"field"`
+    Missed      int          `json:"missed"`
+    Share       float64      `json:"share"`
+    Samples     []int        `json:"samples"`
+    Suggestions []Suggestion `json:"suggestions"`
+}
+
+// Digest summarizes parse failures across the stored pages with suggested
+// selectors for the fields that fail most
+type Digest struct {
+    GeneratedAt time.Time     `json:"generated_at"`
+    Pages       int           `json:"pages"`
+    Fields      []FieldReport `json:"fields"`
+}
+
+// Suggestion returns the n-th suggestion counting across fields, as numbered
+// in admin reports
+func (d *Digest) Suggestion(n int) (string, *Suggestion, bool) {
+    for i := range d.Fields {
+        field := &d.Fields[i]
+        if n < len(field.Suggestions) {
+            return field.Field, &field.Suggestions[n], true
+        }
+        n -= len(field.Suggestions)
+    }
+    return "", nil, false
+}
+
+// Digester finds fields the parser keeps missing and asks the LLM to propose
+// CSS selectors for them from the stored raw HTML. Suggestions are tried
+// against the failing pages before they are reported, and adopted into the
+// scraper's selector config on an admin's request.
+type Digester struct {
+    scraper  *webscraper.VirtualsScraper
+    client   *llm.OpenRouterClient
+    interval time.Duration
+    path     string
+    mu       sync.Mutex // Serializes runs and digest file access
+    hooks    []func(*Digest)
+    hooksMu  sync.Mutex
+    logger   *log.Logger
+}
+
+// NewDigester creates a digester that runs automatically at most once per
+// interval; zero uses DefaultInterval
+func NewDigester(scraper *webscraper.VirtualsScraper, client *llm.OpenRouterClient, interval time.Duration, logger *log.Logger) *Digester {
+    if interval <= 0 {
+        interval = DefaultInterval
+    }
+    return &Digester{
+        scraper:  scraper,
+        client:   client,
+        interval: interval,
+        path:     digestFile,
+        logger:   logger,
+    }
+}
+
+// AddReportHook registers a function called with every digest that has
+// failing fields
+func (d *Digester) AddReportHook(hook func(*Digest)) {
+    d.hooksMu.Lock()
+    defer d.hooksMu.Unlock()
+    d.hooks = append(d.hooks, hook)
+}
+
+// Start runs a digest after scrape cycles, once the interval has passed
+// since the last one, until ctx is cancelled
+func (d *Digester) Start(ctx context.Context) {
+    d.scraper.AddScrapeHook(func() {
+        if ctx.Err() != nil {
+            return
+        }
+        last, err := d.Latest()
+        if err != nil {
+            d.logger.Printf("[DIGEST] Error loading parse digest: %v", err)
+        }
+        if last != nil && d.now().Sub(last.GeneratedAt) < d.interval {
+            return
+        }
+        if _, err := d.Run(ctx); err != nil {
+            d.logger.Printf("[DIGEST] Error running parse digest: %v", err)
+        }
+    })
+}
+
+func (d *Digester) now() time.Time {
+    return d.scraper.GetStore().Clock().Now()
+}
+
+// Run scans the stored pages, suggests selectors for every field missing
+// often enough, saves the digest and reports it to the hooks when any field
+// is failing
+func (d *Digester) Run(ctx context.Context) (*Digest, error) {
+    d.mu.Lock()
+    defer d.mu.Unlock()
+
+    misses, err := d.scraper.FieldMisses()
+    if err != nil {
+        return nil, fmt.Errorf("failed to scan stored pages: %w", err)
+    }
+    digest := &Digest{GeneratedAt: d.now(), Pages: misses.Pages, Fields: []FieldReport{}}
+
+    fields := make([]string, 0, len(misses.Missed))
+    for field := range misses.Missed {
+        fields = append(fields, field)
+    }
+    sort.Strings(fields)
+    for _, field := range fields {
+        ids := misses.Missed[field]
+        share := float64(len(ids)) / float64(misses.Pages)
+        if len(ids) < MinMissed || share < MinShare {
+            continue
+        }
+        report := FieldReport{Field: field, Missed: len(ids), Share: share, Samples: ids[:min(samplePages, len(ids))]}
+        report.Suggestions, err = d.suggest(ctx, field, ids)
+        if err != nil {
+            d.logger.Printf("[DIGEST] Error suggesting selectors for %s: %v", field, err)
+        }
+        digest.Fields = append(digest.Fields, report)
+    }
+
+    if err := d.save(digest); err != nil {
+        return nil, err
+    }
+    d.logger.Printf("[DIGEST] Scanned %d pages, %d fields failing", digest.Pages, len(digest.Fields))
+    if len(digest.Fields) == 0 {
+        return digest, nil
+    }
+
+    d.hooksMu.Lock()
+    hooks := append([]func(*Digest){}, d.hooks...)
+    d.hooksMu.Unlock()
+    for _, hook := range hooks {
+        hook(digest)
+    }
+    return digest, nil
+}
+
+// suggest asks the LLM for selectors finding field on sample failing pages
+// and keeps the ones that find text on any failing page, best first
+func (d *Digester) suggest(ctx context.Context, field string, ids []int) ([]Suggestion, error) {
+    var prompt strings.Builder
+    fmt.Fprintf(&prompt, "Field: %s\nSelectors that no longer match: %s\n", field, strings.Join(webscraper.DefaultSelectors(field), ", "))
+    for _, id := range ids[:min(samplePages, len(ids))] {
+        html, err := d.scraper.LoadPage(id)
+        if err != nil {
+            continue
+        }
+        fmt.Fprintf(&prompt, "\nPage %d:\n%s\n", id, trimHTML(html))
+    }
+
+    response, err := d.client.GetResponse(llm.WithCommand(ctx, PromptKey), PromptKey, prompt.String())
+    if err != nil {
+        return nil, err
+    }
+
+    tested := ids[:min(testPages, len(ids))]
+    var suggestions []Suggestion
+    for _, selector := range parseSelectors(response) {
+        if webscraper.ValidateSelector(selector) != nil {
+            continue
+        }
+        fixes, example := d.scraper.TrySelector(selector, tested)
+        if fixes == 0 {
+            continue
+        }
+        suggestions = append(suggestions, Suggestion{Selector: selector, Fixes: fixes, Tested: len(tested), Example: truncate(example, 80)})
+    }
+    sort.SliceStable(suggestions, func(i, j int) bool { return suggestions[i].Fixes > suggestions[j].Fixes })
+    if len(suggestions) > maxSuggestions {
+        suggestions = suggestions[:maxSuggestions]
+    }
+    return suggestions, nil
+}
+
+// Adopt adds the n-th suggestion of the latest digest to the selector config
+// and marks it adopted in the digest
+func (d *Digester) Adopt(n int) (string, string, error) {
+    d.mu.Lock()
+    defer d.mu.Unlock()
+
+    digest, err := d.load()
+    if err != nil {
+        return "", "", err
+    }
+    if digest == nil {
+        return "", "", fmt.Errorf("no parse digest yet")
+    }
+    field, suggestion, ok := digest.Suggestion(n)
+    if !ok {
+        return "", "", fmt.Errorf("no suggestion %d in the latest digest", n+1)
+    }
+    if _, err := d.scraper.AdoptSelector(field, suggestion.Selector); err != nil {
+        return "", "", err
+    }
+    suggestion.Adopted = true
+    if err := d.save(digest); err != nil {
+        d.logger.Printf("[DIGEST] Error saving parse digest: %v", err)
+    }
+    return field, suggestion.Selector, nil
+}
+
+// Latest returns the last saved digest, or nil if none has run yet
+func (d *Digester) Latest() (*Digest, error) {
+    d.mu.Lock()
+    defer d.mu.Unlock()
+    return d.load()
+}
+
+func (d *Digester) load() (*Digest, error) {
+    data, err := os.ReadFile(d.path)
+    if os.IsNotExist(err) {
+        return nil, nil
+    }
+    if err != nil {
+        return nil, err
+    }
+    var digest Digest
+    if err := json.Unmarshal(data, &digest); err != nil {
+        return nil, err
+    }
+    return &digest, nil
+}
+
+func (d *Digester) save(digest *Digest) error {
+    if err := os.MkdirAll(filepath.Dir(d.path), 0755); err != nil {
+        return err
+    }
+    data, err := json.MarshalIndent(digest, "", "  ")
+    if err != nil {
+        return err
+    }
+    return os.WriteFile(d.path, data, 0644)
+}
+
+var (
+    whitespacePattern = regexp.MustCompile(`\s+`)
+    listMarkerPattern = regexp.MustCompile(`^(?:[-*•]|\d+[.)])\s+`)
+)
+
+// trimHTML strips scripts, styles and other markup that carries no text from
+// a page and shortens it to fit the prompt
+func trimHTML(html string) string {
+    doc, err := goquery.NewDocumentFromReader(strings.NewReader(html))
+    if err != nil {
+        return truncate(html, sampleChars)
+    }
+    doc.Find("script, style, noscript, svg, link, meta, iframe").Remove()
+    body, err := doc.Find("body").Html()
+    if err != nil || body == "" {
+        body, _ = doc.Html()
+    }
+    return truncate(whitespacePattern.ReplaceAllString(body, " "), sampleChars)
+}
+
+// parseSelectors reads one selector per line from the LLM's reply, dropping
+// list markers, numbering and code fences
+func parseSelectors(response string) []string {
+    seen := make(map[string]bool)
+    var selectors []string
+    for _, line := range strings.Split(response, "\n") {
+        line = strings.TrimSpace(line)
+        if line == "" || strings.HasPrefix(line, "```") {
+            continue
+        }
+        line = listMarkerPattern.ReplaceAllString(line, "")
+        line = strings.Trim(strings.TrimSpace(line), "`")
+        if line == "" || seen[line] {
+            continue
+        }
+        seen[line] = true
+        selectors = append(selectors, line)
+    }
+    return selectors
+}
+
+func truncate(s string, n int) string {
+    runes := []rune(s)
+    if len(runes) <= n {
+        return s
+    }
+    return string(runes[:n]) + "…"
+}
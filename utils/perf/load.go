@@ -0,0 +1,157 @@
+package perf
+
+import (
+    "context"
+    "fmt"
+    "io"
+    "net/http"
+    "sort"
+    "sync"
+    "time"
+)
+
+// Target is one request the load generator sends
+type Target struct {
+    Method string
+    URL    string
+    Header http.Header
+}
+
+// Result summarizes an attack's latencies and failures
+type Result struct {
+    Requests int           `json:"requests"`
+    Errors   int           `json:"errors"` // Transport errors and non-2xx/304 responses
+    Rate     float64       `json:"rate"`   // Requests completed per second
+    P50      time.Duration `json:"p50"`
+    P90      time.Duration `json:"p90"`
+    P99      time.Duration `json:"p99"`
+    Max      time.Duration `json:"max"`
+}
+
+// Budget is the latency and error rate a run must stay within
+type Budget struct {
+    P99       time.Duration
+    ErrorRate float64
+}
+
+// DefaultBudget holds p99 under 50ms with no failed requests
+var DefaultBudget = Budget{P99: 50 * time.Millisecond}
+
+// Check returns an error describing how the result exceeded the budget
+func (r Result) Check(budget Budget) error {
+    if r.Requests == 0 {
+        return fmt.Errorf("no requests completed")
+    }
+    if r.P99 > budget.P99 {
+        return fmt.Errorf("p99 %v exceeds budget %v", r.P99, budget.P99)
+    }
+    if rate := float64(r.Errors) / float64(r.Requests); rate > budget.ErrorRate {
+        return fmt.Errorf("error rate %.2f%% exceeds budget %.2f%%", rate*100, budget.ErrorRate*100)
+    }
+    return nil
+}
+
+func (r Result) String() string {
+    return fmt.Sprintf("%d requests at %.0f/s, %d errors, p50 %v, p90 %v, p99 %v, max %v",
+        r.Requests, r.Rate, r.Errors, r.P50, r.P90, r.P99, r.Max)
+}
+
+// Attack sends requests at a constant rate for duration, cycling through
+// targets, and measures each response's latency including reading the body.
+// Requests are paced by the clock rather than by responses, so a slow server
+// builds up concurrent requests instead of hiding its latency.
+func Attack(ctx context.Context, client *http.Client, targets []Target, rate int, duration time.Duration) Result {
+    if len(targets) == 0 || rate <= 0 {
+        return Result{}
+    }
+
+    ctx, cancel := context.WithTimeout(ctx, duration)
+    defer cancel()
+
+    var mu sync.Mutex
+    var wg sync.WaitGroup
+    latencies := make([]time.Duration, 0, rate*int(duration/time.Second+1))
+    errors := 0
+
+    ticker := time.NewTicker(time.Second / time.Duration(rate))
+    defer ticker.Stop()
+    start := time.Now()
+    for sent := 0; ; sent++ {
+        select {
+        case <-ctx.Done():
+            wg.Wait()
+            return summarize(latencies, errors, time.Since(start))
+        case <-ticker.C:
+        }
+
+        wg.Add(1)
+        go func(target Target) {
+            defer wg.Done()
+            latency, err := hit(client, target)
+            mu.Lock()
+            defer mu.Unlock()
+            latencies = append(latencies, latency)
+            if err != nil {
+                errors++
+            }
+        }(targets[sent%len(targets)])
+    }
+}
+
+// hit sends one request, which isn't bound to the attack's context so
+// requests in flight when it ends still complete
+func hit(client *http.Client, target Target) (time.Duration, error) {
+    method := target.Method
+    if method == "" {
+        method = http.MethodGet
+    }
+    req, err := http.NewRequest(method, target.URL, nil)
+    if err != nil {
+        return 0, err
+    }
+    for name, values := range target.Header {
+        req.Header[name] = values
+    }
+
+    start := time.Now()
+    resp, err := client.Do(req)
+    if err != nil {
+        return time.Since(start), err
+    }
+    _, err = io.Copy(io.Discard, resp.Body)
+    resp.Body.Close()
+    latency := time.Since(start)
+    if err != nil {
+        return latency, err
+    }
+    if resp.StatusCode >= 300 && resp.StatusCode != http.StatusNotModified {
+        return latency, fmt.Errorf("HTTP %d", resp.StatusCode)
+    }
+    return latency, nil
+}
+
+func summarize(latencies []time.Duration, errors int, elapsed time.Duration) Result {
+    result := Result{Requests: len(latencies), Errors: errors}
+    if len(latencies) == 0 {
+        return result
+    }
+    sort.Slice(latencies, func(i, j int) bool { return latencies[i] < latencies[j] })
+    result.Rate = float64(len(latencies)) / elapsed.Seconds()
+    result.P50 = percentile(latencies, 0.50)
+    result.P90 = percentile(latencies, 0.90)
+    result.P99 = percentile(latencies, 0.99)
+    result.Max = latencies[len(latencies)-1]
+    return result
+}
+
+// percentile reads the nearest-rank percentile from sorted latencies
+func percentile(sorted []time.Duration, p float64) time.Duration {
+    rank := int(p*float64(len(sorted))+0.5) - 1
+    if rank < 0 {
+        rank = 0
+    }
+    if rank >= len(sorted) {
+        rank = len(sorted) - 1
+    }
+    return sorted[rank]
+}
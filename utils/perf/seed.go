@@ -0,0 +1,58 @@
+package perf
+
+import (
+    "fmt"
+    "math/rand"
+    "time"
+    "anondd/utils/models"
+    "anondd/utils/storage"
+)
+
+// seedBatch is how many synthetic agents are saved per batch
+const seedBatch = 500
+
+// Seed fills store with n synthetic agents shaped like scraped ones, so load
+// tests can run against a full-size index without scraping. It returns the
+// agents' IDs.
+func Seed(store *storage.AgentStore, n int) ([]string, error) {
+    random := rand.New(rand.NewSource(1))
+    statuses := []string{models.StatusActive, models.StatusActive, models.StatusLatent, models.StatusDead}
+    now := time.Now()
+
+    ids := make([]string, 0, n)
+    batch := make([]models.Agent, 0, seedBatch)
+    for i := 1; i <= n; i++ {
+        agent := models.Agent{
+            SourceID:    i,
+            Name:        fmt.Sprintf("Agent %05d", i),
+            Description: fmt.Sprintf("Synthetic agent %d for load testing. It posts market takes and answers questions about its token.", i),
+            Stats:       fmt.Sprintf("%d holders", random.Intn(50000)),
+            Price:       fmt.Sprintf("$%.6f", random.Float64()),
+            ScrapedAt:   now,
+            LaunchedAt:  now.Add(-time.Duration(random.Intn(365*24)) * time.Hour),
+            Status:      statuses[random.Intn(len(statuses))],
+            InfluenceMetrics: models.InfluenceMetrics{
+                Mindshare: fmt.Sprintf("%.2f%%", random.Float64()*5),
+                Followers: fmt.Sprintf("%d", random.Intn(100000)),
+            },
+            TokenData: models.TokenData{
+                MCFDV:     fmt.Sprintf("$%.2fM", random.Float64()*100),
+                Change24h: fmt.Sprintf("%.2f%%", random.NormFloat64()*10),
+                Holders:   fmt.Sprintf("%d", random.Intn(50000)),
+                Volume24h: fmt.Sprintf("$%.2fK", random.Float64()*1000),
+            },
+            ParseSuccess: true,
+        }
+        agent.GenerateID()
+        ids = append(ids, agent.ID)
+        batch = append(batch, agent)
+
+        if len(batch) == seedBatch || i == n {
+            if err := store.SaveAgents(batch); err != nil {
+                return nil, fmt.Errorf("failed to seed agents: %w", err)
+            }
+            batch = batch[:0]
+        }
+    }
+    return ids, nil
+}
@@ -0,0 +1,197 @@
+package pipeline
+
+import (
+    "context"
+    "fmt"
+    "log"
+    "sort"
+    "strings"
+    "text/template"
+    "anondd/llm"
+    "anondd/utils/models"
+    "anondd/utils/storage"
+)
+
+// State is the data passed between steps
+type State struct {
+    Agent  *models.Agent
+    Data   map[string]string
+    Keys   []string // Data keys in insertion order
+    LLM    string
+    Output string
+}
+
+func (s *State) set(key, value string) {
+    if _, exists := s.Data[key]; !exists {
+        s.Keys = append(s.Keys, key)
+    }
+    s.Data[key] = value
+}
+
+// Engine runs pipelines against the agent store
+type Engine struct {
+    store     *storage.AgentStore
+    client    *llm.OpenRouterClient
+    logger    *log.Logger
+    pipelines map[string]Pipeline
+}
+
+// NewEngine creates an engine for the given pipelines
+func NewEngine(store *storage.AgentStore, client *llm.OpenRouterClient, pipelines []Pipeline, logger *log.Logger) *Engine {
+    engine := &Engine{
+        store:     store,
+        client:    client,
+        logger:    logger,
+        pipelines: make(map[string]Pipeline, len(pipelines)),
+    }
+    for _, p := range pipelines {
+        engine.pipelines[p.Name] = p
+    }
+    return engine
+}
+
+// List returns the configured pipelines sorted by name
+func (e *Engine) List() []Pipeline {
+    list := make([]Pipeline, 0, len(e.pipelines))
+    for _, p := range e.pipelines {
+        list = append(list, p)
+    }
+    sort.Slice(list, func(i, j int) bool { return list[i].Name < list[j].Name })
+    return list
+}
+
+// ForCommand returns the pipeline bound to a Telegram command
+func (e *Engine) ForCommand(command string) (Pipeline, bool) {
+    for _, p := range e.pipelines {
+        if p.Command != "" && p.Command == command {
+            return p, true
+        }
+    }
+    return Pipeline{}, false
+}
+
+// Run executes the named pipeline for the agent matching query (an ID or name)
+func (e *Engine) Run(ctx context.Context, name, query string) (*State, error) {
+    p, exists := e.pipelines[name]
+    if !exists {
+        return nil, fmt.Errorf("unknown pipeline %s: %w", name, storage.ErrNotFound)
+    }
+
+    state := &State{Data: make(map[string]string)}
+    for i, step := range p.Steps {
+        var err error
+        switch step.Type {
+        case StepFetch:
+            err = e.fetch(ctx, state, step, query)
+        case StepStats:
+            computeStats(state)
+        case StepLLM:
+            err = e.callLLM(ctx, state, step)
+        case StepFormat:
+            err = format(state, step)
+        }
+        if err != nil {
+            return nil, fmt.Errorf("pipeline %s step %d (%s): %w", name, i+1, step.Type, err)
+        }
+    }
+
+    if state.Output == "" {
+        state.Output = state.LLM
+    }
+    return state, nil
+}
+
+func (e *Engine) fetch(ctx context.Context, state *State, step Step, query string) error {
+    agent, err := e.store.FindAgent(ctx, query)
+    if err != nil {
+        return err
+    }
+    state.Agent = agent
+
+    all := agentFields(agent)
+    fields := step.Fields
+    if len(fields) == 0 {
+        fields = fieldOrder
+    }
+    for _, field := range fields {
+        if value := all[field]; value != "" {
+            state.set(field, value)
+        }
+    }
+    return nil
+}
+
+func (e *Engine) callLLM(ctx context.Context, state *State, step Step) error {
+    var b strings.Builder
+    if state.Agent != nil {
+        fmt.Fprintf(&b, "Name: %s\n", state.Agent.Name)
+    }
+    for _, key := range state.Keys {
+        fmt.Fprintf(&b, "%s: %s\n", key, state.Data[key])
+    }
+
+    response, err := e.client.GetResponse(ctx, step.PromptKey, b.String())
+    if err != nil {
+        return err
+    }
+    state.LLM = response
+    return nil
+}
+
+func format(state *State, step Step) error {
+    tmpl, err := template.New("output").Parse(step.Template)
+    if err != nil {
+        return fmt.Errorf("invalid template: %w", err)
+    }
+    var b strings.Builder
+    if err := tmpl.Execute(&b, state); err != nil {
+        return fmt.Errorf("failed to render template: %w", err)
+    }
+    state.Output = strings.TrimSpace(b.String())
+    return nil
+}
+
+// fieldOrder is the order fields are emitted when a fetch step keeps all fields
+var fieldOrder = []string{
+    "price", "status", "stats", "description",
+    "mindshare", "impressions", "engagement", "followers", "smart_followers",
+    "mc_fdv", "change_24h", "tvl", "holders", "volume_24h", "inferences",
+}
+
+func agentFields(a *models.Agent) map[string]string {
+    return map[string]string{
+        "price":           a.Price,
+        "status":          a.Status,
+        "stats":           a.StatsText(),
+        "description":     a.Description,
+        "mindshare":       a.InfluenceMetrics.Mindshare,
+        "impressions":     a.InfluenceMetrics.Impressions,
+        "engagement":      a.InfluenceMetrics.Engagement,
+        "followers":       a.InfluenceMetrics.Followers,
+        "smart_followers": a.InfluenceMetrics.SmartFollowers,
+        "mc_fdv":          a.TokenData.MCFDV,
+        "change_24h":      a.TokenData.Change24h,
+        "tvl":             a.TokenData.TVL,
+        "holders":         a.TokenData.Holders,
+        "volume_24h":      a.TokenData.Volume24h,
+        "inferences":      a.TokenData.Inferences,
+    }
+}
+
+// computeStats derives ratios from the fetched fields when both sides parse
+func computeStats(state *State) {
+    mcap, hasMcap := models.ParseAmount(state.Data["mc_fdv"])
+    volume, hasVolume := models.ParseAmount(state.Data["volume_24h"])
+    tvl, hasTVL := models.ParseAmount(state.Data["tvl"])
+    holders, hasHolders := models.ParseAmount(state.Data["holders"])
+
+    if hasMcap && hasVolume && mcap > 0 {
+        state.set("volume_to_mcap", fmt.Sprintf("%.2f%%", volume/mcap*100))
+    }
+    if hasMcap && hasTVL && mcap > 0 {
+        state.set("tvl_to_mcap", fmt.Sprintf("%.2f%%", tvl/mcap*100))
+    }
+    if hasMcap && hasHolders && holders > 0 {
+        state.set("mcap_per_holder", fmt.Sprintf("$%.2f", mcap/holders))
+    }
+}
@@ -0,0 +1,116 @@
+package pipeline
+
+import (
+    "encoding/json"
+    "fmt"
+    "os"
+    "strings"
+)
+
+// Step types understood by the engine
+const (
+    StepFetch  = "fetch"  // Load the agent and select fields into the data set
+    StepStats  = "stats"  // Compute derived metrics from the data set
+    StepLLM    = "llm"    // Send the data set to the LLM with a prompt key
+    StepFormat = "format" // Render the output with a text/template
+)
+
+// Step is one stage of a pipeline
+type Step struct {
+    Type      string   `json:"type"`
+    Fields    []string `json:"fields,omitempty"`     // fetch: fields to keep, empty keeps all
+    PromptKey string   `json:"prompt_key,omitempty"` // llm: prompt key to use
+    Template  string   `json:"template,omitempty"`   // format: output template
+}
+
+// Pipeline is a named, ordered list of steps
+type Pipeline struct {
+    Name        string `json:"name"`
+    Description string `json:"description"`
+    Command     string `json:"command,omitempty"` // Telegram command that runs it, e.g. /risk_dd
+    Steps       []Step `json:"steps"`
+}
+
+// Validate checks that the pipeline is runnable
+func (p *Pipeline) Validate() error {
+    if p.Name == "" {
+        return fmt.Errorf("pipeline name is required")
+    }
+    if len(p.Steps) == 0 || p.Steps[0].Type != StepFetch {
+        return fmt.Errorf("pipeline %s must start with a fetch step", p.Name)
+    }
+    for i, step := range p.Steps {
+        switch step.Type {
+        case StepFetch, StepStats, StepFormat:
+        case StepLLM:
+            if step.PromptKey == "" {
+                return fmt.Errorf("pipeline %s step %d: llm step needs a prompt_key", p.Name, i+1)
+            }
+        default:
+            return fmt.Errorf("pipeline %s step %d: unknown step type %q", p.Name, i+1, step.Type)
+        }
+    }
+    return nil
+}
+
+// DefaultPipelines are used when no pipeline config file exists
+var DefaultPipelines = []Pipeline{
+    {
+        Name:        "metrics",
+        Description: "Metrics only, no LLM",
+        Command:     "/metrics",
+        Steps: []Step{
+            {Type: StepFetch, Fields: []string{"price", "mc_fdv", "change_24h", "tvl", "holders", "volume_24h", "mindshare", "followers"}},
+            {Type: StepStats},
+            {Type: StepFormat, Template: "📊 {{.Agent.Name}}\n{{range .Keys}}{{.}}: {{index $.Data .}}\n{{end}}"},
+        },
+    },
+    {
+        Name:        "risk",
+        Description: "Risk-focused DD",
+        Command:     "/risk_dd",
+        Steps: []Step{
+            {Type: StepFetch, Fields: []string{"price", "status", "description", "mc_fdv", "change_24h", "tvl", "holders", "volume_24h"}},
+            {Type: StepStats},
+            {Type: StepLLM, PromptKey: "dd_risks"},
+            {Type: StepFormat, Template: "⚠️ Risk check for {{.Agent.Name}}\n\n{{.LLM}}"},
+        },
+    },
+    {
+        Name:        "tokenomics",
+        Description: "Tokenomics-focused DD",
+        Command:     "/tokenomics",
+        Steps: []Step{
+            {Type: StepFetch, Fields: []string{"price", "mc_fdv", "tvl", "holders", "volume_24h", "change_24h"}},
+            {Type: StepStats},
+            {Type: StepLLM, PromptKey: "tokenomics"},
+            {Type: StepFormat, Template: "🪙 Tokenomics for {{.Agent.Name}}\n\n{{.LLM}}"},
+        },
+    },
+}
+
+// LoadPipelines reads pipeline definitions from a JSON file, falling back to
+// DefaultPipelines if the file does not exist
+func LoadPipelines(path string) ([]Pipeline, error) {
+    data, err := os.ReadFile(path)
+    if os.IsNotExist(err) {
+        return DefaultPipelines, nil
+    }
+    if err != nil {
+        return nil, fmt.Errorf("failed to read pipelines: %w", err)
+    }
+
+    var pipelines []Pipeline
+    if err := json.Unmarshal(data, &pipelines); err != nil {
+        return nil, fmt.Errorf("failed to unmarshal pipelines: %w", err)
+    }
+    for i := range pipelines {
+        if err := pipelines[i].Validate(); err != nil {
+            return nil, err
+        }
+        if pipelines[i].Command != "" && !strings.HasPrefix(pipelines[i].Command, "/") {
+            pipelines[i].Command = "/" + pipelines[i].Command
+        }
+    }
+    return pipelines, nil
+}
@@ -0,0 +1,46 @@
+package plugins
+
+import (
+    "fmt"
+    "os"
+    "path/filepath"
+    "plugin"
+)
+
+// Symbol is the name a shared-object plugin exports its CommandPlugin as
+const Symbol = "Plugin"
+
+// LoadDir opens every .so file in dir, built with go build -buildmode=plugin
+// against the same version of this module, and returns the CommandPlugin each
+// exports as Plugin. A missing directory loads nothing.
+func LoadDir(dir string) ([]CommandPlugin, error) {
+    if _, err := os.Stat(dir); os.IsNotExist(err) {
+        return nil, nil
+    }
+    paths, err := filepath.Glob(filepath.Join(dir, "*.so"))
+    if err != nil {
+        return nil, err
+    }
+
+    var loaded []CommandPlugin
+    for _, path := range paths {
+        p, err := plugin.Open(path)
+        if err != nil {
+            return loaded, fmt.Errorf("failed to open plugin %s: %w", path, err)
+        }
+        sym, err := p.Lookup(Symbol)
+        if err != nil {
+            return loaded, fmt.Errorf("plugin %s has no %s symbol: %w", path, Symbol, err)
+        }
+        // An exported variable is looked up as a pointer to it
+        switch v := sym.(type) {
+        case *CommandPlugin:
+            loaded = append(loaded, *v)
+        case CommandPlugin:
+            loaded = append(loaded, v)
+        default:
+            return loaded, fmt.Errorf("plugin %s: %s is a %T, not a CommandPlugin", path, Symbol, sym)
+        }
+    }
+    return loaded, nil
+}
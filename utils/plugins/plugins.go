@@ -0,0 +1,126 @@
+package plugins
+
+import (
+    "context"
+    "fmt"
+    "log"
+    "regexp"
+    "sort"
+    "strings"
+    "sync"
+    "anondd/llm"
+    "anondd/utils/storage"
+)
+
+// CommandInfo describes a plugin's bot command
+type CommandInfo struct {
+    Command     string // Slash command, such as "/whale_watch"
+    Description string // One line, shown by /plugins
+    Usage       string // Arguments, such as "<agent name>"; empty when there are none
+    Admin       bool   // Only admins may run the command
+}
+
+// Env is what the bot shares with plugins
+type Env struct {
+    Store  *storage.AgentStore
+    LLM    *llm.OpenRouterClient
+    Logger *log.Logger
+}
+
+// Request is one run of a plugin command
+type Request struct {
+    Env
+    Bot    string   // Name of the bot the command was sent to
+    ChatID int64
+    UserID int64    // 0 when the sender is unknown, such as in channels
+    Args   []string // Words after the command
+}
+
+// Response is what the bot sends back. Empty Text sends nothing, for
+// plugins that have nothing to say.
+type Response struct {
+    Text     string
+    Markdown bool // Send Text with Telegram's Markdown parse mode
+}
+
+// CommandPlugin adds a bot command without changes to the bot itself.
+// Handle runs on the bot's update workers, so it must be safe for
+// concurrent use and should return promptly once ctx is done.
+type CommandPlugin interface {
+    Info() CommandInfo
+    Handle(ctx context.Context, req Request) (Response, error)
+}
+
+var commandPattern = regexp.MustCompile(`^/[a-z][a-z0-9_]{0,31}$`)
+
+// Registry holds the installed plugins by command
+type Registry struct {
+    mu      sync.RWMutex
+    plugins map[string]CommandPlugin
+}
+
+// NewRegistry creates an empty registry
+func NewRegistry() *Registry {
+    return &Registry{plugins: make(map[string]CommandPlugin)}
+}
+
+// Register installs p under its command. Commands must be lowercase slash
+// commands, and two plugins can't share one.
+func (r *Registry) Register(p CommandPlugin) error {
+    info := p.Info()
+    if !commandPattern.MatchString(info.Command) {
+        return fmt.Errorf("invalid plugin command %q", info.Command)
+    }
+    r.mu.Lock()
+    defer r.mu.Unlock()
+    if _, exists := r.plugins[info.Command]; exists {
+        return fmt.Errorf("plugin command %s is already registered", info.Command)
+    }
+    r.plugins[info.Command] = p
+    return nil
+}
+
+// Lookup returns the plugin handling command, ignoring any @botname suffix
+func (r *Registry) Lookup(command string) (CommandPlugin, bool) {
+    if i := strings.Index(command, "@"); i >= 0 {
+        command = command[:i]
+    }
+    r.mu.RLock()
+    defer r.mu.RUnlock()
+    p, ok := r.plugins[strings.ToLower(command)]
+    return p, ok
+}
+
+// List returns the installed plugins' commands sorted by command
+func (r *Registry) List() []CommandInfo {
+    r.mu.RLock()
+    defer r.mu.RUnlock()
+    list := make([]CommandInfo, 0, len(r.plugins))
+    for _, p := range r.plugins {
+        list = append(list, p.Info())
+    }
+    sort.Slice(list, func(i, j int) bool { return list[i].Command < list[j].Command })
+    return list
+}
+
+// builtin holds plugins compiled into the binary, registered from their
+// packages' init functions
+var builtin struct {
+    mu      sync.Mutex
+    plugins []CommandPlugin
+}
+
+// Register adds a compiled-in plugin. Plugin packages call it from init and
+// are linked in with a blank import, usually behind a build tag.
+func Register(p CommandPlugin) {
+    builtin.mu.Lock()
+    defer builtin.mu.Unlock()
+    builtin.plugins = append(builtin.plugins, p)
+}
+
+// Builtin returns the compiled-in plugins in registration order
+func Builtin() []CommandPlugin {
+    builtin.mu.Lock()
+    defer builtin.mu.Unlock()
+    return append([]CommandPlugin(nil), builtin.plugins...)
+}
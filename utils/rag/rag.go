@@ -0,0 +1,207 @@
+package rag
+
+import (
+    "fmt"
+    "sort"
+    "strings"
+    "sync"
+    "time"
+    "unicode"
+    "anondd/utils/models"
+    "anondd/utils/storage"
+)
+
+// DefaultTopK is the number of agents injected into a RAG prompt
+const DefaultTopK = 3
+
+// MinScore is the score an agent needs to be retrieved without its name
+// being in the query, so small talk isn't answered from agent data
+const MinScore = 3
+
+// nameWeight is what a query word matching a word of an agent's name adds
+const nameWeight = 5
+
+// Words ignored when scoring queries against agents
+var stopWords = map[string]bool{
+    "a": true, "an": true, "the": true, "is": true, "are": true, "what": true, "which": true,
+    "who": true, "how": true, "of": true, "to": true, "in": true, "on": true, "for": true,
+    "and": true, "or": true, "about": true, "me": true, "tell": true, "do": true, "does": true,
+    "agent": true, "agents": true, "it": true, "its": true, "this": true, "that": true,
+    "you": true, "your": true, "yours": true, "i": true, "im": true, "my": true, "we": true,
+    "our": true, "they": true, "their": true, "he": true, "she": true, "be": true, "am": true,
+    "was": true, "were": true, "been": true, "have": true, "has": true, "had": true,
+    "can": true, "could": true, "would": true, "should": true, "will": true, "with": true,
+    "any": true, "some": true, "there": true, "here": true, "when": true, "where": true,
+    "why": true, "not": true, "no": true, "yes": true, "so": true, "if": true, "at": true,
+    "by": true, "from": true, "up": true, "out": true, "now": true, "today": true,
+    "hi": true, "hey": true, "hello": true, "thanks": true, "ok": true, "good": true,
+}
+
+// Result is an agent retrieved for a query with its relevance score
+type Result struct {
+    Agent   *models.Agent
+    Score   int
+    NameHit bool // A query word is a word of the agent's name
+}
+
+// document is an agent's words, counted for scoring
+type document struct {
+    id   string
+    name map[string]bool
+    body map[string]int
+}
+
+// Retriever finds the stored agents most relevant to a free-form question.
+// It keeps the words of every indexed agent and rereads them only when the
+// index changes, so questions don't load every agent record.
+type Retriever struct {
+    store *storage.AgentStore
+
+    mu       sync.Mutex
+    docs     []document
+    builtFor time.Time // LastUpdated of the index docs were read from
+}
+
+// NewRetriever creates a keyword retriever over the agent store
+func NewRetriever(store *storage.AgentStore) *Retriever {
+    return &Retriever{store: store}
+}
+
+// TopK returns up to k agents ranked by keyword overlap with the query.
+// Only agents named in the query or scoring at least MinScore are returned.
+func (r *Retriever) TopK(query string, k int) ([]Result, error) {
+    terms := uniqueTerms(Tokenize(query))
+    if len(terms) == 0 {
+        return nil, nil
+    }
+
+    docs, err := r.documents()
+    if err != nil {
+        return nil, err
+    }
+
+    type scored struct {
+        id      string
+        score   int
+        nameHit bool
+    }
+    var ranked []scored
+    for _, doc := range docs {
+        score, nameHit := scoreDocument(doc, terms)
+        if nameHit || score >= MinScore {
+            ranked = append(ranked, scored{id: doc.id, score: score, nameHit: nameHit})
+        }
+    }
+    sort.SliceStable(ranked, func(i, j int) bool {
+        return ranked[i].score > ranked[j].score
+    })
+
+    var results []Result
+    for _, match := range ranked {
+        if len(results) == k {
+            break
+        }
+        agent, err := r.store.GetAgent(match.id)
+        if err != nil {
+            continue
+        }
+        results = append(results, Result{Agent: agent, Score: match.score, NameHit: match.nameHit})
+    }
+    return results, nil
+}
+
+// documents returns the agents' words, rereading them when the index changed
+func (r *Retriever) documents() ([]document, error) {
+    index, err := r.store.GetIndex()
+    if err != nil {
+        return nil, fmt.Errorf("failed to load index: %w", err)
+    }
+
+    r.mu.Lock()
+    defer r.mu.Unlock()
+    if r.docs != nil && index.LastUpdated.Equal(r.builtFor) {
+        return r.docs, nil
+    }
+
+    docs := make([]document, 0, len(index.Agents))
+    for _, summary := range index.Agents {
+        agent, err := r.store.GetAgent(summary.ID)
+        if err != nil {
+            continue
+        }
+        doc := document{id: agent.ID, name: make(map[string]bool), body: make(map[string]int)}
+        for _, term := range Tokenize(agent.Name) {
+            doc.name[term] = true
+        }
+        for _, term := range Tokenize(agent.Description + " " + agent.StatsText()) {
+            doc.body[term]++
+        }
+        docs = append(docs, doc)
+    }
+    r.docs, r.builtFor = docs, index.LastUpdated
+    return docs, nil
+}
+
+// scoreDocument weights name matches above description and stats matches.
+// Only whole words count, so "you" doesn't match "your".
+func scoreDocument(doc document, terms []string) (score int, nameHit bool) {
+    for _, term := range terms {
+        if doc.name[term] {
+            score += nameWeight
+            nameHit = true
+        }
+        score += doc.body[term]
+    }
+    return score, nameHit
+}
+
+func uniqueTerms(terms []string) []string {
+    seen := make(map[string]bool, len(terms))
+    var unique []string
+    for _, term := range terms {
+        if !seen[term] {
+            seen[term] = true
+            unique = append(unique, term)
+        }
+    }
+    return unique
+}
+
+// Tokenize lowercases the text and splits it into keywords, dropping stop words
+func Tokenize(text string) []string {
+    words := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+        return !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '$'
+    })
+
+    var terms []string
+    for _, word := range words {
+        word = strings.TrimPrefix(word, "$")
+        if len(word) < 2 || stopWords[word] {
+            continue
+        }
+        terms = append(terms, word)
+    }
+    return terms
+}
+
+// BuildContext formats retrieved agents as numbered sources for the prompt
+func BuildContext(results []Result) string {
+    var b strings.Builder
+    for i, result := range results {
+        a := result.Agent
+        fmt.Fprintf(&b, "[%d] Name: %s\nPrice: %s\nStatus: %s\nStats: %s\nMindshare: %s\nHolders: %s\n24h Volume: %s\nDescription: %s\n\n",
+            i+1, a.Name, a.Price, a.Status, a.StatsText(), a.InfluenceMetrics.Mindshare,
+            a.TokenData.Holders, a.TokenData.Volume24h, a.Description)
+    }
+    return b.String()
+}
+
+// Citations lists the sources used for an answer
+func Citations(results []Result) string {
+    var b strings.Builder
+    b.WriteString("📚 Sources:")
+    for i, result := range results {
+        fmt.Fprintf(&b, "\n[%d] %s (%s)", i+1, result.Agent.Name, result.Agent.ID)
+    }
+    return b.String()
+}
@@ -0,0 +1,54 @@
+package rag
+
+import (
+    "io"
+    "log"
+    "testing"
+    "anondd/utils/models"
+    "anondd/utils/storage"
+)
+
+func newTestRetriever(t *testing.T) *Retriever {
+    t.Helper()
+    store := storage.NewAgentStore(t.TempDir(), log.New(io.Discard, "", 0))
+    agents := []models.Agent{
+        {Name: "Luna", Price: "1", Description: "Your virtual companion for music and your daily chats"},
+        {Name: "Aixbt", Price: "2", Description: "Market intelligence agent tracking narratives and market sentiment"},
+    }
+    if err := store.SaveAgents(agents); err != nil {
+        t.Fatalf("SaveAgents: %v", err)
+    }
+    return NewRetriever(store)
+}
+
+func TestTopKIgnoresSmallTalk(t *testing.T) {
+    r := newTestRetriever(t)
+    for _, query := range []string{"how are you?", "are you there?", "what's up with music?"} {
+        results, err := r.TopK(query, DefaultTopK)
+        if err != nil {
+            t.Fatalf("TopK(%q): %v", query, err)
+        }
+        if len(results) != 0 {
+            t.Errorf("TopK(%q) = %s, want no agents", query, results[0].Agent.Name)
+        }
+    }
+}
+
+func TestTopKMatchesNamesAndStrongMatches(t *testing.T) {
+    r := newTestRetriever(t)
+    results, err := r.TopK("is $LUNA any good?", DefaultTopK)
+    if err != nil {
+        t.Fatalf("TopK: %v", err)
+    }
+    if len(results) != 1 || results[0].Agent.Name != "Luna" || !results[0].NameHit {
+        t.Errorf("TopK by name = %+v, want Luna", results)
+    }
+
+    results, err = r.TopK("which one covers market sentiment and narratives?", DefaultTopK)
+    if err != nil {
+        t.Fatalf("TopK: %v", err)
+    }
+    if len(results) != 1 || results[0].Agent.Name != "Aixbt" || results[0].NameHit {
+        t.Errorf("TopK by description = %+v, want Aixbt", results)
+    }
+}
@@ -0,0 +1,218 @@
+package report
+
+import (
+    "context"
+    "fmt"
+    "log"
+    "sort"
+    "strings"
+    "sync"
+    "time"
+    "anondd/llm"
+    "anondd/utils/clock"
+    "anondd/utils/models"
+    "anondd/utils/storage"
+)
+
+const (
+    // DefaultWeeklySchedule runs the weekly report on Mondays at 09:00
+    DefaultWeeklySchedule = "0 9 * * 1"
+
+    weeklyPeriod = 7 * 24 * time.Hour
+    topMovers    = 5
+)
+
+// Reporter compiles, writes and publishes periodic market reports
+type Reporter struct {
+    store     *storage.AgentStore
+    client    *llm.OpenRouterClient
+    scheduler *clock.Cron
+    location  *time.Location
+    hooks     []func(*models.Report)
+    hooksMu   sync.Mutex
+    logger    *log.Logger
+}
+
+// NewReporter creates a reporter over the store's index and metric history
+func NewReporter(store *storage.AgentStore, client *llm.OpenRouterClient, logger *log.Logger) *Reporter {
+    return &Reporter{
+        store:     store,
+        client:    client,
+        location:  time.Local,
+        logger:    logger,
+    }
+}
+
+// SetLocation sets the time zone the schedule and report dates use. Call it
+// before Start.
+func (r *Reporter) SetLocation(loc *time.Location) {
+    r.location = loc
+}
+
+// AddPublishHook registers a function called with every newly generated report
+func (r *Reporter) AddPublishHook(hook func(*models.Report)) {
+    r.hooksMu.Lock()
+    defer r.hooksMu.Unlock()
+    r.hooks = append(r.hooks, hook)
+}
+
+// Start generates the weekly report on the given cron schedule until ctx is cancelled
+func (r *Reporter) Start(ctx context.Context, schedule string) error {
+    // The schedule follows the store's clock, so a replayed run reports on
+    // replayed weeks
+    r.scheduler = clock.NewCron(r.store.Clock(), r.location)
+    err := r.scheduler.AddFunc(schedule, func() {
+        if _, err := r.GenerateWeekly(ctx, r.store.Clock().Now().In(r.location)); err != nil {
+            r.logger.Printf("Error generating weekly report: %v", err)
+        }
+    })
+    if err != nil {
+        return fmt.Errorf("invalid report schedule %q: %w", schedule, err)
+    }
+    r.scheduler.Start()
+
+    go func() {
+        <-ctx.Done()
+        r.scheduler.Stop()
+    }()
+    return nil
+}
+
+// GenerateWeekly compiles the week ending at now, has the LLM write it up,
+// saves it and runs the publish hooks
+func (r *Reporter) GenerateWeekly(ctx context.Context, now time.Time) (*models.Report, error) {
+    start := now.Add(-weeklyPeriod)
+    aggregates, err := r.compile(ctx, start, now)
+    if err != nil {
+        return nil, err
+    }
+
+    text, err := r.client.GetResponse(ctx, "weekly_report", formatAggregates(aggregates, start, now))
+    if err != nil {
+        return nil, fmt.Errorf("failed to write weekly report: %w", err)
+    }
+
+    report := &models.Report{
+        Kind:        models.ReportWeekly,
+        PeriodStart: start,
+        PeriodEnd:   now,
+        GeneratedAt: r.store.Clock().Now(),
+        Aggregates:  aggregates,
+        Text:        text,
+    }
+    if err := r.store.SaveReport(report); err != nil {
+        return nil, fmt.Errorf("failed to save weekly report: %w", err)
+    }
+    r.logger.Printf("Generated weekly report for %s - %s", start.Format("2006-01-02"), now.Format("2006-01-02"))
+
+    r.hooksMu.Lock()
+    hooks := append([]func(*models.Report){}, r.hooks...)
+    r.hooksMu.Unlock()
+    for _, hook := range hooks {
+        hook(report)
+    }
+    return report, nil
+}
+
+// compile gathers new and dead agents, top movers by market cap and market totals
+func (r *Reporter) compile(ctx context.Context, start, end time.Time) (models.ReportAggregates, error) {
+    var aggregates models.ReportAggregates
+
+    index, err := r.store.GetIndexContext(ctx)
+    if err != nil {
+        return aggregates, err
+    }
+    aggregates.TotalAgents = len(index.Agents)
+
+    var moves []models.AgentMove
+    var fromTotal, toTotal float64
+    for _, summary := range index.Agents {
+        if summary.FirstSeen.After(start) {
+            aggregates.NewAgents = append(aggregates.NewAgents, summary)
+        }
+
+        agent, err := r.store.GetAgentContext(ctx, summary.ID)
+        if err != nil {
+            continue
+        }
+        if agent.Status == models.StatusDead {
+            aggregates.DeadAgents = append(aggregates.DeadAgents, summary)
+        }
+
+        history, err := r.store.GetHistory(agent.SourceID)
+        if err != nil || len(history) == 0 {
+            continue
+        }
+        latest := history[len(history)-1]
+        aggregates.TotalMCap += latest.MCap
+        aggregates.TotalVolume24h += latest.Volume24h
+        aggregates.TotalHolders += latest.Holders
+
+        baseline, ok := baselineSnapshot(history, start)
+        if !ok || baseline.MCap <= 0 || latest.MCap <= 0 {
+            continue
+        }
+        fromTotal += baseline.MCap
+        toTotal += latest.MCap
+        moves = append(moves, models.AgentMove{
+            ID:        agent.ID,
+            Name:      agent.Name,
+            FromMCap:  baseline.MCap,
+            ToMCap:    latest.MCap,
+            ChangePct: (latest.MCap - baseline.MCap) / baseline.MCap * 100,
+        })
+    }
+
+    if fromTotal > 0 {
+        aggregates.MCapChangePct = (toTotal - fromTotal) / fromTotal * 100
+    }
+
+    sort.Slice(moves, func(i, j int) bool { return moves[i].ChangePct > moves[j].ChangePct })
+    for i := 0; i < len(moves) && i < topMovers && moves[i].ChangePct > 0; i++ {
+        aggregates.TopGainers = append(aggregates.TopGainers, moves[i])
+    }
+    for i := len(moves) - 1; i >= 0 && len(aggregates.TopLosers) < topMovers && moves[i].ChangePct < 0; i-- {
+        aggregates.TopLosers = append(aggregates.TopLosers, moves[i])
+    }
+    return aggregates, nil
+}
+
+// baselineSnapshot returns the last snapshot taken at or before start, or the
+// first one after it for agents tracked only during the period
+func baselineSnapshot(history []storage.MetricSnapshot, start time.Time) (storage.MetricSnapshot, bool) {
+    for i := len(history) - 1; i >= 0; i-- {
+        if !history[i].At.After(start) {
+            return history[i], true
+        }
+    }
+    if len(history) > 1 {
+        return history[0], true
+    }
+    return storage.MetricSnapshot{}, false
+}
+
+// formatAggregates renders the aggregates as the data section of the report prompt
+func formatAggregates(a models.ReportAggregates, start, end time.Time) string {
+    var b strings.Builder
+    fmt.Fprintf(&b, "Period: %s to %s\n", start.Format("2006-01-02"), end.Format("2006-01-02"))
+    fmt.Fprintf(&b, "Tracked agents: %d\nTotal market cap: $%.0f (%+.1f%% over the week)\n", a.TotalAgents, a.TotalMCap, a.MCapChangePct)
+    fmt.Fprintf(&b, "Total 24h volume: $%.0f\nTotal holders: %.0f\n", a.TotalVolume24h, a.TotalHolders)
+
+    fmt.Fprintf(&b, "\nNew agents (%d):\n", len(a.NewAgents))
+    for _, agent := range a.NewAgents {
+        fmt.Fprintf(&b, "- %s (%s)\n", agent.Name, agent.Price)
+    }
+    fmt.Fprintf(&b, "\nDead agents (%d):\n", len(a.DeadAgents))
+    for _, agent := range a.DeadAgents {
+        fmt.Fprintf(&b, "- %s\n", agent.Name)
+    }
+    b.WriteString("\nTop gainers by market cap:\n")
+    for _, move := range a.TopGainers {
+        fmt.Fprintf(&b, "- %s: $%.0f -> $%.0f (%+.1f%%)\n", move.Name, move.FromMCap, move.ToMCap, move.ChangePct)
+    }
+    b.WriteString("\nTop losers by market cap:\n")
+    for _, move := range a.TopLosers {
+        fmt.Fprintf(&b, "- %s: $%.0f -> $%.0f (%+.1f%%)\n", move.Name, move.FromMCap, move.ToMCap, move.ChangePct)
+    }
+    return b.String()
+}
@@ -0,0 +1,315 @@
+package risk
+
+import (
+    "context"
+    "fmt"
+    "log"
+    "math"
+    "regexp"
+    "strconv"
+    "strings"
+    "time"
+    "anondd/llm"
+    "anondd/utils/models"
+    "anondd/utils/onchain"
+    "anondd/utils/storage"
+)
+
+const (
+    // MaxAge is how long a risk score stays current before it is recomputed
+    MaxAge = 24 * time.Hour
+
+    // batchSize caps the agents scored per RescoreStale call, bounding LLM use per scrape
+    batchSize = 25
+
+    // unknownScore is used for a heuristic whose input data is missing
+    unknownScore = 50
+)
+
+// Factor weights; factors that can't be computed are left out and the rest renormalized
+const (
+    weightHolders   = 0.25
+    weightLiquidity = 0.25
+    weightParse     = 0.10
+    weightAge       = 0.15
+    weightLLM       = 0.25
+    weightCreator   = 0.15
+)
+
+var llmScorePattern = regexp.MustCompile(`(?i)score\s*[:=]\s*(\d{1,3})`)
+
+// Scorer computes agent risk scores from heuristics and an LLM assessment
+type Scorer struct {
+    store    *storage.AgentStore
+    client   *llm.OpenRouterClient
+    enricher *onchain.Enricher
+    logger   *log.Logger
+}
+
+// NewScorer creates a scorer. enricher may be nil, in which case holder
+// concentration falls back to the scraped holder count.
+func NewScorer(store *storage.AgentStore, client *llm.OpenRouterClient, enricher *onchain.Enricher, logger *log.Logger) *Scorer {
+    return &Scorer{
+        store:    store,
+        client:   client,
+        enricher: enricher,
+        logger:   logger,
+    }
+}
+
+// Score computes the agent's risk score without storing it
+func (s *Scorer) Score(ctx context.Context, agent *models.Agent) *models.RiskScore {
+    if agent.OnChain == nil && s.enricher.Enabled() && agent.ContractAddress != "" {
+        enriched := *agent
+        if err := s.enricher.Enrich(ctx, &enriched); err != nil {
+            s.logger.Printf("Error enriching agent %s for risk score: %v", agent.ID, err)
+        }
+        agent = &enriched
+    }
+
+    factors := []models.RiskFactor{
+        holderFactor(agent),
+        liquidityFactor(agent),
+        parseFactor(agent),
+        ageFactor(agent),
+    }
+    if factor, ok := s.creatorFactor(ctx, agent); ok {
+        factors = append(factors, factor)
+    }
+    risk := &models.RiskScore{ComputedAt: time.Now()}
+    if factor, assessment, err := s.llmFactor(ctx, agent); err != nil {
+        s.logger.Printf("Error getting LLM risk assessment for %s: %v", agent.ID, err)
+    } else {
+        factors = append(factors, factor)
+        risk.Assessment = assessment
+    }
+
+    var total, weights float64
+    for _, factor := range factors {
+        total += float64(factor.Score) * factor.Weight
+        weights += factor.Weight
+    }
+    risk.Factors = factors
+    risk.Score = int(math.Round(total / weights))
+    risk.Level = models.RiskLevel(risk.Score)
+    return risk
+}
+
+// ScoreAndSave computes and stores the agent's risk score
+func (s *Scorer) ScoreAndSave(ctx context.Context, agentID string) (*models.RiskScore, error) {
+    agent, err := s.store.GetAgentContext(ctx, agentID)
+    if err != nil {
+        return nil, err
+    }
+    risk := s.Score(ctx, agent)
+    if err := s.store.SetAgentRisk(ctx, agentID, risk); err != nil {
+        return nil, fmt.Errorf("failed to save risk score: %w", err)
+    }
+    return risk, nil
+}
+
+// RescoreStale scores agents that have no score or an outdated one, at most
+// batchSize per call so each scrape spreads the LLM cost
+func (s *Scorer) RescoreStale(ctx context.Context) {
+    index, err := s.store.GetIndexContext(ctx)
+    if err != nil {
+        s.logger.Printf("Error loading index for risk scoring: %v", err)
+        return
+    }
+
+    scored := 0
+    for _, summary := range index.Agents {
+        if scored >= batchSize || ctx.Err() != nil {
+            break
+        }
+        agent, err := s.store.GetAgentContext(ctx, summary.ID)
+        if err != nil {
+            continue
+        }
+        if agent.Risk != nil && time.Since(agent.Risk.ComputedAt) < MaxAge {
+            continue
+        }
+        if _, err := s.ScoreAndSave(ctx, agent.ID); err != nil {
+            s.logger.Printf("Error scoring agent %s: %v", agent.ID, err)
+            continue
+        }
+        scored++
+    }
+    if scored > 0 {
+        s.logger.Printf("Updated risk scores for %d agents", scored)
+    }
+}
+
+// holderFactor rates holder concentration: the top holders' share when
+// on-chain data is available, otherwise the holder count
+func holderFactor(agent *models.Agent) models.RiskFactor {
+    factor := models.RiskFactor{Name: "holder_concentration", Weight: weightHolders}
+    if agent.OnChain != nil && agent.OnChain.HoldersSampled > 0 {
+        share := agent.OnChain.TopHolderShare
+        factor.Score = clamp(int(math.Round(share * 100)))
+        factor.Detail = fmt.Sprintf("top 10 holders own %.1f%% of sampled supply", share*100)
+        return factor
+    }
+
+    holders, ok := models.ParseAmount(agent.TokenData.Holders)
+    switch {
+    case !ok:
+        factor.Score, factor.Detail = unknownScore, "holder data unavailable"
+    case holders < 100:
+        factor.Score = 90
+    case holders < 1000:
+        factor.Score = 60
+    case holders < 10000:
+        factor.Score = 30
+    default:
+        factor.Score = 10
+    }
+    if factor.Detail == "" {
+        factor.Detail = fmt.Sprintf("%.0f holders", holders)
+    }
+    return factor
+}
+
+// liquidityFactor rates liquidity (TVL) relative to market cap
+func liquidityFactor(agent *models.Agent) models.RiskFactor {
+    factor := models.RiskFactor{Name: "liquidity", Weight: weightLiquidity}
+    if agent.OnChain != nil && agent.OnChain.Pool == nil {
+        factor.Score, factor.Detail = 100, "no liquidity pool found on-chain"
+        return factor
+    }
+
+    tvl, tvlOK := models.ParseAmount(agent.TokenData.TVL)
+    mcap, mcapOK := models.ParseAmount(agent.TokenData.MCFDV)
+    if !tvlOK || !mcapOK || mcap <= 0 {
+        factor.Score, factor.Detail = unknownScore, "liquidity data unavailable"
+        return factor
+    }
+
+    ratio := tvl / mcap
+    switch {
+    case ratio < 0.01:
+        factor.Score = 90
+    case ratio < 0.05:
+        factor.Score = 60
+    case ratio < 0.15:
+        factor.Score = 30
+    default:
+        factor.Score = 10
+    }
+    factor.Detail = fmt.Sprintf("TVL is %.1f%% of market cap", ratio*100)
+    return factor
+}
+
+// parseFactor rates how much of the agent's data could be scraped; thin data is itself a risk
+func parseFactor(agent *models.Agent) models.RiskFactor {
+    factor := models.RiskFactor{Name: "data_quality", Weight: weightParse}
+    fields := []string{
+        agent.Description, agent.Price, agent.TokenData.MCFDV, agent.TokenData.TVL,
+        agent.TokenData.Holders, agent.TokenData.Volume24h, agent.InfluenceMetrics.Followers,
+    }
+    missing := 0
+    for _, field := range fields {
+        if strings.TrimSpace(field) == "" {
+            missing++
+        }
+    }
+
+    factor.Score = missing * 100 / len(fields)
+    if !agent.ParseSuccess {
+        factor.Score = max(factor.Score, 80)
+    }
+    factor.Detail = fmt.Sprintf("%d of %d key fields missing", missing, len(fields))
+    return factor
+}
+
+// ageFactor rates how long ago the agent launched
+func ageFactor(agent *models.Agent) models.RiskFactor {
+    factor := models.RiskFactor{Name: "age", Weight: weightAge}
+    launched := agent.LaunchDate()
+    if launched.IsZero() {
+        factor.Score, factor.Detail = unknownScore, "launch date unknown"
+        return factor
+    }
+
+    age := time.Since(launched)
+    switch {
+    case age < 7*24*time.Hour:
+        factor.Score = 80
+    case age < 30*24*time.Hour:
+        factor.Score = 50
+    case age < 90*24*time.Hour:
+        factor.Score = 30
+    default:
+        factor.Score = 10
+    }
+    factor.Detail = fmt.Sprintf("launched %d days ago", int(age.Hours()/24))
+    return factor
+}
+
+// creatorFactor rates the creator's track record by how many of their other
+// agents are dead. It is left out when the creator is unknown.
+func (s *Scorer) creatorFactor(ctx context.Context, agent *models.Agent) (models.RiskFactor, bool) {
+    factor := models.RiskFactor{Name: "creator", Weight: weightCreator}
+    creator, ok, err := s.store.CreatorOf(ctx, agent.Creator())
+    if err != nil {
+        s.logger.Printf("Error loading creator of %s: %v", agent.ID, err)
+        return factor, false
+    }
+    if !ok {
+        return factor, false
+    }
+
+    others := 0
+    for _, launched := range creator.Agents {
+        if launched.ID != agent.ID {
+            others++
+        }
+    }
+    if others == 0 {
+        factor.Score, factor.Detail = 30, "creator's first agent"
+        return factor, true
+    }
+
+    dead := len(creator.DeadAgents(agent.ID))
+    switch {
+    case dead >= models.SerialLauncherThreshold:
+        factor.Score = 90
+        factor.Detail = fmt.Sprintf("serial launcher, %d of the creator's other agents are dead", dead)
+    case dead > 0:
+        factor.Score = 50
+        factor.Detail = "1 of the creator's other agents is dead"
+    default:
+        factor.Score = 10
+        factor.Detail = fmt.Sprintf("none of the creator's %d other agents are dead", others)
+    }
+    return factor, true
+}
+
+// llmFactor asks the LLM for a qualitative risk rating of the agent
+func (s *Scorer) llmFactor(ctx context.Context, agent *models.Agent) (models.RiskFactor, string, error) {
+    query := fmt.Sprintf("Name: %s\nDescription: %s\nStats: %s\nMC (FDV): %s\nTVL: %s\nHolders: %s\n24h Volume: %s\nFollowers: %s",
+        agent.Name, agent.Description, agent.StatsText(), agent.TokenData.MCFDV, agent.TokenData.TVL,
+        agent.TokenData.Holders, agent.TokenData.Volume24h, agent.InfluenceMetrics.Followers)
+    response, err := s.client.GetResponse(ctx, "risk_assessment", query)
+    if err != nil {
+        return models.RiskFactor{}, "", err
+    }
+
+    match := llmScorePattern.FindStringSubmatch(response)
+    if match == nil {
+        return models.RiskFactor{}, "", fmt.Errorf("no score in LLM response: %q", response)
+    }
+    score, _ := strconv.Atoi(match[1])
+    assessment := strings.TrimSpace(llmScorePattern.ReplaceAllString(response, ""))
+
+    return models.RiskFactor{
+        Name:   "llm_assessment",
+        Score:  clamp(score),
+        Weight: weightLLM,
+        Detail: assessment,
+    }, assessment, nil
+}
+
+func clamp(score int) int {
+    return min(max(score, 0), 100)
+}
@@ -0,0 +1,309 @@
+// Package search is a lightweight full-text index over agent names,
+// descriptions and generated reports, ranked with BM25.
+package search
+
+import (
+    "errors"
+    "fmt"
+    "log"
+    "math"
+    "sort"
+    "strings"
+    "sync"
+    "time"
+    "unicode"
+    "anondd/utils/models"
+    "anondd/utils/rag"
+    "anondd/utils/storage"
+)
+
+// Document kinds
+const (
+    KindAgent  = "agent"
+    KindReport = "report"
+)
+
+// DefaultLimit is how many hits a search returns when no limit is given
+const DefaultLimit = 10
+
+// MaxLimit bounds how many hits one search returns
+const MaxLimit = 50
+
+// BM25 parameters, and the weight of a title match against a body match
+const (
+    bm25K1      = 1.2
+    bm25B       = 0.75
+    titleWeight = 3
+)
+
+// snippetLength is the approximate length of a hit's snippet, in runes
+const snippetLength = 200
+
+// Hit is a document matching a search, with a snippet around the matches
+type Hit struct {
+    Kind     string  `json:"kind"`
+    ID       string  `json:"id"`
+    Title    string  `json:"title"`
+    SourceID int     `json:"source_id,omitempty"`
+    Status   string  `json:"status,omitempty"` // Agent status, for hits on agents
+    Score    float64 `json:"score"`
+    Snippet  string  `json:"snippet"`
+}
+
+// Options narrow a search
+type Options struct {
+    Kind  string         // Only documents of this kind; empty searches all
+    Limit int            // Hits returned, DefaultLimit if 0
+    Allow func(Hit) bool // Hits it rejects are skipped, when set
+}
+
+// document is one indexed agent or report
+type document struct {
+    hit    Hit    // Search result fields, without score and snippet
+    body   string // Text the snippet is cut from
+    length int    // Weighted token count
+}
+
+// posting is a term's weighted frequency in one document
+type posting struct {
+    doc int
+    tf  int
+}
+
+// Index is a full-text index rebuilt from the store after scrapes and new
+// reports. Searches see the last complete build.
+type Index struct {
+    store  *storage.AgentStore
+    logger *log.Logger
+
+    mu       sync.RWMutex
+    docs     []document
+    postings map[string][]posting
+    avgLen   float64
+    builtAt  time.Time
+
+    buildMu sync.Mutex // Serializes rebuilds
+}
+
+// NewIndex creates an index over store; it is built on first search or Rebuild
+func NewIndex(store *storage.AgentStore, logger *log.Logger) *Index {
+    return &Index{store: store, logger: logger}
+}
+
+// Rebuild indexes the stored agents and reports from scratch
+func (x *Index) Rebuild() error {
+    x.buildMu.Lock()
+    defer x.buildMu.Unlock()
+    startedAt := time.Now()
+
+    // Before the first scrape there is no agent index, only reports
+    agentIndex, err := x.store.GetIndex()
+    if errors.Is(err, storage.ErrNotFound) {
+        agentIndex, err = &models.AgentIndex{}, nil
+    }
+    if err != nil {
+        return fmt.Errorf("failed to load index: %w", err)
+    }
+    var docs []document
+    for _, summary := range agentIndex.Agents {
+        agent, err := x.store.GetAgent(summary.ID)
+        if err != nil {
+            continue
+        }
+        docs = append(docs, document{
+            hit:  Hit{Kind: KindAgent, ID: agent.ID, Title: agent.Name, SourceID: agent.SourceID, Status: agent.Status},
+            body: agent.Description,
+        })
+    }
+
+    reports, err := x.store.Reports(models.ReportWeekly)
+    if err != nil {
+        return fmt.Errorf("failed to load reports: %w", err)
+    }
+    for _, report := range reports {
+        docs = append(docs, document{
+            hit: Hit{Kind: KindReport, ID: report.Kind + "/" + report.PeriodEnd.Format("2006-01-02"),
+                Title: fmt.Sprintf("%s report %s - %s", report.Kind,
+                    report.PeriodStart.Format("Jan 2"), report.PeriodEnd.Format("Jan 2, 2006"))},
+            body: report.Text,
+        })
+    }
+
+    postings := make(map[string][]posting)
+    total := 0
+    for i := range docs {
+        counts := make(map[string]int)
+        for _, term := range rag.Tokenize(docs[i].hit.Title) {
+            counts[term] += titleWeight
+            docs[i].length += titleWeight
+        }
+        for _, term := range rag.Tokenize(docs[i].body) {
+            counts[term]++
+            docs[i].length++
+        }
+        for term, tf := range counts {
+            postings[term] = append(postings[term], posting{doc: i, tf: tf})
+        }
+        total += docs[i].length
+    }
+
+    x.mu.Lock()
+    x.docs, x.postings, x.builtAt = docs, postings, time.Now()
+    x.avgLen = 0
+    if len(docs) > 0 {
+        x.avgLen = float64(total) / float64(len(docs))
+    }
+    x.mu.Unlock()
+
+    x.logger.Printf("Built search index over %d documents, %d terms in %s",
+        len(docs), len(postings), time.Since(startedAt).Round(time.Millisecond))
+    return nil
+}
+
+// Stats returns how many documents the index holds and when it was built
+func (x *Index) Stats() (int, time.Time) {
+    x.mu.RLock()
+    defer x.mu.RUnlock()
+    return len(x.docs), x.builtAt
+}
+
+// Search returns the documents best matching query, highest score first.
+// Every document matching at least one query term is a candidate.
+func (x *Index) Search(query string, opts Options) ([]Hit, error) {
+    if _, builtAt := x.Stats(); builtAt.IsZero() {
+        if err := x.Rebuild(); err != nil {
+            return nil, err
+        }
+    }
+    terms := uniqueTerms(rag.Tokenize(query))
+    if len(terms) == 0 {
+        return nil, nil
+    }
+    limit := opts.Limit
+    if limit <= 0 {
+        limit = DefaultLimit
+    }
+
+    x.mu.RLock()
+    defer x.mu.RUnlock()
+
+    scores := make(map[int]float64)
+    for _, term := range terms {
+        list := x.postings[term]
+        if len(list) == 0 {
+            continue
+        }
+        idf := math.Log(1 + (float64(len(x.docs))-float64(len(list))+0.5)/(float64(len(list))+0.5))
+        for _, p := range list {
+            tf := float64(p.tf)
+            norm := bm25K1 * (1 - bm25B + bm25B*float64(x.docs[p.doc].length)/x.avgLen)
+            scores[p.doc] += idf * tf * (bm25K1 + 1) / (tf + norm)
+        }
+    }
+
+    ranked := make([]int, 0, len(scores))
+    for doc := range scores {
+        ranked = append(ranked, doc)
+    }
+    sort.Slice(ranked, func(i, j int) bool {
+        if scores[ranked[i]] != scores[ranked[j]] {
+            return scores[ranked[i]] > scores[ranked[j]]
+        }
+        return x.docs[ranked[i]].hit.Title < x.docs[ranked[j]].hit.Title
+    })
+
+    var hits []Hit
+    for _, doc := range ranked {
+        hit := x.docs[doc].hit
+        if opts.Kind != "" && hit.Kind != opts.Kind {
+            continue
+        }
+        if opts.Allow != nil && !opts.Allow(hit) {
+            continue
+        }
+        hit.Score = math.Round(scores[doc]*1000) / 1000
+        hit.Snippet = snippet(x.docs[doc].body, terms)
+        hits = append(hits, hit)
+        if len(hits) == limit {
+            break
+        }
+    }
+    return hits, nil
+}
+
+func uniqueTerms(terms []string) []string {
+    seen := make(map[string]bool, len(terms))
+    var unique []string
+    for _, term := range terms {
+        if !seen[term] {
+            seen[term] = true
+            unique = append(unique, term)
+        }
+    }
+    return unique
+}
+
+// snippet cuts the stretch of text around the densest cluster of query
+// terms, falling back to its start
+func snippet(text string, terms []string) string {
+    text = strings.Join(strings.Fields(text), " ")
+    runes := []rune(text)
+    if len(runes) <= snippetLength {
+        return text
+    }
+    // Lowercased rune by rune so positions line up with runes
+    lower := make([]rune, len(runes))
+    for i, r := range runes {
+        lower[i] = unicode.ToLower(r)
+    }
+
+    // Candidate starts are term occurrences; a window is scored by the
+    // distinct terms it contains
+    best, bestCount := 0, 0
+    lowerText := string(lower)
+    for _, term := range terms {
+        offset := 0
+        for found := 0; found < 20; found++ {
+            at := strings.Index(lowerText[offset:], term)
+            if at < 0 {
+                break
+            }
+            pos := len([]rune(lowerText[:offset+at]))
+            offset += at + len(term)
+
+            start := max(pos-snippetLength/4, 0)
+            end := min(start+snippetLength, len(lower))
+            window := string(lower[start:end])
+            count := 0
+            for _, other := range terms {
+                if strings.Contains(window, other) {
+                    count++
+                }
+            }
+            if count > bestCount {
+                best, bestCount = start, count
+            }
+        }
+    }
+
+    start := best
+    // Start and end on word boundaries
+    for start > 0 && start < len(runes) && runes[start-1] != ' ' {
+        start--
+    }
+    end := min(start+snippetLength, len(runes))
+    for end < len(runes) && end > start && runes[end-1] != ' ' {
+        end--
+    }
+    if end <= start {
+        end = min(start+snippetLength, len(runes))
+    }
+    result := strings.TrimSpace(string(runes[start:end]))
+    if start > 0 {
+        result = "…" + result
+    }
+    if end < len(runes) {
+        result += "…"
+    }
+    return result
+}
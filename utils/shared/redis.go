@@ -0,0 +1,299 @@
+package shared
+
+import (
+    "bufio"
+    "context"
+    "errors"
+    "fmt"
+    "io"
+    "net"
+    "net/url"
+    "strconv"
+    "strings"
+    "sync"
+    "time"
+)
+
+const (
+    redisDialTimeout  = 5 * time.Second
+    redisMaxIdleConns = 8
+    // redisResubscribeDelay is how long Subscribe waits before reconnecting
+    redisResubscribeDelay = 2 * time.Second
+)
+
+// redisIncrScript increments a counter and sets its expiry in one atomic
+// step. Keys left without a TTL, e.g. by a crash of an older version between
+// INCR and PEXPIRE, get one on their next increment.
+const redisIncrScript = `local count = redis.call('INCR', KEYS[1])
+if tonumber(ARGV[1]) > 0 and redis.call('PTTL', KEYS[1]) < 0 then
+    redis.call('PEXPIRE', KEYS[1], ARGV[1])
+end
+return count`
+
+// RedisStore is a Store backed by a Redis server, speaking RESP directly
+type RedisStore struct {
+    addr     string
+    password string
+    db       int
+    mu       sync.Mutex
+    idle     []*redisConn
+}
+
+// NewRedisStore parses a redis://[:password@]host:port[/db] URL. Connections
+// are opened lazily.
+func NewRedisStore(rawURL string) (*RedisStore, error) {
+    u, err := url.Parse(rawURL)
+    if err != nil {
+        return nil, fmt.Errorf("invalid redis URL: %w", err)
+    }
+    store := &RedisStore{addr: u.Host}
+    if !strings.Contains(store.addr, ":") {
+        store.addr += ":6379"
+    }
+    if u.User != nil {
+        store.password, _ = u.User.Password()
+    }
+    if db := strings.TrimPrefix(u.Path, "/"); db != "" {
+        if store.db, err = strconv.Atoi(db); err != nil {
+            return nil, fmt.Errorf("invalid redis database %q", db)
+        }
+    }
+    return store, nil
+}
+
+// Ping checks that the server is reachable
+func (r *RedisStore) Ping(ctx context.Context) error {
+    _, err := r.do(ctx, "PING")
+    return err
+}
+
+func (r *RedisStore) Get(ctx context.Context, key string) (string, bool, error) {
+    reply, err := r.do(ctx, "GET", key)
+    if err != nil {
+        return "", false, err
+    }
+    if reply == nil {
+        return "", false, nil
+    }
+    value, ok := reply.(string)
+    if !ok {
+        return "", false, fmt.Errorf("unexpected redis reply to GET: %T", reply)
+    }
+    return value, true, nil
+}
+
+func (r *RedisStore) Set(ctx context.Context, key, value string, ttl time.Duration) error {
+    args := []string{"SET", key, value}
+    if ttl > 0 {
+        args = append(args, "PX", strconv.FormatInt(ttl.Milliseconds(), 10))
+    }
+    _, err := r.do(ctx, args...)
+    return err
+}
+
+func (r *RedisStore) Incr(ctx context.Context, key string, ttl time.Duration) (int64, error) {
+    reply, err := r.do(ctx, "EVAL", redisIncrScript, "1", key, strconv.FormatInt(max(ttl.Milliseconds(), 0), 10))
+    if err != nil {
+        return 0, err
+    }
+    count, ok := reply.(int64)
+    if !ok {
+        return 0, fmt.Errorf("unexpected redis reply to INCR: %T", reply)
+    }
+    return count, nil
+}
+
+func (r *RedisStore) Publish(ctx context.Context, channel, message string) error {
+    _, err := r.do(ctx, "PUBLISH", channel, message)
+    return err
+}
+
+// Subscribe holds a dedicated connection for the channel, reconnecting after
+// errors until ctx is cancelled
+func (r *RedisStore) Subscribe(ctx context.Context, channel string, handler func(message string)) error {
+    for {
+        // Messages published while reconnecting are lost; subscribers only
+        // use them to drop cached data, which also expires on its own
+        r.subscribeOnce(ctx, channel, handler)
+        select {
+        case <-time.After(redisResubscribeDelay):
+        case <-ctx.Done():
+            return nil
+        }
+    }
+}
+
+func (r *RedisStore) subscribeOnce(ctx context.Context, channel string, handler func(message string)) error {
+    conn, err := r.dial(ctx)
+    if err != nil {
+        return err
+    }
+    defer conn.Close()
+
+    // Unblock the read when ctx is cancelled
+    stop := context.AfterFunc(ctx, func() { conn.Close() })
+    defer stop()
+
+    if err := conn.write("SUBSCRIBE", channel); err != nil {
+        return err
+    }
+    for {
+        reply, err := conn.read()
+        if err != nil {
+            return err
+        }
+        parts, ok := reply.([]interface{})
+        if !ok || len(parts) != 3 {
+            continue
+        }
+        if kind, _ := parts[0].(string); kind == "message" {
+            if message, ok := parts[2].(string); ok {
+                handler(message)
+            }
+        }
+    }
+}
+
+// do runs one command on a pooled connection
+func (r *RedisStore) do(ctx context.Context, args ...string) (interface{}, error) {
+    conn, err := r.get(ctx)
+    if err != nil {
+        return nil, err
+    }
+    if deadline, ok := ctx.Deadline(); ok {
+        conn.SetDeadline(deadline)
+    } else {
+        conn.SetDeadline(time.Time{})
+    }
+
+    if err := conn.write(args...); err != nil {
+        conn.Close()
+        return nil, err
+    }
+    reply, err := conn.read()
+    var serverErr redisError
+    if err != nil && !errors.As(err, &serverErr) {
+        conn.Close()
+        return nil, err
+    }
+    r.put(conn)
+    return reply, err
+}
+
+func (r *RedisStore) get(ctx context.Context) (*redisConn, error) {
+    r.mu.Lock()
+    if n := len(r.idle); n > 0 {
+        conn := r.idle[n-1]
+        r.idle = r.idle[:n-1]
+        r.mu.Unlock()
+        return conn, nil
+    }
+    r.mu.Unlock()
+    return r.dial(ctx)
+}
+
+func (r *RedisStore) put(conn *redisConn) {
+    r.mu.Lock()
+    defer r.mu.Unlock()
+    if len(r.idle) >= redisMaxIdleConns {
+        conn.Close()
+        return
+    }
+    r.idle = append(r.idle, conn)
+}
+
+// dial opens a connection, authenticating and selecting the database
+func (r *RedisStore) dial(ctx context.Context) (*redisConn, error) {
+    dialer := net.Dialer{Timeout: redisDialTimeout}
+    netConn, err := dialer.DialContext(ctx, "tcp", r.addr)
+    if err != nil {
+        return nil, fmt.Errorf("failed to connect to redis: %w", err)
+    }
+    conn := &redisConn{Conn: netConn, reader: bufio.NewReader(netConn)}
+
+    if r.password != "" {
+        if err := conn.write("AUTH", r.password); err == nil {
+            _, err = conn.read()
+        }
+        if err != nil {
+            conn.Close()
+            return nil, fmt.Errorf("redis auth failed: %w", err)
+        }
+    }
+    if r.db != 0 {
+        if err := conn.write("SELECT", strconv.Itoa(r.db)); err == nil {
+            _, err = conn.read()
+        }
+        if err != nil {
+            conn.Close()
+            return nil, fmt.Errorf("redis select failed: %w", err)
+        }
+    }
+    return conn, nil
+}
+
+// redisError is an error reply from the server; the connection stays usable
+type redisError string
+
+func (e redisError) Error() string { return "redis: " + string(e) }
+
+type redisConn struct {
+    net.Conn
+    reader *bufio.Reader
+}
+
+// write sends a command as a RESP array of bulk strings
+func (c *redisConn) write(args ...string) error {
+    var b strings.Builder
+    fmt.Fprintf(&b, "*%d\r\n", len(args))
+    for _, arg := range args {
+        fmt.Fprintf(&b, "$%d\r\n%s\r\n", len(arg), arg)
+    }
+    _, err := io.WriteString(c.Conn, b.String())
+    return err
+}
+
+// read parses one RESP reply: strings, int64s, nil, or []interface{} for arrays
+func (c *redisConn) read() (interface{}, error) {
+    line, err := c.reader.ReadString('\n')
+    if err != nil {
+        return nil, err
+    }
+    line = strings.TrimSuffix(line, "\r\n")
+    if line == "" {
+        return nil, fmt.Errorf("empty redis reply")
+    }
+
+    switch line[0] {
+    case '+':
+        return line[1:], nil
+    case '-':
+        return nil, redisError(line[1:])
+    case ':':
+        return strconv.ParseInt(line[1:], 10, 64)
+    case '$':
+        size, err := strconv.Atoi(line[1:])
+        if err != nil || size < 0 {
+            return nil, err
+        }
+        data := make([]byte, size+2)
+        if _, err := io.ReadFull(c.reader, data); err != nil {
+            return nil, err
+        }
+        return string(data[:size]), nil
+    case '*':
+        count, err := strconv.Atoi(line[1:])
+        if err != nil || count < 0 {
+            return nil, err
+        }
+        items := make([]interface{}, count)
+        for i := range items {
+            if items[i], err = c.read(); err != nil {
+                return nil, err
+            }
+        }
+        return items, nil
+    default:
+        return nil, fmt.Errorf("unexpected redis reply %q", line)
+    }
+}
@@ -0,0 +1,156 @@
+package shared
+
+import (
+    "context"
+    "fmt"
+    "strings"
+    "sync"
+    "time"
+)
+
+// Store is key/value and pub/sub state that multiple instances of the app can
+// share. The in-memory store serves a single instance; Redis serves several.
+type Store interface {
+    // Get returns the value for key and whether it exists
+    Get(ctx context.Context, key string) (string, bool, error)
+    // Set stores value under key, expiring after ttl (0 keeps it forever)
+    Set(ctx context.Context, key, value string, ttl time.Duration) error
+    // Incr increments the counter at key, starting its ttl on the first increment
+    Incr(ctx context.Context, key string, ttl time.Duration) (int64, error)
+    // Publish sends message to every subscriber of channel
+    Publish(ctx context.Context, channel, message string) error
+    // Subscribe calls handler for each message on channel until ctx is cancelled
+    Subscribe(ctx context.Context, channel string, handler func(message string)) error
+}
+
+// Open returns a Redis store for a redis:// URL, or an in-memory store for ""
+func Open(url string) (Store, error) {
+    if url == "" {
+        return NewMemoryStore(), nil
+    }
+    if !strings.HasPrefix(url, "redis://") {
+        return nil, fmt.Errorf("unsupported shared store URL %q, use redis://", url)
+    }
+    return NewRedisStore(url)
+}
+
+type memoryEntry struct {
+    value     string
+    expiresAt time.Time
+}
+
+func (e memoryEntry) expired(now time.Time) bool {
+    return !e.expiresAt.IsZero() && now.After(e.expiresAt)
+}
+
+// MemoryStore is a Store local to this process
+type MemoryStore struct {
+    mu          sync.Mutex
+    entries     map[string]memoryEntry
+    subscribers map[string][]*subscription
+}
+
+type subscription struct {
+    handler func(string)
+}
+
+// NewMemoryStore creates an empty in-memory store
+func NewMemoryStore() *MemoryStore {
+    return &MemoryStore{
+        entries:     make(map[string]memoryEntry),
+        subscribers: make(map[string][]*subscription),
+    }
+}
+
+func (m *MemoryStore) Get(ctx context.Context, key string) (string, bool, error) {
+    m.mu.Lock()
+    defer m.mu.Unlock()
+
+    entry, exists := m.entries[key]
+    if !exists || entry.expired(time.Now()) {
+        delete(m.entries, key)
+        return "", false, nil
+    }
+    return entry.value, true, nil
+}
+
+func (m *MemoryStore) Set(ctx context.Context, key, value string, ttl time.Duration) error {
+    m.mu.Lock()
+    defer m.mu.Unlock()
+
+    entry := memoryEntry{value: value}
+    if ttl > 0 {
+        entry.expiresAt = time.Now().Add(ttl)
+    }
+    m.entries[key] = entry
+    m.sweep()
+    return nil
+}
+
+func (m *MemoryStore) Incr(ctx context.Context, key string, ttl time.Duration) (int64, error) {
+    m.mu.Lock()
+    defer m.mu.Unlock()
+
+    now := time.Now()
+    entry, exists := m.entries[key]
+    if !exists || entry.expired(now) {
+        entry = memoryEntry{value: "0"}
+        if ttl > 0 {
+            entry.expiresAt = now.Add(ttl)
+        }
+    }
+    var count int64
+    fmt.Sscan(entry.value, &count)
+    count++
+    entry.value = fmt.Sprint(count)
+    m.entries[key] = entry
+    m.sweep()
+    return count, nil
+}
+
+func (m *MemoryStore) Publish(ctx context.Context, channel, message string) error {
+    m.mu.Lock()
+    subs := append([]*subscription{}, m.subscribers[channel]...)
+    m.mu.Unlock()
+
+    for _, sub := range subs {
+        sub.handler(message)
+    }
+    return nil
+}
+
+func (m *MemoryStore) Subscribe(ctx context.Context, channel string, handler func(message string)) error {
+    sub := &subscription{handler: handler}
+    m.mu.Lock()
+    m.subscribers[channel] = append(m.subscribers[channel], sub)
+    m.mu.Unlock()
+
+    <-ctx.Done()
+
+    m.mu.Lock()
+    defer m.mu.Unlock()
+    subs := m.subscribers[channel]
+    for i, existing := range subs {
+        if existing == sub {
+            m.subscribers[channel] = append(subs[:i:i], subs[i+1:]...)
+            break
+        }
+    }
+    return nil
+}
+
+// sweepThreshold is the entry count above which Set and Incr drop expired keys
+const sweepThreshold = 10000
+
+// sweep drops expired entries once the map grows large; callers must hold mu
+func (m *MemoryStore) sweep() {
+    if len(m.entries) < sweepThreshold {
+        return
+    }
+    now := time.Now()
+    for key, entry := range m.entries {
+        if entry.expired(now) {
+            delete(m.entries, key)
+        }
+    }
+}
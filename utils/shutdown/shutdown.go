@@ -0,0 +1,151 @@
+// Package shutdown runs the process's shutdown in ordered stages, so work in
+// flight finishes before what it depends on goes away: intake stops first,
+// then workers drain, then stores flush, and the HTTP server closes last.
+package shutdown
+
+import (
+    "context"
+    "fmt"
+    "log"
+    "sync"
+    "time"
+)
+
+// Stage is one step of the shutdown. Stages run in the order declared here;
+// the hooks within a stage run concurrently.
+type Stage int
+
+const (
+    StopIntake Stage = iota // Stop schedulers and receiving updates
+    Drain                   // Wait for handlers, scrapes and queued sends to finish
+    Flush                   // Sync stores and exporters to disk
+    CloseHTTP               // Stop the API server
+    numStages
+)
+
+// DefaultTimeouts bound each stage; hooks still running when their stage
+// times out are abandoned and the shutdown moves on
+var DefaultTimeouts = [numStages]time.Duration{
+    StopIntake: 5 * time.Second,
+    Drain:      45 * time.Second,
+    Flush:      10 * time.Second,
+    CloseHTTP:  10 * time.Second,
+}
+
+func (s Stage) String() string {
+    switch s {
+    case StopIntake:
+        return "stop intake"
+    case Drain:
+        return "drain"
+    case Flush:
+        return "flush"
+    case CloseHTTP:
+        return "close HTTP"
+    }
+    return fmt.Sprintf("stage %d", int(s))
+}
+
+// Hook is one component's part in a stage. It should return once its work is
+// done or ctx, which carries the stage's timeout, is done.
+type Hook func(ctx context.Context) error
+
+type namedHook struct {
+    name string
+    run  Hook
+}
+
+// Manager collects the shutdown hooks of the running components and runs
+// them once, stage by stage
+type Manager struct {
+    mu       sync.Mutex
+    hooks    [numStages][]namedHook
+    timeouts [numStages]time.Duration
+    once     sync.Once
+    done     chan struct{}
+    logger   *log.Logger
+}
+
+// New creates a manager with the default stage timeouts
+func New(logger *log.Logger) *Manager {
+    return &Manager{timeouts: DefaultTimeouts, done: make(chan struct{}), logger: logger}
+}
+
+// SetTimeout changes how long stage may take
+func (m *Manager) SetTimeout(stage Stage, timeout time.Duration) {
+    m.mu.Lock()
+    defer m.mu.Unlock()
+    m.timeouts[stage] = timeout
+}
+
+// Add registers hook under name to run in stage
+func (m *Manager) Add(stage Stage, name string, hook Hook) {
+    m.mu.Lock()
+    defer m.mu.Unlock()
+    m.hooks[stage] = append(m.hooks[stage], namedHook{name: name, run: hook})
+}
+
+// Shutdown runs every stage in order and returns when the last one ends.
+// Later calls wait for the first to finish.
+func (m *Manager) Shutdown() {
+    m.once.Do(func() {
+        defer close(m.done)
+        startedAt := time.Now()
+        m.logger.Println("[SHUTDOWN] Starting")
+        for stage := Stage(0); stage < numStages; stage++ {
+            m.runStage(stage)
+        }
+        m.logger.Printf("[SHUTDOWN] Finished in %s", time.Since(startedAt).Round(time.Millisecond))
+    })
+    <-m.done
+}
+
+// Done is closed once a shutdown has finished
+func (m *Manager) Done() <-chan struct{} {
+    return m.done
+}
+
+func (m *Manager) runStage(stage Stage) {
+    m.mu.Lock()
+    hooks := append([]namedHook(nil), m.hooks[stage]...)
+    timeout := m.timeouts[stage]
+    m.mu.Unlock()
+    if len(hooks) == 0 {
+        return
+    }
+
+    startedAt := time.Now()
+    ctx, cancel := context.WithTimeout(context.Background(), timeout)
+    defer cancel()
+
+    var wg sync.WaitGroup
+    for _, hook := range hooks {
+        wg.Add(1)
+        go func(hook namedHook) {
+            defer wg.Done()
+            hookStart := time.Now()
+            finished := make(chan error, 1)
+            go func() {
+                defer func() {
+                    if r := recover(); r != nil {
+                        finished <- fmt.Errorf("panic: %v", r)
+                    }
+                }()
+                finished <- hook.run(ctx)
+            }()
+            select {
+            case err := <-finished:
+                if err != nil {
+                    m.logger.Printf("[SHUTDOWN] %s: %s failed after %s: %v", stage, hook.name,
+                        time.Since(hookStart).Round(time.Millisecond), err)
+                    return
+                }
+                m.logger.Printf("[SHUTDOWN] %s: %s done in %s", stage, hook.name, time.Since(hookStart).Round(time.Millisecond))
+            case <-ctx.Done():
+                m.logger.Printf("[SHUTDOWN] %s: gave up on %s after %s", stage, hook.name, timeout)
+            }
+        }(hook)
+    }
+    wg.Wait()
+    m.logger.Printf("[SHUTDOWN] Stage %s finished in %s", stage, time.Since(startedAt).Round(time.Millisecond))
+}
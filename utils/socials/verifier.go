@@ -0,0 +1,203 @@
+package socials
+
+import (
+    "context"
+    "encoding/json"
+    "fmt"
+    "io"
+    "log"
+    "net/http"
+    "net/url"
+    "regexp"
+    "strings"
+    "time"
+    "anondd/utils/httpclient"
+    "anondd/utils/models"
+    "anondd/utils/storage"
+)
+
+const (
+    // MaxAge is how long a link check stays current before it is repeated
+    MaxAge = 24 * time.Hour
+
+    // batchSize caps the agents checked per VerifyStale call
+    batchSize = 25
+
+    // maxPageBytes bounds how much of a checked page is read
+    maxPageBytes = 512 << 10
+
+    requestTimeout = 15 * time.Second
+)
+
+// twitterOEmbedURL resolves a tweet or profile URL, returning 404 for
+// accounts that don't exist, without needing an API key
+const twitterOEmbedURL = "https://publish.twitter.com/oembed?omit_script=true&url="
+
+// telegramResolvePattern finds the handle a t.me page actually resolves to
+var telegramResolvePattern = regexp.MustCompile(`tg://resolve\?domain=([A-Za-z0-9_]+)`)
+
+// Verifier checks that an agent's social links resolve to the handles listed
+type Verifier struct {
+    store  *storage.AgentStore
+    client *http.Client
+    logger *log.Logger
+}
+
+// NewVerifier creates a verifier that stores results on the agents
+func NewVerifier(store *storage.AgentStore, logger *log.Logger) *Verifier {
+    config := httpclient.DefaultConfig()
+    config.Timeout = requestTimeout
+    config.UserAgent = httpclient.BrowserUserAgent
+    return &Verifier{
+        store:  store,
+        client: httpclient.New(config),
+        logger: logger,
+    }
+}
+
+// Check verifies one link: it must return HTTP 200 and resolve to the same
+// handle (or site, for websites) that was linked
+func (v *Verifier) Check(ctx context.Context, link models.SocialLink) models.SocialLink {
+    var status, detail string
+    switch link.Kind {
+    case models.SocialTwitter:
+        status, detail = v.checkTwitter(ctx, link)
+    case models.SocialTelegram:
+        status, detail = v.checkTelegram(ctx, link)
+    default:
+        status, detail = v.checkWebsite(ctx, link)
+    }
+    link.Status, link.Detail, link.CheckedAt = status, detail, time.Now()
+    return link
+}
+
+func (v *Verifier) checkTwitter(ctx context.Context, link models.SocialLink) (string, string) {
+    resp, body, err := v.get(ctx, twitterOEmbedURL+url.QueryEscape("https://twitter.com/"+link.Handle))
+    if err != nil {
+        return models.SocialDead, err.Error()
+    }
+    if resp.StatusCode != http.StatusOK {
+        return models.SocialDead, fmt.Sprintf("account lookup returned HTTP %d", resp.StatusCode)
+    }
+
+    var embed struct {
+        AuthorURL string `json:"author_url"`
+    }
+    if err := json.Unmarshal(body, &embed); err != nil || embed.AuthorURL == "" {
+        return models.SocialOK, ""
+    }
+    resolved := strings.Trim(strings.TrimPrefix(embed.AuthorURL, "https://twitter.com"), "/")
+    if !strings.EqualFold(resolved, link.Handle) {
+        return models.SocialMismatch, fmt.Sprintf("resolves to @%s", resolved)
+    }
+    return models.SocialOK, ""
+}
+
+func (v *Verifier) checkTelegram(ctx context.Context, link models.SocialLink) (string, string) {
+    resp, body, err := v.get(ctx, link.URL)
+    if err != nil {
+        return models.SocialDead, err.Error()
+    }
+    if resp.StatusCode != http.StatusOK {
+        return models.SocialDead, fmt.Sprintf("HTTP %d", resp.StatusCode)
+    }
+    // t.me answers 200 for every name; only existing chats have a title
+    if !strings.Contains(string(body), "tgme_page_title") {
+        return models.SocialDead, "no such channel or group"
+    }
+    if match := telegramResolvePattern.FindSubmatch(body); match != nil && link.Handle != "" && !strings.EqualFold(string(match[1]), link.Handle) {
+        return models.SocialMismatch, fmt.Sprintf("resolves to @%s", match[1])
+    }
+    return models.SocialOK, ""
+}
+
+func (v *Verifier) checkWebsite(ctx context.Context, link models.SocialLink) (string, string) {
+    resp, _, err := v.get(ctx, link.URL)
+    if err != nil {
+        return models.SocialDead, err.Error()
+    }
+    if resp.StatusCode != http.StatusOK {
+        return models.SocialDead, fmt.Sprintf("HTTP %d", resp.StatusCode)
+    }
+    // Redirects to another site (parked or sold domains) count as a mismatch
+    final := strings.TrimPrefix(strings.ToLower(resp.Request.URL.Hostname()), "www.")
+    if final != link.Handle && !strings.HasSuffix(final, "."+link.Handle) {
+        return models.SocialMismatch, "redirects to " + final
+    }
+    return models.SocialOK, ""
+}
+
+// get fetches a URL, following redirects, and reads a bounded body
+func (v *Verifier) get(ctx context.Context, rawURL string) (*http.Response, []byte, error) {
+    req, err := http.NewRequestWithContext(ctx, http.MethodGet, rawURL, nil)
+    if err != nil {
+        return nil, nil, err
+    }
+    resp, err := v.client.Do(req)
+    if err != nil {
+        return nil, nil, err
+    }
+    defer resp.Body.Close()
+    body, err := io.ReadAll(io.LimitReader(resp.Body, maxPageBytes))
+    if err != nil {
+        return nil, nil, err
+    }
+    return resp, body, nil
+}
+
+// Verify checks every social link on the agent and stores the results
+func (v *Verifier) Verify(ctx context.Context, agent *models.Agent) ([]models.SocialLink, error) {
+    checked := make([]models.SocialLink, 0, len(agent.Socials))
+    for _, link := range agent.Socials {
+        checked = append(checked, v.Check(ctx, link))
+    }
+    if err := v.store.SetAgentSocials(ctx, agent.ID, checked); err != nil {
+        return nil, fmt.Errorf("failed to save social checks: %w", err)
+    }
+    return checked, nil
+}
+
+// VerifyStale checks agents with unverified or outdated social links, at most
+// batchSize per call so each scrape spreads the requests
+func (v *Verifier) VerifyStale(ctx context.Context) {
+    index, err := v.store.GetIndexContext(ctx)
+    if err != nil {
+        v.logger.Printf("Error loading index for social checks: %v", err)
+        return
+    }
+
+    verified, flagged := 0, 0
+    for _, summary := range index.Agents {
+        if verified >= batchSize || ctx.Err() != nil {
+            break
+        }
+        agent, err := v.store.GetAgentContext(ctx, summary.ID)
+        if err != nil || !stale(agent.Socials) {
+            continue
+        }
+        checked, err := v.Verify(ctx, agent)
+        if err != nil {
+            v.logger.Printf("Error checking socials for agent %s: %v", agent.ID, err)
+            continue
+        }
+        for _, link := range checked {
+            if link.Flagged() {
+                flagged++
+            }
+        }
+        verified++
+    }
+    if verified > 0 {
+        v.logger.Printf("Checked social links for %d agents, %d flagged", verified, flagged)
+    }
+}
+
+// stale reports whether any link is unverified or was checked too long ago
+func stale(links []models.SocialLink) bool {
+    for _, link := range links {
+        if link.Status == "" || time.Since(link.CheckedAt) >= MaxAge {
+            return true
+        }
+    }
+    return false
+}
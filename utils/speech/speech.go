@@ -0,0 +1,149 @@
+package speech
+
+import (
+    "bytes"
+    "context"
+    "encoding/json"
+    "fmt"
+    "io"
+    "mime/multipart"
+    "net/http"
+    "os"
+    "strings"
+    "time"
+    "anondd/utils/httpclient"
+)
+
+// Config selects an OpenAI-compatible speech provider
+type Config struct {
+    BaseURL  string
+    APIKey   string
+    STTModel string
+    TTSModel string
+    TTSVoice string
+    // TTSEnabled makes the bot answer voice notes with audio as well as text
+    TTSEnabled bool
+}
+
+// ConfigFromEnv reads STT_API_URL, STT_API_KEY, STT_MODEL, TTS_MODEL, TTS_VOICE
+// and TTS_ENABLED. Speech is disabled when no API key is set.
+func ConfigFromEnv() Config {
+    config := Config{
+        BaseURL:    os.Getenv("STT_API_URL"),
+        APIKey:     os.Getenv("STT_API_KEY"),
+        STTModel:   os.Getenv("STT_MODEL"),
+        TTSModel:   os.Getenv("TTS_MODEL"),
+        TTSVoice:   os.Getenv("TTS_VOICE"),
+        TTSEnabled: os.Getenv("TTS_ENABLED") == "true",
+    }
+    if config.BaseURL == "" {
+        config.BaseURL = "https://api.openai.com/v1"
+    }
+    if config.STTModel == "" {
+        config.STTModel = "whisper-1"
+    }
+    if config.TTSModel == "" {
+        config.TTSModel = "tts-1"
+    }
+    if config.TTSVoice == "" {
+        config.TTSVoice = "alloy"
+    }
+    return config
+}
+
+// Client transcribes and synthesizes speech through a Whisper-compatible API
+type Client struct {
+    config     Config
+    httpClient *http.Client
+}
+
+// NewClient creates a speech client
+func NewClient(config Config) *Client {
+    return &Client{
+        config:     config,
+        httpClient: httpclient.WithTimeout(60 * time.Second),
+    }
+}
+
+// TTSEnabled reports whether replies to voice notes should include audio
+func (c *Client) TTSEnabled() bool {
+    return c != nil && c.config.TTSEnabled
+}
+
+// Transcribe converts an audio file (e.g. a Telegram OGG voice note) to text
+func (c *Client) Transcribe(ctx context.Context, filename string, audio io.Reader) (string, error) {
+    var body bytes.Buffer
+    form := multipart.NewWriter(&body)
+    if err := form.WriteField("model", c.config.STTModel); err != nil {
+        return "", fmt.Errorf("failed to build transcription request: %w", err)
+    }
+    part, err := form.CreateFormFile("file", filename)
+    if err != nil {
+        return "", fmt.Errorf("failed to build transcription request: %w", err)
+    }
+    if _, err := io.Copy(part, audio); err != nil {
+        return "", fmt.Errorf("failed to read audio: %w", err)
+    }
+    if err := form.Close(); err != nil {
+        return "", fmt.Errorf("failed to build transcription request: %w", err)
+    }
+
+    resp, err := c.post(ctx, "/audio/transcriptions", form.FormDataContentType(), &body)
+    if err != nil {
+        return "", err
+    }
+    defer resp.Body.Close()
+
+    var result struct {
+        Text string `json:"text"`
+    }
+    if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+        return "", fmt.Errorf("failed to decode transcription: %w", err)
+    }
+    return strings.TrimSpace(result.Text), nil
+}
+
+// Synthesize renders text as OGG/Opus audio suitable for a Telegram voice message
+func (c *Client) Synthesize(ctx context.Context, text string) ([]byte, error) {
+    payload, err := json.Marshal(map[string]string{
+        "model":           c.config.TTSModel,
+        "voice":           c.config.TTSVoice,
+        "input":           text,
+        "response_format": "opus",
+    })
+    if err != nil {
+        return nil, fmt.Errorf("failed to encode speech request: %w", err)
+    }
+
+    resp, err := c.post(ctx, "/audio/speech", "application/json", bytes.NewReader(payload))
+    if err != nil {
+        return nil, err
+    }
+    defer resp.Body.Close()
+
+    audio, err := io.ReadAll(resp.Body)
+    if err != nil {
+        return nil, fmt.Errorf("failed to read speech audio: %w", err)
+    }
+    return audio, nil
+}
+
+func (c *Client) post(ctx context.Context, path, contentType string, body io.Reader) (*http.Response, error) {
+    req, err := http.NewRequestWithContext(ctx, http.MethodPost, strings.TrimSuffix(c.config.BaseURL, "/")+path, body)
+    if err != nil {
+        return nil, fmt.Errorf("failed to create request: %w", err)
+    }
+    req.Header.Set("Content-Type", contentType)
+    req.Header.Set("Authorization", "Bearer "+c.config.APIKey)
+
+    resp, err := c.httpClient.Do(req)
+    if err != nil {
+        return nil, fmt.Errorf("failed to execute request: %w", err)
+    }
+    if resp.StatusCode != http.StatusOK {
+        respBody, _ := io.ReadAll(resp.Body)
+        resp.Body.Close()
+        return nil, fmt.Errorf("speech API error: %s", string(respBody))
+    }
+    return resp, nil
+}
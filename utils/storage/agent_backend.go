@@ -0,0 +1,76 @@
+package storage
+
+import (
+    "fmt"
+    "os"
+    "path/filepath"
+    "strings"
+    "time"
+)
+
+// agentBackend persists raw agent records by ID. Missing records are reported
+// with an error wrapping os.ErrNotExist.
+type agentBackend interface {
+    read(id string) ([]byte, error)
+    write(id string, data []byte) error
+    modTime(id string) (time.Time, error)
+    ids() ([]string, error)
+    remove(id string) error
+}
+
+// fileBackend keeps one JSON file per agent under <baseDir>/agents
+type fileBackend struct {
+    dir string
+}
+
+func newFileBackend(baseDir string) *fileBackend {
+    return &fileBackend{dir: filepath.Join(baseDir, "agents")}
+}
+
+func (b *fileBackend) path(id string) string {
+    return filepath.Join(b.dir, fmt.Sprintf("%s.json", id))
+}
+
+func (b *fileBackend) read(id string) ([]byte, error) {
+    return os.ReadFile(b.path(id))
+}
+
+func (b *fileBackend) write(id string, data []byte) error {
+    if err := os.MkdirAll(b.dir, 0755); err != nil {
+        return fmt.Errorf("failed to create directory: %w", err)
+    }
+    return os.WriteFile(b.path(id), data, 0644)
+}
+
+func (b *fileBackend) ids() ([]string, error) {
+    entries, err := os.ReadDir(b.dir)
+    if os.IsNotExist(err) {
+        return nil, nil
+    }
+    if err != nil {
+        return nil, fmt.Errorf("failed to read agents directory: %w", err)
+    }
+
+    var ids []string
+    for _, entry := range entries {
+        if !entry.IsDir() && strings.HasSuffix(entry.Name(), ".json") {
+            ids = append(ids, strings.TrimSuffix(entry.Name(), ".json"))
+        }
+    }
+    return ids, nil
+}
+
+func (b *fileBackend) remove(id string) error {
+    if err := os.Remove(b.path(id)); err != nil && !os.IsNotExist(err) {
+        return err
+    }
+    return nil
+}
+
+func (b *fileBackend) modTime(id string) (time.Time, error) {
+    info, err := os.Stat(b.path(id))
+    if err != nil {
+        return time.Time{}, err
+    }
+    return info.ModTime(), nil
+}
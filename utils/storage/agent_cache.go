@@ -0,0 +1,169 @@
+package storage
+
+import (
+    "sync"
+    "sync/atomic"
+    "time"
+    "anondd/utils/clock"
+    "anondd/utils/models"
+)
+
+// DefaultCacheTTL is how long cached agents and the index stay valid
+const DefaultCacheTTL = 5 * time.Minute
+
+// CacheStats reports the in-memory cache hit/miss counters
+type CacheStats struct {
+    Hits    uint64 `json:"hits"`
+    Misses  uint64 `json:"misses"`
+    Entries int    `json:"entries"`
+}
+
+type cachedAgent struct {
+    agent    models.Agent
+    cachedAt time.Time
+}
+
+// agentCache is a thread-safe read-through cache in front of the JSON files.
+// Readers take a generation before reading from disk and pass it to the put;
+// an invalidation in between bumps the generation and the stale value is
+// not cached.
+type agentCache struct {
+    ttl      time.Duration
+    clock    clock.Clock
+    mu       sync.RWMutex
+    agents   map[string]cachedAgent
+    agentGen uint64
+    index    *models.AgentIndex
+    indexAt  time.Time
+    indexGen uint64
+    hits     uint64
+    misses   uint64
+}
+
+func newAgentCache(ttl time.Duration, c clock.Clock) *agentCache {
+    return &agentCache{
+        ttl:    ttl,
+        clock:  c,
+        agents: make(map[string]cachedAgent),
+    }
+}
+
+// getAgent returns a copy of the cached agent if present and fresh
+func (c *agentCache) getAgent(id string) (*models.Agent, bool) {
+    c.mu.RLock()
+    entry, exists := c.agents[id]
+    ttl := c.ttl
+    c.mu.RUnlock()
+
+    if !exists || clock.Since(c.clock, entry.cachedAt) > ttl {
+        atomic.AddUint64(&c.misses, 1)
+        return nil, false
+    }
+
+    atomic.AddUint64(&c.hits, 1)
+    agent := entry.agent
+    return &agent, true
+}
+
+// agentGeneration is taken before reading an agent from disk for putAgent
+func (c *agentCache) agentGeneration() uint64 {
+    c.mu.RLock()
+    defer c.mu.RUnlock()
+    return c.agentGen
+}
+
+// putAgent caches an agent read at generation gen, unless an agent was
+// invalidated since
+func (c *agentCache) putAgent(agent *models.Agent, gen uint64) {
+    c.mu.Lock()
+    defer c.mu.Unlock()
+    if gen != c.agentGen {
+        return
+    }
+    c.agents[agent.ID] = cachedAgent{agent: *agent, cachedAt: c.clock.Now()}
+}
+
+func (c *agentCache) invalidateAgent(id string) {
+    c.mu.Lock()
+    defer c.mu.Unlock()
+    delete(c.agents, id)
+    c.agentGen++
+}
+
+// getIndex returns a copy of the cached index if present and fresh
+func (c *agentCache) getIndex() (*models.AgentIndex, bool) {
+    c.mu.RLock()
+    index, indexAt, ttl := c.index, c.indexAt, c.ttl
+    c.mu.RUnlock()
+
+    if index == nil || clock.Since(c.clock, indexAt) > ttl {
+        atomic.AddUint64(&c.misses, 1)
+        return nil, false
+    }
+
+    atomic.AddUint64(&c.hits, 1)
+    copied := *index
+    copied.Agents = append([]models.AgentSummary(nil), index.Agents...)
+    return &copied, true
+}
+
+// indexUpdated returns the cached index's LastUpdated without copying the
+// index, if it is present and fresh
+func (c *agentCache) indexUpdated() (time.Time, bool) {
+    c.mu.RLock()
+    index, indexAt, ttl := c.index, c.indexAt, c.ttl
+    c.mu.RUnlock()
+
+    if index == nil || clock.Since(c.clock, indexAt) > ttl {
+        return time.Time{}, false
+    }
+    atomic.AddUint64(&c.hits, 1)
+    return index.LastUpdated, true
+}
+
+// indexGeneration is taken before reading the index from disk for putIndex
+func (c *agentCache) indexGeneration() uint64 {
+    c.mu.RLock()
+    defer c.mu.RUnlock()
+    return c.indexGen
+}
+
+// putIndex caches an index read at generation gen, unless the index was
+// invalidated since
+func (c *agentCache) putIndex(index *models.AgentIndex, gen uint64) {
+    copied := *index
+    copied.Agents = append([]models.AgentSummary(nil), index.Agents...)
+
+    c.mu.Lock()
+    defer c.mu.Unlock()
+    if gen != c.indexGen {
+        return
+    }
+    c.index = &copied
+    c.indexAt = c.clock.Now()
+}
+
+func (c *agentCache) invalidateIndex() {
+    c.mu.Lock()
+    defer c.mu.Unlock()
+    c.index = nil
+    c.indexGen++
+}
+
+func (c *agentCache) setTTL(ttl time.Duration) {
+    c.mu.Lock()
+    defer c.mu.Unlock()
+    c.ttl = ttl
+}
+
+func (c *agentCache) stats() CacheStats {
+    c.mu.RLock()
+    entries := len(c.agents)
+    c.mu.RUnlock()
+
+    return CacheStats{
+        Hits:    atomic.LoadUint64(&c.hits),
+        Misses:  atomic.LoadUint64(&c.misses),
+        Entries: entries,
+    }
+}
@@ -0,0 +1,340 @@
+package storage
+
+import (
+    "bufio"
+    "context"
+    "encoding/json"
+    "fmt"
+    "io"
+    "log"
+    "os"
+    "path/filepath"
+    "sort"
+    "strings"
+    "sync"
+    "time"
+)
+
+const (
+    agentLogFile = "agents.log"
+
+    // DefaultCompactInterval is how often the background compactor checks the log
+    DefaultCompactInterval = 10 * time.Minute
+
+    // compactMinDeadBytes and compactDeadRatio decide when rewriting the log pays off
+    compactMinDeadBytes = 1 << 20
+    compactDeadRatio    = 0.5
+)
+
+// logRecord is one line of the append-only agent log
+type logRecord struct {
+    ID      string          `json:"id"`
+    At      time.Time       `json:"at"`
+    Agent   json.RawMessage `json:"agent,omitempty"`
+    Deleted bool            `json:"deleted,omitempty"` // Tombstone removing the agent
+}
+
+// logEntry locates the latest record for an agent in the log
+type logEntry struct {
+    offset int64
+    length int64
+    at     time.Time
+}
+
+// logBackend stores every agent snapshot in a single append-only file with an
+// in-memory offset index pointing at each agent's latest record. Superseded
+// records are dropped by compaction.
+type logBackend struct {
+    path      string
+    mu        sync.RWMutex
+    file      *os.File
+    size      int64
+    liveBytes int64
+    index     map[string]logEntry
+    logger    *log.Logger
+}
+
+// openLogBackend opens (or creates) the log under baseDir and rebuilds its
+// index. A new, empty log is seeded from any existing per-agent JSON files.
+func openLogBackend(baseDir string, logger *log.Logger) (*logBackend, error) {
+    if err := os.MkdirAll(baseDir, 0755); err != nil {
+        return nil, fmt.Errorf("failed to create directory: %w", err)
+    }
+
+    b := &logBackend{
+        path:   filepath.Join(baseDir, agentLogFile),
+        index:  make(map[string]logEntry),
+        logger: logger,
+    }
+    file, err := os.OpenFile(b.path, os.O_RDWR|os.O_CREATE, 0644)
+    if err != nil {
+        return nil, fmt.Errorf("failed to open agent log: %w", err)
+    }
+    b.file = file
+
+    if err := b.load(); err != nil {
+        file.Close()
+        return nil, err
+    }
+    if b.size == 0 {
+        if err := b.importFiles(newFileBackend(baseDir)); err != nil {
+            logger.Printf("Error importing agent files into log: %v", err)
+        }
+    }
+    return b, nil
+}
+
+// load scans the log and indexes the last record for each agent. A torn
+// trailing record from an interrupted write is truncated away.
+func (b *logBackend) load() error {
+    if _, err := b.file.Seek(0, io.SeekStart); err != nil {
+        return fmt.Errorf("failed to seek agent log: %w", err)
+    }
+
+    reader := bufio.NewReader(b.file)
+    var offset int64
+    for {
+        line, err := reader.ReadBytes('\n')
+        if err == io.EOF {
+            if len(line) > 0 {
+                b.logger.Printf("Truncating incomplete record at end of agent log")
+                if err := b.file.Truncate(offset); err != nil {
+                    return fmt.Errorf("failed to truncate agent log: %w", err)
+                }
+            }
+            break
+        }
+        if err != nil {
+            return fmt.Errorf("failed to read agent log: %w", err)
+        }
+
+        var record logRecord
+        if err := json.Unmarshal(line, &record); err != nil {
+            return fmt.Errorf("agent log record at offset %d: %w: %w", offset, ErrCorrupt, err)
+        }
+        if record.Deleted {
+            delete(b.index, record.ID)
+        } else {
+            b.index[record.ID] = logEntry{offset: offset, length: int64(len(line)), at: record.At}
+        }
+        offset += int64(len(line))
+    }
+
+    b.size = offset
+    b.liveBytes = 0
+    for _, entry := range b.index {
+        b.liveBytes += entry.length
+    }
+    return nil
+}
+
+// importFiles copies per-agent JSON files into the log
+func (b *logBackend) importFiles(files *fileBackend) error {
+    entries, err := os.ReadDir(files.dir)
+    if os.IsNotExist(err) {
+        return nil
+    }
+    if err != nil {
+        return err
+    }
+
+    imported := 0
+    for _, entry := range entries {
+        if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".json") {
+            continue
+        }
+        id := strings.TrimSuffix(entry.Name(), ".json")
+        data, err := files.read(id)
+        if err != nil {
+            return err
+        }
+        if err := b.write(id, data); err != nil {
+            return err
+        }
+        imported++
+    }
+    if imported > 0 {
+        b.logger.Printf("Imported %d agent files into %s", imported, b.path)
+    }
+    return nil
+}
+
+func (b *logBackend) read(id string) ([]byte, error) {
+    b.mu.RLock()
+    defer b.mu.RUnlock()
+
+    entry, ok := b.index[id]
+    if !ok {
+        return nil, fmt.Errorf("agent %s: %w", id, os.ErrNotExist)
+    }
+
+    line := make([]byte, entry.length)
+    if _, err := b.file.ReadAt(line, entry.offset); err != nil {
+        return nil, fmt.Errorf("failed to read agent log: %w", err)
+    }
+    var record logRecord
+    if err := json.Unmarshal(line, &record); err != nil {
+        return nil, fmt.Errorf("agent log record for %s: %w: %w", id, ErrCorrupt, err)
+    }
+    return record.Agent, nil
+}
+
+func (b *logBackend) write(id string, data []byte) error {
+    // Records are one line each, so store the agent JSON compacted
+    var compact strings.Builder
+    encoder := json.NewEncoder(&compact)
+    if err := encoder.Encode(logRecord{ID: id, At: time.Now(), Agent: json.RawMessage(data)}); err != nil {
+        return fmt.Errorf("failed to encode agent log record: %w", err)
+    }
+    line := []byte(compact.String())
+
+    b.mu.Lock()
+    defer b.mu.Unlock()
+
+    if _, err := b.file.WriteAt(line, b.size); err != nil {
+        return fmt.Errorf("failed to append to agent log: %w", err)
+    }
+    if previous, ok := b.index[id]; ok {
+        b.liveBytes -= previous.length
+    }
+    b.index[id] = logEntry{offset: b.size, length: int64(len(line)), at: time.Now()}
+    b.size += int64(len(line))
+    b.liveBytes += int64(len(line))
+    return nil
+}
+
+func (b *logBackend) ids() ([]string, error) {
+    b.mu.RLock()
+    defer b.mu.RUnlock()
+
+    ids := make([]string, 0, len(b.index))
+    for id := range b.index {
+        ids = append(ids, id)
+    }
+    sort.Strings(ids)
+    return ids, nil
+}
+
+// remove appends a tombstone so the agent stays deleted after a reload
+func (b *logBackend) remove(id string) error {
+    line, err := json.Marshal(logRecord{ID: id, At: time.Now(), Deleted: true})
+    if err != nil {
+        return fmt.Errorf("failed to encode agent log tombstone: %w", err)
+    }
+    line = append(line, '\n')
+
+    b.mu.Lock()
+    defer b.mu.Unlock()
+
+    if _, err := b.file.WriteAt(line, b.size); err != nil {
+        return fmt.Errorf("failed to append to agent log: %w", err)
+    }
+    if previous, ok := b.index[id]; ok {
+        b.liveBytes -= previous.length
+        delete(b.index, id)
+    }
+    b.size += int64(len(line))
+    return nil
+}
+
+func (b *logBackend) modTime(id string) (time.Time, error) {
+    b.mu.RLock()
+    defer b.mu.RUnlock()
+
+    entry, ok := b.index[id]
+    if !ok {
+        return time.Time{}, fmt.Errorf("agent %s: %w", id, os.ErrNotExist)
+    }
+    return entry.at, nil
+}
+
+// flush syncs the log to disk
+func (b *logBackend) flush() error {
+    b.mu.Lock()
+    defer b.mu.Unlock()
+    if err := b.file.Sync(); err != nil {
+        return fmt.Errorf("failed to sync agent log: %w", err)
+    }
+    return nil
+}
+
+// needsCompaction reports whether superseded records take up enough of the log
+func (b *logBackend) needsCompaction() bool {
+    b.mu.RLock()
+    defer b.mu.RUnlock()
+
+    dead := b.size - b.liveBytes
+    return dead >= compactMinDeadBytes && float64(dead) >= compactDeadRatio*float64(b.size)
+}
+
+// compact rewrites the log with only the latest record per agent and swaps it
+// in atomically. Writers are blocked for the duration.
+func (b *logBackend) compact() error {
+    b.mu.Lock()
+    defer b.mu.Unlock()
+
+    tmpPath := b.path + ".tmp"
+    tmp, err := os.OpenFile(tmpPath, os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0644)
+    if err != nil {
+        return fmt.Errorf("failed to create compacted log: %w", err)
+    }
+
+    index := make(map[string]logEntry, len(b.index))
+    var offset int64
+    for id, entry := range b.index {
+        line := make([]byte, entry.length)
+        if _, err := b.file.ReadAt(line, entry.offset); err != nil {
+            tmp.Close()
+            os.Remove(tmpPath)
+            return fmt.Errorf("failed to read agent log: %w", err)
+        }
+        if _, err := tmp.WriteAt(line, offset); err != nil {
+            tmp.Close()
+            os.Remove(tmpPath)
+            return fmt.Errorf("failed to write compacted log: %w", err)
+        }
+        index[id] = logEntry{offset: offset, length: entry.length, at: entry.at}
+        offset += entry.length
+    }
+
+    if err := tmp.Sync(); err != nil {
+        tmp.Close()
+        os.Remove(tmpPath)
+        return fmt.Errorf("failed to sync compacted log: %w", err)
+    }
+    if err := os.Rename(tmpPath, b.path); err != nil {
+        tmp.Close()
+        os.Remove(tmpPath)
+        return fmt.Errorf("failed to replace agent log: %w", err)
+    }
+
+    b.file.Close()
+    b.file = tmp
+    b.index = index
+    b.size = offset
+    b.liveBytes = offset
+    return nil
+}
+
+// runCompaction compacts the log whenever it is worth it until ctx is cancelled
+func (b *logBackend) runCompaction(ctx context.Context, interval time.Duration) {
+    ticker := time.NewTicker(interval)
+    defer ticker.Stop()
+
+    for {
+        select {
+        case <-ticker.C:
+            if !b.needsCompaction() {
+                continue
+            }
+            start := time.Now()
+            if err := b.compact(); err != nil {
+                b.logger.Printf("Error compacting agent log: %v", err)
+                continue
+            }
+            b.logger.Printf("Compacted agent log in %s", time.Since(start).Round(time.Millisecond))
+        case <-ctx.Done():
+            return
+        }
+    }
+}
@@ -1,14 +1,22 @@
 package storage
 
 import (
+    "context"
     "encoding/json"
+    "errors"
     "fmt"
     "log"
     "os"
     "path/filepath"
+    "sort"
+    "strings"
     "sync"
     "time"
+    "anondd/utils/clock"
+    "anondd/utils/events"
     "anondd/utils/models"
+    "anondd/utils/shared"
+    "anondd/utils/trace"
     "reflect"
 )
 
@@ -16,9 +24,22 @@ import (
 type AgentStore struct {
     BaseDir    string
     indexMutex sync.RWMutex
+    batchMutex sync.Mutex
     logger     *log.Logger
     fetchCache map[string]time.Time
     cacheMutex sync.RWMutex
+    cache      *agentCache
+    failures   *failureTracker
+    changes    *changeLog
+    historyMu  sync.Mutex
+    newsMu     sync.Mutex
+    runsMu     sync.Mutex
+    agents     agentBackend
+    shared     shared.Store
+    events     *events.Bus
+    clock      clock.Clock
+
+    keepVersions int
 }
 
 // NewAgentStore creates a new agent store
@@ -27,12 +48,88 @@ func NewAgentStore(baseDir string, logger *log.Logger) *AgentStore {
         BaseDir:    baseDir,
         logger:     logger,
         fetchCache: make(map[string]time.Time),
+        cache:      newAgentCache(DefaultCacheTTL, clock.Real()),
+        failures:   newFailureTracker(baseDir, logger),
+        changes:    newChangeLog(baseDir),
+        agents:     newFileBackend(baseDir),
+        clock:      clock.Real(),
+
+        keepVersions: DefaultIndexVersions,
+    }
+    if err := store.failures.load(); err != nil {
+        logger.Printf("Error loading failure records: %v", err)
     }
     return store
 }
 
+// SetEvents makes the store publish AgentChanged events on bus
+func (s *AgentStore) SetEvents(bus *events.Bus) {
+    s.events = bus
+}
+
+// SetClock makes the store read time from c for staleness checks, caching,
+// quarantines and timestamps. Call it before the store is used.
+func (s *AgentStore) SetClock(c clock.Clock) {
+    s.clock = c
+    s.cache.clock = c
+}
+
+// Clock returns the clock the store reads time from
+func (s *AgentStore) Clock() clock.Clock {
+    return s.clock
+}
+
+// EnableCompactStorage switches agent records from one JSON file per agent to a
+// single append-only log with an in-memory offset index, importing existing
+// files on first use. The log is compacted in the background until ctx is done.
+// Call it before the store is used.
+func (s *AgentStore) EnableCompactStorage(ctx context.Context, compactInterval time.Duration) error {
+    backend, err := openLogBackend(s.BaseDir, s.logger)
+    if err != nil {
+        return err
+    }
+    s.agents = backend
+    go backend.runCompaction(ctx, compactInterval)
+    return nil
+}
+
+// Flush waits for batch and index writes in progress, saves pending failure
+// records and syncs compact storage to disk. Call it at shutdown, once the
+// scrapers have stopped.
+func (s *AgentStore) Flush() error {
+    failuresErr := s.failures.flush()
+    s.batchMutex.Lock()
+    defer s.batchMutex.Unlock()
+    s.indexMutex.Lock()
+    defer s.indexMutex.Unlock()
+    if backend, ok := s.agents.(*logBackend); ok {
+        if err := backend.flush(); err != nil {
+            return err
+        }
+    }
+    return failuresErr
+}
+
+// SetCacheTTL changes how long agents and the index stay in the in-memory cache
+func (s *AgentStore) SetCacheTTL(ttl time.Duration) {
+    s.cache.setTTL(ttl)
+}
+
+// CacheStats returns the in-memory cache hit/miss metrics
+func (s *AgentStore) CacheStats() CacheStats {
+    return s.cache.stats()
+}
+
 // ShouldFetch checks if an agent should be fetched again
 func (s *AgentStore) ShouldFetch(agentID string) bool {
+    if s.shared != nil {
+        _, fetched, err := s.shared.Get(context.Background(), fetchedKeyPrefix+agentID)
+        if err == nil {
+            return !fetched
+        }
+        s.logger.Printf("Error reading shared fetch cache, using local: %v", err)
+    }
+
     s.cacheMutex.RLock()
     defer s.cacheMutex.RUnlock()
     
@@ -41,122 +138,564 @@ func (s *AgentStore) ShouldFetch(agentID string) bool {
         return true
     }
     
-    return time.Since(lastFetch) > 24*time.Hour
+    return clock.Since(s.clock, lastFetch) > refetchAfter
 }
 
 // MarkFetched updates the fetch cache
 func (s *AgentStore) MarkFetched(agentID string) {
+    if s.shared != nil {
+        if err := s.shared.Set(context.Background(), fetchedKeyPrefix+agentID, s.clock.Now().Format(time.RFC3339), refetchAfter); err != nil {
+            s.logger.Printf("Error writing shared fetch cache: %v", err)
+        }
+    }
+
     s.cacheMutex.Lock()
     defer s.cacheMutex.Unlock()
-    s.fetchCache[agentID] = time.Now()
+    s.fetchCache[agentID] = s.clock.Now()
 }
 
 // SaveAgent saves an individual agent to storage
 func (s *AgentStore) SaveAgent(agent *models.Agent) error {
-    agent.LastChecked = time.Now()
+    return s.SaveAgentAt(agent, s.clock.Now())
+}
+
+// SaveAgentAt saves an agent checked at checkedAt rather than now, e.g. one
+// reparsed from a page fetched earlier
+func (s *AgentStore) SaveAgentAt(agent *models.Agent, checkedAt time.Time) error {
+    data, err := s.prepareAgent(agent, checkedAt)
+    if err != nil || data == nil {
+        return err
+    }
+
+    if err := s.agents.write(agent.ID, data); err != nil {
+        return err
+    }
+    s.invalidateAgent(agent.ID)
+    return nil
+}
+
+// prepareAgent stamps an agent checked at checkedAt for saving and marshals
+// it. It returns nil data when the stored record is already identical.
+func (s *AgentStore) prepareAgent(agent *models.Agent, checkedAt time.Time) ([]byte, error) {
+    agent.LastChecked = checkedAt
     agent.UpdateCount++
     agent.UpdateStatus()
+    agent.SchemaVersion = CurrentSchemaVersion()
 
     if agent.ID == "" {
         agent.GenerateID()
     }
 
-    filePath := filepath.Join(s.BaseDir, "agents", fmt.Sprintf("%s.json", agent.ID))
-    fmt.Printf("Filepath", filePath)
-    // Check if file exists
-    if _, err := os.Stat(filePath); err == nil {
-        // Load existing agent to compare
-        existing, err := s.GetAgent(agent.ID)
-        if err == nil {
-            // Only update if there are changes
-            if reflect.DeepEqual(existing, agent) {
-                return nil
-            }
-            agent.UpdateCount = existing.UpdateCount + 1
+    // Load existing agent to compare
+    existing, err := s.GetAgent(agent.ID)
+    if err == nil {
+        // Fresh scrapes don't carry the risk score or on-chain data; keep them
+        // until they are recomputed
+        if agent.Risk == nil {
+            agent.Risk = existing.Risk
+        }
+        if agent.OnChain == nil {
+            agent.OnChain = existing.OnChain
+        }
+        // Heartbeats come from their own checks; a scrape doesn't reset them
+        if agent.Heartbeat == nil {
+            agent.Heartbeat = existing.Heartbeat
         }
+        // The first sighting and launch date never move once known
+        if !existing.FirstSeen.IsZero() {
+            agent.FirstSeen = existing.FirstSeen
+        }
+        if agent.LaunchedAt.IsZero() {
+            agent.LaunchedAt = existing.LaunchedAt
+        }
+        // Likewise keep social link checks for links still listed
+        models.CarrySocialChecks(agent.Socials, existing.Socials)
+        // Only update if there are changes
+        if reflect.DeepEqual(existing, agent) {
+            return nil, nil
+        }
+        agent.UpdateCount = existing.UpdateCount + 1
+    } else if agent.FirstSeen.IsZero() {
+        agent.FirstSeen = agent.LastChecked
     }
 
     data, err := json.MarshalIndent(agent, "", "  ")
     if err != nil {
-        return fmt.Errorf("failed to marshal agent: %w", err)
-    }
-
-    if err := os.MkdirAll(filepath.Dir(filePath), 0755); err != nil {
-        return fmt.Errorf("failed to create directory: %w", err)
+        return nil, fmt.Errorf("failed to marshal agent: %w", err)
     }
+    return data, nil
+}
 
-    return os.WriteFile(filePath, data, 0644)
+// stagedWrite remembers an agent record's previous contents so a batch can be undone
+type stagedWrite struct {
+    id       string
+    previous []byte
+    existed  bool
 }
 
-// SaveAgents saves multiple agents and updates the index
+// SaveAgents saves a batch of agents and merges them into the index as one
+// transaction: if any record or the index fails to write, every record written
+// by the batch is restored to its previous contents. Batches never interleave.
 func (s *AgentStore) SaveAgents(agents []models.Agent) error {
-    for _, agent := range agents {
-        if err := s.SaveAgent(&agent); err != nil {
-            s.logger.Printf("Error saving agent %s: %v", agent.ID, err)
+    s.batchMutex.Lock()
+    defer s.batchMutex.Unlock()
+
+    var staged []stagedWrite
+    rollback := func(cause error) error {
+        for i := len(staged) - 1; i >= 0; i-- {
+            write := staged[i]
+            var err error
+            if write.existed {
+                err = s.agents.write(write.id, write.previous)
+            } else {
+                err = s.agents.remove(write.id)
+            }
+            if err != nil {
+                s.logger.Printf("Error rolling back agent %s: %v", write.id, err)
+            }
+            s.invalidateAgent(write.id)
+        }
+        return cause
+    }
+
+    for i := range agents {
+        data, err := s.prepareAgent(&agents[i], s.clock.Now())
+        if err != nil {
+            return rollback(fmt.Errorf("failed to prepare agent %s: %w", agents[i].ID, err))
+        }
+        if data == nil {
             continue
         }
+
+        write := stagedWrite{id: agents[i].ID}
+        previous, err := s.agents.read(write.id)
+        switch {
+        case err == nil:
+            write.previous, write.existed = previous, true
+        case !errors.Is(err, os.ErrNotExist):
+            return rollback(fmt.Errorf("failed to read agent %s: %w", write.id, err))
+        }
+
+        if err := s.agents.write(write.id, data); err != nil {
+            return rollback(fmt.Errorf("failed to save agent %s: %w", write.id, err))
+        }
+        staged = append(staged, write)
+        s.invalidateAgent(write.id)
     }
-    return s.UpdateIndex(agents)
+
+    if err := s.MergeIndex(agents); err != nil {
+        return rollback(fmt.Errorf("failed to update index: %w", err))
+    }
+    return nil
 }
 
-// UpdateIndex updates the agent index file
+// UpdateIndex replaces the agent index with the given agents
 func (s *AgentStore) UpdateIndex(agents []models.Agent) error {
     s.indexMutex.Lock()
     defer s.indexMutex.Unlock()
+    return s.writeIndex(agents)
+}
+
+// writeIndex writes the index file; callers must hold the indexMutex write lock
+func (s *AgentStore) writeIndex(agents []models.Agent) error {
+    summaries := make([]models.AgentSummary, len(agents))
+    for i := range agents {
+        summaries[i] = agents[i].ToSummary()
+    }
+    return s.writeSummaries(summaries)
+}
 
+// writeSummaries writes the index file from summaries; callers must hold the
+// indexMutex write lock
+func (s *AgentStore) writeSummaries(summaries []models.AgentSummary) error {
+    now := s.clock.Now()
     index := models.AgentIndex{
-        LastUpdated: time.Now(),
-        Agents:      make([]models.AgentSummary, len(agents)),
+        LastUpdated: now,
+        Agents:      summaries,
     }
 
-    for i, agent := range agents {
-        index.Agents[i] = models.AgentSummary{
-            ID:    agent.ID,
-            Name:  agent.Name,
-            Price: agent.Price,
+    // Carry first-seen timestamps, launch dates and risk scores over from the
+    // previous index. First sightings follow the agent's ID on its source, so
+    // a price change that re-keys the agent doesn't make it new again.
+    firstSeen := make(map[string]time.Time)
+    launchedAt := make(map[string]time.Time)
+    riskScores := make(map[string]*int)
+    if previous, err := s.readIndex(); err == nil {
+        for _, summary := range previous.Agents {
+            for _, key := range []string{summary.ID, summary.IdentityKey()} {
+                seen := firstSeen[key]
+                if !summary.FirstSeen.IsZero() && (seen.IsZero() || summary.FirstSeen.Before(seen)) {
+                    firstSeen[key] = summary.FirstSeen
+                }
+            }
+            launchedAt[summary.ID] = summary.LaunchedAt
+            riskScores[summary.ID] = summary.RiskScore
+        }
+    }
+
+    for i, summary := range summaries {
+        seen := firstSeen[summary.IdentityKey()]
+        if seen.IsZero() {
+            seen = firstSeen[summary.ID]
+        }
+        if !seen.IsZero() {
+            index.Agents[i].FirstSeen = seen
+        } else if index.Agents[i].FirstSeen.IsZero() {
+            index.Agents[i].FirstSeen = now
+        }
+        if index.Agents[i].LaunchedAt.IsZero() {
+            index.Agents[i].LaunchedAt = launchedAt[summary.ID]
+        }
+        if index.Agents[i].RiskScore == nil {
+            index.Agents[i].RiskScore = riskScores[summary.ID]
         }
     }
 
+    return s.saveIndex(&index)
+}
+
+// saveIndex writes the index file as given; callers must hold the indexMutex
+// write lock
+func (s *AgentStore) saveIndex(index *models.AgentIndex) error {
     data, err := json.MarshalIndent(index, "", "  ")
     if (err != nil) {
         return fmt.Errorf("failed to marshal index: %w", err)
     }
 
+    // Write to a temporary file and rename so readers never see a partial index
     indexPath := filepath.Join(s.BaseDir, "agent_index.json")
-    return os.WriteFile(indexPath, data, 0644)
+    tmpPath := indexPath + ".tmp"
+    if err := os.WriteFile(tmpPath, data, 0644); err != nil {
+        return err
+    }
+    if err := os.Rename(tmpPath, indexPath); err != nil {
+        os.Remove(tmpPath)
+        return err
+    }
+    s.invalidateIndex()
+    return nil
 }
 
-// GetAgent retrieves an agent by ID
+// AllAgents loads every stored agent record, sorted by name. Unreadable
+// records are logged and skipped.
+func (s *AgentStore) AllAgents() ([]models.Agent, error) {
+    ids, err := s.agents.ids()
+    if err != nil {
+        return nil, fmt.Errorf("failed to list agent records: %w", err)
+    }
+    agents := make([]models.Agent, 0, len(ids))
+    for _, id := range ids {
+        agent, err := s.GetAgent(id)
+        if err != nil {
+            s.logger.Printf("Skipping agent %s: %v", id, err)
+            continue
+        }
+        agents = append(agents, *agent)
+    }
+    sort.Slice(agents, func(i, j int) bool { return agents[i].Name < agents[j].Name })
+    return agents, nil
+}
+
+// RebuildIndex replaces the index with one built from the stored agent
+// records, saving the current index as a version first
+func (s *AgentStore) RebuildIndex() (int, error) {
+    agents, err := s.AllAgents()
+    if err != nil {
+        return 0, err
+    }
+
+    s.indexMutex.Lock()
+    defer s.indexMutex.Unlock()
+    if _, err := s.snapshotIndex("rebuild"); err != nil && !errors.Is(err, ErrNotFound) {
+        return 0, fmt.Errorf("failed to save current index: %w", err)
+    }
+    if err := s.writeIndex(agents); err != nil {
+        return 0, fmt.Errorf("failed to write index: %w", err)
+    }
+    return len(agents), nil
+}
+
+// BackfillIndexSources fills in the source IDs of index entries written
+// before the index recorded them, leaving their first sightings alone, so
+// the next index write doesn't take re-keyed agents for new ones. It returns
+// how many entries were filled.
+func (s *AgentStore) BackfillIndexSources() (int, error) {
+    s.indexMutex.Lock()
+    defer s.indexMutex.Unlock()
+
+    index, err := s.readIndex()
+    if errors.Is(err, ErrNotFound) {
+        return 0, nil
+    }
+    if err != nil {
+        return 0, err
+    }
+    filled := 0
+    for i := range index.Agents {
+        summary := &index.Agents[i]
+        if summary.SourceID > 0 {
+            continue
+        }
+        data, err := s.agents.read(summary.ID)
+        if err != nil {
+            continue
+        }
+        var record gcRecord
+        if err := json.Unmarshal(data, &record); err != nil || record.SourceID <= 0 {
+            continue
+        }
+        summary.Source, summary.SourceID = record.Source, record.SourceID
+        filled++
+    }
+    if filled == 0 {
+        return 0, nil
+    }
+    return filled, s.saveIndex(index)
+}
+
+// MergeIndex upserts the given agents into the existing index, keeping agents
+// that were not part of this batch. Entries with the same source ID as an
+// agent in the batch are replaced, whatever their ID. The read and write happen under one lock
+// so concurrent merges cannot drop each other's entries.
+func (s *AgentStore) MergeIndex(agents []models.Agent) error {
+    s.indexMutex.Lock()
+    defer s.indexMutex.Unlock()
+
+    existing, err := s.readIndex()
+    if errors.Is(err, ErrNotFound) {
+        return s.writeIndex(agents)
+    }
+    if err != nil {
+        return err
+    }
+
+    merged := make([]models.AgentSummary, 0, len(existing.Agents)+len(agents))
+    updated := make(map[string]bool, len(agents))
+    for i := range agents {
+        updated[agents[i].ID] = true
+        // An agent re-keyed by a price change replaces its old entry
+        updated[agents[i].IdentityKey()] = true
+    }
+    // Entries outside the batch are kept exactly as indexed
+    for _, summary := range existing.Agents {
+        if !updated[summary.ID] && !updated[summary.IdentityKey()] {
+            merged = append(merged, summary)
+        }
+    }
+    for i := range agents {
+        merged = append(merged, agents[i].ToSummary())
+    }
+
+    return s.writeSummaries(merged)
+}
+
+// GetAgent retrieves an agent by ID, serving from the in-memory cache when fresh
 func (s *AgentStore) GetAgent(id string) (*models.Agent, error) {
-    filePath := filepath.Join(s.BaseDir, "agents", fmt.Sprintf("%s.json", id))
-    data, err := os.ReadFile(filePath)
+    return s.GetAgentContext(context.Background(), id)
+}
+
+// GetAgentContext is GetAgent with the request's trace carried into logs and spans
+func (s *AgentStore) GetAgentContext(ctx context.Context, id string) (result *models.Agent, err error) {
+    traced := trace.FromContext(ctx) != ""
+    ctx, span := trace.StartSpan(ctx, "storage.GetAgent")
+    span.SetAttribute("agent_id", id)
+    defer func() {
+        if err != nil && traced {
+            trace.Logf(ctx, s.logger, "Failed to load agent %s: %v", id, err)
+        }
+        span.Finish(err)
+    }()
+
+    // IDs come from users too; only well-formed ones reach the filesystem
+    if !models.IsAgentID(id) {
+        return nil, fmt.Errorf("agent %q: %w", id, ErrNotFound)
+    }
+    if cached, ok := s.cache.getAgent(id); ok {
+        span.SetAttribute("cache", "hit")
+        return cached, nil
+    }
+    span.SetAttribute("cache", "miss")
+
+    // Writers invalidate after writing, so a save landing during the read
+    // keeps this copy out of the cache
+    gen := s.cache.agentGeneration()
+    data, err := s.agents.read(id)
+    if errors.Is(err, os.ErrNotExist) {
+        return nil, fmt.Errorf("agent %s: %w", id, ErrNotFound)
+    }
     if err != nil {
-        return nil, fmt.Errorf("failed to read agent file: %w", err)
+        return nil, fmt.Errorf("failed to read agent record: %w", err)
     }
 
     var agent models.Agent
     if err := json.Unmarshal(data, &agent); err != nil {
-        return nil, fmt.Errorf("failed to unmarshal agent: %w", err)
+        return nil, fmt.Errorf("failed to unmarshal agent: %w: %w", ErrCorrupt, err)
     }
 
+    s.cache.putAgent(&agent, gen)
     return &agent, nil
 }
 
-// GetIndex retrieves the current agent index
+// AgentModTime returns when an agent's record was last written
+func (s *AgentStore) AgentModTime(id string) (time.Time, error) {
+    modTime, err := s.agents.modTime(id)
+    if err != nil {
+        return time.Time{}, fmt.Errorf("failed to stat agent record: %w", err)
+    }
+    return modTime, nil
+}
+
+// FindAgent resolves an agent by exact ID, falling back to the first index
+// entry whose name contains query (case-insensitive)
+func (s *AgentStore) FindAgent(ctx context.Context, query string) (*models.Agent, error) {
+    query = strings.TrimSpace(query)
+    if query == "" {
+        return nil, fmt.Errorf("empty agent query: %w", ErrNotFound)
+    }
+    if models.IsAgentID(query) {
+        if agent, err := s.GetAgentContext(ctx, query); err == nil {
+            return agent, nil
+        }
+    }
+
+    index, err := s.GetIndexContext(ctx)
+    if err != nil {
+        return nil, err
+    }
+    for _, summary := range index.Agents {
+        if strings.Contains(strings.ToLower(summary.Name), strings.ToLower(query)) {
+            return s.GetAgentContext(ctx, summary.ID)
+        }
+    }
+    return nil, fmt.Errorf("no agent matching '%s': %w", query, ErrNotFound)
+}
+
+// FindAgentExact resolves an agent by ID or by a name equal to query,
+// ignoring case and a leading $
+func (s *AgentStore) FindAgentExact(ctx context.Context, query string) (*models.Agent, error) {
+    query = strings.TrimSpace(query)
+    if models.IsAgentID(query) {
+        if agent, err := s.GetAgentContext(ctx, query); err == nil {
+            return agent, nil
+        }
+    }
+
+    index, err := s.GetIndexContext(ctx)
+    if err != nil {
+        return nil, err
+    }
+    name := strings.TrimPrefix(query, "$")
+    for _, summary := range index.Agents {
+        if name != "" && strings.EqualFold(strings.TrimPrefix(summary.Name, "$"), name) {
+            return s.GetAgentContext(ctx, summary.ID)
+        }
+    }
+    return nil, fmt.Errorf("no agent named '%s': %w", query, ErrNotFound)
+}
+
+// GetIndex retrieves the current agent index, serving from the in-memory cache when fresh
 func (s *AgentStore) GetIndex() (*models.AgentIndex, error) {
+    return s.GetIndexContext(context.Background())
+}
+
+// GetIndexContext is GetIndex with the request's trace carried into logs and spans
+func (s *AgentStore) GetIndexContext(ctx context.Context) (result *models.AgentIndex, err error) {
+    traced := trace.FromContext(ctx) != ""
+    ctx, span := trace.StartSpan(ctx, "storage.GetIndex")
+    defer func() {
+        if err != nil && traced {
+            trace.Logf(ctx, s.logger, "Failed to load index: %v", err)
+        }
+        span.Finish(err)
+    }()
+
+    if cached, ok := s.cache.getIndex(); ok {
+        span.SetAttribute("cache", "hit")
+        return cached, nil
+    }
+    span.SetAttribute("cache", "miss")
+
     s.indexMutex.RLock()
     defer s.indexMutex.RUnlock()
 
+    gen := s.cache.indexGeneration()
+    index, err := s.readIndex()
+    if err != nil {
+        return nil, err
+    }
+
+    s.cache.putIndex(index, gen)
+    return index, nil
+}
+
+// IndexUpdated returns when the index was last written. It is cheap on a cache
+// hit, so callers can check whether responses built from the index are current.
+func (s *AgentStore) IndexUpdated(ctx context.Context) (time.Time, error) {
+    if updated, ok := s.cache.indexUpdated(); ok {
+        return updated, nil
+    }
+    index, err := s.GetIndexContext(ctx)
+    if err != nil {
+        return time.Time{}, err
+    }
+    return index.LastUpdated, nil
+}
+
+// readIndex loads the index file; callers must hold indexMutex
+func (s *AgentStore) readIndex() (*models.AgentIndex, error) {
     indexPath := filepath.Join(s.BaseDir, "agent_index.json")
     data, err := os.ReadFile(indexPath)
+    if os.IsNotExist(err) {
+        return nil, fmt.Errorf("agent index: %w", ErrNotFound)
+    }
     if err != nil {
         return nil, fmt.Errorf("failed to read index file: %w", err)
     }
 
     var index models.AgentIndex
     if err := json.Unmarshal(data, &index); err != nil {
-        return nil, fmt.Errorf("failed to unmarshal index: %w", err)
+        return nil, fmt.Errorf("failed to unmarshal index: %w: %w", ErrCorrupt, err)
     }
 
     return &index, nil
 }
+
+// NewAgentsSince returns index entries first seen after the given time, oldest first
+func (s *AgentStore) NewAgentsSince(since time.Time) ([]models.AgentSummary, error) {
+    index, err := s.GetIndex()
+    if err != nil {
+        return nil, err
+    }
+
+    var newAgents []models.AgentSummary
+    for _, summary := range index.Agents {
+        if summary.FirstSeen.After(since) {
+            newAgents = append(newAgents, summary)
+        }
+    }
+    sort.Slice(newAgents, func(i, j int) bool {
+        return newAgents[i].FirstSeen.Before(newAgents[j].FirstSeen)
+    })
+    return newAgents, nil
+}
+
+// LaunchedSince returns index entries whose launch date is after the given
+// time, newest first. Agents without an on-page creation date count from when
+// they were first seen.
+func (s *AgentStore) LaunchedSince(since time.Time) ([]models.AgentSummary, error) {
+    index, err := s.GetIndex()
+    if err != nil {
+        return nil, err
+    }
+
+    var launched []models.AgentSummary
+    for _, summary := range index.Agents {
+        if summary.LaunchDate().After(since) {
+            launched = append(launched, summary)
+        }
+    }
+    sort.Slice(launched, func(i, j int) bool {
+        return launched[i].LaunchDate().After(launched[j].LaunchDate())
+    })
+    return launched, nil
+}
@@ -0,0 +1,85 @@
+package storage
+
+import (
+    "fmt"
+    "time"
+    "anondd/utils/events"
+    "anondd/utils/models"
+)
+
+// AnomalyAlertCooldown is how long an anomaly that keeps holding stays quiet
+// before it alerts again
+const AnomalyAlertCooldown = 24 * time.Hour
+
+// AnomalyAlert is the alert state of one kind of anomaly for an agent
+type AnomalyAlert struct {
+    Active    bool      `json:"active"`     // Detected on the latest scrape
+    AlertedAt time.Time `json:"alerted_at"` // When it last made the change feed
+}
+
+// anomalyKinds are the event types Detect can report
+var anomalyKinds = []string{models.ChangeHoldersSpike, models.ChangeVolumeSpike, models.ChangePriceMove}
+
+// RecordAnomalies takes the anomalies detected on an agent's latest scrape
+// and appends to the change feed only those that just started, or that have
+// held since an alert at least AnomalyAlertCooldown ago. It returns the
+// events that were added.
+func (s *AgentStore) RecordAnomalies(agent *models.Agent, detected []models.ChangeEvent) ([]models.ChangeEvent, error) {
+    added, err := s.recordAnomalies(agent, detected)
+    for _, event := range added {
+        s.events.Publish(events.AgentChanged{Change: event})
+    }
+    return added, err
+}
+
+func (s *AgentStore) recordAnomalies(agent *models.Agent, detected []models.ChangeEvent) ([]models.ChangeEvent, error) {
+    s.changes.mu.Lock()
+    defer s.changes.mu.Unlock()
+    if err := s.changes.ensureLoaded(); err != nil {
+        return nil, err
+    }
+
+    now := s.clock.Now()
+    byKind := make(map[string]models.ChangeEvent, len(detected))
+    for _, event := range detected {
+        byKind[event.Type] = event
+    }
+
+    var added []models.ChangeEvent
+    changed := false
+    for _, kind := range anomalyKinds {
+        key := fmt.Sprintf("%d:%s", agent.SourceID, kind)
+        previous, seen := s.changes.alerts[key]
+        event, active := byKind[kind]
+        if !active {
+            // Cleared, so the next time it holds alerts straight away
+            if seen {
+                delete(s.changes.alerts, key)
+                changed = true
+            }
+            continue
+        }
+
+        current := AnomalyAlert{Active: true, AlertedAt: previous.AlertedAt}
+        if !previous.Active || now.Sub(previous.AlertedAt) >= AnomalyAlertCooldown {
+            current.AlertedAt = now
+            added = append(added, event)
+        }
+        if current != previous {
+            s.changes.alerts[key] = current
+            changed = true
+        }
+    }
+
+    if changed {
+        if err := writeJSONFile(s.changes.alertsPath, s.changes.alerts); err != nil {
+            return nil, err
+        }
+    }
+    for i, event := range added {
+        if err := s.appendChange(event); err != nil {
+            return added[:i], err
+        }
+    }
+    return added, nil
+}
@@ -0,0 +1,54 @@
+package storage
+
+import (
+    "io"
+    "log"
+    "testing"
+    "time"
+    "anondd/utils/clock"
+    "anondd/utils/models"
+)
+
+func TestRecordAnomaliesAlertsOnTransitions(t *testing.T) {
+    start := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+    fake := clock.NewFake(start)
+    store := NewAgentStore(t.TempDir(), log.New(io.Discard, "", 0))
+    store.SetClock(fake)
+
+    agent := &models.Agent{SourceID: 42, Name: "Test"}
+    spike := []models.ChangeEvent{{SourceID: 42, Type: models.ChangeVolumeSpike, Summary: "spike"}}
+    record := func(detected []models.ChangeEvent) int {
+        t.Helper()
+        added, err := store.RecordAnomalies(agent, detected)
+        if err != nil {
+            t.Fatalf("RecordAnomalies: %v", err)
+        }
+        return len(added)
+    }
+
+    if n := record(spike); n != 1 {
+        t.Fatalf("new anomaly added %d events, want 1", n)
+    }
+    fake.Advance(time.Hour)
+    if n := record(spike); n != 0 {
+        t.Errorf("held anomaly alerted again within the cooldown (%d events)", n)
+    }
+    fake.Advance(AnomalyAlertCooldown)
+    if n := record(spike); n != 1 {
+        t.Errorf("held anomaly added %d events after the cooldown, want 1", n)
+    }
+    fake.Advance(time.Hour)
+    if n := record(nil); n != 0 {
+        t.Errorf("cleared anomaly added %d events", n)
+    }
+    if n := record(spike); n != 1 {
+        t.Errorf("anomaly returning after clearing added %d events, want 1", n)
+    }
+
+    // The state survives a restart
+    reopened := NewAgentStore(store.BaseDir, log.New(io.Discard, "", 0))
+    reopened.SetClock(fake)
+    if added, err := reopened.RecordAnomalies(agent, spike); err != nil || len(added) != 0 {
+        t.Errorf("RecordAnomalies after reopening = %d events, %v; want none", len(added), err)
+    }
+}
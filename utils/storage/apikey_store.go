@@ -0,0 +1,122 @@
+package storage
+
+import (
+    "crypto/rand"
+    "crypto/sha256"
+    "encoding/hex"
+    "fmt"
+    "path/filepath"
+    "strconv"
+    "strings"
+    "sync"
+    "time"
+    "anondd/utils/clock"
+)
+
+// UserKeyPrefix starts every personal API key, so leaked keys are easy to spot
+const UserKeyPrefix = "ddk_"
+
+// UserAPIKey links a personal API key to the Telegram user who made it with
+// /apikey. Only a hash of the key is kept.
+type UserAPIKey struct {
+    UserID    int64     `json:"user_id"`
+    Bot       string    `json:"bot"`  // Bot the key was made on, whose watchlist and alerts it serves
+    Hash      string    `json:"hash"` // SHA-256 of the key
+    Hint      string    `json:"hint"` // The key's first characters, to tell keys apart
+    CreatedAt time.Time `json:"created_at"`
+}
+
+// UserKeyStore persists personal API keys, at most one per user
+type UserKeyStore struct {
+    path  string
+    mu    sync.Mutex
+    keys  map[string]UserAPIKey // By user ID
+    clock clock.Clock
+}
+
+// NewUserKeyStore creates a key store backed by user_api_keys.json in baseDir
+func NewUserKeyStore(baseDir string) (*UserKeyStore, error) {
+    store := &UserKeyStore{
+        path: filepath.Join(baseDir, "user_api_keys.json"),
+        keys:  make(map[string]UserAPIKey),
+        clock: clock.Real(),
+    }
+    if err := readJSONFile(store.path, &store.keys); err != nil {
+        return store, err
+    }
+    return store, nil
+}
+
+// SetClock makes the store read time from c for key creation times. Call it
+// before the store is used.
+func (s *UserKeyStore) SetClock(c clock.Clock) {
+    s.mu.Lock()
+    defer s.mu.Unlock()
+    s.clock = c
+}
+
+// Issue makes a new key for the user on bot, replacing any earlier one, and
+// returns it. The key can't be recovered later.
+func (s *UserKeyStore) Issue(userID int64, bot string) (string, UserAPIKey, error) {
+    secret := make([]byte, 24)
+    if _, err := rand.Read(secret); err != nil {
+        return "", UserAPIKey{}, fmt.Errorf("failed to generate API key: %w", err)
+    }
+    key := UserKeyPrefix + hex.EncodeToString(secret)
+    record := UserAPIKey{
+        UserID:    userID,
+        Bot:       bot,
+        Hash:      hashUserKey(key),
+        Hint:      key[:len(UserKeyPrefix)+6],
+    }
+
+    s.mu.Lock()
+    defer s.mu.Unlock()
+    record.CreatedAt = s.clock.Now()
+    s.keys[strconv.FormatInt(userID, 10)] = record
+    if err := writeJSONFile(s.path, s.keys); err != nil {
+        return "", UserAPIKey{}, err
+    }
+    return key, record, nil
+}
+
+// Revoke deletes the user's key; it returns false if they had none
+func (s *UserKeyStore) Revoke(userID int64) (bool, error) {
+    s.mu.Lock()
+    defer s.mu.Unlock()
+    id := strconv.FormatInt(userID, 10)
+    if _, exists := s.keys[id]; !exists {
+        return false, nil
+    }
+    delete(s.keys, id)
+    return true, writeJSONFile(s.path, s.keys)
+}
+
+// Get returns the user's key record
+func (s *UserKeyStore) Get(userID int64) (UserAPIKey, bool) {
+    s.mu.Lock()
+    defer s.mu.Unlock()
+    record, exists := s.keys[strconv.FormatInt(userID, 10)]
+    return record, exists
+}
+
+// Lookup returns the record of a presented key
+func (s *UserKeyStore) Lookup(key string) (UserAPIKey, bool) {
+    if !strings.HasPrefix(key, UserKeyPrefix) {
+        return UserAPIKey{}, false
+    }
+    hash := hashUserKey(key)
+    s.mu.Lock()
+    defer s.mu.Unlock()
+    for _, record := range s.keys {
+        if record.Hash == hash {
+            return record, true
+        }
+    }
+    return UserAPIKey{}, false
+}
+
+func hashUserKey(key string) string {
+    sum := sha256.Sum256([]byte(key))
+    return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,231 @@
+package storage
+
+import (
+    "encoding/json"
+    "fmt"
+    "os"
+    "path/filepath"
+    "strconv"
+    "sync"
+    "time"
+    "anondd/utils/events"
+    "anondd/utils/models"
+)
+
+// maxChangeEvents caps the persisted change feed
+const maxChangeEvents = 1000
+
+// maxDescriptionVersions caps the descriptions kept per agent; older ones are dropped
+const maxDescriptionVersions = 20
+
+// DescriptionVersion is one observed description for an agent
+type DescriptionVersion struct {
+    Description string    `json:"description"`
+    SeenAt      time.Time `json:"seen_at"`
+}
+
+// changeLog persists description and launch stage history, anomaly alert
+// state and the change feed
+type changeLog struct {
+    mu           sync.Mutex
+    historyPath  string
+    stagesPath   string
+    alertsPath   string
+    feedPath     string
+    descriptions map[string][]DescriptionVersion
+    stages       map[string][]StageVersion
+    alerts       map[string]AnomalyAlert
+    events       []models.ChangeEvent
+    loaded       bool
+}
+
+func newChangeLog(baseDir string) *changeLog {
+    return &changeLog{
+        historyPath:  filepath.Join(baseDir, "descriptions.json"),
+        stagesPath:   filepath.Join(baseDir, "stages.json"),
+        alertsPath:   filepath.Join(baseDir, "anomaly_alerts.json"),
+        feedPath:     filepath.Join(baseDir, "changes.json"),
+        descriptions: make(map[string][]DescriptionVersion),
+        stages:       make(map[string][]StageVersion),
+        alerts:       make(map[string]AnomalyAlert),
+    }
+}
+
+// ensureLoaded reads the files on first use; callers must hold mu
+func (c *changeLog) ensureLoaded() error {
+    if c.loaded {
+        return nil
+    }
+    if err := readJSONFile(c.historyPath, &c.descriptions); err != nil {
+        return err
+    }
+    if err := readJSONFile(c.stagesPath, &c.stages); err != nil {
+        return err
+    }
+    if err := readJSONFile(c.alertsPath, &c.alerts); err != nil {
+        return err
+    }
+    if err := readJSONFile(c.feedPath, &c.events); err != nil {
+        return err
+    }
+    c.loaded = true
+    return nil
+}
+
+// RecordDescription stores the description seen for a scraped source ID and,
+// if it differs from the previous one, appends a description_updated event
+func (s *AgentStore) RecordDescription(agent *models.Agent) (*models.ChangeEvent, error) {
+    event, err := s.recordDescription(agent)
+    if event != nil {
+        s.events.Publish(events.AgentChanged{Change: *event})
+    }
+    return event, err
+}
+
+func (s *AgentStore) recordDescription(agent *models.Agent) (*models.ChangeEvent, error) {
+    if agent.Description == "" {
+        return nil, nil
+    }
+
+    s.changes.mu.Lock()
+    defer s.changes.mu.Unlock()
+    if err := s.changes.ensureLoaded(); err != nil {
+        return nil, err
+    }
+
+    key := strconv.Itoa(agent.SourceID)
+    history := s.changes.descriptions[key]
+    now := s.clock.Now()
+
+    if len(history) > 0 && history[len(history)-1].Description == agent.Description {
+        return nil, nil
+    }
+    versions := append(history, DescriptionVersion{Description: agent.Description, SeenAt: now})
+    if len(versions) > maxDescriptionVersions {
+        versions = append([]DescriptionVersion(nil), versions[len(versions)-maxDescriptionVersions:]...)
+    }
+    s.changes.descriptions[key] = versions
+    if err := writeJSONFile(s.changes.historyPath, s.changes.descriptions); err != nil {
+        return nil, err
+    }
+
+    // The first description seen is a baseline, not a change
+    if len(history) == 0 {
+        return nil, nil
+    }
+
+    before := history[len(history)-1].Description
+    added, removed := models.DescriptionDiff(before, agent.Description)
+    event := models.ChangeEvent{
+        ID:        fmt.Sprintf("%s-%d", key, now.UnixNano()),
+        AgentID:   agent.ID,
+        SourceID:  agent.SourceID,
+        AgentName: agent.Name,
+        Type:      models.ChangeDescriptionUpdated,
+        Before:    before,
+        After:     agent.Description,
+        Added:     added,
+        Removed:   removed,
+        At:        now,
+    }
+    if err := s.appendChange(event); err != nil {
+        return nil, err
+    }
+    return &event, nil
+}
+
+// DescriptionHistory returns the latest descriptions recorded for a source ID,
+// up to maxDescriptionVersions, oldest first
+func (s *AgentStore) DescriptionHistory(sourceID int) ([]DescriptionVersion, error) {
+    s.changes.mu.Lock()
+    defer s.changes.mu.Unlock()
+    if err := s.changes.ensureLoaded(); err != nil {
+        return nil, err
+    }
+    return append([]DescriptionVersion(nil), s.changes.descriptions[strconv.Itoa(sourceID)]...), nil
+}
+
+// AddChange appends an event to the change feed and publishes it
+func (s *AgentStore) AddChange(event models.ChangeEvent) error {
+    s.changes.mu.Lock()
+    err := s.changes.ensureLoaded()
+    if err == nil {
+        err = s.appendChange(event)
+    }
+    s.changes.mu.Unlock()
+    if err != nil {
+        return err
+    }
+    s.events.Publish(events.AgentChanged{Change: event})
+    return nil
+}
+
+// appendChange adds an event to the feed; callers must hold changes.mu
+func (s *AgentStore) appendChange(event models.ChangeEvent) error {
+    s.changes.events = append(s.changes.events, event)
+    if len(s.changes.events) > maxChangeEvents {
+        s.changes.events = s.changes.events[len(s.changes.events)-maxChangeEvents:]
+    }
+    return writeJSONFile(s.changes.feedPath, s.changes.events)
+}
+
+// GetChanges returns change events that happened after since, oldest first
+func (s *AgentStore) GetChanges(since time.Time) ([]models.ChangeEvent, error) {
+    s.changes.mu.Lock()
+    defer s.changes.mu.Unlock()
+    if err := s.changes.ensureLoaded(); err != nil {
+        return nil, err
+    }
+
+    var events []models.ChangeEvent
+    for _, event := range s.changes.events {
+        if event.At.After(since) {
+            events = append(events, event)
+        }
+    }
+    return events, nil
+}
+
+// SetChangeSummary attaches a generated summary to a change event
+func (s *AgentStore) SetChangeSummary(eventID, summary string) error {
+    s.changes.mu.Lock()
+    defer s.changes.mu.Unlock()
+    if err := s.changes.ensureLoaded(); err != nil {
+        return err
+    }
+
+    for i := range s.changes.events {
+        if s.changes.events[i].ID == eventID {
+            s.changes.events[i].Summary = summary
+            return writeJSONFile(s.changes.feedPath, s.changes.events)
+        }
+    }
+    return fmt.Errorf("change event %s not found", eventID)
+}
+
+// readJSONFile unmarshals path into v, leaving v untouched if the file does not exist
+func readJSONFile(path string, v interface{}) error {
+    data, err := os.ReadFile(path)
+    if os.IsNotExist(err) {
+        return nil
+    }
+    if err != nil {
+        return fmt.Errorf("failed to read %s: %w", filepath.Base(path), err)
+    }
+    if err := json.Unmarshal(data, v); err != nil {
+        return fmt.Errorf("failed to unmarshal %s: %w", filepath.Base(path), err)
+    }
+    return nil
+}
+
+// writeJSONFile marshals v to path, creating the parent directory if needed
+func writeJSONFile(path string, v interface{}) error {
+    data, err := json.MarshalIndent(v, "", "  ")
+    if err != nil {
+        return fmt.Errorf("failed to marshal %s: %w", filepath.Base(path), err)
+    }
+    if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+        return fmt.Errorf("failed to create directory: %w", err)
+    }
+    return os.WriteFile(path, data, 0644)
+}
@@ -0,0 +1,54 @@
+package storage
+
+import (
+    "path/filepath"
+    "strconv"
+    "sync"
+    "anondd/utils/models"
+)
+
+// ChatSettingsStore persists per-chat settings, shared by every bot
+type ChatSettingsStore struct {
+    path     string
+    mu       sync.RWMutex
+    settings map[string]models.ChatSettings // By chat ID
+}
+
+// NewChatSettingsStore creates a chat settings store backed by chat_settings.json in baseDir
+func NewChatSettingsStore(baseDir string) (*ChatSettingsStore, error) {
+    store := &ChatSettingsStore{
+        path:     filepath.Join(baseDir, "chat_settings.json"),
+        settings: make(map[string]models.ChatSettings),
+    }
+    if err := readJSONFile(store.path, &store.settings); err != nil {
+        return store, err
+    }
+    if store.settings == nil {
+        store.settings = make(map[string]models.ChatSettings)
+    }
+    return store, nil
+}
+
+// Get returns a chat's settings, the zero value if it has none
+func (s *ChatSettingsStore) Get(chatID int64) models.ChatSettings {
+    s.mu.RLock()
+    defer s.mu.RUnlock()
+    return s.settings[strconv.FormatInt(chatID, 10)]
+}
+
+// Filter returns the agent filter of a chat
+func (s *ChatSettingsStore) Filter(chatID int64) models.AgentFilter {
+    return s.Get(chatID).Filter
+}
+
+// Update applies change to a chat's settings and saves them
+func (s *ChatSettingsStore) Update(chatID int64, change func(*models.ChatSettings)) (models.ChatSettings, error) {
+    s.mu.Lock()
+    defer s.mu.Unlock()
+
+    key := strconv.FormatInt(chatID, 10)
+    settings := s.settings[key]
+    change(&settings)
+    s.settings[key] = settings
+    return settings, writeJSONFile(s.path, s.settings)
+}
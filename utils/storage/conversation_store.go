@@ -0,0 +1,200 @@
+package storage
+
+import (
+    "context"
+    "fmt"
+    "log"
+    "path/filepath"
+    "sync"
+    "time"
+    "anondd/utils/clock"
+)
+
+const (
+    // DefaultConversationRetention is how long conversation turns are kept
+    DefaultConversationRetention = 30 * 24 * time.Hour
+
+    // ConversationExpiryInterval is how often expired turns are swept
+    ConversationExpiryInterval = time.Hour
+
+    // maxConversationTurns caps the turns kept per chat, oldest dropped first
+    maxConversationTurns = 500
+)
+
+// Conversation turn kinds
+const (
+    TurnChat = "chat" // Free-form message to the bot
+    TurnAsk  = "ask"  // /ask question about an agent
+)
+
+// ConversationTurn is one user message and the bot's reply
+type ConversationTurn struct {
+    UserID   int64     `json:"user_id"`
+    Username string    `json:"username,omitempty"`
+    Kind     string    `json:"kind"`
+    Agent    string    `json:"agent,omitempty"` // Agent name for /ask turns
+    Message  string    `json:"message"`
+    Reply    string    `json:"reply"`
+    At       time.Time `json:"at"`
+}
+
+// ConversationStore persists what users said to the bot and what it
+// answered, per bot and chat, expiring turns older than the retention period
+type ConversationStore struct {
+    path      string
+    mu        sync.Mutex
+    chats     map[string][]ConversationTurn
+    retention time.Duration
+    clock     clock.Clock
+}
+
+// NewConversationStore creates a conversation store backed by conversations.json in baseDir
+func NewConversationStore(baseDir string) (*ConversationStore, error) {
+    store := &ConversationStore{
+        path:      filepath.Join(baseDir, "conversations.json"),
+        chats:     make(map[string][]ConversationTurn),
+        retention: DefaultConversationRetention,
+        clock:     clock.Real(),
+    }
+    if err := readJSONFile(store.path, &store.chats); err != nil {
+        return store, err
+    }
+    if store.chats == nil {
+        store.chats = make(map[string][]ConversationTurn)
+    }
+    return store, nil
+}
+
+func conversationKey(bot string, chatID int64) string {
+    return fmt.Sprintf("%s:%d", bot, chatID)
+}
+
+// SetRetention changes how long turns are kept; older turns are dropped at
+// the next write
+func (s *ConversationStore) SetRetention(retention time.Duration) {
+    s.mu.Lock()
+    defer s.mu.Unlock()
+    s.retention = retention
+}
+
+// SetClock makes the store read time from c for expiry. Call it before the
+// store is used.
+func (s *ConversationStore) SetClock(c clock.Clock) {
+    s.mu.Lock()
+    defer s.mu.Unlock()
+    s.clock = c
+}
+
+// Append records a turn, expiring old turns in every chat
+func (s *ConversationStore) Append(bot string, chatID int64, turn ConversationTurn) error {
+    s.mu.Lock()
+    defer s.mu.Unlock()
+
+    key := conversationKey(bot, chatID)
+    turns := append(s.chats[key], turn)
+    if len(turns) > maxConversationTurns {
+        turns = turns[len(turns)-maxConversationTurns:]
+    }
+    s.chats[key] = turns
+    s.expire(s.clock.Now())
+    return writeJSONFile(s.path, s.chats)
+}
+
+// Turns returns a user's unexpired turns in a chat, oldest first
+func (s *ConversationStore) Turns(bot string, chatID, userID int64) []ConversationTurn {
+    s.mu.Lock()
+    defer s.mu.Unlock()
+
+    cutoff := s.clock.Now().Add(-s.retention)
+    var turns []ConversationTurn
+    for _, turn := range s.chats[conversationKey(bot, chatID)] {
+        if turn.UserID == userID && turn.At.After(cutoff) {
+            turns = append(turns, turn)
+        }
+    }
+    return turns
+}
+
+// Forget deletes a user's turns in a chat and returns how many were removed
+func (s *ConversationStore) Forget(bot string, chatID, userID int64) (int, error) {
+    s.mu.Lock()
+    defer s.mu.Unlock()
+
+    key := conversationKey(bot, chatID)
+    var kept []ConversationTurn
+    for _, turn := range s.chats[key] {
+        if turn.UserID != userID {
+            kept = append(kept, turn)
+        }
+    }
+    removed := len(s.chats[key]) - len(kept)
+    if removed == 0 {
+        return 0, nil
+    }
+    if len(kept) == 0 {
+        delete(s.chats, key)
+    } else {
+        s.chats[key] = kept
+    }
+    return removed, writeJSONFile(s.path, s.chats)
+}
+
+// Expire drops turns older than the retention period and returns how many
+// were removed
+func (s *ConversationStore) Expire() (int, error) {
+    s.mu.Lock()
+    defer s.mu.Unlock()
+
+    removed := s.expire(s.clock.Now())
+    if removed == 0 {
+        return 0, nil
+    }
+    return removed, writeJSONFile(s.path, s.chats)
+}
+
+// expire is Expire without the write; callers must hold mu
+func (s *ConversationStore) expire(now time.Time) int {
+    cutoff := now.Add(-s.retention)
+    removed := 0
+    for key, turns := range s.chats {
+        // Turns are appended in time order, so the expired ones lead
+        i := 0
+        for i < len(turns) && !turns[i].At.After(cutoff) {
+            i++
+        }
+        if i == 0 {
+            continue
+        }
+        removed += i
+        if i == len(turns) {
+            delete(s.chats, key)
+        } else {
+            s.chats[key] = turns[i:]
+        }
+    }
+    return removed
+}
+
+// StartExpiry sweeps expired turns every interval until ctx is cancelled, so
+// chats nobody writes to still lose old turns
+func (s *ConversationStore) StartExpiry(ctx context.Context, interval time.Duration, logger *log.Logger) {
+    go func() {
+        s.mu.Lock()
+        ticker := s.clock.NewTicker(interval)
+        s.mu.Unlock()
+        defer ticker.Stop()
+        for {
+            select {
+            case <-ticker.C():
+                removed, err := s.Expire()
+                if err != nil {
+                    logger.Printf("Error expiring conversations: %v", err)
+                } else if removed > 0 {
+                    logger.Printf("Expired %d conversation turns", removed)
+                }
+            case <-ctx.Done():
+                return
+            }
+        }
+    }()
+}
@@ -0,0 +1,42 @@
+package storage
+
+import (
+    "testing"
+    "time"
+    "anondd/utils/clock"
+)
+
+func TestConversationExpiryFollowsClock(t *testing.T) {
+    start := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+    fake := clock.NewFake(start)
+    store, err := NewConversationStore(t.TempDir())
+    if err != nil {
+        t.Fatalf("NewConversationStore: %v", err)
+    }
+    store.SetClock(fake)
+    store.SetRetention(24 * time.Hour)
+
+    if err := store.Append("bot", 1, ConversationTurn{UserID: 7, Kind: TurnChat, Message: "old", At: start}); err != nil {
+        t.Fatalf("Append: %v", err)
+    }
+    fake.Advance(12 * time.Hour)
+    if err := store.Append("bot", 1, ConversationTurn{UserID: 7, Kind: TurnChat, Message: "new", At: fake.Now()}); err != nil {
+        t.Fatalf("Append: %v", err)
+    }
+    if turns := store.Turns("bot", 1, 7); len(turns) != 2 {
+        t.Fatalf("Turns() = %d turns before the retention period, want 2", len(turns))
+    }
+
+    fake.Advance(13 * time.Hour)
+    removed, err := store.Expire()
+    if err != nil {
+        t.Fatalf("Expire: %v", err)
+    }
+    if removed != 1 {
+        t.Errorf("Expire() removed %d turns, want 1", removed)
+    }
+    turns := store.Turns("bot", 1, 7)
+    if len(turns) != 1 || turns[0].Message != "new" {
+        t.Errorf("Turns() = %+v, want only the new turn", turns)
+    }
+}
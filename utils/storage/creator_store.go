@@ -0,0 +1,41 @@
+package storage
+
+import (
+    "context"
+    "strings"
+    "anondd/utils/models"
+)
+
+// Creators groups the indexed agents by developer wallet
+func (s *AgentStore) Creators(ctx context.Context) ([]models.Creator, error) {
+    index, err := s.GetIndexContext(ctx)
+    if err != nil {
+        return nil, err
+    }
+    return models.GroupByCreator(index.Agents), nil
+}
+
+// CreatorOf returns the agents launched from a developer wallet; the bool is
+// false when no indexed agent has that creator
+func (s *AgentStore) CreatorOf(ctx context.Context, address string) (models.Creator, bool, error) {
+    address = strings.ToLower(address)
+    if address == "" {
+        return models.Creator{}, false, nil
+    }
+    index, err := s.GetIndexContext(ctx)
+    if err != nil {
+        return models.Creator{}, false, err
+    }
+
+    var launched []models.AgentSummary
+    for _, summary := range index.Agents {
+        if strings.ToLower(summary.Creator) == address {
+            launched = append(launched, summary)
+        }
+    }
+    creators := models.GroupByCreator(launched)
+    if len(creators) == 0 {
+        return models.Creator{}, false, nil
+    }
+    return creators[0], true, nil
+}
@@ -0,0 +1,270 @@
+package storage
+
+import (
+    "crypto/rand"
+    "fmt"
+    "math/big"
+    "path/filepath"
+    "sort"
+    "strconv"
+    "strings"
+    "sync"
+    "time"
+)
+
+// Where a premium entitlement came from
+const (
+    EntitlementInvite = "invite"
+    EntitlementStars  = "stars"
+    EntitlementAdmin  = "admin"
+)
+
+const (
+    inviteCodeLength   = 10
+    inviteCodeAlphabet = "ABCDEFGHJKLMNPQRSTUVWXYZ23456789"
+)
+
+// Entitlement is a Telegram user's premium access
+type Entitlement struct {
+    UserID    int64     `json:"user_id"`
+    Source    string    `json:"source"`              // Source of the latest grant
+    Reference string    `json:"reference,omitempty"` // Invite code or payment charge ID of the latest grant
+    GrantedAt time.Time `json:"granted_at"`
+    ExpiresAt time.Time `json:"expires_at,omitempty"` // Zero never expires
+}
+
+// Active reports whether the entitlement is in effect at now
+func (e Entitlement) Active(now time.Time) bool {
+    return e.ExpiresAt.IsZero() || now.Before(e.ExpiresAt)
+}
+
+// InviteCode grants premium to the users who redeem it, up to MaxUses of them
+type InviteCode struct {
+    Code       string    `json:"code"`
+    Days       int       `json:"days"` // Premium days granted; 0 never expires
+    MaxUses    int       `json:"max_uses"`
+    RedeemedBy []int64   `json:"redeemed_by,omitempty"`
+    CreatedBy  int64     `json:"created_by,omitempty"`
+    CreatedAt  time.Time `json:"created_at"`
+}
+
+// Remaining is how many more users can redeem the code
+func (c InviteCode) Remaining() int {
+    return max(c.MaxUses-len(c.RedeemedBy), 0)
+}
+
+// entitlementData is the persisted state of the entitlement store
+type entitlementData struct {
+    Users    map[string]*Entitlement `json:"users"`
+    Invites  map[string]*InviteCode  `json:"invites"`
+    Payments map[string]int64        `json:"payments"` // Charge IDs already granted, to the paying user
+}
+
+// EntitlementStore persists which users have premium access, the invite
+// codes that grant it and the payments already credited
+type EntitlementStore struct {
+    path string
+    mu   sync.Mutex
+    data entitlementData
+}
+
+// NewEntitlementStore creates an entitlement store backed by entitlements.json in baseDir
+func NewEntitlementStore(baseDir string) (*EntitlementStore, error) {
+    store := &EntitlementStore{path: filepath.Join(baseDir, "entitlements.json")}
+    err := readJSONFile(store.path, &store.data)
+    if store.data.Users == nil {
+        store.data.Users = make(map[string]*Entitlement)
+    }
+    if store.data.Invites == nil {
+        store.data.Invites = make(map[string]*InviteCode)
+    }
+    if store.data.Payments == nil {
+        store.data.Payments = make(map[string]int64)
+    }
+    return store, err
+}
+
+// Premium reports whether the user has active premium access at now
+func (s *EntitlementStore) Premium(userID int64, now time.Time) bool {
+    entitlement, ok := s.Get(userID)
+    return ok && entitlement.Active(now)
+}
+
+// Get returns the user's entitlement, active or expired
+func (s *EntitlementStore) Get(userID int64) (Entitlement, bool) {
+    s.mu.Lock()
+    defer s.mu.Unlock()
+    entitlement, ok := s.data.Users[strconv.FormatInt(userID, 10)]
+    if !ok {
+        return Entitlement{}, false
+    }
+    return *entitlement, true
+}
+
+// Grant gives the user days of premium from now, or from the end of their
+// current access if it is still running; 0 days never expires
+func (s *EntitlementStore) Grant(userID int64, source, reference string, days int, now time.Time) (Entitlement, error) {
+    s.mu.Lock()
+    defer s.mu.Unlock()
+    entitlement := s.grant(userID, source, reference, days, now)
+    return entitlement, writeJSONFile(s.path, s.data)
+}
+
+// grant updates the user's entitlement in memory; callers must hold mu
+func (s *EntitlementStore) grant(userID int64, source, reference string, days int, now time.Time) Entitlement {
+    key := strconv.FormatInt(userID, 10)
+    entitlement, exists := s.data.Users[key]
+    if !exists {
+        entitlement = &Entitlement{UserID: userID}
+        s.data.Users[key] = entitlement
+    }
+
+    switch {
+    case days <= 0:
+        entitlement.ExpiresAt = time.Time{}
+    case exists && entitlement.ExpiresAt.IsZero():
+        // Already permanent
+    case exists && entitlement.Active(now):
+        entitlement.ExpiresAt = entitlement.ExpiresAt.AddDate(0, 0, days)
+    default:
+        entitlement.ExpiresAt = now.AddDate(0, 0, days)
+    }
+    entitlement.Source = source
+    entitlement.Reference = reference
+    entitlement.GrantedAt = now
+    return *entitlement
+}
+
+// Revoke removes the user's premium access; it returns false if they had none
+func (s *EntitlementStore) Revoke(userID int64) (bool, error) {
+    s.mu.Lock()
+    defer s.mu.Unlock()
+    key := strconv.FormatInt(userID, 10)
+    if _, exists := s.data.Users[key]; !exists {
+        return false, nil
+    }
+    delete(s.data.Users, key)
+    return true, writeJSONFile(s.path, s.data)
+}
+
+// List returns every entitlement, soonest to expire first and permanent ones last
+func (s *EntitlementStore) List() []Entitlement {
+    s.mu.Lock()
+    defer s.mu.Unlock()
+    list := make([]Entitlement, 0, len(s.data.Users))
+    for _, entitlement := range s.data.Users {
+        list = append(list, *entitlement)
+    }
+    sort.Slice(list, func(i, j int) bool {
+        a, b := list[i].ExpiresAt, list[j].ExpiresAt
+        if a.IsZero() != b.IsZero() {
+            return b.IsZero()
+        }
+        if !a.Equal(b) {
+            return a.Before(b)
+        }
+        return list[i].UserID < list[j].UserID
+    })
+    return list
+}
+
+// RecordPayment grants days of premium for a payment. A charge ID that was
+// already credited grants nothing and returns false.
+func (s *EntitlementStore) RecordPayment(chargeID string, userID int64, days int, now time.Time) (Entitlement, bool, error) {
+    s.mu.Lock()
+    defer s.mu.Unlock()
+    if _, credited := s.data.Payments[chargeID]; credited {
+        entitlement := s.data.Users[strconv.FormatInt(userID, 10)]
+        if entitlement == nil {
+            return Entitlement{}, false, nil
+        }
+        return *entitlement, false, nil
+    }
+    s.data.Payments[chargeID] = userID
+    entitlement := s.grant(userID, EntitlementStars, chargeID, days, now)
+    return entitlement, true, writeJSONFile(s.path, s.data)
+}
+
+// CreateInvite stores a new random invite code granting days of premium to
+// up to maxUses users
+func (s *EntitlementStore) CreateInvite(days, maxUses int, createdBy int64, now time.Time) (InviteCode, error) {
+    s.mu.Lock()
+    defer s.mu.Unlock()
+    for attempt := 0; attempt < 5; attempt++ {
+        code, err := newInviteCode()
+        if err != nil {
+            return InviteCode{}, err
+        }
+        if _, exists := s.data.Invites[code]; exists {
+            continue
+        }
+        invite := &InviteCode{Code: code, Days: days, MaxUses: maxUses, CreatedBy: createdBy, CreatedAt: now}
+        s.data.Invites[code] = invite
+        return *invite, writeJSONFile(s.path, s.data)
+    }
+    return InviteCode{}, fmt.Errorf("failed to allocate a unique invite code")
+}
+
+// Redeem grants the invite's premium days to the user. It fails with
+// ErrNotFound for an unknown code, ErrRedeemed if the user already redeemed
+// it and ErrUsedUp once every use is taken.
+func (s *EntitlementStore) Redeem(code string, userID int64, now time.Time) (Entitlement, error) {
+    s.mu.Lock()
+    defer s.mu.Unlock()
+    code = strings.ToUpper(strings.TrimSpace(code))
+    invite, exists := s.data.Invites[code]
+    if !exists {
+        return Entitlement{}, fmt.Errorf("invite code %s: %w", code, ErrNotFound)
+    }
+    for _, id := range invite.RedeemedBy {
+        if id == userID {
+            return Entitlement{}, fmt.Errorf("invite code %s: %w", code, ErrRedeemed)
+        }
+    }
+    if invite.Remaining() == 0 {
+        return Entitlement{}, fmt.Errorf("invite code %s: %w", code, ErrUsedUp)
+    }
+    invite.RedeemedBy = append(invite.RedeemedBy, userID)
+    entitlement := s.grant(userID, EntitlementInvite, code, invite.Days, now)
+    return entitlement, writeJSONFile(s.path, s.data)
+}
+
+// Invites returns every invite code, newest first
+func (s *EntitlementStore) Invites() []InviteCode {
+    s.mu.Lock()
+    defer s.mu.Unlock()
+    invites := make([]InviteCode, 0, len(s.data.Invites))
+    for _, invite := range s.data.Invites {
+        invites = append(invites, *invite)
+    }
+    sort.Slice(invites, func(i, j int) bool {
+        return invites[i].CreatedAt.After(invites[j].CreatedAt)
+    })
+    return invites
+}
+
+// DeleteInvite removes an invite code; users who redeemed it keep their
+// access. It returns false if there was no such code.
+func (s *EntitlementStore) DeleteInvite(code string) (bool, error) {
+    s.mu.Lock()
+    defer s.mu.Unlock()
+    code = strings.ToUpper(strings.TrimSpace(code))
+    if _, exists := s.data.Invites[code]; !exists {
+        return false, nil
+    }
+    delete(s.data.Invites, code)
+    return true, writeJSONFile(s.path, s.data)
+}
+
+func newInviteCode() (string, error) {
+    code := make([]byte, inviteCodeLength)
+    max := big.NewInt(int64(len(inviteCodeAlphabet)))
+    for i := range code {
+        n, err := rand.Int(rand.Reader, max)
+        if err != nil {
+            return "", fmt.Errorf("failed to generate invite code: %w", err)
+        }
+        code[i] = inviteCodeAlphabet[n.Int64()]
+    }
+    return string(code), nil
+}
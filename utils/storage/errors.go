@@ -0,0 +1,12 @@
+package storage
+
+import "errors"
+
+// Typed storage errors; match them with errors.Is
+var (
+    ErrNotFound = errors.New("not found")
+    ErrCorrupt  = errors.New("corrupt data")
+    ErrUsedUp   = errors.New("used up")
+    ErrRedeemed = errors.New("already redeemed")
+    ErrFull     = errors.New("limit reached")
+)
@@ -0,0 +1,224 @@
+package storage
+
+import (
+    "encoding/json"
+    "fmt"
+    "log"
+    "os"
+    "path/filepath"
+    "sync"
+    "time"
+    "anondd/utils/models"
+)
+
+// Retry budget for IDs that keep failing to fetch or parse
+const (
+    QuarantineThreshold = 3
+    quarantineBaseDelay = 24 * time.Hour
+    quarantineMaxDelay  = 7 * 24 * time.Hour
+
+    // failureSaveDelay is how long failure records may sit unsaved, so a
+    // cycle with many failures rewrites failures.json a few times at most
+    failureSaveDelay = 10 * time.Second
+)
+
+// FailureRecord tracks consecutive scrape failures for an agent ID
+type FailureRecord struct {
+    ConsecutiveFailures int            `json:"consecutive_failures"`
+    LastFailure         time.Time      `json:"last_failure"`
+    LastError           string         `json:"last_error"`
+    LastErrorKind       string         `json:"last_error_kind,omitempty"`
+    ErrorKinds          map[string]int `json:"error_kinds,omitempty"` // Failures by kind since the last success
+    RetryAfter          time.Time      `json:"retry_after,omitempty"`
+}
+
+// failureTracker persists failure records to failures.json. Records changed
+// by the scraper are saved together after failureSaveDelay rather than on
+// every change.
+type failureTracker struct {
+    path    string
+    mu      sync.Mutex
+    records map[string]*FailureRecord
+    dirty   bool
+    pending *time.Timer
+    logger  *log.Logger
+}
+
+func newFailureTracker(baseDir string, logger *log.Logger) *failureTracker {
+    return &failureTracker{
+        path:    filepath.Join(baseDir, "failures.json"),
+        records: make(map[string]*FailureRecord),
+        logger:  logger,
+    }
+}
+
+func (t *failureTracker) load() error {
+    data, err := os.ReadFile(t.path)
+    if os.IsNotExist(err) {
+        return nil
+    }
+    if err != nil {
+        return fmt.Errorf("failed to read failures file: %w", err)
+    }
+    if err := json.Unmarshal(data, &t.records); err != nil {
+        return fmt.Errorf("failed to unmarshal failures: %w", err)
+    }
+    return nil
+}
+
+// saveLater marks the records changed and schedules a save if none is
+// pending; callers must hold mu
+func (t *failureTracker) saveLater() {
+    t.dirty = true
+    if t.pending == nil {
+        t.pending = time.AfterFunc(failureSaveDelay, func() {
+            if err := t.flush(); err != nil {
+                t.logger.Printf("Error saving failure records: %v", err)
+            }
+        })
+    }
+}
+
+// flush saves the records now if they changed since the last save
+func (t *failureTracker) flush() error {
+    t.mu.Lock()
+    defer t.mu.Unlock()
+    if t.pending != nil {
+        t.pending.Stop()
+        t.pending = nil
+    }
+    if !t.dirty {
+        return nil
+    }
+    return t.save()
+}
+
+// save writes the records to disk; callers must hold mu
+func (t *failureTracker) save() error {
+    data, err := json.MarshalIndent(t.records, "", "  ")
+    if err != nil {
+        return fmt.Errorf("failed to marshal failures: %w", err)
+    }
+    if err := os.MkdirAll(filepath.Dir(t.path), 0755); err != nil {
+        return fmt.Errorf("failed to create directory: %w", err)
+    }
+    if err := os.WriteFile(t.path, data, 0644); err != nil {
+        return err
+    }
+    t.dirty = false
+    return nil
+}
+
+// quarantineDelay doubles from a day per failure past the threshold, capped at a week
+func quarantineDelay(failures int) time.Duration {
+    delay := quarantineBaseDelay
+    for i := QuarantineThreshold; i < failures && delay < quarantineMaxDelay; i++ {
+        delay *= 2
+    }
+    if delay > quarantineMaxDelay {
+        delay = quarantineMaxDelay
+    }
+    return delay
+}
+
+// RecordFailure counts a failed fetch or parse for an agent ID by kind and,
+// once the retry budget is spent, quarantines it with exponential backoff.
+// The record is saved with others shortly after; Flush saves it at once.
+func (s *AgentStore) RecordFailure(agentID string, cause error) {
+    s.failures.mu.Lock()
+    defer s.failures.mu.Unlock()
+
+    record, exists := s.failures.records[agentID]
+    if !exists {
+        record = &FailureRecord{}
+        s.failures.records[agentID] = record
+    }
+
+    now := s.clock.Now()
+    record.ConsecutiveFailures++
+    record.LastFailure = now
+    if cause != nil {
+        kind := models.ScrapeErrorKind(cause)
+        record.LastError = cause.Error()
+        record.LastErrorKind = kind
+        if record.ErrorKinds == nil {
+            record.ErrorKinds = make(map[string]int)
+        }
+        record.ErrorKinds[kind]++
+    }
+    if record.ConsecutiveFailures >= QuarantineThreshold {
+        record.RetryAfter = now.Add(quarantineDelay(record.ConsecutiveFailures))
+    }
+    s.failures.saveLater()
+}
+
+// RecordSuccess clears the failure history for an agent ID, saved like
+// RecordFailure
+func (s *AgentStore) RecordSuccess(agentID string) {
+    s.failures.mu.Lock()
+    defer s.failures.mu.Unlock()
+
+    if _, exists := s.failures.records[agentID]; !exists {
+        return
+    }
+    delete(s.failures.records, agentID)
+    s.failures.saveLater()
+}
+
+// IsQuarantined reports whether an agent ID should be skipped until its retry time
+func (s *AgentStore) IsQuarantined(agentID string) bool {
+    s.failures.mu.Lock()
+    defer s.failures.mu.Unlock()
+
+    record, exists := s.failures.records[agentID]
+    return exists && s.clock.Now().Before(record.RetryAfter)
+}
+
+// QuarantinedCount returns how many IDs are currently quarantined
+func (s *AgentStore) QuarantinedCount() int {
+    s.failures.mu.Lock()
+    defer s.failures.mu.Unlock()
+
+    now := s.clock.Now()
+    count := 0
+    for _, record := range s.failures.records {
+        if now.Before(record.RetryAfter) {
+            count++
+        }
+    }
+    return count
+}
+
+// ResetFailures clears the failure history for one agent ID, or for all IDs if agentID is empty
+func (s *AgentStore) ResetFailures(agentID string) (int, error) {
+    s.failures.mu.Lock()
+    defer s.failures.mu.Unlock()
+
+    cleared := 0
+    if agentID == "" {
+        cleared = len(s.failures.records)
+        s.failures.records = make(map[string]*FailureRecord)
+    } else if _, exists := s.failures.records[agentID]; exists {
+        delete(s.failures.records, agentID)
+        cleared = 1
+    }
+
+    return cleared, s.failures.save()
+}
+
+// FailureRecords returns a copy of the failure records by agent ID
+func (s *AgentStore) FailureRecords() map[string]FailureRecord {
+    s.failures.mu.Lock()
+    defer s.failures.mu.Unlock()
+
+    records := make(map[string]FailureRecord, len(s.failures.records))
+    for id, record := range s.failures.records {
+        copied := *record
+        copied.ErrorKinds = make(map[string]int, len(record.ErrorKinds))
+        for kind, count := range record.ErrorKinds {
+            copied.ErrorKinds[kind] = count
+        }
+        records[id] = copied
+    }
+    return records
+}
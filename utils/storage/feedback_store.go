@@ -0,0 +1,116 @@
+package storage
+
+import (
+    "fmt"
+    "path/filepath"
+    "sort"
+    "sync"
+)
+
+// maxFeedbackVotes caps the per-message vote records kept for de-duplication
+const maxFeedbackVotes = 20000
+
+// VariantFeedback is the thumbs-up/down tally for one prompt variant
+type VariantFeedback struct {
+    PromptKey string `json:"prompt_key"`
+    Variant   string `json:"variant"`
+    Up        int    `json:"up"`
+    Down      int    `json:"down"`
+}
+
+// feedbackVote remembers one user's vote on one message so it can be changed
+type feedbackVote struct {
+    Key     string `json:"key"`
+    Variant string `json:"variant"`
+    Up      bool   `json:"up"`
+}
+
+type feedbackFile struct {
+    Tallies map[string]*VariantFeedback `json:"tallies"`
+    Votes   map[string]feedbackVote     `json:"votes"`
+    Order   []string                    `json:"order"`
+}
+
+// FeedbackStore records user ratings of LLM responses per prompt variant
+type FeedbackStore struct {
+    path string
+    mu   sync.Mutex
+    data feedbackFile
+}
+
+// NewFeedbackStore creates a feedback store backed by feedback.json in baseDir
+func NewFeedbackStore(baseDir string) (*FeedbackStore, error) {
+    store := &FeedbackStore{path: filepath.Join(baseDir, "feedback.json")}
+    err := readJSONFile(store.path, &store.data)
+    if store.data.Tallies == nil {
+        store.data.Tallies = make(map[string]*VariantFeedback)
+    }
+    if store.data.Votes == nil {
+        store.data.Votes = make(map[string]feedbackVote)
+    }
+    return store, err
+}
+
+// Record stores a vote identified by voteID (e.g. chat, message and user).
+// Voting again on the same message replaces the earlier vote.
+func (s *FeedbackStore) Record(voteID, promptKey, variant string, up bool) error {
+    s.mu.Lock()
+    defer s.mu.Unlock()
+
+    if previous, ok := s.data.Votes[voteID]; ok {
+        if previous.Up == up {
+            return nil
+        }
+        s.tally(previous.Key, previous.Variant).add(previous.Up, -1)
+    } else {
+        s.data.Order = append(s.data.Order, voteID)
+        if len(s.data.Order) > maxFeedbackVotes {
+            delete(s.data.Votes, s.data.Order[0])
+            s.data.Order = s.data.Order[1:]
+        }
+    }
+
+    s.data.Votes[voteID] = feedbackVote{Key: promptKey, Variant: variant, Up: up}
+    s.tally(promptKey, variant).add(up, 1)
+    if err := writeJSONFile(s.path, s.data); err != nil {
+        return fmt.Errorf("failed to save feedback: %w", err)
+    }
+    return nil
+}
+
+// Stats returns the tallies sorted by prompt key and variant
+func (s *FeedbackStore) Stats() []VariantFeedback {
+    s.mu.Lock()
+    defer s.mu.Unlock()
+
+    stats := make([]VariantFeedback, 0, len(s.data.Tallies))
+    for _, tally := range s.data.Tallies {
+        stats = append(stats, *tally)
+    }
+    sort.Slice(stats, func(i, j int) bool {
+        if stats[i].PromptKey != stats[j].PromptKey {
+            return stats[i].PromptKey < stats[j].PromptKey
+        }
+        return stats[i].Variant < stats[j].Variant
+    })
+    return stats
+}
+
+// tally returns the counter for a prompt variant; callers must hold mu
+func (s *FeedbackStore) tally(promptKey, variant string) *VariantFeedback {
+    key := promptKey + "/" + variant
+    tally, ok := s.data.Tallies[key]
+    if !ok {
+        tally = &VariantFeedback{PromptKey: promptKey, Variant: variant}
+        s.data.Tallies[key] = tally
+    }
+    return tally
+}
+
+func (f *VariantFeedback) add(up bool, delta int) {
+    if up {
+        f.Up += delta
+    } else {
+        f.Down += delta
+    }
+}
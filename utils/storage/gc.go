@@ -0,0 +1,273 @@
+package storage
+
+import (
+    "context"
+    "encoding/json"
+    "fmt"
+    "log"
+    "os"
+    "path/filepath"
+    "strconv"
+    "strings"
+    "time"
+)
+
+// Defaults for the agent record garbage collector
+const (
+    DefaultGCInterval = 24 * time.Hour
+    // DefaultGCGrace leaves recent records alone, since a running scrape
+    // writes agents before merging them into the index
+    DefaultGCGrace = 24 * time.Hour
+)
+
+// GCReport summarizes a garbage collection run over agent records
+type GCReport struct {
+    DryRun         bool     `json:"dry_run"`
+    Scanned        int      `json:"scanned"`
+    Indexed        int      `json:"indexed"`
+    Orphans        []string `json:"orphans"`    // IDs in no index, current or saved
+    Duplicates     []string `json:"duplicates"` // IDs for an agent indexed under another, newer ID
+    Unindexed      int      `json:"unindexed"`  // Duplicates dropped from the index before archiving
+    Skipped        int      `json:"skipped"`    // Unindexed records within the grace period
+    Archived       int      `json:"archived"`
+    ReclaimedBytes int64    `json:"reclaimed_bytes"`
+    ArchiveDir     string   `json:"archive_dir,omitempty"`
+}
+
+// gcRecord is the part of an agent record that identifies the agent across
+// ID schemes
+type gcRecord struct {
+    Name        string    `json:"name"`
+    Source      string    `json:"source"`
+    SourceID    int       `json:"source_id"`
+    LastChecked time.Time `json:"last_checked"`
+}
+
+// gcIndexed is the newest indexed record seen for an agent
+type gcIndexed struct {
+    id      string
+    checked time.Time
+}
+
+// key identifies the agent: its source and ID on the source when known,
+// otherwise its name
+func (r gcRecord) key() string {
+    source := r.Source
+    if source == "" {
+        source = "virtuals"
+    }
+    if r.SourceID > 0 {
+        return source + "#" + strconv.Itoa(r.SourceID)
+    }
+    return source + ":" + strings.ToLower(strings.TrimSpace(r.Name))
+}
+
+// CollectGarbage archives agent records that no index lists: orphans, which
+// neither the current index nor any saved index version lists, and
+// duplicates, which are the same agent as a newer record under another ID.
+// Duplicates still in the index, left there by the ID scheme change, are
+// dropped from it first, keeping the most recently checked record of each
+// agent. Records modified within grace are left alone. Archived records are
+// moved under archive/<timestamp> rather than deleted. With dryRun set
+// nothing is moved and the report shows what would be.
+func (s *AgentStore) CollectGarbage(dryRun bool, grace time.Duration) (*GCReport, error) {
+    report := &GCReport{DryRun: dryRun, Orphans: []string{}, Duplicates: []string{}}
+
+    index, err := s.GetIndex()
+    if err != nil {
+        return report, err
+    }
+    indexed := make(map[string]bool, len(index.Agents))
+    for _, summary := range index.Agents {
+        indexed[summary.ID] = true
+    }
+    // Saved versions keep their agents reachable by rollback
+    listed, err := s.listedInVersions()
+    if err != nil {
+        return report, err
+    }
+
+    ids, err := s.agents.ids()
+    if err != nil {
+        return report, err
+    }
+    indexedKeys := make(map[string]bool, len(index.Agents))
+    newest := make(map[string]gcIndexed, len(index.Agents))
+    var candidates, stale []string
+    for _, id := range ids {
+        report.Scanned++
+        if !indexed[id] {
+            candidates = append(candidates, id)
+            continue
+        }
+        report.Indexed++
+        data, err := s.agents.read(id)
+        if err != nil {
+            return report, fmt.Errorf("failed to read agent %s: %w", id, err)
+        }
+        var record gcRecord
+        if err := json.Unmarshal(data, &record); err != nil {
+            s.logger.Printf("Skipping corrupt agent %s in garbage collection: %v", id, err)
+            continue
+        }
+        key := record.key()
+        indexedKeys[key] = true
+        kept, exists := newest[key]
+        if !exists {
+            newest[key] = gcIndexed{id: id, checked: record.LastChecked}
+            continue
+        }
+        older := id
+        if record.LastChecked.After(kept.checked) {
+            older = kept.id
+            newest[key] = gcIndexed{id: id, checked: record.LastChecked}
+        }
+        stale = append(stale, older)
+    }
+
+    cutoff := s.clock.Now().Add(-grace)
+    // Indexed duplicates past the grace period leave the index before their
+    // records are archived, so the index never lists a missing record
+    unindex := make(map[string]bool, len(stale))
+    for _, id := range stale {
+        modTime, err := s.agents.modTime(id)
+        if err != nil {
+            return report, fmt.Errorf("failed to stat agent %s: %w", id, err)
+        }
+        if !modTime.After(cutoff) {
+            unindex[id] = true
+        }
+    }
+    if len(unindex) > 0 && !dryRun {
+        removed, err := s.removeFromIndex(unindex)
+        if err != nil {
+            return report, fmt.Errorf("failed to drop duplicates from index: %w", err)
+        }
+        report.Unindexed = removed
+    }
+    candidates = append(candidates, stale...)
+
+    stamp := s.clock.Now().Format("20060102-150405")
+    for _, id := range candidates {
+        modTime, err := s.agents.modTime(id)
+        if err != nil {
+            return report, fmt.Errorf("failed to stat agent %s: %w", id, err)
+        }
+        if modTime.After(cutoff) {
+            report.Skipped++
+            continue
+        }
+        data, err := s.agents.read(id)
+        if err != nil {
+            return report, fmt.Errorf("failed to read agent %s: %w", id, err)
+        }
+
+        // Corrupt records are kept for a person to look at
+        var record gcRecord
+        if err := json.Unmarshal(data, &record); err != nil {
+            s.logger.Printf("Skipping corrupt agent %s in garbage collection: %v", id, err)
+            continue
+        }
+        switch {
+        case indexedKeys[record.key()]:
+            report.Duplicates = append(report.Duplicates, id)
+        case !listed[id]:
+            report.Orphans = append(report.Orphans, id)
+        default:
+            continue
+        }
+        if dryRun {
+            report.ReclaimedBytes += int64(len(data))
+            continue
+        }
+
+        report.ArchiveDir = filepath.Join(s.BaseDir, "archive", stamp)
+        if err := os.MkdirAll(report.ArchiveDir, 0755); err != nil {
+            return report, fmt.Errorf("failed to create archive directory: %w", err)
+        }
+        if err := os.WriteFile(filepath.Join(report.ArchiveDir, id+".json"), data, 0644); err != nil {
+            return report, fmt.Errorf("failed to archive agent %s: %w", id, err)
+        }
+        if err := s.agents.remove(id); err != nil {
+            return report, fmt.Errorf("failed to remove archived agent %s: %w", id, err)
+        }
+        s.invalidateAgent(id)
+        report.Archived++
+        report.ReclaimedBytes += int64(len(data))
+    }
+    return report, nil
+}
+
+// removeFromIndex drops the given IDs from the index and returns how many
+// entries were removed
+func (s *AgentStore) removeFromIndex(ids map[string]bool) (int, error) {
+    s.indexMutex.Lock()
+    defer s.indexMutex.Unlock()
+
+    index, err := s.readIndex()
+    if err != nil {
+        return 0, err
+    }
+    kept := index.Agents[:0]
+    for _, summary := range index.Agents {
+        if !ids[summary.ID] {
+            kept = append(kept, summary)
+        }
+    }
+    removed := len(index.Agents) - len(kept)
+    if removed == 0 {
+        return 0, nil
+    }
+    index.Agents = kept
+    index.LastUpdated = s.clock.Now()
+    return removed, s.saveIndex(index)
+}
+
+// listedInVersions returns the IDs listed in any saved index version
+func (s *AgentStore) listedInVersions() (map[string]bool, error) {
+    s.indexMutex.RLock()
+    defer s.indexMutex.RUnlock()
+
+    var versions []IndexVersion
+    if err := readJSONFile(s.indexVersionsPath(), &versions); err != nil {
+        return nil, err
+    }
+    listed := make(map[string]bool)
+    for _, version := range versions {
+        var index struct {
+            Agents []struct {
+                ID string `json:"id"`
+            } `json:"agents"`
+        }
+        if err := readJSONFile(s.indexVersionPath(version.ID), &index); err != nil {
+            return nil, err
+        }
+        for _, agent := range index.Agents {
+            listed[agent.ID] = true
+        }
+    }
+    return listed, nil
+}
+
+// StartGC archives orphaned and duplicate agent records every interval until
+// ctx is cancelled
+func (s *AgentStore) StartGC(ctx context.Context, interval, grace time.Duration, logger *log.Logger) {
+    go func() {
+        ticker := s.clock.NewTicker(interval)
+        defer ticker.Stop()
+        for {
+            select {
+            case <-ticker.C():
+                report, err := s.CollectGarbage(false, grace)
+                if err != nil {
+                    logger.Printf("Error collecting agent garbage: %v", err)
+                } else if report.Archived > 0 {
+                    logger.Printf("Archived %d orphaned and %d duplicate agent records to %s, reclaiming %d bytes",
+                        len(report.Orphans), len(report.Duplicates), report.ArchiveDir, report.ReclaimedBytes)
+                }
+            case <-ctx.Done():
+                return
+            }
+        }
+    }()
+}
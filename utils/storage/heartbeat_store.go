@@ -0,0 +1,63 @@
+package storage
+
+import (
+    "context"
+    "encoding/json"
+    "fmt"
+    "anondd/utils/models"
+)
+
+// RecordHeartbeat stores the outcome of a liveness check on the agent and
+// re-derives its status, so agents found gone often enough turn dead without
+// a full scrape. Like SetAgentSocials it leaves the scrape bookkeeping alone.
+// When the status changes the index is updated and a status_changed event
+// is added to the change feed.
+func (s *AgentStore) RecordHeartbeat(ctx context.Context, agentID, outcome string, statusCode int) (*models.Agent, error) {
+    s.batchMutex.Lock()
+    defer s.batchMutex.Unlock()
+
+    agent, err := s.GetAgentContext(ctx, agentID)
+    if err != nil {
+        return nil, err
+    }
+    now := s.clock.Now()
+    heartbeat := agent.Heartbeat.Next(outcome, statusCode, now)
+    agent.Heartbeat = &heartbeat
+    before := agent.Status
+    agent.UpdateStatus()
+
+    data, err := json.MarshalIndent(agent, "", "  ")
+    if err != nil {
+        return nil, fmt.Errorf("failed to marshal agent: %w", err)
+    }
+    if err := s.agents.write(agent.ID, data); err != nil {
+        return nil, err
+    }
+    s.invalidateAgent(agent.ID)
+    if agent.Status == before {
+        return agent, nil
+    }
+
+    if err := s.MergeIndex([]models.Agent{*agent}); err != nil {
+        return agent, fmt.Errorf("failed to update index status: %w", err)
+    }
+    summary := "Status changed after a heartbeat check"
+    if heartbeat.Gone() {
+        summary = fmt.Sprintf("Looks delisted, %d heartbeat checks in a row found it gone", heartbeat.Misses)
+    }
+    err = s.AddChange(models.ChangeEvent{
+        ID:        fmt.Sprintf("%d-%d", agent.SourceID, now.UnixNano()),
+        AgentID:   agent.ID,
+        SourceID:  agent.SourceID,
+        AgentName: agent.Name,
+        Type:      models.ChangeStatusChanged,
+        Before:    before,
+        After:     agent.Status,
+        Summary:   summary,
+        At:        now,
+    })
+    if err != nil {
+        return agent, fmt.Errorf("failed to record status change: %w", err)
+    }
+    return agent, nil
+}
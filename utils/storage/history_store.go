@@ -0,0 +1,72 @@
+package storage
+
+import (
+    "fmt"
+    "path/filepath"
+    "time"
+    "anondd/utils/models"
+)
+
+// maxSnapshots caps the metric history kept per agent
+const maxSnapshots = 500
+
+// MetricSnapshot is the numeric state of an agent at one scrape
+type MetricSnapshot struct {
+    At        time.Time `json:"at"`
+    Price     string    `json:"price"`
+    MCap      float64   `json:"mcap,omitempty"`
+    Holders   float64   `json:"holders,omitempty"`
+    Volume24h float64   `json:"volume_24h,omitempty"`
+    Mindshare string    `json:"mindshare,omitempty"`
+}
+
+// SnapshotFromAgent extracts the numeric metrics tracked over time
+func SnapshotFromAgent(agent *models.Agent, at time.Time) MetricSnapshot {
+    snapshot := MetricSnapshot{
+        At:        at,
+        Price:     agent.Price,
+        Mindshare: agent.InfluenceMetrics.Mindshare,
+    }
+    snapshot.MCap, _ = models.ParseAmount(agent.TokenData.MCFDV)
+    snapshot.Holders, _ = models.ParseAmount(agent.TokenData.Holders)
+    snapshot.Volume24h, _ = models.ParseAmount(agent.TokenData.Volume24h)
+    return snapshot
+}
+
+func (s *AgentStore) historyPath(sourceID int) string {
+    return filepath.Join(s.BaseDir, "history", fmt.Sprintf("%d.json", sourceID))
+}
+
+// RecordSnapshot appends the agent's current metrics to its history and
+// returns the history as it was before this snapshot
+func (s *AgentStore) RecordSnapshot(agent *models.Agent) ([]MetricSnapshot, error) {
+    s.historyMu.Lock()
+    defer s.historyMu.Unlock()
+
+    var history []MetricSnapshot
+    path := s.historyPath(agent.SourceID)
+    if err := readJSONFile(path, &history); err != nil {
+        return nil, err
+    }
+
+    updated := append(append([]MetricSnapshot(nil), history...), SnapshotFromAgent(agent, s.clock.Now()))
+    if len(updated) > maxSnapshots {
+        updated = updated[len(updated)-maxSnapshots:]
+    }
+    if err := writeJSONFile(path, updated); err != nil {
+        return nil, err
+    }
+    return history, nil
+}
+
+// GetHistory returns the recorded metric snapshots for a source ID, oldest first
+func (s *AgentStore) GetHistory(sourceID int) ([]MetricSnapshot, error) {
+    s.historyMu.Lock()
+    defer s.historyMu.Unlock()
+
+    var history []MetricSnapshot
+    if err := readJSONFile(s.historyPath(sourceID), &history); err != nil {
+        return nil, err
+    }
+    return history, nil
+}
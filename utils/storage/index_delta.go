@@ -0,0 +1,109 @@
+package storage
+
+import (
+    "context"
+    "encoding/json"
+    "fmt"
+    "os"
+    "sort"
+    "time"
+    "anondd/utils/models"
+)
+
+// IndexDelta returns the index entries added or changed since the given time,
+// and the IDs removed from the index since then. Removals are found from the
+// saved index versions: any agent listed in the newest version from at or
+// before since, or in a later one, that is no longer in the index. When no
+// version is that old the delta is marked for a full resync.
+func (s *AgentStore) IndexDelta(ctx context.Context, since time.Time) (*models.IndexDelta, error) {
+    index, err := s.GetIndexContext(ctx)
+    if err != nil {
+        return nil, err
+    }
+
+    delta := &models.IndexDelta{
+        Since:       since,
+        LastUpdated: index.LastUpdated,
+        Added:       []models.AgentSummary{},
+        Changed:     []models.AgentSummary{},
+        Removed:     []string{},
+    }
+    current := make(map[string]bool, len(index.Agents))
+    for _, summary := range index.Agents {
+        current[summary.ID] = true
+        if summary.FirstSeen.After(since) {
+            delta.Added = append(delta.Added, summary)
+            continue
+        }
+        modTime, err := s.agents.modTime(summary.ID)
+        if err != nil {
+            // Listed without a readable record; let the mirror find out
+            s.logger.Printf("Error checking agent %s for index delta: %v", summary.ID, err)
+            delta.Changed = append(delta.Changed, summary)
+            continue
+        }
+        if modTime.After(since) {
+            delta.Changed = append(delta.Changed, summary)
+        }
+    }
+    sort.Slice(delta.Added, func(i, j int) bool {
+        return delta.Added[i].FirstSeen.Before(delta.Added[j].FirstSeen)
+    })
+
+    listed, ok, err := s.listedSince(since)
+    if err != nil {
+        return nil, err
+    }
+    if !ok {
+        delta.Resync = true
+        return delta, nil
+    }
+    for _, id := range listed {
+        if !current[id] {
+            delta.Removed = append(delta.Removed, id)
+        }
+    }
+    return delta, nil
+}
+
+// listedSince returns the IDs of every agent listed in the saved index
+// versions from the newest one at or before t onwards, in first-listed order.
+// Snapshots are taken before each scrape, rebuild and rollback, so an agent
+// in the index at t is in one of them. ok is false when no version is that old.
+func (s *AgentStore) listedSince(t time.Time) (ids []string, ok bool, err error) {
+    s.indexMutex.RLock()
+    defer s.indexMutex.RUnlock()
+
+    var versions []IndexVersion
+    if err := readJSONFile(s.indexVersionsPath(), &versions); err != nil {
+        return nil, false, err
+    }
+    first := -1
+    for i := range versions {
+        if !versions[i].CreatedAt.After(t) {
+            first = i
+        }
+    }
+    if first < 0 {
+        return nil, false, nil
+    }
+
+    seen := make(map[string]bool)
+    for _, version := range versions[first:] {
+        data, err := os.ReadFile(s.indexVersionPath(version.ID))
+        if err != nil {
+            return nil, false, fmt.Errorf("failed to read index version %s: %w", version.ID, err)
+        }
+        var index models.AgentIndex
+        if err := json.Unmarshal(data, &index); err != nil {
+            return nil, false, fmt.Errorf("index version %s: %w: %w", version.ID, ErrCorrupt, err)
+        }
+        for _, summary := range index.Agents {
+            if !seen[summary.ID] {
+                seen[summary.ID] = true
+                ids = append(ids, summary.ID)
+            }
+        }
+    }
+    return ids, true, nil
+}
@@ -0,0 +1,169 @@
+package storage
+
+import (
+    "errors"
+    "fmt"
+    "os"
+    "path/filepath"
+    "time"
+)
+
+// DefaultIndexVersions is how many previous indexes are kept for rollback
+const DefaultIndexVersions = 10
+
+// IndexVersion describes a saved copy of agent_index.json
+type IndexVersion struct {
+    ID        string    `json:"id"`
+    CreatedAt time.Time `json:"created_at"`
+    Reason    string    `json:"reason"` // What was about to change the index
+    Agents    int       `json:"agents"`
+}
+
+func (s *AgentStore) indexVersionsDir() string {
+    return filepath.Join(s.BaseDir, "index_versions")
+}
+
+func (s *AgentStore) indexVersionsPath() string {
+    return filepath.Join(s.indexVersionsDir(), "versions.json")
+}
+
+func (s *AgentStore) indexVersionPath(id string) string {
+    return filepath.Join(s.indexVersionsDir(), "agent_index-"+id+".json")
+}
+
+// SetIndexVersions changes how many index versions are kept; older ones are
+// pruned at the next snapshot
+func (s *AgentStore) SetIndexVersions(n int) {
+    s.indexMutex.Lock()
+    defer s.indexMutex.Unlock()
+    s.keepVersions = n
+}
+
+// SnapshotIndex saves a copy of the current index so it can be restored with
+// RollbackIndex, keeping the newest versions only
+func (s *AgentStore) SnapshotIndex(reason string) (*IndexVersion, error) {
+    s.indexMutex.Lock()
+    defer s.indexMutex.Unlock()
+    return s.snapshotIndex(reason)
+}
+
+// snapshotIndex is SnapshotIndex for callers holding the indexMutex write lock
+func (s *AgentStore) snapshotIndex(reason string) (*IndexVersion, error) {
+    index, err := s.readIndex()
+    if err != nil {
+        return nil, err
+    }
+    data, err := os.ReadFile(filepath.Join(s.BaseDir, "agent_index.json"))
+    if err != nil {
+        return nil, fmt.Errorf("failed to read index file: %w", err)
+    }
+
+    var versions []IndexVersion
+    if err := readJSONFile(s.indexVersionsPath(), &versions); err != nil {
+        return nil, err
+    }
+
+    // IDs are timestamps, suffixed when several snapshots land in one second
+    now := s.clock.Now().UTC()
+    id := now.Format("20060102-150405")
+    for n := 2; hasIndexVersion(versions, id); n++ {
+        id = fmt.Sprintf("%s-%d", now.Format("20060102-150405"), n)
+    }
+    version := IndexVersion{
+        ID:        id,
+        CreatedAt: now,
+        Reason:    reason,
+        Agents:    len(index.Agents),
+    }
+    if err := os.MkdirAll(s.indexVersionsDir(), 0755); err != nil {
+        return nil, fmt.Errorf("failed to create index versions directory: %w", err)
+    }
+    if err := os.WriteFile(s.indexVersionPath(version.ID), data, 0644); err != nil {
+        return nil, fmt.Errorf("failed to write index version: %w", err)
+    }
+
+    versions = append(versions, version)
+    keep := s.keepVersions
+    if keep <= 0 {
+        keep = DefaultIndexVersions
+    }
+    if len(versions) > keep {
+        for _, old := range versions[:len(versions)-keep] {
+            if err := os.Remove(s.indexVersionPath(old.ID)); err != nil && !os.IsNotExist(err) {
+                s.logger.Printf("Error removing index version %s: %v", old.ID, err)
+            }
+        }
+        versions = versions[len(versions)-keep:]
+    }
+    if err := writeJSONFile(s.indexVersionsPath(), versions); err != nil {
+        return nil, err
+    }
+    return &version, nil
+}
+
+func hasIndexVersion(versions []IndexVersion, id string) bool {
+    for _, version := range versions {
+        if version.ID == id {
+            return true
+        }
+    }
+    return false
+}
+
+// IndexVersions lists the saved index versions, newest first
+func (s *AgentStore) IndexVersions() ([]IndexVersion, error) {
+    s.indexMutex.RLock()
+    defer s.indexMutex.RUnlock()
+
+    var versions []IndexVersion
+    if err := readJSONFile(s.indexVersionsPath(), &versions); err != nil {
+        return nil, err
+    }
+    for i, j := 0, len(versions)-1; i < j; i, j = i+1, j-1 {
+        versions[i], versions[j] = versions[j], versions[i]
+    }
+    return versions, nil
+}
+
+// RollbackIndex replaces the index with a saved version. The index being
+// replaced is saved first, so a rollback can itself be undone. Agent records
+// are left as they are; only the index listing is restored.
+func (s *AgentStore) RollbackIndex(id string) (*IndexVersion, error) {
+    s.indexMutex.Lock()
+    defer s.indexMutex.Unlock()
+
+    var versions []IndexVersion
+    if err := readJSONFile(s.indexVersionsPath(), &versions); err != nil {
+        return nil, err
+    }
+    var target *IndexVersion
+    for i := range versions {
+        if versions[i].ID == id {
+            target = &versions[i]
+        }
+    }
+    if target == nil {
+        return nil, fmt.Errorf("index version %s: %w", id, ErrNotFound)
+    }
+    data, err := os.ReadFile(s.indexVersionPath(id))
+    if err != nil {
+        return nil, fmt.Errorf("failed to read index version %s: %w", id, err)
+    }
+
+    if _, err := s.snapshotIndex("before rollback to " + id); err != nil && !errors.Is(err, ErrNotFound) {
+        return nil, fmt.Errorf("failed to save current index: %w", err)
+    }
+
+    indexPath := filepath.Join(s.BaseDir, "agent_index.json")
+    tmpPath := indexPath + ".tmp"
+    if err := os.WriteFile(tmpPath, data, 0644); err != nil {
+        return nil, err
+    }
+    if err := os.Rename(tmpPath, indexPath); err != nil {
+        os.Remove(tmpPath)
+        return nil, err
+    }
+    s.invalidateIndex()
+    s.logger.Printf("Rolled the agent index back to version %s (%d agents)", id, target.Agents)
+    return target, nil
+}
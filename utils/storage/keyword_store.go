@@ -0,0 +1,150 @@
+package storage
+
+import (
+    "fmt"
+    "path/filepath"
+    "regexp"
+    "strings"
+    "sync"
+    "time"
+    "anondd/utils/models"
+)
+
+// DefaultKeywordCooldown is how long a keyword stays quiet after it triggers
+const DefaultKeywordCooldown = 10 * time.Minute
+
+// KeywordTrigger is a word or ticker a group chat watches for; mentioning it
+// makes the bot post the agent's stat card
+type KeywordTrigger struct {
+    Keyword  string        `json:"keyword"` // Lowercase; a leading $ marks a ticker
+    SourceID int           `json:"source_id,omitempty"`
+    AgentID  string        `json:"agent_id"`
+    Name     string        `json:"name"`
+    Cooldown time.Duration `json:"cooldown"`
+    AddedBy  string        `json:"added_by,omitempty"`
+    AddedAt  time.Time     `json:"added_at"`
+}
+
+// KeywordStore persists keyword triggers, one set per bot and chat
+type KeywordStore struct {
+    path     string
+    mu       sync.Mutex
+    triggers map[string][]KeywordTrigger
+    patterns map[string]*regexp.Regexp // By keyword, compiled on first use
+}
+
+// NewKeywordStore creates a keyword store backed by keywords.json in baseDir
+func NewKeywordStore(baseDir string) (*KeywordStore, error) {
+    store := &KeywordStore{
+        path:     filepath.Join(baseDir, "keywords.json"),
+        triggers: make(map[string][]KeywordTrigger),
+        patterns: make(map[string]*regexp.Regexp),
+    }
+    if err := readJSONFile(store.path, &store.triggers); err != nil {
+        return store, err
+    }
+    return store, nil
+}
+
+// NormalizeKeyword lowercases and trims a keyword
+func NormalizeKeyword(keyword string) string {
+    return strings.ToLower(strings.TrimSpace(keyword))
+}
+
+// Add watches for keyword and links it to the agent; an existing trigger for
+// the keyword is replaced. It returns false if the keyword was already set.
+func (s *KeywordStore) Add(bot string, chatID int64, keyword string, agent *models.Agent, cooldown time.Duration, addedBy string) (bool, error) {
+    s.mu.Lock()
+    defer s.mu.Unlock()
+
+    keyword = NormalizeKeyword(keyword)
+    trigger := KeywordTrigger{
+        Keyword:  keyword,
+        SourceID: agent.SourceID,
+        AgentID:  agent.ID,
+        Name:     agent.Name,
+        Cooldown: cooldown,
+        AddedBy:  addedBy,
+        AddedAt:  time.Now(),
+    }
+    key := watchlistKey(bot, chatID)
+    for i, existing := range s.triggers[key] {
+        if existing.Keyword == keyword {
+            s.triggers[key][i] = trigger
+            return false, writeJSONFile(s.path, s.triggers)
+        }
+    }
+    s.triggers[key] = append(s.triggers[key], trigger)
+    return true, writeJSONFile(s.path, s.triggers)
+}
+
+// Remove stops watching for keyword
+func (s *KeywordStore) Remove(bot string, chatID int64, keyword string) (*KeywordTrigger, error) {
+    s.mu.Lock()
+    defer s.mu.Unlock()
+
+    keyword = NormalizeKeyword(keyword)
+    key := watchlistKey(bot, chatID)
+    triggers := s.triggers[key]
+    for i, trigger := range triggers {
+        if trigger.Keyword == keyword {
+            if len(triggers) == 1 {
+                delete(s.triggers, key)
+            } else {
+                s.triggers[key] = append(triggers[:i:i], triggers[i+1:]...)
+            }
+            return &trigger, writeJSONFile(s.path, s.triggers)
+        }
+    }
+    return nil, fmt.Errorf("keyword '%s': %w", keyword, ErrNotFound)
+}
+
+// SetCooldown changes how long keyword stays quiet after triggering
+func (s *KeywordStore) SetCooldown(bot string, chatID int64, keyword string, cooldown time.Duration) error {
+    s.mu.Lock()
+    defer s.mu.Unlock()
+
+    keyword = NormalizeKeyword(keyword)
+    triggers := s.triggers[watchlistKey(bot, chatID)]
+    for i := range triggers {
+        if triggers[i].Keyword == keyword {
+            triggers[i].Cooldown = cooldown
+            return writeJSONFile(s.path, s.triggers)
+        }
+    }
+    return fmt.Errorf("keyword '%s': %w", keyword, ErrNotFound)
+}
+
+// List returns the chat's keyword triggers in the order they were added
+func (s *KeywordStore) List(bot string, chatID int64) []KeywordTrigger {
+    s.mu.Lock()
+    defer s.mu.Unlock()
+    return append([]KeywordTrigger(nil), s.triggers[watchlistKey(bot, chatID)]...)
+}
+
+// Match returns the chat's triggers mentioned in text as whole words,
+// ignoring case
+func (s *KeywordStore) Match(bot string, chatID int64, text string) []KeywordTrigger {
+    s.mu.Lock()
+    defer s.mu.Unlock()
+
+    var matched []KeywordTrigger
+    for _, trigger := range s.triggers[watchlistKey(bot, chatID)] {
+        if s.pattern(trigger.Keyword).MatchString(text) {
+            matched = append(matched, trigger)
+        }
+    }
+    return matched
+}
+
+// pattern compiles a keyword into a case-insensitive whole-word match;
+// callers must hold mu
+func (s *KeywordStore) pattern(keyword string) *regexp.Regexp {
+    if re, ok := s.patterns[keyword]; ok {
+        return re
+    }
+    // \b doesn't work next to $, so word edges are spelled out
+    re := regexp.MustCompile(`(?i)(^|[^\pL\pN_])` + regexp.QuoteMeta(keyword) + `($|[^\pL\pN_])`)
+    s.patterns[keyword] = re
+    return re
+}
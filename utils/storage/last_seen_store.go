@@ -0,0 +1,69 @@
+package storage
+
+import (
+    "fmt"
+    "path/filepath"
+    "sync"
+    "time"
+)
+
+// VisitGap is how long a chat must be quiet before its next message starts
+// a new visit
+const VisitGap = 30 * time.Minute
+
+// ChatVisit is when a chat last talked to a bot and where its previous
+// visit ended, which is what "since you last looked" means
+type ChatVisit struct {
+    Active time.Time `json:"active"`          // Latest interaction
+    Since  time.Time `json:"since,omitempty"` // Last interaction of the previous visit; zero during the first
+}
+
+// LastSeenStore persists each chat's visits, per bot
+type LastSeenStore struct {
+    path   string
+    mu     sync.Mutex
+    visits map[string]ChatVisit
+}
+
+// NewLastSeenStore creates a last seen store backed by last_seen.json in baseDir
+func NewLastSeenStore(baseDir string) (*LastSeenStore, error) {
+    store := &LastSeenStore{
+        path:   filepath.Join(baseDir, "last_seen.json"),
+        visits: make(map[string]ChatVisit),
+    }
+    if err := readJSONFile(store.path, &store.visits); err != nil {
+        return store, err
+    }
+    if store.visits == nil {
+        store.visits = make(map[string]ChatVisit)
+    }
+    return store, nil
+}
+
+func lastSeenKey(bot string, chatID int64) string {
+    return fmt.Sprintf("%s:%d", bot, chatID)
+}
+
+// Touch records an interaction at now. An interaction more than VisitGap
+// after the last one starts a new visit, moving Since up to the last one.
+func (s *LastSeenStore) Touch(bot string, chatID int64, now time.Time) (ChatVisit, error) {
+    s.mu.Lock()
+    defer s.mu.Unlock()
+
+    key := lastSeenKey(bot, chatID)
+    visit := s.visits[key]
+    if !visit.Active.IsZero() && now.Sub(visit.Active) > VisitGap {
+        visit.Since = visit.Active
+    }
+    visit.Active = now
+    s.visits[key] = visit
+    return visit, writeJSONFile(s.path, s.visits)
+}
+
+// Get returns the chat's visits; the bool is false for chats never seen
+func (s *LastSeenStore) Get(bot string, chatID int64) (ChatVisit, bool) {
+    s.mu.Lock()
+    defer s.mu.Unlock()
+    visit, exists := s.visits[lastSeenKey(bot, chatID)]
+    return visit, exists
+}
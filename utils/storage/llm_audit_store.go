@@ -0,0 +1,293 @@
+package storage
+
+import (
+    "bufio"
+    "context"
+    "encoding/json"
+    "fmt"
+    "log"
+    "os"
+    "path/filepath"
+    "sort"
+    "strconv"
+    "strings"
+    "sync"
+    "time"
+    "anondd/utils/clock"
+)
+
+const (
+    // DefaultLLMAuditRetention is how long audited LLM calls are kept
+    DefaultLLMAuditRetention = 7 * 24 * time.Hour
+
+    // LLMAuditExpiryInterval is how often expired audit files are removed
+    LLMAuditExpiryInterval = time.Hour
+
+    llmAuditDayFormat = "2006-01-02"
+
+    // maxLLMAuditLine bounds one audit record; bodies are capped well below it
+    maxLLMAuditLine = 1 << 20
+)
+
+// LLMAuditEntry is one call to the LLM API with its request and response
+// bodies, secrets already redacted
+type LLMAuditEntry struct {
+    ID         string    `json:"id"`
+    At         time.Time `json:"at"`
+    PromptKey  string    `json:"prompt_key,omitempty"`
+    Command    string    `json:"command,omitempty"`
+    Method     string    `json:"method"`
+    URL        string    `json:"url"`
+    Status     int       `json:"status,omitempty"` // Zero when no response arrived
+    DurationMS int64     `json:"duration_ms"`
+    Error      string    `json:"error,omitempty"`
+    Request    string    `json:"request"`
+    Response   string    `json:"response"`
+    Truncated  bool      `json:"truncated,omitempty"` // A body was cut at the audit size limit
+}
+
+// Failed reports whether the call errored or the API answered with an error status
+func (e LLMAuditEntry) Failed() bool {
+    return e.Error != "" || e.Status >= 400
+}
+
+// LLMAuditFilter selects audited calls; empty fields match everything
+type LLMAuditFilter struct {
+    PromptKey  string
+    Command    string
+    FailedOnly bool
+}
+
+func (f LLMAuditFilter) matches(entry LLMAuditEntry) bool {
+    if f.PromptKey != "" && entry.PromptKey != f.PromptKey {
+        return false
+    }
+    if f.Command != "" && entry.Command != f.Command {
+        return false
+    }
+    return !f.FailedOnly || entry.Failed()
+}
+
+// LLMAuditLog appends every LLM call to one JSON lines file per day and
+// deletes days older than the retention period
+type LLMAuditLog struct {
+    dir       string
+    mu        sync.Mutex
+    retention time.Duration
+    lastID    int64
+    clock     clock.Clock
+}
+
+// NewLLMAuditLog creates an audit log writing to baseDir/llm_audit
+func NewLLMAuditLog(baseDir string) (*LLMAuditLog, error) {
+    auditLog := &LLMAuditLog{dir: filepath.Join(baseDir, "llm_audit"), retention: DefaultLLMAuditRetention, clock: clock.Real()}
+    if err := os.MkdirAll(auditLog.dir, 0755); err != nil {
+        return auditLog, fmt.Errorf("failed to create LLM audit directory: %w", err)
+    }
+    return auditLog, nil
+}
+
+// SetRetention changes how long calls are kept; older days are removed at
+// the next expiry
+func (l *LLMAuditLog) SetRetention(retention time.Duration) {
+    l.mu.Lock()
+    defer l.mu.Unlock()
+    l.retention = retention
+}
+
+// SetClock makes the log read time from c for expiry. Call it before
+// StartExpiry.
+func (l *LLMAuditLog) SetClock(c clock.Clock) {
+    l.mu.Lock()
+    defer l.mu.Unlock()
+    l.clock = c
+}
+
+func (l *LLMAuditLog) dayPath(day time.Time) string {
+    return filepath.Join(l.dir, day.Format(llmAuditDayFormat)+".jsonl")
+}
+
+// Append records a call, giving it an ID that orders it after every earlier one
+func (l *LLMAuditLog) Append(entry LLMAuditEntry) (LLMAuditEntry, error) {
+    l.mu.Lock()
+    defer l.mu.Unlock()
+
+    id := entry.At.UnixNano()
+    if id <= l.lastID {
+        id = l.lastID + 1
+    }
+    l.lastID = id
+    entry.ID = strconv.FormatInt(id, 10)
+
+    data, err := json.Marshal(entry)
+    if err != nil {
+        return entry, fmt.Errorf("failed to marshal LLM audit entry: %w", err)
+    }
+    file, err := os.OpenFile(l.dayPath(entry.At), os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+    if err != nil {
+        return entry, fmt.Errorf("failed to open LLM audit file: %w", err)
+    }
+    defer file.Close()
+    if _, err := file.Write(append(data, '\n')); err != nil {
+        return entry, fmt.Errorf("failed to write LLM audit entry: %w", err)
+    }
+    return entry, nil
+}
+
+// days returns the dates with audit files, newest first
+func (l *LLMAuditLog) days() ([]string, error) {
+    files, err := os.ReadDir(l.dir)
+    if err != nil {
+        if os.IsNotExist(err) {
+            return nil, nil
+        }
+        return nil, fmt.Errorf("failed to list LLM audit files: %w", err)
+    }
+    var days []string
+    for _, file := range files {
+        day := strings.TrimSuffix(file.Name(), ".jsonl")
+        if _, err := time.Parse(llmAuditDayFormat, day); err == nil && day != file.Name() {
+            days = append(days, day)
+        }
+    }
+    sort.Sort(sort.Reverse(sort.StringSlice(days)))
+    return days, nil
+}
+
+// readDay returns a day's entries in the order they were written, skipping
+// lines that don't parse
+func (l *LLMAuditLog) readDay(day string) ([]LLMAuditEntry, error) {
+    file, err := os.Open(filepath.Join(l.dir, day+".jsonl"))
+    if err != nil {
+        if os.IsNotExist(err) {
+            return nil, nil
+        }
+        return nil, fmt.Errorf("failed to open LLM audit file: %w", err)
+    }
+    defer file.Close()
+
+    var entries []LLMAuditEntry
+    scanner := bufio.NewScanner(file)
+    scanner.Buffer(make([]byte, 64*1024), maxLLMAuditLine)
+    for scanner.Scan() {
+        var entry LLMAuditEntry
+        if err := json.Unmarshal(scanner.Bytes(), &entry); err == nil {
+            entries = append(entries, entry)
+        }
+    }
+    if err := scanner.Err(); err != nil {
+        return entries, fmt.Errorf("failed to read LLM audit file: %w", err)
+    }
+    return entries, nil
+}
+
+// Recent returns up to limit calls matching the filter, newest first
+func (l *LLMAuditLog) Recent(limit int, filter LLMAuditFilter) ([]LLMAuditEntry, error) {
+    l.mu.Lock()
+    defer l.mu.Unlock()
+
+    days, err := l.days()
+    if err != nil {
+        return nil, err
+    }
+    var recent []LLMAuditEntry
+    for _, day := range days {
+        entries, err := l.readDay(day)
+        if err != nil {
+            return nil, err
+        }
+        for i := len(entries) - 1; i >= 0; i-- {
+            if filter.matches(entries[i]) {
+                recent = append(recent, entries[i])
+                if len(recent) == limit {
+                    return recent, nil
+                }
+            }
+        }
+    }
+    return recent, nil
+}
+
+// Get returns one audited call by ID
+func (l *LLMAuditLog) Get(id string) (LLMAuditEntry, error) {
+    nanos, err := strconv.ParseInt(id, 10, 64)
+    if err != nil {
+        return LLMAuditEntry{}, ErrNotFound
+    }
+
+    l.mu.Lock()
+    defer l.mu.Unlock()
+
+    // IDs are the call's time, bumped by at most a few nanoseconds, so the
+    // entry is in that day's file or, around midnight, the one before
+    at := time.Unix(0, nanos)
+    for _, day := range []time.Time{at, at.AddDate(0, 0, -1)} {
+        entries, err := l.readDay(day.Format(llmAuditDayFormat))
+        if err != nil {
+            return LLMAuditEntry{}, err
+        }
+        for _, entry := range entries {
+            if entry.ID == id {
+                return entry, nil
+            }
+        }
+    }
+    return LLMAuditEntry{}, ErrNotFound
+}
+
+// Expire deletes the days entirely older than the retention period and
+// returns how many were removed
+func (l *LLMAuditLog) Expire(now time.Time) (int, error) {
+    l.mu.Lock()
+    defer l.mu.Unlock()
+
+    days, err := l.days()
+    if err != nil {
+        return 0, err
+    }
+    cutoff := now.Add(-l.retention).Format(llmAuditDayFormat)
+    removed := 0
+    for _, day := range days {
+        if day >= cutoff {
+            continue
+        }
+        if err := os.Remove(filepath.Join(l.dir, day+".jsonl")); err != nil && !os.IsNotExist(err) {
+            return removed, fmt.Errorf("failed to remove LLM audit file: %w", err)
+        }
+        removed++
+    }
+    return removed, nil
+}
+
+func (l *LLMAuditLog) now() time.Time {
+    l.mu.Lock()
+    defer l.mu.Unlock()
+    return l.clock.Now()
+}
+
+// StartExpiry removes expired days now and every interval until ctx is cancelled
+func (l *LLMAuditLog) StartExpiry(ctx context.Context, interval time.Duration, logger *log.Logger) {
+    expire := func() {
+        removed, err := l.Expire(l.now())
+        if err != nil {
+            logger.Printf("Error expiring LLM audit log: %v", err)
+        } else if removed > 0 {
+            logger.Printf("Expired %d days of LLM audit log", removed)
+        }
+    }
+    go func() {
+        expire()
+        l.mu.Lock()
+        ticker := l.clock.NewTicker(interval)
+        l.mu.Unlock()
+        defer ticker.Stop()
+        for {
+            select {
+            case <-ticker.C():
+                expire()
+            case <-ctx.Done():
+                return
+            }
+        }
+    }()
+}
@@ -0,0 +1,170 @@
+package storage
+
+import (
+    "path/filepath"
+    "sort"
+    "sync"
+    "time"
+)
+
+// llmUsageRetention is how many days of LLM usage the ledger keeps
+const llmUsageRetention = 90
+
+// UnlabeledCommand is the ledger key for calls not made for a known command
+const UnlabeledCommand = "other"
+
+// LLMUsage totals LLM completion requests
+type LLMUsage struct {
+    Calls            int     `json:"calls"`
+    Errors           int     `json:"errors"`
+    PromptTokens     int     `json:"prompt_tokens"`
+    CompletionTokens int     `json:"completion_tokens"`
+    Cost             float64 `json:"cost"` // USD, reported by the provider or estimated
+}
+
+// Tokens is the total of prompt and completion tokens
+func (u LLMUsage) Tokens() int {
+    return u.PromptTokens + u.CompletionTokens
+}
+
+func (u *LLMUsage) add(other LLMUsage) {
+    u.Calls += other.Calls
+    u.Errors += other.Errors
+    u.PromptTokens += other.PromptTokens
+    u.CompletionTokens += other.CompletionTokens
+    u.Cost += other.Cost
+}
+
+// LLMUsageDay is one day of usage, totalled and split by prompt key and command
+type LLMUsageDay struct {
+    Day         string              `json:"day"` // YYYY-MM-DD, local time
+    Total       LLMUsage            `json:"total"`
+    ByPromptKey map[string]LLMUsage `json:"by_prompt_key,omitempty"`
+    ByCommand   map[string]LLMUsage `json:"by_command,omitempty"`
+}
+
+// LLMUsageReport is usage over a range of days split by prompt key and
+// command, with each day's totals oldest first
+type LLMUsageReport struct {
+    From        string              `json:"from"`
+    To          string              `json:"to"`
+    Total       LLMUsage            `json:"total"`
+    ByPromptKey map[string]LLMUsage `json:"by_prompt_key"`
+    ByCommand   map[string]LLMUsage `json:"by_command"`
+    Days        []LLMUsageDay       `json:"days"`
+}
+
+// LLMUsageLedger persists daily LLM token usage and cost per prompt key and
+// per command
+type LLMUsageLedger struct {
+    path string
+    mu   sync.Mutex
+    days map[string]*LLMUsageDay
+}
+
+// NewLLMUsageLedger creates a ledger backed by llm_usage.json in baseDir
+func NewLLMUsageLedger(baseDir string) (*LLMUsageLedger, error) {
+    ledger := &LLMUsageLedger{
+        path: filepath.Join(baseDir, "llm_usage.json"),
+        days: make(map[string]*LLMUsageDay),
+    }
+    if err := readJSONFile(ledger.path, &ledger.days); err != nil {
+        return ledger, err
+    }
+    if ledger.days == nil {
+        ledger.days = make(map[string]*LLMUsageDay)
+    }
+    return ledger, nil
+}
+
+// Record adds one call's usage to the day of at, dropping days past retention
+func (l *LLMUsageLedger) Record(at time.Time, promptKey, command string, usage LLMUsage) error {
+    if command == "" {
+        command = UnlabeledCommand
+    }
+
+    l.mu.Lock()
+    defer l.mu.Unlock()
+
+    key := at.Format("2006-01-02")
+    day, exists := l.days[key]
+    if !exists {
+        day = &LLMUsageDay{Day: key}
+        l.days[key] = day
+        cutoff := at.AddDate(0, 0, -llmUsageRetention).Format("2006-01-02")
+        for old := range l.days {
+            if old < cutoff {
+                delete(l.days, old)
+            }
+        }
+    }
+    if day.ByPromptKey == nil {
+        day.ByPromptKey = make(map[string]LLMUsage)
+    }
+    if day.ByCommand == nil {
+        day.ByCommand = make(map[string]LLMUsage)
+    }
+
+    day.Total.add(usage)
+    byKey := day.ByPromptKey[promptKey]
+    byKey.add(usage)
+    day.ByPromptKey[promptKey] = byKey
+    byCommand := day.ByCommand[command]
+    byCommand.add(usage)
+    day.ByCommand[command] = byCommand
+    return writeJSONFile(l.path, l.days)
+}
+
+// Report totals the last days days up to and including now's day
+func (l *LLMUsageLedger) Report(days int, now time.Time) LLMUsageReport {
+    l.mu.Lock()
+    defer l.mu.Unlock()
+
+    report := LLMUsageReport{
+        From:        now.AddDate(0, 0, -(days - 1)).Format("2006-01-02"),
+        To:          now.Format("2006-01-02"),
+        ByPromptKey: make(map[string]LLMUsage),
+        ByCommand:   make(map[string]LLMUsage),
+        Days:        make([]LLMUsageDay, 0, days),
+    }
+    for i := days - 1; i >= 0; i-- {
+        key := now.AddDate(0, 0, -i).Format("2006-01-02")
+        day, exists := l.days[key]
+        if !exists {
+            report.Days = append(report.Days, LLMUsageDay{Day: key})
+            continue
+        }
+        report.Days = append(report.Days, LLMUsageDay{Day: key, Total: day.Total})
+        report.Total.add(day.Total)
+        for promptKey, usage := range day.ByPromptKey {
+            total := report.ByPromptKey[promptKey]
+            total.add(usage)
+            report.ByPromptKey[promptKey] = total
+        }
+        for command, usage := range day.ByCommand {
+            total := report.ByCommand[command]
+            total.add(usage)
+            report.ByCommand[command] = total
+        }
+    }
+    return report
+}
+
+// TopUsage orders a usage breakdown by cost, then tokens, most expensive first
+func TopUsage(breakdown map[string]LLMUsage) []string {
+    keys := make([]string, 0, len(breakdown))
+    for key := range breakdown {
+        keys = append(keys, key)
+    }
+    sort.Slice(keys, func(i, j int) bool {
+        a, b := breakdown[keys[i]], breakdown[keys[j]]
+        if a.Cost != b.Cost {
+            return a.Cost > b.Cost
+        }
+        if a.Tokens() != b.Tokens() {
+            return a.Tokens() > b.Tokens()
+        }
+        return keys[i] < keys[j]
+    })
+    return keys
+}
@@ -0,0 +1,212 @@
+package storage
+
+import (
+    "encoding/json"
+    "fmt"
+    "io"
+    "os"
+    "path/filepath"
+    "strings"
+    "anondd/utils/models"
+)
+
+// Migration upgrades one stored agent record to Version. Apply edits the raw
+// JSON object in place and reports whether anything changed.
+type Migration struct {
+    Version     int
+    Description string
+    Apply       func(record map[string]interface{}) (bool, error)
+}
+
+// agentMigrations must stay ordered by version; append new ones at the end
+var agentMigrations = []Migration{
+    {
+        Version:     1,
+        Description: "Default missing first_seen to scraped_at",
+        Apply: func(record map[string]interface{}) (bool, error) {
+            firstSeen, _ := record["first_seen"].(string)
+            if firstSeen != "" && !strings.HasPrefix(firstSeen, "0001-01-01") {
+                return false, nil
+            }
+            scrapedAt, ok := record["scraped_at"].(string)
+            if !ok || scrapedAt == "" {
+                return false, nil
+            }
+            record["first_seen"] = scrapedAt
+            return true, nil
+        },
+    },
+    {
+        Version:     2,
+        Description: "Normalize contract addresses to lowercase",
+        Apply: func(record map[string]interface{}) (bool, error) {
+            address, ok := record["contract_address"].(string)
+            if !ok || address == strings.ToLower(address) {
+                return false, nil
+            }
+            record["contract_address"] = strings.ToLower(address)
+            return true, nil
+        },
+    },
+    {
+        Version:     3,
+        Description: "Parse stats text into stats_detail",
+        Apply: func(record map[string]interface{}) (bool, error) {
+            raw, _ := record["stats"].(string)
+            if raw == "" || record["stats_detail"] != nil {
+                return false, nil
+            }
+            stats := models.ParseAgentStats(raw)
+            if stats.Empty() {
+                return false, nil
+            }
+            data, err := json.Marshal(stats)
+            if err != nil {
+                return false, err
+            }
+            var detail map[string]interface{}
+            if err := json.Unmarshal(data, &detail); err != nil {
+                return false, err
+            }
+            record["stats_detail"] = detail
+            return true, nil
+        },
+    },
+}
+
+// CurrentSchemaVersion is the schema version written on newly saved agents
+func CurrentSchemaVersion() int {
+    return agentMigrations[len(agentMigrations)-1].Version
+}
+
+// MigrationReport summarizes a migration run
+type MigrationReport struct {
+    DryRun    bool           `json:"dry_run"`
+    Scanned   int            `json:"scanned"`
+    Migrated  int            `json:"migrated"`
+    Applied   map[string]int `json:"applied"` // Records changed per migration description
+    BackupDir string         `json:"backup_dir,omitempty"`
+}
+
+// Migrate upgrades every stored agent record below CurrentSchemaVersion. Before
+// the first write it backs up the agent data under backups/<timestamp>. With
+// dryRun set nothing is written and the report shows what would change.
+func (s *AgentStore) Migrate(dryRun bool) (*MigrationReport, error) {
+    report := &MigrationReport{DryRun: dryRun, Applied: make(map[string]int)}
+    current := CurrentSchemaVersion()
+
+    ids, err := s.agents.ids()
+    if err != nil {
+        return report, err
+    }
+
+    for _, id := range ids {
+        data, err := s.agents.read(id)
+        if err != nil {
+            return report, fmt.Errorf("failed to read agent %s: %w", id, err)
+        }
+        report.Scanned++
+
+        var record map[string]interface{}
+        if err := json.Unmarshal(data, &record); err != nil {
+            return report, fmt.Errorf("agent %s: %w: %w", id, ErrCorrupt, err)
+        }
+        version := 0
+        if v, ok := record["schema_version"].(float64); ok {
+            version = int(v)
+        }
+        if version >= current {
+            continue
+        }
+
+        for _, migration := range agentMigrations {
+            if migration.Version <= version {
+                continue
+            }
+            changed, err := migration.Apply(record)
+            if err != nil {
+                return report, fmt.Errorf("migration %d on agent %s: %w", migration.Version, id, err)
+            }
+            if changed {
+                report.Applied[migration.Description]++
+            }
+        }
+        record["schema_version"] = current
+        report.Migrated++
+
+        if dryRun {
+            continue
+        }
+        if report.BackupDir == "" {
+            if report.BackupDir, err = s.backupAgentData(); err != nil {
+                return report, err
+            }
+            s.logger.Printf("Backed up agent data to %s before migrating", report.BackupDir)
+        }
+
+        migrated, err := json.MarshalIndent(record, "", "  ")
+        if err != nil {
+            return report, fmt.Errorf("failed to marshal migrated agent %s: %w", id, err)
+        }
+        if err := s.agents.write(id, migrated); err != nil {
+            return report, fmt.Errorf("failed to write migrated agent %s: %w", id, err)
+        }
+        s.invalidateAgent(id)
+    }
+    return report, nil
+}
+
+// backupAgentData copies the agent records and index into a timestamped directory
+func (s *AgentStore) backupAgentData() (string, error) {
+    dir := filepath.Join(s.BaseDir, "backups", s.clock.Now().Format("20060102-150405"))
+    sources := []string{"agents", agentLogFile, "agent_index.json"}
+
+    for _, name := range sources {
+        if err := copyPath(filepath.Join(s.BaseDir, name), filepath.Join(dir, name)); err != nil {
+            return "", fmt.Errorf("failed to back up %s: %w", name, err)
+        }
+    }
+    return dir, nil
+}
+
+// copyPath copies a file or directory tree; missing sources are skipped
+func copyPath(src, dst string) error {
+    info, err := os.Stat(src)
+    if os.IsNotExist(err) {
+        return nil
+    }
+    if err != nil {
+        return err
+    }
+
+    if info.IsDir() {
+        entries, err := os.ReadDir(src)
+        if err != nil {
+            return err
+        }
+        for _, entry := range entries {
+            if err := copyPath(filepath.Join(src, entry.Name()), filepath.Join(dst, entry.Name())); err != nil {
+                return err
+            }
+        }
+        return nil
+    }
+
+    if err := os.MkdirAll(filepath.Dir(dst), 0755); err != nil {
+        return err
+    }
+    in, err := os.Open(src)
+    if err != nil {
+        return err
+    }
+    defer in.Close()
+    out, err := os.Create(dst)
+    if err != nil {
+        return err
+    }
+    if _, err := io.Copy(out, in); err != nil {
+        out.Close()
+        return err
+    }
+    return out.Close()
+}
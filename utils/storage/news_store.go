@@ -0,0 +1,78 @@
+package storage
+
+import (
+    "path/filepath"
+    "sort"
+    "anondd/utils/models"
+)
+
+// maxNewsArticles caps the matched articles kept on disk
+const maxNewsArticles = 2000
+
+func (s *AgentStore) newsPath() string {
+    return filepath.Join(s.BaseDir, "news", "articles.json")
+}
+
+// SaveNews merges matched articles into the news store, newest first, and
+// returns how many were not stored before. Agent associations of known
+// articles are replaced with the latest match.
+func (s *AgentStore) SaveNews(articles []models.NewsArticle) (int, error) {
+    s.newsMu.Lock()
+    defer s.newsMu.Unlock()
+
+    var stored []models.NewsArticle
+    if err := readJSONFile(s.newsPath(), &stored); err != nil {
+        return 0, err
+    }
+
+    positions := make(map[string]int, len(stored))
+    for i, article := range stored {
+        positions[article.ID] = i
+    }
+    added := 0
+    for _, article := range articles {
+        if i, exists := positions[article.ID]; exists {
+            stored[i].AgentIDs = article.AgentIDs
+            continue
+        }
+        positions[article.ID] = len(stored)
+        stored = append(stored, article)
+        added++
+    }
+
+    sort.SliceStable(stored, func(i, j int) bool {
+        return stored[i].PublishedAt.After(stored[j].PublishedAt)
+    })
+    if len(stored) > maxNewsArticles {
+        stored = stored[:maxNewsArticles]
+    }
+    if err := writeJSONFile(s.newsPath(), stored); err != nil {
+        return 0, err
+    }
+    return added, nil
+}
+
+// GetAgentNews returns up to limit of the most recent articles mentioning the agent
+func (s *AgentStore) GetAgentNews(agentID string, limit int) ([]models.NewsArticle, error) {
+    s.newsMu.Lock()
+    defer s.newsMu.Unlock()
+
+    var stored []models.NewsArticle
+    if err := readJSONFile(s.newsPath(), &stored); err != nil {
+        return nil, err
+    }
+
+    var news []models.NewsArticle
+    for _, article := range stored {
+        for _, id := range article.AgentIDs {
+            if id == agentID {
+                news = append(news, article)
+                break
+            }
+        }
+        if len(news) == limit {
+            break
+        }
+    }
+    return news, nil
+}
@@ -0,0 +1,36 @@
+package storage
+
+import (
+    "context"
+    "encoding/json"
+    "fmt"
+    "anondd/utils/models"
+)
+
+// SetAgentOnChain stores on-chain token data on the agent. Like SetAgentRisk
+// it leaves the scrape bookkeeping alone. The index is only rewritten when
+// the data changed the agent's creator.
+func (s *AgentStore) SetAgentOnChain(ctx context.Context, agentID string, data *models.OnChainData) error {
+    s.batchMutex.Lock()
+    defer s.batchMutex.Unlock()
+
+    agent, err := s.GetAgentContext(ctx, agentID)
+    if err != nil {
+        return err
+    }
+    creator := agent.Creator()
+    agent.OnChain = data
+
+    encoded, err := json.MarshalIndent(agent, "", "  ")
+    if err != nil {
+        return fmt.Errorf("failed to marshal agent: %w", err)
+    }
+    if err := s.agents.write(agent.ID, encoded); err != nil {
+        return err
+    }
+    s.invalidateAgent(agent.ID)
+    if agent.Creator() != creator {
+        return s.MergeIndex([]models.Agent{*agent})
+    }
+    return nil
+}
@@ -0,0 +1,114 @@
+package storage
+
+import (
+    "encoding/json"
+    "fmt"
+    "log"
+    "os"
+    "path/filepath"
+    "strconv"
+    "sync"
+)
+
+// PersonaStore persists the persona chosen or defined by each chat
+type PersonaStore struct {
+    path     string
+    mu       sync.RWMutex
+    logger   *log.Logger
+    personas map[int64]string
+}
+
+// NewPersonaStore creates a persona store backed by personas.json in baseDir
+func NewPersonaStore(baseDir string, logger *log.Logger) *PersonaStore {
+    store := &PersonaStore{
+        path:     filepath.Join(baseDir, "personas.json"),
+        logger:   logger,
+        personas: make(map[int64]string),
+    }
+    if err := store.load(); err != nil {
+        logger.Printf("Error loading personas: %v", err)
+    }
+    return store
+}
+
+// Get returns the persona for a chat, if one is set
+func (s *PersonaStore) Get(chatID int64) (string, bool) {
+    s.mu.RLock()
+    defer s.mu.RUnlock()
+    persona, exists := s.personas[chatID]
+    return persona, exists
+}
+
+// Set stores the persona for a chat
+func (s *PersonaStore) Set(chatID int64, persona string) error {
+    s.mu.Lock()
+    defer s.mu.Unlock()
+    personas := s.copyPersonas()
+    personas[chatID] = persona
+    return s.save(personas)
+}
+
+// Reset removes a chat's persona so the default is used again
+func (s *PersonaStore) Reset(chatID int64) error {
+    s.mu.Lock()
+    defer s.mu.Unlock()
+    personas := s.copyPersonas()
+    delete(personas, chatID)
+    return s.save(personas)
+}
+
+func (s *PersonaStore) load() error {
+    data, err := os.ReadFile(s.path)
+    if os.IsNotExist(err) {
+        return nil
+    }
+    if err != nil {
+        return fmt.Errorf("failed to read personas file: %w", err)
+    }
+
+    var stored map[string]string
+    if err := json.Unmarshal(data, &stored); err != nil {
+        return fmt.Errorf("failed to unmarshal personas: %w", err)
+    }
+    for key, persona := range stored {
+        chatID, err := strconv.ParseInt(key, 10, 64)
+        if err != nil {
+            continue
+        }
+        s.personas[chatID] = persona
+    }
+    return nil
+}
+
+// copyPersonas returns a copy of the personas to change before saving;
+// callers must hold the lock
+func (s *PersonaStore) copyPersonas() map[int64]string {
+    personas := make(map[int64]string, len(s.personas)+1)
+    for chatID, persona := range s.personas {
+        personas[chatID] = persona
+    }
+    return personas
+}
+
+// save writes personas to disk and, once that succeeds, makes them the
+// store's, so a failed write leaves memory matching the file; callers must
+// hold the write lock
+func (s *PersonaStore) save(personas map[int64]string) error {
+    stored := make(map[string]string, len(personas))
+    for chatID, persona := range personas {
+        stored[strconv.FormatInt(chatID, 10)] = persona
+    }
+
+    data, err := json.MarshalIndent(stored, "", "  ")
+    if err != nil {
+        return fmt.Errorf("failed to marshal personas: %w", err)
+    }
+    if err := os.MkdirAll(filepath.Dir(s.path), 0755); err != nil {
+        return fmt.Errorf("failed to create directory: %w", err)
+    }
+    if err := os.WriteFile(s.path, data, 0644); err != nil {
+        return err
+    }
+    s.personas = personas
+    return nil
+}
@@ -0,0 +1,157 @@
+package storage
+
+import (
+    "fmt"
+    "path/filepath"
+    "strconv"
+    "strings"
+    "sync"
+    "time"
+    "anondd/utils/models"
+)
+
+// MaxFavorites bounds how many agents one user can pin
+const MaxFavorites = 20
+
+// UserProfile holds the preferences a Telegram user picked during onboarding
+type UserProfile struct {
+    UserID      int64     `json:"user_id"`
+    Username    string    `json:"username,omitempty"`
+    Language    string    `json:"language,omitempty"`  // Language code, e.g. "en"
+    Persona     string    `json:"persona,omitempty"`   // Persona preset name
+    Verbosity   string    `json:"verbosity,omitempty"` // Reply verbosity chosen with /style
+    Alerts      bool      `json:"alerts"`
+    Onboarded   bool      `json:"onboarded"`
+    OnboardedAt time.Time `json:"onboarded_at,omitempty"`
+    UpdatedAt   time.Time `json:"updated_at"`
+
+    // Agents pinned with /fav for quick access
+    Favorites []FavoriteAgent `json:"favorites,omitempty"`
+}
+
+// FavoriteAgent is an agent a user pinned for quick access
+type FavoriteAgent struct {
+    SourceID int       `json:"source_id,omitempty"` // Stable virtuals ID; record IDs change with price
+    AgentID  string    `json:"agent_id"`
+    Name     string    `json:"name"`
+    AddedAt  time.Time `json:"added_at"`
+}
+
+// Matches reports whether the favorite refers to the given agent
+func (f FavoriteAgent) Matches(sourceID int, agentID string) bool {
+    if f.SourceID != 0 && sourceID != 0 {
+        return f.SourceID == sourceID
+    }
+    return f.AgentID == agentID
+}
+
+// ProfileStore persists user profiles, shared by every bot
+type ProfileStore struct {
+    path     string
+    mu       sync.RWMutex
+    profiles map[string]UserProfile
+}
+
+// NewProfileStore creates a profile store backed by profiles.json in baseDir
+func NewProfileStore(baseDir string) (*ProfileStore, error) {
+    store := &ProfileStore{
+        path:     filepath.Join(baseDir, "profiles.json"),
+        profiles: make(map[string]UserProfile),
+    }
+    if err := readJSONFile(store.path, &store.profiles); err != nil {
+        return store, err
+    }
+    return store, nil
+}
+
+// Get returns a user's profile, if they have one
+func (s *ProfileStore) Get(userID int64) (UserProfile, bool) {
+    s.mu.RLock()
+    defer s.mu.RUnlock()
+    profile, exists := s.profiles[strconv.FormatInt(userID, 10)]
+    return profile, exists
+}
+
+// Update applies change to a user's profile, creating it if needed, and saves it
+func (s *ProfileStore) Update(userID int64, change func(*UserProfile)) (UserProfile, error) {
+    s.mu.Lock()
+    defer s.mu.Unlock()
+
+    key := strconv.FormatInt(userID, 10)
+    profile, exists := s.profiles[key]
+    if !exists {
+        profile = UserProfile{UserID: userID}
+    }
+    change(&profile)
+    profile.UpdatedAt = time.Now()
+    s.profiles[key] = profile
+    return profile, writeJSONFile(s.path, s.profiles)
+}
+
+// Favorites returns the agents a user pinned, in the order they were added
+func (s *ProfileStore) Favorites(userID int64) []FavoriteAgent {
+    profile, _ := s.Get(userID)
+    return append([]FavoriteAgent(nil), profile.Favorites...)
+}
+
+// AddFavorite pins an agent for a user; it returns false if already pinned
+// and ErrFull once the user has MaxFavorites
+func (s *ProfileStore) AddFavorite(userID int64, agent *models.Agent) (bool, error) {
+    added := false
+    var full bool
+    _, err := s.Update(userID, func(p *UserProfile) {
+        for _, favorite := range p.Favorites {
+            if favorite.Matches(agent.SourceID, agent.ID) {
+                return
+            }
+        }
+        if len(p.Favorites) >= MaxFavorites {
+            full = true
+            return
+        }
+        p.Favorites = append(p.Favorites, FavoriteAgent{
+            SourceID: agent.SourceID,
+            AgentID:  agent.ID,
+            Name:     agent.Name,
+            AddedAt:  time.Now(),
+        })
+        added = true
+    })
+    if err == nil && full {
+        err = fmt.Errorf("at most %d favorites: %w", MaxFavorites, ErrFull)
+    }
+    return added, err
+}
+
+// RemoveFavorite unpins the first favorite whose name contains query
+// (case-insensitive) or whose agent ID equals it
+func (s *ProfileStore) RemoveFavorite(userID int64, query string) (*FavoriteAgent, error) {
+    query = strings.ToLower(strings.TrimSpace(query))
+    var removed *FavoriteAgent
+    _, err := s.Update(userID, func(p *UserProfile) {
+        for i, favorite := range p.Favorites {
+            if favorite.AgentID == query || strings.Contains(strings.ToLower(favorite.Name), query) {
+                removed = &favorite
+                p.Favorites = append(p.Favorites[:i:i], p.Favorites[i+1:]...)
+                return
+            }
+        }
+    })
+    if err != nil {
+        return nil, err
+    }
+    if removed == nil {
+        return nil, fmt.Errorf("no favorite matching '%s': %w", query, ErrNotFound)
+    }
+    return removed, nil
+}
+
+// IsFavorite reports whether a user pinned the agent
+func (s *ProfileStore) IsFavorite(userID int64, sourceID int, agentID string) bool {
+    for _, favorite := range s.Favorites(userID) {
+        if favorite.Matches(sourceID, agentID) {
+            return true
+        }
+    }
+    return false
+}
@@ -0,0 +1,147 @@
+package storage
+
+import (
+    "path/filepath"
+    "strconv"
+    "sync"
+    "time"
+    "anondd/utils/models"
+)
+
+// HeldMessage is a notification waiting for a chat's quiet hours to end, or
+// for the chat's next digest
+type HeldMessage struct {
+    Bot    string    `json:"bot"`
+    ChatID int64     `json:"chat_id"`
+    Text   string    `json:"text"`
+    HeldAt time.Time `json:"held_at"`
+
+    // The agent the message is about, if any, so catch-ups can put
+    // favorites first
+    SourceID int    `json:"source_id,omitempty"`
+    AgentID  string `json:"agent_id,omitempty"`
+
+    // Digest marks an informational update waiting for the next digest
+    // rather than for quiet hours to end
+    Digest bool `json:"digest,omitempty"`
+}
+
+// quietState is the persisted quiet hours file
+type quietState struct {
+    Hours map[string]models.QuietHours `json:"hours"` // By chat ID
+    Held  []HeldMessage                `json:"held"`
+}
+
+// QuietHoursStore persists per-chat quiet hours and the notifications held
+// during them
+type QuietHoursStore struct {
+    path     string
+    mu       sync.Mutex
+    state    quietState
+    location *time.Location
+}
+
+// NewQuietHoursStore creates a quiet hours store backed by quiet_hours.json in baseDir
+func NewQuietHoursStore(baseDir string) (*QuietHoursStore, error) {
+    store := &QuietHoursStore{
+        path:     filepath.Join(baseDir, "quiet_hours.json"),
+        state:    quietState{Hours: make(map[string]models.QuietHours)},
+        location: time.Local,
+    }
+    if err := readJSONFile(store.path, &store.state); err != nil {
+        return store, err
+    }
+    if store.state.Hours == nil {
+        store.state.Hours = make(map[string]models.QuietHours)
+    }
+    return store, nil
+}
+
+// SetLocation sets the time zone for quiet hours that don't name their own
+func (s *QuietHoursStore) SetLocation(loc *time.Location) {
+    s.mu.Lock()
+    defer s.mu.Unlock()
+    s.location = loc
+}
+
+// Set replaces a chat's quiet hours
+func (s *QuietHoursStore) Set(chatID int64, hours models.QuietHours) error {
+    s.mu.Lock()
+    defer s.mu.Unlock()
+    s.state.Hours[strconv.FormatInt(chatID, 10)] = hours
+    return writeJSONFile(s.path, s.state)
+}
+
+// Clear removes a chat's quiet hours; it returns false if none were set.
+// Messages already held are delivered on the next release.
+func (s *QuietHoursStore) Clear(chatID int64) (bool, error) {
+    s.mu.Lock()
+    defer s.mu.Unlock()
+    key := strconv.FormatInt(chatID, 10)
+    if _, exists := s.state.Hours[key]; !exists {
+        return false, nil
+    }
+    delete(s.state.Hours, key)
+    return true, writeJSONFile(s.path, s.state)
+}
+
+// Get returns a chat's quiet hours
+func (s *QuietHoursStore) Get(chatID int64) (models.QuietHours, bool) {
+    s.mu.Lock()
+    defer s.mu.Unlock()
+    hours, exists := s.state.Hours[strconv.FormatInt(chatID, 10)]
+    return hours, exists
+}
+
+// IsQuiet reports whether the chat is inside its quiet hours at now
+func (s *QuietHoursStore) IsQuiet(chatID int64, now time.Time) bool {
+    s.mu.Lock()
+    defer s.mu.Unlock()
+    return s.isQuiet(chatID, now)
+}
+
+// isQuiet is IsQuiet for callers holding mu
+func (s *QuietHoursStore) isQuiet(chatID int64, now time.Time) bool {
+    hours, exists := s.state.Hours[strconv.FormatInt(chatID, 10)]
+    return exists && hours.Active(now, s.location)
+}
+
+// Hold stores a message until the chat's quiet hours end, or until the next
+// digest if it is marked Digest
+func (s *QuietHoursStore) Hold(message HeldMessage) error {
+    s.mu.Lock()
+    defer s.mu.Unlock()
+    s.state.Held = append(s.state.Held, message)
+    return writeJSONFile(s.path, s.state)
+}
+
+// Release removes and returns a bot's held messages for chats no longer in
+// quiet hours at now, oldest first. Digest messages wait for ReleaseDigest.
+func (s *QuietHoursStore) Release(bot string, now time.Time) ([]HeldMessage, error) {
+    return s.release(bot, now, false)
+}
+
+// ReleaseDigest removes and returns a bot's digest messages for chats not in
+// quiet hours at now, oldest first
+func (s *QuietHoursStore) ReleaseDigest(bot string, now time.Time) ([]HeldMessage, error) {
+    return s.release(bot, now, true)
+}
+
+func (s *QuietHoursStore) release(bot string, now time.Time, digest bool) ([]HeldMessage, error) {
+    s.mu.Lock()
+    defer s.mu.Unlock()
+
+    var released, kept []HeldMessage
+    for _, message := range s.state.Held {
+        if message.Bot == bot && message.Digest == digest && !s.isQuiet(message.ChatID, now) {
+            released = append(released, message)
+        } else {
+            kept = append(kept, message)
+        }
+    }
+    if len(released) == 0 {
+        return nil, nil
+    }
+    s.state.Held = kept
+    return released, writeJSONFile(s.path, s.state)
+}
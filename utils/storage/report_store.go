@@ -0,0 +1,75 @@
+package storage
+
+import (
+    "fmt"
+    "os"
+    "path/filepath"
+    "sort"
+    "strings"
+    "anondd/utils/models"
+)
+
+func (s *AgentStore) reportDir(kind string) string {
+    return filepath.Join(s.BaseDir, "reports", kind)
+}
+
+// SaveReport stores a report under its kind, named by the end of its period
+func (s *AgentStore) SaveReport(report *models.Report) error {
+    path := filepath.Join(s.reportDir(report.Kind), report.PeriodEnd.Format("2006-01-02")+".json")
+    return writeJSONFile(path, report)
+}
+
+// reportNames lists the stored report files of a kind, oldest first
+func (s *AgentStore) reportNames(kind string) ([]string, error) {
+    entries, err := os.ReadDir(s.reportDir(kind))
+    if os.IsNotExist(err) {
+        return nil, nil
+    }
+    if err != nil {
+        return nil, fmt.Errorf("failed to read reports: %w", err)
+    }
+
+    var names []string
+    for _, entry := range entries {
+        if !entry.IsDir() && strings.HasSuffix(entry.Name(), ".json") {
+            names = append(names, entry.Name())
+        }
+    }
+    sort.Strings(names)
+    return names, nil
+}
+
+// Reports returns every stored report of the given kind, oldest first,
+// skipping unreadable ones
+func (s *AgentStore) Reports(kind string) ([]models.Report, error) {
+    names, err := s.reportNames(kind)
+    if err != nil {
+        return nil, err
+    }
+    reports := make([]models.Report, 0, len(names))
+    for _, name := range names {
+        var report models.Report
+        if err := readJSONFile(filepath.Join(s.reportDir(kind), name), &report); err != nil {
+            continue
+        }
+        reports = append(reports, report)
+    }
+    return reports, nil
+}
+
+// LatestReport returns the most recent report of the given kind
+func (s *AgentStore) LatestReport(kind string) (*models.Report, error) {
+    names, err := s.reportNames(kind)
+    if err != nil {
+        return nil, err
+    }
+    if len(names) == 0 {
+        return nil, fmt.Errorf("%s report: %w", kind, ErrNotFound)
+    }
+
+    var report models.Report
+    if err := readJSONFile(filepath.Join(s.reportDir(kind), names[len(names)-1]), &report); err != nil {
+        return nil, fmt.Errorf("%w: %w", ErrCorrupt, err)
+    }
+    return &report, nil
+}
@@ -0,0 +1,31 @@
+package storage
+
+import (
+    "context"
+    "encoding/json"
+    "fmt"
+    "anondd/utils/models"
+)
+
+// SetAgentRisk stores a risk score on the agent and in the index. Unlike
+// SaveAgent it leaves the scrape bookkeeping (LastChecked, UpdateCount) alone.
+func (s *AgentStore) SetAgentRisk(ctx context.Context, agentID string, risk *models.RiskScore) error {
+    s.batchMutex.Lock()
+    defer s.batchMutex.Unlock()
+
+    agent, err := s.GetAgentContext(ctx, agentID)
+    if err != nil {
+        return err
+    }
+    agent.Risk = risk
+
+    data, err := json.MarshalIndent(agent, "", "  ")
+    if err != nil {
+        return fmt.Errorf("failed to marshal agent: %w", err)
+    }
+    if err := s.agents.write(agent.ID, data); err != nil {
+        return err
+    }
+    s.invalidateAgent(agent.ID)
+    return s.MergeIndex([]models.Agent{*agent})
+}
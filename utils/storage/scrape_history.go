@@ -0,0 +1,97 @@
+package storage
+
+import (
+    "context"
+    "io/fs"
+    "path/filepath"
+    "anondd/utils/models"
+)
+
+// maxScrapeRuns caps the scrape run history kept on disk
+const maxScrapeRuns = 500
+
+func (s *AgentStore) scrapeRunsPath() string {
+    return filepath.Join(s.BaseDir, "scrape_runs.json")
+}
+
+// RecordScrapeRun appends a completed scrape cycle to the run history
+func (s *AgentStore) RecordScrapeRun(run models.ScrapeRun) error {
+    s.runsMu.Lock()
+    defer s.runsMu.Unlock()
+
+    var runs []models.ScrapeRun
+    if err := readJSONFile(s.scrapeRunsPath(), &runs); err != nil {
+        return err
+    }
+    runs = append(runs, run)
+    if len(runs) > maxScrapeRuns {
+        runs = runs[len(runs)-maxScrapeRuns:]
+    }
+    return writeJSONFile(s.scrapeRunsPath(), runs)
+}
+
+// ScrapeRuns returns the recorded runs for a source, oldest first. An empty
+// source returns the runs of every source.
+func (s *AgentStore) ScrapeRuns(source string) ([]models.ScrapeRun, error) {
+    s.runsMu.Lock()
+    defer s.runsMu.Unlock()
+
+    var runs []models.ScrapeRun
+    if err := readJSONFile(s.scrapeRunsPath(), &runs); err != nil {
+        return nil, err
+    }
+    if source == "" {
+        return runs, nil
+    }
+
+    var filtered []models.ScrapeRun
+    for _, run := range runs {
+        if run.Source == source {
+            filtered = append(filtered, run)
+        }
+    }
+    return filtered, nil
+}
+
+// StatusCounts returns how many indexed agents are in each status
+func (s *AgentStore) StatusCounts(ctx context.Context) (map[string]int, error) {
+    index, err := s.GetIndexContext(ctx)
+    if err != nil {
+        return nil, err
+    }
+
+    counts := make(map[string]int)
+    for _, summary := range index.Agents {
+        agent, err := s.GetAgentContext(ctx, summary.ID)
+        if err != nil {
+            counts["unreadable"]++
+            continue
+        }
+        status := agent.Status
+        if status == "" {
+            status = models.StatusDefault
+        }
+        counts[status]++
+    }
+    return counts, nil
+}
+
+// DiskUsage returns the total size in bytes of everything under the store's directory
+func (s *AgentStore) DiskUsage() (int64, error) {
+    var total int64
+    err := filepath.WalkDir(s.BaseDir, func(path string, entry fs.DirEntry, err error) error {
+        if err != nil {
+            return err
+        }
+        if entry.IsDir() {
+            return nil
+        }
+        info, err := entry.Info()
+        if err != nil {
+            return err
+        }
+        total += info.Size()
+        return nil
+    })
+    return total, err
+}
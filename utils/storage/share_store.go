@@ -0,0 +1,82 @@
+package storage
+
+import (
+    "crypto/rand"
+    "fmt"
+    "math/big"
+    "os"
+    "path/filepath"
+    "strings"
+    "anondd/utils/models"
+)
+
+const (
+    shareIDLength   = 8
+    shareIDAlphabet = "abcdefghijkmnopqrstuvwxyzABCDEFGHJKLMNPQRSTUVWXYZ23456789"
+)
+
+func (s *AgentStore) sharePath(id string) string {
+    return filepath.Join(s.BaseDir, "shares", id+".json")
+}
+
+// SaveShare stores a report under a new short random ID
+func (s *AgentStore) SaveShare(title, text string) (*models.SharedReport, error) {
+    for attempt := 0; attempt < 5; attempt++ {
+        id, err := newShareID()
+        if err != nil {
+            return nil, err
+        }
+        if _, err := os.Stat(s.sharePath(id)); err == nil {
+            continue
+        }
+
+        share := &models.SharedReport{ID: id, Title: title, Text: text, CreatedAt: s.clock.Now()}
+        if err := writeJSONFile(s.sharePath(id), share); err != nil {
+            return nil, err
+        }
+        return share, nil
+    }
+    return nil, fmt.Errorf("failed to allocate a unique share ID")
+}
+
+// GetShare loads a shared report by ID
+func (s *AgentStore) GetShare(id string) (*models.SharedReport, error) {
+    if !validShareID(id) {
+        return nil, fmt.Errorf("share %s: %w", id, ErrNotFound)
+    }
+    if _, err := os.Stat(s.sharePath(id)); os.IsNotExist(err) {
+        return nil, fmt.Errorf("share %s: %w", id, ErrNotFound)
+    }
+
+    var share models.SharedReport
+    if err := readJSONFile(s.sharePath(id), &share); err != nil {
+        return nil, fmt.Errorf("%w: %w", ErrCorrupt, err)
+    }
+    return &share, nil
+}
+
+func newShareID() (string, error) {
+    id := make([]byte, shareIDLength)
+    max := big.NewInt(int64(len(shareIDAlphabet)))
+    for i := range id {
+        n, err := rand.Int(rand.Reader, max)
+        if err != nil {
+            return "", fmt.Errorf("failed to generate share ID: %w", err)
+        }
+        id[i] = shareIDAlphabet[n.Int64()]
+    }
+    return string(id), nil
+}
+
+// validShareID rejects anything that isn't a generated ID, keeping lookups inside shares/
+func validShareID(id string) bool {
+    if len(id) != shareIDLength {
+        return false
+    }
+    for _, c := range id {
+        if !strings.ContainsRune(shareIDAlphabet, c) {
+            return false
+        }
+    }
+    return true
+}
@@ -0,0 +1,59 @@
+package storage
+
+import (
+    "context"
+    "strings"
+    "time"
+    "anondd/utils/shared"
+)
+
+const (
+    // agentUpdatesChannel carries "agent:<id>" and "index" invalidations between instances
+    agentUpdatesChannel = "anondd:agent_updates"
+    fetchedKeyPrefix    = "anondd:fetched:"
+
+    // refetchAfter is how long a fetched agent counts as fresh for ShouldFetch
+    refetchAfter = 24 * time.Hour
+)
+
+// EnableSharedState lets several instances over the same data directory stay
+// consistent: the fetch cache moves into the shared store, and every agent or
+// index write tells the other instances to drop their cached copy. Only the
+// per-file storage format is safe to share; the compact log keeps its offset
+// index in memory. Call it before the store is used.
+func (s *AgentStore) EnableSharedState(ctx context.Context, store shared.Store) {
+    s.shared = store
+    go func() {
+        err := store.Subscribe(ctx, agentUpdatesChannel, func(message string) {
+            if id, ok := strings.CutPrefix(message, "agent:"); ok {
+                s.cache.invalidateAgent(id)
+            } else if message == "index" {
+                s.cache.invalidateIndex()
+            }
+        })
+        if err != nil {
+            s.logger.Printf("Error subscribing to agent updates: %v", err)
+        }
+    }()
+}
+
+// invalidateAgent drops the agent from this instance's cache and the others'
+func (s *AgentStore) invalidateAgent(id string) {
+    s.cache.invalidateAgent(id)
+    s.publishUpdate("agent:" + id)
+}
+
+// invalidateIndex drops the index from this instance's cache and the others'
+func (s *AgentStore) invalidateIndex() {
+    s.cache.invalidateIndex()
+    s.publishUpdate("index")
+}
+
+func (s *AgentStore) publishUpdate(message string) {
+    if s.shared == nil {
+        return
+    }
+    if err := s.shared.Publish(context.Background(), agentUpdatesChannel, message); err != nil {
+        s.logger.Printf("Error publishing agent update: %v", err)
+    }
+}
@@ -0,0 +1,31 @@
+package storage
+
+import (
+    "context"
+    "encoding/json"
+    "fmt"
+    "anondd/utils/models"
+)
+
+// SetAgentSocials stores verified social links on the agent. Like
+// SetAgentRisk it leaves the scrape bookkeeping alone.
+func (s *AgentStore) SetAgentSocials(ctx context.Context, agentID string, socials []models.SocialLink) error {
+    s.batchMutex.Lock()
+    defer s.batchMutex.Unlock()
+
+    agent, err := s.GetAgentContext(ctx, agentID)
+    if err != nil {
+        return err
+    }
+    agent.Socials = socials
+
+    data, err := json.MarshalIndent(agent, "", "  ")
+    if err != nil {
+        return fmt.Errorf("failed to marshal agent: %w", err)
+    }
+    if err := s.agents.write(agent.ID, data); err != nil {
+        return err
+    }
+    s.invalidateAgent(agent.ID)
+    return nil
+}
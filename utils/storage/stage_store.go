@@ -0,0 +1,121 @@
+package storage
+
+import (
+    "fmt"
+    "sort"
+    "strconv"
+    "time"
+    "anondd/utils/events"
+    "anondd/utils/models"
+)
+
+// StageVersion is a launch stage an agent was seen in. A new version is
+// recorded when the stage changes or the agent comes near to graduating.
+type StageVersion struct {
+    Stage    string    `json:"stage"`
+    Progress float64   `json:"progress,omitempty"` // Bonding curve progress when seen, in percent
+    Near     bool      `json:"near,omitempty"`     // At or past NearGraduationProgress
+    SeenAt   time.Time `json:"seen_at"`
+}
+
+// RecordStage stores the launch stage seen for a scraped source ID and
+// appends a stage_changed event when it moved to another stage, or a
+// near_graduation event when its bonding curve came close to filling. The
+// first stage seen is a baseline, not a change.
+func (s *AgentStore) RecordStage(agent *models.Agent) (*models.ChangeEvent, error) {
+    event, err := s.recordStage(agent)
+    if event != nil {
+        s.events.Publish(events.AgentChanged{Change: *event})
+    }
+    return event, err
+}
+
+func (s *AgentStore) recordStage(agent *models.Agent) (*models.ChangeEvent, error) {
+    if agent.Stage == "" {
+        return nil, nil
+    }
+
+    s.changes.mu.Lock()
+    defer s.changes.mu.Unlock()
+    if err := s.changes.ensureLoaded(); err != nil {
+        return nil, err
+    }
+
+    key := strconv.Itoa(agent.SourceID)
+    history := s.changes.stages[key]
+    now := s.clock.Now()
+    current := StageVersion{Stage: agent.Stage, Progress: agent.BondingProgress, Near: agent.NearGraduation(), SeenAt: now}
+
+    var previous StageVersion
+    if len(history) > 0 {
+        previous = history[len(history)-1]
+        if previous.Stage == current.Stage && previous.Near == current.Near {
+            return nil, nil
+        }
+    }
+    s.changes.stages[key] = append(history, current)
+    if err := writeJSONFile(s.changes.stagesPath, s.changes.stages); err != nil {
+        return nil, err
+    }
+    if len(history) == 0 {
+        return nil, nil
+    }
+
+    event := models.ChangeEvent{
+        ID:        fmt.Sprintf("%s-%d", key, now.UnixNano()),
+        AgentID:   agent.ID,
+        SourceID:  agent.SourceID,
+        AgentName: agent.Name,
+        Before:    models.FormatStage(previous.Stage, previous.Progress),
+        After:     models.FormatStage(current.Stage, current.Progress),
+        At:        now,
+    }
+    switch {
+    case previous.Stage != current.Stage && current.Stage == models.StageSentient:
+        event.Type = models.ChangeStageChanged
+        event.Summary = "Graduated from its bonding curve"
+    case previous.Stage != current.Stage:
+        event.Type = models.ChangeStageChanged
+        event.Summary = "Launch stage changed"
+    case current.Near:
+        event.Type = models.ChangeNearGraduation
+        event.Summary = fmt.Sprintf("About to graduate, bonding curve %s%% full", strconv.FormatFloat(current.Progress, 'f', -1, 64))
+    default:
+        // Dropped back below the threshold; recorded so the next climb alerts again
+        return nil, nil
+    }
+    if err := s.appendChange(event); err != nil {
+        return nil, err
+    }
+    return &event, nil
+}
+
+// StageHistory returns every launch stage recorded for a source ID, oldest first
+func (s *AgentStore) StageHistory(sourceID int) ([]StageVersion, error) {
+    s.changes.mu.Lock()
+    defer s.changes.mu.Unlock()
+    if err := s.changes.ensureLoaded(); err != nil {
+        return nil, err
+    }
+    return append([]StageVersion(nil), s.changes.stages[strconv.Itoa(sourceID)]...), nil
+}
+
+// NearGraduation returns the indexed agents about to graduate, furthest
+// along their bonding curve first
+func (s *AgentStore) NearGraduation() ([]models.AgentSummary, error) {
+    index, err := s.GetIndex()
+    if err != nil {
+        return nil, err
+    }
+
+    var near []models.AgentSummary
+    for _, summary := range index.Agents {
+        if summary.NearGraduation() {
+            near = append(near, summary)
+        }
+    }
+    sort.Slice(near, func(i, j int) bool {
+        return near[i].BondingProgress > near[j].BondingProgress
+    })
+    return near, nil
+}
@@ -0,0 +1,61 @@
+package storage
+
+import (
+    "path/filepath"
+    "sync"
+)
+
+// SubscriberStore persists which chats subscribed to alerts, per bot
+type SubscriberStore struct {
+    path        string
+    mu          sync.Mutex
+    subscribers map[string][]int64
+}
+
+// NewSubscriberStore creates a subscriber store backed by filename in baseDir
+func NewSubscriberStore(baseDir, filename string) (*SubscriberStore, error) {
+    store := &SubscriberStore{
+        path:        filepath.Join(baseDir, filename),
+        subscribers: make(map[string][]int64),
+    }
+    if err := readJSONFile(store.path, &store.subscribers); err != nil {
+        return store, err
+    }
+    return store, nil
+}
+
+// Subscribe adds a chat to a bot's subscribers; it returns false if already subscribed
+func (s *SubscriberStore) Subscribe(bot string, chatID int64) (bool, error) {
+    s.mu.Lock()
+    defer s.mu.Unlock()
+
+    for _, id := range s.subscribers[bot] {
+        if id == chatID {
+            return false, nil
+        }
+    }
+    s.subscribers[bot] = append(s.subscribers[bot], chatID)
+    return true, writeJSONFile(s.path, s.subscribers)
+}
+
+// Unsubscribe removes a chat from a bot's subscribers; it returns false if it was not subscribed
+func (s *SubscriberStore) Unsubscribe(bot string, chatID int64) (bool, error) {
+    s.mu.Lock()
+    defer s.mu.Unlock()
+
+    chats := s.subscribers[bot]
+    for i, id := range chats {
+        if id == chatID {
+            s.subscribers[bot] = append(chats[:i:i], chats[i+1:]...)
+            return true, writeJSONFile(s.path, s.subscribers)
+        }
+    }
+    return false, nil
+}
+
+// Subscribers returns the chats subscribed to a bot's alerts
+func (s *SubscriberStore) Subscribers(bot string) []int64 {
+    s.mu.Lock()
+    defer s.mu.Unlock()
+    return append([]int64(nil), s.subscribers[bot]...)
+}
@@ -0,0 +1,138 @@
+package storage
+
+import (
+    "fmt"
+    "path/filepath"
+    "strings"
+    "sync"
+    "time"
+    "anondd/utils/models"
+)
+
+// WatchEntry is one agent on a chat's shared watchlist
+type WatchEntry struct {
+    SourceID int       `json:"source_id,omitempty"` // Stable virtuals ID; record IDs change with price
+    AgentID  string    `json:"agent_id"`
+    Name     string    `json:"name"`
+    AddedBy  string    `json:"added_by,omitempty"`
+    AddedAt  time.Time `json:"added_at"`
+}
+
+// matches reports whether the entry refers to the given agent
+func (e WatchEntry) matches(sourceID int, agentID string) bool {
+    if e.SourceID != 0 && sourceID != 0 {
+        return e.SourceID == sourceID
+    }
+    return e.AgentID == agentID
+}
+
+// WatchlistStore persists shared watchlists, one per bot and chat, so every
+// member of a group chat manages and is alerted about the same agents
+type WatchlistStore struct {
+    path  string
+    mu    sync.Mutex
+    lists map[string][]WatchEntry
+}
+
+// NewWatchlistStore creates a watchlist store backed by watchlists.json in baseDir
+func NewWatchlistStore(baseDir string) (*WatchlistStore, error) {
+    store := &WatchlistStore{
+        path:  filepath.Join(baseDir, "watchlists.json"),
+        lists: make(map[string][]WatchEntry),
+    }
+    if err := readJSONFile(store.path, &store.lists); err != nil {
+        return store, err
+    }
+    return store, nil
+}
+
+func watchlistKey(bot string, chatID int64) string {
+    return fmt.Sprintf("%s:%d", bot, chatID)
+}
+
+// Add puts an agent on the chat's watchlist; it returns false if already watched
+func (s *WatchlistStore) Add(bot string, chatID int64, agent *models.Agent, addedBy string) (bool, error) {
+    s.mu.Lock()
+    defer s.mu.Unlock()
+
+    key := watchlistKey(bot, chatID)
+    for _, entry := range s.lists[key] {
+        if entry.matches(agent.SourceID, agent.ID) {
+            return false, nil
+        }
+    }
+    s.lists[key] = append(s.lists[key], WatchEntry{
+        SourceID: agent.SourceID,
+        AgentID:  agent.ID,
+        Name:     agent.Name,
+        AddedBy:  addedBy,
+        AddedAt:  time.Now(),
+    })
+    return true, writeJSONFile(s.path, s.lists)
+}
+
+// Remove takes the first entry whose name contains query (case-insensitive)
+// or whose agent ID equals it off the chat's watchlist
+func (s *WatchlistStore) Remove(bot string, chatID int64, query string) (*WatchEntry, error) {
+    s.mu.Lock()
+    defer s.mu.Unlock()
+
+    key := watchlistKey(bot, chatID)
+    query = strings.ToLower(strings.TrimSpace(query))
+    entries := s.lists[key]
+    for i, entry := range entries {
+        if entry.AgentID == query || strings.Contains(strings.ToLower(entry.Name), query) {
+            if len(entries) == 1 {
+                delete(s.lists, key)
+            } else {
+                s.lists[key] = append(entries[:i:i], entries[i+1:]...)
+            }
+            return &entry, writeJSONFile(s.path, s.lists)
+        }
+    }
+    return nil, fmt.Errorf("no watched agent matching '%s': %w", query, ErrNotFound)
+}
+
+// List returns the chat's watchlist in the order agents were added
+func (s *WatchlistStore) List(bot string, chatID int64) []WatchEntry {
+    s.mu.Lock()
+    defer s.mu.Unlock()
+    return append([]WatchEntry(nil), s.lists[watchlistKey(bot, chatID)]...)
+}
+
+// Watches reports whether the chat's watchlist includes the agent
+func (s *WatchlistStore) Watches(bot string, chatID int64, sourceID int, agentID string) bool {
+    s.mu.Lock()
+    defer s.mu.Unlock()
+
+    for _, entry := range s.lists[watchlistKey(bot, chatID)] {
+        if entry.matches(sourceID, agentID) {
+            return true
+        }
+    }
+    return false
+}
+
+// Watchers returns the chats on a bot whose watchlist includes the agent
+func (s *WatchlistStore) Watchers(bot string, sourceID int, agentID string) []int64 {
+    s.mu.Lock()
+    defer s.mu.Unlock()
+
+    prefix := bot + ":"
+    var chats []int64
+    for key, entries := range s.lists {
+        if !strings.HasPrefix(key, prefix) {
+            continue
+        }
+        for _, entry := range entries {
+            if entry.matches(sourceID, agentID) {
+                var chatID int64
+                if _, err := fmt.Sscanf(strings.TrimPrefix(key, prefix), "%d", &chatID); err == nil {
+                    chats = append(chats, chatID)
+                }
+                break
+            }
+        }
+    }
+    return chats
+}
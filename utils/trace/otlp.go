@@ -0,0 +1,138 @@
+package trace
+
+import (
+    "bytes"
+    "encoding/json"
+    "fmt"
+    "log"
+    "net/http"
+    "strings"
+    "sync"
+    "time"
+    "anondd/utils/httpclient"
+)
+
+// otlpBatchSize is the number of spans buffered before an export request is sent
+const otlpBatchSize = 50
+
+// OTLPExporter sends spans to an OpenTelemetry collector using OTLP/HTTP JSON
+type OTLPExporter struct {
+    endpoint    string
+    serviceName string
+    client      *http.Client
+    logger      *log.Logger
+
+    mu      sync.Mutex
+    pending []*Span
+}
+
+// NewOTLPExporter creates an exporter posting to endpoint (e.g. http://localhost:4318)
+func NewOTLPExporter(endpoint, serviceName string, logger *log.Logger) *OTLPExporter {
+    return &OTLPExporter{
+        endpoint:    strings.TrimSuffix(endpoint, "/") + "/v1/traces",
+        serviceName: serviceName,
+        client:      httpclient.WithTimeout(10 * time.Second),
+        logger:      logger,
+    }
+}
+
+// Export buffers the span and flushes once a batch is full
+func (e *OTLPExporter) Export(span *Span) {
+    e.mu.Lock()
+    e.pending = append(e.pending, span)
+    if len(e.pending) < otlpBatchSize {
+        e.mu.Unlock()
+        return
+    }
+    batch := e.pending
+    e.pending = nil
+    e.mu.Unlock()
+
+    go e.send(batch)
+}
+
+// Flush sends any buffered spans synchronously
+func (e *OTLPExporter) Flush() {
+    e.mu.Lock()
+    batch := e.pending
+    e.pending = nil
+    e.mu.Unlock()
+
+    if len(batch) > 0 {
+        e.send(batch)
+    }
+}
+
+func (e *OTLPExporter) send(batch []*Span) {
+    body, err := json.Marshal(e.payload(batch))
+    if err != nil {
+        e.logger.Printf("Error encoding spans: %v", err)
+        return
+    }
+
+    resp, err := e.client.Post(e.endpoint, "application/json", bytes.NewReader(body))
+    if err != nil {
+        e.logger.Printf("Error exporting spans: %v", err)
+        return
+    }
+    defer resp.Body.Close()
+
+    if resp.StatusCode >= 300 {
+        e.logger.Printf("Span export rejected with status %d", resp.StatusCode)
+    }
+}
+
+type otlpAttribute struct {
+    Key   string `json:"key"`
+    Value struct {
+        StringValue string `json:"stringValue"`
+    } `json:"value"`
+}
+
+func otlpAttr(key, value string) otlpAttribute {
+    attr := otlpAttribute{Key: key}
+    attr.Value.StringValue = value
+    return attr
+}
+
+func (e *OTLPExporter) payload(batch []*Span) map[string]interface{} {
+    spans := make([]map[string]interface{}, 0, len(batch))
+    for _, span := range batch {
+        attrs := make([]otlpAttribute, 0, len(span.Attributes))
+        for key, value := range span.Attributes {
+            attrs = append(attrs, otlpAttr(key, value))
+        }
+
+        status := map[string]interface{}{"code": 1}
+        if span.Err != nil {
+            status = map[string]interface{}{"code": 2, "message": span.Err.Error()}
+        }
+
+        otlpSpan := map[string]interface{}{
+            "traceId":           span.TraceID,
+            "spanId":            span.SpanID,
+            "name":              span.Name,
+            "kind":              1,
+            "startTimeUnixNano": fmt.Sprintf("%d", span.Start.UnixNano()),
+            "endTimeUnixNano":   fmt.Sprintf("%d", span.End.UnixNano()),
+            "attributes":        attrs,
+            "status":            status,
+        }
+        if span.ParentID != "" {
+            otlpSpan["parentSpanId"] = span.ParentID
+        }
+        spans = append(spans, otlpSpan)
+    }
+
+    return map[string]interface{}{
+        "resourceSpans": []map[string]interface{}{{
+            "resource": map[string]interface{}{
+                "attributes": []otlpAttribute{otlpAttr("service.name", e.serviceName)},
+            },
+            "scopeSpans": []map[string]interface{}{{
+                "scope": map[string]string{"name": "anondd"},
+                "spans": spans,
+            }},
+        }},
+    }
+}
@@ -0,0 +1,144 @@
+package trace
+
+import (
+    "context"
+    "crypto/rand"
+    "encoding/hex"
+    "fmt"
+    "log"
+    "strings"
+    "sync"
+    "time"
+)
+
+type contextKey int
+
+const (
+    traceIDKey contextKey = iota
+    spanKey
+)
+
+// NewID returns a random 16-byte trace ID in hex, compatible with OpenTelemetry
+func NewID() string {
+    return randomHex(16)
+}
+
+// ValidID reports whether id is a trace ID as NewID makes them: 32 lowercase
+// hex characters, not all zero, which OpenTelemetry treats as invalid
+func ValidID(id string) bool {
+    if len(id) != 32 || strings.Trim(id, "0") == "" {
+        return false
+    }
+    for _, c := range id {
+        if (c < '0' || c > '9') && (c < 'a' || c > 'f') {
+            return false
+        }
+    }
+    return true
+}
+
+func randomHex(n int) string {
+    b := make([]byte, n)
+    if _, err := rand.Read(b); err != nil {
+        return fmt.Sprintf("%0*x", n*2, time.Now().UnixNano())
+    }
+    return hex.EncodeToString(b)
+}
+
+// WithID returns a context carrying the given trace ID
+func WithID(ctx context.Context, id string) context.Context {
+    return context.WithValue(ctx, traceIDKey, id)
+}
+
+// Ensure returns ctx unchanged if it already carries a trace ID, otherwise a
+// context with a fresh one
+func Ensure(ctx context.Context) context.Context {
+    if FromContext(ctx) != "" {
+        return ctx
+    }
+    return WithID(ctx, NewID())
+}
+
+// FromContext returns the trace ID carried by ctx, or "" if none
+func FromContext(ctx context.Context) string {
+    if ctx == nil {
+        return ""
+    }
+    id, _ := ctx.Value(traceIDKey).(string)
+    return id
+}
+
+// Logf logs through logger, prefixing the line with the context's trace ID
+func Logf(ctx context.Context, logger *log.Logger, format string, args ...interface{}) {
+    if id := FromContext(ctx); id != "" {
+        format = "[trace=" + id + "] " + format
+    }
+    logger.Output(2, fmt.Sprintf(format, args...))
+}
+
+// Span is a timed operation within a trace
+type Span struct {
+    TraceID    string
+    SpanID     string
+    ParentID   string
+    Name       string
+    Start      time.Time
+    End        time.Time
+    Attributes map[string]string
+    Err        error
+    once       sync.Once
+}
+
+// Exporter receives finished spans
+type Exporter interface {
+    Export(span *Span)
+}
+
+var (
+    exporterMu sync.RWMutex
+    exporter   Exporter
+)
+
+// SetExporter installs the exporter that receives finished spans; nil disables export
+func SetExporter(e Exporter) {
+    exporterMu.Lock()
+    defer exporterMu.Unlock()
+    exporter = e
+}
+
+// StartSpan starts a span named name under the context's trace, creating a
+// trace ID if needed. Call Finish on the returned span when the operation ends.
+func StartSpan(ctx context.Context, name string) (context.Context, *Span) {
+    ctx = Ensure(ctx)
+    span := &Span{
+        TraceID:    FromContext(ctx),
+        SpanID:     randomHex(8),
+        Name:       name,
+        Start:      time.Now(),
+        Attributes: make(map[string]string),
+    }
+    if parent, ok := ctx.Value(spanKey).(*Span); ok {
+        span.ParentID = parent.SpanID
+    }
+    return context.WithValue(ctx, spanKey, span), span
+}
+
+// SetAttribute records a key/value pair on the span
+func (s *Span) SetAttribute(key, value string) {
+    s.Attributes[key] = value
+}
+
+// Finish ends the span, recording err if non-nil, and hands it to the exporter
+func (s *Span) Finish(err error) {
+    s.once.Do(func() {
+        s.End = time.Now()
+        s.Err = err
+
+        exporterMu.RLock()
+        e := exporter
+        exporterMu.RUnlock()
+        if e != nil {
+            e.Export(s)
+        }
+    })
+}
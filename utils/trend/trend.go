@@ -0,0 +1,232 @@
+package trend
+
+import (
+    "fmt"
+    "strings"
+    "time"
+    "anondd/utils/models"
+    "anondd/utils/storage"
+)
+
+// Trend windows
+const (
+    ShortWindow    = 24 * time.Hour     // Short moving average and momentum lookback
+    LongWindow     = 7 * 24 * time.Hour // Long moving average, momentum lookback and fit window
+    ProjectionDays = 7                  // How many days ahead the fitted slope is extrapolated
+    MinSamples     = 3                  // Fewer snapshots than this give no trend
+)
+
+// Metric is the trend of one tracked metric
+type Metric struct {
+    Name         string   `json:"name"`
+    Latest       float64  `json:"latest"`
+    ShortAverage float64  `json:"short_average"`          // Mean over ShortWindow
+    LongAverage  float64  `json:"long_average"`           // Mean over LongWindow
+    Momentum24h  *float64 `json:"momentum_24h,omitempty"` // Percent change versus ~24h ago
+    Momentum7d   *float64 `json:"momentum_7d,omitempty"`  // Percent change versus ~7d ago
+    SlopePerDay  float64  `json:"slope_per_day"`          // Least-squares slope over LongWindow
+    Projection   float64  `json:"projection"`             // Latest plus the slope over ProjectionDays
+    Samples      int      `json:"samples"`
+}
+
+// Trend is the simple trend statistics of an agent's metric history
+type Trend struct {
+    SourceID       int       `json:"source_id"`
+    AgentID        string    `json:"agent_id"`
+    Name           string    `json:"name"`
+    ComputedAt     time.Time `json:"computed_at"`
+    Since          time.Time `json:"since,omitempty"` // Oldest snapshot used
+    Samples        int       `json:"samples"`
+    ProjectionDays int       `json:"projection_days"`
+    Metrics        []Metric  `json:"metrics"`
+}
+
+// Sufficient reports whether there was enough history to compute a trend
+func (t Trend) Sufficient() bool {
+    return len(t.Metrics) > 0
+}
+
+type point struct {
+    at    time.Time
+    value float64
+}
+
+// metricValues extracts the tracked metrics from a snapshot, by name
+var metricValues = []struct {
+    name  string
+    value func(storage.MetricSnapshot) (float64, bool)
+}{
+    {"price", func(s storage.MetricSnapshot) (float64, bool) { return models.ParseAmount(s.Price) }},
+    {"mcap", func(s storage.MetricSnapshot) (float64, bool) { return s.MCap, s.MCap > 0 }},
+    {"holders", func(s storage.MetricSnapshot) (float64, bool) { return s.Holders, s.Holders > 0 }},
+    {"volume_24h", func(s storage.MetricSnapshot) (float64, bool) { return s.Volume24h, s.Volume24h > 0 }},
+    {"mindshare", func(s storage.MetricSnapshot) (float64, bool) { return models.ParseAmount(s.Mindshare) }},
+}
+
+// Value reads a tracked metric from a snapshot by name: price, mcap, holders,
+// volume_24h or mindshare
+func Value(metric string, snapshot storage.MetricSnapshot) (float64, bool) {
+    for _, m := range metricValues {
+        if m.name == metric {
+            return m.value(snapshot)
+        }
+    }
+    return 0, false
+}
+
+// Compute derives moving averages, momentum and a linear projection from an
+// agent's metric history. Metrics with fewer than MinSamples values in the
+// long window are left out.
+func Compute(agent *models.Agent, history []storage.MetricSnapshot, now time.Time) Trend {
+    trend := Trend{
+        SourceID:       agent.SourceID,
+        AgentID:        agent.ID,
+        Name:           agent.Name,
+        ComputedAt:     now,
+        ProjectionDays: ProjectionDays,
+        Metrics:        []Metric{},
+    }
+
+    cutoff := now.Add(-LongWindow)
+    for _, snapshot := range history {
+        if snapshot.At.Before(cutoff) {
+            continue
+        }
+        if trend.Samples == 0 {
+            trend.Since = snapshot.At
+        }
+        trend.Samples++
+    }
+
+    for _, metric := range metricValues {
+        var points []point
+        for _, snapshot := range history {
+            if value, ok := metric.value(snapshot); ok {
+                points = append(points, point{at: snapshot.At, value: value})
+            }
+        }
+        if m, ok := computeMetric(metric.name, points, now); ok {
+            trend.Metrics = append(trend.Metrics, m)
+        }
+    }
+    return trend
+}
+
+func computeMetric(name string, points []point, now time.Time) (Metric, bool) {
+    recent := pointsSince(points, now.Add(-LongWindow))
+    if len(recent) < MinSamples {
+        return Metric{}, false
+    }
+
+    latest := recent[len(recent)-1].value
+    m := Metric{
+        Name:         name,
+        Latest:       latest,
+        ShortAverage: mean(pointsSince(recent, now.Add(-ShortWindow))),
+        LongAverage:  mean(recent),
+        Momentum24h:  momentum(points, latest, now.Add(-ShortWindow)),
+        Momentum7d:   momentum(points, latest, now.Add(-LongWindow)),
+        SlopePerDay:  slopePerDay(recent),
+        Samples:      len(recent),
+    }
+    m.Projection = latest + m.SlopePerDay*ProjectionDays
+    if m.Projection < 0 {
+        m.Projection = 0
+    }
+    return m, true
+}
+
+func pointsSince(points []point, cutoff time.Time) []point {
+    for i, p := range points {
+        if !p.at.Before(cutoff) {
+            return points[i:]
+        }
+    }
+    return nil
+}
+
+func mean(points []point) float64 {
+    if len(points) == 0 {
+        return 0
+    }
+    var total float64
+    for _, p := range points {
+        total += p.value
+    }
+    return total / float64(len(points))
+}
+
+// momentum is the percent change from the latest value at or before cutoff,
+// or nil if no value is that old
+func momentum(points []point, latest float64, cutoff time.Time) *float64 {
+    var base float64
+    for _, p := range points {
+        if p.at.After(cutoff) {
+            break
+        }
+        base = p.value
+    }
+    if base <= 0 {
+        return nil
+    }
+    change := (latest - base) / base * 100
+    return &change
+}
+
+// slopePerDay fits a least-squares line through the points, in units per day
+func slopePerDay(points []point) float64 {
+    origin := points[0].at
+    var sumX, sumY, sumXY, sumXX float64
+    for _, p := range points {
+        x := p.at.Sub(origin).Hours() / 24
+        sumX += x
+        sumY += p.value
+        sumXY += x * p.value
+        sumXX += x * x
+    }
+    n := float64(len(points))
+    denominator := n*sumXX - sumX*sumX
+    if denominator == 0 {
+        return 0
+    }
+    return (n*sumXY - sumX*sumY) / denominator
+}
+
+// Format lists the trend numbers, one metric per line
+func (t Trend) Format() string {
+    var b strings.Builder
+    fmt.Fprintf(&b, "%d snapshots since %s\n", t.Samples, t.Since.Format("2006-01-02 15:04"))
+    for _, m := range t.Metrics {
+        fmt.Fprintf(&b, "- %s: latest %s, 24h avg %s, 7d avg %s, 24h %s, 7d %s, slope %s/day, %dd projection %s\n",
+            m.Name, formatValue(m.Latest), formatValue(m.ShortAverage), formatValue(m.LongAverage),
+            formatPercent(m.Momentum24h), formatPercent(m.Momentum7d), formatValue(m.SlopePerDay),
+            t.ProjectionDays, formatValue(m.Projection))
+    }
+    return b.String()
+}
+
+func formatValue(v float64) string {
+    abs := v
+    if abs < 0 {
+        abs = -abs
+    }
+    switch {
+    case abs >= 1e9:
+        return fmt.Sprintf("%.2fB", v/1e9)
+    case abs >= 1e6:
+        return fmt.Sprintf("%.2fM", v/1e6)
+    case abs >= 1e3:
+        return fmt.Sprintf("%.2fK", v/1e3)
+    case abs >= 1 || abs == 0:
+        return fmt.Sprintf("%.2f", v)
+    default:
+        return fmt.Sprintf("%.6f", v)
+    }
+}
+
+func formatPercent(p *float64) string {
+    if p == nil {
+        return "n/a"
+    }
+    return fmt.Sprintf("%+.1f%%", *p)
+}
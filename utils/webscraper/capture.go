@@ -0,0 +1,207 @@
+package webscraper
+
+import (
+    "crypto/sha256"
+    "encoding/hex"
+    "encoding/json"
+    "fmt"
+    "os"
+    "path/filepath"
+    "regexp"
+    "strings"
+    "sync"
+    "anondd/utils/models"
+    "github.com/PuerkitoBio/goquery"
+)
+
+// Raw capture policies: when a fetched page's HTML and screenshot are kept
+// in the debug directory. Parsing never depends on these copies.
+const (
+    CaptureNever    = "never"     // Nothing is kept
+    CaptureOnChange = "on_change" // Kept when the page's content differs from its last capture
+    CaptureOnError  = "on_error"  // Kept when the page renders empty or fails to parse
+    CaptureAlways   = "always"    // Every fetch is kept
+)
+
+// DefaultCapturePolicy keeps a page only when its content changed, so
+// unchanged agents don't fill the disk every cycle
+const DefaultCapturePolicy = CaptureOnChange
+
+const (
+    captureDir        = "training_data/raw/debug"
+    captureHashesFile = "training_data/capture_hashes.json"
+)
+
+var captureSpacePattern = regexp.MustCompile(`\s+`)
+
+// ValidateCapturePolicy checks that policy is one of the capture policies
+func ValidateCapturePolicy(policy string) error {
+    switch policy {
+    case CaptureNever, CaptureOnChange, CaptureOnError, CaptureAlways:
+        return nil
+    }
+    return fmt.Errorf("unknown capture policy %q, use %s, %s, %s or %s",
+        policy, CaptureNever, CaptureOnChange, CaptureOnError, CaptureAlways)
+}
+
+// LoadCaptureConfig reads per-source capture policies from a JSON file keyed
+// by source name. A missing file configures nothing.
+func LoadCaptureConfig(path string) (map[string]string, error) {
+    data, err := os.ReadFile(path)
+    if os.IsNotExist(err) {
+        return map[string]string{}, nil
+    }
+    if err != nil {
+        return nil, fmt.Errorf("failed to read capture config: %w", err)
+    }
+
+    var config map[string]string
+    if err := json.Unmarshal(data, &config); err != nil {
+        return nil, fmt.Errorf("failed to unmarshal capture config: %w", err)
+    }
+    for source, policy := range config {
+        if err := ValidateCapturePolicy(policy); err != nil {
+            return nil, fmt.Errorf("capture policy for %s: %w", source, err)
+        }
+    }
+    return config, nil
+}
+
+// captureRecorder writes raw captures under a policy, remembering the
+// content hash of each ID's last capture for on_change
+type captureRecorder struct {
+    policy string
+    dir    string
+    path   string
+    mu     sync.Mutex
+    hashes map[string]string // Content hash of the last capture, by source ID
+}
+
+func newCaptureRecorder(policy, dir, path string) *captureRecorder {
+    return &captureRecorder{policy: policy, dir: dir, path: path}
+}
+
+// SetCapturePolicy sets when raw HTML and screenshots of fetched pages are
+// kept, for pages fetched from then on
+func (v *VirtualsScraper) SetCapturePolicy(policy string) error {
+    if err := ValidateCapturePolicy(policy); err != nil {
+        return err
+    }
+    v.capture.mu.Lock()
+    defer v.capture.mu.Unlock()
+    v.capture.policy = policy
+    return nil
+}
+
+// CapturePolicy returns the raw capture policy for the scraper's source
+func (v *VirtualsScraper) CapturePolicy() string {
+    v.capture.mu.Lock()
+    defer v.capture.mu.Unlock()
+    return v.capture.policy
+}
+
+// record keeps a fetched page if the policy calls for it. failed marks a
+// page that rendered empty or failed to parse.
+func (c *captureRecorder) record(id int, html string, screenshot []byte, failed bool, now int64) (bool, error) {
+    c.mu.Lock()
+    defer c.mu.Unlock()
+
+    switch c.policy {
+    case CaptureNever:
+        return false, nil
+    case CaptureOnError:
+        if !failed {
+            return false, nil
+        }
+    case CaptureOnChange:
+        if err := c.load(); err != nil {
+            return false, err
+        }
+        key := fmt.Sprintf("%d", id)
+        hash := contentHash(html)
+        if c.hashes[key] == hash {
+            return false, nil
+        }
+        c.hashes[key] = hash
+        if err := c.save(); err != nil {
+            return false, err
+        }
+    }
+
+    if err := os.MkdirAll(c.dir, 0755); err != nil {
+        return false, err
+    }
+    if len(screenshot) > 0 {
+        path := filepath.Join(c.dir, fmt.Sprintf("screenshot_%d_%d.png", id, now))
+        if err := os.WriteFile(path, screenshot, 0644); err != nil {
+            return false, fmt.Errorf("failed to save screenshot: %w", err)
+        }
+    }
+    path := filepath.Join(c.dir, fmt.Sprintf("page_%d_%d.html", id, now))
+    if err := os.WriteFile(path, []byte(html), 0644); err != nil {
+        return false, fmt.Errorf("failed to save HTML: %w", err)
+    }
+    return true, nil
+}
+
+// load reads the capture hashes once; callers must hold mu
+func (c *captureRecorder) load() error {
+    if c.hashes != nil {
+        return nil
+    }
+    c.hashes = make(map[string]string)
+    data, err := os.ReadFile(c.path)
+    if os.IsNotExist(err) {
+        return nil
+    }
+    if err != nil {
+        return err
+    }
+    return json.Unmarshal(data, &c.hashes)
+}
+
+// save writes the capture hashes; callers must hold mu
+func (c *captureRecorder) save() error {
+    if err := os.MkdirAll(filepath.Dir(c.path), 0755); err != nil {
+        return err
+    }
+    data, err := json.MarshalIndent(c.hashes, "", "  ")
+    if err != nil {
+        return err
+    }
+    return os.WriteFile(c.path, data, 0644)
+}
+
+// contentHash hashes a page's visible text, so markup churn such as script
+// nonces and build hashes doesn't count as a change but any value does
+func contentHash(html string) string {
+    text := html
+    if doc, err := goquery.NewDocumentFromReader(strings.NewReader(html)); err == nil {
+        doc.Find("script, style, noscript").Remove()
+        text = doc.Find("body").Text()
+    }
+    sum := sha256.Sum256([]byte(captureSpacePattern.ReplaceAllString(strings.TrimSpace(text), " ")))
+    return hex.EncodeToString(sum[:])
+}
+
+// capturePage keeps a fetched page under the capture policy, logging failures
+func (v *VirtualsScraper) capturePage(id int, html string, screenshot []byte, failed bool) {
+    kept, err := v.capture.record(id, html, screenshot, failed, v.now().Unix())
+    if err != nil {
+        v.logger.Printf("[WARN] Failed to capture raw page for ID %d: %v", id, err)
+        return
+    }
+    if kept {
+        v.logger.Printf("[DEBUG] Captured raw page for ID %d (%s)", id, v.CapturePolicy())
+    }
+}
+
+// captureParseFailure keeps a stored page that failed to parse when only
+// failures are captured. Empty renders were already kept when fetched, and
+// the other policies decided when the page was fetched.
+func (v *VirtualsScraper) captureParseFailure(id int, html string, err error) {
+    if v.CapturePolicy() != CaptureOnError || models.ScrapeErrorKind(err) == models.ScrapeErrEmpty {
+        return
+    }
+    v.capturePage(id, html, nil, true)
+}
@@ -0,0 +1,116 @@
+package webscraper
+
+import (
+    "encoding/json"
+    "fmt"
+    "os"
+    "path/filepath"
+    "time"
+    "anondd/utils/models"
+)
+
+const checkpointFile = "training_data/scrape_checkpoint.json"
+
+const (
+    scrapeChunkSize  = 25             // IDs fetched or pages parsed between checkpoints
+    maxCheckpointAge = 12 * time.Hour // Older interrupted runs start over
+)
+
+// scrapeCheckpoint is the persisted progress of an in-flight scrape cycle,
+// so a restarted process resumes it instead of starting over
+type scrapeCheckpoint struct {
+    StartedAt   time.Time      `json:"started_at"`
+    Profile     string         `json:"profile,omitempty"` // Scrape profile the run was started with
+    Stage       string         `json:"stage"` // StageFetch or StageParse
+    IDs         []int          `json:"ids"`   // IDs due when the run started
+    Next        int            `json:"next"`  // Index into IDs of the next ID to fetch
+    Fetched     int            `json:"fetched"`
+    FetchErrors int            `json:"fetch_errors"`
+    Quarantined int            `json:"quarantined"`
+    Dropped     int            `json:"dropped,omitempty"` // Fetched pages shed by backpressure
+    Found       int            `json:"found"`
+    ParseErrors int            `json:"parse_errors"`
+    ErrorKinds  map[string]int `json:"error_kinds,omitempty"` // Fetch and parse failures by kind
+    UpdatedAt   time.Time      `json:"updated_at"`
+}
+
+// profile is the name of the run's scrape profile; checkpoints from before
+// profiles existed were full runs
+func (cp *scrapeCheckpoint) profile() string {
+    if cp.Profile == "" {
+        return ProfileFull
+    }
+    return cp.Profile
+}
+
+// countError adds a fetch failure to the run's counts by kind
+func (cp *scrapeCheckpoint) countError(err error) {
+    if cp.ErrorKinds == nil {
+        cp.ErrorKinds = make(map[string]int)
+    }
+    countError(cp.ErrorKinds, err)
+}
+
+// countError adds err to counts by its ScrapeError kind; a nil map is ignored
+func countError(counts map[string]int, err error) {
+    if counts != nil {
+        counts[models.ScrapeErrorKind(err)]++
+    }
+}
+
+// loadCheckpoint returns the interrupted run to resume, or nil if there is
+// none or it is too old to be worth resuming
+func loadCheckpoint(path string, now time.Time) (*scrapeCheckpoint, error) {
+    data, err := os.ReadFile(path)
+    if os.IsNotExist(err) {
+        return nil, nil
+    }
+    if err != nil {
+        return nil, fmt.Errorf("failed to read scrape checkpoint: %w", err)
+    }
+
+    var checkpoint scrapeCheckpoint
+    if err := json.Unmarshal(data, &checkpoint); err != nil {
+        return nil, fmt.Errorf("failed to unmarshal scrape checkpoint: %w", err)
+    }
+    if now.Sub(checkpoint.UpdatedAt) > maxCheckpointAge {
+        return nil, nil
+    }
+    return &checkpoint, nil
+}
+
+// save persists the checkpoint atomically, stamped with now
+func (c *scrapeCheckpoint) save(path string, now time.Time) error {
+    c.UpdatedAt = now
+    data, err := json.Marshal(c)
+    if err != nil {
+        return fmt.Errorf("failed to marshal scrape checkpoint: %w", err)
+    }
+    if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+        return fmt.Errorf("failed to create directory: %w", err)
+    }
+    tmp := path + ".tmp"
+    if err := os.WriteFile(tmp, data, 0644); err != nil {
+        return fmt.Errorf("failed to write scrape checkpoint: %w", err)
+    }
+    return os.Rename(tmp, path)
+}
+
+// clearCheckpoint removes the checkpoint of a finished run
+func clearCheckpoint(path string) error {
+    if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+        return fmt.Errorf("failed to remove scrape checkpoint: %w", err)
+    }
+    return nil
+}
+
+// checkpoint persists the run's progress along with the scheduling state it
+// depends on; failures are logged since the scrape itself can continue
+func (v *VirtualsScraper) checkpoint(c *scrapeCheckpoint) {
+    if err := v.priority.Save(); err != nil {
+        v.logger.Printf("[ERROR] Failed to save scrape priority queue: %v", err)
+    }
+    if err := c.save(checkpointFile, v.now()); err != nil {
+        v.logger.Printf("[WARN] Failed to save scrape checkpoint: %v", err)
+    }
+}
@@ -0,0 +1,297 @@
+package webscraper
+
+import (
+    "encoding/json"
+    "errors"
+    "fmt"
+    "os"
+    "path/filepath"
+    "sort"
+    "sync"
+    "time"
+    "anondd/utils/models"
+)
+
+const (
+    deadLetterDir = "training_data/raw/dead_letter"
+
+    // DeadLetterThreshold is how many scrapes in a row must fail to parse an
+    // ID's page before the page is set aside as a dead letter
+    DeadLetterThreshold = 3
+)
+
+// ErrNoDeadLetter is returned when requeueing an ID without a dead letter
+var ErrNoDeadLetter = errors.New("no dead letter for that ID")
+
+// DeadLetter is a page that keeps failing to parse, kept with its raw HTML
+// until the parser is fixed and the page requeued
+type DeadLetter struct {
+    SourceID      int       `json:"source_id"`
+    URL           string    `json:"url"`
+    ErrorKind     string    `json:"error_kind"`
+    Error         string    `json:"error"`
+    Attempts      int       `json:"attempts"` // Failed parses in a row
+    FirstFailedAt time.Time `json:"first_failed_at"`
+    LastFailedAt  time.Time `json:"last_failed_at"`
+    DeadAt        time.Time `json:"dead_at,omitempty"` // Zero until Attempts reaches the threshold
+    HTMLPath      string    `json:"html_path,omitempty"`
+    Bytes         int       `json:"bytes,omitempty"`
+    Requeues      int       `json:"requeues,omitempty"`
+}
+
+// Dead reports whether the page was set aside, rather than only failing so far
+func (l DeadLetter) Dead() bool {
+    return !l.DeadAt.IsZero()
+}
+
+// DeadLetterStore tracks pages failing to parse and keeps a copy of those
+// that fail DeadLetterThreshold times in a row, so the page queue can move
+// on and purge them without losing what broke the parser
+type DeadLetterStore struct {
+    dir     string
+    path    string
+    mu      sync.Mutex
+    letters map[int]*DeadLetter
+}
+
+// NewDeadLetterStore loads the dead letters kept in dir
+func NewDeadLetterStore(dir string) (*DeadLetterStore, error) {
+    store := &DeadLetterStore{dir: dir, path: filepath.Join(dir, "letters.json"), letters: make(map[int]*DeadLetter)}
+    data, err := os.ReadFile(store.path)
+    if os.IsNotExist(err) {
+        return store, nil
+    }
+    if err != nil {
+        return store, fmt.Errorf("failed to read dead letters: %w", err)
+    }
+    if err := json.Unmarshal(data, &store.letters); err != nil {
+        return store, fmt.Errorf("failed to unmarshal dead letters: %w", err)
+    }
+    return store, nil
+}
+
+func (s *DeadLetterStore) htmlPath(id int) string {
+    return filepath.Join(s.dir, fmt.Sprintf("%d.html", id))
+}
+
+// RecordFailure counts a failed parse of an ID's page. It reports whether
+// this failure set the page aside; pages already set aside get their copy
+// and error refreshed.
+func (s *DeadLetterStore) RecordFailure(id int, url, html string, cause error, now time.Time) (bool, error) {
+    s.mu.Lock()
+    defer s.mu.Unlock()
+
+    letter, exists := s.letters[id]
+    if !exists {
+        letter = &DeadLetter{SourceID: id, FirstFailedAt: now}
+        s.letters[id] = letter
+    }
+    letter.URL = url
+    letter.Attempts++
+    letter.LastFailedAt = now
+    letter.ErrorKind = models.ScrapeErrorKind(cause)
+    letter.Error = cause.Error()
+
+    deadNow := !letter.Dead() && letter.Attempts >= DeadLetterThreshold
+    if letter.Dead() || deadNow {
+        if err := os.MkdirAll(s.dir, 0755); err != nil {
+            return false, fmt.Errorf("failed to create dead letter directory: %w", err)
+        }
+        if err := os.WriteFile(s.htmlPath(id), []byte(html), 0644); err != nil {
+            return false, fmt.Errorf("failed to write dead letter page: %w", err)
+        }
+        letter.HTMLPath = s.htmlPath(id)
+        letter.Bytes = len(html)
+        if deadNow {
+            letter.DeadAt = now
+        }
+    }
+    return deadNow, s.save()
+}
+
+// Resolve forgets an ID whose page parsed, deleting its dead letter if it had one
+func (s *DeadLetterStore) Resolve(id int) error {
+    s.mu.Lock()
+    defer s.mu.Unlock()
+
+    letter, exists := s.letters[id]
+    if !exists {
+        return nil
+    }
+    delete(s.letters, id)
+    if letter.HTMLPath != "" {
+        if err := os.Remove(letter.HTMLPath); err != nil && !os.IsNotExist(err) {
+            return fmt.Errorf("failed to remove dead letter page: %w", err)
+        }
+    }
+    return s.save()
+}
+
+// Get returns the dead letter of an ID, if its page was set aside
+func (s *DeadLetterStore) Get(id int) (DeadLetter, bool) {
+    s.mu.Lock()
+    defer s.mu.Unlock()
+
+    letter, exists := s.letters[id]
+    if !exists || !letter.Dead() {
+        return DeadLetter{}, false
+    }
+    return *letter, true
+}
+
+// List returns the dead letters, optionally only those of one error kind,
+// most recently failed first
+func (s *DeadLetterStore) List(kind string) []DeadLetter {
+    s.mu.Lock()
+    defer s.mu.Unlock()
+
+    var letters []DeadLetter
+    for _, letter := range s.letters {
+        if letter.Dead() && (kind == "" || letter.ErrorKind == kind) {
+            letters = append(letters, *letter)
+        }
+    }
+    sort.Slice(letters, func(i, j int) bool {
+        if !letters[i].LastFailedAt.Equal(letters[j].LastFailedAt) {
+            return letters[i].LastFailedAt.After(letters[j].LastFailedAt)
+        }
+        return letters[i].SourceID < letters[j].SourceID
+    })
+    return letters
+}
+
+// LoadHTML returns the raw page kept for a dead letter
+func (s *DeadLetterStore) LoadHTML(id int) (string, error) {
+    letter, exists := s.Get(id)
+    if !exists {
+        return "", ErrNoDeadLetter
+    }
+    html, err := os.ReadFile(letter.HTMLPath)
+    if err != nil {
+        return "", fmt.Errorf("failed to read dead letter page: %w", err)
+    }
+    return string(html), nil
+}
+
+// markRequeued counts a requeue of a dead letter
+func (s *DeadLetterStore) markRequeued(id int) error {
+    s.mu.Lock()
+    defer s.mu.Unlock()
+
+    if letter, exists := s.letters[id]; exists {
+        letter.Requeues++
+    }
+    return s.save()
+}
+
+// save writes the letters to disk; callers must hold mu
+func (s *DeadLetterStore) save() error {
+    data, err := json.MarshalIndent(s.letters, "", "  ")
+    if err != nil {
+        return fmt.Errorf("failed to marshal dead letters: %w", err)
+    }
+    if err := os.MkdirAll(s.dir, 0755); err != nil {
+        return fmt.Errorf("failed to create dead letter directory: %w", err)
+    }
+    return os.WriteFile(s.path, data, 0644)
+}
+
+// DeadLetters returns the store of pages that keep failing to parse
+func (v *VirtualsScraper) DeadLetters() *DeadLetterStore {
+    return v.deadLetters
+}
+
+// RequeueResult is the outcome of requeueing dead letters
+type RequeueResult struct {
+    Requeued int   `json:"requeued"`
+    Parsed   int   `json:"parsed"`            // Requeued pages that parsed and left the dead letters
+    Failed   int   `json:"failed"`            // Requeued pages that failed again and stay dead letters
+    Missing  []int `json:"missing,omitempty"` // IDs without a dead letter
+}
+
+// RequeueDeadLetters puts the kept pages of the given dead letters, or of all
+// of them if ids is empty, back in the page queue and parses them with the
+// current parser, e.g. after a parser fix. Their IDs leave quarantine, and
+// pages that parse leave the dead letters. Like a scrape it returns
+// ErrScrapeInProgress if another scrape or reparse is running.
+func (v *VirtualsScraper) RequeueDeadLetters(ids []int) (RequeueResult, error) {
+    var result RequeueResult
+    if !v.runMu.TryLock() {
+        return result, ErrScrapeInProgress
+    }
+    defer v.runMu.Unlock()
+
+    if len(ids) == 0 {
+        for _, letter := range v.deadLetters.List("") {
+            ids = append(ids, letter.SourceID)
+        }
+    }
+    var requeued []int
+    for _, id := range ids {
+        letter, exists := v.deadLetters.Get(id)
+        if !exists {
+            result.Missing = append(result.Missing, id)
+            continue
+        }
+        html, err := v.deadLetters.LoadHTML(id)
+        if err != nil {
+            return result, err
+        }
+        if err := v.pages.Enqueue(id, letter.URL, html); err != nil {
+            return result, fmt.Errorf("failed to requeue page %d: %w", id, err)
+        }
+        if _, err := v.store.ResetFailures(fmt.Sprintf("%d", id)); err != nil {
+            v.logger.Printf("[WARN] Failed to release %d from quarantine: %v", id, err)
+        }
+        if err := v.deadLetters.markRequeued(id); err != nil {
+            v.logger.Printf("[WARN] Failed to update dead letter %d: %v", id, err)
+        }
+        requeued = append(requeued, id)
+    }
+    result.Requeued = len(requeued)
+    if len(requeued) == 0 {
+        return result, nil
+    }
+
+    v.logger.Printf("[DEADLETTER] Requeueing %d pages", len(requeued))
+    v.snapshotIndex("requeue")
+    v.parsePages(requeued, false, nil, nil)
+    for _, id := range requeued {
+        if _, stillDead := v.deadLetters.Get(id); stillDead {
+            result.Failed++
+        } else {
+            result.Parsed++
+        }
+    }
+    return result, nil
+}
+
+// recordParseOutcome tracks whether an ID's page parsed. Only scrape parses
+// and requeued dead letters count towards a dead letter; reparses of stored
+// pages would count the same page again.
+func (v *VirtualsScraper) recordParseOutcome(id int, html string, cause error, track bool) {
+    if v.deadLetters == nil {
+        return
+    }
+    if cause == nil {
+        if err := v.deadLetters.Resolve(id); err != nil {
+            v.logger.Printf("[WARN] Failed to resolve dead letter %d: %v", id, err)
+        }
+        return
+    }
+    // Empty renders are usually IDs without an agent yet, not parser bugs
+    if models.ScrapeErrorKind(cause) == models.ScrapeErrEmpty {
+        return
+    }
+    if _, dead := v.deadLetters.Get(id); !track && !dead {
+        return
+    }
+    dead, err := v.deadLetters.RecordFailure(id, fmt.Sprintf("%s/virtuals/%d", v.baseURL, id), html, cause, v.now())
+    if err != nil {
+        v.logger.Printf("[WARN] Failed to record dead letter %d: %v", id, err)
+        return
+    }
+    if dead {
+        v.logger.Printf("[DEADLETTER] Page %d failed to parse %d times in a row, set aside: %v", id, DeadLetterThreshold, cause)
+    }
+}
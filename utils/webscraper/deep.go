@@ -0,0 +1,44 @@
+package webscraper
+
+import (
+    "context"
+    "fmt"
+    "strings"
+    "anondd/utils/models"
+    "github.com/PuerkitoBio/goquery"
+)
+
+// DeepScrape fetches one agent's page with a screenshot, outside of any
+// scrape run, and saves and indexes the parsed agent. Scheduling, failure and
+// anomaly tracking are left to full scrapes, so a deep scrape started by an
+// anomaly can't start another. The screenshot is empty when the fetcher
+// can't capture one.
+func (v *VirtualsScraper) DeepScrape(ctx context.Context, id int) (*models.Agent, []byte, error) {
+    endpoint := fmt.Sprintf("/virtuals/%d", id)
+    v.logger.Printf("[DEEP] Deep scraping agent %d", id)
+
+    page, err := v.fetchPage(withScreenshots(ctx, true), endpoint)
+    if err != nil {
+        return nil, nil, err
+    }
+    if err := v.storePage(fetchedPage{id: id, url: v.baseURL + endpoint, html: page.HTML}); err != nil {
+        return nil, nil, err
+    }
+
+    doc, err := goquery.NewDocumentFromReader(strings.NewReader(page.HTML))
+    if err != nil {
+        return nil, nil, err
+    }
+    agent, err := v.parseAgentPage(doc, id, true)
+    if err != nil {
+        if markErr := v.pages.MarkParsed(id, err); markErr != nil {
+            v.logger.Printf("[WARN] Failed to update page metadata for ID %d: %v", id, markErr)
+        }
+        return nil, nil, err
+    }
+    agent, err = v.persistAgent(id, agent, false, nil)
+    if err != nil {
+        return nil, nil, err
+    }
+    return agent, page.Screenshot, nil
+}
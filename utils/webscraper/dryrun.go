@@ -0,0 +1,193 @@
+package webscraper
+
+import (
+    "context"
+    "encoding/json"
+    "errors"
+    "fmt"
+    "os"
+    "strconv"
+    "strings"
+    "time"
+
+    "anondd/utils/models"
+    "anondd/utils/storage"
+    "github.com/PuerkitoBio/goquery"
+)
+
+// DryRunDefaultLimit is how many due IDs a dry run checks when none are given
+const DryRunDefaultLimit = 10
+
+// FieldChange is one field that a scrape would change
+type FieldChange struct {
+    Field  string `json:"field"`
+    Before string `json:"before"`
+    After  string `json:"after"`
+}
+
+// DryRunResult is what a scrape would do for one agent ID
+type DryRunResult struct {
+    SourceID  int           `json:"source_id"`
+    Name      string        `json:"name,omitempty"`
+    AgentID   string        `json:"agent_id,omitempty"`
+    Error     string        `json:"error,omitempty"`
+    Stage     string        `json:"stage,omitempty"` // Stage that failed, fetch or parse
+    NewRecord bool          `json:"new_record"`      // No stored agent has this ID yet
+    Changes   []FieldChange `json:"changes,omitempty"`
+}
+
+// DryRunReport summarizes a scrape that fetched and parsed but wrote nothing
+type DryRunReport struct {
+    StartedAt time.Time      `json:"started_at"`
+    Duration  time.Duration  `json:"duration"`
+    Fetcher   string         `json:"fetcher"`
+    Attempted int            `json:"attempted"`
+    Parsed    int            `json:"parsed"`
+    Failed    int            `json:"failed"`
+    New       int            `json:"new"`
+    Changed   int            `json:"changed"`
+    Unchanged int            `json:"unchanged"`
+    Results   []DryRunResult `json:"results"`
+}
+
+// DryRun fetches and parses the given agent IDs, or the most urgent
+// DryRunDefaultLimit due IDs if none are given, and reports what a scrape
+// would change without writing pages, agents, history or debug files.
+func (v *VirtualsScraper) DryRun(ids []int) (*DryRunReport, error) {
+    if len(ids) == 0 {
+        ids = v.priority.DueIDs(startAgentID, maxAgentID, v.now())
+        if len(ids) > DryRunDefaultLimit {
+            ids = ids[:DryRunDefaultLimit]
+        }
+    }
+
+    report := &DryRunReport{StartedAt: time.Now(), Fetcher: v.fetcher.Name()}
+    for _, id := range ids {
+        result := v.dryRunID(id)
+        report.Attempted++
+        switch {
+        case result.Error != "":
+            report.Failed++
+        case result.NewRecord:
+            report.Parsed++
+            report.New++
+        case len(result.Changes) > 0:
+            report.Parsed++
+            report.Changed++
+        default:
+            report.Parsed++
+            report.Unchanged++
+        }
+        report.Results = append(report.Results, result)
+    }
+    report.Duration = time.Since(report.StartedAt)
+
+    v.logger.Printf("[DRY RUN] %d IDs: %d parsed, %d failed, %d new, %d changed",
+        report.Attempted, report.Parsed, report.Failed, report.New, report.Changed)
+    return report, nil
+}
+
+func (v *VirtualsScraper) dryRunID(id int) DryRunResult {
+    result := DryRunResult{SourceID: id}
+
+    page, err := v.fetchPage(context.Background(), fmt.Sprintf("/virtuals/%d", id))
+    if err != nil {
+        result.Stage, result.Error = StageFetch, err.Error()
+        return result
+    }
+    doc, err := goquery.NewDocumentFromReader(strings.NewReader(page.HTML))
+    var agent *models.Agent
+    if err == nil {
+        agent, err = v.parseAgentPage(doc, id, false)
+    }
+    if err != nil {
+        result.Stage, result.Error = StageParse, err.Error()
+        return result
+    }
+
+    result.Name, result.AgentID = agent.Name, agent.ID
+    if _, err := v.store.GetAgent(agent.ID); errors.Is(err, storage.ErrNotFound) {
+        result.NewRecord = true
+    }
+
+    // Compare with the last parse of this source ID, since a price change
+    // gives the agent a new record ID
+    if previous, err := loadParsedAgent(id); err == nil {
+        result.Changes = diffAgentFields(previous, agent)
+    } else if !os.IsNotExist(err) {
+        v.logger.Printf("[WARN] Failed to load previous parse for %d: %v", id, err)
+    }
+    return result
+}
+
+// loadParsedAgent reads the agent saved by the last parse of a source ID
+func loadParsedAgent(id int) (*models.Agent, error) {
+    data, err := os.ReadFile(parsedAgentPath(id))
+    if err != nil {
+        return nil, err
+    }
+    var agent models.Agent
+    if err := json.Unmarshal(data, &agent); err != nil {
+        return nil, fmt.Errorf("failed to unmarshal parsed agent: %w", err)
+    }
+    return &agent, nil
+}
+
+// diffAgentFields lists the scraped fields that differ between two parses
+func diffAgentFields(before, after *models.Agent) []FieldChange {
+    fields := []struct {
+        name          string
+        before, after string
+    }{
+        {"name", before.Name, after.Name},
+        {"price", before.Price, after.Price},
+        {"description", before.Description, after.Description},
+        {"contract_address", before.ContractAddress, after.ContractAddress},
+        {"creator_address", before.CreatorAddress, after.CreatorAddress},
+        {"category", before.Category, after.Category},
+        {"stage", before.Stage, after.Stage},
+        {"bonding_progress", formatProgress(before.BondingProgress), formatProgress(after.BondingProgress)},
+        {"mindshare", before.InfluenceMetrics.Mindshare, after.InfluenceMetrics.Mindshare},
+        {"impressions", before.InfluenceMetrics.Impressions, after.InfluenceMetrics.Impressions},
+        {"engagement", before.InfluenceMetrics.Engagement, after.InfluenceMetrics.Engagement},
+        {"followers", before.InfluenceMetrics.Followers, after.InfluenceMetrics.Followers},
+        {"smart_followers", before.InfluenceMetrics.SmartFollowers, after.InfluenceMetrics.SmartFollowers},
+        {"top_tweets", before.InfluenceMetrics.TopTweets, after.InfluenceMetrics.TopTweets},
+        {"mc_fdv", before.TokenData.MCFDV, after.TokenData.MCFDV},
+        {"change_24h", before.TokenData.Change24h, after.TokenData.Change24h},
+        {"tvl", before.TokenData.TVL, after.TokenData.TVL},
+        {"holders", before.TokenData.Holders, after.TokenData.Holders},
+        {"volume_24h", before.TokenData.Volume24h, after.TokenData.Volume24h},
+        {"inferences", before.TokenData.Inferences, after.TokenData.Inferences},
+    }
+
+    var changes []FieldChange
+    for _, field := range fields {
+        // Numbers shown differently, e.g. before normalization, aren't changes
+        if !models.SameAmount(field.before, field.after) {
+            changes = append(changes, FieldChange{Field: field.name, Before: field.before, After: field.after})
+        }
+    }
+    return changes
+}
+
+// formatProgress renders a bonding curve progress for field diffs, "" when unknown
+func formatProgress(progress float64) string {
+    if progress == 0 {
+        return ""
+    }
+    return strconv.FormatFloat(progress, 'f', -1, 64)
+}
+
+// ParseIDs reads agent IDs separated by commas or whitespace
+func ParseIDs(raw string) ([]int, error) {
+    var ids []int
+    for _, field := range strings.FieldsFunc(raw, func(r rune) bool { return r == ',' || r == ' ' }) {
+        id, err := strconv.Atoi(field)
+        if err != nil || id < startAgentID || id > maxAgentID {
+            return nil, fmt.Errorf("invalid agent ID %q", field)
+        }
+        ids = append(ids, id)
+    }
+    return ids, nil
+}
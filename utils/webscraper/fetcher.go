@@ -0,0 +1,353 @@
+package webscraper
+
+import (
+    "context"
+    "encoding/json"
+    "errors"
+    "fmt"
+    "io"
+    "log"
+    "net/http"
+    "net/url"
+    "os"
+    "regexp"
+    "strings"
+    "sync"
+    "time"
+
+    "anondd/utils/httpclient"
+    "anondd/utils/models"
+    "github.com/chromedp/chromedp"
+)
+
+// Fetcher backend types
+const (
+    FetcherChrome       = "chrome"        // Local headless Chrome
+    FetcherRemoteChrome = "remote_chrome" // browserless.io, Selenium grid or any CDP endpoint
+    FetcherRenderAPI    = "render_api"    // ScrapingBee-style rendering APIs
+)
+
+// fetchTimeout bounds a single page render on any backend
+const fetchTimeout = 60 * time.Second
+
+// Page is a rendered page returned by a Fetcher
+type Page struct {
+    HTML       string
+    Title      string
+    Screenshot []byte // Empty when the backend can't capture one
+}
+
+type screenshotsKey struct{}
+
+// withScreenshots tells fetchers whether the caller wants a screenshot of the
+// page; fetchers capture one by default
+func withScreenshots(ctx context.Context, screenshots bool) context.Context {
+    return context.WithValue(ctx, screenshotsKey{}, screenshots)
+}
+
+func wantsScreenshot(ctx context.Context) bool {
+    screenshots, set := ctx.Value(screenshotsKey{}).(bool)
+    return !set || screenshots
+}
+
+// Fetcher renders a URL into HTML
+type Fetcher interface {
+    Fetch(ctx context.Context, url string) (*Page, error)
+    Name() string
+}
+
+// FetcherConfig describes one fetcher backend
+type FetcherConfig struct {
+    Type   string            `json:"type"`
+    URL    string            `json:"url,omitempty"`     // CDP websocket or rendering API endpoint
+    APIKey string            `json:"api_key,omitempty"`
+    Params map[string]string `json:"params,omitempty"` // Extra API query params, e.g. country_code for geo routing
+    // Session carries cookies and localStorage between fetches on Chrome
+    // backends, shared by the source's backends and kept apart from other sources
+    Session bool `json:"session,omitempty"`
+}
+
+// LoadFetcherConfig reads per-source fetcher backends from a JSON file keyed by
+// source name. Missing files and sources fall back to local Chrome.
+func LoadFetcherConfig(path string) (map[string][]FetcherConfig, error) {
+    data, err := os.ReadFile(path)
+    if os.IsNotExist(err) {
+        return map[string][]FetcherConfig{}, nil
+    }
+    if err != nil {
+        return nil, fmt.Errorf("failed to read fetcher config: %w", err)
+    }
+
+    var config map[string][]FetcherConfig
+    if err := json.Unmarshal(data, &config); err != nil {
+        return nil, fmt.Errorf("failed to unmarshal fetcher config: %w", err)
+    }
+    for source, backends := range config {
+        for i, backend := range backends {
+            switch backend.Type {
+            case FetcherChrome:
+            case FetcherRemoteChrome, FetcherRenderAPI:
+                if backend.URL == "" {
+                    return nil, fmt.Errorf("fetcher %s/%d: %s needs a url", source, i+1, backend.Type)
+                }
+                if backend.Type == FetcherRenderAPI && backend.Session {
+                    return nil, fmt.Errorf("fetcher %s/%d: sessions need a chrome backend", source, i+1)
+                }
+            default:
+                return nil, fmt.Errorf("fetcher %s/%d: unknown type %q", source, i+1, backend.Type)
+            }
+        }
+    }
+    return config, nil
+}
+
+// NewFetcher builds the fetcher for a source, spreading requests over the
+// configured backends. Sources without backends render locally.
+func NewFetcher(source string, backends []FetcherConfig, logger *log.Logger) Fetcher {
+    if len(backends) == 0 {
+        return NewChromeFetcher("", logger)
+    }
+
+    fetchers := make([]Fetcher, 0, len(backends))
+    for _, backend := range backends {
+        if backend.Type == FetcherRenderAPI {
+            fetchers = append(fetchers, NewRenderAPIFetcher(backend))
+            continue
+        }
+        chrome := NewChromeFetcher("", logger)
+        if backend.Type == FetcherRemoteChrome {
+            chrome = NewChromeFetcher(backend.URL, logger)
+        }
+        if backend.Session {
+            chrome.session = sessionJar(source)
+        }
+        fetchers = append(fetchers, chrome)
+    }
+    if len(fetchers) == 1 {
+        return fetchers[0]
+    }
+    return &poolFetcher{fetchers: fetchers, logger: logger}
+}
+
+// ChromeFetcher renders pages with Chrome over the DevTools protocol, either
+// a local headless instance or a remote one when remoteURL is set
+type ChromeFetcher struct {
+    remoteURL string
+    session   *SessionJar // Carries cookies and localStorage between fetches when set
+    logger    *log.Logger
+}
+
+func NewChromeFetcher(remoteURL string, logger *log.Logger) *ChromeFetcher {
+    return &ChromeFetcher{remoteURL: remoteURL, logger: logger}
+}
+
+func (c *ChromeFetcher) Name() string {
+    if c.remoteURL != "" {
+        return FetcherRemoteChrome
+    }
+    return FetcherChrome
+}
+
+func (c *ChromeFetcher) Fetch(ctx context.Context, url string) (*Page, error) {
+    var allocCtx context.Context
+    var cancel context.CancelFunc
+    if c.remoteURL != "" {
+        allocCtx, cancel = chromedp.NewRemoteAllocator(ctx, c.remoteURL)
+    } else {
+        opts := append(chromedp.DefaultExecAllocatorOptions[:],
+            chromedp.Flag("headless", true),
+            chromedp.Flag("disable-gpu", true),
+            chromedp.Flag("no-sandbox", true),
+            chromedp.Flag("disable-dev-shm-usage", true),
+            chromedp.Flag("disable-web-security", true),
+            chromedp.UserAgent(httpclient.BrowserUserAgent),
+        )
+        if proxy := httpclient.DefaultConfig().ProxyURL; proxy != "" {
+            opts = append(opts, chromedp.ProxyServer(proxy))
+        }
+        allocCtx, cancel = chromedp.NewExecAllocator(ctx, opts...)
+    }
+    defer cancel()
+
+    taskCtx, cancel := chromedp.NewContext(allocCtx, chromedp.WithLogf(c.logger.Printf))
+    defer cancel()
+
+    taskCtx, cancel = context.WithTimeout(taskCtx, fetchTimeout)
+    defer cancel()
+
+    if c.session != nil {
+        // A fetch without the saved session is still worth making
+        if err := chromedp.Run(taskCtx, c.session.restore()); err != nil {
+            c.logger.Printf("[WARN] Failed to restore %s session: %v", c.session.source, err)
+        }
+    }
+
+    var page Page
+    actions := []chromedp.Action{
+        chromedp.Navigate(url),
+        chromedp.WaitVisible(`body`, chromedp.ByQuery),
+    }
+    actions = append(actions, chromeWaitActions(strategyFrom(ctx))...)
+    if wantsScreenshot(ctx) {
+        actions = append(actions, chromedp.CaptureScreenshot(&page.Screenshot))
+    }
+    actions = append(actions,
+        chromedp.Title(&page.Title),
+        chromedp.OuterHTML(`html`, &page.HTML, chromedp.ByQuery),
+    )
+    err := chromedp.Run(taskCtx, actions...)
+    if errors.Is(taskCtx.Err(), context.DeadlineExceeded) {
+        return nil, models.NewScrapeError(models.ScrapeErrTimeout, fmt.Errorf("page didn't load within %s: %w", fetchTimeout, err))
+    }
+    if err != nil {
+        return nil, fmt.Errorf("chrome automation failed: %w", err)
+    }
+    if c.session != nil {
+        if err := chromedp.Run(taskCtx, c.session.capture()); err != nil {
+            c.logger.Printf("[WARN] Failed to save %s session: %v", c.session.source, err)
+        }
+    }
+    return &page, nil
+}
+
+// RenderAPIFetcher renders pages through a ScrapingBee-style HTTP API that
+// takes the API key and target URL as query parameters and returns the HTML
+type RenderAPIFetcher struct {
+    config FetcherConfig
+    client *http.Client
+}
+
+func NewRenderAPIFetcher(config FetcherConfig) *RenderAPIFetcher {
+    return &RenderAPIFetcher{
+        config: config,
+        client: httpclient.WithTimeout(fetchTimeout),
+    }
+}
+
+func (r *RenderAPIFetcher) Name() string {
+    return FetcherRenderAPI
+}
+
+var titlePattern = regexp.MustCompile(`(?is)<title[^>]*>(.*?)</title>`)
+
+func (r *RenderAPIFetcher) Fetch(ctx context.Context, target string) (*Page, error) {
+    query := url.Values{}
+    query.Set("api_key", r.config.APIKey)
+    query.Set("url", target)
+    query.Set("render_js", "true")
+    for key, value := range r.config.Params {
+        query.Set(key, value)
+    }
+    for key, value := range renderAPIParams(strategyFrom(ctx)) {
+        query.Set(key, value)
+    }
+
+    endpoint := r.config.URL
+    if strings.Contains(endpoint, "?") {
+        endpoint += "&" + query.Encode()
+    } else {
+        endpoint += "?" + query.Encode()
+    }
+
+    req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+    if err != nil {
+        return nil, fmt.Errorf("failed to build render request: %w", err)
+    }
+    resp, err := r.client.Do(req)
+    if err != nil {
+        return nil, fmt.Errorf("render request failed: %w", err)
+    }
+    defer resp.Body.Close()
+
+    body, err := io.ReadAll(resp.Body)
+    if err != nil {
+        return nil, fmt.Errorf("failed to read render response: %w", err)
+    }
+    if resp.StatusCode != http.StatusOK {
+        return nil, models.NewScrapeError(statusErrorKind(resp.StatusCode),
+            fmt.Errorf("render API returned %s: %s", resp.Status, strings.TrimSpace(string(body))))
+    }
+
+    page := &Page{HTML: string(body)}
+    if match := titlePattern.FindStringSubmatch(page.HTML); match != nil {
+        page.Title = strings.TrimSpace(match[1])
+    }
+    return page, nil
+}
+
+// statusErrorKind classifies a failed HTTP status
+func statusErrorKind(status int) string {
+    switch status {
+    case http.StatusNotFound, http.StatusGone:
+        return models.ScrapeErrNotFound
+    case http.StatusUnauthorized, http.StatusForbidden, http.StatusTooManyRequests, http.StatusServiceUnavailable:
+        return models.ScrapeErrBlocked
+    case http.StatusGatewayTimeout, http.StatusRequestTimeout:
+        return models.ScrapeErrTimeout
+    default:
+        return models.ScrapeErrOther
+    }
+}
+
+// blockedTitles and notFoundTitles mark rendered pages that are bot
+// protection challenges or error pages rather than agent pages
+var (
+    blockedTitles  = []string{"just a moment", "attention required", "access denied", "are you a robot"}
+    notFoundTitles = []string{"404", "page not found", "not found"}
+)
+
+// checkPage classifies a page that rendered but isn't the requested content
+func checkPage(page *Page) error {
+    title := strings.ToLower(page.Title)
+    for _, marker := range blockedTitles {
+        if strings.Contains(title, marker) {
+            return models.NewScrapeError(models.ScrapeErrBlocked, fmt.Errorf("bot protection page %q", page.Title))
+        }
+    }
+    for _, marker := range notFoundTitles {
+        if strings.Contains(title, marker) {
+            return models.NewScrapeError(models.ScrapeErrNotFound, fmt.Errorf("error page %q", page.Title))
+        }
+    }
+    return nil
+}
+
+// poolFetcher rotates requests across backends, trying the next one when a
+// backend fails
+type poolFetcher struct {
+    fetchers []Fetcher
+    logger   *log.Logger
+
+    mu   sync.Mutex
+    next int
+}
+
+func (p *poolFetcher) Name() string {
+    names := make([]string, len(p.fetchers))
+    for i, fetcher := range p.fetchers {
+        names[i] = fetcher.Name()
+    }
+    return "pool(" + strings.Join(names, ",") + ")"
+}
+
+func (p *poolFetcher) Fetch(ctx context.Context, url string) (*Page, error) {
+    p.mu.Lock()
+    start := p.next
+    p.next = (p.next + 1) % len(p.fetchers)
+    p.mu.Unlock()
+
+    var lastErr error
+    for i := range p.fetchers {
+        fetcher := p.fetchers[(start+i)%len(p.fetchers)]
+        page, err := fetcher.Fetch(ctx, url)
+        if err == nil {
+            return page, nil
+        }
+        p.logger.Printf("[WARN] Fetcher %s failed for %s: %v", fetcher.Name(), url, err)
+        lastErr = err
+        if ctx.Err() != nil {
+            break
+        }
+    }
+    return nil, lastErr
+}
@@ -0,0 +1,311 @@
+package webscraper
+
+import (
+    "bytes"
+    "crypto/sha256"
+    "encoding/hex"
+    "encoding/json"
+    "fmt"
+    "log"
+    "net/url"
+    "os"
+    "path/filepath"
+    "regexp"
+    "sort"
+    "strings"
+    "time"
+    "anondd/utils/clock"
+    "anondd/utils/models"
+    "anondd/utils/storage"
+    "github.com/PuerkitoBio/goquery"
+)
+
+// DefaultFixtureDir holds the parser fixtures: anonymized agent pages, each
+// with the output the parser is expected to produce for it. Both are
+// committed, so changes to expected output show up in review.
+const DefaultFixtureDir = "utils/webscraper/testdata/parser"
+
+var (
+    fixtureCommentPattern = regexp.MustCompile(`(?s)<!--.*?-->`)
+    fixtureEmailPattern   = regexp.MustCompile(`[A-Za-z0-9._%+-]+@[A-Za-z0-9.-]+\.[A-Za-z]{2,}`)
+)
+
+// fixtureSecretParams are URL query parameters dropped from fixtures, since
+// they can identify the visitor or carry credentials
+var fixtureSecretParams = []string{"token", "key", "sig", "signature", "session", "auth", "ref", "referrer", "fbclid", "gclid"}
+
+// Fixture is the expected parse of one fixture page, stored next to it as
+// <name>.json
+type Fixture struct {
+    Name       string        `json:"name"`
+    SourceID   int           `json:"source_id"`
+    CapturedAt time.Time     `json:"captured_at"`     // The parse runs at this time, for relative launch dates
+    Error      string        `json:"error,omitempty"` // Expected parse error, if the page doesn't parse
+    Agent      *models.Agent `json:"agent,omitempty"`
+}
+
+// FixtureResult is how the current parser did on one fixture
+type FixtureResult struct {
+    Name       string        `json:"name"`
+    Error      string        `json:"error,omitempty"`      // The fixture couldn't be run
+    Mismatches []string      `json:"mismatches,omitempty"` // Top-level agent fields that differ from the expected output
+    Changes    []FieldChange `json:"changes,omitempty"`    // Scraped values that differ, for the mismatched fields
+}
+
+// Passed reports whether the parser reproduced the expected output
+func (r FixtureResult) Passed() bool {
+    return r.Error == "" && len(r.Mismatches) == 0
+}
+
+// AnonymizeHTML strips an archived page down to what the parser reads:
+// scripts, iframes and comments are removed, wallet addresses are replaced
+// with stable pseudonyms, and emails and identifying URL parameters dropped.
+func AnonymizeHTML(html string) (string, error) {
+    doc, err := goquery.NewDocumentFromReader(strings.NewReader(html))
+    if err != nil {
+        return "", fmt.Errorf("failed to parse page: %w", err)
+    }
+    doc.Find("script, noscript, iframe, link[rel=preload], link[rel=prefetch]").Remove()
+    doc.Find("[href], [src]").Each(func(i int, s *goquery.Selection) {
+        for _, attr := range []string{"href", "src"} {
+            if value, exists := s.Attr(attr); exists {
+                s.SetAttr(attr, scrubFixtureURL(value))
+            }
+        }
+    })
+    out, err := doc.Html()
+    if err != nil {
+        return "", fmt.Errorf("failed to render page: %w", err)
+    }
+
+    out = fixtureCommentPattern.ReplaceAllString(out, "")
+    out = fixtureEmailPattern.ReplaceAllString(out, "user@example.com")
+    out = contractAddressPattern.ReplaceAllStringFunc(out, pseudonymAddress)
+    return out, nil
+}
+
+// pseudonymAddress maps an address to a stable stand-in, so the same
+// address is replaced the same way across a page and across fixtures
+func pseudonymAddress(address string) string {
+    sum := sha256.Sum256([]byte("anondd-fixture:" + strings.ToLower(address)))
+    return "0x" + hex.EncodeToString(sum[:])[:40]
+}
+
+func scrubFixtureURL(raw string) string {
+    parsed, err := url.Parse(raw)
+    if err != nil || parsed.RawQuery == "" {
+        return raw
+    }
+    query := parsed.Query()
+    for name := range query {
+        lower := strings.ToLower(name)
+        if strings.HasPrefix(lower, "utm_") {
+            query.Del(name)
+            continue
+        }
+        for _, secret := range fixtureSecretParams {
+            if lower == secret {
+                query.Del(name)
+            }
+        }
+    }
+    parsed.RawQuery = query.Encode()
+    return parsed.String()
+}
+
+// AddFixtures copies the newest archived page of each agent ID into dir as
+// an anonymized fixture, with the current parser's output as its expected
+// result. Review the expected output before committing it.
+func (v *VirtualsScraper) AddFixtures(dir string, ids []int) ([]Fixture, error) {
+    if len(ids) == 0 {
+        return nil, fmt.Errorf("no agent IDs given")
+    }
+    snapshots, err := v.Snapshots(ids)
+    if err != nil {
+        return nil, err
+    }
+    // Snapshots are oldest first, so later ones replace earlier ones
+    newest := make(map[int]Snapshot)
+    for _, snapshot := range snapshots {
+        newest[snapshot.SourceID] = snapshot
+    }
+    if err := os.MkdirAll(dir, 0755); err != nil {
+        return nil, err
+    }
+
+    var fixtures []Fixture
+    for _, id := range ids {
+        snapshot, found := newest[id]
+        if !found {
+            return fixtures, fmt.Errorf("no archived page for agent %d", id)
+        }
+        raw, err := os.ReadFile(snapshot.Path)
+        if err != nil {
+            return fixtures, err
+        }
+        html, err := AnonymizeHTML(string(raw))
+        if err != nil {
+            return fixtures, fmt.Errorf("agent %d: %w", id, err)
+        }
+        name := fmt.Sprintf("agent_%d", id)
+        if err := os.WriteFile(filepath.Join(dir, name+".html"), []byte(html), 0644); err != nil {
+            return fixtures, err
+        }
+        fixture := expectFixture(name, id, snapshot.CapturedAt, html, v.logger)
+        if err := writeFixture(dir, fixture); err != nil {
+            return fixtures, err
+        }
+        fixtures = append(fixtures, fixture)
+    }
+    return fixtures, nil
+}
+
+// UpdateFixtures regenerates the expected output of every fixture in dir
+// with the current parser, after an intended parser change
+func UpdateFixtures(dir string, logger *log.Logger) ([]Fixture, error) {
+    names, err := fixtureNames(dir)
+    if err != nil {
+        return nil, err
+    }
+    var fixtures []Fixture
+    for _, name := range names {
+        previous, err := readFixture(dir, name)
+        if err != nil {
+            return fixtures, err
+        }
+        html, err := os.ReadFile(filepath.Join(dir, name+".html"))
+        if err != nil {
+            return fixtures, err
+        }
+        fixture := expectFixture(name, previous.SourceID, previous.CapturedAt, string(html), logger)
+        if err := writeFixture(dir, fixture); err != nil {
+            return fixtures, err
+        }
+        fixtures = append(fixtures, fixture)
+    }
+    return fixtures, nil
+}
+
+// CheckFixtures parses every fixture in dir with the current parser and
+// compares the result with its expected output
+func CheckFixtures(dir string, logger *log.Logger) ([]FixtureResult, error) {
+    names, err := fixtureNames(dir)
+    if err != nil {
+        return nil, err
+    }
+    var results []FixtureResult
+    for _, name := range names {
+        result := FixtureResult{Name: name}
+        expected, err := readFixture(dir, name)
+        if err != nil {
+            result.Error = err.Error()
+            results = append(results, result)
+            continue
+        }
+        html, err := os.ReadFile(filepath.Join(dir, name+".html"))
+        if err != nil {
+            result.Error = err.Error()
+            results = append(results, result)
+            continue
+        }
+
+        actual := expectFixture(name, expected.SourceID, expected.CapturedAt, string(html), logger)
+        if actual.Error != expected.Error {
+            result.Mismatches = append(result.Mismatches, "error")
+            result.Changes = append(result.Changes, FieldChange{Field: "error", Before: expected.Error, After: actual.Error})
+        }
+        if expected.Agent != nil && actual.Agent != nil {
+            result.Mismatches = append(result.Mismatches, mismatchedFields(expected.Agent, actual.Agent)...)
+            result.Changes = append(result.Changes, diffAgentFields(expected.Agent, actual.Agent)...)
+        } else if (expected.Agent == nil) != (actual.Agent == nil) && actual.Error == expected.Error {
+            result.Mismatches = append(result.Mismatches, "agent")
+        }
+        results = append(results, result)
+    }
+    return results, nil
+}
+
+// expectFixture runs the parser on a fixture page the way a scrape at
+// capturedAt would, with only the built-in selectors
+func expectFixture(name string, sourceID int, capturedAt time.Time, html string, logger *log.Logger) Fixture {
+    fixture := Fixture{Name: name, SourceID: sourceID, CapturedAt: capturedAt.UTC()}
+
+    // An empty store only supplies the clock; nothing is read or written
+    store := storage.NewAgentStore(filepath.Join(os.TempDir(), "anondd-fixtures"), logger)
+    store.SetClock(clock.NewFake(fixture.CapturedAt))
+    parser := &VirtualsScraper{logger: logger, store: store, selectors: &selectorConfig{Extra: make(map[string][]string)}}
+
+    doc, err := goquery.NewDocumentFromReader(strings.NewReader(html))
+    if err == nil {
+        fixture.Agent, err = parser.parseAgentPage(doc, sourceID, false)
+    }
+    if err != nil {
+        fixture.Error = err.Error()
+    }
+    return fixture
+}
+
+// mismatchedFields lists the top-level JSON fields that differ between two
+// agents, covering fields diffAgentFields doesn't compare
+func mismatchedFields(expected, actual *models.Agent) []string {
+    var before, after map[string]json.RawMessage
+    expectedJSON, _ := json.Marshal(expected)
+    actualJSON, _ := json.Marshal(actual)
+    if json.Unmarshal(expectedJSON, &before) != nil || json.Unmarshal(actualJSON, &after) != nil {
+        return []string{"agent"}
+    }
+
+    var fields []string
+    for field, value := range before {
+        if !bytes.Equal(value, after[field]) {
+            fields = append(fields, field)
+        }
+    }
+    for field := range after {
+        if _, exists := before[field]; !exists {
+            fields = append(fields, field)
+        }
+    }
+    sort.Strings(fields)
+    return fields
+}
+
+// fixtureNames lists the fixture pages in dir by name, without extension
+func fixtureNames(dir string) ([]string, error) {
+    paths, err := filepath.Glob(filepath.Join(dir, "*.html"))
+    if err != nil {
+        return nil, err
+    }
+    if len(paths) == 0 {
+        return nil, fmt.Errorf("no fixtures in %s", dir)
+    }
+    names := make([]string, 0, len(paths))
+    for _, path := range paths {
+        names = append(names, strings.TrimSuffix(filepath.Base(path), ".html"))
+    }
+    sort.Strings(names)
+    return names, nil
+}
+
+func readFixture(dir, name string) (Fixture, error) {
+    var fixture Fixture
+    data, err := os.ReadFile(filepath.Join(dir, name+".json"))
+    if os.IsNotExist(err) {
+        return fixture, fmt.Errorf("no expected output for %s", name)
+    }
+    if err != nil {
+        return fixture, err
+    }
+    if err := json.Unmarshal(data, &fixture); err != nil {
+        return fixture, fmt.Errorf("failed to unmarshal expected output for %s: %w", name, err)
+    }
+    return fixture, nil
+}
+
+func writeFixture(dir string, fixture Fixture) error {
+    data, err := json.MarshalIndent(fixture, "", "  ")
+    if err != nil {
+        return err
+    }
+    return os.WriteFile(filepath.Join(dir, fixture.Name+".json"), append(data, '\n'), 0644)
+}
@@ -0,0 +1,37 @@
+package webscraper
+
+import (
+    "io"
+    "log"
+    "strings"
+    "testing"
+)
+
+// fixtureTestDir is DefaultFixtureDir relative to this package, where go
+// test runs
+const fixtureTestDir = "testdata/parser"
+
+// TestParserFixtures parses every committed fixture page and fails on any
+// difference from its expected output. After an intended parser change,
+// regenerate the expected output with "agentctl fixtures update" and review it.
+func TestParserFixtures(t *testing.T) {
+    results, err := CheckFixtures(fixtureTestDir, log.New(io.Discard, "", 0))
+    if err != nil {
+        t.Fatalf("CheckFixtures: %v", err)
+    }
+    for _, result := range results {
+        if result.Passed() {
+            continue
+        }
+        if result.Error != "" {
+            t.Errorf("%s: %s", result.Name, result.Error)
+            continue
+        }
+        var changes []string
+        for _, change := range result.Changes {
+            changes = append(changes, change.Field+": "+change.Before+" -> "+change.After)
+        }
+        t.Errorf("%s: fields differ from expected output: %s\n%s",
+            result.Name, strings.Join(result.Mismatches, ", "), strings.Join(changes, "\n"))
+    }
+}
@@ -0,0 +1,199 @@
+package webscraper
+
+import (
+    "encoding/json"
+    "fmt"
+    "os"
+    "path/filepath"
+    "sort"
+    "strings"
+    "sync"
+    "time"
+    "anondd/utils/clock"
+)
+
+const pageQueueDir = "training_data/raw/pages"
+
+// PageMeta describes a fetched page waiting for, or already through, parsing
+type PageMeta struct {
+    SourceID   int       `json:"source_id"`
+    URL        string    `json:"url"`
+    FetchedAt  time.Time `json:"fetched_at"`
+    Bytes      int       `json:"bytes"`
+    Parsed     bool      `json:"parsed"`
+    ParsedAt   time.Time `json:"parsed_at,omitempty"`
+    ParseError string    `json:"parse_error,omitempty"`
+}
+
+// PageQueue stores the latest raw HTML per agent ID with metadata so parsing
+// can run, and re-run, independently of fetching
+type PageQueue struct {
+    dir   string
+    mu    sync.Mutex
+    clock clock.Clock
+}
+
+// NewPageQueue creates a queue rooted at dir
+func NewPageQueue(dir string) *PageQueue {
+    return &PageQueue{dir: dir, clock: clock.Real()}
+}
+
+// SetClock makes the queue stamp fetch and parse times from c. Call it
+// before the queue is used.
+func (q *PageQueue) SetClock(c clock.Clock) {
+    q.mu.Lock()
+    defer q.mu.Unlock()
+    q.clock = c
+}
+
+func (q *PageQueue) htmlPath(id int) string {
+    return filepath.Join(q.dir, fmt.Sprintf("%d.html", id))
+}
+
+func (q *PageQueue) metaPath(id int) string {
+    return filepath.Join(q.dir, fmt.Sprintf("%d.meta.json", id))
+}
+
+// Enqueue stores fetched HTML for an ID, replacing any earlier page, and marks it pending
+func (q *PageQueue) Enqueue(id int, url, html string) error {
+    q.mu.Lock()
+    defer q.mu.Unlock()
+
+    if err := os.MkdirAll(q.dir, 0755); err != nil {
+        return fmt.Errorf("failed to create queue directory: %w", err)
+    }
+    if err := os.WriteFile(q.htmlPath(id), []byte(html), 0644); err != nil {
+        return fmt.Errorf("failed to write page: %w", err)
+    }
+    return q.writeMeta(PageMeta{SourceID: id, URL: url, FetchedAt: q.clock.Now(), Bytes: len(html)})
+}
+
+// Load returns the stored HTML and metadata for an ID
+func (q *PageQueue) Load(id int) (string, PageMeta, error) {
+    q.mu.Lock()
+    defer q.mu.Unlock()
+
+    meta, err := q.readMeta(id)
+    if err != nil {
+        return "", PageMeta{}, err
+    }
+    html, err := os.ReadFile(q.htmlPath(id))
+    if err != nil {
+        return "", PageMeta{}, fmt.Errorf("failed to read page: %w", err)
+    }
+    return string(html), meta, nil
+}
+
+// MarkParsed records the outcome of parsing a stored page
+func (q *PageQueue) MarkParsed(id int, parseErr error) error {
+    q.mu.Lock()
+    defer q.mu.Unlock()
+
+    meta, err := q.readMeta(id)
+    if err != nil {
+        return err
+    }
+    meta.Parsed = true
+    meta.ParsedAt = q.clock.Now()
+    meta.ParseError = ""
+    if parseErr != nil {
+        meta.ParseError = parseErr.Error()
+    }
+    return q.writeMeta(meta)
+}
+
+// Pending returns IDs whose stored page has not been parsed yet
+func (q *PageQueue) Pending() ([]int, error) {
+    return q.list(func(meta PageMeta) bool { return !meta.Parsed })
+}
+
+// All returns every ID with a stored page
+func (q *PageQueue) All() ([]int, error) {
+    return q.list(func(PageMeta) bool { return true })
+}
+
+// Purgeable returns IDs whose page was parsed and fetched before cutoff
+func (q *PageQueue) Purgeable(cutoff time.Time) ([]int, error) {
+    return q.list(func(meta PageMeta) bool { return meta.Parsed && meta.FetchedAt.Before(cutoff) })
+}
+
+// Purge deletes parsed pages fetched before cutoff and returns how many were
+// removed. Pending pages are kept so nothing is lost before it is parsed.
+func (q *PageQueue) Purge(cutoff time.Time) (int, error) {
+    ids, err := q.Purgeable(cutoff)
+    if err != nil {
+        return 0, err
+    }
+
+    q.mu.Lock()
+    defer q.mu.Unlock()
+    removed := 0
+    for _, id := range ids {
+        // The page may have been fetched again since it was listed
+        if meta, err := q.readMeta(id); err != nil || !meta.Parsed || !meta.FetchedAt.Before(cutoff) {
+            continue
+        }
+        for _, path := range []string{q.htmlPath(id), q.metaPath(id)} {
+            if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+                return removed, fmt.Errorf("failed to remove page %d: %w", id, err)
+            }
+        }
+        removed++
+    }
+    return removed, nil
+}
+
+func (q *PageQueue) list(keep func(PageMeta) bool) ([]int, error) {
+    q.mu.Lock()
+    defer q.mu.Unlock()
+
+    entries, err := os.ReadDir(q.dir)
+    if os.IsNotExist(err) {
+        return nil, nil
+    }
+    if err != nil {
+        return nil, fmt.Errorf("failed to read queue directory: %w", err)
+    }
+
+    var ids []int
+    for _, entry := range entries {
+        if !strings.HasSuffix(entry.Name(), ".meta.json") {
+            continue
+        }
+        var id int
+        if _, err := fmt.Sscanf(entry.Name(), "%d.meta.json", &id); err != nil {
+            continue
+        }
+        meta, err := q.readMeta(id)
+        if err != nil {
+            continue
+        }
+        if keep(meta) {
+            ids = append(ids, id)
+        }
+    }
+    sort.Ints(ids)
+    return ids, nil
+}
+
+// readMeta loads metadata for an ID; callers must hold mu
+func (q *PageQueue) readMeta(id int) (PageMeta, error) {
+    data, err := os.ReadFile(q.metaPath(id))
+    if err != nil {
+        return PageMeta{}, fmt.Errorf("failed to read page metadata: %w", err)
+    }
+    var meta PageMeta
+    if err := json.Unmarshal(data, &meta); err != nil {
+        return PageMeta{}, fmt.Errorf("failed to unmarshal page metadata: %w", err)
+    }
+    return meta, nil
+}
+
+// writeMeta saves metadata for an ID; callers must hold mu
+func (q *PageQueue) writeMeta(meta PageMeta) error {
+    data, err := json.MarshalIndent(meta, "", "  ")
+    if err != nil {
+        return fmt.Errorf("failed to marshal page metadata: %w", err)
+    }
+    return os.WriteFile(q.metaPath(meta.SourceID), data, 0644)
+}
@@ -0,0 +1,66 @@
+package webscraper
+
+import (
+    "fmt"
+    "strconv"
+    "time"
+)
+
+// IdleCheck is what a scheduled run would have to do, decided before it
+// launches a browser
+type IdleCheck struct {
+    Profile     string `json:"profile"`
+    Selected    int    `json:"selected"`         // IDs the profile would cover now
+    NotDue      int    `json:"not_due"`          // IDs the profile covers that were scraped recently enough
+    Quarantined int    `json:"quarantined"`      // Selected IDs waiting out their retry backoff
+    Eligible    int    `json:"eligible"`         // Selected IDs a run would fetch
+    MinEligible int    `json:"min_eligible"`
+    Pending     int    `json:"pending"`          // Fetched pages still waiting to be parsed
+    Resume      bool   `json:"resume,omitempty"` // An interrupted run of the profile is waiting
+    Skip        bool   `json:"skip"`
+}
+
+// Reason explains the decision for the log
+func (c IdleCheck) Reason() string {
+    switch {
+    case c.Resume:
+        return "an interrupted run needs finishing"
+    case c.Pending > 0 && c.Eligible < c.MinEligible:
+        return fmt.Sprintf("%d fetched pages are waiting to be parsed", c.Pending)
+    case c.Skip:
+        return fmt.Sprintf("%d of %d IDs eligible, %d needed (%d not due, %d quarantined)",
+            c.Eligible, c.Selected+c.NotDue, c.MinEligible, c.NotDue, c.Quarantined)
+    default:
+        return fmt.Sprintf("%d IDs eligible", c.Eligible)
+    }
+}
+
+// CheckIdle reports whether a scheduled run of the profile has enough to do.
+// Runs are skipped when fewer than the profile's MinEligible IDs are due and
+// out of quarantine, unless an interrupted run or unparsed pages are waiting.
+func (v *VirtualsScraper) CheckIdle(profile ScrapeProfile, now time.Time) IdleCheck {
+    check := IdleCheck{Profile: profile.Name, MinEligible: max(profile.MinEligible, 1)}
+
+    ids := v.scrapeIDs(profile, now)
+    check.Selected = len(ids)
+    for _, id := range ids {
+        if v.store.IsQuarantined(strconv.Itoa(id)) {
+            check.Quarantined++
+        }
+    }
+    check.Eligible = check.Selected - check.Quarantined
+    if profile.DueOnly {
+        all := profile
+        all.DueOnly = false
+        check.NotDue = len(v.scrapeIDs(all, now)) - check.Selected
+    }
+
+    if cp, err := loadCheckpoint(checkpointFile, now); err == nil && cp != nil && cp.profile() == profile.Name {
+        check.Resume = true
+    }
+    if pending, err := v.pages.Pending(); err == nil {
+        check.Pending = len(pending)
+    }
+    check.Skip = check.Eligible < check.MinEligible && !check.Resume && check.Pending == 0
+    return check
+}
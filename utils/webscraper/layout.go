@@ -0,0 +1,235 @@
+package webscraper
+
+import (
+    "encoding/json"
+    "fmt"
+    "os"
+    "path/filepath"
+    "sort"
+    "strings"
+    "sync"
+    "time"
+
+    "github.com/PuerkitoBio/goquery"
+)
+
+const layoutBaselineFile = "training_data/layout_baseline.json"
+
+const (
+    layoutSampleSize     = 50  // Pending pages fingerprinted per scrape
+    layoutMinPages       = 10  // Fewer known-agent pages than this can't show drift
+    layoutDriftThreshold = 0.5 // Drop in a probe's presence rate that counts as drift
+    layoutBaselineWeight = 0.2 // Weight of each new scrape in the rolling baseline
+)
+
+// layoutProbes are the page structures the parser depends on, by name
+var layoutProbes = map[string]string{
+    "name":              ".text-neutral10.text-2xl",
+    "price":             ".text-neutral30",
+    "biography":         "div:contains('Biography')",
+    "influence_metrics": "div:contains('Influence Metrics')",
+    "metric_labels":     ".rounded-2xl .text-neutral50",
+    "token_data":        "div:contains('Token Data')",
+    "token_grid":        ".grid-cols-4",
+    "explorer_links":    "a[href*='scan.org/']",
+}
+
+// PageFingerprint counts the elements matching each layout probe
+type PageFingerprint map[string]int
+
+// Fingerprint computes the structural fingerprint of a page
+func Fingerprint(doc *goquery.Document) PageFingerprint {
+    fingerprint := make(PageFingerprint, len(layoutProbes))
+    for name, selector := range layoutProbes {
+        fingerprint[name] = doc.Find(selector).Length()
+    }
+    return fingerprint
+}
+
+// ProbeDrift is a probe found on far fewer pages than usual
+type ProbeDrift struct {
+    Probe    string  `json:"probe"`
+    Baseline float64 `json:"baseline"` // Share of pages the probe was present on
+    Current  float64 `json:"current"`
+}
+
+// LayoutDrift reports a site layout change seen across many agent pages
+type LayoutDrift struct {
+    DetectedAt time.Time    `json:"detected_at"`
+    Pages      int          `json:"pages"`
+    Probes     []ProbeDrift `json:"probes"`
+}
+
+// Summary describes the drift for an admin alert
+func (d LayoutDrift) Summary() string {
+    var b strings.Builder
+    fmt.Fprintf(&b, "Site layout changed on %d agent pages:\n", d.Pages)
+    for _, probe := range d.Probes {
+        fmt.Fprintf(&b, "- %s: on %.0f%% of pages, usually %.0f%%\n", probe.Probe, probe.Current*100, probe.Baseline*100)
+    }
+    return b.String()
+}
+
+// layoutBaseline is the rolling share of known-agent pages each probe appears on
+type layoutBaseline struct {
+    Presence  map[string]float64 `json:"presence"`
+    UpdatedAt time.Time          `json:"updated_at"`
+}
+
+// layoutMonitor compares page fingerprints against a persisted baseline
+type layoutMonitor struct {
+    path     string
+    mu       sync.Mutex
+    drifting bool // Drift was already reported and not yet cleared
+}
+
+func newLayoutMonitor(path string) *layoutMonitor {
+    return &layoutMonitor{path: path}
+}
+
+// check compares the fingerprints with the baseline, returning the drift if
+// any probe dropped past the threshold and whether it is newly detected.
+// Without drift the baseline absorbs the fingerprints.
+func (m *layoutMonitor) check(fingerprints []PageFingerprint, now time.Time) (*LayoutDrift, bool, error) {
+    if len(fingerprints) < layoutMinPages {
+        return nil, false, nil
+    }
+
+    m.mu.Lock()
+    defer m.mu.Unlock()
+
+    current := make(map[string]float64, len(layoutProbes))
+    for name := range layoutProbes {
+        present := 0
+        for _, fingerprint := range fingerprints {
+            if fingerprint[name] > 0 {
+                present++
+            }
+        }
+        current[name] = float64(present) / float64(len(fingerprints))
+    }
+
+    baseline, err := m.load()
+    if err != nil {
+        return nil, false, err
+    }
+
+    if baseline.Presence != nil {
+        drift := &LayoutDrift{DetectedAt: now, Pages: len(fingerprints)}
+        for name, usual := range baseline.Presence {
+            if rate, tracked := current[name]; tracked && usual-rate >= layoutDriftThreshold {
+                drift.Probes = append(drift.Probes, ProbeDrift{Probe: name, Baseline: usual, Current: rate})
+            }
+        }
+        if len(drift.Probes) > 0 {
+            sort.Slice(drift.Probes, func(i, j int) bool { return drift.Probes[i].Probe < drift.Probes[j].Probe })
+            fresh := !m.drifting
+            m.drifting = true
+            return drift, fresh, nil
+        }
+
+        for name, rate := range current {
+            if usual, tracked := baseline.Presence[name]; tracked {
+                current[name] = usual + layoutBaselineWeight*(rate-usual)
+            }
+        }
+    }
+
+    m.drifting = false
+    return nil, false, m.save(layoutBaseline{Presence: current, UpdatedAt: now})
+}
+
+// reset forgets the baseline so the next check learns the current layout
+func (m *layoutMonitor) reset() error {
+    m.mu.Lock()
+    defer m.mu.Unlock()
+    m.drifting = false
+    if err := os.Remove(m.path); err != nil && !os.IsNotExist(err) {
+        return fmt.Errorf("failed to remove layout baseline: %w", err)
+    }
+    return nil
+}
+
+func (m *layoutMonitor) load() (layoutBaseline, error) {
+    var baseline layoutBaseline
+    data, err := os.ReadFile(m.path)
+    if os.IsNotExist(err) {
+        return baseline, nil
+    }
+    if err != nil {
+        return baseline, fmt.Errorf("failed to read layout baseline: %w", err)
+    }
+    if err := json.Unmarshal(data, &baseline); err != nil {
+        return baseline, fmt.Errorf("failed to unmarshal layout baseline: %w", err)
+    }
+    return baseline, nil
+}
+
+func (m *layoutMonitor) save(baseline layoutBaseline) error {
+    data, err := json.MarshalIndent(baseline, "", "  ")
+    if err != nil {
+        return fmt.Errorf("failed to marshal layout baseline: %w", err)
+    }
+    if err := os.MkdirAll(filepath.Dir(m.path), 0755); err != nil {
+        return fmt.Errorf("failed to create directory: %w", err)
+    }
+    return os.WriteFile(m.path, data, 0644)
+}
+
+// checkLayout fingerprints a sample of pending pages from known agents and
+// reports new drift to the layout hooks. Parsing is held back while the layout
+// looks changed, so pages stay queued instead of storing empty fields.
+func (v *VirtualsScraper) checkLayout(pending []int) bool {
+    var fingerprints []PageFingerprint
+    for _, id := range pending {
+        if len(fingerprints) == layoutSampleSize {
+            break
+        }
+        if !v.priority.Known(id) {
+            continue
+        }
+        html, _, err := v.pages.Load(id)
+        if err != nil {
+            continue
+        }
+        doc, err := goquery.NewDocumentFromReader(strings.NewReader(html))
+        if err != nil {
+            continue
+        }
+        fingerprints = append(fingerprints, Fingerprint(doc))
+    }
+
+    drift, fresh, err := v.layout.check(fingerprints, v.now())
+    if err != nil {
+        v.logger.Printf("[WARN] Failed to check page layout: %v", err)
+        return true
+    }
+    if drift == nil {
+        return true
+    }
+
+    v.logger.Printf("[LAYOUT] %s", drift.Summary())
+    if !fresh {
+        return false
+    }
+    v.hooksMu.Lock()
+    hooks := append([]func(LayoutDrift){}, v.layoutHooks...)
+    v.hooksMu.Unlock()
+    for _, hook := range hooks {
+        hook(*drift)
+    }
+    return false
+}
+
+// AddLayoutHook registers a function called when the site layout drifts
+func (v *VirtualsScraper) AddLayoutHook(hook func(LayoutDrift)) {
+    v.hooksMu.Lock()
+    defer v.hooksMu.Unlock()
+    v.layoutHooks = append(v.layoutHooks, hook)
+}
+
+// AcceptLayout forgets the layout baseline, e.g. after the parser has been
+// updated for a site change, so held pages are parsed on the next scrape
+func (v *VirtualsScraper) AcceptLayout() error {
+    return v.layout.reset()
+}
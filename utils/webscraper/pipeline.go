@@ -0,0 +1,311 @@
+package webscraper
+
+import (
+    "fmt"
+    "log"
+    "sync"
+    "time"
+    "anondd/utils/models"
+    "anondd/utils/storage"
+)
+
+// Backpressure policies for a stage whose queue is full
+const (
+    PolicyPause = "pause" // The producer waits for room, slowing down to the write rate
+    PolicyDrop  = "drop"  // The item is dropped and picked up again next run
+)
+
+// PipelineConfig bounds the queues between the scrape stages. Fetched pages
+// queue up for the page writer and parsed agents for the store, so when disk
+// writes slow down the queues fill and the policy decides what happens
+// instead of memory growing without limit. A run of slow writes also opens a
+// breaker that holds the producer back for the cooldown. Agent records are
+// written as they arrive, but merged into the index in batches.
+type PipelineConfig struct {
+    QueueSize  int           `json:"queue_size"`
+    Policy     string        `json:"policy"`
+    SlowWrite  time.Duration `json:"slow_write"`  // A write taking at least this long is slow
+    TripAfter  int           `json:"trip_after"`  // Consecutive slow writes that open the breaker
+    Cooldown   time.Duration `json:"cooldown"`    // How long an open breaker holds producers
+    IndexBatch int           `json:"index_batch"` // Saved agents merged into the index per write
+    IndexFlush time.Duration `json:"index_flush"` // Longest a saved agent waits to be indexed
+}
+
+// DefaultPipelineConfig pauses producers on a full queue of 16, backs off
+// for 30s after 5 writes in a row take 2s or more, and indexes saved agents
+// 50 at a time or every 10s
+func DefaultPipelineConfig() PipelineConfig {
+    return PipelineConfig{
+        QueueSize:  16,
+        Policy:     PolicyPause,
+        SlowWrite:  2 * time.Second,
+        TripAfter:  5,
+        Cooldown:   30 * time.Second,
+        IndexBatch: 50,
+        IndexFlush: 10 * time.Second,
+    }
+}
+
+func (c PipelineConfig) validate() error {
+    if c.QueueSize < 1 {
+        return fmt.Errorf("queue size must be at least 1")
+    }
+    if c.Policy != PolicyPause && c.Policy != PolicyDrop {
+        return fmt.Errorf("unknown backpressure policy %q, use %s or %s", c.Policy, PolicyPause, PolicyDrop)
+    }
+    if c.SlowWrite <= 0 || c.TripAfter < 1 || c.Cooldown < 0 {
+        return fmt.Errorf("slow write threshold, trip count and cooldown must be positive")
+    }
+    if c.IndexBatch < 1 || c.IndexFlush <= 0 {
+        return fmt.Errorf("index batch size and flush interval must be positive")
+    }
+    return nil
+}
+
+// SetPipelineConfig replaces the queue bounds and backpressure policy used
+// from the next scrape or reparse on
+func (v *VirtualsScraper) SetPipelineConfig(config PipelineConfig) error {
+    if err := config.validate(); err != nil {
+        return err
+    }
+    v.stagesMu.Lock()
+    defer v.stagesMu.Unlock()
+    v.pipeline = config
+    return nil
+}
+
+func (v *VirtualsScraper) pipelineConfig() PipelineConfig {
+    v.stagesMu.Lock()
+    defer v.stagesMu.Unlock()
+    return v.pipeline
+}
+
+// PipelineStats returns the saturation of each stage queue of the running
+// scrape, or of the last one if none is running, keyed by the stage feeding
+// the queue
+func (v *VirtualsScraper) PipelineStats() map[string]models.StageStats {
+    v.stagesMu.Lock()
+    defer v.stagesMu.Unlock()
+    stats := make(map[string]models.StageStats, len(v.stages))
+    for name, meter := range v.stages {
+        stats[name] = meter.snapshot()
+    }
+    return stats
+}
+
+// resetStages clears the stage metrics at the start of a run
+func (v *VirtualsScraper) resetStages() {
+    v.stagesMu.Lock()
+    defer v.stagesMu.Unlock()
+    v.stages = make(map[string]*stageMeter)
+}
+
+// stage returns the run's meter for a stage, so queues created per chunk add
+// up to one set of metrics
+func (v *VirtualsScraper) stage(name string, capacity int) *stageMeter {
+    v.stagesMu.Lock()
+    defer v.stagesMu.Unlock()
+    if v.stages == nil {
+        v.stages = make(map[string]*stageMeter)
+    }
+    meter, exists := v.stages[name]
+    if !exists {
+        meter = &stageMeter{}
+        v.stages[name] = meter
+    }
+    meter.mu.Lock()
+    meter.stats.Capacity = capacity
+    meter.mu.Unlock()
+    return meter
+}
+
+// stageMeter accumulates a stage queue's saturation metrics
+type stageMeter struct {
+    mu    sync.Mutex
+    stats models.StageStats
+    depth func() int // Items currently queued, nil once the queue is gone
+}
+
+func (m *stageMeter) update(change func(*models.StageStats)) {
+    m.mu.Lock()
+    defer m.mu.Unlock()
+    change(&m.stats)
+}
+
+func (m *stageMeter) snapshot() models.StageStats {
+    m.mu.Lock()
+    defer m.mu.Unlock()
+    stats := m.stats
+    if m.depth != nil {
+        stats.Depth = m.depth()
+    }
+    return stats
+}
+
+// stageQueue is a bounded queue between a producing and a consuming stage
+type stageQueue[T any] struct {
+    items  chan T
+    policy string
+    meter  *stageMeter
+}
+
+func newStageQueue[T any](meter *stageMeter, config PipelineConfig) *stageQueue[T] {
+    q := &stageQueue[T]{
+        items:  make(chan T, config.QueueSize),
+        policy: config.Policy,
+        meter:  meter,
+    }
+    meter.mu.Lock()
+    meter.depth = func() int { return len(q.items) }
+    meter.mu.Unlock()
+    return q
+}
+
+// push hands item to the consumer. A full queue blocks under PolicyPause
+// and drops the item under PolicyDrop, in which case push returns false.
+func (q *stageQueue[T]) push(item T) bool {
+    var blocked time.Duration
+    select {
+    case q.items <- item:
+    default:
+        if q.policy == PolicyDrop {
+            q.meter.update(func(s *models.StageStats) { s.Dropped++ })
+            return false
+        }
+        start := time.Now()
+        q.items <- item
+        blocked = time.Since(start)
+    }
+    depth := len(q.items)
+    q.meter.update(func(s *models.StageStats) {
+        s.Passed++
+        s.Blocked += blocked
+        if depth > s.MaxDepth {
+            s.MaxDepth = depth
+        }
+    })
+    return true
+}
+
+// close tells the consumer no more items are coming
+func (q *stageQueue[T]) close() {
+    close(q.items)
+}
+
+// writeBreaker watches a consumer's write latency and holds the producer
+// back for a cooldown once enough writes in a row are slow, giving a
+// struggling disk room to catch up
+type writeBreaker struct {
+    config    PipelineConfig
+    meter     *stageMeter
+    mu        sync.Mutex
+    slow      int
+    openUntil time.Time
+}
+
+func newWriteBreaker(meter *stageMeter, config PipelineConfig) *writeBreaker {
+    return &writeBreaker{config: config, meter: meter}
+}
+
+// record adds a write's latency and reports whether it opened the breaker
+func (b *writeBreaker) record(took time.Duration) bool {
+    b.mu.Lock()
+    defer b.mu.Unlock()
+    if took < b.config.SlowWrite {
+        b.slow = 0
+        return false
+    }
+    b.slow++
+    tripped := b.slow >= b.config.TripAfter
+    if tripped {
+        b.slow = 0
+        b.openUntil = time.Now().Add(b.config.Cooldown)
+    }
+    b.meter.update(func(s *models.StageStats) {
+        s.SlowWrites++
+        if tripped {
+            s.Trips++
+        }
+    })
+    return tripped
+}
+
+// wait blocks the producer while the breaker is open
+func (b *writeBreaker) wait() {
+    b.mu.Lock()
+    pause := time.Until(b.openUntil)
+    b.mu.Unlock()
+    if pause <= 0 {
+        return
+    }
+    time.Sleep(pause)
+    b.meter.update(func(s *models.StageStats) { s.Paused += pause })
+}
+
+// indexBatch merges saved agents into the index every IndexBatch agents or
+// IndexFlush, whichever comes first, rather than rewriting the whole index
+// and dropping the response caches for each agent. Agents whose merge fails
+// stay pending for the next flush.
+type indexBatch struct {
+    store   *storage.AgentStore
+    size    int
+    mu      sync.Mutex
+    pending []models.Agent
+    done    chan struct{}
+    stopped sync.WaitGroup
+    logger  *log.Logger
+}
+
+func newIndexBatch(store *storage.AgentStore, config PipelineConfig, logger *log.Logger) *indexBatch {
+    b := &indexBatch{store: store, size: config.IndexBatch, done: make(chan struct{}), logger: logger}
+    b.stopped.Add(1)
+    go func() {
+        defer b.stopped.Done()
+        ticker := time.NewTicker(config.IndexFlush)
+        defer ticker.Stop()
+        for {
+            select {
+            case <-ticker.C:
+                if err := b.flush(); err != nil {
+                    b.logger.Printf("[ERROR] Failed to update index: %v", err)
+                }
+            case <-b.done:
+                return
+            }
+        }
+    }()
+    return b
+}
+
+// add queues a saved agent for indexing, merging the batch once it is full
+func (b *indexBatch) add(agent models.Agent) error {
+    b.mu.Lock()
+    b.pending = append(b.pending, agent)
+    full := len(b.pending) >= b.size
+    b.mu.Unlock()
+    if full {
+        return b.flush()
+    }
+    return nil
+}
+
+// flush merges every pending agent into the index
+func (b *indexBatch) flush() error {
+    b.mu.Lock()
+    defer b.mu.Unlock()
+    if len(b.pending) == 0 {
+        return nil
+    }
+    if err := b.store.MergeIndex(b.pending); err != nil {
+        return fmt.Errorf("failed to index %d agents: %w", len(b.pending), err)
+    }
+    b.pending = nil
+    return nil
+}
+
+// close stops the timer and merges what is left
+func (b *indexBatch) close() error {
+    close(b.done)
+    b.stopped.Wait()
+    return b.flush()
+}
@@ -0,0 +1,210 @@
+package webscraper
+
+import (
+    "encoding/json"
+    "fmt"
+    "os"
+    "path/filepath"
+    "sort"
+    "sync"
+    "time"
+    "anondd/utils/models"
+)
+
+const priorityFile = "training_data/scrape_priority.json"
+
+// Re-scrape intervals per agent status used by the differential mode
+var priorityIntervals = map[string]time.Duration{
+    priorityVolatile:    15 * time.Minute,
+    models.StatusActive:  1 * time.Hour,
+    models.StatusDefault: 6 * time.Hour,
+    models.StatusLatent:  24 * time.Hour,
+    models.StatusDead:    7 * 24 * time.Hour,
+}
+
+// priorityVolatile marks active agents whose price moved on recent scrapes
+const priorityVolatile = "volatile"
+
+// PriorityEntry tracks scrape scheduling state for a single agent ID
+type PriorityEntry struct {
+    ID          int       `json:"id"`
+    Status      string    `json:"status"`
+    LastPrice   string    `json:"last_price"`
+    ChangeCount int       `json:"change_count"`
+    LastScraped time.Time `json:"last_scraped"`
+    NextDue     time.Time `json:"next_due"`
+    Failures    int       `json:"failures,omitempty"` // Consecutive failed fetches or parses
+}
+
+// Volatile reports whether the agent's price changed on its latest scrape
+func (e *PriorityEntry) Volatile() bool {
+    return e.Status == models.StatusActive && e.ChangeCount > 0
+}
+
+// PriorityQueue decides which agent IDs are due for scraping and persists its state
+type PriorityQueue struct {
+    path    string
+    mu      sync.Mutex
+    entries map[int]*PriorityEntry
+}
+
+// NewPriorityQueue loads the persisted queue from path, starting empty if missing
+func NewPriorityQueue(path string) (*PriorityQueue, error) {
+    q := &PriorityQueue{
+        path:    path,
+        entries: make(map[int]*PriorityEntry),
+    }
+
+    data, err := os.ReadFile(path)
+    if os.IsNotExist(err) {
+        return q, nil
+    }
+    if err != nil {
+        return q, fmt.Errorf("failed to read priority queue: %w", err)
+    }
+
+    var entries []*PriorityEntry
+    if err := json.Unmarshal(data, &entries); err != nil {
+        return q, fmt.Errorf("failed to unmarshal priority queue: %w", err)
+    }
+    for _, entry := range entries {
+        q.entries[entry.ID] = entry
+    }
+    return q, nil
+}
+
+// DueIDs returns the IDs in [from, to] that are due at now, most urgent first.
+// Volatile and active agents come first, then never-seen IDs, then the rest.
+func (q *PriorityQueue) DueIDs(from, to int, now time.Time) []int {
+    q.mu.Lock()
+    defer q.mu.Unlock()
+
+    var due []int
+    for id := from; id <= to; id++ {
+        entry, exists := q.entries[id]
+        if !exists || !now.Before(entry.NextDue) {
+            due = append(due, id)
+        }
+    }
+
+    q.sortByRank(due)
+    return due
+}
+
+// RankedIDs returns every ID in [from, to], due or not, most urgent first
+func (q *PriorityQueue) RankedIDs(from, to int) []int {
+    q.mu.Lock()
+    defer q.mu.Unlock()
+
+    ids := make([]int, 0, to-from+1)
+    for id := from; id <= to; id++ {
+        ids = append(ids, id)
+    }
+    q.sortByRank(ids)
+    return ids
+}
+
+// sortByRank orders ids most urgent first; callers must hold mu
+func (q *PriorityQueue) sortByRank(ids []int) {
+    sort.SliceStable(ids, func(i, j int) bool {
+        return q.rank(ids[i]) < q.rank(ids[j])
+    })
+}
+
+func (q *PriorityQueue) rank(id int) int {
+    entry, exists := q.entries[id]
+    switch {
+    case !exists:
+        return 2
+    case entry.Volatile():
+        return 0
+    case entry.Status == models.StatusActive:
+        return 1
+    case entry.Status == models.StatusDead:
+        return 4
+    default:
+        return 3
+    }
+}
+
+// RecordSuccess updates the entry for a scraped agent and schedules its next check
+func (q *PriorityQueue) RecordSuccess(id int, agent *models.Agent, now time.Time) {
+    q.mu.Lock()
+    defer q.mu.Unlock()
+
+    entry := q.entry(id)
+    if entry.LastPrice != "" && entry.LastPrice != agent.Price {
+        entry.ChangeCount++
+    } else {
+        entry.ChangeCount = 0
+    }
+    entry.Status = agent.Status
+    entry.Failures = 0
+    entry.LastPrice = agent.Price
+    entry.LastScraped = now
+    entry.NextDue = now.Add(q.interval(entry))
+}
+
+// RecordFailure schedules the next check of an ID that could not be fetched
+// or parsed. IDs that never parsed are demoted to weekly checks; known agents
+// keep their status's interval.
+func (q *PriorityQueue) RecordFailure(id int, now time.Time) {
+    q.mu.Lock()
+    defer q.mu.Unlock()
+
+    entry := q.entry(id)
+    entry.Failures++
+    entry.ChangeCount = 0
+    entry.NextDue = now.Add(q.interval(entry))
+}
+
+func (q *PriorityQueue) entry(id int) *PriorityEntry {
+    entry, exists := q.entries[id]
+    if !exists {
+        entry = &PriorityEntry{ID: id}
+        q.entries[id] = entry
+    }
+    return entry
+}
+
+func (q *PriorityQueue) interval(entry *PriorityEntry) time.Duration {
+    if entry.Volatile() {
+        return priorityIntervals[priorityVolatile]
+    }
+    if entry.Status == "" {
+        return priorityIntervals[models.StatusDead]
+    }
+    if interval, exists := priorityIntervals[entry.Status]; exists {
+        return interval
+    }
+    return priorityIntervals[models.StatusDefault]
+}
+
+// Save persists the queue to disk
+func (q *PriorityQueue) Save() error {
+    q.mu.Lock()
+    entries := make([]PriorityEntry, 0, len(q.entries))
+    for _, entry := range q.entries {
+        entries = append(entries, *entry)
+    }
+    q.mu.Unlock()
+
+    sort.Slice(entries, func(i, j int) bool { return entries[i].ID < entries[j].ID })
+
+    data, err := json.MarshalIndent(entries, "", "  ")
+    if err != nil {
+        return fmt.Errorf("failed to marshal priority queue: %w", err)
+    }
+    if err := os.MkdirAll(filepath.Dir(q.path), 0755); err != nil {
+        return fmt.Errorf("failed to create directory: %w", err)
+    }
+    return os.WriteFile(q.path, data, 0644)
+}
+
+// Known reports whether the ID has parsed as an agent before
+func (q *PriorityQueue) Known(id int) bool {
+    q.mu.Lock()
+    defer q.mu.Unlock()
+    entry, exists := q.entries[id]
+    return exists && entry.Status != ""
+}
@@ -0,0 +1,278 @@
+package webscraper
+
+import (
+    "encoding/json"
+    "errors"
+    "fmt"
+    "os"
+    "sort"
+    "strings"
+    "time"
+    "anondd/utils/clock"
+    "github.com/robfig/cron/v3"
+)
+
+// Built-in scrape profile names
+const (
+    ProfileQuick = "quick" // Market data for known agents that are due, nothing else
+    ProfileFull  = "full"  // Every due ID with the standard post-scrape enrichment
+    ProfileDeep  = "deep"  // Every ID with screenshots and on-chain enrichment
+)
+
+// ErrUnknownProfile is returned when a run names a profile that isn't configured
+var ErrUnknownProfile = errors.New("unknown scrape profile")
+
+// EnrichDepth is how much post-scrape work a profile runs. Each depth
+// includes the ones below it.
+type EnrichDepth int
+
+const (
+    EnrichNone     EnrichDepth = iota // Save scraped agents only
+    EnrichStandard                    // Risk scores, social checks and change summaries
+    EnrichOnChain                     // Also refresh supply, holders and liquidity from chain
+)
+
+var enrichDepthNames = map[EnrichDepth]string{
+    EnrichNone:     "none",
+    EnrichStandard: "standard",
+    EnrichOnChain:  "onchain",
+}
+
+func (d EnrichDepth) String() string {
+    if name, exists := enrichDepthNames[d]; exists {
+        return name
+    }
+    return fmt.Sprintf("EnrichDepth(%d)", int(d))
+}
+
+func (d EnrichDepth) MarshalText() ([]byte, error) {
+    return []byte(d.String()), nil
+}
+
+func (d *EnrichDepth) UnmarshalText(text []byte) error {
+    for depth, name := range enrichDepthNames {
+        if strings.EqualFold(string(text), name) {
+            *d = depth
+            return nil
+        }
+    }
+    return fmt.Errorf("unknown enrichment depth %q", text)
+}
+
+// ScrapeProfile is a named scrape preset controlling which IDs a run covers,
+// how many pages it renders at once, whether screenshots are kept and how
+// much enrichment follows
+type ScrapeProfile struct {
+    Name        string      `json:"name"`
+    StartID     int         `json:"start_id"`
+    EndID       int         `json:"end_id"`
+    DueOnly     bool        `json:"due_only"`   // Skip IDs the priority queue hasn't made due
+    KnownOnly   bool        `json:"known_only"` // Skip IDs no agent has been scraped from
+    Concurrency int         `json:"concurrency"`
+    Screenshots bool        `json:"screenshots"` // Capture and keep a screenshot of each page
+    Enrichment  EnrichDepth `json:"enrichment"`
+    Schedule    string      `json:"schedule,omitempty"` // Cron spec for scheduled runs; empty runs on demand only
+    // MinEligible is how many IDs must be due and out of quarantine for a
+    // scheduled run to go ahead; zero means one. Runs started by hand always go.
+    MinEligible int `json:"min_eligible,omitempty"`
+}
+
+// DefaultProfiles are the built-in presets. quick refreshes prices every five
+// minutes without rendering extras; deep re-reads everything nightly.
+func DefaultProfiles() map[string]ScrapeProfile {
+    return map[string]ScrapeProfile{
+        ProfileQuick: {
+            Name: ProfileQuick, StartID: startAgentID, EndID: maxAgentID,
+            DueOnly: true, KnownOnly: true, Concurrency: 4,
+            Enrichment: EnrichNone, Schedule: "*/5 * * * *",
+        },
+        ProfileFull: {
+            Name: ProfileFull, StartID: startAgentID, EndID: maxAgentID,
+            DueOnly: true, Concurrency: 1,
+            Enrichment: EnrichStandard, Schedule: "0 * * * *",
+        },
+        ProfileDeep: {
+            Name: ProfileDeep, StartID: startAgentID, EndID: maxAgentID,
+            Concurrency: 2, Screenshots: true,
+            Enrichment: EnrichOnChain, Schedule: "0 3 * * *",
+        },
+    }
+}
+
+// validate fills defaults and checks the profile's range and schedule
+func (p *ScrapeProfile) validate() error {
+    if p.Concurrency <= 0 {
+        p.Concurrency = 1
+    }
+    if p.MinEligible < 0 {
+        return fmt.Errorf("profile %s: min_eligible can't be negative", p.Name)
+    }
+    if p.StartID <= 0 || p.EndID < p.StartID {
+        return fmt.Errorf("profile %s: invalid ID range %d-%d", p.Name, p.StartID, p.EndID)
+    }
+    if p.Schedule != "" {
+        if _, err := cron.ParseStandard(p.Schedule); err != nil {
+            return fmt.Errorf("profile %s: invalid schedule %q: %w", p.Name, p.Schedule, err)
+        }
+    }
+    return nil
+}
+
+// LoadScrapeProfiles returns the built-in profiles with any from a JSON file
+// keyed by profile name layered on top. A profile in the file replaces the
+// built-in one of the same name, so an empty schedule there unschedules it.
+// A missing file leaves the defaults.
+func LoadScrapeProfiles(path string) (map[string]ScrapeProfile, error) {
+    profiles := DefaultProfiles()
+    data, err := os.ReadFile(path)
+    if os.IsNotExist(err) {
+        return profiles, nil
+    }
+    if err != nil {
+        return nil, fmt.Errorf("failed to read scrape profiles: %w", err)
+    }
+
+    var custom map[string]ScrapeProfile
+    if err := json.Unmarshal(data, &custom); err != nil {
+        return nil, fmt.Errorf("failed to unmarshal scrape profiles: %w", err)
+    }
+    for name, profile := range custom {
+        profile.Name = name
+        if err := profile.validate(); err != nil {
+            return nil, err
+        }
+        profiles[name] = profile
+    }
+    return profiles, nil
+}
+
+// SetProfiles replaces the profiles runs can be started with
+func (v *VirtualsScraper) SetProfiles(profiles map[string]ScrapeProfile) {
+    v.profilesMu.Lock()
+    defer v.profilesMu.Unlock()
+    v.profiles = profiles
+}
+
+// Profile returns the named profile
+func (v *VirtualsScraper) Profile(name string) (ScrapeProfile, bool) {
+    v.profilesMu.RLock()
+    defer v.profilesMu.RUnlock()
+    profile, exists := v.profiles[name]
+    return profile, exists
+}
+
+// Profiles lists the configured profiles by name
+func (v *VirtualsScraper) Profiles() []ScrapeProfile {
+    v.profilesMu.RLock()
+    defer v.profilesMu.RUnlock()
+    profiles := make([]ScrapeProfile, 0, len(v.profiles))
+    for _, profile := range v.profiles {
+        profiles = append(profiles, profile)
+    }
+    sort.Slice(profiles, func(i, j int) bool { return profiles[i].Name < profiles[j].Name })
+    return profiles
+}
+
+// ScrapeProfileWithProgress runs a scrape cycle with the named profile like
+// ScrapeAgentsWithProgress, returning ErrScrapeInProgress instead of waiting
+// if another scrape or reparse is running
+func (v *VirtualsScraper) ScrapeProfileWithProgress(name string, progress func(ScrapeProgress)) error {
+    profile, exists := v.Profile(name)
+    if !exists {
+        return fmt.Errorf("%w %q", ErrUnknownProfile, name)
+    }
+    if !v.runMu.TryLock() {
+        return ErrScrapeInProgress
+    }
+    defer v.runMu.Unlock()
+    return v.scrape(profile, progress)
+}
+
+// StartProfile starts a scrape cycle with the named profile in the
+// background. Like ScrapeProfileWithProgress it returns ErrScrapeInProgress
+// if another scrape or reparse is running.
+func (v *VirtualsScraper) StartProfile(name string) error {
+    profile, exists := v.Profile(name)
+    if !exists {
+        return fmt.Errorf("%w %q", ErrUnknownProfile, name)
+    }
+    if !v.runMu.TryLock() {
+        return ErrScrapeInProgress
+    }
+    go func() {
+        defer v.runMu.Unlock()
+        if err := v.scrape(profile, nil); err != nil {
+            v.logger.Printf("[ERROR] %s scrape failed: %v", name, err)
+        }
+    }()
+    return nil
+}
+
+// StartSchedules runs every profile with a schedule on it in loc. A run that
+// comes due while another scrape is in progress is skipped, as is one with
+// too little to do to be worth launching a browser for (see CheckIdle).
+func (v *VirtualsScraper) StartSchedules(loc *time.Location) error {
+    scheduler := clock.NewCron(v.store.Clock(), loc)
+    for _, profile := range v.Profiles() {
+        if profile.Schedule == "" {
+            continue
+        }
+        name := profile.Name
+        if err := scheduler.AddFunc(profile.Schedule, func() {
+            if current, exists := v.Profile(name); exists {
+                check := v.CheckIdle(current, v.now())
+                if check.Skip {
+                    v.logger.Printf("[SCHEDULE] Skipping %s scrape, %s", name, check.Reason())
+                    return
+                }
+                v.logger.Printf("[SCHEDULE] Starting scheduled %s scrape, %s", name, check.Reason())
+            }
+            err := v.ScrapeProfileWithProgress(name, nil)
+            if errors.Is(err, ErrScrapeInProgress) {
+                v.logger.Printf("[SCHEDULE] Skipping %s scrape, another scrape is running", name)
+            } else if err != nil {
+                v.logger.Printf("[SCHEDULE] Scheduled %s scrape failed: %v", name, err)
+            }
+        }); err != nil {
+            return fmt.Errorf("failed to schedule %s scrape: %w", name, err)
+        }
+        v.logger.Printf("[SCHEDULE] %s scrape runs at %q", name, profile.Schedule)
+    }
+
+    v.scheduler = scheduler
+    scheduler.Start()
+    return nil
+}
+
+// AddEnrichHook registers a function to run after completed scrape cycles
+// whose profile enriches at least to depth
+func (v *VirtualsScraper) AddEnrichHook(depth EnrichDepth, hook func()) {
+    v.hooksMu.Lock()
+    defer v.hooksMu.Unlock()
+    v.enrichHooks = append(v.enrichHooks, enrichHook{depth: depth, run: hook})
+}
+
+type enrichHook struct {
+    depth EnrichDepth
+    run   func()
+}
+
+// scrapeIDs lists the IDs a fresh run of profile covers, most urgent first
+func (v *VirtualsScraper) scrapeIDs(profile ScrapeProfile, now time.Time) []int {
+    var ids []int
+    if profile.DueOnly {
+        ids = v.priority.DueIDs(profile.StartID, profile.EndID, now)
+    } else {
+        ids = v.priority.RankedIDs(profile.StartID, profile.EndID)
+    }
+    if !profile.KnownOnly {
+        return ids
+    }
+    known := ids[:0]
+    for _, id := range ids {
+        if v.priority.Known(id) {
+            known = append(known, id)
+        }
+    }
+    return known
+}
@@ -0,0 +1,56 @@
+package webscraper
+
+import (
+    "errors"
+    "time"
+)
+
+// Scrape cycle stages reported in ScrapeProgress
+const (
+    StageFetch = "fetch"
+    StageParse = "parse"
+    StageDone  = "done"
+)
+
+// progressInterval throttles progress callbacks so listeners (e.g. a Telegram
+// message being edited) are not flooded
+const progressInterval = 3 * time.Second
+
+// ErrScrapeInProgress is returned when a scrape is requested while one is running
+var ErrScrapeInProgress = errors.New("a scrape is already in progress")
+
+// ScrapeProgress is a snapshot of a running scrape cycle
+type ScrapeProgress struct {
+    Stage     string
+    Done      int // Items processed in the current stage
+    Total     int // Items in the current stage
+    CurrentID int // Agent ID being fetched
+    Fetched   int // Pages fetched so far
+    Found     int // Agents parsed so far
+    Errors    int // Fetch and parse errors so far
+}
+
+// progressReporter forwards progress to a callback at most once per progressInterval
+type progressReporter struct {
+    callback func(ScrapeProgress)
+    last     time.Time
+}
+
+func newProgressReporter(callback func(ScrapeProgress)) *progressReporter {
+    return &progressReporter{callback: callback}
+}
+
+func (r *progressReporter) update(progress ScrapeProgress) {
+    if r.callback == nil || time.Since(r.last) < progressInterval {
+        return
+    }
+    r.last = time.Now()
+    r.callback(progress)
+}
+
+// finish always reports the final snapshot
+func (r *progressReporter) finish(progress ScrapeProgress) {
+    if r.callback != nil {
+        r.callback(progress)
+    }
+}
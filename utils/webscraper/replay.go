@@ -0,0 +1,244 @@
+package webscraper
+
+import (
+    "encoding/json"
+    "fmt"
+    "os"
+    "path/filepath"
+    "regexp"
+    "sort"
+    "strconv"
+    "strings"
+    "time"
+    "anondd/utils/models"
+    "github.com/PuerkitoBio/goquery"
+)
+
+// replayBaselineFile pins what each archived snapshot parsed to, so a replay
+// can tell a parser change from a page that changed since
+const replayBaselineFile = "training_data/replay_baseline.json"
+
+// Archived snapshot kinds
+const (
+    SnapshotPage    = "page"    // Latest fetched page in the page queue
+    SnapshotCapture = "capture" // Raw capture kept under the capture policy
+)
+
+var capturePagePattern = regexp.MustCompile(`^page_(\d+)_(\d+)\.html$`)
+
+// Snapshot is one archived raw HTML page that a replay can re-parse
+type Snapshot struct {
+    SourceID   int       `json:"source_id"`
+    Kind       string    `json:"kind"`
+    Path       string    `json:"path"`
+    CapturedAt time.Time `json:"captured_at"`
+}
+
+// ReplayResult is how the current parser did on one snapshot compared with
+// the result stored for it
+type ReplayResult struct {
+    Snapshot
+    Baseline    string        `json:"baseline"`              // pinned, parsed or none
+    Error       string        `json:"error,omitempty"`       // Parse error of the current parser
+    Regressions []FieldChange `json:"regressions,omitempty"` // Fields lost, or changed against a pinned baseline
+    Drift       []FieldChange `json:"drift,omitempty"`       // Values that differ from a parse of a later page
+    Gained      []string      `json:"gained,omitempty"`      // Fields found now but not before
+}
+
+// Regressed reports whether the current parser did worse on the snapshot
+func (r ReplayResult) Regressed() bool {
+    return len(r.Regressions) > 0 || (r.Error != "" && r.Baseline != "none")
+}
+
+// ReplayReport summarizes re-parsing archived snapshots with the current
+// parser. A replay writes nothing unless it pins a new baseline.
+type ReplayReport struct {
+    StartedAt  time.Time      `json:"started_at"`
+    Duration   time.Duration  `json:"duration"`
+    Snapshots  int            `json:"snapshots"`
+    Parsed     int            `json:"parsed"`
+    Failed     int            `json:"failed"`
+    Regressed  int            `json:"regressed"`
+    Improved   int            `json:"improved"`
+    NoBaseline int            `json:"no_baseline"`
+    Pinned     int            `json:"pinned,omitempty"`
+    Results    []ReplayResult `json:"results"`
+}
+
+// Snapshots lists the archived pages of the given agent IDs, or of every ID
+// if none are given, oldest first
+func (v *VirtualsScraper) Snapshots(ids []int) ([]Snapshot, error) {
+    wanted := make(map[int]bool, len(ids))
+    for _, id := range ids {
+        wanted[id] = true
+    }
+    keep := func(id int) bool { return len(wanted) == 0 || wanted[id] }
+
+    var snapshots []Snapshot
+    pageIDs, err := v.pages.All()
+    if err != nil {
+        return nil, fmt.Errorf("failed to list stored pages: %w", err)
+    }
+    for _, id := range pageIDs {
+        if !keep(id) {
+            continue
+        }
+        meta, err := v.pages.readMeta(id)
+        if err != nil {
+            v.logger.Printf("[WARN] Skipping stored page %d in replay: %v", id, err)
+            continue
+        }
+        snapshots = append(snapshots, Snapshot{SourceID: id, Kind: SnapshotPage, Path: v.pages.htmlPath(id), CapturedAt: meta.FetchedAt})
+    }
+
+    entries, err := os.ReadDir(v.capture.dir)
+    if err != nil && !os.IsNotExist(err) {
+        return nil, fmt.Errorf("failed to list raw captures: %w", err)
+    }
+    for _, entry := range entries {
+        match := capturePagePattern.FindStringSubmatch(entry.Name())
+        if match == nil {
+            continue
+        }
+        id, _ := strconv.Atoi(match[1])
+        unix, _ := strconv.ParseInt(match[2], 10, 64)
+        if !keep(id) {
+            continue
+        }
+        snapshots = append(snapshots, Snapshot{SourceID: id, Kind: SnapshotCapture, Path: filepath.Join(v.capture.dir, entry.Name()),
+            CapturedAt: time.Unix(unix, 0)})
+    }
+
+    sort.Slice(snapshots, func(i, j int) bool { return snapshots[i].CapturedAt.Before(snapshots[j].CapturedAt) })
+    return snapshots, nil
+}
+
+// Replay re-parses the archived snapshots of the given agent IDs, or of every
+// ID if none are given, with the current parser and reports regressions
+// against what was stored for them. Run it before shipping selector changes.
+//
+// A snapshot pinned by an earlier replay with pin set is compared field by
+// field, so any difference is a regression. Otherwise it is compared with
+// the last stored parse of its ID, which may come from a later page: a field
+// that was found there but is missing now is a regression, while different
+// values are only drift, except for the stored page the parse came from.
+func (v *VirtualsScraper) Replay(ids []int, pin bool) (*ReplayReport, error) {
+    snapshots, err := v.Snapshots(ids)
+    if err != nil {
+        return nil, err
+    }
+    baseline, err := loadReplayBaseline(replayBaselineFile)
+    if err != nil {
+        return nil, err
+    }
+
+    report := &ReplayReport{StartedAt: time.Now()}
+    for _, snapshot := range snapshots {
+        agent, result := v.replaySnapshot(snapshot, baseline)
+        report.Snapshots++
+        if result.Error != "" {
+            report.Failed++
+        } else {
+            report.Parsed++
+        }
+        switch {
+        case result.Regressed():
+            report.Regressed++
+        case result.Baseline == "none":
+            report.NoBaseline++
+        case len(result.Gained) > 0:
+            report.Improved++
+        }
+        if pin && agent != nil && !result.Regressed() {
+            baseline[snapshot.Path] = agent
+            report.Pinned++
+        }
+        report.Results = append(report.Results, result)
+    }
+    report.Duration = time.Since(report.StartedAt)
+
+    if report.Pinned > 0 {
+        if err := saveReplayBaseline(replayBaselineFile, baseline); err != nil {
+            return report, err
+        }
+    }
+    v.logger.Printf("[REPLAY] %d snapshots: %d parsed, %d failed, %d regressed, %d improved, %d pinned",
+        report.Snapshots, report.Parsed, report.Failed, report.Regressed, report.Improved, report.Pinned)
+    return report, nil
+}
+
+func (v *VirtualsScraper) replaySnapshot(snapshot Snapshot, baseline map[string]*models.Agent) (*models.Agent, ReplayResult) {
+    result := ReplayResult{Snapshot: snapshot, Baseline: "none"}
+
+    before, exact := baseline[snapshot.Path]
+    if exact {
+        result.Baseline = "pinned"
+    } else if parsed, err := loadParsedAgent(snapshot.SourceID); err == nil {
+        before, result.Baseline = parsed, "parsed"
+        // The stored page is what the last parse read, unless it was
+        // fetched again and not parsed yet
+        if snapshot.Kind == SnapshotPage && !parsed.ScrapedAt.Before(snapshot.CapturedAt) {
+            exact = true
+        }
+    } else if !os.IsNotExist(err) {
+        v.logger.Printf("[WARN] Failed to load previous parse for %d: %v", snapshot.SourceID, err)
+    }
+
+    html, err := os.ReadFile(snapshot.Path)
+    if err != nil {
+        result.Error = err.Error()
+        return nil, result
+    }
+    doc, err := goquery.NewDocumentFromReader(strings.NewReader(string(html)))
+    var agent *models.Agent
+    if err == nil {
+        agent, err = v.parseAgentPage(doc, snapshot.SourceID, false)
+    }
+    if err != nil {
+        result.Error = err.Error()
+        return nil, result
+    }
+    if before == nil {
+        return agent, result
+    }
+
+    for _, change := range diffAgentFields(before, agent) {
+        switch {
+        case change.Before == "":
+            result.Gained = append(result.Gained, change.Field)
+        case change.After == "" || exact:
+            result.Regressions = append(result.Regressions, change)
+        default:
+            result.Drift = append(result.Drift, change)
+        }
+    }
+    return agent, result
+}
+
+// loadReplayBaseline reads the pinned parses by snapshot path; a missing
+// file pins nothing
+func loadReplayBaseline(path string) (map[string]*models.Agent, error) {
+    baseline := make(map[string]*models.Agent)
+    data, err := os.ReadFile(path)
+    if os.IsNotExist(err) {
+        return baseline, nil
+    }
+    if err != nil {
+        return nil, fmt.Errorf("failed to read replay baseline: %w", err)
+    }
+    if err := json.Unmarshal(data, &baseline); err != nil {
+        return nil, fmt.Errorf("failed to unmarshal replay baseline: %w", err)
+    }
+    return baseline, nil
+}
+
+func saveReplayBaseline(path string, baseline map[string]*models.Agent) error {
+    if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+        return err
+    }
+    data, err := json.MarshalIndent(baseline, "", "  ")
+    if err != nil {
+        return err
+    }
+    return os.WriteFile(path, data, 0644)
+}
@@ -0,0 +1,224 @@
+package webscraper
+
+import (
+    "encoding/json"
+    "fmt"
+    "os"
+    "path/filepath"
+    "sort"
+    "strings"
+    "sync"
+    "github.com/PuerkitoBio/goquery"
+    "github.com/andybalholm/cascadia"
+)
+
+const selectorConfigFile = "training_data/selectors.json"
+
+// missScanLimit bounds how many stored pages FieldMisses parses, newest IDs first
+const missScanLimit = 500
+
+// defaultSelectors are the built-in CSS selectors for the text fields of an
+// agent page, tried in order until one finds text
+var defaultSelectors = map[string][]string{
+    "name": {
+        ".text-neutral10.text-2xl",
+        "h1",
+        ".agent-name",
+        "div.text-2xl",
+    },
+    "price": {
+        ".text-neutral30",
+        "div:contains('$')",
+        ".price",
+    },
+    "description": {
+        "div:contains('Biography') + div",
+        ".text-base.text-neutral30.break-all",
+        ".agent-description",
+    },
+}
+
+// SelectorFields lists the agent page fields whose selectors can be configured
+func SelectorFields() []string {
+    fields := make([]string, 0, len(defaultSelectors))
+    for field := range defaultSelectors {
+        fields = append(fields, field)
+    }
+    sort.Strings(fields)
+    return fields
+}
+
+// DefaultSelectors returns the built-in selectors for field
+func DefaultSelectors(field string) []string {
+    return append([]string{}, defaultSelectors[field]...)
+}
+
+// selectorConfig holds adopted selectors per field, persisted so they survive
+// restarts. They are tried before the built-in ones.
+type selectorConfig struct {
+    path string
+    mu   sync.RWMutex
+    // Extra holds adopted selectors by field, newest first
+    Extra map[string][]string `json:"extra"`
+}
+
+func loadSelectorConfig(path string) (*selectorConfig, error) {
+    config := &selectorConfig{path: path, Extra: make(map[string][]string)}
+    data, err := os.ReadFile(path)
+    if os.IsNotExist(err) {
+        return config, nil
+    }
+    if err != nil {
+        return config, err
+    }
+    if err := json.Unmarshal(data, config); err != nil {
+        return config, err
+    }
+    if config.Extra == nil {
+        config.Extra = make(map[string][]string)
+    }
+    return config, nil
+}
+
+// selectors returns the adopted selectors followed by the built-in ones for every field
+func (c *selectorConfig) selectors() map[string][]string {
+    c.mu.RLock()
+    defer c.mu.RUnlock()
+    merged := make(map[string][]string, len(defaultSelectors))
+    for field, builtin := range defaultSelectors {
+        merged[field] = append(append([]string{}, c.Extra[field]...), builtin...)
+    }
+    return merged
+}
+
+// adopt puts selector first for field, reporting false if it was already adopted
+func (c *selectorConfig) adopt(field, selector string) (bool, error) {
+    c.mu.Lock()
+    defer c.mu.Unlock()
+    for _, existing := range c.Extra[field] {
+        if existing == selector {
+            return false, nil
+        }
+    }
+    c.Extra[field] = append([]string{selector}, c.Extra[field]...)
+    if err := c.save(); err != nil {
+        c.Extra[field] = c.Extra[field][1:]
+        return false, err
+    }
+    return true, nil
+}
+
+// save writes the config; callers must hold mu
+func (c *selectorConfig) save() error {
+    if err := os.MkdirAll(filepath.Dir(c.path), 0755); err != nil {
+        return err
+    }
+    data, err := json.MarshalIndent(c, "", "  ")
+    if err != nil {
+        return err
+    }
+    return os.WriteFile(c.path, data, 0644)
+}
+
+// ValidateSelector checks that selector is a CSS selector the parser can run
+func ValidateSelector(selector string) error {
+    if strings.TrimSpace(selector) == "" {
+        return fmt.Errorf("empty selector")
+    }
+    if _, err := cascadia.ParseGroup(selector); err != nil {
+        return fmt.Errorf("invalid selector %q: %w", selector, err)
+    }
+    return nil
+}
+
+// AdoptSelector adds selector to the config for field, ahead of the built-in
+// selectors, so the next parse tries it first. It reports false when the
+// selector was already adopted.
+func (v *VirtualsScraper) AdoptSelector(field, selector string) (bool, error) {
+    if _, ok := defaultSelectors[field]; !ok {
+        return false, fmt.Errorf("unknown field %q", field)
+    }
+    if err := ValidateSelector(selector); err != nil {
+        return false, err
+    }
+    adopted, err := v.selectors.adopt(field, selector)
+    if err != nil {
+        return false, err
+    }
+    if adopted {
+        v.logger.Printf("[SELECTORS] Adopted %q for %s", selector, field)
+    }
+    return adopted, nil
+}
+
+// FieldMisses is how often each configurable field came back empty across
+// the stored agent pages
+type FieldMisses struct {
+    Pages  int              `json:"pages"`
+    Missed map[string][]int `json:"missed"` // IDs of pages missing each field
+}
+
+// FieldMisses parses the most recent stored pages with the current selectors
+// and reports which pages each field could not be found on
+func (v *VirtualsScraper) FieldMisses() (FieldMisses, error) {
+    misses := FieldMisses{Missed: make(map[string][]int)}
+    ids, err := v.pages.All()
+    if err != nil {
+        return misses, err
+    }
+    sort.Sort(sort.Reverse(sort.IntSlice(ids)))
+    if len(ids) > missScanLimit {
+        ids = ids[:missScanLimit]
+    }
+
+    selectors := v.selectors.selectors()
+    for _, id := range ids {
+        doc, err := v.loadPageDocument(id)
+        if err != nil {
+            continue
+        }
+        misses.Pages++
+        for field, list := range selectors {
+            if v.extractText(doc, list) == "" {
+                misses.Missed[field] = append(misses.Missed[field], id)
+            }
+        }
+    }
+    return misses, nil
+}
+
+// TrySelector runs selector against the stored pages of ids, returning how
+// many it finds text on and the first text found
+func (v *VirtualsScraper) TrySelector(selector string, ids []int) (int, string) {
+    if ValidateSelector(selector) != nil {
+        return 0, ""
+    }
+    hits, example := 0, ""
+    for _, id := range ids {
+        doc, err := v.loadPageDocument(id)
+        if err != nil {
+            continue
+        }
+        if text := v.extractText(doc, []string{selector}); text != "" {
+            hits++
+            if example == "" {
+                example = text
+            }
+        }
+    }
+    return hits, example
+}
+
+// LoadPage returns the stored raw HTML for an agent page
+func (v *VirtualsScraper) LoadPage(id int) (string, error) {
+    html, _, err := v.pages.Load(id)
+    return html, err
+}
+
+func (v *VirtualsScraper) loadPageDocument(id int) (*goquery.Document, error) {
+    html, _, err := v.pages.Load(id)
+    if err != nil {
+        return nil, err
+    }
+    return goquery.NewDocumentFromReader(strings.NewReader(html))
+}
@@ -0,0 +1,360 @@
+package webscraper
+
+import (
+    "context"
+    "fmt"
+    "io"
+    "net/http"
+    "strings"
+    "sync"
+    "time"
+    "anondd/utils/httpclient"
+    "anondd/utils/models"
+    "github.com/PuerkitoBio/goquery"
+    "github.com/chromedp/chromedp"
+)
+
+// Self-test checks, in the order they run
+const (
+    CheckOutbound  = "outbound"  // The egress IP and whether the site answers it
+    CheckBrowser   = "browser"   // Chrome launches, for Chrome backends
+    CheckFetch     = "fetch"     // The known agent page renders
+    CheckSelectors = "selectors" // The page parses into the expected fields
+)
+
+const (
+    // DefaultSelfTestAgentID is the agent page fetched when no stored agent
+    // has a source ID to check against
+    DefaultSelfTestAgentID = 1
+
+    // DefaultIPEchoURL answers with the caller's public IP in plain text
+    DefaultIPEchoURL = "https://api.ipify.org"
+
+    selfTestRequestTimeout = 15 * time.Second
+    browserCheckTimeout    = 30 * time.Second
+)
+
+// SelfTestConfig picks what the startup self-test checks
+type SelfTestConfig struct {
+    AgentID   int    // Agent page to fetch and parse; 0 picks a stored agent
+    IPEchoURL string // Returns the outbound IP; empty uses DefaultIPEchoURL
+}
+
+// SelfTestCheck is the outcome of one self-test check
+type SelfTestCheck struct {
+    Name     string        `json:"name"`
+    OK       bool          `json:"ok"`
+    Skipped  bool          `json:"skipped,omitempty"`
+    Detail   string        `json:"detail,omitempty"`
+    Duration time.Duration `json:"duration"`
+}
+
+// SelfTestReport is the outcome of a self-test of the scrape environment
+type SelfTestReport struct {
+    StartedAt  time.Time       `json:"started_at"`
+    Duration   time.Duration   `json:"duration"`
+    Fetcher    string          `json:"fetcher"`
+    AgentID    int             `json:"agent_id"`
+    OutboundIP string          `json:"outbound_ip,omitempty"`
+    Checks     []SelfTestCheck `json:"checks"`
+}
+
+// Passed reports whether every check that ran succeeded
+func (r *SelfTestReport) Passed() bool {
+    for _, check := range r.Checks {
+        if !check.OK && !check.Skipped {
+            return false
+        }
+    }
+    return true
+}
+
+// Summary renders the report as a short multi-line message
+func (r *SelfTestReport) Summary() string {
+    var sb strings.Builder
+    if r.Passed() {
+        sb.WriteString("✅ Scrape self-test passed")
+    } else {
+        sb.WriteString("🚨 Scrape self-test failed")
+    }
+    sb.WriteString(fmt.Sprintf(" (%s via %s, agent %d)\n", r.Duration.Round(time.Millisecond), r.Fetcher, r.AgentID))
+    if r.OutboundIP != "" {
+        sb.WriteString("Outbound IP: " + r.OutboundIP + "\n")
+    }
+    for _, check := range r.Checks {
+        mark := "✅"
+        switch {
+        case check.Skipped:
+            mark = "⏭"
+        case !check.OK:
+            mark = "❌"
+        }
+        sb.WriteString(fmt.Sprintf("%s %s", mark, check.Name))
+        if check.Detail != "" {
+            sb.WriteString(": " + check.Detail)
+        }
+        sb.WriteString("\n")
+    }
+    return strings.TrimRight(sb.String(), "\n")
+}
+
+// browserChecker is implemented by fetchers that can verify their browser
+// starts without fetching anything
+type browserChecker interface {
+    CheckBrowser(ctx context.Context) error
+}
+
+// CheckBrowser launches Chrome, or connects to the remote one, and opens a
+// blank page
+func (c *ChromeFetcher) CheckBrowser(ctx context.Context) error {
+    var allocCtx context.Context
+    var cancel context.CancelFunc
+    if c.remoteURL != "" {
+        allocCtx, cancel = chromedp.NewRemoteAllocator(ctx, c.remoteURL)
+    } else {
+        opts := append(chromedp.DefaultExecAllocatorOptions[:],
+            chromedp.Flag("headless", true),
+            chromedp.Flag("disable-gpu", true),
+            chromedp.Flag("no-sandbox", true),
+            chromedp.Flag("disable-dev-shm-usage", true),
+        )
+        allocCtx, cancel = chromedp.NewExecAllocator(ctx, opts...)
+    }
+    defer cancel()
+
+    taskCtx, cancel := chromedp.NewContext(allocCtx)
+    defer cancel()
+    taskCtx, cancel = context.WithTimeout(taskCtx, browserCheckTimeout)
+    defer cancel()
+    return chromedp.Run(taskCtx, chromedp.Navigate("about:blank"))
+}
+
+// CheckBrowser checks the browser of every backend that has one
+func (p *poolFetcher) CheckBrowser(ctx context.Context) error {
+    for _, fetcher := range p.fetchers {
+        checker, ok := fetcher.(browserChecker)
+        if !ok {
+            continue
+        }
+        if err := checker.CheckBrowser(ctx); err != nil {
+            return fmt.Errorf("%s: %w", fetcher.Name(), err)
+        }
+    }
+    return nil
+}
+
+// selfTestState holds the latest self-test report and who to tell about it
+type selfTestState struct {
+    mu    sync.Mutex
+    last  *SelfTestReport
+    hooks []func(*SelfTestReport)
+}
+
+// SelfTest checks the scrape environment before scrapes rely on it: the
+// outbound IP and whether the site answers it, that Chrome launches, that a
+// known agent page renders, and that the selectors still extract its fields.
+// Hooks hear about the first report and every change between pass and fail.
+func (v *VirtualsScraper) SelfTest(ctx context.Context, config SelfTestConfig) *SelfTestReport {
+    report := &SelfTestReport{StartedAt: time.Now(), Fetcher: v.fetcher.Name(), AgentID: config.AgentID}
+    expected := v.selfTestAgent(report)
+
+    run := func(name string, check func() (string, error)) bool {
+        startedAt := time.Now()
+        detail, err := check()
+        result := SelfTestCheck{Name: name, OK: err == nil, Detail: detail, Duration: time.Since(startedAt)}
+        if err != nil {
+            result.Detail = err.Error()
+        }
+        report.Checks = append(report.Checks, result)
+        return err == nil
+    }
+
+    run(CheckOutbound, func() (string, error) {
+        return v.checkOutbound(ctx, config.IPEchoURL, report)
+    })
+    if checker, ok := v.fetcher.(browserChecker); ok {
+        run(CheckBrowser, func() (string, error) {
+            return "", checker.CheckBrowser(ctx)
+        })
+    } else {
+        report.Checks = append(report.Checks, SelfTestCheck{Name: CheckBrowser, Skipped: true, Detail: "no browser on " + v.fetcher.Name()})
+    }
+
+    var doc *goquery.Document
+    fetched := run(CheckFetch, func() (string, error) {
+        page, err := v.fetchPage(withScreenshots(ctx, false), fmt.Sprintf("/virtuals/%d", report.AgentID))
+        if err != nil {
+            return "", err
+        }
+        doc, err = goquery.NewDocumentFromReader(strings.NewReader(page.HTML))
+        if err != nil {
+            return "", err
+        }
+        return fmt.Sprintf("%q, %d bytes", page.Title, len(page.HTML)), nil
+    })
+    if fetched {
+        run(CheckSelectors, func() (string, error) {
+            return v.checkSelectors(doc, report.AgentID, expected)
+        })
+    } else {
+        report.Checks = append(report.Checks, SelfTestCheck{Name: CheckSelectors, Skipped: true, Detail: "no page to parse"})
+    }
+
+    report.Duration = time.Since(report.StartedAt)
+    v.logger.Printf("[SELFTEST] %s", strings.ReplaceAll(report.Summary(), "\n", "; "))
+    v.finishSelfTest(report)
+    return report
+}
+
+// selfTestAgent picks the agent page to test: the configured ID, or else
+// the most recently scraped stored agent. It returns the stored record the
+// parse is expected to reproduce, if there is one.
+func (v *VirtualsScraper) selfTestAgent(report *SelfTestReport) *models.Agent {
+    agents, err := v.store.AllAgents()
+    if err != nil {
+        v.logger.Printf("[SELFTEST] No stored agents to check against: %v", err)
+    }
+    var expected *models.Agent
+    for i := range agents {
+        agent := &agents[i]
+        if agent.SourceID == 0 || agent.Source != models.SourceVirtuals || !agent.ParseSuccess {
+            continue
+        }
+        if report.AgentID != 0 {
+            if agent.SourceID == report.AgentID && (expected == nil || agent.ScrapedAt.After(expected.ScrapedAt)) {
+                expected = agent
+            }
+        } else if expected == nil || agent.ScrapedAt.After(expected.ScrapedAt) {
+            expected = agent
+        }
+    }
+    switch {
+    case report.AgentID != 0:
+    case expected != nil:
+        report.AgentID = expected.SourceID
+    default:
+        report.AgentID = DefaultSelfTestAgentID
+    }
+    return expected
+}
+
+// checkOutbound finds the outbound IP through the same proxy as the fetchers
+// and checks that the site doesn't refuse it
+func (v *VirtualsScraper) checkOutbound(ctx context.Context, echoURL string, report *SelfTestReport) (string, error) {
+    if echoURL == "" {
+        echoURL = DefaultIPEchoURL
+    }
+    client := httpclient.WithTimeout(selfTestRequestTimeout)
+
+    var notes []string
+    if body, _, err := selfTestGet(ctx, client, echoURL); err != nil {
+        // The echo service being down says nothing about the scrape target
+        notes = append(notes, "outbound IP unknown: "+err.Error())
+    } else {
+        report.OutboundIP = strings.TrimSpace(body)
+    }
+
+    _, status, err := selfTestGet(ctx, client, v.baseURL)
+    if err != nil {
+        return "", fmt.Errorf("%s unreachable: %w", v.baseURL, err)
+    }
+    if statusErrorKind(status) == models.ScrapeErrBlocked {
+        return "", models.NewScrapeError(models.ScrapeErrBlocked, fmt.Errorf("%s answered %d, the outbound IP may be blocked", v.baseURL, status))
+    }
+    notes = append(notes, fmt.Sprintf("%s answered %d", v.baseURL, status))
+    return strings.Join(notes, ", "), nil
+}
+
+func selfTestGet(ctx context.Context, client *http.Client, url string) (string, int, error) {
+    req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+    if err != nil {
+        return "", 0, err
+    }
+    resp, err := client.Do(req)
+    if err != nil {
+        return "", 0, err
+    }
+    defer resp.Body.Close()
+    body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<10))
+    if err != nil {
+        return "", resp.StatusCode, err
+    }
+    return string(body), resp.StatusCode, nil
+}
+
+// checkSelectors parses the test page without saving it, and fails if it
+// lacks a name or a field the stored record of the agent has
+func (v *VirtualsScraper) checkSelectors(doc *goquery.Document, id int, expected *models.Agent) (string, error) {
+    agent, err := v.parseAgentPage(doc, id, false)
+    if err != nil {
+        return "", err
+    }
+    found := map[string]bool{
+        "name":             agent.Name != "",
+        "price":            agent.Price != "",
+        "description":      agent.Description != "",
+        "contract_address": agent.ContractAddress != "",
+        "category":         agent.Category != "",
+    }
+    var missing []string
+    if expected != nil {
+        wanted := map[string]bool{
+            "price":            expected.Price != "",
+            "description":      expected.Description != "",
+            "contract_address": expected.ContractAddress != "",
+            "category":         expected.Category != "",
+        }
+        for _, field := range []string{"price", "description", "contract_address", "category"} {
+            if wanted[field] && !found[field] {
+                missing = append(missing, field)
+            }
+        }
+    } else if !found["price"] && !found["description"] {
+        missing = append(missing, "price", "description")
+    }
+    if len(missing) > 0 {
+        return "", models.NewScrapeError(models.ScrapeErrSelector, fmt.Errorf("%s parsed without %s", agent.Name, strings.Join(missing, ", ")))
+    }
+
+    var extracted []string
+    for _, field := range []string{"name", "price", "description", "contract_address", "category"} {
+        if found[field] {
+            extracted = append(extracted, field)
+        }
+    }
+    return fmt.Sprintf("%s: %s", agent.Name, strings.Join(extracted, ", ")), nil
+}
+
+// LastSelfTest returns the latest self-test report, or nil before the first
+func (v *VirtualsScraper) LastSelfTest() *SelfTestReport {
+    v.selfTest.mu.Lock()
+    defer v.selfTest.mu.Unlock()
+    return v.selfTest.last
+}
+
+// AddSelfTestHook registers a function to hear about self-test results. A
+// hook added after a self-test finished hears about its report right away.
+func (v *VirtualsScraper) AddSelfTestHook(hook func(*SelfTestReport)) {
+    v.selfTest.mu.Lock()
+    v.selfTest.hooks = append(v.selfTest.hooks, hook)
+    last := v.selfTest.last
+    v.selfTest.mu.Unlock()
+    if last != nil {
+        go hook(last)
+    }
+}
+
+func (v *VirtualsScraper) finishSelfTest(report *SelfTestReport) {
+    v.selfTest.mu.Lock()
+    previous := v.selfTest.last
+    v.selfTest.last = report
+    hooks := append([]func(*SelfTestReport){}, v.selfTest.hooks...)
+    v.selfTest.mu.Unlock()
+
+    if previous != nil && previous.Passed() == report.Passed() {
+        return
+    }
+    for _, hook := range hooks {
+        go hook(report)
+    }
+}
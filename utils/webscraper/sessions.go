@@ -0,0 +1,296 @@
+package webscraper
+
+import (
+    "context"
+    "encoding/json"
+    "fmt"
+    "math"
+    "os"
+    "path/filepath"
+    "strings"
+    "sync"
+    "time"
+    "github.com/chromedp/cdproto/cdp"
+    "github.com/chromedp/cdproto/network"
+    "github.com/chromedp/cdproto/page"
+    "github.com/chromedp/cdproto/storage"
+    "github.com/chromedp/chromedp"
+)
+
+// sessionsDir holds one session file per source
+const sessionsDir = "training_data/sessions"
+
+// sessionCookie is a browser cookie as saved between fetches
+type sessionCookie struct {
+    Name     string  `json:"name"`
+    Value    string  `json:"value"`
+    Domain   string  `json:"domain"`
+    Path     string  `json:"path"`
+    Expires  float64 `json:"expires,omitempty"` // Seconds since the epoch; 0 for session cookies
+    HTTPOnly bool    `json:"http_only,omitempty"`
+    Secure   bool    `json:"secure,omitempty"`
+    SameSite string  `json:"same_site,omitempty"`
+}
+
+func (c sessionCookie) key() string {
+    return c.Domain + "|" + c.Path + "|" + c.Name
+}
+
+func (c sessionCookie) expired(now time.Time) bool {
+    return c.Expires > 0 && now.Unix() >= int64(c.Expires)
+}
+
+// sessionState is the saved session of one source
+type sessionState struct {
+    Cookies      []sessionCookie              `json:"cookies"`
+    LocalStorage map[string]map[string]string `json:"local_storage"` // Items by origin
+    UpdatedAt    time.Time                    `json:"updated_at"`
+}
+
+// SessionInfo summarizes a source's saved session
+type SessionInfo struct {
+    Source    string    `json:"source"`
+    Cookies   int       `json:"cookies"`
+    Origins   int       `json:"origins"` // Origins with saved localStorage
+    UpdatedAt time.Time `json:"updated_at,omitempty"`
+}
+
+// SessionJar carries a source's cookies and localStorage from one Chrome
+// fetch to the next. Each fetch starts from the saved session and merges
+// what the page set back in, so concurrent fetches can share a jar, which
+// a Chrome user-data-dir can't since Chrome locks it to one instance.
+type SessionJar struct {
+    source string
+    path   string
+    mu     sync.Mutex
+}
+
+var sessionJars = struct {
+    mu   sync.Mutex
+    jars map[string]*SessionJar
+}{jars: make(map[string]*SessionJar)}
+
+// sessionJar returns the jar of a source, shared by all its fetchers
+func sessionJar(source string) *SessionJar {
+    sessionJars.mu.Lock()
+    defer sessionJars.mu.Unlock()
+    if jar, ok := sessionJars.jars[source]; ok {
+        return jar
+    }
+    jar := &SessionJar{source: source, path: filepath.Join(sessionsDir, source+".json")}
+    sessionJars.jars[source] = jar
+    return jar
+}
+
+// ResetSession forgets the saved session of a source, so its next fetch
+// starts without cookies or localStorage
+func ResetSession(source string) error {
+    if source == "" || strings.ContainsAny(source, `/\.`) {
+        return fmt.Errorf("invalid source name %q", source)
+    }
+    jar := sessionJar(source)
+    jar.mu.Lock()
+    defer jar.mu.Unlock()
+    if err := os.Remove(jar.path); err != nil && !os.IsNotExist(err) {
+        return fmt.Errorf("failed to remove session of %s: %w", source, err)
+    }
+    return nil
+}
+
+// Sessions lists the saved sessions, one per source
+func Sessions() ([]SessionInfo, error) {
+    paths, err := filepath.Glob(filepath.Join(sessionsDir, "*.json"))
+    if err != nil {
+        return nil, err
+    }
+    sessions := []SessionInfo{}
+    for _, path := range paths {
+        jar := sessionJar(strings.TrimSuffix(filepath.Base(path), ".json"))
+        jar.mu.Lock()
+        state, err := jar.load()
+        jar.mu.Unlock()
+        if err != nil {
+            return nil, err
+        }
+        sessions = append(sessions, SessionInfo{
+            Source:    jar.source,
+            Cookies:   len(state.Cookies),
+            Origins:   len(state.LocalStorage),
+            UpdatedAt: state.UpdatedAt,
+        })
+    }
+    return sessions, nil
+}
+
+// load reads the saved session; callers hold mu
+func (j *SessionJar) load() (sessionState, error) {
+    state := sessionState{LocalStorage: make(map[string]map[string]string)}
+    data, err := os.ReadFile(j.path)
+    if os.IsNotExist(err) {
+        return state, nil
+    }
+    if err != nil {
+        return state, fmt.Errorf("failed to read session of %s: %w", j.source, err)
+    }
+    if err := json.Unmarshal(data, &state); err != nil {
+        return state, fmt.Errorf("failed to unmarshal session of %s: %w", j.source, err)
+    }
+    if state.LocalStorage == nil {
+        state.LocalStorage = make(map[string]map[string]string)
+    }
+    return state, nil
+}
+
+// save writes the session; callers hold mu
+func (j *SessionJar) save(state sessionState) error {
+    data, err := json.MarshalIndent(state, "", "  ")
+    if err != nil {
+        return fmt.Errorf("failed to marshal session of %s: %w", j.source, err)
+    }
+    if err := os.MkdirAll(filepath.Dir(j.path), 0755); err != nil {
+        return fmt.Errorf("failed to create directory: %w", err)
+    }
+    return os.WriteFile(j.path, data, 0600)
+}
+
+// restoreScript seeds localStorage for the page's origin before its own
+// scripts run, without overwriting items the page already has
+const restoreScript = `(() => {
+    try {
+        const items = (%s)[location.origin];
+        if (!items) return;
+        for (const [key, value] of Object.entries(items)) {
+            if (localStorage.getItem(key) === null) localStorage.setItem(key, value);
+        }
+    } catch (e) {}
+})()`
+
+// captureScript returns the page's origin and localStorage items
+const captureScript = `(() => {
+    try {
+        return {origin: location.origin, items: Object.fromEntries(Object.entries(localStorage))};
+    } catch (e) {
+        return {origin: location.origin, items: {}};
+    }
+})()`
+
+// restore loads the saved session into the browser before navigating
+func (j *SessionJar) restore() chromedp.Action {
+    return chromedp.ActionFunc(func(ctx context.Context) error {
+        j.mu.Lock()
+        state, err := j.load()
+        j.mu.Unlock()
+        if err != nil {
+            return err
+        }
+
+        now := time.Now()
+        var cookies []*network.CookieParam
+        for _, cookie := range state.Cookies {
+            if cookie.expired(now) {
+                continue
+            }
+            param := &network.CookieParam{
+                Name:     cookie.Name,
+                Value:    cookie.Value,
+                Domain:   cookie.Domain,
+                Path:     cookie.Path,
+                HTTPOnly: cookie.HTTPOnly,
+                Secure:   cookie.Secure,
+                SameSite: network.CookieSameSite(cookie.SameSite),
+            }
+            if cookie.Expires > 0 {
+                seconds, fraction := math.Modf(cookie.Expires)
+                expires := cdp.TimeSinceEpoch(time.Unix(int64(seconds), int64(fraction*1e9)))
+                param.Expires = &expires
+            }
+            cookies = append(cookies, param)
+        }
+        if len(cookies) > 0 {
+            if err := network.SetCookies(cookies).Do(ctx); err != nil {
+                return fmt.Errorf("failed to restore cookies: %w", err)
+            }
+        }
+        if len(state.LocalStorage) > 0 {
+            items, err := json.Marshal(state.LocalStorage)
+            if err != nil {
+                return err
+            }
+            if _, err := page.AddScriptToEvaluateOnNewDocument(fmt.Sprintf(restoreScript, items)).Do(ctx); err != nil {
+                return fmt.Errorf("failed to restore localStorage: %w", err)
+            }
+        }
+        return nil
+    })
+}
+
+// capture merges the browser's cookies and the page's localStorage into the
+// saved session after a fetch
+func (j *SessionJar) capture() chromedp.Action {
+    return chromedp.ActionFunc(func(ctx context.Context) error {
+        cookies, err := storage.GetCookies().Do(ctx)
+        if err != nil {
+            return fmt.Errorf("failed to read cookies: %w", err)
+        }
+        var local struct {
+            Origin string            `json:"origin"`
+            Items  map[string]string `json:"items"`
+        }
+        if err := chromedp.Evaluate(captureScript, &local).Do(ctx); err != nil {
+            return fmt.Errorf("failed to read localStorage: %w", err)
+        }
+
+        j.mu.Lock()
+        defer j.mu.Unlock()
+        state, err := j.load()
+        if err != nil {
+            return err
+        }
+
+        // Cookies set during this fetch replace saved ones with the same
+        // domain, path and name; expired ones are dropped
+        now := time.Now()
+        merged := make(map[string]sessionCookie, len(state.Cookies)+len(cookies))
+        var order []string
+        for _, cookie := range state.Cookies {
+            if _, ok := merged[cookie.key()]; !ok {
+                order = append(order, cookie.key())
+            }
+            merged[cookie.key()] = cookie
+        }
+        for _, c := range cookies {
+            cookie := sessionCookie{
+                Name:     c.Name,
+                Value:    c.Value,
+                Domain:   c.Domain,
+                Path:     c.Path,
+                HTTPOnly: c.HTTPOnly,
+                Secure:   c.Secure,
+                SameSite: string(c.SameSite),
+            }
+            if !c.Session {
+                cookie.Expires = c.Expires
+            }
+            if _, ok := merged[cookie.key()]; !ok {
+                order = append(order, cookie.key())
+            }
+            merged[cookie.key()] = cookie
+        }
+        state.Cookies = state.Cookies[:0]
+        for _, key := range order {
+            if cookie := merged[key]; !cookie.expired(now) {
+                state.Cookies = append(state.Cookies, cookie)
+            }
+        }
+
+        if local.Origin != "" && local.Origin != "null" {
+            if len(local.Items) > 0 {
+                state.LocalStorage[local.Origin] = local.Items
+            } else {
+                delete(state.LocalStorage, local.Origin)
+            }
+        }
+        state.UpdatedAt = now
+        return j.save(state)
+    })
+}
@@ -0,0 +1,255 @@
+package webscraper
+
+import (
+    "context"
+    "encoding/json"
+    "fmt"
+    "os"
+    "path/filepath"
+    "sort"
+    "sync"
+    "time"
+    "anondd/utils/models"
+    "github.com/PuerkitoBio/goquery"
+    "github.com/chromedp/chromedp"
+)
+
+const strategyStatsFile = "training_data/wait_strategies.json"
+
+// Wait strategies for letting a rendered page settle before reading it.
+// Pages render with StrategyDefault; the others are retries for pages that
+// load without any agent content.
+const (
+    StrategyDefault     = "default"      // Fixed 5s wait
+    StrategyLongWait    = "long_wait"    // Fixed 15s wait for slow hydration
+    StrategyScroll      = "scroll"       // Scroll to the bottom to trigger lazy loading
+    StrategyNetworkIdle = "network_idle" // Wait until no new requests start for a while
+    StrategyShowMore    = "show_more"    // Click "show more" style buttons
+)
+
+// retryStrategies are the alternate strategies tried on empty pages
+var retryStrategies = []string{StrategyLongWait, StrategyScroll, StrategyNetworkIdle, StrategyShowMore}
+
+// networkIdleScript is truthy once the page has loaded and no new resource
+// requests have started for 1.5s
+const networkIdleScript = `(() => {
+    const count = performance.getEntriesByType('resource').length;
+    const idle = window.__anonddIdle || (window.__anonddIdle = {count: -1, since: Date.now()});
+    if (count !== idle.count) { idle.count = count; idle.since = Date.now(); }
+    return document.readyState === 'complete' && Date.now() - idle.since > 1500;
+})()`
+
+// showMoreScript clicks buttons and links labeled like "show more" and
+// returns how many it clicked
+const showMoreScript = `(() => {
+    const labels = ['show more', 'load more', 'see more', 'view more', 'read more', 'expand'];
+    let clicked = 0;
+    document.querySelectorAll('button, a, [role="button"]').forEach(el => {
+        const text = (el.innerText || '').trim().toLowerCase();
+        if (text.length < 30 && labels.some(label => text.startsWith(label))) { el.click(); clicked++; }
+    });
+    return clicked;
+})()`
+
+// scrollScript scrolls to the bottom of the page
+const scrollScript = `window.scrollTo(0, document.body.scrollHeight)`
+
+type strategyKey struct{}
+
+// withStrategy asks fetchers to let the page settle with strategy
+func withStrategy(ctx context.Context, strategy string) context.Context {
+    return context.WithValue(ctx, strategyKey{}, strategy)
+}
+
+// strategyFrom returns the wait strategy requested with ctx
+func strategyFrom(ctx context.Context) string {
+    if strategy, ok := ctx.Value(strategyKey{}).(string); ok && strategy != "" {
+        return strategy
+    }
+    return StrategyDefault
+}
+
+// chromeWaitActions are the actions ChromeFetcher runs after the body is
+// visible to let the page settle under strategy
+func chromeWaitActions(strategy string) []chromedp.Action {
+    switch strategy {
+    case StrategyLongWait:
+        return []chromedp.Action{chromedp.Sleep(15 * time.Second)}
+    case StrategyScroll:
+        var ignored interface{}
+        return []chromedp.Action{
+            chromedp.Sleep(3 * time.Second),
+            chromedp.Evaluate(scrollScript, &ignored),
+            chromedp.Sleep(2 * time.Second),
+            chromedp.Evaluate(scrollScript, &ignored),
+            chromedp.Sleep(3 * time.Second),
+        }
+    case StrategyNetworkIdle:
+        return []chromedp.Action{chromedp.ActionFunc(func(ctx context.Context) error {
+            var idle bool
+            err := chromedp.Poll(networkIdleScript, &idle,
+                chromedp.WithPollingInterval(250*time.Millisecond),
+                chromedp.WithPollingTimeout(20*time.Second)).Do(ctx)
+            // A page that never goes quiet is still worth reading
+            if err == chromedp.ErrPollingTimeout {
+                return nil
+            }
+            return err
+        })}
+    case StrategyShowMore:
+        var clicked int
+        return []chromedp.Action{
+            chromedp.Sleep(5 * time.Second),
+            chromedp.Evaluate(showMoreScript, &clicked),
+            chromedp.Sleep(3 * time.Second),
+        }
+    default:
+        return []chromedp.Action{chromedp.Sleep(5 * time.Second)}
+    }
+}
+
+// renderAPIParams are the ScrapingBee-style query parameters that apply
+// strategy on a rendering API
+func renderAPIParams(strategy string) map[string]string {
+    evaluate := func(script string) string {
+        scenario, _ := json.Marshal(map[string]interface{}{
+            "instructions": []interface{}{
+                map[string]int{"wait": 3000},
+                map[string]string{"evaluate": script},
+                map[string]int{"wait": 3000},
+            },
+        })
+        return string(scenario)
+    }
+    switch strategy {
+    case StrategyLongWait:
+        return map[string]string{"wait": "15000"}
+    case StrategyScroll:
+        return map[string]string{"js_scenario": evaluate(scrollScript)}
+    case StrategyNetworkIdle:
+        return map[string]string{"wait_browser": "networkidle2"}
+    case StrategyShowMore:
+        return map[string]string{"js_scenario": evaluate(showMoreScript)}
+    default:
+        return nil
+    }
+}
+
+// StrategyStats counts retries of empty pages with one wait strategy
+type StrategyStats struct {
+    Attempts    int       `json:"attempts"`
+    Successes   int       `json:"successes"` // Retries that found agent content
+    LastSuccess time.Time `json:"last_success,omitempty"`
+}
+
+// successRate is the smoothed share of successful attempts, so untried
+// strategies start at 50%
+func (s StrategyStats) successRate() float64 {
+    return float64(s.Successes+1) / float64(s.Attempts+2)
+}
+
+// strategyTracker persists how each retry strategy performs, so retries try
+// the ones that work on this site first
+type strategyTracker struct {
+    path  string
+    mu    sync.Mutex
+    stats map[string]StrategyStats
+}
+
+func newStrategyTracker(path string) (*strategyTracker, error) {
+    t := &strategyTracker{path: path, stats: make(map[string]StrategyStats)}
+    data, err := os.ReadFile(path)
+    if os.IsNotExist(err) {
+        return t, nil
+    }
+    if err != nil {
+        return t, fmt.Errorf("failed to read wait strategy stats: %w", err)
+    }
+    if err := json.Unmarshal(data, &t.stats); err != nil {
+        return t, fmt.Errorf("failed to unmarshal wait strategy stats: %w", err)
+    }
+    if t.stats == nil {
+        t.stats = make(map[string]StrategyStats)
+    }
+    return t, nil
+}
+
+// order returns the retry strategies, best success rate first
+func (t *strategyTracker) order() []string {
+    t.mu.Lock()
+    defer t.mu.Unlock()
+    order := append([]string(nil), retryStrategies...)
+    sort.SliceStable(order, func(i, j int) bool {
+        return t.stats[order[i]].successRate() > t.stats[order[j]].successRate()
+    })
+    return order
+}
+
+// record counts one retry with strategy and saves the stats
+func (t *strategyTracker) record(strategy string, success bool, now time.Time) error {
+    t.mu.Lock()
+    defer t.mu.Unlock()
+    stats := t.stats[strategy]
+    stats.Attempts++
+    if success {
+        stats.Successes++
+        stats.LastSuccess = now
+    }
+    t.stats[strategy] = stats
+
+    data, err := json.MarshalIndent(t.stats, "", "  ")
+    if err != nil {
+        return fmt.Errorf("failed to marshal wait strategy stats: %w", err)
+    }
+    if err := os.MkdirAll(filepath.Dir(t.path), 0755); err != nil {
+        return fmt.Errorf("failed to create directory: %w", err)
+    }
+    return os.WriteFile(t.path, data, 0644)
+}
+
+func (t *strategyTracker) snapshot() map[string]StrategyStats {
+    t.mu.Lock()
+    defer t.mu.Unlock()
+    stats := make(map[string]StrategyStats, len(retryStrategies))
+    for _, strategy := range retryStrategies {
+        stats[strategy] = t.stats[strategy]
+    }
+    return stats
+}
+
+// WaitStrategyStats returns how each retry strategy has done on pages that
+// first rendered empty
+func (v *VirtualsScraper) WaitStrategyStats() map[string]StrategyStats {
+    return v.strategies.snapshot()
+}
+
+// renderedEmpty reports whether a rendered page has none of the content an
+// agent page should, as opposed to a page whose selectors partly miss
+func (v *VirtualsScraper) renderedEmpty(doc *goquery.Document, id int) bool {
+    _, err := v.parseAgentPage(doc, id, false)
+    return models.ScrapeErrorKind(err) == models.ScrapeErrEmpty
+}
+
+// retryEmpty re-renders a page that loaded without agent content with each
+// alternate wait strategy, best performing first, until one finds content.
+// It returns that page and its screenshot, or nil if every strategy came
+// back empty.
+func (v *VirtualsScraper) retryEmpty(id int, endpoint string, screenshots bool) (*goquery.Document, []byte) {
+    for _, strategy := range v.strategies.order() {
+        v.logger.Printf("[RETRY] Agent %d rendered empty, retrying with %s", id, strategy)
+        doc, screenshot, err := v.fetchHTML(withStrategy(context.Background(), strategy), endpoint, screenshots)
+        found := err == nil && !v.renderedEmpty(doc, id)
+        if recordErr := v.strategies.record(strategy, found, v.now()); recordErr != nil {
+            v.logger.Printf("[WARN] Failed to save wait strategy stats: %v", recordErr)
+        }
+        if found {
+            v.logger.Printf("[RETRY] Agent %d has content with %s", id, strategy)
+            return doc, screenshot
+        }
+        if err != nil {
+            v.logger.Printf("[RETRY] %s render of agent %d failed: %v", strategy, id, err)
+        }
+    }
+    v.logger.Printf("[RETRY] Agent %d stayed empty with every wait strategy", id)
+    return nil, nil
+}
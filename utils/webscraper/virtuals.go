@@ -1,6 +1,7 @@
 package webscraper
 
 import (
+    "errors"
     "fmt"
 	"encoding/json"
     "log"
@@ -11,11 +12,16 @@ import (
     "context"
     "github.com/chromedp/chromedp"
     "github.com/PuerkitoBio/goquery"
+    "anondd/utils/anomaly"
+    "anondd/utils/clock"
+    "anondd/utils/events"
     "anondd/utils/models"
     "anondd/utils/storage"
-    "github.com/robfig/cron/v3"
     "sync"
+    "sync/atomic"
     "io"
+    "regexp"
+    "strconv"
 )
 
 const (
@@ -26,55 +32,136 @@ const (
 )
 
 type VirtualsScraper struct {
-    baseURL   string
-    logger    *log.Logger
-    store     *storage.AgentStore
-    scheduler *cron.Cron
-    cache     struct {
+    baseURL     string
+    logger      *log.Logger
+    store       *storage.AgentStore
+    scheduler   *clock.Cron
+    priority    *PriorityQueue
+    pages       *PageQueue
+    deadLetters *DeadLetterStore
+    fetcher     Fetcher
+    layout      *layoutMonitor
+    selectors   *selectorConfig
+    capture     *captureRecorder
+    events      *events.Bus
+    runMu       sync.Mutex
+    profiles    map[string]ScrapeProfile
+    profilesMu  sync.RWMutex
+    pipeline    PipelineConfig
+    stages      map[string]*stageMeter
+    stagesMu    sync.Mutex
+    strategies  *strategyTracker
+    hooks       []func()
+    enrichHooks []enrichHook
+    layoutHooks []func(LayoutDrift)
+    hooksMu     sync.Mutex
+    selfTest    selfTestState
+    cache       struct {
         agents    []models.Agent
         lastFetch time.Time
         mu        sync.RWMutex
     }
 }
 
+// SetFetcher replaces the backend used to render pages
+func (v *VirtualsScraper) SetFetcher(fetcher Fetcher) {
+    v.fetcher = fetcher
+}
+
+// SetEvents makes the scraper publish AgentSaved, AgentScraped and ScrapeCompleted events on bus
+func (v *VirtualsScraper) SetEvents(bus *events.Bus) {
+    v.events = bus
+}
+
+// now reads the store's clock, so scheduling, checkpoints and anomaly
+// baselines follow a fake or replayed clock along with the data
+func (v *VirtualsScraper) now() time.Time {
+    return v.store.Clock().Now()
+}
+
 // GetStore returns the store instance
 func (v *VirtualsScraper) GetStore() *storage.AgentStore {
     return v.store
 }
 
+// AddScrapeHook registers a function to run after every completed scrape
+// cycle, whatever its profile
+func (v *VirtualsScraper) AddScrapeHook(hook func()) {
+    v.hooksMu.Lock()
+    defer v.hooksMu.Unlock()
+    v.hooks = append(v.hooks, hook)
+}
+
 // NewVirtualsScraper initializes a new scraper for app.virtuals.io
 func NewVirtualsScraper(logger *log.Logger, store *storage.AgentStore) *VirtualsScraper {
     if store == nil {
         logger.Fatal("store cannot be nil")
     }
     
-    vs := &VirtualsScraper{
-        baseURL:   "https://app.virtuals.io",
-        logger:    logger,
-        store:     store,
-        scheduler: cron.New(),
+    priority, err := NewPriorityQueue(priorityFile)
+    if err != nil {
+        logger.Printf("Error loading scrape priority queue, starting fresh: %v", err)
     }
-    
-    // Set up the scheduler to run every 5 minutes
-    if _, err := vs.scheduler.AddFunc("*/1 * * * *", func() {
-        vs.logger.Println("Starting scheduled scrape...")
-        if err := vs.ScrapeAgents(); err != nil {
-            vs.logger.Printf("Scheduled scrape failed: %v", err)
-        }
-    }); err != nil {
-        logger.Printf("Error setting up scheduler: %v", err)
+
+    strategies, err := newStrategyTracker(strategyStatsFile)
+    if err != nil {
+        logger.Printf("Error loading wait strategy stats, starting fresh: %v", err)
     }
-    
-    // Start the scheduler
-   // vs.scheduler.Start()
-    
+
+    selectors, err := loadSelectorConfig(selectorConfigFile)
+    if err != nil {
+        logger.Printf("Error loading selector config, using built-in selectors: %v", err)
+    }
+
+    deadLetters, err := NewDeadLetterStore(deadLetterDir)
+    if err != nil {
+        logger.Printf("Error loading dead letters, starting fresh: %v", err)
+    }
+
+    vs := &VirtualsScraper{
+        baseURL:     "https://app.virtuals.io",
+        logger:      logger,
+        store:       store,
+        priority:    priority,
+        profiles:    DefaultProfiles(),
+        pipeline:    DefaultPipelineConfig(),
+        strategies:  strategies,
+        pages:       NewPageQueue(pageQueueDir),
+        deadLetters: deadLetters,
+        fetcher:     NewChromeFetcher("", logger),
+        layout:      newLayoutMonitor(layoutBaselineFile),
+        selectors:   selectors,
+        capture:     newCaptureRecorder(DefaultCapturePolicy, captureDir, captureHashesFile),
+    }
+    vs.pages.SetClock(store.Clock())
+
     return vs
 }
 
-// ScrapeAgents fetches and processes all agent data
+// ScrapeAgents fetches and processes all agent data with the full profile
 func (v *VirtualsScraper) ScrapeAgents() error {
-    v.logger.Printf("[SCRAPE] Starting new scrape cycle")
-    v.logger.Printf("[SCRAPE] Scanning agent IDs from %d to %d", startAgentID, maxAgentID)
+    profile, _ := v.Profile(ProfileFull)
+    v.runMu.Lock()
+    defer v.runMu.Unlock()
+    return v.scrape(profile, nil)
+}
+
+// ScrapeAgentsWithProgress runs a scrape cycle like ScrapeAgents, calling
+// progress as it goes. It returns ErrScrapeInProgress instead of waiting if
+// another scrape or reparse is running.
+func (v *VirtualsScraper) ScrapeAgentsWithProgress(progress func(ScrapeProgress)) error {
+    return v.ScrapeProfileWithProgress(ProfileFull, progress)
+}
+
+// scrape runs one scrape cycle with profile; callers must hold runMu
+func (v *VirtualsScraper) scrape(profile ScrapeProfile, progress func(ScrapeProgress)) error {
+    if err := profile.validate(); err != nil {
+        return err
+    }
+    report := newProgressReporter(progress)
+    startedAt := v.now()
+    v.logger.Printf("[SCRAPE] Starting new %s scrape cycle", profile.Name)
+    v.logger.Printf("[SCRAPE] Scanning agent IDs from %d to %d", profile.StartID, profile.EndID)
 
     // Create scraper log file
     f, err := os.OpenFile(logFile, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
@@ -91,157 +178,504 @@ func (v *VirtualsScraper) ScrapeAgents() error {
         return fmt.Errorf("[ERROR] failed to create raw data directory: %w", err)
     }
 
-    var agents []models.Agent
-    successCount := 0
-    errorCount := 0
+    // Resume an interrupted run of the same profile, otherwise scrape the
+    // profile's IDs, most volatile first
+    cp, err := loadCheckpoint(checkpointFile, v.now())
+    if err != nil {
+        v.logger.Printf("[WARN] Ignoring unreadable scrape checkpoint: %v", err)
+    }
+    if cp != nil && cp.profile() != profile.Name {
+        v.logger.Printf("[SCRAPE] Not resuming interrupted %s run in a %s run; its fetched pages are still parsed",
+            cp.profile(), profile.Name)
+        cp = nil
+    }
+    if cp != nil {
+        startedAt = cp.StartedAt
+        v.logger.Printf("[SCRAPE] Resuming run from %s at %s stage, ID %d of %d",
+            cp.StartedAt.Format(time.RFC3339), cp.Stage, cp.Next, len(cp.IDs))
+    } else {
+        ids := v.scrapeIDs(profile, v.now())
+        v.logger.Printf("[SCRAPE] %d of %d agent IDs are selected", len(ids), profile.EndID-profile.StartID+1)
+        cp = &scrapeCheckpoint{StartedAt: startedAt, Profile: profile.Name, Stage: StageFetch, IDs: ids}
+        v.checkpoint(cp)
+    }
 
-    // Iterate through agent IDs
-    for id := startAgentID; id <= maxAgentID; id++ {
-        agentID := fmt.Sprintf("%d", id)
-        
-        // Check if we should fetch this agent
-        if (!v.store.ShouldFetch(agentID)) {
-            v.logger.Printf("[SKIP] Agent %s was recently fetched", agentID)
-            continue
+    // Fetch stage: render pages up to the profile's concurrency at a time and
+    // hand them to a single writer through a bounded queue, so slow disk
+    // writes pause or shed fetching instead of piling pages up in memory.
+    // Pages still queued at a checkpoint stay due and are fetched next run.
+    v.resetStages()
+    config := v.pipelineConfig()
+    pages := newStageQueue[fetchedPage](v.stage(StageFetch, config.QueueSize), config)
+    breaker := newWriteBreaker(pages.meter, config)
+    var cpMu sync.Mutex
+    written := make(chan struct{})
+    go func() {
+        defer close(written)
+        for page := range pages.items {
+            start := time.Now()
+            err := v.storePage(page)
+            if breaker.record(time.Since(start)) {
+                v.logger.Printf("[BACKPRESSURE] Page writes are slow, pausing fetches for %s", config.Cooldown)
+            }
+            cpMu.Lock()
+            if err != nil {
+                cp.FetchErrors++
+                cp.countError(err)
+            } else {
+                cp.Fetched++
+            }
+            cpMu.Unlock()
         }
+    }()
 
-        endpoint := fmt.Sprintf("/virtuals/%d", id)
-        v.logger.Printf("[FETCH] Attempting to fetch agent %d from %s", id, endpoint)
+    for cp.Stage == StageFetch && cp.Next < len(cp.IDs) {
+        breaker.wait()
+        batch := cp.IDs[cp.Next:min(cp.Next+profile.Concurrency, len(cp.IDs))]
+        cpMu.Lock()
+        report.update(ScrapeProgress{Stage: StageFetch, Done: cp.Next, Total: len(cp.IDs), CurrentID: batch[0], Fetched: cp.Fetched, Errors: cp.FetchErrors})
+        cpMu.Unlock()
 
-        // Fetch HTML using chromedp
-        doc, err := v.FetchHTML(endpoint)
-        if err != nil {
-            errorCount++
-            v.logger.Printf("[ERROR] Failed to fetch HTML for ID %d: %v", id, err)
-            continue
+        var wg sync.WaitGroup
+        var fetched atomic.Int32
+        for _, id := range batch {
+            wg.Add(1)
+            go func(id int) {
+                defer wg.Done()
+                page, quarantined, err := v.fetchAgentPage(id, profile)
+                switch {
+                case quarantined:
+                    cpMu.Lock()
+                    cp.Quarantined++
+                    cpMu.Unlock()
+                case err != nil:
+                    cpMu.Lock()
+                    cp.FetchErrors++
+                    cp.countError(err)
+                    cpMu.Unlock()
+                default:
+                    fetched.Add(1)
+                    if !pages.push(page) {
+                        v.logger.Printf("[BACKPRESSURE] Page queue full, dropped ID %d until the next run", id)
+                        cpMu.Lock()
+                        cp.Dropped++
+                        cpMu.Unlock()
+                    }
+                }
+            }(id)
         }
+        wg.Wait()
 
-        // Parse HTML
-        agent, err := v.parseAgentPage(doc, id)
-        if err != nil {
-            errorCount++
-            v.logger.Printf("[ERROR] Failed to parse HTML for ID %d: %v", id, err)
-            continue
+        cpMu.Lock()
+        before := cp.Next
+        cp.Next += len(batch)
+        if cp.Next/scrapeChunkSize != before/scrapeChunkSize {
+            v.checkpoint(cp)
         }
+        cpMu.Unlock()
 
-        if agent != nil {
-            // Mark as fetched regardless of status
-            v.store.MarkFetched(agentID)
-            
-            successCount++
-            agents = append(agents, *agent)
-            v.logger.Printf("[SUCCESS] Successfully processed agent %d: %s (Status: %s)", 
-                id, agent.Name, agent.Status)
+        // Add delay to avoid rate limiting
+        if fetched.Load() > 0 {
+            v.logger.Printf("[DELAY] Waiting 500ms before next request")
+            time.Sleep(500 * time.Millisecond)
         }
+    }
+    pages.close()
+    <-written
+    cp.Stage = StageParse
+    v.checkpoint(cp)
 
-        // Add delay to avoid rate limiting
-        v.logger.Printf("[DELAY] Waiting 500ms before next request")
-        time.Sleep(500 * time.Millisecond)
+    // Parse stage: parse everything fetched but not yet parsed. Agents are
+    // saved and indexed one by one; the checkpoint advances every chunk.
+    pending, err := v.pages.Pending()
+    if err != nil {
+        v.logger.Printf("[ERROR] Failed to list queued pages: %v", err)
+    }
+    if !v.checkLayout(pending) {
+        v.logger.Printf("[LAYOUT] Holding %d pages until the layout change is accepted", len(pending))
+        pending = nil
     }
+    if len(pending) > 0 {
+        v.snapshotIndex("scrape started " + startedAt.Format(time.RFC3339))
+    }
+    for start := 0; start < len(pending); start += scrapeChunkSize {
+        chunk := pending[start:min(start+scrapeChunkSize, len(pending))]
+        found, parseErrors := cp.Found, cp.ParseErrors
+        if cp.ErrorKinds == nil {
+            cp.ErrorKinds = make(map[string]int)
+        }
+        chunkFound, chunkErrors := v.parsePages(chunk, true, cp.ErrorKinds, func(done, chunkFound, errors int) {
+            report.update(ScrapeProgress{Stage: StageParse, Done: start + done, Total: len(pending), Fetched: cp.Fetched,
+                Found: found + chunkFound, Errors: cp.FetchErrors + parseErrors + errors})
+        })
+        cp.Found += chunkFound
+        cp.ParseErrors += chunkErrors
+        v.checkpoint(cp)
+    }
+    successCount := cp.Found
+    errorCount := cp.FetchErrors + cp.ParseErrors
+    report.finish(ScrapeProgress{Stage: StageDone, Done: len(pending), Total: len(pending), Fetched: cp.Fetched, Found: successCount, Errors: errorCount})
 
     // Log summary
     v.logger.Printf("[SUMMARY] Scrape cycle completed:")
-    v.logger.Printf("- Total attempts: %d", maxAgentID-startAgentID+1)
+    v.logger.Printf("- Total attempts: %d", len(cp.IDs)-cp.Quarantined)
     v.logger.Printf("- Successful: %d", successCount)
     v.logger.Printf("- Failed: %d", errorCount)
-    v.logger.Printf("- Agents found: %d", len(agents))
+    v.logger.Printf("- Quarantined (skipped): %d", cp.Quarantined)
+    v.logger.Printf("- Agents found: %d", successCount)
+
+    if err := clearCheckpoint(checkpointFile); err != nil {
+        v.logger.Printf("[WARN] %v", err)
+    }
+
+    stages := v.PipelineStats()
+    dropped := cp.Dropped
+    if parse, ok := stages[StageParse]; ok {
+        dropped += parse.Dropped
+    }
+    if dropped > 0 {
+        v.logger.Printf("- Dropped by backpressure: %d", dropped)
+    }
+
+    run := models.ScrapeRun{
+        Source:      models.SourceVirtuals,
+        Profile:     profile.Name,
+        StartedAt:   startedAt,
+        Duration:    v.now().Sub(startedAt),
+        Attempted:   len(cp.IDs) - cp.Quarantined,
+        Succeeded:   successCount,
+        Failed:      errorCount,
+        Quarantined: cp.Quarantined,
+        Errors:      cp.ErrorKinds,
+        Dropped:     dropped,
+        Stages:      stages,
+    }
+    if err := v.store.RecordScrapeRun(run); err != nil {
+        v.logger.Printf("[ERROR] Failed to record scrape run: %v", err)
+    }
+    v.events.Publish(events.ScrapeCompleted{Run: run})
 
-    if len(agents) > 0 {
-        if err := v.store.UpdateIndex(agents); err != nil {
-            v.logger.Printf("[ERROR] Failed to update index: %v", err)
-        } else {
-            v.logger.Printf("[SUCCESS] Updated index with %d agents", len(agents))
+    v.hooksMu.Lock()
+    hooks := append([]func(){}, v.hooks...)
+    for _, hook := range v.enrichHooks {
+        if profile.Enrichment >= hook.depth {
+            hooks = append(hooks, hook.run)
         }
     }
+    v.hooksMu.Unlock()
+    for _, hook := range hooks {
+        hook()
+    }
 
     return nil
 }
 
-func (v *VirtualsScraper) FetchHTML(endpoint string) (*goquery.Document, error) {
-    url := v.baseURL + endpoint
-    v.logger.Printf("[DEBUG] Fetching URL: %s", url)
+// fetchedPage is a rendered page on its way to the page queue
+type fetchedPage struct {
+    id   int
+    url  string
+    html string
+}
 
-    // Create Chrome instance with options
-    opts := append(chromedp.DefaultExecAllocatorOptions[:],
-        chromedp.Flag("headless", true),
-        chromedp.Flag("disable-gpu", true),
-        chromedp.Flag("no-sandbox", true),
-        chromedp.Flag("disable-dev-shm-usage", true),
-        chromedp.Flag("disable-web-security", true),
-        chromedp.UserAgent("Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/91.0.4472.124 Safari/537.36"),
-    )
+// fetchAgentPage renders an ID's page, recording failures against the ID's
+// retry budget. It reports whether the ID was skipped for being quarantined.
+func (v *VirtualsScraper) fetchAgentPage(id int, profile ScrapeProfile) (fetchedPage, bool, error) {
+    agentID := fmt.Sprintf("%d", id)
 
-    allocCtx, cancel := chromedp.NewExecAllocator(context.Background(), opts...)
-    defer cancel()
+    // Skip IDs that spent their retry budget until their backoff expires
+    if v.store.IsQuarantined(agentID) {
+        return fetchedPage{}, true, nil
+    }
 
-    ctx, cancel := chromedp.NewContext(allocCtx, chromedp.WithLogf(v.logger.Printf))
-    defer cancel()
+    endpoint := fmt.Sprintf("/virtuals/%d", id)
+    v.logger.Printf("[FETCH] Attempting to fetch agent %d from %s", id, endpoint)
 
-    // Increase timeout to 60 seconds
-    ctx, cancel = context.WithTimeout(ctx, 60*time.Second)
-    defer cancel()
+    doc, screenshot, err := v.fetchHTML(context.Background(), endpoint, profile.Screenshots)
+    empty := err == nil && v.renderedEmpty(doc, id)
+    if empty {
+        // Keep the empty page if no strategy helps; parsing records the failure
+        if retried, retriedShot := v.retryEmpty(id, endpoint, profile.Screenshots); retried != nil {
+            doc, screenshot, empty = retried, retriedShot, false
+        }
+    }
+    var html string
+    if err == nil {
+        html, err = doc.Html()
+    }
+    if err != nil {
+        v.recordFailure(id, err)
+        v.logger.Printf("[ERROR] Failed to fetch HTML for ID %d: %v", id, err)
+        return fetchedPage{}, false, err
+    }
+    v.capturePage(id, html, screenshot, empty)
+    return fetchedPage{id: id, url: v.baseURL + endpoint, html: html}, false, nil
+}
+
+// storePage writes a fetched page to the page queue and marks its ID fetched
+func (v *VirtualsScraper) storePage(page fetchedPage) error {
+    agentID := fmt.Sprintf("%d", page.id)
+    if err := v.pages.Enqueue(page.id, page.url, page.html); err != nil {
+        err = models.NewScrapeError(models.ScrapeErrStorage, err)
+        v.recordFailure(page.id, err)
+        v.logger.Printf("[ERROR] Failed to queue page for ID %d: %v", page.id, err)
+        return err
+    }
+    v.store.MarkFetched(agentID)
+    return nil
+}
 
-    var htmlContent string
-    var debugScreenshot []byte
-    var pageTitle string
+// parsePages parses stored pages for the given IDs and hands each agent
+// through a bounded queue to be saved as soon as it parses, so a crash loses
+// at most the page in hand. Saved agents are indexed in batches. With track set, each parsed agent
+// also goes through scheduling, failure, description and anomaly tracking;
+// reparses leave that history alone. Agents dropped by backpressure keep
+// their page queued for the next run. Failures are counted by kind into
+// failures when it is set. progress, if set, is called before each page.
+func (v *VirtualsScraper) parsePages(ids []int, track bool, failures map[string]int, progress func(done, found, errors int)) (int, int) {
+    config := v.pipelineConfig()
+    agents := newStageQueue[parsedPage](v.stage(StageParse, config.QueueSize), config)
+    breaker := newWriteBreaker(agents.meter, config)
+    index := newIndexBatch(v.store, config, v.logger)
 
-    // Add error channel for monitoring
-    errChan := make(chan error, 1)
-    doneChan := make(chan bool, 1)
+    var mu sync.Mutex
+    found, errorCount := 0, 0
+    fail := func(err error) {
+        mu.Lock()
+        defer mu.Unlock()
+        errorCount++
+        countError(failures, err)
+    }
 
+    // Parser: turn stored pages into agents for the persister below
     go func() {
-        err := chromedp.Run(ctx,
-            chromedp.Navigate(url),
-            chromedp.WaitVisible(`body`, chromedp.ByQuery), // Changed from #root to body
-            chromedp.Sleep(5*time.Second),
-            chromedp.CaptureScreenshot(&debugScreenshot),
-            chromedp.Title(&pageTitle),
-            chromedp.OuterHTML(`html`, &htmlContent, chromedp.ByQuery),
-        )
-        if err != nil {
-            errChan <- err
-            return
+        defer agents.close()
+        for i, id := range ids {
+            if progress != nil {
+                mu.Lock()
+                done, errors := found, errorCount
+                mu.Unlock()
+                progress(i, done, errors)
+            }
+            breaker.wait()
+
+            html, meta, err := v.pages.Load(id)
+            if err != nil {
+                fail(models.NewScrapeError(models.ScrapeErrStorage, err))
+                v.logger.Printf("[ERROR] Failed to load queued page for ID %d: %v", id, err)
+                continue
+            }
+
+            doc, err := goquery.NewDocumentFromReader(strings.NewReader(html))
+            var agent *models.Agent
+            if err == nil {
+                // Stamp agents with when their page was fetched, so a reparse
+                // doesn't make old pages look freshly scraped
+                fetchedAt := meta.FetchedAt
+                if fetchedAt.IsZero() {
+                    fetchedAt = v.now()
+                }
+                agent, err = v.parseAgentPageAt(doc, id, true, fetchedAt)
+            }
+            if err != nil {
+                fail(err)
+                if markErr := v.pages.MarkParsed(id, err); markErr != nil {
+                    v.logger.Printf("[WARN] Failed to update page metadata for ID %d: %v", id, markErr)
+                }
+                if track {
+                    v.recordFailure(id, err)
+                }
+                v.captureParseFailure(id, html, err)
+                v.recordParseOutcome(id, html, err, track)
+                v.logger.Printf("[ERROR] Failed to parse HTML for ID %d: %v", id, err)
+                continue
+            }
+
+            v.recordParseOutcome(id, html, nil, track)
+            if !agents.push(parsedPage{id: id, agent: agent}) {
+                v.logger.Printf("[BACKPRESSURE] Agent queue full, left ID %d queued for the next run", id)
+            }
         }
-        doneChan <- true
     }()
 
-    // Wait for completion or error
-    select {
-    case err := <-errChan:
-        v.logger.Printf("[ERROR] Chrome task failed: %v", err)
-        return nil, fmt.Errorf("chrome automation failed: %w", err)
-    case <-doneChan:
-        v.logger.Printf("[SUCCESS] Page loaded successfully: %s", pageTitle)
-    case <-time.After(55*time.Second):
-        v.logger.Printf("[ERROR] Timeout while loading page")
-        return nil, fmt.Errorf("timeout while loading page")
+    // Persister: save, index and track agents in the order they parsed
+    for item := range agents.items {
+        start := time.Now()
+        agent, err := v.persistAgent(item.id, item.agent, track, index)
+        if breaker.record(time.Since(start)) {
+            v.logger.Printf("[BACKPRESSURE] Agent writes are slow, pausing parsing for %s", config.Cooldown)
+        }
+        if err != nil {
+            fail(err)
+            continue
+        }
+        mu.Lock()
+        found++
+        mu.Unlock()
+        v.logger.Printf("[SUCCESS] Saved agent %d: %s (Status: %s)", item.id, agent.Name, agent.Status)
+    }
+    if err := index.close(); err != nil {
+        v.logger.Printf("[ERROR] Failed to update index: %v", err)
     }
 
-    // Debug logging
-    v.logger.Printf("[DEBUG] Page title: %s", pageTitle)
-    v.logger.Printf("[DEBUG] Content length: %d bytes", len(htmlContent))
+    return found, errorCount
+}
 
-    // Save debug data
-    debugDir := filepath.Join(rawDataDir, "debug")
-    if err := os.MkdirAll(debugDir, 0755); err == nil {
-        timestamp := time.Now().Unix()
-        
-        // Save screenshot
-        screenshotPath := filepath.Join(debugDir, fmt.Sprintf("screenshot_%s_%d.png",
-            strings.TrimPrefix(endpoint, "/virtuals/"), timestamp))
-        if err := os.WriteFile(screenshotPath, debugScreenshot, 0644); err != nil {
-            v.logger.Printf("[WARN] Failed to save screenshot: %v", err)
+// parsedPage is a parsed agent on its way to the store
+type parsedPage struct {
+    id    int
+    agent *models.Agent
+}
+
+// persistAgent saves a parsed agent's record, queues it for index's next
+// merge (or merges it at once when index is nil) and marks its page parsed;
+// a failed save leaves the page queued.
+// With track set it also records the agent's scheduling, description and
+// anomaly history.
+func (v *VirtualsScraper) persistAgent(id int, agent *models.Agent, track bool, index *indexBatch) (*models.Agent, error) {
+    agentID := fmt.Sprintf("%d", id)
+    if err := v.store.SaveAgentAt(agent, agent.ScrapedAt); err != nil {
+        v.logger.Printf("[ERROR] Failed to save agent %d: %v", id, err)
+        err = models.NewScrapeError(models.ScrapeErrStorage, err)
+        if track {
+            v.recordFailure(id, err)
         }
+        return nil, err
+    }
+    if index == nil {
+        if err := v.store.MergeIndex([]models.Agent{*agent}); err != nil {
+            return nil, models.NewScrapeError(models.ScrapeErrStorage, fmt.Errorf("failed to update index: %w", err))
+        }
+    } else if err := index.add(*agent); err != nil {
+        v.logger.Printf("[ERROR] Failed to update index: %v", err)
+    }
+    if err := v.pages.MarkParsed(id, nil); err != nil {
+        v.logger.Printf("[WARN] Failed to update page metadata for ID %d: %v", id, err)
+    }
+    v.events.Publish(events.AgentSaved{Agent: *agent})
 
-        // Save HTML
-        htmlPath := filepath.Join(debugDir, fmt.Sprintf("page_%s_%d.html",
-            strings.TrimPrefix(endpoint, "/virtuals/"), timestamp))
-        if err := os.WriteFile(htmlPath, []byte(htmlContent), 0644); err != nil {
-            v.logger.Printf("[WARN] Failed to save HTML: %v", err)
+    if track {
+        v.priority.RecordSuccess(id, agent, v.now())
+        v.store.RecordSuccess(agentID)
+        if event, err := v.store.RecordDescription(agent); err != nil {
+            v.logger.Printf("[WARN] Failed to record description for %s: %v", agentID, err)
+        } else if event != nil {
+            v.logger.Printf("[CHANGE] Description updated for agent %d: %s", id, agent.Name)
         }
+        if event, err := v.store.RecordStage(agent); err != nil {
+            v.logger.Printf("[WARN] Failed to record launch stage for %s: %v", agentID, err)
+        } else if event != nil {
+            v.logger.Printf("[CHANGE] %s for agent %d: %s", event.Summary, id, agent.Name)
+        }
+        v.detectAnomalies(agent)
+        v.events.Publish(events.AgentScraped{Agent: *agent})
     }
+    return agent, nil
+}
+
+// ReparseAll re-runs the parser over every stored page without refetching,
+// e.g. after a parser fix, saving and indexing the results
+func (v *VirtualsScraper) ReparseAll() (parsed int, failed int, err error) {
+    v.runMu.Lock()
+    defer v.runMu.Unlock()
 
-    return goquery.NewDocumentFromReader(strings.NewReader(htmlContent))
+    ids, err := v.pages.All()
+    if err != nil {
+        return 0, 0, err
+    }
+    v.logger.Printf("[REPARSE] Reparsing %d stored pages", len(ids))
+    v.snapshotIndex("reparse")
+    v.resetStages()
+
+    parsed, failed = v.parsePages(ids, false, nil, nil)
+    return parsed, failed, nil
+}
+
+// snapshotIndex saves the index before a run changes it, so a bad scrape or
+// parser regression can be rolled back
+func (v *VirtualsScraper) snapshotIndex(reason string) {
+    version, err := v.store.SnapshotIndex(reason)
+    if errors.Is(err, storage.ErrNotFound) {
+        return
+    }
+    if err != nil {
+        v.logger.Printf("[WARN] Failed to save index version: %v", err)
+        return
+    }
+    v.logger.Printf("[INDEX] Saved index version %s (%d agents)", version.ID, version.Agents)
+}
+
+// detectAnomalies records the agent's metric snapshot and adds anomaly
+// events to the change feed when it deviates from its rolling baseline
+func (v *VirtualsScraper) detectAnomalies(agent *models.Agent) {
+    history, err := v.store.RecordSnapshot(agent)
+    if err != nil {
+        v.logger.Printf("[WARN] Failed to record metrics snapshot for %d: %v", agent.SourceID, err)
+        return
+    }
+
+    // Anomalies alert when they start, then again only after a cooldown
+    // while they keep holding
+    alerts, err := v.store.RecordAnomalies(agent, anomaly.Detect(agent, history, v.now()))
+    for _, event := range alerts {
+        v.logger.Printf("[ANOMALY] %s: %s", agent.Name, event.Summary)
+    }
+    if err != nil {
+        v.logger.Printf("[WARN] Failed to save anomaly events: %v", err)
+    }
+}
+
+// recordFailure counts a failed fetch, parse or write towards the ID's retry
+// budget and pushes back its next scheduled check
+func (v *VirtualsScraper) recordFailure(id int, cause error) {
+    v.store.RecordFailure(strconv.Itoa(id), cause)
+    v.priority.RecordFailure(id, v.now())
+}
+
+func (v *VirtualsScraper) FetchHTML(endpoint string) (*goquery.Document, error) {
+    doc, _, err := v.fetchHTML(context.Background(), endpoint, true)
+    return doc, err
+}
+
+// fetchHTML renders an endpoint with the wait strategy requested with ctx,
+// returning its screenshot too when screenshots is set and the backend
+// captured one. Keeping raw copies is left to the capture policy.
+func (v *VirtualsScraper) fetchHTML(ctx context.Context, endpoint string, screenshots bool) (*goquery.Document, []byte, error) {
+    page, err := v.fetchPage(withScreenshots(ctx, screenshots), endpoint)
+    if err != nil {
+        return nil, nil, err
+    }
+    v.logger.Printf("[SUCCESS] Page loaded successfully via %s: %s", v.fetcher.Name(), page.Title)
+
+    // Debug logging
+    v.logger.Printf("[DEBUG] Page title: %s", page.Title)
+    v.logger.Printf("[DEBUG] Content length: %d bytes", len(page.HTML))
+
+    doc, err := goquery.NewDocumentFromReader(strings.NewReader(page.HTML))
+    if err != nil {
+        return nil, nil, err
+    }
+    return doc, page.Screenshot, nil
+}
+
+// fetchPage renders an endpoint with the configured fetcher without saving anything
+func (v *VirtualsScraper) fetchPage(ctx context.Context, endpoint string) (*Page, error) {
+    url := v.baseURL + endpoint
+    v.logger.Printf("[DEBUG] Fetching URL: %s", url)
+
+    page, err := v.fetcher.Fetch(ctx, url)
+    if err == nil {
+        err = checkPage(page)
+    }
+    if err != nil {
+        v.logger.Printf("[ERROR] Fetcher %s failed: %v", v.fetcher.Name(), err)
+        return nil, err
+    }
+    return page, nil
+}
+
+// parsedAgentPath is where the latest parse of a source ID is saved
+func parsedAgentPath(id int) string {
+    return filepath.Join(rawDataDir, fmt.Sprintf("agent_%d.json", id))
 }
 
 // GetAgentScreenshot takes an agent ID and returns the screenshot of the agent's page
@@ -331,36 +765,26 @@ func (v *VirtualsScraper) extractTextBySelector(doc *goquery.Document, selectors
     return result
 }
 
-func (v *VirtualsScraper) parseAgentPage(doc *goquery.Document, id int) (*models.Agent, error) {
+// parseAgentPage extracts an agent from its page. With save set, the raw HTML
+// and parsed JSON are also written to the raw data directory.
+func (v *VirtualsScraper) parseAgentPage(doc *goquery.Document, id int, save bool) (*models.Agent, error) {
+    return v.parseAgentPageAt(doc, id, save, v.now())
+}
+
+// parseAgentPageAt is parseAgentPage for a page fetched at scrapedAt
+func (v *VirtualsScraper) parseAgentPageAt(doc *goquery.Document, id int, save bool, scrapedAt time.Time) (*models.Agent, error) {
     v.logger.Printf("[DEBUG] Starting to parse agent page %d", id)
     
     // Save raw HTML first
     rawPath := filepath.Join(rawDataDir, fmt.Sprintf("agent_%d_raw.html", id))
-    if html, err := doc.Html(); err == nil {
+    if html, err := doc.Html(); save && err == nil {
         if err := os.WriteFile(rawPath, []byte(html), 0644); err != nil {
             v.logger.Printf("[WARN] Failed to save raw HTML: %v", err)
         }
     }
 
-    // Define selectors for different fields
-    selectors := map[string][]string{
-        "name": {
-            ".text-neutral10.text-2xl",
-            "h1",
-            ".agent-name",
-            "div.text-2xl",
-        },
-        "price": {
-            ".text-neutral30",
-            "div:contains('$')",
-            ".price",
-        },
-        "description": {
-            "div:contains('Biography') + div",
-            ".text-base.text-neutral30.break-all",
-            ".agent-description",
-        },
-    }
+    // Adopted selectors are tried before the built-in ones
+    selectors := v.selectors.selectors()
 
     // Extract text using selectors
     extracted := v.extractTextBySelector(doc, selectors)
@@ -373,7 +797,9 @@ func (v *VirtualsScraper) parseAgentPage(doc *goquery.Document, id int) (*models
 
     // Create agent with found data
     agent := &models.Agent{
-        ScrapedAt:    time.Now(),
+        SourceID:     id,
+        Source:       models.SourceVirtuals,
+        ScrapedAt:    scrapedAt,
         ParseSuccess: true,
     }
 
@@ -391,10 +817,21 @@ func (v *VirtualsScraper) parseAgentPage(doc *goquery.Document, id int) (*models
     agent.Description = extracted["description"]
     agent.InfluenceMetrics = metrics
     agent.TokenData = tokenData
+    agent.NormalizeAmounts()
+    agent.CreatorAddress = extractCreatorAddress(doc)
+    agent.ContractAddress = extractContractAddress(doc, agent.CreatorAddress)
+    agent.Socials = extractSocialLinks(doc)
+    agent.LaunchedAt = extractLaunchDate(doc, agent.ScrapedAt)
+    agent.Category = extractCategory(doc)
+    agent.Stage, agent.BondingProgress = extractStage(doc)
+    agent.Stats = extractStats(doc)
+    if stats := models.ParseAgentStats(agent.Stats); !stats.Empty() {
+        agent.StatsDetail = &stats
+    }
 
     // Save parsed data as JSON
-    if agent.Name != "" || agent.Price != "" || agent.Description != "" {
-        jsonPath := filepath.Join(rawDataDir, fmt.Sprintf("agent_%d.json", id))
+    if save && (agent.Name != "" || agent.Price != "" || agent.Description != "") {
+        jsonPath := parsedAgentPath(id)
         if data, err := json.MarshalIndent(agent, "", "  "); err == nil {
             if err := os.WriteFile(jsonPath, data, 0644); err != nil {
                 v.logger.Printf("[WARN] Failed to save JSON data: %v", err)
@@ -411,7 +848,10 @@ func (v *VirtualsScraper) parseAgentPage(doc *goquery.Document, id int) (*models
                 v.logger.Printf("[DEBUG] Potential name found: %s", text)
             }
         })
-        return nil, fmt.Errorf("no agent name found for ID %d", id)
+        if agent.Price == "" && agent.Description == "" && agent.InfluenceMetrics == (models.InfluenceMetrics{}) && agent.TokenData == (models.TokenData{}) {
+            return nil, models.NewScrapeError(models.ScrapeErrEmpty, fmt.Errorf("no agent content found for ID %d", id))
+        }
+        return nil, models.NewScrapeError(models.ScrapeErrSelector, fmt.Errorf("no agent name found for ID %d", id))
     }
 
     agent.GenerateID()
@@ -422,6 +862,266 @@ func (v *VirtualsScraper) parseAgentPage(doc *goquery.Document, id int) (*models
     return agent, nil
 }
 
+// contractAddressPattern matches an EVM address
+var contractAddressPattern = regexp.MustCompile(`0x[0-9a-fA-F]{40}`)
+
+// extractContractAddress finds the token contract, preferring block explorer
+// token links over addresses mentioned anywhere in the page text, and
+// skipping the creator's wallet
+func extractContractAddress(doc *goquery.Document, creator string) string {
+    var address string
+    doc.Find("a[href]").EachWithBreak(func(i int, s *goquery.Selection) bool {
+        href, _ := s.Attr("href")
+        if !strings.Contains(href, "scan.org/token/") && !strings.Contains(href, "scan.org/address/") {
+            return true
+        }
+        if creatorLabelled(s) {
+            return true
+        }
+        address = contractAddressPattern.FindString(href)
+        return address == ""
+    })
+    if address == "" {
+        for _, candidate := range contractAddressPattern.FindAllString(doc.Text(), -1) {
+            if !strings.EqualFold(candidate, creator) {
+                address = candidate
+                break
+            }
+        }
+    }
+    return strings.ToLower(address)
+}
+
+// creatorLabelPattern matches the labels pages put next to the developer wallet
+var creatorLabelPattern = regexp.MustCompile(`(?i)\b(creator|created by|developer|dev wallet|deployer|deployed by)\b`)
+
+// creatorTextPattern matches a developer wallet shown as text after its label
+var creatorTextPattern = regexp.MustCompile(`(?i)\b(?:creator|created by|developer|dev wallet|deployer|deployed by)\b\W{0,5}(0x[0-9a-fA-F]{40})`)
+
+// maxCreatorLabelText bounds the text of an element searched for a creator
+// label, so a link isn't labelled by a distant heading
+const maxCreatorLabelText = 160
+
+// creatorLabelled reports whether an explorer link is labelled as the
+// developer wallet, by its own text or that of its nearest containers
+func creatorLabelled(s *goquery.Selection) bool {
+    for node := s; node.Length() > 0 && node.Is("a, span, div, p, li, dd, td"); node = node.Parent() {
+        text := node.Text()
+        if len(text) > maxCreatorLabelText {
+            return false
+        }
+        if title, ok := node.Attr("title"); ok {
+            text += " " + title
+        }
+        if creatorLabelPattern.MatchString(text) {
+            return true
+        }
+    }
+    return false
+}
+
+// extractCreatorAddress finds the wallet that launched the agent: an
+// explorer link labelled as the creator or developer, or an address shown
+// after such a label
+func extractCreatorAddress(doc *goquery.Document) string {
+    var address string
+    doc.Find("a[href]").EachWithBreak(func(i int, s *goquery.Selection) bool {
+        href, _ := s.Attr("href")
+        if !strings.Contains(href, "scan.org/address/") || !creatorLabelled(s) {
+            return true
+        }
+        address = contractAddressPattern.FindString(href)
+        return address == ""
+    })
+    if address == "" {
+        if match := creatorTextPattern.FindStringSubmatch(doc.Text()); match != nil {
+            address = match[1]
+        }
+    }
+    return strings.ToLower(address)
+}
+
+// siteSocialHandles are the site's own accounts, linked from every page
+var siteSocialHandles = map[string]bool{
+    "virtuals_io":       true,
+    "virtualsprotocol":  true,
+    "virtuals_protocol": true,
+}
+
+// extractSocialLinks collects the Twitter, Telegram and website links on an
+// agent page, skipping the site's own pages, explorers and accounts
+func extractSocialLinks(doc *goquery.Document) []models.SocialLink {
+    var links []models.SocialLink
+    seen := make(map[string]bool)
+    doc.Find("a[href]").Each(func(i int, s *goquery.Selection) {
+        href, _ := s.Attr("href")
+        link, ok := models.ParseSocialLink(href)
+        if !ok || seen[link.URL] {
+            return
+        }
+        switch link.Kind {
+        case models.SocialWebsite:
+            if strings.HasSuffix(link.Handle, "virtuals.io") || strings.Contains(link.Handle, "scan.org") {
+                return
+            }
+        default:
+            if siteSocialHandles[strings.ToLower(link.Handle)] {
+                return
+            }
+        }
+        seen[link.URL] = true
+        links = append(links, link)
+    })
+    return links
+}
+
+// launchLabelPattern finds a creation date label and the text that follows it
+var launchLabelPattern = regexp.MustCompile(`(?i)\b(?:created|launched|launch date|creation date)\b[\s:]*(?:on\s+|at\s+)?(.{1,40})`)
+
+// relativeDatePattern reads "3 days ago" style dates
+var relativeDatePattern = regexp.MustCompile(`(?i)^(\d+|an?)\s*(minute|hour|day|week|month|year)s?\s+ago`)
+
+// absoluteDatePattern reads ISO and written-out dates such as "Jan 2, 2025"
+var absoluteDatePattern = regexp.MustCompile(`^(\d{4}-\d{2}-\d{2}|[A-Za-z]{3,9}\.? \d{1,2},? \d{4}|\d{1,2} [A-Za-z]{3,9},? \d{4})`)
+
+var launchDateLayouts = []string{"2006-01-02", "Jan 2 2006", "January 2 2006", "2 Jan 2006", "2 January 2006"}
+
+var relativeDateUnits = map[string]time.Duration{
+    "minute": time.Minute,
+    "hour":   time.Hour,
+    "day":    24 * time.Hour,
+    "week":   7 * 24 * time.Hour,
+    "month":  30 * 24 * time.Hour,
+    "year":   365 * 24 * time.Hour,
+}
+
+// extractLaunchDate reads the creation date an agent page shows next to a
+// "Created" or "Launched" label, either as a date or relative to now. It
+// returns the zero time when the page shows none.
+func extractLaunchDate(doc *goquery.Document, now time.Time) time.Time {
+    // Labels and values usually sit in sibling elements, whose text runs
+    // together in the page text, so search the leaf elements' text too
+    var leaves []string
+    doc.Find("body *").Each(func(i int, s *goquery.Selection) {
+        if s.Children().Length() == 0 {
+            leaves = append(leaves, s.Text())
+        }
+    })
+    for _, text := range []string{strings.Join(leaves, " "), doc.Find("body").Text()} {
+        text = strings.Join(strings.Fields(text), " ")
+        for _, match := range launchLabelPattern.FindAllStringSubmatch(text, -1) {
+            if launched := parseLaunchDate(match[1], now); !launched.IsZero() {
+                return launched
+            }
+        }
+    }
+    return time.Time{}
+}
+
+// agentCategories are the categories Virtuals tags agents with, lowercased
+var agentCategories = map[string]bool{
+    "entertainment": true,
+    "information":   true,
+    "productivity":  true,
+    "creative":      true,
+    "on-chain":      true,
+    "ip mirror":     true,
+    "functional":    true,
+}
+
+// extractCategory returns the first element whose whole text is a known
+// category, lowercased, or "" when the page shows none
+func extractCategory(doc *goquery.Document) string {
+    category := ""
+    doc.Find("body *").EachWithBreak(func(i int, s *goquery.Selection) bool {
+        if s.Children().Length() > 0 {
+            return true
+        }
+        text := strings.ToLower(strings.Join(strings.Fields(s.Text()), " "))
+        if agentCategories[text] {
+            category = text
+            return false
+        }
+        return true
+    })
+    return category
+}
+
+// bondingProgressPattern reads the bonding curve progress shown on a
+// prototype agent's page, e.g. "Bonding curve progress 73.5%"
+var bondingProgressPattern = regexp.MustCompile(`(?i)(?:bonding curve|graduation)[^%\d]{0,40}?(\d{1,3}(?:\.\d+)?)\s*%`)
+
+// extractStage returns the agent's launch stage from the stage badge on its
+// page, with the bonding curve progress while bonding. A page showing only
+// the progress is bonding; a page showing neither returns "".
+func extractStage(doc *goquery.Document) (string, float64) {
+    stage := ""
+    doc.Find("body *").EachWithBreak(func(i int, s *goquery.Selection) bool {
+        if s.Children().Length() > 0 {
+            return true
+        }
+        if parsed, ok := models.ParseStage(s.Text()); ok {
+            stage = parsed
+            return false
+        }
+        return true
+    })
+    if stage == models.StageSentient {
+        return stage, 0
+    }
+
+    match := bondingProgressPattern.FindStringSubmatch(strings.Join(strings.Fields(doc.Find("body").Text()), " "))
+    if match == nil {
+        return stage, 0
+    }
+    progress, err := strconv.ParseFloat(match[1], 64)
+    if err != nil || progress > 100 {
+        return stage, 0
+    }
+    return models.StageBonding, progress
+}
+
+// extractStats returns the label and value cells of the page's stats and
+// ranking section as "label: value" lines, or "" when the page shows none
+func extractStats(doc *goquery.Document) string {
+    var lines []string
+    seen := make(map[string]bool)
+    doc.Find("div:contains('Agent Stats'), div:contains('Ranking')").Parent().Find(".rounded-2xl").Each(func(i int, s *goquery.Selection) {
+        label := strings.TrimSpace(s.Find(".text-neutral50").Text())
+        value := strings.Join(strings.Fields(s.Find(".text-neutral10").Text()), " ")
+        if label == "" || value == "" || seen[strings.ToLower(label)] {
+            return
+        }
+        seen[strings.ToLower(label)] = true
+        lines = append(lines, label+": "+value)
+    })
+    return strings.Join(lines, "\n")
+}
+
+// parseLaunchDate parses the date at the start of text, rejecting dates in the future
+func parseLaunchDate(text string, now time.Time) time.Time {
+    var launched time.Time
+    if match := relativeDatePattern.FindStringSubmatch(text); match != nil {
+        count := 1
+        if n, err := strconv.Atoi(match[1]); err == nil {
+            count = n
+        }
+        launched = now.Add(-time.Duration(count) * relativeDateUnits[strings.ToLower(match[2])])
+    } else if match := absoluteDatePattern.FindString(text); match != "" {
+        normalized := strings.NewReplacer(".", "", ",", "").Replace(match)
+        for _, layout := range launchDateLayouts {
+            if parsed, err := time.Parse(layout, normalized); err == nil {
+                launched = parsed
+                break
+            }
+        }
+    }
+    if launched.After(now) {
+        return time.Time{}
+    }
+    return launched
+}
+
 func (v *VirtualsScraper) extractText(doc *goquery.Document, selectors []string) string {
     for _, selector := range selectors {
         if text := strings.TrimSpace(doc.Find(selector).First().Text()); text != "" {
@@ -436,7 +1136,7 @@ func (v *VirtualsScraper) extractInfluenceMetrics(doc *goquery.Document) models.
     
     doc.Find("div:contains('Influence Metrics')").Parent().Find(".rounded-2xl").Each(func(i int, s *goquery.Selection) {
         label := strings.TrimSpace(s.Find(".text-neutral50").Text())
-        value := strings.TrimSpace(s.Find(".text-neutral10").Text())
+        value := s.Find(".text-neutral10").Text()
         
         switch strings.ToLower(label) {
         case "mindshare":
@@ -463,7 +1163,8 @@ func (v *VirtualsScraper) extractTokenData(doc *goquery.Document) models.TokenDa
     doc.Find("div:contains('Token Data')").Parent().Find(".grid-cols-4").Each(func(i int, s *goquery.Selection) {
         s.Find(".flex-col").Each(func(j int, col *goquery.Selection) {
             label := strings.TrimSpace(col.Find(".text-neutral50").Text())
-            value := strings.TrimSpace(col.Find(".text-[#236D66]").Text())
+            // Tailwind's arbitrary value class isn't valid CSS as a class selector
+            value := col.Find("[class~='text-[#236D66]']").Text()
             
             switch strings.ToLower(label) {
             case "mc (fdv)":
@@ -512,6 +1213,22 @@ func (v *VirtualsScraper) StopScheduler() {
     }
 }
 
+// WaitIdle waits for a scrape in progress to finish, or for ctx to be done
+func (v *VirtualsScraper) WaitIdle(ctx context.Context) error {
+    idle := make(chan struct{})
+    go func() {
+        v.runMu.Lock()
+        v.runMu.Unlock()
+        close(idle)
+    }()
+    select {
+    case <-idle:
+        return nil
+    case <-ctx.Done():
+        return fmt.Errorf("scrape still running: %w", ctx.Err())
+    }
+}
+
 func min(a, b int) int {
     if a < b {
         return a